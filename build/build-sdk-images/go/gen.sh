@@ -34,12 +34,17 @@ protoc -I ${googleapis} -I . sdk.proto --swagger_out=logtostderr=true:.
 jq 'del(.schemes[] | select(. == "https"))' sdk.swagger.json > sdk.swagger.temp.json
 mv sdk.swagger.temp.json sdk.swagger.json
 
+protoc -I ${googleapis} -I ./cmd/allocator/v1alpha1 allocation.proto --go_out=plugins=grpc:pkg/allocation/go/v1alpha1
+
 cat ./build/boilerplate.go.txt ./pkg/sdk/sdk.pb.go >> ./sdk.pb.go
 cat ./build/boilerplate.go.txt ./pkg/sdk/sdk.pb.gw.go >> ./sdk.pb.gw.go
+cat ./build/boilerplate.go.txt ./pkg/allocation/go/v1alpha1/allocation.pb.go >> ./allocation.pb.go
 
 goimports -w ./sdk.pb.go
 goimports -w ./sdk.pb.gw.go
+goimports -w ./allocation.pb.go
 
 mv ./sdk.pb.go ./pkg/sdk
 mv ./sdk.pb.gw.go ./pkg/sdk
+mv ./allocation.pb.go ./pkg/allocation/go/v1alpha1
 
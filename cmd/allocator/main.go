@@ -14,20 +14,25 @@
 package main
 
 import (
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"agones.dev/agones/pkg"
+	pb "agones.dev/agones/pkg/allocation/go/v1alpha1"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
 	"agones.dev/agones/pkg/client/clientset/versioned"
+	"agones.dev/agones/pkg/gameserverallocations"
 	"agones.dev/agones/pkg/metrics"
 	"agones.dev/agones/pkg/util/runtime"
 	"github.com/heptiolabs/healthcheck"
@@ -36,7 +41,18 @@ import (
 	"github.com/spf13/viper"
 	"go.opencensus.io/plugin/ochttp"
 	"go.opencensus.io/stats/view"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 	k8serror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
 )
 
@@ -52,6 +68,10 @@ const (
 	enableStackdriverMetricsFlag = "stackdriver-exporter"
 	enablePrometheusMetricsFlag  = "prometheus-exporter"
 	projectIDFlag                = "gcp-project-id"
+	clientNamespacesFlag         = "client-namespaces"
+	regionLabelFlag              = "region-label"
+	regionHeaderFlag             = "region-header"
+	regionCIDRsFlag              = "region-cidrs"
 )
 
 func init() {
@@ -87,13 +107,23 @@ func main() {
 	})
 
 	h := httpHandler{
-		agonesClient: agonesClient,
+		agonesClient:     agonesClient,
+		clientNamespaces: conf.ClientNamespaces,
+		regionLabel:      conf.RegionLabel,
+		regionHinter: gameserverallocations.RegionHinters{
+			gameserverallocations.HeaderRegionHinter(conf.RegionHeader),
+			conf.RegionCIDRs,
+		},
 	}
 
 	// mux for https server to serve gameserver allocations
 	httpsMux := http.NewServeMux()
 	httpsMux.HandleFunc("/v1alpha1/gameserverallocation", h.postOnly(h.allocateHandler))
 
+	// gRPC server for the AllocationService, served on the same port as https
+	grpcServer := grpc.NewServer()
+	pb.RegisterAllocationServiceServer(grpcServer, &h)
+
 	caCertPool, err := getCACertPool(certDir)
 	if err != nil {
 		logger.WithError(err).Fatal("could not get CA certs")
@@ -106,10 +136,10 @@ func main() {
 	srv := &http.Server{
 		Addr:      ":" + sslPort,
 		TLSConfig: cfg,
-		// add http OC metrics (opencensus.io/http/server/*)
-		Handler: &ochttp.Handler{
+		Handler: grpcHandlerFunc(grpcServer, &ochttp.Handler{
+			// add http OC metrics (opencensus.io/http/server/*)
 			Handler: httpsMux,
-		},
+		}),
 	}
 
 	// listen on https to serve allocations
@@ -125,6 +155,17 @@ func main() {
 	logger.WithError(err).Fatal("allocation service crashed")
 }
 
+// grpcHandlerFunc routes gRPC requests to the gRPC server, and all other requests to the http handler
+func grpcHandlerFunc(grpcServer *grpc.Server, otherHandler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			grpcServer.ServeHTTP(w, r)
+			return
+		}
+		otherHandler.ServeHTTP(w, r)
+	})
+}
+
 // Set up our client which we will use to call the API
 func getAgonesClient() (*versioned.Clientset, error) {
 	// Create the in-cluster config
@@ -180,6 +221,33 @@ func (h *httpHandler) postOnly(in handler) handler {
 
 type httpHandler struct {
 	agonesClient versioned.Interface
+	// clientNamespaces maps the common name of a client certificate to the namespace
+	// that allocations of that client are made in, when they don't set one
+	clientNamespaces map[string]string
+	// regionLabel is the label of GameServers that allocations prefer to match the region hint of their client
+	// with, or empty to not derive region hints
+	regionLabel  string
+	regionHinter gameserverallocations.RegionHinter
+}
+
+// clientNamespace returns the namespace for the client identity of the TLS connection, if it is mapped to one
+func (h *httpHandler) clientNamespace(state *tls.ConnectionState) string {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return ""
+	}
+	return h.clientNamespaces[state.PeerCertificates[0].Subject.CommonName]
+}
+
+// applyRegionHint prefers the GameServers in the region hinted by the metadata of the client of the allocation,
+// if region hints are enabled
+func (h *httpHandler) applyRegionHint(gsa *allocationv1.GameServerAllocation, header http.Header, remoteAddr string) {
+	if h.regionLabel == "" || h.regionHinter == nil {
+		return
+	}
+	region := h.regionHinter.RegionHint(header, remoteAddr)
+	if gameserverallocations.ApplyRegionHint(gsa, h.regionLabel, region) {
+		logger.WithField("region", region).WithField("remoteAddr", remoteAddr).Debug("Applied region hint to allocation")
+	}
 }
 
 func (h *httpHandler) allocateHandler(w http.ResponseWriter, r *http.Request) {
@@ -188,6 +256,10 @@ func (h *httpHandler) allocateHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "invalid request", http.StatusBadRequest)
 		return
 	}
+	if gsa.ObjectMeta.Namespace == "" {
+		gsa.ObjectMeta.Namespace = h.clientNamespace(r.TLS)
+	}
+	h.applyRegionHint(&gsa, r.Header, r.RemoteAddr)
 
 	allocation := h.agonesClient.AllocationV1().GameServerAllocations(gsa.ObjectMeta.Namespace)
 	allocatedGsa, err := allocation.Create(&gsa)
@@ -205,6 +277,122 @@ func (h *httpHandler) allocateHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// PostAllocate implements the AllocationService gRPC API
+func (h *httpHandler) PostAllocate(ctx context.Context, in *pb.AllocationRequest) (*pb.AllocationResponse, error) {
+	gsa := h.convertAllocationRequest(ctx, in)
+	allocation := h.agonesClient.AllocationV1().GameServerAllocations(gsa.ObjectMeta.Namespace)
+	allocatedGsa, err := allocation.Create(gsa)
+	if err != nil {
+		logger.Debug(err)
+		return nil, status.Error(grpcCode(err), err.Error())
+	}
+	return gameserverallocations.ConvertGSAToAllocationResponse(allocatedGsa), nil
+}
+
+// WatchAllocate implements the AllocationService gRPC API. It sends the allocation response, then a response for
+// the current state of the allocated GameServer and each of its changes, until it is shut down or deleted, or the
+// client closes the stream.
+func (h *httpHandler) WatchAllocate(in *pb.AllocationRequest, stream pb.AllocationService_WatchAllocateServer) error {
+	if in.GetMultiClusterSetting().GetEnabled() {
+		// the GameServer may be allocated in another cluster, which can not be watched from here
+		return status.Error(codes.InvalidArgument, "multi-cluster allocations can not be watched")
+	}
+
+	ctx := stream.Context()
+	gsa := h.convertAllocationRequest(ctx, in)
+	allocation := h.agonesClient.AllocationV1().GameServerAllocations(gsa.ObjectMeta.Namespace)
+	allocatedGsa, err := allocation.Create(gsa)
+	if err != nil {
+		logger.Debug(err)
+		return status.Error(grpcCode(err), err.Error())
+	}
+	if err := stream.Send(gameserverallocations.ConvertGSAToAllocationResponse(allocatedGsa)); err != nil {
+		return err
+	}
+	if allocatedGsa.Status.State != allocationv1.GameServerAllocationAllocated {
+		return nil
+	}
+
+	gameServers := h.agonesClient.AgonesV1().GameServers(gsa.ObjectMeta.Namespace)
+	opts := metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", allocatedGsa.Status.GameServerName).String()}
+	for {
+		w, err := gameServers.Watch(opts)
+		if err != nil {
+			logger.Debug(err)
+			return status.Error(grpcCode(err), err.Error())
+		}
+		done, err := sendGameServerChanges(ctx, w, allocatedGsa.Status.GameServerUID, stream, &opts)
+		w.Stop()
+		if done || err != nil {
+			return err
+		}
+	}
+}
+
+// sendGameServerChanges sends a response for each change of the allocated GameServer with the uid, and returns
+// whether the stream is done, or the watch ended and is to be resumed from the ResourceVersion of the opts
+func sendGameServerChanges(ctx context.Context, w watch.Interface, uid types.UID, stream pb.AllocationService_WatchAllocateServer, opts *metav1.ListOptions) (bool, error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return true, nil
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return false, nil
+			}
+			if event.Type == watch.Error {
+				err := k8serror.FromObject(event.Object)
+				logger.Debug(err)
+				return true, status.Error(grpcCode(err), err.Error())
+			}
+			gs, ok := event.Object.(*agonesv1.GameServer)
+			if !ok {
+				continue
+			}
+			if uid != "" && gs.ObjectMeta.UID != uid {
+				// a GameServer of the same name replaced the allocated one, which is gone
+				return true, nil
+			}
+			opts.ResourceVersion = gs.ObjectMeta.ResourceVersion
+			if event.Type == watch.Deleted {
+				return true, nil
+			}
+			if err := stream.Send(gameserverallocations.ConvertGameServerToAllocationResponse(gs)); err != nil {
+				return true, err
+			}
+			if gs.Status.State == agonesv1.GameServerStateShutdown {
+				return true, nil
+			}
+		}
+	}
+}
+
+// convertAllocationRequest converts the AllocationRequest of a gRPC client to a GameServerAllocation, in the
+// namespace of the client identity if it has none, and with the region hint of the client
+func (h *httpHandler) convertAllocationRequest(ctx context.Context, in *pb.AllocationRequest) *allocationv1.GameServerAllocation {
+	gsa := gameserverallocations.ConvertAllocationRequestToGSA(in)
+	p, hasPeer := peer.FromContext(ctx)
+	if gsa.ObjectMeta.Namespace == "" && hasPeer {
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			gsa.ObjectMeta.Namespace = h.clientNamespace(&info.State)
+		}
+	}
+
+	// gRPC metadata keys are lower case, so they are canonicalized like http headers
+	header := http.Header{}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for k, v := range md {
+			header[http.CanonicalHeaderKey(k)] = v
+		}
+	}
+	remoteAddr := ""
+	if hasPeer && p.Addr != nil {
+		remoteAddr = p.Addr.String()
+	}
+	h.applyRegionHint(gsa, header, remoteAddr)
+	return gsa
+}
+
 func httpCode(err error) int {
 	code := http.StatusInternalServerError
 	switch t := err.(type) {
@@ -214,10 +402,36 @@ func httpCode(err error) int {
 	return code
 }
 
+// grpcCode converts an error from the api server into a gRPC status code
+func grpcCode(err error) codes.Code {
+	switch code := httpCode(err); {
+	case code == http.StatusBadRequest || code == http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case code == http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case code == http.StatusForbidden:
+		return codes.PermissionDenied
+	case code == http.StatusNotFound:
+		return codes.NotFound
+	case code == http.StatusConflict:
+		return codes.Aborted
+	case code == http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case code == http.StatusServiceUnavailable:
+		return codes.Unavailable
+	default:
+		return codes.Internal
+	}
+}
+
 type config struct {
 	PrometheusMetrics bool
 	Stackdriver       bool
 	GCPProjectID      string
+	ClientNamespaces  map[string]string
+	RegionLabel       string
+	RegionHeader      string
+	RegionCIDRs       gameserverallocations.CIDRRegionHinter
 }
 
 func parseEnvFlags() config {
@@ -225,23 +439,107 @@ func parseEnvFlags() config {
 	viper.SetDefault(enablePrometheusMetricsFlag, true)
 	viper.SetDefault(enableStackdriverMetricsFlag, false)
 	viper.SetDefault(projectIDFlag, "")
+	viper.SetDefault(clientNamespacesFlag, "")
+	viper.SetDefault(regionLabelFlag, "")
+	viper.SetDefault(regionHeaderFlag, "X-Agones-Region")
+	viper.SetDefault(regionCIDRsFlag, "")
 
 	pflag.Bool(enablePrometheusMetricsFlag, viper.GetBool(enablePrometheusMetricsFlag), "Flag to activate metrics of Agones. Can also use PROMETHEUS_EXPORTER env variable.")
 	pflag.Bool(enableStackdriverMetricsFlag, viper.GetBool(enableStackdriverMetricsFlag), "Flag to activate stackdriver monitoring metrics for Agones. Can also use STACKDRIVER_EXPORTER env variable.")
 	pflag.String(projectIDFlag, viper.GetString(projectIDFlag), "GCP ProjectID used for Stackdriver, if not specified ProjectID from Application Default Credentials would be used. Can also use GCP_PROJECT_ID env variable.")
+	pflag.String(clientNamespacesFlag, viper.GetString(clientNamespacesFlag), "Comma separated list of commonName=namespace pairs, that default the namespace of allocations from clients whose certificate has that common name. Can also use CLIENT_NAMESPACES env variable.")
+	pflag.String(regionLabelFlag, viper.GetString(regionLabelFlag), "Label of GameServers that allocations prefer to match with the region hinted by their client. Region hints are disabled if empty. Can also use REGION_LABEL env variable.")
+	pflag.String(regionHeaderFlag, viper.GetString(regionHeaderFlag), "Header, or gRPC metadata, that clients hint the region of their allocations with. Can also use REGION_HEADER env variable.")
+	pflag.String(regionCIDRsFlag, viper.GetString(regionCIDRsFlag), "Comma separated list of cidr=region pairs, that hint the region of allocations from clients whose address is in the cidr, if they have no region header. Can also use REGION_CIDRS env variable.")
 	pflag.Parse()
 
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
 	runtime.Must(viper.BindEnv(enablePrometheusMetricsFlag))
 	runtime.Must(viper.BindEnv(enableStackdriverMetricsFlag))
 	runtime.Must(viper.BindEnv(projectIDFlag))
+	runtime.Must(viper.BindEnv(clientNamespacesFlag))
+	runtime.Must(viper.BindEnv(regionLabelFlag))
+	runtime.Must(viper.BindEnv(regionHeaderFlag))
+	runtime.Must(viper.BindEnv(regionCIDRsFlag))
 	runtime.Must(viper.BindPFlags(pflag.CommandLine))
 
+	clientNamespaces, err := parseClientNamespaces(viper.GetString(clientNamespacesFlag))
+	if err != nil {
+		logger.WithError(err).Fatalf("could not parse %s", clientNamespacesFlag)
+	}
+	regionLabel := viper.GetString(regionLabelFlag)
+	if regionLabel != "" {
+		if errs := validation.IsQualifiedName(regionLabel); len(errs) > 0 {
+			logger.WithField("errors", errs).Fatalf("%s is not a valid label", regionLabelFlag)
+		}
+	}
+	regionCIDRs, err := parseRegionCIDRs(viper.GetString(regionCIDRsFlag))
+	if err != nil {
+		logger.WithError(err).Fatalf("could not parse %s", regionCIDRsFlag)
+	}
+
 	return config{
 		PrometheusMetrics: viper.GetBool(enablePrometheusMetricsFlag),
 		Stackdriver:       viper.GetBool(enableStackdriverMetricsFlag),
 		GCPProjectID:      viper.GetString(projectIDFlag),
+		ClientNamespaces:  clientNamespaces,
+		RegionLabel:       regionLabel,
+		RegionHeader:      viper.GetString(regionHeaderFlag),
+		RegionCIDRs:       regionCIDRs,
+	}
+}
+
+// parseClientNamespaces parses a comma separated list of commonName=namespace pairs
+func parseClientNamespaces(s string) (map[string]string, error) {
+	namespaces := map[string]string{}
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("client namespace %q must be in the form commonName=namespace", entry)
+		}
+		name, namespace := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if name == "" {
+			return nil, fmt.Errorf("client namespace %q must have a common name", entry)
+		}
+		if errs := validation.IsDNS1123Label(namespace); len(errs) > 0 {
+			return nil, fmt.Errorf("client namespace %q is not a valid namespace: %s", entry, strings.Join(errs, ", "))
+		}
+		if _, ok := namespaces[name]; ok {
+			return nil, fmt.Errorf("common name %s has more than one namespace", name)
+		}
+		namespaces[name] = namespace
+	}
+	return namespaces, nil
+}
+
+// parseRegionCIDRs parses a comma separated list of cidr=region pairs, in the order they are matched in
+func parseRegionCIDRs(s string) (gameserverallocations.CIDRRegionHinter, error) {
+	var hinter gameserverallocations.CIDRRegionHinter
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("region cidr %q must be in the form cidr=region", entry)
+		}
+		_, network, err := net.ParseCIDR(strings.TrimSpace(parts[0]))
+		if err != nil {
+			return nil, fmt.Errorf("region cidr %q does not have a valid cidr: %s", entry, err.Error())
+		}
+		region := strings.TrimSpace(parts[1])
+		if region == "" {
+			return nil, fmt.Errorf("region cidr %q must have a region", entry)
+		}
+		if errs := validation.IsValidLabelValue(region); len(errs) > 0 {
+			return nil, fmt.Errorf("region cidr %q is not a valid label value: %s", entry, strings.Join(errs, ", "))
+		}
+		hinter = append(hinter, gameserverallocations.CIDRRegion{Network: network, Region: region})
 	}
+	return hinter, nil
 }
 
 func registerMetricViews() {
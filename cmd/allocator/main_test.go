@@ -15,19 +15,36 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/json"
+	"errors"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
 
+	pb "agones.dev/agones/pkg/allocation/go/v1alpha1"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
 	agonesfake "agones.dev/agones/pkg/client/clientset/versioned/fake"
+	"agones.dev/agones/pkg/gameserverallocations"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 	k8serror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	k8stesting "k8s.io/client-go/testing"
 )
 
@@ -95,6 +112,339 @@ func TestAllocateHandlerReturnsError(t *testing.T) {
 	assert.Contains(t, rec.Body.String(), "error")
 }
 
+func TestAllocateGRPCHandler(t *testing.T) {
+	t.Parallel()
+
+	fakeAgones := &agonesfake.Clientset{}
+	h := httpHandler{
+		agonesClient: fakeAgones,
+	}
+
+	fakeAgones.AddReactor("create", "gameserverallocations", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gsa := action.(k8stesting.CreateAction).GetObject().(*allocationv1.GameServerAllocation)
+		assert.Equal(t, "default", gsa.ObjectMeta.Namespace)
+		return true, &allocationv1.GameServerAllocation{
+			ObjectMeta: gsa.ObjectMeta,
+			Status: allocationv1.GameServerAllocationStatus{
+				State:          allocationv1.GameServerAllocationAllocated,
+				GameServerName: "gs1",
+				Ports:          []agonesv1.GameServerStatusPort{{Name: "default", Port: 7777}},
+			},
+		}, nil
+	})
+
+	response, err := h.PostAllocate(context.Background(), &pb.AllocationRequest{Namespace: "default"})
+	if assert.NoError(t, err) {
+		assert.Equal(t, pb.AllocationResponse_Allocated, response.State)
+		assert.Equal(t, "gs1", response.GameServerName)
+		assert.Equal(t, []*pb.AllocationResponse_GameServerStatusPort{{Name: "default", Port: 7777}}, response.Ports)
+	}
+}
+
+func TestAllocateGRPCHandlerReturnsError(t *testing.T) {
+	t.Parallel()
+
+	fakeAgones := &agonesfake.Clientset{}
+	h := httpHandler{
+		agonesClient: fakeAgones,
+	}
+
+	fakeAgones.AddReactor("create", "gameserverallocations", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, nil, k8serror.NewBadRequest("error")
+	})
+
+	response, err := h.PostAllocate(context.Background(), &pb.AllocationRequest{})
+	assert.Nil(t, response)
+	if assert.Error(t, err) {
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	}
+}
+
+func TestWatchAllocateGRPCHandler(t *testing.T) {
+	t.Parallel()
+
+	newGameServer := func(resourceVersion string, state agonesv1.GameServerState, labels map[string]string) *agonesv1.GameServer {
+		return &agonesv1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "gs1", Namespace: "default", UID: "uid1", ResourceVersion: resourceVersion, Labels: labels},
+			Status:     agonesv1.GameServerStatus{State: state},
+		}
+	}
+	newHandler := func(state allocationv1.GameServerAllocationState) (*httpHandler, *agonesfake.Clientset) {
+		fakeAgones := &agonesfake.Clientset{}
+		fakeAgones.AddReactor("create", "gameserverallocations", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			gsa := action.(k8stesting.CreateAction).GetObject().(*allocationv1.GameServerAllocation)
+			return true, &allocationv1.GameServerAllocation{
+				ObjectMeta: gsa.ObjectMeta,
+				Status:     allocationv1.GameServerAllocationStatus{State: state, GameServerName: "gs1", GameServerUID: "uid1"},
+			}, nil
+		})
+		return &httpHandler{agonesClient: fakeAgones}, fakeAgones
+	}
+
+	t.Run("until shutdown", func(t *testing.T) {
+		h, fakeAgones := newHandler(allocationv1.GameServerAllocationAllocated)
+		watcher := watch.NewFakeWithChanSize(10, false)
+		fakeAgones.AddWatchReactor("gameservers", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			assert.Equal(t, "default", action.GetNamespace())
+			assert.Equal(t, "metadata.name=gs1", action.(k8stesting.WatchAction).GetWatchRestrictions().Fields.String())
+			return true, watcher, nil
+		})
+		watcher.Add(newGameServer("1", agonesv1.GameServerStateAllocated, nil))
+		watcher.Modify(newGameServer("2", agonesv1.GameServerStateAllocated, map[string]string{"players": "2"}))
+		watcher.Modify(newGameServer("3", agonesv1.GameServerStateShutdown, map[string]string{"players": "0"}))
+
+		stream := newWatchAllocateStream(context.Background())
+		assert.NoError(t, h.WatchAllocate(&pb.AllocationRequest{Namespace: "default"}, stream))
+		if assert.Len(t, stream.responses, 4) {
+			assert.Equal(t, pb.AllocationResponse_Allocated, stream.responses[0].State)
+			assert.Equal(t, "gs1", stream.responses[0].GameServerName)
+			assert.Equal(t, "", stream.responses[0].GameServerState)
+			assert.Equal(t, "Allocated", stream.responses[1].GameServerState)
+			assert.Equal(t, map[string]string{"players": "2"}, stream.responses[2].GameServerLabels)
+			assert.Equal(t, "Shutdown", stream.responses[3].GameServerState)
+		}
+	})
+
+	t.Run("resumes the watch until deleted", func(t *testing.T) {
+		h, fakeAgones := newHandler(allocationv1.GameServerAllocationAllocated)
+		var resourceVersions []string
+		fakeAgones.AddWatchReactor("gameservers", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			resourceVersions = append(resourceVersions, action.(k8stesting.WatchAction).GetWatchRestrictions().ResourceVersion)
+			watcher := watch.NewFakeWithChanSize(10, false)
+			if len(resourceVersions) == 1 {
+				watcher.Add(newGameServer("1", agonesv1.GameServerStateAllocated, nil))
+				// the api server ends the watch
+				watcher.Stop()
+			} else {
+				watcher.Delete(newGameServer("2", agonesv1.GameServerStateShutdown, nil))
+			}
+			return true, watcher, nil
+		})
+
+		stream := newWatchAllocateStream(context.Background())
+		assert.NoError(t, h.WatchAllocate(&pb.AllocationRequest{Namespace: "default"}, stream))
+		assert.Len(t, stream.responses, 2)
+		assert.Equal(t, []string{"", "1"}, resourceVersions)
+	})
+
+	t.Run("until replaced", func(t *testing.T) {
+		h, fakeAgones := newHandler(allocationv1.GameServerAllocationAllocated)
+		watcher := watch.NewFakeWithChanSize(10, false)
+		fakeAgones.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(watcher, nil))
+		gs := newGameServer("2", agonesv1.GameServerStateReady, nil)
+		gs.ObjectMeta.UID = "uid2"
+		watcher.Add(gs)
+
+		stream := newWatchAllocateStream(context.Background())
+		assert.NoError(t, h.WatchAllocate(&pb.AllocationRequest{Namespace: "default"}, stream))
+		assert.Len(t, stream.responses, 1)
+	})
+
+	t.Run("until the client closes the stream", func(t *testing.T) {
+		h, fakeAgones := newHandler(allocationv1.GameServerAllocationAllocated)
+		watcher := watch.NewFakeWithChanSize(10, false)
+		fakeAgones.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(watcher, nil))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		stream := newWatchAllocateStream(ctx)
+		assert.NoError(t, h.WatchAllocate(&pb.AllocationRequest{Namespace: "default"}, stream))
+		assert.Len(t, stream.responses, 1)
+		assert.True(t, watcher.IsStopped())
+	})
+
+	t.Run("unallocated", func(t *testing.T) {
+		h, fakeAgones := newHandler(allocationv1.GameServerAllocationUnAllocated)
+		fakeAgones.AddWatchReactor("gameservers", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			assert.FailNow(t, "should not watch")
+			return false, nil, nil
+		})
+
+		stream := newWatchAllocateStream(context.Background())
+		assert.NoError(t, h.WatchAllocate(&pb.AllocationRequest{Namespace: "default"}, stream))
+		if assert.Len(t, stream.responses, 1) {
+			assert.Equal(t, pb.AllocationResponse_UnAllocated, stream.responses[0].State)
+		}
+	})
+
+	t.Run("watch error", func(t *testing.T) {
+		h, fakeAgones := newHandler(allocationv1.GameServerAllocationAllocated)
+		fakeAgones.AddWatchReactor("gameservers", func(action k8stesting.Action) (bool, watch.Interface, error) {
+			return true, nil, k8serror.NewForbidden(schema.GroupResource{Resource: "gameservers"}, "gs1", errors.New("error"))
+		})
+
+		err := h.WatchAllocate(&pb.AllocationRequest{Namespace: "default"}, newWatchAllocateStream(context.Background()))
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+
+	t.Run("multi-cluster", func(t *testing.T) {
+		h, _ := newHandler(allocationv1.GameServerAllocationAllocated)
+		err := h.WatchAllocate(&pb.AllocationRequest{MultiClusterSetting: &pb.MultiClusterSetting{Enabled: true}}, newWatchAllocateStream(context.Background()))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestAllocateClientNamespace(t *testing.T) {
+	t.Parallel()
+
+	fakeAgones := &agonesfake.Clientset{}
+	h := httpHandler{
+		agonesClient:     fakeAgones,
+		clientNamespaces: map[string]string{"matchmaker": "team-a"},
+	}
+
+	namespaces := make(chan string, 1)
+	fakeAgones.AddReactor("create", "gameserverallocations", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gsa := action.(k8stesting.CreateAction).GetObject().(*allocationv1.GameServerAllocation)
+		namespaces <- gsa.ObjectMeta.Namespace
+		return true, gsa, nil
+	})
+
+	state := func(commonName string) *tls.ConnectionState {
+		return &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: commonName}}}}
+	}
+	postHTTP := func(namespace string, state *tls.ConnectionState) string {
+		gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}}
+		body, _ := json.Marshal(gsa)
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(body))
+		assert.NoError(t, err)
+		req.TLS = state
+		rec := httptest.NewRecorder()
+		h.allocateHandler(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return <-namespaces
+	}
+	postGRPC := func(namespace string, state *tls.ConnectionState) string {
+		ctx := context.Background()
+		if state != nil {
+			ctx = peer.NewContext(ctx, &peer.Peer{AuthInfo: credentials.TLSInfo{State: *state}})
+		}
+		_, err := h.PostAllocate(ctx, &pb.AllocationRequest{Namespace: namespace})
+		assert.NoError(t, err)
+		return <-namespaces
+	}
+
+	for name, post := range map[string]func(string, *tls.ConnectionState) string{"http": postHTTP, "grpc": postGRPC} {
+		assert.Equal(t, "team-a", post("", state("matchmaker")), name)
+		// the namespace of the request takes precedence
+		assert.Equal(t, "team-b", post("team-b", state("matchmaker")), name)
+		// clients that are not mapped have to set the namespace
+		assert.Equal(t, "", post("", state("other")), name)
+		assert.Equal(t, "", post("", nil), name)
+	}
+}
+
+func TestParseClientNamespaces(t *testing.T) {
+	t.Parallel()
+
+	namespaces, err := parseClientNamespaces("")
+	assert.NoError(t, err)
+	assert.Empty(t, namespaces)
+
+	namespaces, err = parseClientNamespaces(" matchmaker-a = team-a,matchmaker-b=team-b, ")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"matchmaker-a": "team-a", "matchmaker-b": "team-b"}, namespaces)
+
+	for _, s := range []string{"matchmaker", "=team-a", "matchmaker=Team_A", "matchmaker=team-a,matchmaker=team-b"} {
+		_, err = parseClientNamespaces(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestAllocateRegionHint(t *testing.T) {
+	t.Parallel()
+
+	_, network, err := net.ParseCIDR("10.0.0.0/8")
+	assert.NoError(t, err)
+	fakeAgones := &agonesfake.Clientset{}
+	h := httpHandler{
+		agonesClient: fakeAgones,
+		regionLabel:  "region",
+		regionHinter: gameserverallocations.RegionHinters{
+			gameserverallocations.HeaderRegionHinter("X-Agones-Region"),
+			gameserverallocations.CIDRRegionHinter{{Network: network, Region: "eu"}},
+		},
+	}
+
+	preferred := make(chan []metav1.LabelSelector, 1)
+	fakeAgones.AddReactor("create", "gameserverallocations", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gsa := action.(k8stesting.CreateAction).GetObject().(*allocationv1.GameServerAllocation)
+		preferred <- gsa.Spec.Preferred
+		return true, gsa, nil
+	})
+
+	postHTTP := func(region, remoteAddr string) []metav1.LabelSelector {
+		body, _ := json.Marshal(&allocationv1.GameServerAllocation{})
+		req, err := http.NewRequest(http.MethodPost, "/", bytes.NewBuffer(body))
+		assert.NoError(t, err)
+		if region != "" {
+			req.Header.Set("X-Agones-Region", region)
+		}
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		h.allocateHandler(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return <-preferred
+	}
+	postGRPC := func(region, remoteAddr string) []metav1.LabelSelector {
+		ctx := context.Background()
+		if region != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-agones-region", region))
+		}
+		addr, err := net.ResolveTCPAddr("tcp", remoteAddr)
+		assert.NoError(t, err)
+		ctx = peer.NewContext(ctx, &peer.Peer{Addr: addr})
+		_, err = h.PostAllocate(ctx, &pb.AllocationRequest{Namespace: "default"})
+		assert.NoError(t, err)
+		return <-preferred
+	}
+
+	regionSelector := func(region string) []metav1.LabelSelector {
+		return []metav1.LabelSelector{{MatchLabels: map[string]string{"region": region}}}
+	}
+	for name, post := range map[string]func(string, string) []metav1.LabelSelector{"http": postHTTP, "grpc": postGRPC} {
+		assert.Equal(t, regionSelector("us"), post("us", "10.0.0.1:4000"), name)
+		assert.Equal(t, regionSelector("eu"), post("", "10.0.0.1:4000"), name)
+		assert.Empty(t, post("", "192.168.0.1:4000"), name)
+	}
+
+	// disabled without a region label
+	h.regionLabel = ""
+	assert.Empty(t, postHTTP("us", "10.0.0.1:4000"))
+}
+
+func TestParseRegionCIDRs(t *testing.T) {
+	t.Parallel()
+
+	hinter, err := parseRegionCIDRs("")
+	assert.NoError(t, err)
+	assert.Empty(t, hinter)
+
+	hinter, err = parseRegionCIDRs(" 10.0.0.0/8 = eu,2001:db8::/32=us, ")
+	assert.NoError(t, err)
+	if assert.Len(t, hinter, 2) {
+		assert.Equal(t, "10.0.0.0/8", hinter[0].Network.String())
+		assert.Equal(t, "eu", hinter[0].Region)
+		assert.Equal(t, "2001:db8::/32", hinter[1].Network.String())
+		assert.Equal(t, "us", hinter[1].Region)
+	}
+
+	for _, s := range []string{"10.0.0.0/8", "10.0.0.0=eu", "10.0.0.0/8=", "10.0.0.0/8=eu west"} {
+		_, err = parseRegionCIDRs(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestGRPCCode(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, codes.InvalidArgument, grpcCode(k8serror.NewBadRequest("error")))
+	assert.Equal(t, codes.NotFound, grpcCode(k8serror.NewNotFound(schema.GroupResource{}, "name")))
+	assert.Equal(t, codes.Aborted, grpcCode(k8serror.NewConflict(schema.GroupResource{}, "name", errors.New("error"))))
+	assert.Equal(t, codes.Unavailable, grpcCode(k8serror.NewServiceUnavailable("error")))
+	assert.Equal(t, codes.Internal, grpcCode(errors.New("error")))
+}
+
 func TestGettingCaCert(t *testing.T) {
 	t.Parallel()
 
@@ -111,6 +461,26 @@ func TestGettingCaCert(t *testing.T) {
 	}
 }
 
+// watchAllocateStream records the responses that WatchAllocate sends to the client
+type watchAllocateStream struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*pb.AllocationResponse
+}
+
+func newWatchAllocateStream(ctx context.Context) *watchAllocateStream {
+	return &watchAllocateStream{ctx: ctx}
+}
+
+func (s *watchAllocateStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchAllocateStream) Send(response *pb.AllocationResponse) error {
+	s.responses = append(s.responses, response)
+	return nil
+}
+
 var clientCert = `-----BEGIN CERTIFICATE-----
 MIIDuzCCAqOgAwIBAgIUduDWtqpUsp3rZhCEfUrzI05laVIwDQYJKoZIhvcNAQEL
 BQAwbTELMAkGA1UEBhMCR0IxDzANBgNVBAgMBkxvbmRvbjEPMA0GA1UEBwwGTG9u
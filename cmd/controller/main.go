@@ -20,23 +20,29 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
 	"agones.dev/agones/pkg"
+	"agones.dev/agones/pkg/apis"
 	"agones.dev/agones/pkg/client/clientset/versioned"
 	"agones.dev/agones/pkg/client/informers/externalversions"
+	"agones.dev/agones/pkg/cloudproduct"
 	"agones.dev/agones/pkg/fleetautoscalers"
 	"agones.dev/agones/pkg/fleets"
 	"agones.dev/agones/pkg/gameserverallocations"
 	"agones.dev/agones/pkg/gameservers"
 	"agones.dev/agones/pkg/gameserversets"
 	"agones.dev/agones/pkg/metrics"
+	"agones.dev/agones/pkg/metrics/externalmetrics"
 	"agones.dev/agones/pkg/util/apiserver"
 	"agones.dev/agones/pkg/util/https"
 	"agones.dev/agones/pkg/util/runtime"
 	"agones.dev/agones/pkg/util/signals"
 	"agones.dev/agones/pkg/util/webhooks"
+	"agones.dev/agones/pkg/util/workerqueue"
 	"github.com/heptiolabs/healthcheck"
 	"github.com/pkg/errors"
 	prom "github.com/prometheus/client_golang/prometheus"
@@ -46,6 +52,7 @@ import (
 	"gopkg.in/natefinch/lumberjack.v2"
 	extclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
 	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
@@ -54,14 +61,29 @@ import (
 const (
 	enableStackdriverMetricsFlag = "stackdriver-exporter"
 	enablePrometheusMetricsFlag  = "prometheus-exporter"
+	externalMetricsFlag          = "external-metrics"
 	projectIDFlag                = "gcp-project-id"
 	sidecarImageFlag             = "sidecar-image"
 	sidecarCPURequestFlag        = "sidecar-cpu-request"
 	sidecarCPULimitFlag          = "sidecar-cpu-limit"
 	sdkServerAccountFlag         = "sdk-service-account"
 	pullSidecarFlag              = "always-pull-sidecar"
+	sidecarFirstFlag             = "sidecar-first"
+	sidecarUpgradeInPlaceFlag    = "sidecar-upgrade-in-place"
+	sidecarLogTailFlag           = "sidecar-log-tail"
 	minPortFlag                  = "min-port"
 	maxPortFlag                  = "max-port"
+	namespacePortRangesFlag      = "namespace-port-ranges"
+	imagePullSecretsFlag         = "image-pull-secrets"
+	preemptionTaintsFlag         = "preemption-taints"
+	safeToEvictFlag              = "gameserver-safe-to-evict"
+	maintenanceCordonedFlag      = "maintenance-cordoned-nodes"
+	maintenanceTaintsFlag        = "maintenance-taints"
+	disruptionProtectionFlag     = "allocated-disruption-protection"
+	disruptionMaxGraceFlag       = "allocated-disruption-max-grace"
+	nodeNotReadyFlag             = "node-not-ready-threshold"
+	nodeNotReadyAllocatedFlag    = "node-not-ready-allocated-threshold"
+	cloudProductFlag             = "cloud-product"
 	certFileFlag                 = "cert-file"
 	keyFileFlag                  = "key-file"
 	numWorkersFlag               = "num-workers"
@@ -69,7 +91,35 @@ const (
 	apiServerBurstQPSFlag        = "api-server-qps-burst"
 	logDirFlag                   = "log-dir"
 	logSizeLimitMBFlag           = "log-size-limit-mb"
+	logLevelFlag                 = "log-level"
+	logSampleRateFlag            = "log-sample-rate"
 	kubeconfigFlag               = "kubeconfig"
+	allocationHedgeDelayFlag     = "remote-allocation-hedge-delay"
+	allocationTransportFlag      = "remote-allocation-transport"
+	allocationBatchQueueFlag     = "allocation-batch-queue"
+	allocationBatchRefreshFlag   = "allocation-batch-refresh"
+	allocationBatchWaitTimeFlag  = "allocation-batch-wait-time"
+	allocationIndexLabelsFlag    = "allocation-index-labels"
+	allocationSchedulingFlag     = "allocation-scheduling"
+	allocationWebhookURLFlag     = "allocation-filter-webhook-url"
+	allocationWebhookTimeoutFlag = "allocation-filter-webhook-timeout"
+	allocationWebhookCandsFlag   = "allocation-filter-webhook-candidates"
+	allocationNamespaceQPSFlag   = "allocation-namespace-qps"
+	allocationNamespaceBurstFlag = "allocation-namespace-burst"
+	allocationFleetQPSFlag       = "allocation-fleet-qps"
+	allocationFleetBurstFlag     = "allocation-fleet-burst"
+	allocationDegradedFlag       = "allocation-degraded-failure-threshold"
+	allocationDegradedQueueFlag  = "allocation-degraded-max-pending-writes"
+	allocationDegradedRetryFlag  = "allocation-degraded-max-retries"
+	allocationDegradedPeriodFlag = "allocation-degraded-retry-period"
+	allocationSelectionFlag      = "allocation-selection"
+	allocationTopNFlag           = "allocation-top-n"
+	allocationAuditSinkFlag      = "allocation-audit-sink"
+	allocationAuditFileFlag      = "allocation-audit-file"
+	allocationAuditURLFlag       = "allocation-audit-webhook-url"
+	allocationAuditTimeoutFlag   = "allocation-audit-webhook-timeout"
+	allocationProtectedFlag      = "allocation-protected-metadata-prefixes"
+	allocationSelectorLintFlag   = "allocation-selector-lint"
 	defaultResync                = 30 * time.Second
 )
 
@@ -110,6 +160,11 @@ func main() {
 		logger.WithError(err).Fatal("Could not create controller from environment or flags")
 	}
 
+	// validate has checked the level already
+	logLevel, _ := logrus.ParseLevel(ctlConf.LogLevel)
+	logrus.SetLevel(logLevel)
+	runtime.SetLogSampleRate(ctlConf.LogSampleRate)
+
 	// if the kubeconfig fails BuildConfigFromFlags will try in cluster config
 	clientConf, err := clientcmd.BuildConfigFromFlags("", ctlConf.KubeConfig)
 	if err != nil {
@@ -182,17 +237,58 @@ func main() {
 	server.Handle("/", health)
 
 	gsCounter := gameservers.NewPerNodeCounter(kubeInformerFactory, agonesInformerFactory)
+	preemptions := gameservers.NewNodePreemptions(kubeInformerFactory, ctlConf.PreemptionTaints)
+	maintenance := gameservers.NewNodeMaintenance(kubeInformerFactory, ctlConf.MaintenanceCordoned, ctlConf.MaintenanceTaints)
+	product, err := cloudproduct.New(ctlConf.CloudProduct, kubeClient)
+	if err != nil {
+		logger.WithError(err).Fatal("Could not set up the cloud product")
+	}
+	logger.WithField("cloudProduct", product.Name()).Info("Running on cloud product")
 
 	gsController := gameservers.NewController(wh, health,
-		ctlConf.MinPort, ctlConf.MaxPort, ctlConf.SidecarImage, ctlConf.AlwaysPullSidecar,
-		ctlConf.SidecarCPURequest, ctlConf.SidecarCPULimit, ctlConf.SdkServiceAccount,
+		ctlConf.MinPort, ctlConf.MaxPort, ctlConf.NamespacePortRanges, ctlConf.ImagePullSecrets, ctlConf.SafeToEvict, ctlConf.Disruption, ctlConf.NodeNotReady, ctlConf.SidecarImage, ctlConf.AlwaysPullSidecar,
+		ctlConf.SidecarFirst, ctlConf.SidecarUpgradeInPlace, ctlConf.SidecarLogTail, ctlConf.SidecarCPURequest, ctlConf.SidecarCPULimit, ctlConf.SdkServiceAccount, preemptions, maintenance, product,
 		kubeClient, kubeInformerFactory, extClient, agonesClient, agonesInformerFactory)
 	gsSetController := gameserversets.NewController(wh, health, gsCounter,
 		kubeClient, extClient, agonesClient, agonesInformerFactory)
-	fleetController := fleets.NewController(wh, health, kubeClient, extClient, agonesClient, agonesInformerFactory)
-	gasController := gameserverallocations.NewController(api, health, gsCounter, kubeClient, kubeInformerFactory, agonesClient, agonesInformerFactory)
+	fleetController := fleets.NewController(wh, health, product, kubeClient, extClient, agonesClient, kubeInformerFactory, agonesInformerFactory)
+	auditSink, err := gameserverallocations.NewAuditSink(ctlConf.AllocationAudit)
+	if err != nil {
+		logger.WithError(err).Fatal("Could not create the allocation audit sink")
+	}
+	gasController := gameserverallocations.NewController(api, wh, health, gsCounter, preemptions, maintenance, kubeClient, kubeInformerFactory, agonesClient, agonesInformerFactory,
+		gameserverallocations.Config{
+			Batch:                      ctlConf.AllocationBatch,
+			FilterWebhook:              ctlConf.AllocationWebhook,
+			RateLimit:                  ctlConf.AllocationRateLimit,
+			DegradedMode:               ctlConf.AllocationDegraded,
+			Selection:                  ctlConf.AllocationSelection,
+			AuditSink:                  auditSink,
+			IndexLabels:                ctlConf.AllocationIndexLabels,
+			ProtectedMetadataPrefixes:  ctlConf.AllocationProtected,
+			SelectorLint:               ctlConf.AllocationLint,
+			DefaultScheduling:          ctlConf.AllocationScheduling,
+			RemoteAllocationHedgeDelay: ctlConf.AllocationHedgeDelay,
+			RemoteAllocationTransport:  ctlConf.AllocationTransport,
+		})
 	fasController := fleetautoscalers.NewController(wh, health,
-		kubeClient, extClient, agonesClient, agonesInformerFactory)
+		kubeClient, kubeInformerFactory, extClient, agonesClient, agonesInformerFactory)
+	if ctlConf.ExternalMetrics {
+		externalmetrics.NewProvider(api, agonesInformerFactory, fasController.AllocationHistory())
+	}
+
+	status := &statusz{
+		certFile:        ctlConf.CertFile,
+		informers:       map[string]informerFactory{"kubernetes": kubeInformerFactory, "agones": agonesInformerFactory},
+		ports:           gsController.PortUtilization,
+		allocationCache: gasController.CacheStatus,
+		now:             time.Now,
+	}
+	for _, wq := range [][]*workerqueue.WorkerQueue{gsController.WorkQueues(), gsSetController.WorkQueues(),
+		fleetController.WorkQueues(), fasController.WorkQueues(), gasController.WorkQueues()} {
+		status.workQueues = append(status.workQueues, wq...)
+	}
+	server.Handle("/statusz", status)
 
 	rs = append(rs,
 		httpsServer, gsCounter, gsController, gsSetController, fleetController, fasController, gasController, server)
@@ -225,36 +321,120 @@ func parseEnvFlags() config {
 	viper.SetDefault(sidecarCPURequestFlag, "0")
 	viper.SetDefault(sidecarCPULimitFlag, "0")
 	viper.SetDefault(pullSidecarFlag, false)
+	viper.SetDefault(sidecarFirstFlag, false)
+	viper.SetDefault(sidecarUpgradeInPlaceFlag, false)
+	viper.SetDefault(sidecarLogTailFlag, false)
 	viper.SetDefault(sdkServerAccountFlag, "agones-sdk")
+	viper.SetDefault(preemptionTaintsFlag, strings.Join(gameservers.DefaultPreemptionTaints, ","))
+	viper.SetDefault(safeToEvictFlag, "")
+	viper.SetDefault(maintenanceCordonedFlag, false)
+	viper.SetDefault(maintenanceTaintsFlag, "")
+	viper.SetDefault(disruptionProtectionFlag, false)
+	viper.SetDefault(disruptionMaxGraceFlag, time.Duration(0))
+	viper.SetDefault(nodeNotReadyFlag, time.Duration(0))
+	viper.SetDefault(nodeNotReadyAllocatedFlag, time.Duration(0))
+	viper.SetDefault(cloudProductFlag, cloudproduct.AutoDetect)
 	viper.SetDefault(certFileFlag, filepath.Join(base, "certs/server.crt"))
 	viper.SetDefault(keyFileFlag, filepath.Join(base, "certs/server.key"))
 	viper.SetDefault(enablePrometheusMetricsFlag, true)
 	viper.SetDefault(enableStackdriverMetricsFlag, false)
+	viper.SetDefault(externalMetricsFlag, false)
 	viper.SetDefault(projectIDFlag, "")
 	viper.SetDefault(numWorkersFlag, 64)
 	viper.SetDefault(apiServerSustainedQPSFlag, 100)
 	viper.SetDefault(apiServerBurstQPSFlag, 200)
 	viper.SetDefault(logDirFlag, "")
 	viper.SetDefault(logSizeLimitMBFlag, 10000) // 10 GB, will be split into 100 MB chunks
+	viper.SetDefault(logLevelFlag, logrus.InfoLevel.String())
+	viper.SetDefault(logSampleRateFlag, 1)
+	viper.SetDefault(allocationHedgeDelayFlag, 0)
+	viper.SetDefault(allocationTransportFlag, gameserverallocations.RemoteAllocationTransportHTTP)
+	viper.SetDefault(allocationBatchQueueFlag, gameserverallocations.DefaultBatchConfig.MaxQueue)
+	viper.SetDefault(allocationBatchRefreshFlag, gameserverallocations.DefaultBatchConfig.MaxBatchBeforeRefresh)
+	viper.SetDefault(allocationBatchWaitTimeFlag, gameserverallocations.DefaultBatchConfig.WaitTime)
+	viper.SetDefault(allocationIndexLabelsFlag, "")
+	viper.SetDefault(allocationSchedulingFlag, apis.Packed)
+	viper.SetDefault(allocationWebhookURLFlag, gameserverallocations.DefaultFilterWebhookConfig.URL)
+	viper.SetDefault(allocationWebhookTimeoutFlag, gameserverallocations.DefaultFilterWebhookConfig.Timeout)
+	viper.SetDefault(allocationWebhookCandsFlag, gameserverallocations.DefaultFilterWebhookConfig.MaxCandidates)
+	viper.SetDefault(allocationNamespaceQPSFlag, 0)
+	viper.SetDefault(allocationNamespaceBurstFlag, 0)
+	viper.SetDefault(allocationFleetQPSFlag, 0)
+	viper.SetDefault(allocationFleetBurstFlag, 0)
+	viper.SetDefault(allocationDegradedFlag, gameserverallocations.DefaultDegradedModeConfig.FailureThreshold)
+	viper.SetDefault(allocationDegradedQueueFlag, gameserverallocations.DefaultDegradedModeConfig.MaxPendingWrites)
+	viper.SetDefault(allocationDegradedRetryFlag, gameserverallocations.DefaultDegradedModeConfig.MaxRetries)
+	viper.SetDefault(allocationDegradedPeriodFlag, gameserverallocations.DefaultDegradedModeConfig.RetryPeriod)
+	viper.SetDefault(allocationSelectionFlag, gameserverallocations.DefaultSelectionConfig.Strategy)
+	viper.SetDefault(allocationTopNFlag, gameserverallocations.DefaultSelectionConfig.TopN)
+	viper.SetDefault(allocationAuditSinkFlag, gameserverallocations.DefaultAuditConfig.Sink)
+	viper.SetDefault(allocationAuditFileFlag, gameserverallocations.DefaultAuditConfig.File)
+	viper.SetDefault(allocationAuditURLFlag, gameserverallocations.DefaultAuditConfig.WebhookURL)
+	viper.SetDefault(allocationAuditTimeoutFlag, gameserverallocations.DefaultAuditConfig.WebhookTimeout)
+	viper.SetDefault(allocationProtectedFlag, strings.Join(gameserverallocations.DefaultProtectedMetadataPrefixes, ","))
+	viper.SetDefault(allocationSelectorLintFlag, false)
 
 	pflag.String(sidecarImageFlag, viper.GetString(sidecarImageFlag), "Flag to overwrite the GameServer sidecar image that is used. Can also use SIDECAR env variable")
 	pflag.String(sidecarCPULimitFlag, viper.GetString(sidecarCPULimitFlag), "Flag to overwrite the GameServer sidecar container's cpu limit. Can also use SIDECAR_CPU_LIMIT env variable")
 	pflag.String(sidecarCPURequestFlag, viper.GetString(sidecarCPURequestFlag), "Flag to overwrite the GameServer sidecar container's cpu request. Can also use SIDECAR_CPU_REQUEST env variable")
 	pflag.Bool(pullSidecarFlag, viper.GetBool(pullSidecarFlag), "For development purposes, set the sidecar image to have a ImagePullPolicy of Always. Can also use ALWAYS_PULL_SIDECAR env variable")
+	pflag.Bool(sidecarFirstFlag, viper.GetBool(sidecarFirstFlag), "Start the GameServer sidecar before the game server container, and only start the latter once the SDK server is serving. Can also use SIDECAR_FIRST env variable")
+	pflag.Bool(sidecarUpgradeInPlaceFlag, viper.GetBool(sidecarUpgradeInPlaceFlag), "Upgrade the GameServer sidecar of the Pods of Ready, Reserved and Allocated GameServers to the sidecar image in place, without restarting the game server container. Can also use SIDECAR_UPGRADE_IN_PLACE env variable")
+	pflag.Bool(sidecarLogTailFlag, viper.GetBool(sidecarLogTailFlag), "Allow GameServers to set sdkServer.logTail, to serve the tail of the game server container log from the sidecar. The SDK service account must be able to get pods/log. Can also use SIDECAR_LOG_TAIL env variable")
 	pflag.String(sdkServerAccountFlag, viper.GetString(sdkServerAccountFlag), "Overwrite what service account default for GameServer Pods. Defaults to Can also use SDK_SERVICE_ACCOUNT")
 	pflag.Int32(minPortFlag, 0, "Required. The minimum port that that a GameServer can be allocated to. Can also use MIN_PORT env variable.")
 	pflag.Int32(maxPortFlag, 0, "Required. The maximum port that that a GameServer can be allocated to. Can also use MAX_PORT env variable")
+	pflag.String(namespacePortRangesFlag, viper.GetString(namespacePortRangesFlag), "Comma separated list of namespace=minPort-maxPort port ranges, that the GameServers of those namespaces are allocated ports from, instead of the min-port to max-port range. Can also use NAMESPACE_PORT_RANGES env variable")
+	pflag.String(imagePullSecretsFlag, viper.GetString(imagePullSecretsFlag), "Comma separated list of namespace=secret;secret or namespace/fleet=secret;secret entries, of the image pull secrets that are added to the Pods of the GameServers of a namespace, or of a Fleet, which replace those of its namespace. Can also use IMAGE_PULL_SECRETS env variable")
+	pflag.String(preemptionTaintsFlag, viper.GetString(preemptionTaintsFlag), "Comma separated list of the keys of the taints that node termination handlers put on nodes that are about to be preempted, such as spot VMs. GameServers on these nodes are not allocated, and Allocated GameServers on them are given the agones.dev/preemption-deadline annotation. Can also use PREEMPTION_TAINTS env variable")
+	pflag.String(safeToEvictFlag, viper.GetString(safeToEvictFlag), "Value of the cluster-autoscaler.kubernetes.io/safe-to-evict annotation of the Pods of GameServers that are not Packed, true or false. Not set if empty. The Pods of Packed GameServers are never safe to evict. Can also use GAMESERVER_SAFE_TO_EVICT env variable")
+	pflag.Bool(maintenanceCordonedFlag, viper.GetBool(maintenanceCordonedFlag), "Whether cordoned nodes are under maintenance, so that their Ready GameServers are not allocated, and events are recorded on their GameServers. Can also use MAINTENANCE_CORDONED_NODES env variable")
+	pflag.String(maintenanceTaintsFlag, viper.GetString(maintenanceTaintsFlag), "Comma separated list of the keys of the taints that put nodes under maintenance, so that their Ready GameServers are not allocated, and events are recorded on their GameServers. Can also use MAINTENANCE_TAINTS env variable")
+	pflag.Bool(disruptionProtectionFlag, viper.GetBool(disruptionProtectionFlag), "Whether a PodDisruptionBudget is created in each namespace that protects the Pods of its Allocated GameServers from voluntary disruptions, such as node upgrades. Can also use ALLOCATED_DISRUPTION_PROTECTION env variable")
+	pflag.Duration(disruptionMaxGraceFlag, viper.GetDuration(disruptionMaxGraceFlag), "How long the Pod of an Allocated GameServer is protected from voluntary disruptions for at most. 0 protects it for as long as it is Allocated. Can also use ALLOCATED_DISRUPTION_MAX_GRACE env variable")
+	pflag.Duration(nodeNotReadyFlag, viper.GetDuration(nodeNotReadyFlag), "How long a node is NotReady for before its GameServers that are not Allocated are moved to Unhealthy, so that their Fleets replace them on other nodes. 0 disables it. Can also use NODE_NOT_READY_THRESHOLD env variable")
+	pflag.Duration(nodeNotReadyAllocatedFlag, viper.GetDuration(nodeNotReadyAllocatedFlag), "How long a node is NotReady for before its Allocated GameServers are moved to Unhealthy too. 0 leaves them Allocated. Can also use NODE_NOT_READY_ALLOCATED_THRESHOLD env variable")
+	pflag.String(cloudProductFlag, viper.GetString(cloudProductFlag), "The managed Kubernetes product that Agones runs on, which the Pods of GameServers are adapted to: auto, generic, gke-autopilot or eks-fargate. auto detects it from the cluster. Can also use CLOUD_PRODUCT env variable")
 	pflag.String(keyFileFlag, viper.GetString(keyFileFlag), "Optional. Path to the key file")
 	pflag.String(certFileFlag, viper.GetString(certFileFlag), "Optional. Path to the crt file")
 	pflag.String(kubeconfigFlag, viper.GetString(kubeconfigFlag), "Optional. kubeconfig to run the controller out of the cluster. Only use it for debugging as webhook won't works.")
 	pflag.Bool(enablePrometheusMetricsFlag, viper.GetBool(enablePrometheusMetricsFlag), "Flag to activate metrics of Agones. Can also use PROMETHEUS_EXPORTER env variable.")
 	pflag.Bool(enableStackdriverMetricsFlag, viper.GetBool(enableStackdriverMetricsFlag), "Flag to activate stackdriver monitoring metrics for Agones. Can also use STACKDRIVER_EXPORTER env variable.")
+	pflag.Bool(externalMetricsFlag, viper.GetBool(externalMetricsFlag), "If set, the replicas and allocation rates of Fleets are served on the Kubernetes external metrics API, for HorizontalPodAutoscalers. Requires the external.metrics.k8s.io APIService to point to the controller. Can also use EXTERNAL_METRICS env variable.")
 	pflag.String(projectIDFlag, viper.GetString(projectIDFlag), "GCP ProjectID used for Stackdriver, if not specified ProjectID from Application Default Credentials would be used. Can also use GCP_PROJECT_ID env variable.")
 	pflag.Int32(numWorkersFlag, 64, "Number of controller workers per resource type")
 	pflag.Int32(apiServerSustainedQPSFlag, 100, "Maximum sustained queries per second to send to the API server")
 	pflag.Int32(apiServerBurstQPSFlag, 200, "Maximum burst queries per second to send to the API server")
 	pflag.String(logDirFlag, viper.GetString(logDirFlag), "If set, store logs in a given directory.")
 	pflag.Int32(logSizeLimitMBFlag, 1000, "Log file size limit in MB")
+	pflag.String(logLevelFlag, viper.GetString(logLevelFlag), "Level of the controller logs, e.g. info or debug. At debug level, the resources are included in the log entries about them. Can also use LOG_LEVEL env variable")
+	pflag.Int32(logSampleRateFlag, viper.GetInt32(logSampleRateFlag), "At debug level, only one in every this many of the messages logged every time a resource is synchronised are logged. Can also use LOG_SAMPLE_RATE env variable")
+	pflag.Duration(allocationHedgeDelayFlag, viper.GetDuration(allocationHedgeDelayFlag), "If set, a multi-cluster allocation request is also sent to the next endpoint of a remote cluster when the current one has not responded within this duration. Can also use REMOTE_ALLOCATION_HEDGE_DELAY env variable")
+	pflag.String(allocationTransportFlag, viper.GetString(allocationTransportFlag), "Transport used to forward multi-cluster allocation requests to remote clusters, either http or grpc. Can also use REMOTE_ALLOCATION_TRANSPORT env variable")
+	pflag.Int32(allocationBatchQueueFlag, viper.GetInt32(allocationBatchQueueFlag), "Number of allocation requests that can be queued for a batch, and number of workers that move allocated GameServers to Allocated. Can also use ALLOCATION_BATCH_QUEUE env variable")
+	pflag.Int32(allocationBatchRefreshFlag, viper.GetInt32(allocationBatchRefreshFlag), "Number of allocations in a batch after which the list of Ready GameServers is refreshed. Can also use ALLOCATION_BATCH_REFRESH env variable")
+	pflag.Duration(allocationBatchWaitTimeFlag, viper.GetDuration(allocationBatchWaitTimeFlag), "How long to wait for more allocation requests when there are none queued. Lower values reduce allocation latency at the cost of CPU. Can also use ALLOCATION_BATCH_WAIT_TIME env variable")
+	pflag.String(allocationIndexLabelsFlag, viper.GetString(allocationIndexLabelsFlag), "Comma separated list of GameServer label keys, that the Ready GameServers are indexed on for allocation, in addition to the Fleet name label. Allocations with a selector that matches on one of these labels only look at the GameServers with that label value. Can also use ALLOCATION_INDEX_LABELS env variable")
+	pflag.String(allocationSchedulingFlag, viper.GetString(allocationSchedulingFlag), "Scheduling strategy of GameServerAllocations that do not set spec.scheduling, e.g. Packed, Distributed, LeastUptime or MostUptime. Can also use ALLOCATION_SCHEDULING env variable")
+	pflag.String(allocationWebhookURLFlag, viper.GetString(allocationWebhookURLFlag), "If set, the URL of a webhook that is sent the Ready GameServers that match an allocation, and decides which of them may be allocated, and in what order. Can also use ALLOCATION_FILTER_WEBHOOK_URL env variable")
+	pflag.Duration(allocationWebhookTimeoutFlag, viper.GetDuration(allocationWebhookTimeoutFlag), "Timeout of a request to the allocation filter webhook. Can also use ALLOCATION_FILTER_WEBHOOK_TIMEOUT env variable")
+	pflag.Int32(allocationWebhookCandsFlag, viper.GetInt32(allocationWebhookCandsFlag), "Maximum number of Ready GameServers that are sent to the allocation filter webhook. Can also use ALLOCATION_FILTER_WEBHOOK_CANDIDATES env variable")
+	pflag.Float64(allocationNamespaceQPSFlag, viper.GetFloat64(allocationNamespaceQPSFlag), "If set, the maximum sustained number of allocations per second in each namespace. Allocations over the limit are rejected with a 429 status. Can also use ALLOCATION_NAMESPACE_QPS env variable")
+	pflag.Int32(allocationNamespaceBurstFlag, viper.GetInt32(allocationNamespaceBurstFlag), "Maximum number of allocations in a namespace that can be made at once, when allocation-namespace-qps is set. Can also use ALLOCATION_NAMESPACE_BURST env variable")
+	pflag.Float64(allocationFleetQPSFlag, viper.GetFloat64(allocationFleetQPSFlag), "If set, the maximum sustained number of allocations per second from each Fleet, for allocations that select the Fleet name label. Allocations over the limit are rejected with a 429 status. Can also use ALLOCATION_FLEET_QPS env variable")
+	pflag.Int32(allocationFleetBurstFlag, viper.GetInt32(allocationFleetBurstFlag), "Maximum number of allocations from a Fleet that can be made at once, when allocation-fleet-qps is set. Can also use ALLOCATION_FLEET_BURST env variable")
+	pflag.Int32(allocationDegradedFlag, viper.GetInt32(allocationDegradedFlag), "If set, the number of consecutive failures of the apiserver to move allocated GameServers to Allocated, after which allocations are returned from the cache, and the writes are queued and retried until the apiserver recovers. Can also use ALLOCATION_DEGRADED_FAILURE_THRESHOLD env variable")
+	pflag.Int32(allocationDegradedQueueFlag, viper.GetInt32(allocationDegradedQueueFlag), "Maximum number of writes that can be queued while the apiserver is failing, after which allocations fail. Can also use ALLOCATION_DEGRADED_MAX_PENDING_WRITES env variable")
+	pflag.Int32(allocationDegradedRetryFlag, viper.GetInt32(allocationDegradedRetryFlag), "Number of times a write queued while the apiserver is failing is retried, before it is dropped. Can also use ALLOCATION_DEGRADED_MAX_RETRIES env variable")
+	pflag.Duration(allocationDegradedPeriodFlag, viper.GetDuration(allocationDegradedPeriodFlag), "How often the writes queued while the apiserver is failing are retried. Can also use ALLOCATION_DEGRADED_RETRY_PERIOD env variable")
+	pflag.String(allocationSelectionFlag, viper.GetString(allocationSelectionFlag), "Which of the best matching Ready GameServers is allocated: best always allocates the best one, random a random one of the top n, and round-robin each of the top n in turn. Can also use ALLOCATION_SELECTION env variable")
+	pflag.Int32(allocationTopNFlag, viper.GetInt32(allocationTopNFlag), "Number of best matching Ready GameServers that the random and round-robin allocation selections select from. Can also use ALLOCATION_TOP_N env variable")
+	pflag.String(allocationAuditSinkFlag, viper.GetString(allocationAuditSinkFlag), "Where the audit records of allocations are written: none, stdout, file or webhook. Can also use ALLOCATION_AUDIT_SINK env variable")
+	pflag.String(allocationAuditFileFlag, viper.GetString(allocationAuditFileFlag), "Path of the file the audit records of allocations are appended to, for the file audit sink. Can also use ALLOCATION_AUDIT_FILE env variable")
+	pflag.String(allocationAuditURLFlag, viper.GetString(allocationAuditURLFlag), "URL the audit records of allocations are posted to, for the webhook audit sink. Can also use ALLOCATION_AUDIT_WEBHOOK_URL env variable")
+	pflag.Duration(allocationAuditTimeoutFlag, viper.GetDuration(allocationAuditTimeoutFlag), "Timeout of a request to the allocation audit webhook. Can also use ALLOCATION_AUDIT_WEBHOOK_TIMEOUT env variable")
+	pflag.String(allocationProtectedFlag, viper.GetString(allocationProtectedFlag), "Comma separated list of prefixes of the GameServer labels and annotations that allocations are not allowed to patch. Can also use ALLOCATION_PROTECTED_METADATA_PREFIXES env variable")
+	pflag.Bool(allocationSelectorLintFlag, viper.GetBool(allocationSelectorLintFlag), "If set, allocations that find no Ready GameServer, and whose required selector matches no GameServer or Fleet at all, have the SelectorMatchesNothing reason instead of NoCapacity. Can also use ALLOCATION_SELECTOR_LINT env variable")
 	pflag.Parse()
 
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
@@ -262,14 +442,29 @@ func parseEnvFlags() config {
 	runtime.Must(viper.BindEnv(sidecarCPULimitFlag))
 	runtime.Must(viper.BindEnv(sidecarCPURequestFlag))
 	runtime.Must(viper.BindEnv(pullSidecarFlag))
+	runtime.Must(viper.BindEnv(sidecarFirstFlag))
+	runtime.Must(viper.BindEnv(sidecarUpgradeInPlaceFlag))
+	runtime.Must(viper.BindEnv(sidecarLogTailFlag))
 	runtime.Must(viper.BindEnv(sdkServerAccountFlag))
 	runtime.Must(viper.BindEnv(minPortFlag))
 	runtime.Must(viper.BindEnv(maxPortFlag))
+	runtime.Must(viper.BindEnv(namespacePortRangesFlag))
+	runtime.Must(viper.BindEnv(imagePullSecretsFlag))
+	runtime.Must(viper.BindEnv(preemptionTaintsFlag))
+	runtime.Must(viper.BindEnv(safeToEvictFlag))
+	runtime.Must(viper.BindEnv(maintenanceCordonedFlag))
+	runtime.Must(viper.BindEnv(maintenanceTaintsFlag))
+	runtime.Must(viper.BindEnv(disruptionProtectionFlag))
+	runtime.Must(viper.BindEnv(disruptionMaxGraceFlag))
+	runtime.Must(viper.BindEnv(nodeNotReadyFlag))
+	runtime.Must(viper.BindEnv(nodeNotReadyAllocatedFlag))
+	runtime.Must(viper.BindEnv(cloudProductFlag))
 	runtime.Must(viper.BindEnv(keyFileFlag))
 	runtime.Must(viper.BindEnv(certFileFlag))
 	runtime.Must(viper.BindEnv(kubeconfigFlag))
 	runtime.Must(viper.BindEnv(enablePrometheusMetricsFlag))
 	runtime.Must(viper.BindEnv(enableStackdriverMetricsFlag))
+	runtime.Must(viper.BindEnv(externalMetricsFlag))
 	runtime.Must(viper.BindEnv(projectIDFlag))
 	runtime.Must(viper.BindPFlags(pflag.CommandLine))
 	runtime.Must(viper.BindEnv(numWorkersFlag))
@@ -277,6 +472,34 @@ func parseEnvFlags() config {
 	runtime.Must(viper.BindEnv(apiServerBurstQPSFlag))
 	runtime.Must(viper.BindEnv(logDirFlag))
 	runtime.Must(viper.BindEnv(logSizeLimitMBFlag))
+	runtime.Must(viper.BindEnv(logLevelFlag))
+	runtime.Must(viper.BindEnv(logSampleRateFlag))
+	runtime.Must(viper.BindEnv(allocationHedgeDelayFlag))
+	runtime.Must(viper.BindEnv(allocationTransportFlag))
+	runtime.Must(viper.BindEnv(allocationBatchQueueFlag))
+	runtime.Must(viper.BindEnv(allocationBatchRefreshFlag))
+	runtime.Must(viper.BindEnv(allocationBatchWaitTimeFlag))
+	runtime.Must(viper.BindEnv(allocationIndexLabelsFlag))
+	runtime.Must(viper.BindEnv(allocationSchedulingFlag))
+	runtime.Must(viper.BindEnv(allocationWebhookURLFlag))
+	runtime.Must(viper.BindEnv(allocationWebhookTimeoutFlag))
+	runtime.Must(viper.BindEnv(allocationWebhookCandsFlag))
+	runtime.Must(viper.BindEnv(allocationNamespaceQPSFlag))
+	runtime.Must(viper.BindEnv(allocationNamespaceBurstFlag))
+	runtime.Must(viper.BindEnv(allocationFleetQPSFlag))
+	runtime.Must(viper.BindEnv(allocationFleetBurstFlag))
+	runtime.Must(viper.BindEnv(allocationDegradedFlag))
+	runtime.Must(viper.BindEnv(allocationDegradedQueueFlag))
+	runtime.Must(viper.BindEnv(allocationDegradedRetryFlag))
+	runtime.Must(viper.BindEnv(allocationDegradedPeriodFlag))
+	runtime.Must(viper.BindEnv(allocationSelectionFlag))
+	runtime.Must(viper.BindEnv(allocationTopNFlag))
+	runtime.Must(viper.BindEnv(allocationAuditSinkFlag))
+	runtime.Must(viper.BindEnv(allocationAuditFileFlag))
+	runtime.Must(viper.BindEnv(allocationAuditURLFlag))
+	runtime.Must(viper.BindEnv(allocationAuditTimeoutFlag))
+	runtime.Must(viper.BindEnv(allocationProtectedFlag))
+	runtime.Must(viper.BindEnv(allocationSelectorLintFlag))
 
 	request, err := resource.ParseQuantity(viper.GetString(sidecarCPURequestFlag))
 	if err != nil {
@@ -288,18 +511,47 @@ func parseEnvFlags() config {
 		logger.WithError(err).Fatalf("could not parse %s", sidecarCPULimitFlag)
 	}
 
+	portRanges, err := parseNamespacePortRanges(viper.GetString(namespacePortRangesFlag))
+	if err != nil {
+		logger.WithError(err).Fatalf("could not parse %s", namespacePortRangesFlag)
+	}
+
+	pullSecrets, err := parseImagePullSecrets(viper.GetString(imagePullSecretsFlag))
+	if err != nil {
+		logger.WithError(err).Fatalf("could not parse %s", imagePullSecretsFlag)
+	}
+
 	return config{
-		MinPort:               int32(viper.GetInt64(minPortFlag)),
-		MaxPort:               int32(viper.GetInt64(maxPortFlag)),
+		MinPort:             int32(viper.GetInt64(minPortFlag)),
+		MaxPort:             int32(viper.GetInt64(maxPortFlag)),
+		NamespacePortRanges: portRanges,
+		ImagePullSecrets:    pullSecrets,
+		PreemptionTaints:    parseLabelKeys(viper.GetString(preemptionTaintsFlag)),
+		SafeToEvict:         viper.GetString(safeToEvictFlag),
+		MaintenanceCordoned: viper.GetBool(maintenanceCordonedFlag),
+		MaintenanceTaints:   parseLabelKeys(viper.GetString(maintenanceTaintsFlag)),
+		Disruption: gameservers.DisruptionProtection{
+			Enabled:  viper.GetBool(disruptionProtectionFlag),
+			MaxGrace: viper.GetDuration(disruptionMaxGraceFlag),
+		},
+		NodeNotReady: gameservers.NodeNotReadyRemediation{
+			Threshold:          viper.GetDuration(nodeNotReadyFlag),
+			AllocatedThreshold: viper.GetDuration(nodeNotReadyAllocatedFlag),
+		},
+		CloudProduct:          viper.GetString(cloudProductFlag),
 		SidecarImage:          viper.GetString(sidecarImageFlag),
 		SidecarCPURequest:     request,
 		SidecarCPULimit:       limit,
 		SdkServiceAccount:     viper.GetString(sdkServerAccountFlag),
 		AlwaysPullSidecar:     viper.GetBool(pullSidecarFlag),
+		SidecarFirst:          viper.GetBool(sidecarFirstFlag),
+		SidecarUpgradeInPlace: viper.GetBool(sidecarUpgradeInPlaceFlag),
+		SidecarLogTail:        viper.GetBool(sidecarLogTailFlag),
 		KeyFile:               viper.GetString(keyFileFlag),
 		CertFile:              viper.GetString(certFileFlag),
 		KubeConfig:            viper.GetString(kubeconfigFlag),
 		PrometheusMetrics:     viper.GetBool(enablePrometheusMetricsFlag),
+		ExternalMetrics:       viper.GetBool(externalMetricsFlag),
 		Stackdriver:           viper.GetBool(enableStackdriverMetricsFlag),
 		GCPProjectID:          viper.GetString(projectIDFlag),
 		NumWorkers:            int(viper.GetInt32(numWorkersFlag)),
@@ -307,6 +559,46 @@ func parseEnvFlags() config {
 		APIServerBurstQPS:     int(viper.GetInt32(apiServerBurstQPSFlag)),
 		LogDir:                viper.GetString(logDirFlag),
 		LogSizeLimitMB:        int(viper.GetInt32(logSizeLimitMBFlag)),
+		LogLevel:              viper.GetString(logLevelFlag),
+		LogSampleRate:         int(viper.GetInt32(logSampleRateFlag)),
+		AllocationHedgeDelay:  viper.GetDuration(allocationHedgeDelayFlag),
+		AllocationTransport:   viper.GetString(allocationTransportFlag),
+		AllocationBatch: gameserverallocations.BatchConfig{
+			MaxQueue:              int(viper.GetInt32(allocationBatchQueueFlag)),
+			MaxBatchBeforeRefresh: int(viper.GetInt32(allocationBatchRefreshFlag)),
+			WaitTime:              viper.GetDuration(allocationBatchWaitTimeFlag),
+		},
+		AllocationIndexLabels: parseLabelKeys(viper.GetString(allocationIndexLabelsFlag)),
+		AllocationProtected:   parseLabelKeys(viper.GetString(allocationProtectedFlag)),
+		AllocationLint:        viper.GetBool(allocationSelectorLintFlag),
+		AllocationScheduling:  apis.SchedulingStrategy(viper.GetString(allocationSchedulingFlag)),
+		AllocationWebhook: gameserverallocations.FilterWebhookConfig{
+			URL:           viper.GetString(allocationWebhookURLFlag),
+			Timeout:       viper.GetDuration(allocationWebhookTimeoutFlag),
+			MaxCandidates: int(viper.GetInt32(allocationWebhookCandsFlag)),
+		},
+		AllocationRateLimit: gameserverallocations.RateLimitConfig{
+			NamespaceQPS:   viper.GetFloat64(allocationNamespaceQPSFlag),
+			NamespaceBurst: int(viper.GetInt32(allocationNamespaceBurstFlag)),
+			FleetQPS:       viper.GetFloat64(allocationFleetQPSFlag),
+			FleetBurst:     int(viper.GetInt32(allocationFleetBurstFlag)),
+		},
+		AllocationDegraded: gameserverallocations.DegradedModeConfig{
+			FailureThreshold: int(viper.GetInt32(allocationDegradedFlag)),
+			MaxPendingWrites: int(viper.GetInt32(allocationDegradedQueueFlag)),
+			MaxRetries:       int(viper.GetInt32(allocationDegradedRetryFlag)),
+			RetryPeriod:      viper.GetDuration(allocationDegradedPeriodFlag),
+		},
+		AllocationSelection: gameserverallocations.SelectionConfig{
+			Strategy: viper.GetString(allocationSelectionFlag),
+			TopN:     int(viper.GetInt32(allocationTopNFlag)),
+		},
+		AllocationAudit: gameserverallocations.AuditConfig{
+			Sink:           viper.GetString(allocationAuditSinkFlag),
+			File:           viper.GetString(allocationAuditFileFlag),
+			WebhookURL:     viper.GetString(allocationAuditURLFlag),
+			WebhookTimeout: viper.GetDuration(allocationAuditTimeoutFlag),
+		},
 	}
 }
 
@@ -314,12 +606,25 @@ func parseEnvFlags() config {
 type config struct {
 	MinPort               int32
 	MaxPort               int32
+	NamespacePortRanges   map[string]gameservers.PortRange
+	ImagePullSecrets      gameservers.ImagePullSecrets
+	PreemptionTaints      []string
+	SafeToEvict           string
+	MaintenanceCordoned   bool
+	MaintenanceTaints     []string
+	Disruption            gameservers.DisruptionProtection
+	NodeNotReady          gameservers.NodeNotReadyRemediation
+	CloudProduct          string
 	SidecarImage          string
 	SidecarCPURequest     resource.Quantity
 	SidecarCPULimit       resource.Quantity
 	SdkServiceAccount     string
 	AlwaysPullSidecar     bool
+	SidecarFirst          bool
+	SidecarUpgradeInPlace bool
+	SidecarLogTail        bool
 	PrometheusMetrics     bool
+	ExternalMetrics       bool
 	Stackdriver           bool
 	KeyFile               string
 	CertFile              string
@@ -330,6 +635,90 @@ type config struct {
 	APIServerBurstQPS     int
 	LogDir                string
 	LogSizeLimitMB        int
+	LogLevel              string
+	LogSampleRate         int
+	AllocationHedgeDelay  time.Duration
+	AllocationTransport   string
+	AllocationBatch       gameserverallocations.BatchConfig
+	AllocationIndexLabels []string
+	AllocationProtected   []string
+	AllocationScheduling  apis.SchedulingStrategy
+	AllocationWebhook     gameserverallocations.FilterWebhookConfig
+	AllocationRateLimit   gameserverallocations.RateLimitConfig
+	AllocationDegraded    gameserverallocations.DegradedModeConfig
+	AllocationSelection   gameserverallocations.SelectionConfig
+	AllocationAudit       gameserverallocations.AuditConfig
+	AllocationLint        bool
+}
+
+// parseLabelKeys parses a comma separated list of label keys
+func parseLabelKeys(s string) []string {
+	var keys []string
+	for _, key := range strings.Split(s, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// parseNamespacePortRanges parses a comma separated list of namespace=minPort-maxPort port ranges
+func parseNamespacePortRanges(s string) (map[string]gameservers.PortRange, error) {
+	ranges := map[string]gameservers.PortRange{}
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("port range %q must be in the form namespace=minPort-maxPort", entry)
+		}
+		ports := strings.SplitN(parts[1], "-", 2)
+		if len(ports) != 2 {
+			return nil, errors.Errorf("port range %q must be in the form namespace=minPort-maxPort", entry)
+		}
+		minPort, err := strconv.ParseInt(strings.TrimSpace(ports[0]), 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid minimum port of port range %q", entry)
+		}
+		maxPort, err := strconv.ParseInt(strings.TrimSpace(ports[1]), 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid maximum port of port range %q", entry)
+		}
+		ns := strings.TrimSpace(parts[0])
+		if _, ok := ranges[ns]; ok {
+			return nil, errors.Errorf("namespace %s has more than one port range", ns)
+		}
+		ranges[ns] = gameservers.PortRange{MinPort: int32(minPort), MaxPort: int32(maxPort)}
+	}
+	return ranges, nil
+}
+
+// parseImagePullSecrets parses a comma separated list of namespace=secret;secret or namespace/fleet=secret;secret
+// image pull secrets
+func parseImagePullSecrets(s string) (gameservers.ImagePullSecrets, error) {
+	secrets := gameservers.ImagePullSecrets{}
+	for _, entry := range strings.Split(s, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			return nil, errors.Errorf("image pull secrets %q must be in the form namespace=secret;secret or namespace/fleet=secret;secret", entry)
+		}
+		key := strings.TrimSpace(parts[0])
+		if _, ok := secrets[key]; ok {
+			return nil, errors.Errorf("%s has more than one list of image pull secrets", key)
+		}
+		var names []string
+		for _, name := range strings.Split(parts[1], ";") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+		secrets[key] = names
+	}
+	return secrets, nil
 }
 
 // validate ensures the ctlConfig data is valid.
@@ -340,6 +729,120 @@ func (c config) validate() error {
 	if c.MaxPort < c.MinPort {
 		return errors.New("max Port cannot be set less that the Min Port")
 	}
+	namespaces := make([]string, 0, len(c.NamespacePortRanges))
+	for ns := range c.NamespacePortRanges {
+		namespaces = append(namespaces, ns)
+	}
+	// sorted, so the same configuration always fails with the same error
+	sort.Strings(namespaces)
+	for i, ns := range namespaces {
+		r := c.NamespacePortRanges[ns]
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return errors.Errorf("port range namespace %q is not a valid namespace: %s", ns, strings.Join(errs, ", "))
+		}
+		if r.MinPort <= 0 || r.MaxPort < r.MinPort || r.MaxPort > 65535 {
+			return errors.Errorf("port range %d-%d of namespace %s is invalid", r.MinPort, r.MaxPort, ns)
+		}
+		if r.MinPort <= c.MaxPort && c.MinPort <= r.MaxPort {
+			return errors.Errorf("port range %d-%d of namespace %s overlaps the default port range %d-%d", r.MinPort, r.MaxPort, ns, c.MinPort, c.MaxPort)
+		}
+		for _, other := range namespaces[:i] {
+			o := c.NamespacePortRanges[other]
+			if r.MinPort <= o.MaxPort && o.MinPort <= r.MaxPort {
+				return errors.Errorf("port range %d-%d of namespace %s overlaps port range %d-%d of namespace %s", r.MinPort, r.MaxPort, ns, o.MinPort, o.MaxPort, other)
+			}
+		}
+	}
+	if err := validateImagePullSecrets(c.ImagePullSecrets); err != nil {
+		return err
+	}
+	for _, key := range c.PreemptionTaints {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return errors.Errorf("preemption taint %q is not a valid taint key: %s", key, strings.Join(errs, ", "))
+		}
+	}
+	for _, key := range c.MaintenanceTaints {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return errors.Errorf("maintenance taint %q is not a valid taint key: %s", key, strings.Join(errs, ", "))
+		}
+	}
+	if c.Disruption.MaxGrace < 0 {
+		return errors.New("allocated disruption max grace must not be negative")
+	}
+	if c.NodeNotReady.Threshold < 0 {
+		return errors.New("node not ready threshold must not be negative")
+	}
+	if c.NodeNotReady.AllocatedThreshold != 0 && (c.NodeNotReady.Threshold == 0 || c.NodeNotReady.AllocatedThreshold < c.NodeNotReady.Threshold) {
+		return errors.New("node not ready allocated threshold must be 0, or at least the node not ready threshold when that is set")
+	}
+	if c.SafeToEvict != "" && c.SafeToEvict != "true" && c.SafeToEvict != "false" {
+		return errors.Errorf("gameserver safe to evict must be true, false or empty, not %q", c.SafeToEvict)
+	}
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		return errors.Wrap(err, "invalid log level")
+	}
+	if c.LogSampleRate < 1 {
+		return errors.New("log sample rate must be at least 1")
+	}
+	if c.AllocationTransport != gameserverallocations.RemoteAllocationTransportHTTP && c.AllocationTransport != gameserverallocations.RemoteAllocationTransportGRPC {
+		return errors.New("remote allocation transport must be either http or grpc")
+	}
+	if err := c.AllocationBatch.Validate(); err != nil {
+		return err
+	}
+	if err := c.AllocationWebhook.Validate(); err != nil {
+		return err
+	}
+	if err := c.AllocationRateLimit.Validate(); err != nil {
+		return err
+	}
+	if err := c.AllocationDegraded.Validate(); err != nil {
+		return err
+	}
+	if err := c.AllocationSelection.Validate(); err != nil {
+		return err
+	}
+	if err := c.AllocationAudit.Validate(); err != nil {
+		return err
+	}
+	if err := gameserverallocations.ValidateStrategy(c.AllocationScheduling); err != nil {
+		return err
+	}
+	for _, key := range c.AllocationIndexLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return errors.Errorf("allocation index label %q is not a valid label key: %s", key, strings.Join(errs, ", "))
+		}
+	}
+	return nil
+}
+
+// validateImagePullSecrets returns an error if a namespace, Fleet or secret name of the image pull secrets is invalid
+func validateImagePullSecrets(secrets gameservers.ImagePullSecrets) error {
+	keys := make([]string, 0, len(secrets))
+	for key := range secrets {
+		keys = append(keys, key)
+	}
+	// sorted, so the same configuration always fails with the same error
+	sort.Strings(keys)
+	for _, key := range keys {
+		parts := strings.SplitN(key, "/", 2)
+		if errs := validation.IsDNS1123Label(parts[0]); len(errs) > 0 {
+			return errors.Errorf("image pull secrets namespace %q is not a valid namespace: %s", parts[0], strings.Join(errs, ", "))
+		}
+		if len(parts) == 2 {
+			if errs := validation.IsDNS1123Subdomain(parts[1]); len(errs) > 0 {
+				return errors.Errorf("image pull secrets fleet %q is not a valid fleet name: %s", parts[1], strings.Join(errs, ", "))
+			}
+		}
+		if len(secrets[key]) == 0 {
+			return errors.Errorf("%s has no image pull secrets", key)
+		}
+		for _, name := range secrets[key] {
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				return errors.Errorf("image pull secret %q of %s is not a valid secret name: %s", name, key, strings.Join(errs, ", "))
+			}
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,283 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"testing"
+	"time"
+
+	"agones.dev/agones/pkg/apis"
+	"agones.dev/agones/pkg/gameserverallocations"
+	"agones.dev/agones/pkg/gameservers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNamespacePortRanges(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]struct {
+		in      string
+		want    map[string]gameservers.PortRange
+		wantErr bool
+	}{
+		"empty": {
+			in:   "",
+			want: map[string]gameservers.PortRange{},
+		},
+		"multiple ranges": {
+			in: " team-a=8001-8500, team-b = 8501 - 9000,",
+			want: map[string]gameservers.PortRange{
+				"team-a": {MinPort: 8001, MaxPort: 8500},
+				"team-b": {MinPort: 8501, MaxPort: 9000},
+			},
+		},
+		"missing range": {
+			in:      "team-a",
+			wantErr: true,
+		},
+		"missing max port": {
+			in:      "team-a=8001",
+			wantErr: true,
+		},
+		"invalid min port": {
+			in:      "team-a=foo-8500",
+			wantErr: true,
+		},
+		"invalid max port": {
+			in:      "team-a=8001-bar",
+			wantErr: true,
+		},
+		"duplicate namespace": {
+			in:      "team-a=8001-8500,team-a=8501-9000",
+			wantErr: true,
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			ranges, err := parseNamespacePortRanges(v.in)
+			if v.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, v.want, ranges)
+		})
+	}
+}
+
+func TestConfigValidateNamespacePortRanges(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]struct {
+		ranges  map[string]gameservers.PortRange
+		wantErr string
+	}{
+		"no ranges": {},
+		"disjoint ranges": {
+			ranges: map[string]gameservers.PortRange{
+				"team-a": {MinPort: 8001, MaxPort: 8500},
+				"team-b": {MinPort: 8501, MaxPort: 9000},
+			},
+		},
+		"invalid namespace": {
+			ranges:  map[string]gameservers.PortRange{"Team_A": {MinPort: 8001, MaxPort: 8500}},
+			wantErr: `port range namespace "Team_A" is not a valid namespace`,
+		},
+		"inverted range": {
+			ranges:  map[string]gameservers.PortRange{"team-a": {MinPort: 8500, MaxPort: 8001}},
+			wantErr: "port range 8500-8001 of namespace team-a is invalid",
+		},
+		"overlaps default range": {
+			ranges:  map[string]gameservers.PortRange{"team-a": {MinPort: 7500, MaxPort: 8500}},
+			wantErr: "port range 7500-8500 of namespace team-a overlaps the default port range 7000-8000",
+		},
+		"overlapping ranges": {
+			ranges: map[string]gameservers.PortRange{
+				"team-a": {MinPort: 8001, MaxPort: 8500},
+				"team-b": {MinPort: 8500, MaxPort: 9000},
+			},
+			wantErr: "port range 8500-9000 of namespace team-b overlaps port range 8001-8500 of namespace team-a",
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			c := validConfig()
+			c.NamespacePortRanges = v.ranges
+			err := c.validate()
+			if v.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), v.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseImagePullSecrets(t *testing.T) {
+	t.Parallel()
+
+	secrets, err := parseImagePullSecrets(" team-a=registry-a; registry-b , team-a/fleet-1 = registry-c,")
+	assert.NoError(t, err)
+	assert.Equal(t, gameservers.ImagePullSecrets{
+		"team-a":         {"registry-a", "registry-b"},
+		"team-a/fleet-1": {"registry-c"},
+	}, secrets)
+
+	_, err = parseImagePullSecrets("team-a")
+	assert.EqualError(t, err, `image pull secrets "team-a" must be in the form namespace=secret;secret or namespace/fleet=secret;secret`)
+
+	_, err = parseImagePullSecrets("team-a=registry-a,team-a=registry-b")
+	assert.EqualError(t, err, "team-a has more than one list of image pull secrets")
+}
+
+func TestConfigValidateImagePullSecrets(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]struct {
+		secrets gameservers.ImagePullSecrets
+		wantErr string
+	}{
+		"namespace and fleet": {
+			secrets: gameservers.ImagePullSecrets{"team-a": {"registry-a"}, "team-a/fleet-1": {"registry-b"}},
+		},
+		"invalid namespace": {
+			secrets: gameservers.ImagePullSecrets{"Team_A": {"registry-a"}},
+			wantErr: `image pull secrets namespace "Team_A" is not a valid namespace`,
+		},
+		"invalid fleet": {
+			secrets: gameservers.ImagePullSecrets{"team-a/Fleet_1": {"registry-a"}},
+			wantErr: `image pull secrets fleet "Fleet_1" is not a valid fleet name`,
+		},
+		"no secrets": {
+			secrets: gameservers.ImagePullSecrets{"team-a": nil},
+			wantErr: "team-a has no image pull secrets",
+		},
+		"invalid secret": {
+			secrets: gameservers.ImagePullSecrets{"team-a": {"Registry_A"}},
+			wantErr: `image pull secret "Registry_A" of team-a is not a valid secret name`,
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			c := validConfig()
+			c.ImagePullSecrets = v.secrets
+			err := c.validate()
+			if v.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), v.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigValidateLogging(t *testing.T) {
+	t.Parallel()
+
+	c := validConfig()
+	c.LogLevel = "debug"
+	c.LogSampleRate = 100
+	assert.NoError(t, c.validate())
+
+	c = validConfig()
+	c.LogLevel = "verbose"
+	assert.EqualError(t, c.validate(), `invalid log level: not a valid logrus Level: "verbose"`)
+
+	c = validConfig()
+	c.LogSampleRate = 0
+	assert.EqualError(t, c.validate(), "log sample rate must be at least 1")
+}
+
+func TestConfigValidateSafeToEvict(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range []string{"", "true", "false"} {
+		c := validConfig()
+		c.SafeToEvict = v
+		assert.NoError(t, c.validate())
+	}
+
+	c := validConfig()
+	c.SafeToEvict = "yes"
+	assert.EqualError(t, c.validate(), `gameserver safe to evict must be true, false or empty, not "yes"`)
+}
+
+func TestConfigValidateDisruptionMaxGrace(t *testing.T) {
+	t.Parallel()
+
+	c := validConfig()
+	c.Disruption = gameservers.DisruptionProtection{Enabled: true, MaxGrace: time.Hour}
+	assert.NoError(t, c.validate())
+
+	c.Disruption.MaxGrace = -time.Second
+	assert.EqualError(t, c.validate(), "allocated disruption max grace must not be negative")
+}
+
+func TestConfigValidateNodeNotReady(t *testing.T) {
+	t.Parallel()
+
+	c := validConfig()
+	c.NodeNotReady = gameservers.NodeNotReadyRemediation{Threshold: 2 * time.Minute}
+	assert.NoError(t, c.validate())
+
+	c.NodeNotReady.AllocatedThreshold = 10 * time.Minute
+	assert.NoError(t, c.validate())
+
+	c.NodeNotReady.AllocatedThreshold = time.Minute
+	assert.EqualError(t, c.validate(), "node not ready allocated threshold must be 0, or at least the node not ready threshold when that is set")
+
+	c.NodeNotReady = gameservers.NodeNotReadyRemediation{AllocatedThreshold: 10 * time.Minute}
+	assert.EqualError(t, c.validate(), "node not ready allocated threshold must be 0, or at least the node not ready threshold when that is set")
+
+	c.NodeNotReady = gameservers.NodeNotReadyRemediation{Threshold: -time.Second}
+	assert.EqualError(t, c.validate(), "node not ready threshold must not be negative")
+}
+
+func TestConfigValidateMaintenanceTaints(t *testing.T) {
+	t.Parallel()
+
+	c := validConfig()
+	c.MaintenanceTaints = []string{"example.com/maintenance", "node.kubernetes.io/unschedulable"}
+	assert.NoError(t, c.validate())
+
+	c.MaintenanceTaints = []string{"example.com/under maintenance"}
+	assert.Error(t, c.validate())
+}
+
+// validConfig returns a config that passes validation
+func validConfig() config {
+	return config{
+		MinPort:             7000,
+		MaxPort:             8000,
+		LogLevel:            "info",
+		LogSampleRate:       1,
+		AllocationTransport: gameserverallocations.RemoteAllocationTransportHTTP,
+		AllocationBatch: gameserverallocations.BatchConfig{
+			MaxQueue:              100,
+			MaxBatchBeforeRefresh: 100,
+			WaitTime:              500 * time.Millisecond,
+		},
+		AllocationScheduling: apis.Packed,
+		AllocationSelection:  gameserverallocations.SelectionConfig{Strategy: gameserverallocations.SelectionBest, TopN: 1},
+		AllocationAudit:      gameserverallocations.AuditConfig{Sink: gameserverallocations.AuditSinkNone},
+	}
+}
@@ -0,0 +1,127 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"time"
+
+	"agones.dev/agones/pkg/gameserverallocations"
+	"agones.dev/agones/pkg/gameservers"
+	"agones.dev/agones/pkg/util/workerqueue"
+	"github.com/pkg/errors"
+)
+
+// informerFactory is the part of the informer factories that reports whether their informers have synced
+type informerFactory interface {
+	WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool
+}
+
+// statusz serves a json summary of the state of the controller on /statusz, as a one stop view for on-call engineers
+type statusz struct {
+	certFile        string
+	informers       map[string]informerFactory
+	workQueues      []*workerqueue.WorkerQueue
+	ports           func() gameservers.PortUtilization
+	allocationCache func() gameserverallocations.CacheStatus
+	now             func() time.Time
+}
+
+// statuszReport is the json body of the statusz page
+type statuszReport struct {
+	// Informers are whether the informers of each informer factory have synced, by the type they inform on
+	Informers map[string]map[string]bool `json:"informers"`
+	// WorkQueues are the number of keys waiting in each work queue, by the name of the queue
+	WorkQueues map[string]int `json:"workQueues"`
+	// Ports is the utilization of the dynamic port range
+	Ports gameservers.PortUtilization `json:"ports"`
+	// AllocationCache is the state of the cache of Ready GameServers
+	AllocationCache gameserverallocations.CacheStatus `json:"allocationCache"`
+	// WebhookCertificate is when the certificate of the webhooks expires
+	WebhookCertificate certificateStatus `json:"webhookCertificate"`
+}
+
+// certificateStatus is when a certificate expires, or why that could not be read
+type certificateStatus struct {
+	NotAfter  *time.Time `json:"notAfter,omitempty"`
+	ExpiresIn string     `json:"expiresIn,omitempty"`
+	Error     string     `json:"error,omitempty"`
+}
+
+// ServeHTTP writes the status of the controller
+func (s *statusz) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	report := statuszReport{
+		Informers:          map[string]map[string]bool{},
+		WorkQueues:         map[string]int{},
+		Ports:              s.ports(),
+		AllocationCache:    s.allocationCache(),
+		WebhookCertificate: s.certificateStatus(),
+	}
+
+	// with a closed stop channel, the factories check whether their informers have synced once, without waiting
+	done := make(chan struct{})
+	close(done)
+	for name, f := range s.informers {
+		synced := map[string]bool{}
+		for t, ok := range f.WaitForCacheSync(done) {
+			synced[t.String()] = ok
+		}
+		report.Informers[name] = synced
+	}
+	for _, wq := range s.workQueues {
+		report.WorkQueues[wq.Name()] = wq.Len()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		logger.WithError(err).Error("error writing statusz")
+	}
+}
+
+// certificateStatus returns when the webhook certificate expires
+func (s *statusz) certificateStatus() certificateStatus {
+	notAfter, err := certificateNotAfter(s.certFile)
+	if err != nil {
+		return certificateStatus{Error: err.Error()}
+	}
+	return certificateStatus{
+		NotAfter:  &notAfter,
+		ExpiresIn: notAfter.Sub(s.now()).Round(time.Second).String(),
+	}
+}
+
+// certificateNotAfter returns the expiry of the first certificate of a PEM file
+func certificateNotAfter(file string) (time.Time, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "error reading certificate")
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return time.Time{}, errors.Errorf("no PEM data in certificate %s", file)
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "error parsing certificate")
+	}
+	return cert.NotAfter, nil
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/gameserverallocations"
+	"agones.dev/agones/pkg/gameservers"
+	"agones.dev/agones/pkg/util/logfields"
+	"agones.dev/agones/pkg/util/workerqueue"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeInformerFactory map[reflect.Type]bool
+
+func (f fakeInformerFactory) WaitForCacheSync(stopCh <-chan struct{}) map[reflect.Type]bool {
+	return f
+}
+
+func TestStatuszServeHTTP(t *testing.T) {
+	t.Parallel()
+
+	wq := workerqueue.NewWorkerQueue(func(string) error { return nil }, logrus.WithField("test", t.Name()), logfields.GameServerKey, "test.Queue")
+	wq.EnqueueImmediately(cache.ExplicitKey("default/gs-1"))
+	wq.EnqueueImmediately(cache.ExplicitKey("default/gs-2"))
+
+	s := &statusz{
+		certFile:   "../../install/helm/agones/certs/server.crt",
+		informers:  map[string]informerFactory{"agones": fakeInformerFactory{reflect.TypeOf(&agonesv1.GameServer{}): false}},
+		workQueues: []*workerqueue.WorkerQueue{wq},
+		ports: func() gameservers.PortUtilization {
+			return gameservers.PortUtilization{Ports: 200, Allocated: 3}
+		},
+		allocationCache: func() gameserverallocations.CacheStatus {
+			return gameserverallocations.CacheStatus{ReadyGameServers: 7}
+		},
+		now: func() time.Time {
+			return time.Date(2028, time.February, 11, 4, 44, 46, 0, time.UTC)
+		},
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/statusz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var report statuszReport
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, map[string]map[string]bool{"agones": {"*v1.GameServer": false}}, report.Informers)
+	assert.Equal(t, map[string]int{"test.Queue": 2}, report.WorkQueues)
+	assert.Equal(t, gameservers.PortUtilization{Ports: 200, Allocated: 3}, report.Ports)
+	assert.Equal(t, gameserverallocations.CacheStatus{ReadyGameServers: 7}, report.AllocationCache)
+	assert.Equal(t, "24h0m0s", report.WebhookCertificate.ExpiresIn)
+	assert.Empty(t, report.WebhookCertificate.Error)
+
+	s.certFile = "missing.crt"
+	assert.Contains(t, s.certificateStatus().Error, "error reading certificate")
+}
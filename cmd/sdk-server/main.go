@@ -36,6 +36,7 @@ import (
 	"github.com/spf13/viper"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 )
@@ -44,6 +45,9 @@ const (
 	defaultGRPCPort = 59357
 	defaultHTTPPort = 59358
 
+	// waitReadyTimeout is how long --wait-ready waits for the sdk server to serve
+	waitReadyTimeout = time.Minute
+
 	// specifically env vars
 	gameServerNameEnv = "GAMESERVER_NAME"
 	podNamespaceEnv   = "POD_NAMESPACE"
@@ -57,6 +61,10 @@ const (
 	timeoutFlag  = "timeout"
 	grpcPortFlag = "grpc-port"
 	httpPortFlag = "http-port"
+	// waits for the sdk server of the pod to serve, instead of running one
+	waitReadyFlag = "wait-ready"
+	// the game server container whose log is served by the sidecar, if set
+	logTailContainerFlag = "log-tail-container"
 )
 
 var (
@@ -65,6 +73,13 @@ var (
 
 func main() {
 	ctlConf := parseEnvFlags()
+	if ctlConf.WaitReady {
+		if err := waitReady(ctlConf); err != nil {
+			logger.WithError(err).Fatal("SDK server is not serving")
+		}
+		return
+	}
+
 	logger.WithField("version", pkg.Version).
 		WithField("ctlConf", ctlConf).Info("Starting sdk sidecar")
 
@@ -141,6 +156,9 @@ func main() {
 		if err != nil {
 			logger.WithError(err).Fatalf("Could not start sidecar")
 		}
+		if ctlConf.LogTailContainer != "" {
+			s.EnableLogTail(ctlConf.LogTailContainer)
+		}
 
 		go func() {
 			err := s.Run(ctx.Done())
@@ -198,6 +216,26 @@ func registerTestSdkServer(grpcServer *grpc.Server, ctlConf config) (localSDK *s
 	return
 }
 
+// waitReady waits for both the grpc service and the grpc-gateway of the sdk server to accept connections,
+// so that it can be run as the post start hook of the sidecar, to hold off the game server container until then
+func waitReady(ctlConf config) error {
+	endpoints := []string{
+		fmt.Sprintf("%s:%d", ctlConf.Address, ctlConf.GRPCPort),
+		fmt.Sprintf("%s:%d", ctlConf.Address, ctlConf.HTTPPort),
+	}
+	err := wait.PollImmediate(100*time.Millisecond, waitReadyTimeout, func() (bool, error) {
+		for _, endpoint := range endpoints {
+			conn, err := net.DialTimeout("tcp", endpoint, time.Second)
+			if err != nil {
+				return false, nil
+			}
+			conn.Close() // nolint: errcheck
+		}
+		return true, nil
+	})
+	return errors.Wrapf(err, "sdk server did not serve on %v", endpoints)
+}
+
 // runGrpc runs the grpc service
 func runGrpc(grpcServer *grpc.Server, grpcEndpoint string) {
 	lis, err := net.Listen("tcp", grpcEndpoint)
@@ -244,6 +282,8 @@ func parseEnvFlags() config {
 	viper.SetDefault(timeoutFlag, 0)
 	viper.SetDefault(grpcPortFlag, defaultGRPCPort)
 	viper.SetDefault(httpPortFlag, defaultHTTPPort)
+	viper.SetDefault(logTailContainerFlag, "")
+	viper.SetDefault(waitReadyFlag, false)
 	pflag.Bool(localFlag, viper.GetBool(localFlag),
 		"Set this, or LOCAL env, to 'true' to run this binary in local development mode. Defaults to 'false'")
 	pflag.StringP(fileFlag, "f", viper.GetString(fileFlag), "Set this, or FILE env var to the path of a local yaml or json file that contains your GameServer resoure configuration")
@@ -252,6 +292,8 @@ func parseEnvFlags() config {
 	pflag.Int(httpPortFlag, viper.GetInt(httpPortFlag), fmt.Sprintf("Port on which to bind the HTTP server. Defaults to %d", defaultHTTPPort))
 	pflag.Int(delayFlag, viper.GetInt(delayFlag), "Time to delay (in seconds) before starting to execute main. Useful for tests")
 	pflag.Int(timeoutFlag, viper.GetInt(timeoutFlag), "Time of execution (in seconds) before close. Useful for tests")
+	pflag.String(logTailContainerFlag, viper.GetString(logTailContainerFlag), "The game server container whose log tail is served on /logs. Disabled if not set")
+	pflag.Bool(waitReadyFlag, viper.GetBool(waitReadyFlag), "Wait for the sdk server to serve on its ports, then exit, instead of running one. Used as the post start hook of the sidecar")
 	pflag.String(testFlag, viper.GetString(testFlag), "List functions which shoud be called during the SDK Conformance test run.")
 	pflag.Parse()
 
@@ -265,6 +307,7 @@ func parseEnvFlags() config {
 	runtime.Must(viper.BindEnv(timeoutFlag))
 	runtime.Must(viper.BindEnv(grpcPortFlag))
 	runtime.Must(viper.BindEnv(httpPortFlag))
+	runtime.Must(viper.BindEnv(logTailContainerFlag))
 	runtime.Must(viper.BindPFlags(pflag.CommandLine))
 
 	return config{
@@ -276,6 +319,9 @@ func parseEnvFlags() config {
 		Test:      viper.GetString(testFlag),
 		GRPCPort:  viper.GetInt(grpcPortFlag),
 		HTTPPort:  viper.GetInt(httpPortFlag),
+
+		LogTailContainer: viper.GetString(logTailContainerFlag),
+		WaitReady:        viper.GetBool(waitReadyFlag),
 	}
 }
 
@@ -289,4 +335,7 @@ type config struct {
 	Test      string
 	GRPCPort  int
 	HTTPPort  int
+
+	LogTailContainer string
+	WaitReady        bool
 }
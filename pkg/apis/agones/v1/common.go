@@ -16,6 +16,7 @@ package v1
 
 import (
 	"fmt"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -24,13 +25,93 @@ import (
 
 // Block of const Error messages
 const (
-	ErrContainerRequired        = "Container is required when using multiple containers in the pod template"
-	ErrHostPortDynamic          = "HostPort cannot be specified with a Dynamic PortPolicy"
-	ErrPortPolicyStatic         = "PortPolicy must be Static"
-	ErrContainerPortRequired    = "ContainerPort must be defined for Dynamic and Static PortPolicies"
-	ErrContainerPortPassthrough = "ContainerPort cannot be specified with Passthrough PortPolicy"
+	ErrContainerRequired             = "Container is required when using multiple containers in the pod template"
+	ErrHostPortDynamic               = "HostPort cannot be specified with a Dynamic PortPolicy"
+	ErrPortPolicyStatic              = "PortPolicy must be Static"
+	ErrContainerPortRequired         = "ContainerPort must be defined for Dynamic and Static PortPolicies"
+	ErrContainerPortPassthrough      = "ContainerPort cannot be specified with Passthrough PortPolicy"
+	ErrReadyOnPodReadyHealth         = "Health checking must be disabled when ReadyOnPodReady is set, as there is no SDK to send health pings"
+	ErrSdkServerDisabledHealth       = "Health checking must be disabled when the SDK Server is disabled, as there is no SDK Server to receive health pings"
+	ErrSdkServerDisabledLogTail      = "LogTail cannot be set when the SDK Server is disabled, as there is no SDK Server to serve the log"
+	ErrDisconnectGracePeriodNegative = "DisconnectGracePeriodSeconds cannot be negative"
+	ErrDisconnectGracePeriodNoSdk    = "DisconnectGracePeriodSeconds cannot be set when ReadyOnPodReady is set or the SDK Server is disabled, as there is no SDK connection"
+	ErrHostAliasIP                   = "HostAlias IP must be a valid IP address"
+	ErrHostAliasHostnames            = "HostAlias must have at least one hostname"
+	ErrSessionMaxDuration            = "Session MaxDuration must be greater than zero"
+	ErrSessionExpiryPolicy           = "Session ExpiryPolicy must be Shutdown or Ready"
+	ErrHostNetworkContainerPort      = "ContainerPort must be the same as HostPort with a Static PortPolicy when the Pod uses the host network"
+	ErrHostNetworkDynamic            = "ContainerPort cannot be specified with a Dynamic PortPolicy when the Pod uses the host network, as it is set to the allocated HostPort"
+	ErrServiceType                   = "ServiceType must be NodePort or LoadBalancer"
+	ErrServiceTypePortPolicy         = "PortPolicy must be Dynamic when the ports are exposed through a Service"
+	ErrServiceTypeProtocols          = "All ports must have the same Protocol when they are exposed through a LoadBalancer Service"
+	ErrServiceTypeHostNetwork        = "The ports cannot be exposed through a Service when the Pod uses the host network"
 )
 
+// AllocationOverflow marks the Allocated GameServers of a GameServerSet that are over its Replicas, such as when
+// its Fleet is scaled down below the number of Allocated GameServers, by applying labels and annotations to them.
+// Without it, these GameServers are left unmarked, and keep running until their game ends.
+type AllocationOverflow struct {
+	// Labels are applied to the Allocated GameServers over the Replicas
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are applied to the Allocated GameServers over the Replicas
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Validate returns the causes if the labels or annotations of the AllocationOverflow are not valid
+func (ao *AllocationOverflow) Validate(field string) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	for k, v := range ao.Labels {
+		if errs := append(validation.IsQualifiedName(k), validation.IsValidLabelValue(v)...); len(errs) > 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".labels",
+				Message: fmt.Sprintf("label %s=%s is invalid: %s", k, v, strings.Join(errs, ", ")),
+			})
+		}
+	}
+	for k := range ao.Annotations {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".annotations",
+				Message: fmt.Sprintf("annotation key %s is invalid: %s", k, strings.Join(errs, ", ")),
+			})
+		}
+	}
+	return causes
+}
+
+// Matches returns true if the GameServer has all the labels and annotations of the AllocationOverflow
+func (ao *AllocationOverflow) Matches(gs *GameServer) bool {
+	for k, v := range ao.Labels {
+		if gs.ObjectMeta.Labels[k] != v {
+			return false
+		}
+	}
+	for k, v := range ao.Annotations {
+		if gs.ObjectMeta.Annotations[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply adds the labels and annotations of the AllocationOverflow to the GameServer
+func (ao *AllocationOverflow) Apply(gs *GameServer) {
+	if len(ao.Labels) > 0 && gs.ObjectMeta.Labels == nil {
+		gs.ObjectMeta.Labels = make(map[string]string, len(ao.Labels))
+	}
+	for k, v := range ao.Labels {
+		gs.ObjectMeta.Labels[k] = v
+	}
+	if len(ao.Annotations) > 0 && gs.ObjectMeta.Annotations == nil {
+		gs.ObjectMeta.Annotations = make(map[string]string, len(ao.Annotations))
+	}
+	for k, v := range ao.Annotations {
+		gs.ObjectMeta.Annotations[k] = v
+	}
+}
+
 // crd is an interface to get Name and Kind of CRD
 type crd interface {
 	GetName() string
@@ -15,18 +15,74 @@
 package v1
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"agones.dev/agones/pkg"
 	"agones.dev/agones/pkg/apis"
 	"agones.dev/agones/pkg/apis/agones"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
+	// FleetMetricsServiceSuffix is appended to the name of a Fleet for the name of the headless Service
+	// for the metrics of its GameServers
+	FleetMetricsServiceSuffix = "-metrics"
+	// FleetMetricsDefaultPath is the default http path of the metrics of the GameServers of a Fleet
+	FleetMetricsDefaultPath = "/metrics"
+
 	// FleetNameLabel is the label that the name of the Fleet
 	// is set to on GameServerSet and GameServer  the Fleet controls
 	FleetNameLabel = agones.GroupName + "/fleet"
+	// FleetTemplateLabel is the label that the name of the Fleet template is set to
+	// on the GameServerSets and GameServers that are created from one of the Fleet's Templates
+	FleetTemplateLabel = agones.GroupName + "/fleet-template"
+	// FleetDryRunTemplateAnnotation is the annotation that is set on a Fleet that is created with a server side
+	// dry run, to the defaulted GameServer that would be created from the Fleet
+	FleetDryRunTemplateAnnotation = agones.GroupName + "/dry-run-template"
+	// FleetRevisionAnnotation is the annotation that is set on the GameServerSets of a Fleet to the revision of the
+	// Fleet they were created for, which increases each time the GameServer templates of the Fleet are changed
+	FleetRevisionAnnotation = agones.GroupName + "/revision"
+	// FleetRollbackAnnotation is the annotation that is set on a Fleet to roll its GameServer templates back to those of
+	// a previous revision, or to the revision before the current one if it is set to 0. It is removed once applied.
+	FleetRollbackAnnotation = agones.GroupName + "/rollback-to"
+	// FleetRolloutFailedAnnotation is the annotation that is set on a GameServerSet to the FleetRolloutFailureAction
+	// that was taken when the failures of its GameServers exceeded the failure threshold of the rollout of its Fleet.
+	// The rollout of a GameServerSet with the annotation is not stopped again.
+	FleetRolloutFailedAnnotation = agones.GroupName + "/rollout-failed"
+
+	// FleetRolloutDefault leaves the Allocated GameServers of previous templates running until they are shut down,
+	// and waits for a GameServerSet of a previous template to scale down before scaling down the next one
+	FleetRolloutDefault FleetRolloutMode = "Default"
+	// FleetRolloutDrainAllocated counts the Allocated GameServers of previous templates as part of the rollout:
+	// the GameServerSets of previous templates are scaled down while their Allocated GameServers drain,
+	// and the Allocated GameServers that are still running after DrainTimeoutSeconds are deleted
+	FleetRolloutDrainAllocated FleetRolloutMode = "DrainAllocated"
+
+	// FleetRolloutFailurePause pauses the rollout of a Fleet whose current templates fail too often
+	FleetRolloutFailurePause FleetRolloutFailureAction = "Pause"
+	// FleetRolloutFailureAbort rolls a Fleet whose current templates fail too often back to its previous revision,
+	// or pauses it if there is none
+	FleetRolloutFailureAbort FleetRolloutFailureAction = "Abort"
+
+	// FleetConditionRolloutFailed is whether the rollout of the newest GameServerSet of the Fleet was stopped, as
+	// its GameServers failed too often. Its Reason is the FleetRolloutFailureAction that was taken.
+	FleetConditionRolloutFailed FleetConditionType = "RolloutFailed"
+	// FleetConditionRolloutWaiting is whether the rollout of changed GameServer templates of the Fleet is waiting
+	// for the rollouts of the Fleets it depends on to complete. Its Message lists the Fleets it is waiting for.
+	FleetConditionRolloutWaiting FleetConditionType = "RolloutWaiting"
+
+	// CanaryDeploymentStrategyType keeps the GameServerSet of the previous template alongside the one of the
+	// current template when the template is changed, with the Canary Ratio of the Replicas given to the current
+	// template, and holds there until the ratio is changed
+	CanaryDeploymentStrategyType appsv1.DeploymentStrategyType = "Canary"
 )
 
 // +genclient
@@ -63,6 +119,98 @@ type FleetSpec struct {
 	Scheduling apis.SchedulingStrategy `json:"scheduling"`
 	// Template the GameServer template to apply for this Fleet
 	Template GameServerTemplateSpec `json:"template"`
+	// Templates are additional GameServer templates, for a Fleet of GameServers with different configurations.
+	// Each one is given its ratio of the Replicas, and Template is given the replicas that are left.
+	Templates []FleetTemplate `json:"templates,omitempty"`
+	// Rollout configures how a rolling update handles the Allocated GameServers of previous templates
+	Rollout FleetRollout `json:"rollout,omitempty"`
+	// Canary configures the Canary deployment strategy
+	Canary FleetCanary `json:"canary,omitempty"`
+	// Paused stops a rollout of changed GameServer templates from starting or progressing, until it is unset
+	Paused bool `json:"paused,omitempty"`
+	// RevisionHistoryLimit is the number of scaled down GameServerSets of previous templates that are kept,
+	// so that the Fleet can be rolled back to them. Defaults to 0.
+	RevisionHistoryLimit int32 `json:"revisionHistoryLimit,omitempty"`
+	// AllocationOverflow marks the Allocated GameServers over the Replicas with labels and annotations,
+	// when the Fleet is scaled down below its Allocated GameServers. They keep running either way.
+	AllocationOverflow *AllocationOverflow `json:"allocationOverflow,omitempty"`
+	// Metrics exposes the metrics port of the GameServers of the Fleet through a headless Service,
+	// so that Prometheus discovers each of them without relabeling Pod IPs and host ports
+	Metrics *FleetMetrics `json:"metrics,omitempty"`
+	// DependsOn are the names of the Fleets in the same namespace whose rollouts have to complete before a rollout
+	// of changed GameServer templates of this Fleet starts or progresses, e.g. lobby servers before match servers
+	DependsOn []string `json:"dependsOn,omitempty"`
+}
+
+// FleetMetrics configures the headless Service for the metrics of the GameServers of a Fleet, which the
+// Fleet controller annotates with the prometheus.io annotations
+type FleetMetrics struct {
+	// Port is the container port that the game servers serve their metrics on
+	Port int32 `json:"port"`
+	// Path is the http path of the metrics. Defaults to "/metrics".
+	Path string `json:"path,omitempty"`
+}
+
+// FleetCanary configures the Canary deployment strategy of a Fleet
+type FleetCanary struct {
+	// Ratio is the percentage of the Replicas that are created from the current template, rounded down.
+	// The rest are kept on the GameServerSet of the previous template. 100 completes the rollout.
+	Ratio int32 `json:"ratio"`
+}
+
+// FleetRolloutMode is how a rolling update of a Fleet handles the Allocated GameServers of previous templates
+type FleetRolloutMode string
+
+// FleetRollout configures how a rolling update handles the Allocated GameServers of previous templates
+type FleetRollout struct {
+	// Mode is either Default or DrainAllocated. Defaults to "Default".
+	Mode FleetRolloutMode `json:"mode,omitempty"`
+	// DrainTimeoutSeconds is how long the DrainAllocated mode waits for the Allocated GameServers of previous
+	// templates to shut down, from the start of the rollout, before they are deleted. 0 waits for as long as it takes.
+	DrainTimeoutSeconds int32 `json:"drainTimeoutSeconds,omitempty"`
+	// FailureThreshold stops the rollout when the GameServers of the current templates fail too often
+	FailureThreshold *FleetRolloutFailureThreshold `json:"failureThreshold,omitempty"`
+}
+
+// FleetRolloutFailureAction is what is done to a rollout whose GameServers fail too often
+type FleetRolloutFailureAction string
+
+// FleetRolloutFailureThreshold configures when a rollout is stopped for the GameServers of the current templates
+// failing too often. GameServers fail when they are moved to Unhealthy or Error, and are replaced.
+type FleetRolloutFailureThreshold struct {
+	// Percent of the GameServers of the GameServerSet of a current template that failed, among those that failed
+	// and those that it still has, at which the rollout is stopped
+	Percent int32 `json:"percent"`
+	// MinFailures is how many GameServers of the GameServerSet must have failed before the rollout is stopped.
+	// Defaults to 1.
+	MinFailures int32 `json:"minFailures,omitempty"`
+	// Action is either Pause or Abort. Defaults to "Pause".
+	Action FleetRolloutFailureAction `json:"action,omitempty"`
+}
+
+// Exceeded returns true if the failed GameServers of a GameServerSet that still has the replicas
+// exceed the threshold
+func (t *FleetRolloutFailureThreshold) Exceeded(failed, replicas int32) bool {
+	if failed < t.MinFailures || failed == 0 {
+		return false
+	}
+	return failed*100 >= t.Percent*(failed+replicas)
+}
+
+// FleetTemplate is an additional GameServer template of a Fleet
+type FleetTemplate struct {
+	// Name of the template, which the agones.dev/fleet-template label is set to
+	// on the GameServerSets and GameServers created from it
+	Name string `json:"name"`
+	// Ratio is the percentage of the Fleet's Replicas that are created from this template
+	Ratio int32 `json:"ratio"`
+	// Template the GameServer template
+	Template GameServerTemplateSpec `json:"template"`
+}
+
+// GetGameServerSpec get underlying Gameserver specification
+func (ft *FleetTemplate) GetGameServerSpec() *GameServerSpec {
+	return &ft.Template.Spec
 }
 
 // FleetStatus is the status of a Fleet
@@ -76,6 +224,110 @@ type FleetStatus struct {
 	ReservedReplicas int32 `json:"reservedReplicas"`
 	// AllocatedReplicas are the number of Allocated GameServer replicas
 	AllocatedReplicas int32 `json:"allocatedReplicas"`
+	// ScheduledReplicas are the number of Scheduled GameServer replicas, whose Pod is running but not yet Ready
+	ScheduledReplicas int32 `json:"scheduledReplicas"`
+	// UnhealthyReplicas are the number of Unhealthy GameServer replicas, that have not been replaced yet
+	UnhealthyReplicas int32 `json:"unhealthyReplicas"`
+	// Rollout is the progress of the rolling update of the Fleet to its current templates,
+	// nil if there are no GameServers of previous templates
+	Rollout *FleetRolloutStatus `json:"rollout,omitempty"`
+	// LabelSelector selects the GameServers of the Fleet, for the scale subresource
+	LabelSelector string `json:"labelSelector,omitempty"`
+	// AllocationOverflowReplicas are the number of Allocated GameServer replicas over the Replicas of the Fleet
+	AllocationOverflowReplicas int32 `json:"allocationOverflowReplicas"`
+	// Conditions are the latest observations of the Fleet, e.g. RolloutFailed
+	// +optional
+	Conditions []FleetCondition `json:"conditions,omitempty"`
+}
+
+// FleetConditionType is the type of a FleetCondition
+type FleetConditionType string
+
+// FleetCondition is an observation of the Fleet at a point in time
+type FleetCondition struct {
+	// Type of the condition, e.g. RolloutFailed
+	Type FleetConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// LastTransitionTime is when the condition last changed Status
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a one word reason for the last transition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message about the last transition
+	Message string `json:"message,omitempty"`
+}
+
+// FleetRolloutStatus is the progress of the rolling update of a Fleet to its current templates
+type FleetRolloutStatus struct {
+	// StartTime is when the last of the OutdatedGameServerSets became outdated
+	StartTime metav1.Time `json:"startTime"`
+	// OutdatedGameServerSets are the names of the GameServerSets of previous templates
+	OutdatedGameServerSets []string `json:"outdatedGameServerSets,omitempty"`
+	// UpdatedReplicas are the number of GameServer replicas of the current templates
+	UpdatedReplicas int32 `json:"updatedReplicas"`
+	// OutdatedReplicas are the number of GameServer replicas of previous templates
+	OutdatedReplicas int32 `json:"outdatedReplicas"`
+	// OutdatedAllocatedReplicas are the number of Allocated GameServer replicas of previous templates,
+	// which the rollout is waiting for to shut down
+	OutdatedAllocatedReplicas int32 `json:"outdatedAllocatedReplicas"`
+	// DrainDeadline is when the Allocated GameServers of previous templates are deleted,
+	// if the Fleet has the DrainAllocated rollout mode with a DrainTimeoutSeconds
+	DrainDeadline *metav1.Time `json:"drainDeadline,omitempty"`
+}
+
+// RolloutDrainDeadline returns when the Allocated GameServers of previous templates are deleted, for a rollout
+// that started at start, or nil if they are not
+func (f *Fleet) RolloutDrainDeadline(start metav1.Time) *metav1.Time {
+	if f.Spec.Rollout.Mode != FleetRolloutDrainAllocated || f.Spec.Rollout.DrainTimeoutSeconds <= 0 {
+		return nil
+	}
+	deadline := metav1.NewTime(start.Add(time.Duration(f.Spec.Rollout.DrainTimeoutSeconds) * time.Second))
+	return &deadline
+}
+
+// LabelSelector returns the serialised label selector of the GameServers of the Fleet
+func (f *Fleet) LabelSelector() string {
+	return labels.SelectorFromSet(labels.Set{FleetNameLabel: f.ObjectMeta.Name}).String()
+}
+
+// MetricsServiceName returns the name of the headless Service for the metrics of the GameServers of the Fleet
+func (f *Fleet) MetricsServiceName() string {
+	return f.ObjectMeta.Name + FleetMetricsServiceSuffix
+}
+
+// MetricsService returns the headless Service for the metrics of the GameServers of the Fleet, which selects
+// their Pods, or nil if the Fleet has no Metrics
+func (f *Fleet) MetricsService() *corev1.Service {
+	if f.Spec.Metrics == nil {
+		return nil
+	}
+
+	port := strconv.Itoa(int(f.Spec.Metrics.Port))
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      f.MetricsServiceName(),
+			Namespace: f.ObjectMeta.Namespace,
+			Labels:    map[string]string{FleetNameLabel: f.ObjectMeta.Name},
+			Annotations: map[string]string{
+				"prometheus.io/scrape": "true",
+				"prometheus.io/port":   port,
+				"prometheus.io/path":   f.Spec.Metrics.Path,
+			},
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  map[string]string{FleetNameLabel: f.ObjectMeta.Name, RoleLabel: GameServerLabelRole},
+			Ports: []corev1.ServicePort{{
+				Name:       "metrics",
+				Protocol:   corev1.ProtocolTCP,
+				Port:       f.Spec.Metrics.Port,
+				TargetPort: intstr.FromInt(int(f.Spec.Metrics.Port)),
+			}},
+		},
+	}
+	ref := metav1.NewControllerRef(f, SchemeGroupVersion.WithKind("Fleet"))
+	svc.ObjectMeta.OwnerReferences = append(svc.ObjectMeta.OwnerReferences, *ref)
+	return svc
 }
 
 // GameServerSet returns a single GameServerSet for this Fleet definition
@@ -83,8 +335,9 @@ func (f *Fleet) GameServerSet() *GameServerSet {
 	gsSet := &GameServerSet{
 		ObjectMeta: *f.Spec.Template.ObjectMeta.DeepCopy(),
 		Spec: GameServerSetSpec{
-			Template:   f.Spec.Template,
-			Scheduling: f.Spec.Scheduling,
+			Template:           f.Spec.Template,
+			Scheduling:         f.Spec.Scheduling,
+			AllocationOverflow: f.Spec.AllocationOverflow.DeepCopy(),
 		},
 	}
 
@@ -108,6 +361,18 @@ func (f *Fleet) GameServerSet() *GameServerSet {
 	return gsSet
 }
 
+// TemplateReplicas splits the Replicas across the Templates by their ratios, rounding down.
+// It returns the replicas of each of the Templates, in order, and the replicas that are left for Template
+func (f *Fleet) TemplateReplicas() ([]int32, int32) {
+	replicas := make([]int32, len(f.Spec.Templates))
+	rest := f.Spec.Replicas
+	for i, ft := range f.Spec.Templates {
+		replicas[i] = f.Spec.Replicas * ft.Ratio / 100
+		rest -= replicas[i]
+	}
+	return replicas, f.LowerBoundReplicas(rest)
+}
+
 // ApplyDefaults applies default values to the Fleet
 func (f *Fleet) ApplyDefaults() {
 	if f.Spec.Strategy.Type == "" {
@@ -118,6 +383,23 @@ func (f *Fleet) ApplyDefaults() {
 		f.Spec.Scheduling = apis.Packed
 	}
 
+	if f.Spec.Rollout.Mode == "" {
+		f.Spec.Rollout.Mode = FleetRolloutDefault
+	}
+
+	if f.Spec.Metrics != nil && f.Spec.Metrics.Path == "" {
+		f.Spec.Metrics.Path = FleetMetricsDefaultPath
+	}
+
+	if t := f.Spec.Rollout.FailureThreshold; t != nil {
+		if t.MinFailures == 0 {
+			t.MinFailures = 1
+		}
+		if t.Action == "" {
+			t.Action = FleetRolloutFailurePause
+		}
+	}
+
 	if f.Spec.Strategy.Type == appsv1.RollingUpdateDeploymentStrategyType {
 		if f.Spec.Strategy.RollingUpdate == nil {
 			f.Spec.Strategy.RollingUpdate = &appsv1.RollingUpdateDeployment{}
@@ -179,10 +461,190 @@ func (f *Fleet) Validate() ([]metav1.StatusCause, bool) {
 	if len(gsCauses) > 0 {
 		causes = append(causes, gsCauses...)
 	}
+	causes = append(causes, f.validateTemplates()...)
+	causes = append(causes, f.validateRollout()...)
+	if f.Spec.AllocationOverflow != nil {
+		causes = append(causes, f.Spec.AllocationOverflow.Validate("allocationOverflow")...)
+	}
+	causes = append(causes, f.validateMetrics()...)
+	causes = append(causes, f.validateDependsOn()...)
+	if f.Spec.RevisionHistoryLimit < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "revisionHistoryLimit",
+			Message: "revisionHistoryLimit must not be negative",
+		})
+	}
+	if f.Spec.Strategy.Type == CanaryDeploymentStrategyType && (f.Spec.Canary.Ratio < 0 || f.Spec.Canary.Ratio > 100) {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "canary.ratio",
+			Message: "canary ratio must be a percentage between 0 and 100",
+		})
+	}
 
 	return causes, len(causes) == 0
 }
 
+// validateMetrics validates the metrics port and path of the Fleet, and that the name of its metrics Service
+// is a valid Service name, e.g. that the Fleet name starts with a letter and is short enough
+func (f *Fleet) validateMetrics() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	m := f.Spec.Metrics
+	if m == nil {
+		return causes
+	}
+	if m.Port < 1 || m.Port > 65535 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "metrics.port",
+			Message: "metrics port must be between 1 and 65535",
+		})
+	}
+	if !strings.HasPrefix(m.Path, "/") {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "metrics.path",
+			Message: "metrics path must start with /",
+		})
+	}
+	// the name is generated after the mutating webhooks, so it is only known when validating
+	if f.ObjectMeta.Name == "" {
+		return causes
+	}
+	if errs := validation.IsDNS1035Label(f.MetricsServiceName()); len(errs) > 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "metrics",
+			Message: fmt.Sprintf("metrics Service name %s is not valid: %s", f.MetricsServiceName(), strings.Join(errs, ", ")),
+		})
+	}
+	return causes
+}
+
+// validateDependsOn validates that the Fleets the Fleet depends on are named once each, and are not the Fleet itself
+func (f *Fleet) validateDependsOn() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	seen := make(map[string]bool, len(f.Spec.DependsOn))
+	for _, name := range f.Spec.DependsOn {
+		field := fmt.Sprintf("dependsOn[%s]", name)
+		switch {
+		case len(validation.IsDNS1123Subdomain(name)) > 0:
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field,
+				Message: fmt.Sprintf("%s is not a valid Fleet name", name),
+			})
+		case name == f.ObjectMeta.Name:
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field,
+				Message: "a Fleet can not depend on itself",
+			})
+		case seen[name]:
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueDuplicate,
+				Field:   field,
+				Message: fmt.Sprintf("Fleet %s is depended on more than once", name),
+			})
+		}
+		seen[name] = true
+	}
+	return causes
+}
+
+// validateRollout validates the rollout mode and drain timeout of the Fleet
+func (f *Fleet) validateRollout() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	switch f.Spec.Rollout.Mode {
+	case "", FleetRolloutDefault, FleetRolloutDrainAllocated:
+	default:
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueNotSupported,
+			Field:   "rollout.mode",
+			Message: fmt.Sprintf("rollout mode must be one of %s or %s", FleetRolloutDefault, FleetRolloutDrainAllocated),
+		})
+	}
+	if f.Spec.Rollout.DrainTimeoutSeconds < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "rollout.drainTimeoutSeconds",
+			Message: "drainTimeoutSeconds must not be negative",
+		})
+	}
+	if t := f.Spec.Rollout.FailureThreshold; t != nil {
+		if t.Percent < 1 || t.Percent > 100 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "rollout.failureThreshold.percent",
+				Message: "failure threshold percent must be a percentage between 1 and 100",
+			})
+		}
+		if t.MinFailures < 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "rollout.failureThreshold.minFailures",
+				Message: "minFailures must not be negative",
+			})
+		}
+		switch t.Action {
+		case "", FleetRolloutFailurePause, FleetRolloutFailureAbort:
+		default:
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueNotSupported,
+				Field:   "rollout.failureThreshold.action",
+				Message: fmt.Sprintf("failure threshold action must be one of %s or %s", FleetRolloutFailurePause, FleetRolloutFailureAbort),
+			})
+		}
+	}
+	return causes
+}
+
+// validateTemplates validates the names and ratios of the Fleet's Templates, and their GameServer specifications
+func (f *Fleet) validateTemplates() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	names := map[string]bool{}
+	var sum int32
+	for i := range f.Spec.Templates {
+		ft := &f.Spec.Templates[i]
+		field := fmt.Sprintf("templates[%d]", i)
+		if ft.Name == "" || len(validation.IsValidLabelValue(ft.Name)) > 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".name",
+				Message: fmt.Sprintf("template name '%s' must be a valid label value", ft.Name),
+			})
+		} else if names[ft.Name] {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueDuplicate,
+				Field:   field + ".name",
+				Message: fmt.Sprintf("template name '%s' is used more than once", ft.Name),
+			})
+		}
+		names[ft.Name] = true
+
+		if ft.Ratio < 1 || ft.Ratio > 100 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".ratio",
+				Message: "ratio must be a percentage between 1 and 100",
+			})
+		}
+		sum += ft.Ratio
+
+		causes = append(causes, validateGSSpec(ft)...)
+	}
+
+	if sum > 100 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "templates",
+			Message: "the ratios of the templates add up to more than 100",
+		})
+	}
+	return causes
+}
+
 // UpperBoundReplicas returns whichever is smaller,
 // the value i, or the f.Spec.Replicas.
 func (f *Fleet) UpperBoundReplicas(i int32) int32 {
@@ -222,3 +684,57 @@ func SumStatusReplicas(list []*GameServerSet) int32 {
 
 	return total
 }
+
+// GetCondition returns the condition of the type, and false if it is not set
+func (fs *FleetStatus) GetCondition(t FleetConditionType) (FleetCondition, bool) {
+	i, ok := apis.GetCondition(fleetConditions{status: fs}, string(t))
+	if !ok {
+		return FleetCondition{}, false
+	}
+	return fs.Conditions[i], true
+}
+
+// SetCondition sets the condition, replacing the condition of the same type, and returns false if nothing changed.
+// The LastTransitionTime is kept if the Status of the condition did not change.
+func (fs *FleetStatus) SetCondition(condition FleetCondition) bool {
+	return apis.SetCondition(fleetConditions{status: fs}, condition.condition())
+}
+
+// condition converts the FleetCondition to the apis.Condition that is shared between resources
+func (c FleetCondition) condition() apis.Condition {
+	return apis.Condition{
+		Type:               string(c.Type),
+		Status:             c.Status,
+		LastTransitionTime: c.LastTransitionTime,
+		Reason:             c.Reason,
+		Message:            c.Message,
+	}
+}
+
+// fleetConditions adapts the conditions of a FleetStatus to apis.Conditions
+type fleetConditions struct {
+	status *FleetStatus
+}
+
+func (c fleetConditions) Len() int {
+	return len(c.status.Conditions)
+}
+
+func (c fleetConditions) At(i int) apis.Condition {
+	return c.status.Conditions[i].condition()
+}
+
+func (c fleetConditions) Set(i int, condition apis.Condition) {
+	converted := FleetCondition{
+		Type:               FleetConditionType(condition.Type),
+		Status:             condition.Status,
+		LastTransitionTime: condition.LastTransitionTime,
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+	}
+	if i == len(c.status.Conditions) {
+		c.status.Conditions = append(c.status.Conditions, converted)
+		return
+	}
+	c.status.Conditions[i] = converted
+}
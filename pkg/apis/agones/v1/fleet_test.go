@@ -16,6 +16,7 @@ package v1
 
 import (
 	"testing"
+	"time"
 
 	"agones.dev/agones/pkg/apis"
 	"github.com/stretchr/testify/assert"
@@ -57,7 +58,12 @@ func TestFleetGameServerSetGameServer(t *testing.T) {
 	assert.Equal(t, int32(0), gsSet.Spec.Replicas)
 	assert.Equal(t, f.Spec.Scheduling, gsSet.Spec.Scheduling)
 	assert.Equal(t, f.Spec.Template, gsSet.Spec.Template)
+	assert.Nil(t, gsSet.Spec.AllocationOverflow)
 	assert.True(t, metav1.IsControlledBy(gsSet, &f))
+
+	f.Spec.AllocationOverflow = &AllocationOverflow{Labels: map[string]string{"stale": "true"}}
+	gsSet = f.GameServerSet()
+	assert.Equal(t, f.Spec.AllocationOverflow, gsSet.Spec.AllocationOverflow)
 }
 
 func TestFleetApplyDefaults(t *testing.T) {
@@ -72,6 +78,12 @@ func TestFleetApplyDefaults(t *testing.T) {
 	assert.Equal(t, "25%", f.Spec.Strategy.RollingUpdate.MaxUnavailable.String())
 	assert.Equal(t, "25%", f.Spec.Strategy.RollingUpdate.MaxSurge.String())
 	assert.Equal(t, apis.Packed, f.Spec.Scheduling)
+	assert.Equal(t, FleetRolloutDefault, f.Spec.Rollout.Mode)
+	assert.Nil(t, f.Spec.Rollout.FailureThreshold)
+
+	f.Spec.Rollout.FailureThreshold = &FleetRolloutFailureThreshold{Percent: 50}
+	f.ApplyDefaults()
+	assert.Equal(t, &FleetRolloutFailureThreshold{Percent: 50, MinFailures: 1, Action: FleetRolloutFailurePause}, f.Spec.Rollout.FailureThreshold)
 }
 
 func TestFleetUpperBoundReplicas(t *testing.T) {
@@ -90,6 +102,281 @@ func TestFleetLowerBoundReplicas(t *testing.T) {
 	assert.Equal(t, int32(0), f.LowerBoundReplicas(-5))
 }
 
+func TestFleetTemplateReplicas(t *testing.T) {
+	f := &Fleet{Spec: FleetSpec{Replicas: 10}}
+	replicas, rest := f.TemplateReplicas()
+	assert.Empty(t, replicas)
+	assert.Equal(t, int32(10), rest)
+
+	f.Spec.Templates = []FleetTemplate{{Name: "large", Ratio: 30}, {Name: "small", Ratio: 25}}
+	replicas, rest = f.TemplateReplicas()
+	assert.Equal(t, []int32{3, 2}, replicas)
+	assert.Equal(t, int32(5), rest)
+
+	f.Spec.Templates = []FleetTemplate{{Name: "large", Ratio: 100}}
+	replicas, rest = f.TemplateReplicas()
+	assert.Equal(t, []int32{10}, replicas)
+	assert.Equal(t, int32(0), rest)
+}
+
+func TestFleetValidateTemplates(t *testing.T) {
+	f := defaultFleet()
+	f.ApplyDefaults()
+	f.Spec.Templates = []FleetTemplate{
+		{Name: "large", Ratio: 30, Template: f.Spec.Template},
+		{Name: "small", Ratio: 70, Template: f.Spec.Template},
+	}
+	causes, ok := f.Validate()
+	assert.True(t, ok)
+	assert.Len(t, causes, 0)
+
+	f.Spec.Templates[1].Name = "large"
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "templates[1].name", causes[0].Field)
+
+	f.Spec.Templates[1].Name = "not a label!"
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "templates[1].name", causes[0].Field)
+
+	f.Spec.Templates[1].Name = "small"
+	f.Spec.Templates[1].Ratio = 0
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "templates[1].ratio", causes[0].Field)
+
+	f.Spec.Templates[1].Ratio = 71
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "templates", causes[0].Field)
+
+	f.Spec.Templates[1].Ratio = 70
+	f.Spec.Templates[1].Template.Spec.Ports = []GameServerPort{{Name: "default", PortPolicy: Dynamic, HostPort: 7777, ContainerPort: 7777}}
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, ErrHostPortDynamic, causes[0].Message)
+	}
+}
+
+func TestFleetValidateRollout(t *testing.T) {
+	f := defaultFleet()
+	f.ApplyDefaults()
+	causes, ok := f.Validate()
+	assert.True(t, ok)
+	assert.Len(t, causes, 0)
+
+	f.Spec.Rollout = FleetRollout{Mode: FleetRolloutDrainAllocated, DrainTimeoutSeconds: 60}
+	causes, ok = f.Validate()
+	assert.True(t, ok)
+	assert.Len(t, causes, 0)
+
+	f.Spec.Rollout.Mode = "Drain"
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "rollout.mode", causes[0].Field)
+	}
+
+	f.Spec.Rollout.Mode = FleetRolloutDrainAllocated
+	f.Spec.Rollout.DrainTimeoutSeconds = -1
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "rollout.drainTimeoutSeconds", causes[0].Field)
+	}
+
+	f.Spec.Rollout.DrainTimeoutSeconds = 0
+	f.Spec.RevisionHistoryLimit = -1
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "revisionHistoryLimit", causes[0].Field)
+	}
+
+	f.Spec.RevisionHistoryLimit = 0
+	f.Spec.Rollout.FailureThreshold = &FleetRolloutFailureThreshold{Percent: 20, MinFailures: 3, Action: FleetRolloutFailureAbort}
+	causes, ok = f.Validate()
+	assert.True(t, ok)
+	assert.Len(t, causes, 0)
+
+	f.Spec.Rollout.FailureThreshold = &FleetRolloutFailureThreshold{Percent: 101, MinFailures: -1, Action: "Stop"}
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 3) {
+		assert.Equal(t, "rollout.failureThreshold.percent", causes[0].Field)
+		assert.Equal(t, "rollout.failureThreshold.minFailures", causes[1].Field)
+		assert.Equal(t, "rollout.failureThreshold.action", causes[2].Field)
+	}
+}
+
+func TestFleetRolloutFailureThresholdExceeded(t *testing.T) {
+	threshold := &FleetRolloutFailureThreshold{Percent: 25, MinFailures: 2}
+
+	assert.False(t, threshold.Exceeded(0, 10))
+	assert.False(t, threshold.Exceeded(1, 0), "below the minimum failures")
+	assert.False(t, threshold.Exceeded(2, 7), "2 of 9 is under 25%")
+	assert.True(t, threshold.Exceeded(2, 6), "2 of 8 is 25%")
+	assert.True(t, threshold.Exceeded(5, 0))
+
+	threshold.MinFailures = 0
+	assert.False(t, threshold.Exceeded(0, 0))
+}
+
+func TestFleetStatusSetCondition(t *testing.T) {
+	status := &FleetStatus{}
+	now := metav1.Now()
+
+	assert.True(t, status.SetCondition(FleetCondition{Type: FleetConditionRolloutFailed, Status: corev1.ConditionTrue, LastTransitionTime: now, Reason: "RolloutPaused", Message: "paused"}))
+	c, ok := status.GetCondition(FleetConditionRolloutFailed)
+	assert.True(t, ok)
+	assert.Equal(t, FleetCondition{Type: FleetConditionRolloutFailed, Status: corev1.ConditionTrue, LastTransitionTime: now, Reason: "RolloutPaused", Message: "paused"}, c)
+	assert.Equal(t, []FleetCondition{c}, status.Conditions)
+}
+
+func TestFleetValidateCanary(t *testing.T) {
+	f := defaultFleet()
+	f.Spec.Strategy.Type = CanaryDeploymentStrategyType
+	f.ApplyDefaults()
+	f.Spec.Canary.Ratio = 10
+	causes, ok := f.Validate()
+	assert.True(t, ok)
+	assert.Len(t, causes, 0)
+
+	for _, ratio := range []int32{-1, 101} {
+		f.Spec.Canary.Ratio = ratio
+		causes, ok = f.Validate()
+		assert.False(t, ok)
+		if assert.Len(t, causes, 1) {
+			assert.Equal(t, "canary.ratio", causes[0].Field)
+		}
+	}
+}
+
+func TestFleetValidateAllocationOverflow(t *testing.T) {
+	f := defaultFleet()
+	f.ApplyDefaults()
+	f.Spec.AllocationOverflow = &AllocationOverflow{
+		Labels:      map[string]string{"agones.dev/stale": "true"},
+		Annotations: map[string]string{"stale-since": "any value"},
+	}
+	causes, ok := f.Validate()
+	assert.True(t, ok)
+	assert.Len(t, causes, 0)
+
+	f.Spec.AllocationOverflow = &AllocationOverflow{
+		Labels:      map[string]string{"stale": "not a label value"},
+		Annotations: map[string]string{"not a key": "true"},
+	}
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 2) {
+		assert.Equal(t, "allocationOverflow.labels", causes[0].Field)
+		assert.Equal(t, "allocationOverflow.annotations", causes[1].Field)
+	}
+}
+
+func TestFleetValidateMetrics(t *testing.T) {
+	f := defaultFleet()
+	f.ObjectMeta.Name = "simple-fleet"
+	f.Spec.Metrics = &FleetMetrics{Port: 9090}
+	f.ApplyDefaults()
+	assert.Equal(t, "/metrics", f.Spec.Metrics.Path)
+	causes, ok := f.Validate()
+	assert.True(t, ok)
+	assert.Len(t, causes, 0)
+
+	f.Spec.Metrics = &FleetMetrics{Port: 0, Path: "metrics"}
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 2) {
+		assert.Equal(t, "metrics.port", causes[0].Field)
+		assert.Equal(t, "metrics.path", causes[1].Field)
+	}
+
+	f.Spec.Metrics = &FleetMetrics{Port: 9090, Path: "/metrics"}
+	f.ObjectMeta.Name = "1-fleet"
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "metrics", causes[0].Field)
+	}
+}
+
+func TestFleetValidateDependsOn(t *testing.T) {
+	f := defaultFleet()
+	f.ObjectMeta.Name = "match"
+	f.Spec.DependsOn = []string{"lobby", "chat"}
+	causes, ok := f.Validate()
+	assert.True(t, ok)
+	assert.Len(t, causes, 0)
+
+	f.Spec.DependsOn = []string{"lobby", "Lobby", "match", "lobby"}
+	causes, ok = f.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 3) {
+		assert.Equal(t, "dependsOn[Lobby]", causes[0].Field)
+		assert.Equal(t, "dependsOn[match]", causes[1].Field)
+		assert.Equal(t, metav1.CauseTypeFieldValueDuplicate, causes[2].Type)
+	}
+}
+
+func TestFleetMetricsService(t *testing.T) {
+	f := defaultFleet()
+	f.ObjectMeta.Name = "simple-fleet"
+	f.ObjectMeta.UID = "1234"
+	assert.Nil(t, f.MetricsService())
+
+	f.Spec.Metrics = &FleetMetrics{Port: 9090, Path: "/stats"}
+	svc := f.MetricsService()
+	assert.Equal(t, "simple-fleet-metrics", svc.ObjectMeta.Name)
+	assert.Equal(t, f.ObjectMeta.Namespace, svc.ObjectMeta.Namespace)
+	assert.True(t, metav1.IsControlledBy(svc, f))
+	assert.Equal(t, map[string]string{"prometheus.io/scrape": "true", "prometheus.io/port": "9090", "prometheus.io/path": "/stats"}, svc.ObjectMeta.Annotations)
+	assert.Equal(t, corev1.ClusterIPNone, svc.Spec.ClusterIP)
+	assert.Equal(t, map[string]string{FleetNameLabel: "simple-fleet", RoleLabel: GameServerLabelRole}, svc.Spec.Selector)
+	if assert.Len(t, svc.Spec.Ports, 1) {
+		assert.Equal(t, int32(9090), svc.Spec.Ports[0].Port)
+		assert.Equal(t, 9090, svc.Spec.Ports[0].TargetPort.IntValue())
+	}
+}
+
+func TestAllocationOverflowMatchesApply(t *testing.T) {
+	overflow := &AllocationOverflow{
+		Labels:      map[string]string{"stale": "true"},
+		Annotations: map[string]string{"reason": "scaled down"},
+	}
+	gs := &GameServer{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"stale": "false", "other": "label"}}}
+	assert.False(t, overflow.Matches(gs))
+
+	overflow.Apply(gs)
+	assert.True(t, overflow.Matches(gs))
+	assert.Equal(t, map[string]string{"stale": "true", "other": "label"}, gs.ObjectMeta.Labels)
+	assert.Equal(t, map[string]string{"reason": "scaled down"}, gs.ObjectMeta.Annotations)
+}
+
+func TestFleetRolloutDrainDeadline(t *testing.T) {
+	start := metav1.NewTime(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC))
+	f := &Fleet{}
+	f.ApplyDefaults()
+	f.Spec.Rollout.DrainTimeoutSeconds = 60
+	assert.Nil(t, f.RolloutDrainDeadline(start))
+
+	f.Spec.Rollout.Mode = FleetRolloutDrainAllocated
+	deadline := f.RolloutDrainDeadline(start)
+	if assert.NotNil(t, deadline) {
+		assert.Equal(t, start.Add(time.Minute), deadline.Time)
+	}
+
+	f.Spec.Rollout.DrainTimeoutSeconds = 0
+	assert.Nil(t, f.RolloutDrainDeadline(start))
+}
+
 func TestSumStatusAllocatedReplicas(t *testing.T) {
 	f := Fleet{}
 	gsSet1 := f.GameServerSet()
@@ -18,6 +18,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"net"
+	"strings"
+	"time"
 
 	"github.com/mattbaird/jsonpatch"
 
@@ -28,6 +30,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -61,7 +65,8 @@ const (
 	GameServerStateAllocated GameServerState = "Allocated"
 
 	// Static PortPolicy means that the user defines the hostPort to be used
-	// in the configuration.
+	// in the configuration. It can not be in the port range of Dynamic ports. Conflicts with
+	// the ports of other GameServers are only rejected for GameServers pinned to a node.
 	Static PortPolicy = "Static"
 	// Dynamic PortPolicy means that the system will choose an open
 	// port for the GameServer in question
@@ -70,6 +75,23 @@ const (
 	// This will mean that users will need to lookup what port has been opened through the server side SDK.
 	Passthrough PortPolicy = "Passthrough"
 
+	// GameServerConditionSDKConnected is the condition of whether the game server is connected to the SDK Server.
+	// It is only set when Health.DisconnectGracePeriodSeconds is set.
+	GameServerConditionSDKConnected GameServerConditionType = "SDKConnected"
+
+	// GameServerConditionReady is the condition of whether the game server has called SDK.Ready().
+	// Its LastTransitionTime is when the game server first moved to Ready.
+	GameServerConditionReady GameServerConditionType = "Ready"
+
+	// GameServerConditionSDKRequestRejected is set to True when the SDK server rejects a state change requested by
+	// the game server, e.g. Ready() after Shutdown(). Its Reason is the reason the request was rejected with.
+	GameServerConditionSDKRequestRejected GameServerConditionType = "SDKRequestRejected"
+
+	// SessionExpiryShutdown moves an Allocated GameServer to Shutdown once its session reaches Session.MaxDuration
+	SessionExpiryShutdown SessionExpiryPolicy = "Shutdown"
+	// SessionExpiryReady moves an Allocated GameServer back to Ready once its session reaches Session.MaxDuration
+	SessionExpiryReady SessionExpiryPolicy = "Ready"
+
 	// SdkServerLogLevelInfo will cause the SDK server to output all messages except for debug messages.
 	SdkServerLogLevelInfo SdkServerLogLevel = "Info"
 	// SdkServerLogLevelDebug will cause the SDK server to output all messages including debug messages.
@@ -77,6 +99,10 @@ const (
 	// SdkServerLogLevelError will cause the SDK server to only output error messages.
 	SdkServerLogLevelError SdkServerLogLevel = "Error"
 
+	// NodePodIP is the type of the address in GameServerStatus.Addresses that is the IP of the Pod, for GameServers
+	// that are reached on the IP of their Pod rather than on the address of their node
+	NodePodIP corev1.NodeAddressType = "PodIP"
+
 	// RoleLabel is the label in which the Agones role is specified.
 	// Pods from a GameServer will have the value "gameserver"
 	RoleLabel = agones.GroupName + "/role"
@@ -91,6 +117,27 @@ const (
 	// DevAddressAnnotation is an annotation to indicate that a GameServer hosted outside of Agones.
 	// A locally hosted GameServer is not managed by Agones it is just simply registered.
 	DevAddressAnnotation = "agones.dev/dev-address"
+	// AllocationAnnotation is the annotation that stores a unique identifier of the current allocation of a GameServer,
+	// when the GameServerAllocation waits for the allocation to be acknowledged
+	AllocationAnnotation = agones.GroupName + "/allocation"
+	// AllocationAcknowledgedAnnotation is the annotation that the game server sets through the SDK to the value of
+	// AllocationAnnotation, to acknowledge that it is ready for players of the allocation
+	AllocationAcknowledgedAnnotation = agones.GroupName + "/sdk-allocation-acknowledged"
+	// PreemptionDeadlineAnnotation is the annotation that is set on an Allocated GameServer whose node is about to be
+	// preempted, such as a spot VM, to the RFC3339 time it is preempted at. It can also be set on the node, by whatever
+	// surfaces its preemption notice, so that the deadline is exact.
+	PreemptionDeadlineAnnotation = agones.GroupName + "/preemption-deadline"
+	// SafeToEvictAnnotation is the annotation that tells the cluster autoscaler whether it may evict a Pod
+	// to remove its node
+	SafeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+	// DisruptionProtectedLabel is the label that is set to "true" on the Pods of Allocated GameServers that the
+	// PodDisruptionBudget of their namespace protects from voluntary disruptions, such as node upgrades
+	DisruptionProtectedLabel = agones.GroupName + "/disruption-protected"
+	// DisruptionProtectedSinceAnnotation is the annotation that is set on the Pod of an Allocated GameServer to the
+	// RFC3339 time it was first protected from voluntary disruptions at, during its current allocation
+	DisruptionProtectedSinceAnnotation = agones.GroupName + "/disruption-protected-since"
+	// ClaimTokenLabel is the label that is set on a GameServer reserved by a pre-allocation to its claim token
+	ClaimTokenLabel = agones.GroupName + "/claim-token"
 )
 
 var (
@@ -144,6 +191,15 @@ type GameServerSpec struct {
 	Scheduling apis.SchedulingStrategy `json:"scheduling,omitempty"`
 	// SdkServer specifies parameters for the Agones SDK Server sidecar container
 	SdkServer SdkServer `json:"sdkServer,omitempty"`
+	// ReadyOnPodReady moves the GameServer to Ready once its Pod is Ready, rather than waiting
+	// for SDK.Ready(), for game server binaries without SDK integration. Defaults to false
+	ReadyOnPodReady bool `json:"readyOnPodReady,omitempty"`
+	// Session caps how long the GameServer can stay Allocated
+	Session Session `json:"session,omitempty"`
+	// ServiceType, if set to NodePort or LoadBalancer, exposes the ports of the GameServer through a Service of that
+	// type rather than through host ports, for clusters that do not let Pods use host ports
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
 	// Template describes the Pod that will be created for the GameServer
 	Template corev1.PodTemplateSpec `json:"template"`
 }
@@ -164,6 +220,22 @@ type Health struct {
 	FailureThreshold int32 `json:"failureThreshold,omitempty"`
 	// InitialDelaySeconds initial delay before checking health
 	InitialDelaySeconds int32 `json:"initialDelaySeconds,omitempty"`
+	// DisconnectGracePeriodSeconds is how long the SDK connection of the game server can stay closed before the
+	// SDKConnected condition is set to False, and the GameServer is no longer allocated. Disabled if 0 (the default)
+	DisconnectGracePeriodSeconds int32 `json:"disconnectGracePeriodSeconds,omitempty"`
+}
+
+// SessionExpiryPolicy is what happens to an Allocated GameServer once its session reaches Session.MaxDuration
+type SessionExpiryPolicy string
+
+// Session caps how long a GameServer can stay Allocated, so that game sessions that never end, such as because
+// of a bug in a game client, do not hold on to capacity forever
+type Session struct {
+	// MaxDuration is how long the GameServer can stay Allocated, from when it was allocated. No cap if not set
+	MaxDuration *metav1.Duration `json:"maxDuration,omitempty"`
+	// ExpiryPolicy is whether the GameServer is moved to Shutdown or back to Ready once it reaches MaxDuration.
+	// Defaults to "Shutdown"
+	ExpiryPolicy SessionExpiryPolicy `json:"expiryPolicy,omitempty"`
 }
 
 // GameServerPort defines a set of Ports that
@@ -196,16 +268,48 @@ type SdkServer struct {
 	GRPCPort int32 `json:"grpcPort,omitempty"`
 	// HTTPPort is the port on which the SDK Server binds the HTTP gRPC gateway server to accept incoming connections
 	HTTPPort int32 `json:"httpPort,omitempty"`
+	// Disabled if true, the SDK Server sidecar is not added to the Pod, for GameServers that do not use the SDK.
+	// Health checking must then be disabled, and the GameServer moved to Ready with ReadyOnPodReady, or through the API.
+	Disabled bool `json:"disabled,omitempty"`
+	// LogTail if true, the SDK Server serves the tail of the log of the game server container on /logs of its
+	// health port, so it can be read through the Kubernetes API server proxy without access to the Pod logs
+	LogTail bool `json:"logTail,omitempty"`
 }
 
 // GameServerStatus is the status for a GameServer resource
 type GameServerStatus struct {
 	// GameServerState is the current state of a GameServer, e.g. Creating, Starting, Ready, etc
-	State         GameServerState        `json:"state"`
-	Ports         []GameServerStatusPort `json:"ports"`
-	Address       string                 `json:"address"`
-	NodeName      string                 `json:"nodeName"`
-	ReservedUntil *metav1.Time           `json:"reservedUntil"`
+	State   GameServerState        `json:"state"`
+	Ports   []GameServerStatusPort `json:"ports"`
+	Address string                 `json:"address"`
+	// Addresses are all the addresses the GameServer can be reached on, IPv4 and IPv6, with their types,
+	// e.g. ExternalIP, so that game clients can pick the one they can connect to. Address is one of them.
+	Addresses     []corev1.NodeAddress `json:"addresses,omitempty"`
+	NodeName      string               `json:"nodeName"`
+	ReservedUntil *metav1.Time         `json:"reservedUntil"`
+	// AllocatedUntil is when an Allocated GameServer reaches the Session.MaxDuration of its spec
+	AllocatedUntil *metav1.Time `json:"allocatedUntil,omitempty"`
+	// Reason is why the GameServer is in the Error state, e.g. PodInvalid
+	Reason apis.Reason `json:"reason,omitempty"`
+	// Conditions are the latest observations of the GameServer, e.g. SDKConnected
+	Conditions []GameServerCondition `json:"conditions,omitempty"`
+}
+
+// GameServerConditionType is the type of a GameServerCondition
+type GameServerConditionType string
+
+// GameServerCondition is an observation of the GameServer at a point in time
+type GameServerCondition struct {
+	// Type of the condition, e.g. SDKConnected
+	Type GameServerConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// LastTransitionTime is when the condition last changed Status
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a one word reason for the last transition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message about the last transition
+	Message string `json:"message,omitempty"`
 }
 
 // GameServerStatusPort shows the port that was allocated to a
@@ -236,6 +340,14 @@ func (gss *GameServerSpec) ApplyDefaults() {
 	gss.applyHealthDefaults()
 	gss.applySchedulingDefaults()
 	gss.applySdkServerDefaults()
+	gss.applySessionDefaults()
+}
+
+// applySessionDefaults applies the default expiry policy ("Shutdown") when the session has a MaxDuration
+func (gss *GameServerSpec) applySessionDefaults() {
+	if gss.Session.MaxDuration != nil && gss.Session.ExpiryPolicy == "" {
+		gss.Session.ExpiryPolicy = SessionExpiryShutdown
+	}
 }
 
 // applySdkServerDefaults applies the default log level ("Info") for the sidecar
@@ -286,7 +398,8 @@ func (gs *GameServer) applyStateDefaults() {
 	}
 }
 
-// applyPortDefaults applies default values for all ports
+// applyPortDefaults applies default values for all ports.
+// When the Pod uses the host network, the ContainerPort of a Static port defaults to its HostPort, as they must be the same.
 func (gss *GameServerSpec) applyPortDefaults() {
 	for i, p := range gss.Ports {
 		// basic spec
@@ -297,6 +410,10 @@ func (gss *GameServerSpec) applyPortDefaults() {
 		if p.Protocol == "" {
 			gss.Ports[i].Protocol = "UDP"
 		}
+
+		if gss.Template.Spec.HostNetwork && gss.Ports[i].PortPolicy == Static && p.ContainerPort == 0 {
+			gss.Ports[i].ContainerPort = p.HostPort
+		}
 	}
 }
 
@@ -350,7 +467,16 @@ func (gss GameServerSpec) Validate(devAddress string) ([]metav1.StatusCause, boo
 
 		// no host port when using dynamic PortPolicy
 		for _, p := range gss.Ports {
-			if p.PortPolicy == Dynamic || p.PortPolicy == Static {
+			// with the host network, the ContainerPort of a Dynamic port is set to the allocated HostPort
+			if p.PortPolicy == Dynamic && gss.Template.Spec.HostNetwork {
+				if p.ContainerPort > 0 {
+					causes = append(causes, metav1.StatusCause{
+						Type:    metav1.CauseTypeFieldValueInvalid,
+						Field:   fmt.Sprintf("%s.containerPort", p.Name),
+						Message: ErrHostNetworkDynamic,
+					})
+				}
+			} else if p.PortPolicy == Dynamic || p.PortPolicy == Static {
 				if p.ContainerPort <= 0 {
 					causes = append(causes, metav1.StatusCause{
 						Type:    metav1.CauseTypeFieldValueInvalid,
@@ -368,6 +494,14 @@ func (gss GameServerSpec) Validate(devAddress string) ([]metav1.StatusCause, boo
 				})
 			}
 
+			if p.PortPolicy == Static && gss.Template.Spec.HostNetwork && p.ContainerPort > 0 && p.ContainerPort != p.HostPort {
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldValueInvalid,
+					Field:   fmt.Sprintf("%s.containerPort", p.Name),
+					Message: ErrHostNetworkContainerPort,
+				})
+			}
+
 			if p.HostPort > 0 && (p.PortPolicy == Dynamic || p.PortPolicy == Passthrough) {
 				causes = append(causes, metav1.StatusCause{
 					Type:    metav1.CauseTypeFieldValueInvalid,
@@ -386,16 +520,168 @@ func (gss GameServerSpec) Validate(devAddress string) ([]metav1.StatusCause, boo
 				Message: err.Error(),
 			})
 		}
+
+		if gss.ReadyOnPodReady && !gss.Health.Disabled {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "health.disabled",
+				Message: ErrReadyOnPodReadyHealth,
+			})
+		}
+
+		if gss.SdkServer.Disabled && !gss.Health.Disabled {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "health.disabled",
+				Message: ErrSdkServerDisabledHealth,
+			})
+		}
+
+		if gss.SdkServer.Disabled && gss.SdkServer.LogTail {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "sdkServer.logTail",
+				Message: ErrSdkServerDisabledLogTail,
+			})
+		}
+
+		if gss.Health.DisconnectGracePeriodSeconds < 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "health.disconnectGracePeriodSeconds",
+				Message: ErrDisconnectGracePeriodNegative,
+			})
+		} else if gss.Health.DisconnectGracePeriodSeconds > 0 && (gss.ReadyOnPodReady || gss.SdkServer.Disabled) {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "health.disconnectGracePeriodSeconds",
+				Message: ErrDisconnectGracePeriodNoSdk,
+			})
+		}
+	}
+
+	causes = append(causes, gss.Session.validate()...)
+	causes = append(causes, validateHostAliases(gss.Template.Spec.HostAliases)...)
+	if devAddress == "" {
+		causes = append(causes, gss.validateServiceType()...)
 	}
 	return causes, len(causes) == 0
 
 }
 
+// validateServiceType returns the causes if the ServiceType is not NodePort or LoadBalancer, or if the ports
+// cannot be exposed through a Service of that type
+func (gss *GameServerSpec) validateServiceType() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	switch gss.ServiceType {
+	case "":
+		return nil
+	case corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer:
+	default:
+		return append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "serviceType",
+			Message: ErrServiceType,
+		})
+	}
+
+	if gss.Template.Spec.HostNetwork {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "serviceType",
+			Message: ErrServiceTypeHostNetwork,
+		})
+	}
+	for _, p := range gss.Ports {
+		if p.PortPolicy != Dynamic {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   fmt.Sprintf("%s.portPolicy", p.Name),
+				Message: ErrServiceTypePortPolicy,
+			})
+		}
+		// LoadBalancer Services with more than one protocol are not supported
+		if gss.ServiceType == corev1.ServiceTypeLoadBalancer && p.Protocol != gss.Ports[0].Protocol {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   fmt.Sprintf("%s.protocol", p.Name),
+				Message: ErrServiceTypeProtocols,
+			})
+		}
+	}
+	return causes
+}
+
+// validate returns the causes if the MaxDuration of the Session is not positive, or its ExpiryPolicy is unknown
+func (s *Session) validate() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if s.MaxDuration != nil && s.MaxDuration.Duration <= 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "session.maxDuration",
+			Message: ErrSessionMaxDuration,
+		})
+	}
+	switch s.ExpiryPolicy {
+	case "", SessionExpiryShutdown, SessionExpiryReady:
+	default:
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "session.expiryPolicy",
+			Message: ErrSessionExpiryPolicy,
+		})
+	}
+	return causes
+}
+
+// validateHostAliases validates the entries that are added to /etc/hosts of the Pod, so
+// that a GameServer with invalid ones is rejected, rather than failing to create its Pod
+func validateHostAliases(aliases []corev1.HostAlias) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	for i, alias := range aliases {
+		field := fmt.Sprintf("template.spec.hostAliases[%d]", i)
+		if net.ParseIP(alias.IP) == nil {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".ip",
+				Message: ErrHostAliasIP,
+			})
+		}
+		if len(alias.Hostnames) == 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueRequired,
+				Field:   field + ".hostnames",
+				Message: ErrHostAliasHostnames,
+			})
+		}
+		for j, hostname := range alias.Hostnames {
+			if errs := validation.IsDNS1123Subdomain(hostname); len(errs) > 0 {
+				causes = append(causes, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldValueInvalid,
+					Field:   fmt.Sprintf("%s.hostnames[%d]", field, j),
+					Message: strings.Join(errs, ", "),
+				})
+			}
+		}
+	}
+	return causes
+}
+
 // Validate validates the GameServer configuration.
 // If a GameServer is invalid there will be > 0 values in
 // the returned array
 func (gs *GameServer) Validate() ([]metav1.StatusCause, bool) {
 	causes := validateName(gs)
+	// the Service that exposes the ports is named after the GameServer
+	if gs.Spec.ServiceType != "" && gs.ObjectMeta.Name != "" {
+		if errs := validation.IsDNS1035Label(gs.ObjectMeta.Name); len(errs) > 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "Name",
+				Message: fmt.Sprintf("GameServer name must be a valid Service name when the serviceType is set: %s", strings.Join(errs, ", ")),
+			})
+		}
+	}
 
 	// make sure the host port is specified if this is a development server
 	devAddress, _ := gs.GetDevAddress()
@@ -425,6 +711,77 @@ func (gs *GameServer) IsBeingDeleted() bool {
 	return !gs.ObjectMeta.DeletionTimestamp.IsZero() || gs.Status.State == GameServerStateShutdown
 }
 
+// StartSession sets when the session of a GameServer that is being moved to Allocated reaches the
+// MaxDuration of its Session, if it has one
+func (gs *GameServer) StartSession(now time.Time) {
+	gs.Status.AllocatedUntil = nil
+	if gs.Spec.Session.MaxDuration != nil {
+		until := metav1.NewTime(now.Add(gs.Spec.Session.MaxDuration.Duration))
+		gs.Status.AllocatedUntil = &until
+	}
+}
+
+// IsSDKDisconnected returns true if the SDKConnected condition of the GameServer is False, as the SDK
+// connection of the game server was closed for longer than Health.DisconnectGracePeriodSeconds
+func (gs *GameServer) IsSDKDisconnected() bool {
+	c, ok := gs.Status.GetCondition(GameServerConditionSDKConnected)
+	return ok && c.Status == corev1.ConditionFalse
+}
+
+// GetCondition returns the condition of the type, and false if it is not set
+func (gss *GameServerStatus) GetCondition(t GameServerConditionType) (GameServerCondition, bool) {
+	i, ok := apis.GetCondition(gameServerConditions{status: gss}, string(t))
+	if !ok {
+		return GameServerCondition{}, false
+	}
+	return gss.Conditions[i], true
+}
+
+// SetCondition sets the condition, replacing the condition of the same type, and returns false if nothing changed.
+// The LastTransitionTime is kept if the Status of the condition did not change.
+func (gss *GameServerStatus) SetCondition(condition GameServerCondition) bool {
+	return apis.SetCondition(gameServerConditions{status: gss}, condition.condition())
+}
+
+// condition converts the GameServerCondition to the apis.Condition that is shared between resources
+func (c GameServerCondition) condition() apis.Condition {
+	return apis.Condition{
+		Type:               string(c.Type),
+		Status:             c.Status,
+		LastTransitionTime: c.LastTransitionTime,
+		Reason:             c.Reason,
+		Message:            c.Message,
+	}
+}
+
+// gameServerConditions adapts the conditions of a GameServerStatus to apis.Conditions
+type gameServerConditions struct {
+	status *GameServerStatus
+}
+
+func (c gameServerConditions) Len() int {
+	return len(c.status.Conditions)
+}
+
+func (c gameServerConditions) At(i int) apis.Condition {
+	return c.status.Conditions[i].condition()
+}
+
+func (c gameServerConditions) Set(i int, condition apis.Condition) {
+	converted := GameServerCondition{
+		Type:               GameServerConditionType(condition.Type),
+		Status:             condition.Status,
+		LastTransitionTime: condition.LastTransitionTime,
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+	}
+	if i == len(c.status.Conditions) {
+		c.status.Conditions = append(c.status.Conditions, converted)
+		return
+	}
+	c.status.Conditions[i] = converted
+}
+
 // FindGameServerContainer returns the container that is specified in
 // gameServer.Spec.Container. Returns the index and the value.
 // Returns an error if not found
@@ -458,6 +815,42 @@ func (gs *GameServer) ApplyToPodGameServerContainer(pod *corev1.Pod, f func(core
 	return pod
 }
 
+// ServicePortName returns the name of the port of the Service of the GameServer for the port at index i of its Spec
+func ServicePortName(i int) string {
+	return fmt.Sprintf("port-%d", i)
+}
+
+// Service returns the Service that exposes the ports of the GameServer, which selects its Pod,
+// or nil if the GameServer does not have a ServiceType
+func (gs *GameServer) Service() *corev1.Service {
+	if gs.Spec.ServiceType == "" {
+		return nil
+	}
+
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gs.ObjectMeta.Name,
+			Namespace: gs.ObjectMeta.Namespace,
+			Labels:    map[string]string{GameServerPodLabel: gs.ObjectMeta.Name},
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     gs.Spec.ServiceType,
+			Selector: map[string]string{GameServerPodLabel: gs.ObjectMeta.Name},
+		},
+	}
+	for i, p := range gs.Spec.Ports {
+		svc.Spec.Ports = append(svc.Spec.Ports, corev1.ServicePort{
+			Name:       ServicePortName(i),
+			Protocol:   p.Protocol,
+			Port:       p.ContainerPort,
+			TargetPort: intstr.FromInt(int(p.ContainerPort)),
+		})
+	}
+	ref := metav1.NewControllerRef(gs, SchemeGroupVersion.WithKind("GameServer"))
+	svc.ObjectMeta.OwnerReferences = append(svc.ObjectMeta.OwnerReferences, *ref)
+	return svc
+}
+
 // Pod creates a new Pod from the PodTemplateSpec
 // attached to the GameServer resource
 func (gs *GameServer) Pod(sidecars ...corev1.Container) (*corev1.Pod, error) {
@@ -511,6 +904,13 @@ func (gs *GameServer) podObjectMeta(pod *corev1.Pod) {
 	pod.ObjectMeta.Labels[RoleLabel] = GameServerLabelRole
 	// store the GameServer name as a label, for easy lookup later on
 	pod.ObjectMeta.Labels[GameServerPodLabel] = gs.ObjectMeta.Name
+	// the Fleet and GameServerSet labels let the label selector of their scale subresource, used by the
+	// HorizontalPodAutoscaler, select the Pods
+	for _, l := range []string{FleetNameLabel, GameServerSetGameServerLabel} {
+		if v, ok := gs.ObjectMeta.Labels[l]; ok {
+			pod.ObjectMeta.Labels[l] = v
+		}
+	}
 	// store the GameServer container as an annotation, to make lookup at a Pod level easier
 	pod.ObjectMeta.Annotations[GameServerContainerAnnotation] = gs.Spec.Container
 	ref := metav1.NewControllerRef(gs, SchemeGroupVersion.WithKind("GameServer"))
@@ -519,7 +919,7 @@ func (gs *GameServer) podObjectMeta(pod *corev1.Pod) {
 	if gs.Spec.Scheduling == apis.Packed {
 		// This means that the autoscaler cannot remove the Node that this Pod is on.
 		// (and evict the Pod in the process)
-		pod.ObjectMeta.Annotations["cluster-autoscaler.kubernetes.io/safe-to-evict"] = "false"
+		pod.ObjectMeta.Annotations[SafeToEvictAnnotation] = "false"
 	}
 
 	// Add Agones version into Pod Annotations
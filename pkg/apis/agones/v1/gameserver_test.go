@@ -17,6 +17,7 @@ package v1
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"agones.dev/agones/pkg"
 	"agones.dev/agones/pkg/apis"
@@ -439,6 +440,236 @@ func TestGameServerValidate(t *testing.T) {
 	assert.Len(t, causes, 2)
 	assert.Contains(t, fields, "one.containerPort")
 	assert.Contains(t, fields, "two.hostPort")
+
+	gs = GameServer{
+		Spec: GameServerSpec{
+			ReadyOnPodReady: true,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "testing", Image: "testing/image"}}}},
+		},
+	}
+	gs.ApplyDefaults()
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "health.disabled", causes[0].Field)
+
+	gs.Spec.Health.Disabled = true
+	causes, ok = gs.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
+
+	gs = GameServer{
+		Spec: GameServerSpec{
+			SdkServer: SdkServer{Disabled: true},
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "testing", Image: "testing/image"}}}},
+		},
+	}
+	gs.ApplyDefaults()
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "health.disabled", causes[0].Field)
+	assert.Equal(t, ErrSdkServerDisabledHealth, causes[0].Message)
+
+	gs.Spec.Health.Disabled = true
+	causes, ok = gs.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
+
+	gs.Spec.SdkServer.LogTail = true
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "sdkServer.logTail", causes[0].Field)
+	assert.Equal(t, ErrSdkServerDisabledLogTail, causes[0].Message)
+
+	gs.Spec.SdkServer.LogTail = false
+	gs.Spec.Health.DisconnectGracePeriodSeconds = 10
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "health.disconnectGracePeriodSeconds", causes[0].Field)
+	assert.Equal(t, ErrDisconnectGracePeriodNoSdk, causes[0].Message)
+
+	gs.Spec.SdkServer.Disabled = false
+	causes, ok = gs.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
+
+	gs.Spec.Health.DisconnectGracePeriodSeconds = -1
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, ErrDisconnectGracePeriodNegative, causes[0].Message)
+
+	gs.Spec.Health.DisconnectGracePeriodSeconds = 0
+	gs.Spec.Template.Spec.HostAliases = []corev1.HostAlias{{IP: "10.0.0.1", Hostnames: []string{"master.example.com", "master"}}}
+	causes, ok = gs.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
+
+	gs.Spec.Template.Spec.HostAliases = []corev1.HostAlias{
+		{IP: "10.0.0.1", Hostnames: []string{"master.example.com"}},
+		{IP: "not-an-ip", Hostnames: []string{"Master_Server"}},
+		{IP: "::1"},
+	}
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 3) {
+		assert.Equal(t, "template.spec.hostAliases[1].ip", causes[0].Field)
+		assert.Equal(t, ErrHostAliasIP, causes[0].Message)
+		assert.Equal(t, "template.spec.hostAliases[1].hostnames[0]", causes[1].Field)
+		assert.Equal(t, "template.spec.hostAliases[2].hostnames", causes[2].Field)
+		assert.Equal(t, ErrHostAliasHostnames, causes[2].Message)
+	}
+
+	gs.Spec.Template.Spec.HostAliases = nil
+	gs.Spec.Session = Session{MaxDuration: &metav1.Duration{Duration: time.Hour}, ExpiryPolicy: SessionExpiryReady}
+	causes, ok = gs.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
+
+	gs.Spec.Session = Session{MaxDuration: &metav1.Duration{}, ExpiryPolicy: "Delete"}
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 2) {
+		assert.Equal(t, "session.maxDuration", causes[0].Field)
+		assert.Equal(t, ErrSessionMaxDuration, causes[0].Message)
+		assert.Equal(t, "session.expiryPolicy", causes[1].Field)
+		assert.Equal(t, ErrSessionExpiryPolicy, causes[1].Message)
+	}
+}
+
+func TestGameServerHostNetwork(t *testing.T) {
+	t.Parallel()
+
+	fixture := func(ports ...GameServerPort) *GameServer {
+		return &GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test"},
+			Spec: GameServerSpec{
+				Ports: ports,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{HostNetwork: true, Containers: []corev1.Container{
+						{Name: "testing", Image: "testing/image"},
+					}}}}}
+	}
+
+	// the container port of a static port defaults to its host port
+	gs := fixture(GameServerPort{Name: "static", PortPolicy: Static, HostPort: 9999})
+	gs.ApplyDefaults()
+	assert.Equal(t, int32(9999), gs.Spec.Ports[0].ContainerPort)
+	causes, ok := gs.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
+
+	gs = fixture(GameServerPort{Name: "static", PortPolicy: Static, HostPort: 9999, ContainerPort: 7777})
+	gs.ApplyDefaults()
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "static.containerPort", causes[0].Field)
+		assert.Equal(t, ErrHostNetworkContainerPort, causes[0].Message)
+	}
+
+	// dynamic ports get the allocated host port as their container port
+	gs = fixture(GameServerPort{Name: "dynamic"}, GameServerPort{Name: "passthrough", PortPolicy: Passthrough})
+	gs.ApplyDefaults()
+	causes, ok = gs.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
+
+	gs = fixture(GameServerPort{Name: "dynamic", ContainerPort: 7777})
+	gs.ApplyDefaults()
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "dynamic.containerPort", causes[0].Field)
+		assert.Equal(t, ErrHostNetworkDynamic, causes[0].Message)
+	}
+}
+
+func TestGameServerServiceType(t *testing.T) {
+	t.Parallel()
+
+	fixture := func(serviceType corev1.ServiceType, ports ...GameServerPort) *GameServer {
+		gs := &GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "1234"},
+			Spec: GameServerSpec{
+				ServiceType: serviceType,
+				Ports:       ports,
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{
+						{Name: "testing", Image: "testing/image"},
+					}}}}}
+		gs.ApplyDefaults()
+		return gs
+	}
+
+	gs := fixture("", GameServerPort{Name: "gameport", ContainerPort: 7777})
+	assert.Nil(t, gs.Service())
+
+	gs = fixture(corev1.ServiceTypeNodePort, GameServerPort{Name: "gameport", ContainerPort: 7777},
+		GameServerPort{Name: "query", ContainerPort: 7778, Protocol: corev1.ProtocolTCP})
+	causes, ok := gs.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
+	svc := gs.Service()
+	if assert.NotNil(t, svc) {
+		assert.Equal(t, "test", svc.ObjectMeta.Name)
+		assert.Equal(t, "default", svc.ObjectMeta.Namespace)
+		assert.True(t, metav1.IsControlledBy(svc, gs))
+		assert.Equal(t, corev1.ServiceTypeNodePort, svc.Spec.Type)
+		assert.Equal(t, map[string]string{GameServerPodLabel: "test"}, svc.Spec.Selector)
+		if assert.Len(t, svc.Spec.Ports, 2) {
+			assert.Equal(t, ServicePortName(0), svc.Spec.Ports[0].Name)
+			assert.Equal(t, corev1.ProtocolUDP, svc.Spec.Ports[0].Protocol)
+			assert.Equal(t, int32(7777), svc.Spec.Ports[0].Port)
+			assert.Equal(t, 7777, svc.Spec.Ports[0].TargetPort.IntValue())
+			assert.Equal(t, corev1.ProtocolTCP, svc.Spec.Ports[1].Protocol)
+		}
+	}
+
+	gs = fixture(corev1.ServiceTypeClusterIP, GameServerPort{Name: "gameport", ContainerPort: 7777})
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "serviceType", causes[0].Field)
+		assert.Equal(t, ErrServiceType, causes[0].Message)
+	}
+
+	gs = fixture(corev1.ServiceTypeNodePort, GameServerPort{Name: "static", PortPolicy: Static, HostPort: 9999, ContainerPort: 7777})
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "static.portPolicy", causes[0].Field)
+		assert.Equal(t, ErrServiceTypePortPolicy, causes[0].Message)
+	}
+
+	gs = fixture(corev1.ServiceTypeLoadBalancer, GameServerPort{Name: "gameport", ContainerPort: 7777},
+		GameServerPort{Name: "query", ContainerPort: 7778, Protocol: corev1.ProtocolTCP})
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "query.protocol", causes[0].Field)
+		assert.Equal(t, ErrServiceTypeProtocols, causes[0].Message)
+	}
+
+	gs = fixture(corev1.ServiceTypeNodePort, GameServerPort{Name: "gameport"})
+	gs.Spec.Template.Spec.HostNetwork = true
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, ErrServiceTypeHostNetwork, causes[0].Message)
+	}
+
+	// the name of the GameServer is the name of its Service
+	gs = fixture(corev1.ServiceTypeNodePort, GameServerPort{Name: "gameport", ContainerPort: 7777})
+	gs.ObjectMeta.Name = "1.test"
+	causes, ok = gs.Validate()
+	assert.False(t, ok)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "Name", causes[0].Field)
+	}
 }
 
 func TestGameServerPod(t *testing.T) {
@@ -505,6 +736,18 @@ func TestGameServerPodObjectMeta(t *testing.T) {
 
 		assert.Equal(t, "", pod.ObjectMeta.Annotations["cluster-autoscaler.kubernetes.io/safe-to-evict"])
 	})
+
+	t.Run("fleet and gameserverset labels", func(t *testing.T) {
+		gs := fixture.DeepCopy()
+		gs.ObjectMeta.Labels = map[string]string{FleetNameLabel: "fleet", GameServerSetGameServerLabel: "fleet-abcde"}
+		pod := &corev1.Pod{}
+
+		gs.podObjectMeta(pod)
+		f(t, gs, pod)
+
+		assert.Equal(t, "fleet", pod.ObjectMeta.Labels[FleetNameLabel])
+		assert.Equal(t, "fleet-abcde", pod.ObjectMeta.Labels[GameServerSetGameServerLabel])
+	})
 }
 
 func TestGameServerPodScheduling(t *testing.T) {
@@ -629,6 +872,41 @@ func TestGameServerIsDeletable(t *testing.T) {
 	assert.True(t, gs.IsDeletable())
 }
 
+func TestGameServerStartSession(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	gs := &GameServer{Spec: GameServerSpec{Session: Session{MaxDuration: &metav1.Duration{Duration: time.Hour}}}}
+	gs.Spec.ApplyDefaults()
+	assert.Equal(t, SessionExpiryShutdown, gs.Spec.Session.ExpiryPolicy)
+
+	gs.StartSession(now)
+	if assert.NotNil(t, gs.Status.AllocatedUntil) {
+		assert.Equal(t, metav1.NewTime(now.Add(time.Hour)), *gs.Status.AllocatedUntil)
+	}
+
+	gs.Spec.Session = Session{}
+	gs.StartSession(now)
+	assert.Nil(t, gs.Status.AllocatedUntil)
+	gs.Spec.ApplyDefaults()
+	assert.Empty(t, gs.Spec.Session.ExpiryPolicy)
+}
+
+func TestGameServerStatusSetCondition(t *testing.T) {
+	t.Parallel()
+
+	gs := &GameServer{}
+	assert.False(t, gs.IsSDKDisconnected())
+
+	assert.True(t, gs.Status.SetCondition(GameServerCondition{Type: GameServerConditionSDKConnected, Status: corev1.ConditionTrue}))
+	assert.False(t, gs.IsSDKDisconnected())
+
+	now := metav1.Now()
+	assert.True(t, gs.Status.SetCondition(GameServerCondition{Type: GameServerConditionSDKConnected, Status: corev1.ConditionFalse, LastTransitionTime: now, Reason: "Disconnected", Message: "closed"}))
+	assert.True(t, gs.IsSDKDisconnected())
+	assert.Equal(t, []GameServerCondition{{Type: GameServerConditionSDKConnected, Status: corev1.ConditionFalse, LastTransitionTime: now, Reason: "Disconnected", Message: "closed"}}, gs.Status.Conditions)
+}
+
 func TestGameServerApplyToPodGameServerContainer(t *testing.T) {
 	t.Parallel()
 
@@ -20,6 +20,7 @@ import (
 	"agones.dev/agones/pkg/apis"
 	"agones.dev/agones/pkg/apis/agones"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const (
@@ -62,6 +63,8 @@ type GameServerSetSpec struct {
 	Scheduling apis.SchedulingStrategy `json:"scheduling,omitempty"`
 	// Template the GameServer template to apply for this GameServerSet
 	Template GameServerTemplateSpec `json:"template"`
+	// AllocationOverflow marks the Allocated GameServers over the Replicas with labels and annotations
+	AllocationOverflow *AllocationOverflow `json:"allocationOverflow,omitempty"`
 }
 
 // GameServerSetStatus is the status of a GameServerSet
@@ -76,6 +79,15 @@ type GameServerSetStatus struct {
 	AllocatedReplicas int32 `json:"allocatedReplicas"`
 	// ShutdownReplicas are the number of Shutdown GameServers replicas
 	ShutdownReplicas int32 `json:"shutdownReplicas"`
+	// ScheduledReplicas are the number of Scheduled GameServer replicas, whose Pod is running but not yet Ready
+	ScheduledReplicas int32 `json:"scheduledReplicas"`
+	// UnhealthyReplicas are the number of Unhealthy GameServer replicas, that have not been replaced yet
+	UnhealthyReplicas int32 `json:"unhealthyReplicas"`
+	// FailedReplicas are the total number of GameServers of the GameServerSet that were replaced
+	// after they moved to Unhealthy or Error
+	FailedReplicas int32 `json:"failedReplicas,omitempty"`
+	// LabelSelector selects the GameServers of the GameServerSet, for the scale subresource
+	LabelSelector string `json:"labelSelector,omitempty"`
 }
 
 // ValidateUpdate validates when updates occur. The argument
@@ -102,10 +114,18 @@ func (gsSet *GameServerSet) Validate() ([]metav1.StatusCause, bool) {
 	if len(gsCauses) > 0 {
 		causes = append(causes, gsCauses...)
 	}
+	if gsSet.Spec.AllocationOverflow != nil {
+		causes = append(causes, gsSet.Spec.AllocationOverflow.Validate("allocationOverflow")...)
+	}
 
 	return causes, len(causes) == 0
 }
 
+// LabelSelector returns the serialised label selector of the GameServers of the GameServerSet
+func (gsSet *GameServerSet) LabelSelector() string {
+	return labels.SelectorFromSet(labels.Set{GameServerSetGameServerLabel: gsSet.ObjectMeta.Name}).String()
+}
+
 // GetGameServerSpec get underlying Gameserver specification
 func (gsSet *GameServerSet) GetGameServerSpec() *GameServerSpec {
 	return &gsSet.Spec.Template.Spec
@@ -138,5 +158,8 @@ func (gsSet *GameServerSet) GameServer() *GameServer {
 
 	gs.ObjectMeta.Labels[GameServerSetGameServerLabel] = gsSet.ObjectMeta.Name
 	gs.ObjectMeta.Labels[FleetNameLabel] = gsSet.ObjectMeta.Labels[FleetNameLabel]
+	if name, ok := gsSet.ObjectMeta.Labels[FleetTemplateLabel]; ok {
+		gs.ObjectMeta.Labels[FleetTemplateLabel] = name
+	}
 	return gs
 }
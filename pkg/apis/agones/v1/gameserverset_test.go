@@ -57,6 +57,12 @@ func TestGameServerSetGameServer(t *testing.T) {
 
 	assert.Equal(t, gs.Spec, gsSet.Spec.Template.Spec)
 	assert.True(t, metav1.IsControlledBy(gs, &gsSet))
+	_, ok := gs.ObjectMeta.Labels[FleetTemplateLabel]
+	assert.False(t, ok)
+
+	gsSet.ObjectMeta.Labels[FleetTemplateLabel] = "large"
+	gs = gsSet.GameServer()
+	assert.Equal(t, "large", gs.ObjectMeta.Labels[FleetTemplateLabel])
 }
 
 // TestGameServerSetValidateUpdate test GameServerSet Validate() and ValidateUpdate()
@@ -21,16 +21,48 @@
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationOverflow) DeepCopyInto(out *AllocationOverflow) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationOverflow.
+func (in *AllocationOverflow) DeepCopy() *AllocationOverflow {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationOverflow)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Fleet) DeepCopyInto(out *Fleet) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -52,6 +84,39 @@ func (in *Fleet) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetCanary) DeepCopyInto(out *FleetCanary) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetCanary.
+func (in *FleetCanary) DeepCopy() *FleetCanary {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetCanary)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetCondition) DeepCopyInto(out *FleetCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetCondition.
+func (in *FleetCondition) DeepCopy() *FleetCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FleetList) DeepCopyInto(out *FleetList) {
 	*out = *in
@@ -85,11 +150,114 @@ func (in *FleetList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetMetrics) DeepCopyInto(out *FleetMetrics) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetMetrics.
+func (in *FleetMetrics) DeepCopy() *FleetMetrics {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetMetrics)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetRollout) DeepCopyInto(out *FleetRollout) {
+	*out = *in
+	if in.FailureThreshold != nil {
+		in, out := &in.FailureThreshold, &out.FailureThreshold
+		*out = new(FleetRolloutFailureThreshold)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetRollout.
+func (in *FleetRollout) DeepCopy() *FleetRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetRolloutFailureThreshold) DeepCopyInto(out *FleetRolloutFailureThreshold) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetRolloutFailureThreshold.
+func (in *FleetRolloutFailureThreshold) DeepCopy() *FleetRolloutFailureThreshold {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetRolloutFailureThreshold)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetRolloutStatus) DeepCopyInto(out *FleetRolloutStatus) {
+	*out = *in
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.OutdatedGameServerSets != nil {
+		in, out := &in.OutdatedGameServerSets, &out.OutdatedGameServerSets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DrainDeadline != nil {
+		in, out := &in.DrainDeadline, &out.DrainDeadline
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetRolloutStatus.
+func (in *FleetRolloutStatus) DeepCopy() *FleetRolloutStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetRolloutStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FleetSpec) DeepCopyInto(out *FleetSpec) {
 	*out = *in
 	in.Strategy.DeepCopyInto(&out.Strategy)
 	in.Template.DeepCopyInto(&out.Template)
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]FleetTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Rollout.DeepCopyInto(&out.Rollout)
+	out.Canary = in.Canary
+	if in.AllocationOverflow != nil {
+		in, out := &in.AllocationOverflow, &out.AllocationOverflow
+		*out = new(AllocationOverflow)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Metrics != nil {
+		in, out := &in.Metrics, &out.Metrics
+		*out = new(FleetMetrics)
+		**out = **in
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -106,6 +274,18 @@ func (in *FleetSpec) DeepCopy() *FleetSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *FleetStatus) DeepCopyInto(out *FleetStatus) {
 	*out = *in
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(FleetRolloutStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]FleetCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -119,6 +299,23 @@ func (in *FleetStatus) DeepCopy() *FleetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FleetTemplate) DeepCopyInto(out *FleetTemplate) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FleetTemplate.
+func (in *FleetTemplate) DeepCopy() *FleetTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(FleetTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GameServer) DeepCopyInto(out *GameServer) {
 	*out = *in
@@ -147,6 +344,23 @@ func (in *GameServer) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GameServerCondition) DeepCopyInto(out *GameServerCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GameServerCondition.
+func (in *GameServerCondition) DeepCopy() *GameServerCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(GameServerCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GameServerList) DeepCopyInto(out *GameServerList) {
 	*out = *in
@@ -261,6 +475,11 @@ func (in *GameServerSetList) DeepCopyObject() runtime.Object {
 func (in *GameServerSetSpec) DeepCopyInto(out *GameServerSetSpec) {
 	*out = *in
 	in.Template.DeepCopyInto(&out.Template)
+	if in.AllocationOverflow != nil {
+		in, out := &in.AllocationOverflow, &out.AllocationOverflow
+		*out = new(AllocationOverflow)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -299,6 +518,7 @@ func (in *GameServerSpec) DeepCopyInto(out *GameServerSpec) {
 		copy(*out, *in)
 	}
 	out.Health = in.Health
+	in.Session.DeepCopyInto(&out.Session)
 	in.Template.DeepCopyInto(&out.Template)
 	return
 }
@@ -321,10 +541,26 @@ func (in *GameServerStatus) DeepCopyInto(out *GameServerStatus) {
 		*out = make([]GameServerStatusPort, len(*in))
 		copy(*out, *in)
 	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]corev1.NodeAddress, len(*in))
+		copy(*out, *in)
+	}
 	if in.ReservedUntil != nil {
 		in, out := &in.ReservedUntil, &out.ReservedUntil
 		*out = (*in).DeepCopy()
 	}
+	if in.AllocatedUntil != nil {
+		in, out := &in.AllocatedUntil, &out.AllocatedUntil
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]GameServerCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -387,3 +623,24 @@ func (in *Health) DeepCopy() *Health {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Session) DeepCopyInto(out *Session) {
+	*out = *in
+	if in.MaxDuration != nil {
+		in, out := &in.MaxDuration, &out.MaxDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Session.
+func (in *Session) DeepCopy() *Session {
+	if in == nil {
+		return nil
+	}
+	out := new(Session)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -16,12 +16,16 @@ package v1
 
 import (
 	"fmt"
+	"strings"
 
 	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 const (
@@ -32,6 +36,35 @@ const (
 	// GameServerAllocationContention when the allocation is unsuccessful
 	// because of contention
 	GameServerAllocationContention GameServerAllocationState = "Contention"
+	// GameServerAllocationPreAllocated when GameServers were reserved for the claim token of a pre-allocation
+	GameServerAllocationPreAllocated GameServerAllocationState = "PreAllocated"
+
+	// LeastUptime scheduling strategy will prioritise allocating the GameServers that were created last
+	LeastUptime apis.SchedulingStrategy = "LeastUptime"
+	// MostUptime scheduling strategy will prioritise allocating the GameServers that were created first,
+	// which is useful to rotate GameServers out of a Fleet, e.g. for a rolling update
+	MostUptime apis.SchedulingStrategy = "MostUptime"
+
+	// MaxAcknowledgeTimeoutSeconds is the longest an allocation can wait for the game server to acknowledge it
+	MaxAcknowledgeTimeoutSeconds = 60
+	// MaxPreAllocateCount is the most GameServers that a single pre-allocation can reserve
+	MaxPreAllocateCount = 1000
+	// MaxRequestIDLength is the longest request ID that an allocation can have
+	MaxRequestIDLength = 128
+)
+
+// MetaPatchMergePolicy is how the MetaPatch of an allocation is merged with the metadata of the GameServer
+type MetaPatchMergePolicy string
+
+const (
+	// MetaPatchMerge overwrites the values of the labels and annotations the GameServer already has
+	MetaPatchMerge MetaPatchMergePolicy = "Merge"
+	// MetaPatchFailOnConflict fails the allocation if the GameServer already has one of the labels or
+	// annotations, with a different value
+	MetaPatchFailOnConflict MetaPatchMergePolicy = "FailOnConflict"
+	// MetaPatchAppendToList appends the values to the comma separated list of values of the labels and
+	// annotations the GameServer already has
+	MetaPatchAppendToList MetaPatchMergePolicy = "AppendToList"
 )
 
 // GameServerAllocationState is the Allocation state
@@ -69,29 +102,77 @@ type GameServerAllocationSpec struct {
 	// Required The required allocation. Defaults to all GameServers.
 	Required metav1.LabelSelector `json:"required,omitempty"`
 
+	// FleetName if set, only GameServers of the Fleet with this name are allocated.
+	// A shortcut for the Fleet name label in the required selector, which it is added to.
+	FleetName string `json:"fleetName,omitempty"`
+
+	// FallbackFleetNames are the Fleets whose GameServers are allocated, in order, if no GameServer of the Fleet
+	// of FleetName is Ready, e.g. region-eu-backup then region-us. They are tried within the same allocation.
+	// Requires FleetName.
+	FallbackFleetNames []string `json:"fallbackFleetNames,omitempty"`
+
 	// Preferred ordered list of preferred allocations out of the `required` set.
 	// If the first selector is not matched,
 	// the selection attempts the second selector, and so on.
 	Preferred []metav1.LabelSelector `json:"preferred,omitempty"`
 
-	// Scheduling strategy. Defaults to "Packed".
+	// Scheduling strategy. Defaults to "Packed". Can also be "Distributed", "LeastUptime", "MostUptime",
+	// or a custom strategy registered with the controller.
 	Scheduling apis.SchedulingStrategy `json:"scheduling"`
 
 	// MetaPatch is optional custom metadata that is added to the game server at allocation
 	// You can use this to tell the server necessary session data
 	MetaPatch MetaPatch `json:"metadata,omitempty"`
+
+	// IncludeGameServer if true, the complete allocated GameServer is returned in the status,
+	// so that its labels, annotations and spec can be read without a follow-up request.
+	IncludeGameServer bool `json:"includeGameServer,omitempty"`
+
+	// AcknowledgeTimeoutSeconds if set, the allocation is only returned once the game server has acknowledged it
+	// through the SDK, e.g. after it has loaded the map for the match. If the game server does not acknowledge it within
+	// this many seconds, the GameServer is moved to Unhealthy, and the allocation is UnAllocated.
+	// Must not be greater than MaxAcknowledgeTimeoutSeconds, as the allocation request is held open while waiting.
+	AcknowledgeTimeoutSeconds int32 `json:"acknowledgeTimeoutSeconds,omitempty"`
+
+	// PreAllocate if set, Count matching Ready GameServers are moved to Reserved for DurationSeconds instead of
+	// allocating one, and the claim token they are reserved for is returned in the status.
+	PreAllocate *PreAllocation `json:"preAllocate,omitempty"`
+
+	// ClaimToken if set, a GameServer that was pre-allocated with this claim token, and matches the required
+	// selector, is allocated instead of a Ready GameServer.
+	ClaimToken string `json:"claimToken,omitempty"`
+
+	// RequestID if set, identifies the allocation request of the client. A request with the same RequestID and the
+	// same spec, made while the first one is still in flight, e.g. when a client retries on a timeout, waits for it
+	// instead of allocating another GameServer, and gets the same GameServer.
+	RequestID string `json:"requestID,omitempty"`
+}
+
+// PreAllocation is how many GameServers a pre-allocation reserves, and for how long
+type PreAllocation struct {
+	// Count is the number of GameServers to reserve, at most MaxPreAllocateCount
+	Count int32 `json:"count"`
+	// DurationSeconds is how long the GameServers stay reserved for the claim token,
+	// after which the ones that were not claimed are moved back to Ready
+	DurationSeconds int32 `json:"durationSeconds"`
 }
 
 // MultiClusterSetting specifies settings for multi-cluster allocation.
 type MultiClusterSetting struct {
 	Enabled        bool                 `json:"enabled,omitempty"`
 	PolicySelector metav1.LabelSelector `json:"policySelector,omitempty"`
+	// PreferLocal if true, the local cluster is tried first, regardless of the priority of its policy,
+	// and remote clusters are only tried if there is no Ready GameServer in the local cluster.
+	PreferLocal bool `json:"preferLocal,omitempty"`
 }
 
 // MetaPatch is the metadata used to patch the GameServer metadata on allocation
 type MetaPatch struct {
 	Labels      map[string]string `json:"labels,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
+	// MergePolicy is how the labels and annotations are merged with those the GameServer already has.
+	// Defaults to Merge.
+	MergePolicy MetaPatchMergePolicy `json:"mergePolicy,omitempty"`
 }
 
 // PreferredSelectors converts all the preferred label selectors into an array of
@@ -117,7 +198,64 @@ type GameServerAllocationStatus struct {
 	GameServerName string                          `json:"gameServerName"`
 	Ports          []agonesv1.GameServerStatusPort `json:"ports,omitempty"`
 	Address        string                          `json:"address,omitempty"`
-	NodeName       string                          `json:"nodeName,omitempty"`
+	// Addresses are all the addresses the allocated GameServer can be reached on, IPv4 and IPv6, with their types
+	Addresses []corev1.NodeAddress `json:"addresses,omitempty"`
+	NodeName  string               `json:"nodeName,omitempty"`
+	// Reason is why the GameServerAllocation is not Allocated, e.g. NoCapacity, or Contention
+	Reason apis.Reason `json:"reason,omitempty"`
+	// GameServerUID is the UID of the allocated GameServer, which tells apart GameServers that reuse the same name
+	GameServerUID types.UID `json:"gameServerUID,omitempty"`
+	// GameServerCreationTimestamp is the creation timestamp of the allocated GameServer
+	GameServerCreationTimestamp *metav1.Time `json:"gameServerCreationTimestamp,omitempty"`
+	// GameServer is the allocated GameServer, only populated when `spec.includeGameServer` is true
+	GameServer *agonesv1.GameServer `json:"gameServer,omitempty"`
+	// ClaimToken is the claim token of a pre-allocation, which allocations set in `spec.claimToken`
+	// to allocate one of the reserved GameServers
+	ClaimToken string `json:"claimToken,omitempty"`
+	// PreAllocatedReplicas is the number of GameServers that a pre-allocation reserved,
+	// which is less than requested if there were not enough Ready GameServers
+	PreAllocatedReplicas int32 `json:"preAllocatedReplicas,omitempty"`
+	// ReservedUntil is when the GameServers of a pre-allocation that are not claimed are moved back to Ready
+	ReservedUntil *metav1.Time `json:"reservedUntil,omitempty"`
+}
+
+// AllocationCandidate is a Ready GameServer that may be allocated, as sent to the allocation filter webhook
+type AllocationCandidate struct {
+	Name        string            `json:"name"`
+	NodeName    string            `json:"nodeName"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// AllocationFilterRequest defines the request to the allocation filter webhook
+type AllocationFilterRequest struct {
+	// UID is an identifier for the individual request/response.
+	// It is suitable for correlating log entries between the webhook and the controller.
+	UID types.UID `json:"uid"`
+	// Namespace is the namespace of the GameServerAllocation
+	Namespace string `json:"namespace"`
+	// Spec is the spec of the GameServerAllocation
+	Spec GameServerAllocationSpec `json:"spec"`
+	// Candidates are the Ready GameServers that match the selectors of the GameServerAllocation,
+	// in the order of its scheduling strategy
+	Candidates []AllocationCandidate `json:"candidates"`
+}
+
+// AllocationFilterResponse defines the response of the allocation filter webhook
+type AllocationFilterResponse struct {
+	// UID is an identifier for the individual request/response.
+	// This should be copied over from the corresponding AllocationFilterRequest.
+	UID types.UID `json:"uid"`
+	// GameServers are the names of the candidates that may be allocated, in the order they should be tried.
+	// Candidates that are left out are not allocated.
+	GameServers []string `json:"gameServers"`
+}
+
+// AllocationFilterReview is passed to the allocation filter webhook with a populated Request value,
+// and then returned with a populated Response.
+type AllocationFilterReview struct {
+	Request  *AllocationFilterRequest  `json:"request"`
+	Response *AllocationFilterResponse `json:"response"`
 }
 
 // ApplyDefaults applies the default values to this GameServerAllocation
@@ -125,23 +263,164 @@ func (gsa *GameServerAllocation) ApplyDefaults() {
 	if gsa.Spec.Scheduling == "" {
 		gsa.Spec.Scheduling = apis.Packed
 	}
+	if gsa.Spec.MetaPatch.MergePolicy == "" {
+		gsa.Spec.MetaPatch.MergePolicy = MetaPatchMerge
+	}
+	if gsa.Spec.FleetName != "" {
+		if gsa.Spec.Required.MatchLabels == nil {
+			gsa.Spec.Required.MatchLabels = map[string]string{}
+		}
+		if _, ok := gsa.Spec.Required.MatchLabels[agonesv1.FleetNameLabel]; !ok {
+			gsa.Spec.Required.MatchLabels[agonesv1.FleetNameLabel] = gsa.Spec.FleetName
+		}
+	}
 }
 
-// Validate validation for the GameServerAllocation
+// Validate validation for the GameServerAllocation.
+// The scheduling strategy is validated by the allocator, as custom strategies can be registered with it.
 func (gsa *GameServerAllocation) Validate() ([]metav1.StatusCause, bool) {
 	var causes []metav1.StatusCause
 
-	valid := false
-	for _, v := range []apis.SchedulingStrategy{apis.Packed, apis.Distributed} {
-		if gsa.Spec.Scheduling == v {
-			valid = true
+	if gsa.Spec.AcknowledgeTimeoutSeconds < 0 {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+			Field:   "spec.acknowledgeTimeoutSeconds",
+			Message: "acknowledgeTimeoutSeconds must not be negative"})
+	} else if gsa.Spec.AcknowledgeTimeoutSeconds > MaxAcknowledgeTimeoutSeconds {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+			Field:   "spec.acknowledgeTimeoutSeconds",
+			Message: fmt.Sprintf("acknowledgeTimeoutSeconds must not be greater than %d", MaxAcknowledgeTimeoutSeconds)})
+	}
+
+	switch gsa.Spec.MetaPatch.MergePolicy {
+	case "", MetaPatchMerge, MetaPatchFailOnConflict, MetaPatchAppendToList:
+	default:
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueNotSupported,
+			Field: "spec.metadata.mergePolicy",
+			Message: fmt.Sprintf("mergePolicy must be one of %s, %s or %s",
+				MetaPatchMerge, MetaPatchFailOnConflict, MetaPatchAppendToList)})
+	}
+
+	if gsa.Spec.FleetName != "" {
+		if errs := validation.IsDNS1123Subdomain(gsa.Spec.FleetName); len(errs) > 0 {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+				Field:   "spec.fleetName",
+				Message: strings.Join(errs, ", ")})
+		} else if v, ok := gsa.Spec.Required.MatchLabels[agonesv1.FleetNameLabel]; ok && v != gsa.Spec.FleetName {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+				Field:   "spec.fleetName",
+				Message: fmt.Sprintf("fleetName must match the %s label of the required selector", agonesv1.FleetNameLabel)})
 		}
 	}
-	if !valid {
-		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
-			Field:   "spec.scheduling",
-			Message: fmt.Sprintf("Invalid value: %s, value must be either Packed or Distributed", gsa.Spec.Scheduling)})
+
+	causes = append(causes, gsa.validateFallbackFleetNames()...)
+	causes = append(causes, gsa.validatePreAllocation()...)
+	causes = append(causes, gsa.validateRequestID()...)
+
+	causes = validateSelector(causes, "spec.required", gsa.Spec.Required)
+	for i := range gsa.Spec.Preferred {
+		causes = validateSelector(causes, fmt.Sprintf("spec.preferred[%d]", i), gsa.Spec.Preferred[i])
 	}
+	causes = validateSelector(causes, "spec.multiClusterSetting.policySelector", gsa.Spec.MultiClusterSetting.PolicySelector)
 
 	return causes, len(causes) == 0
 }
+
+// validateFallbackFleetNames validates that the fallback fleets are valid fleet names, that each fleet is only tried once,
+// and that they are not combined with options that don't support them
+func (gsa *GameServerAllocation) validateFallbackFleetNames() []metav1.StatusCause {
+	if len(gsa.Spec.FallbackFleetNames) == 0 {
+		return nil
+	}
+	var causes []metav1.StatusCause
+	if gsa.Spec.FleetName == "" {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+			Field:   "spec.fallbackFleetNames",
+			Message: "fallbackFleetNames require fleetName"})
+	}
+	if gsa.Spec.PreAllocate != nil || gsa.Spec.ClaimToken != "" {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+			Field:   "spec.fallbackFleetNames",
+			Message: "pre-allocations and claims can't fall back to other fleets"})
+	}
+	seen := map[string]bool{gsa.Spec.FleetName: true}
+	for i, name := range gsa.Spec.FallbackFleetNames {
+		field := fmt.Sprintf("spec.fallbackFleetNames[%d]", i)
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+				Field:   field,
+				Message: strings.Join(errs, ", ")})
+		} else if seen[name] {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueDuplicate,
+				Field:   field,
+				Message: fmt.Sprintf("fleet %s is already tried", name)})
+		}
+		seen[name] = true
+	}
+	return causes
+}
+
+// validatePreAllocation validates the count and duration of a pre-allocation, and that neither a pre-allocation
+// nor a claim are combined with options they don't support
+func (gsa *GameServerAllocation) validatePreAllocation() []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	if pa := gsa.Spec.PreAllocate; pa != nil {
+		if pa.Count < 1 || pa.Count > MaxPreAllocateCount {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+				Field:   "spec.preAllocate.count",
+				Message: fmt.Sprintf("count must be between 1 and %d", MaxPreAllocateCount)})
+		}
+		if pa.DurationSeconds < 1 {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+				Field:   "spec.preAllocate.durationSeconds",
+				Message: "durationSeconds must be greater than 0"})
+		}
+		if gsa.Spec.ClaimToken != "" {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+				Field:   "spec.claimToken",
+				Message: "claimToken can't be set on a pre-allocation"})
+		}
+	}
+	if gsa.Spec.PreAllocate != nil || gsa.Spec.ClaimToken != "" {
+		if gsa.Spec.MultiClusterSetting.Enabled {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+				Field:   "spec.multiClusterSetting.enabled",
+				Message: "pre-allocations and claims can't be made across clusters"})
+		}
+		if gsa.Spec.AcknowledgeTimeoutSeconds > 0 {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+				Field:   "spec.acknowledgeTimeoutSeconds",
+				Message: "pre-allocations and claims can't wait for an acknowledgement"})
+		}
+	}
+	return causes
+}
+
+// validateRequestID validates the length of the request ID, and that it is not combined with options that don't
+// support sharing the response of the request. An allocation that waits for an acknowledgement shares the wait.
+func (gsa *GameServerAllocation) validateRequestID() []metav1.StatusCause {
+	if gsa.Spec.RequestID == "" {
+		return nil
+	}
+	var causes []metav1.StatusCause
+	if len(gsa.Spec.RequestID) > MaxRequestIDLength {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+			Field:   "spec.requestID",
+			Message: fmt.Sprintf("requestID must be no more than %d characters", MaxRequestIDLength)})
+	}
+	if gsa.Spec.PreAllocate != nil || gsa.Spec.ClaimToken != "" {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueInvalid,
+			Field:   "spec.requestID",
+			Message: "requestID can't be set on pre-allocations or claims"})
+	}
+	return causes
+}
+
+// validateSelector adds a cause with the SelectorInvalid reason if the label selector can not be parsed
+func validateSelector(causes []metav1.StatusCause, field string, selector metav1.LabelSelector) []metav1.StatusCause {
+	if _, err := metav1.LabelSelectorAsSelector(&selector); err != nil {
+		causes = append(causes, metav1.StatusCause{Type: metav1.CauseType(apis.ReasonSelectorInvalid),
+			Field:   field,
+			Message: err.Error()})
+	}
+	return causes
+}
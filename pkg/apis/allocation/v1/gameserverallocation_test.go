@@ -15,6 +15,7 @@
 package v1
 
 import (
+	"strings"
 	"testing"
 
 	"agones.dev/agones/pkg/apis"
@@ -31,10 +32,16 @@ func TestGameServerAllocationApplyDefaults(t *testing.T) {
 	gsa.ApplyDefaults()
 
 	assert.Equal(t, apis.Packed, gsa.Spec.Scheduling)
+	assert.Equal(t, MetaPatchMerge, gsa.Spec.MetaPatch.MergePolicy)
 
-	gsa = &GameServerAllocation{Spec: GameServerAllocationSpec{Scheduling: apis.Distributed}}
+	gsa = &GameServerAllocation{Spec: GameServerAllocationSpec{Scheduling: apis.Distributed, MetaPatch: MetaPatch{MergePolicy: MetaPatchAppendToList}}}
 	gsa.ApplyDefaults()
 	assert.Equal(t, apis.Distributed, gsa.Spec.Scheduling)
+	assert.Equal(t, MetaPatchAppendToList, gsa.Spec.MetaPatch.MergePolicy)
+
+	gsa = &GameServerAllocation{Spec: GameServerAllocationSpec{FleetName: "fleet"}}
+	gsa.ApplyDefaults()
+	assert.Equal(t, map[string]string{agonesv1.FleetNameLabel: "fleet"}, gsa.Spec.Required.MatchLabels)
 }
 
 func TestGameServerAllocationSpecPreferredSelectors(t *testing.T) {
@@ -76,12 +83,123 @@ func TestGameServerAllocationValidate(t *testing.T) {
 	assert.True(t, ok)
 	assert.Empty(t, causes)
 
-	gsa.Spec.Scheduling = "FLERG"
+	gsa.Spec.AcknowledgeTimeoutSeconds = -1
+	causes, ok = gsa.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "spec.acknowledgeTimeoutSeconds", causes[0].Field)
+
+	gsa.Spec.AcknowledgeTimeoutSeconds = MaxAcknowledgeTimeoutSeconds + 1
+	causes, ok = gsa.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 1)
+	assert.Equal(t, "spec.acknowledgeTimeoutSeconds", causes[0].Field)
+
+	gsa.Spec.AcknowledgeTimeoutSeconds = MaxAcknowledgeTimeoutSeconds
+	causes, ok = gsa.Validate()
+	assert.True(t, ok)
+	assert.Empty(t, causes)
 
+	gsa.Spec.AcknowledgeTimeoutSeconds = 10
+	gsa.Spec.MetaPatch.MergePolicy = "Replace"
 	causes, ok = gsa.Validate()
 	assert.False(t, ok)
 	assert.Len(t, causes, 1)
+	assert.Equal(t, "spec.metadata.mergePolicy", causes[0].Field)
+
+	gsa.Spec.MetaPatch.MergePolicy = MetaPatchFailOnConflict
+	invalid := metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "level", Operator: "Flerg"}}}
+	gsa.Spec.Preferred = []metav1.LabelSelector{{}, invalid}
+	gsa.Spec.MultiClusterSetting.PolicySelector = invalid
+
+	causes, ok = gsa.Validate()
+	assert.False(t, ok)
+	assert.Len(t, causes, 2)
+	assert.Equal(t, metav1.CauseType(apis.ReasonSelectorInvalid), causes[0].Type)
+	assert.Equal(t, "spec.preferred[1]", causes[0].Field)
+	assert.Equal(t, "spec.multiClusterSetting.policySelector", causes[1].Field)
+}
+
+func TestGameServerAllocationValidateSpecFields(t *testing.T) {
+	t.Parallel()
 
-	assert.Equal(t, metav1.CauseTypeFieldValueInvalid, causes[0].Type)
-	assert.Equal(t, "spec.scheduling", causes[0].Field)
+	fixtures := map[string]struct {
+		spec   GameServerAllocationSpec
+		fields []string
+	}{
+		"valid fleet name": {
+			spec: GameServerAllocationSpec{FleetName: "fleet"},
+		},
+		"invalid fleet name": {
+			spec:   GameServerAllocationSpec{FleetName: "Not_A_Fleet"},
+			fields: []string{"spec.fleetName"},
+		},
+		"fleet name conflicting with the required selector": {
+			spec: GameServerAllocationSpec{FleetName: "fleet",
+				Required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "other"}}},
+			fields: []string{"spec.fleetName"},
+		},
+		"valid fallback fleets": {
+			spec: GameServerAllocationSpec{FleetName: "region-eu", FallbackFleetNames: []string{"region-eu-backup", "region-us"}},
+		},
+		"fallback fleets without fleet name": {
+			spec:   GameServerAllocationSpec{FallbackFleetNames: []string{"region-us"}},
+			fields: []string{"spec.fallbackFleetNames"},
+		},
+		"invalid and repeated fallback fleets": {
+			spec:   GameServerAllocationSpec{FleetName: "region-eu", FallbackFleetNames: []string{"Not_A_Fleet", "region-us", "region-eu"}},
+			fields: []string{"spec.fallbackFleetNames[0]", "spec.fallbackFleetNames[2]"},
+		},
+		"fallback fleets of a claim": {
+			spec:   GameServerAllocationSpec{FleetName: "region-eu", FallbackFleetNames: []string{"region-us"}, ClaimToken: "token"},
+			fields: []string{"spec.fallbackFleetNames"},
+		},
+		"valid pre-allocation": {
+			spec: GameServerAllocationSpec{PreAllocate: &PreAllocation{Count: 10, DurationSeconds: 30}},
+		},
+		"valid claim": {
+			spec: GameServerAllocationSpec{ClaimToken: "token"},
+		},
+		"invalid count and duration": {
+			spec:   GameServerAllocationSpec{PreAllocate: &PreAllocation{Count: MaxPreAllocateCount + 1}},
+			fields: []string{"spec.preAllocate.count", "spec.preAllocate.durationSeconds"},
+		},
+		"pre-allocation with claim token": {
+			spec:   GameServerAllocationSpec{PreAllocate: &PreAllocation{Count: 1, DurationSeconds: 1}, ClaimToken: "token"},
+			fields: []string{"spec.claimToken"},
+		},
+		"claim across clusters with acknowledgement": {
+			spec: GameServerAllocationSpec{ClaimToken: "token", AcknowledgeTimeoutSeconds: 10,
+				MultiClusterSetting: MultiClusterSetting{Enabled: true}},
+			fields: []string{"spec.multiClusterSetting.enabled", "spec.acknowledgeTimeoutSeconds"},
+		},
+		"valid request id": {
+			spec: GameServerAllocationSpec{RequestID: "request-1"},
+		},
+		"request id too long": {
+			spec:   GameServerAllocationSpec{RequestID: strings.Repeat("a", MaxRequestIDLength+1)},
+			fields: []string{"spec.requestID"},
+		},
+		"request id of a claim": {
+			spec:   GameServerAllocationSpec{RequestID: "request-1", ClaimToken: "token"},
+			fields: []string{"spec.requestID"},
+		},
+		"request id with acknowledgement": {
+			spec: GameServerAllocationSpec{RequestID: "request-1", AcknowledgeTimeoutSeconds: 10},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			gsa := &GameServerAllocation{Spec: v.spec}
+			gsa.ApplyDefaults()
+			causes, ok := gsa.Validate()
+			assert.Equal(t, len(v.fields) == 0, ok)
+			var fields []string
+			for _, c := range causes {
+				fields = append(fields, c.Field)
+			}
+			assert.Equal(t, v.fields, fields)
+		})
+	}
 }
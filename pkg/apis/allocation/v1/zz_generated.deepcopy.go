@@ -22,10 +22,112 @@ package v1
 
 import (
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationCandidate) DeepCopyInto(out *AllocationCandidate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationCandidate.
+func (in *AllocationCandidate) DeepCopy() *AllocationCandidate {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationCandidate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationFilterRequest) DeepCopyInto(out *AllocationFilterRequest) {
+	*out = *in
+	in.Spec.DeepCopyInto(&out.Spec)
+	if in.Candidates != nil {
+		in, out := &in.Candidates, &out.Candidates
+		*out = make([]AllocationCandidate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationFilterRequest.
+func (in *AllocationFilterRequest) DeepCopy() *AllocationFilterRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationFilterRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationFilterResponse) DeepCopyInto(out *AllocationFilterResponse) {
+	*out = *in
+	if in.GameServers != nil {
+		in, out := &in.GameServers, &out.GameServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationFilterResponse.
+func (in *AllocationFilterResponse) DeepCopy() *AllocationFilterResponse {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationFilterResponse)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AllocationFilterReview) DeepCopyInto(out *AllocationFilterReview) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		*out = new(AllocationFilterRequest)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Response != nil {
+		in, out := &in.Response, &out.Response
+		*out = new(AllocationFilterResponse)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AllocationFilterReview.
+func (in *AllocationFilterReview) DeepCopy() *AllocationFilterReview {
+	if in == nil {
+		return nil
+	}
+	out := new(AllocationFilterReview)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GameServerAllocation) DeepCopyInto(out *GameServerAllocation) {
 	*out = *in
@@ -92,6 +194,11 @@ func (in *GameServerAllocationSpec) DeepCopyInto(out *GameServerAllocationSpec)
 	*out = *in
 	in.MultiClusterSetting.DeepCopyInto(&out.MultiClusterSetting)
 	in.Required.DeepCopyInto(&out.Required)
+	if in.FallbackFleetNames != nil {
+		in, out := &in.FallbackFleetNames, &out.FallbackFleetNames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Preferred != nil {
 		in, out := &in.Preferred, &out.Preferred
 		*out = make([]metav1.LabelSelector, len(*in))
@@ -100,6 +207,11 @@ func (in *GameServerAllocationSpec) DeepCopyInto(out *GameServerAllocationSpec)
 		}
 	}
 	in.MetaPatch.DeepCopyInto(&out.MetaPatch)
+	if in.PreAllocate != nil {
+		in, out := &in.PreAllocate, &out.PreAllocate
+		*out = new(PreAllocation)
+		**out = **in
+	}
 	return
 }
 
@@ -121,6 +233,24 @@ func (in *GameServerAllocationStatus) DeepCopyInto(out *GameServerAllocationStat
 		*out = make([]agonesv1.GameServerStatusPort, len(*in))
 		copy(*out, *in)
 	}
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]corev1.NodeAddress, len(*in))
+		copy(*out, *in)
+	}
+	if in.GameServerCreationTimestamp != nil {
+		in, out := &in.GameServerCreationTimestamp, &out.GameServerCreationTimestamp
+		*out = (*in).DeepCopy()
+	}
+	if in.GameServer != nil {
+		in, out := &in.GameServer, &out.GameServer
+		*out = new(agonesv1.GameServer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReservedUntil != nil {
+		in, out := &in.ReservedUntil, &out.ReservedUntil
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -180,3 +310,19 @@ func (in *MultiClusterSetting) DeepCopy() *MultiClusterSetting {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreAllocation) DeepCopyInto(out *PreAllocation) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreAllocation.
+func (in *PreAllocation) DeepCopy() *PreAllocation {
+	if in == nil {
+		return nil
+	}
+	out := new(PreAllocation)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -16,10 +16,15 @@ package v1
 
 import (
 	"crypto/x509"
+	"fmt"
 	"net/url"
+	"time"
 
+	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/util/cron"
 	admregv1b "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -53,6 +58,53 @@ type FleetAutoscalerSpec struct {
 
 	// Autoscaling policy
 	Policy FleetAutoscalerPolicy `json:"policy"`
+
+	// SyncInterval is how often the autoscaler is evaluated, on top of whenever the status of its fleet changes.
+	// Defaults to 30s.
+	// +optional
+	SyncInterval *metav1.Duration `json:"syncInterval,omitempty"`
+
+	// Behavior limits how fast the fleet is scaled up and down, on top of the policy.
+	// The fleet is scaled as soon as the policy says so if not set.
+	// +optional
+	Behavior *FleetAutoscalerBehavior `json:"behavior,omitempty"`
+
+	// LimitToNodeCapacity caps the replicas that the fleet is scaled up to, to the GameServers that the nodes of
+	// the cluster have room for, from the resources requested by the template of the fleet, rather than creating
+	// GameServers that can not be scheduled.
+	// +optional
+	LimitToNodeCapacity bool `json:"limitToNodeCapacity,omitempty"`
+}
+
+// FleetAutoscalerBehavior limits how fast the fleet is scaled in each direction,
+// like the behavior of a HorizontalPodAutoscaler
+type FleetAutoscalerBehavior struct {
+	// ScaleUp limits how fast the fleet is scaled up
+	// +optional
+	ScaleUp *ScalingRules `json:"scaleUp,omitempty"`
+
+	// ScaleDown limits how fast the fleet is scaled down
+	// +optional
+	ScaleDown *ScalingRules `json:"scaleDown,omitempty"`
+}
+
+// ScalingRules limits how fast the fleet is scaled in one direction
+type ScalingRules struct {
+	// StabilizationWindowSeconds is how far back the replicas computed by the policy are remembered.
+	// The fleet is only scaled up to the fewest, and down to the most, replicas computed within the window,
+	// so that brief changes in allocations don't scale the fleet back and forth. At most 3600.
+	// +optional
+	StabilizationWindowSeconds int32 `json:"stabilizationWindowSeconds,omitempty"`
+
+	// MaxChange is the most replicas the fleet is scaled by within PeriodSeconds, either an absolute
+	// number (ex: 10) or a percentage of the current replicas of the fleet (ex: 20%), rounded up.
+	// Not limited if not set.
+	// +optional
+	MaxChange *intstr.IntOrString `json:"maxChange,omitempty"`
+
+	// PeriodSeconds is the period that MaxChange applies to. Defaults to 60, and at most 1800.
+	// +optional
+	PeriodSeconds int32 `json:"periodSeconds,omitempty"`
 }
 
 // FleetAutoscalerPolicy describes how to scale a fleet
@@ -66,6 +118,18 @@ type FleetAutoscalerPolicy struct {
 	// Webhook policy config params. Present only if FleetAutoscalerPolicyType = Webhook.
 	// +optional
 	Webhook *WebhookPolicy `json:"webhook,omitempty"`
+	// Combined policy config params. Present only if FleetAutoscalerPolicyType = Combined.
+	// +optional
+	Combined *CombinedPolicy `json:"combined,omitempty"`
+	// Schedule policy config params. Present only if FleetAutoscalerPolicyType = Schedule.
+	// +optional
+	Schedule *SchedulePolicy `json:"schedule,omitempty"`
+	// Chain policy config params. Present only if FleetAutoscalerPolicyType = Chain.
+	// +optional
+	Chain ChainPolicy `json:"chain,omitempty"`
+	// Predictive policy config params. Present only if FleetAutoscalerPolicyType = Predictive.
+	// +optional
+	Predictive *PredictivePolicy `json:"predictive,omitempty"`
 }
 
 // FleetAutoscalerPolicyType is the policy for autoscaling
@@ -79,6 +143,51 @@ const (
 	// WebhookPolicyType is a simple webhook strategy used for horizontal fleet scaling
 	// GameServers
 	WebhookPolicyType FleetAutoscalerPolicyType = "Webhook"
+	// CombinedPolicyType scales the fleet with a combination of the replicas
+	// computed by several other policies
+	CombinedPolicyType FleetAutoscalerPolicyType = "Combined"
+	// SchedulePolicyType scales the fleet with a different buffer, or to a fixed number of replicas,
+	// during time windows
+	SchedulePolicyType FleetAutoscalerPolicyType = "Schedule"
+	// ChainPolicyType scales the fleet with the first policy of an ordered list that applies
+	ChainPolicyType FleetAutoscalerPolicyType = "Chain"
+	// PredictivePolicyType scales the fleet with a buffer of Ready GameServers projected
+	// from its recent allocation rate
+	PredictivePolicyType FleetAutoscalerPolicyType = "Predictive"
+)
+
+// CombinedPolicyCombinator is how the replicas computed by the policies
+// of a combined policy are combined
+type CombinedPolicyCombinator string
+
+const (
+	// MaxCombinator scales the fleet to the largest number of replicas of the policies
+	MaxCombinator CombinedPolicyCombinator = "Max"
+	// MinCombinator scales the fleet to the smallest number of replicas of the policies
+	MinCombinator CombinedPolicyCombinator = "Min"
+)
+
+// DefaultSyncInterval is how often a FleetAutoscaler is evaluated when its SyncInterval is not set
+const DefaultSyncInterval = 30 * time.Second
+
+const (
+	// DefaultPredictiveWindow is how far back the allocations of a fleet are counted by a predictive policy,
+	// when its window is not set
+	DefaultPredictiveWindow = 5 * time.Minute
+	// DefaultPredictiveLeadTime is how far ahead a predictive policy projects allocations,
+	// when its lead time is not set
+	DefaultPredictiveLeadTime = time.Minute
+	// MaxPredictiveWindow is the longest window of a predictive policy
+	MaxPredictiveWindow = time.Hour
+)
+
+const (
+	// MaxStabilizationWindowSeconds is the longest stabilization window of ScalingRules
+	MaxStabilizationWindowSeconds = 3600
+	// MaxScalingPeriodSeconds is the longest period of ScalingRules
+	MaxScalingPeriodSeconds = 1800
+	// DefaultScalingPeriodSeconds is the period of ScalingRules when it is not set
+	DefaultScalingPeriodSeconds = 60
 )
 
 // BufferPolicy controls the desired behavior of the buffer policy.
@@ -105,6 +214,101 @@ type BufferPolicy struct {
 	BufferSize intstr.IntOrString `json:"bufferSize"`
 }
 
+// CombinedPolicy controls the desired behavior of the combined policy.
+// It computes the replicas of each of its policies, and scales the fleet
+// to their maximum or minimum.
+type CombinedPolicy struct {
+	// Combinator is how the replicas of the policies are combined, either Max or Min.
+	// Defaults to Max.
+	// +optional
+	Combinator CombinedPolicyCombinator `json:"combinator,omitempty"`
+
+	// Policies are the Buffer, Webhook and Schedule policies to combine
+	Policies []FleetAutoscalerPolicy `json:"policies"`
+}
+
+// SchedulePolicy controls the desired behavior of the schedule policy.
+// It scales the fleet with the target of the first of its windows that is active,
+// and with its default target outside of all of them.
+type SchedulePolicy struct {
+	// TimeZone is the IANA time zone of the start of the windows, e.g. America/New_York.
+	// Defaults to UTC.
+	// +optional
+	TimeZone string `json:"timeZone,omitempty"`
+
+	// Default is how the fleet is scaled outside of the windows.
+	// It is required, unless the schedule policy is part of a chain policy.
+	// +optional
+	Default *ScheduleTarget `json:"default,omitempty"`
+
+	// Windows are the time windows during which the fleet is scaled differently
+	Windows []ScheduleWindow `json:"windows"`
+}
+
+// PredictivePolicy controls the desired behavior of the predictive policy.
+// It computes the allocation rate of the fleet within the window, and keeps as many Ready GameServers as are
+// projected to be allocated within the lead time at that rate, on top of the allocated ones.
+type PredictivePolicy struct {
+	// Window is how far back the allocations of the fleet are counted, e.g. "5m". Defaults to 5m, and at most 1h.
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+
+	// LeadTime is how far ahead the allocations are projected, e.g. how long it takes a new GameServer
+	// to become Ready. Defaults to 1m.
+	// +optional
+	LeadTime *metav1.Duration `json:"leadTime,omitempty"`
+
+	// MinBufferSize is the fewest Ready GameServers that are kept, whatever the allocation rate
+	// +optional
+	MinBufferSize int32 `json:"minBufferSize,omitempty"`
+
+	// MinReplicas is the minimum amount of replicas that the fleet must have.
+	// If zero, it is ignored.
+	MinReplicas int32 `json:"minReplicas"`
+
+	// MaxReplicas is the maximum amount of replicas that the fleet may have.
+	// It must be bigger than both MinReplicas and MinBufferSize
+	MaxReplicas int32 `json:"maxReplicas"`
+}
+
+// ChainPolicy controls the desired behavior of the chain policy.
+// It scales the fleet with the first of its entries that applies. Schedule policies apply while one of
+// their windows is active, or if they have a default. Buffer and Webhook policies always apply.
+type ChainPolicy []ChainEntry
+
+// ChainEntry is a policy of a chain policy
+type ChainEntry struct {
+	// ID identifies the entry in the chain policy
+	ID string `json:"id"`
+
+	// Policy is the Buffer, Webhook or Schedule policy of the entry
+	FleetAutoscalerPolicy `json:",inline"`
+}
+
+// ScheduleWindow is a recurring time window of a schedule policy
+type ScheduleWindow struct {
+	// Start is a cron expression of when the window starts, e.g. "0 18 * * 5" for 6pm every Friday
+	Start string `json:"start"`
+
+	// Duration is how long the window lasts after each start, e.g. "4h"
+	Duration metav1.Duration `json:"duration"`
+
+	// Target is how the fleet is scaled during the window
+	ScheduleTarget `json:",inline"`
+}
+
+// ScheduleTarget is how a schedule policy scales the fleet, either with a buffer policy
+// or to a fixed number of replicas
+type ScheduleTarget struct {
+	// Buffer is the buffer policy to scale the fleet with
+	// +optional
+	Buffer *BufferPolicy `json:"buffer,omitempty"`
+
+	// Replicas is the fixed number of replicas to scale the fleet to
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
 // WebhookPolicy controls the desired behavior of the webhook policy.
 // It contains the description of the webhook autoscaler service
 // used to form url which is accessible inside the cluster
@@ -130,8 +334,53 @@ type FleetAutoscalerStatus struct {
 	// ScalingLimited indicates that the calculated scale would be above or below the range
 	// defined by MinReplicas and MaxReplicas, and has thus been capped.
 	ScalingLimited bool `json:"scalingLimited"`
+
+	// ScalingLimitedReason is why the scaling was limited, when ScalingLimited is true
+	// +optional
+	ScalingLimitedReason ScalingLimitedReason `json:"scalingLimitedReason,omitempty"`
+
+	// Conditions are the latest observations of the FleetAutoscaler, e.g. AbleToScale
+	// +optional
+	Conditions []FleetAutoscalerCondition `json:"conditions,omitempty"`
+}
+
+// FleetAutoscalerConditionType is the type of a FleetAutoscalerCondition
+type FleetAutoscalerConditionType string
+
+const (
+	// FleetAutoscalerConditionAbleToScale is whether the FleetAutoscaler could compute the size of its fleet and scale
+	// it on its last evaluation. Its Reason is why it could not, e.g. FailedGetFleet.
+	FleetAutoscalerConditionAbleToScale FleetAutoscalerConditionType = "AbleToScale"
+	// FleetAutoscalerConditionScalingLimited is whether the last size the FleetAutoscaler computed for its fleet was
+	// capped. Its Reason is the ScalingLimitedReason.
+	FleetAutoscalerConditionScalingLimited FleetAutoscalerConditionType = "ScalingLimited"
+)
+
+// FleetAutoscalerCondition is an observation of the FleetAutoscaler at a point in time
+type FleetAutoscalerCondition struct {
+	// Type of the condition, e.g. AbleToScale
+	Type FleetAutoscalerConditionType `json:"type"`
+	// Status of the condition, one of True, False or Unknown
+	Status corev1.ConditionStatus `json:"status"`
+	// LastTransitionTime is when the condition last changed Status
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+	// Reason is a one word reason for the last transition
+	Reason string `json:"reason,omitempty"`
+	// Message is a human readable message about the last transition
+	Message string `json:"message,omitempty"`
 }
 
+// ScalingLimitedReason is why the scaling of a fleet was limited
+type ScalingLimitedReason string
+
+const (
+	// ScalingLimitedByPolicy is when the replicas computed by the policy were capped to its MinReplicas or MaxReplicas
+	ScalingLimitedByPolicy ScalingLimitedReason = "Policy"
+	// ScalingLimitedByNodeCapacity is when the fleet could not be scaled up further, as the nodes of the cluster
+	// have no room left for its GameServers
+	ScalingLimitedByNodeCapacity ScalingLimitedReason = "NodeCapacity"
+)
+
 // FleetAutoscaleRequest defines the request to webhook autoscaler endpoint
 type FleetAutoscaleRequest struct {
 	// UID is an identifier for the individual request/response. It allows us to distinguish instances of requests which are
@@ -165,14 +414,322 @@ type FleetAutoscaleReview struct {
 	Response *FleetAutoscaleResponse `json:"response"`
 }
 
+// GetSyncInterval returns how often the FleetAutoscaler is evaluated
+func (fas *FleetAutoscaler) GetSyncInterval() time.Duration {
+	if fas.Spec.SyncInterval == nil {
+		return DefaultSyncInterval
+	}
+	return fas.Spec.SyncInterval.Duration
+}
+
 // Validate validates the FleetAutoscaler scaling settings
 func (fas *FleetAutoscaler) Validate(causes []metav1.StatusCause) []metav1.StatusCause {
+	if fas.Spec.SyncInterval != nil && fas.Spec.SyncInterval.Duration < time.Second {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "syncInterval",
+			Message: "syncInterval should be at least 1s",
+		})
+	}
+
+	if b := fas.Spec.Behavior; b != nil {
+		causes = b.ScaleUp.validateScalingRules(causes, "behavior.scaleUp")
+		causes = b.ScaleDown.validateScalingRules(causes, "behavior.scaleDown")
+	}
+
 	switch fas.Spec.Policy.Type {
 	case BufferPolicyType:
 		causes = fas.Spec.Policy.Buffer.ValidateBufferPolicy(causes)
 
 	case WebhookPolicyType:
 		causes = fas.Spec.Policy.Webhook.ValidateWebhookPolicy(causes)
+
+	case CombinedPolicyType:
+		causes = fas.Spec.Policy.Combined.ValidateCombinedPolicy(causes)
+
+	case SchedulePolicyType:
+		causes = fas.Spec.Policy.Schedule.ValidateSchedulePolicy(causes)
+
+	case ChainPolicyType:
+		causes = fas.Spec.Policy.Chain.ValidateChainPolicy(causes)
+
+	case PredictivePolicyType:
+		causes = fas.Spec.Policy.Predictive.ValidatePredictivePolicy(causes)
+	}
+	return causes
+}
+
+// Period returns the period that MaxChange applies to
+func (r *ScalingRules) Period() time.Duration {
+	if r.PeriodSeconds == 0 {
+		return DefaultScalingPeriodSeconds * time.Second
+	}
+	return time.Duration(r.PeriodSeconds) * time.Second
+}
+
+// validateScalingRules validates the stabilization window, max change and period of the scaling rules
+func (r *ScalingRules) validateScalingRules(causes []metav1.StatusCause, field string) []metav1.StatusCause {
+	if r == nil {
+		return causes
+	}
+	if r.StabilizationWindowSeconds < 0 || r.StabilizationWindowSeconds > MaxStabilizationWindowSeconds {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   field + ".stabilizationWindowSeconds",
+			Message: fmt.Sprintf("stabilizationWindowSeconds should be between 0 and %d", MaxStabilizationWindowSeconds),
+		})
+	}
+	if r.PeriodSeconds < 0 || r.PeriodSeconds > MaxScalingPeriodSeconds {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   field + ".periodSeconds",
+			Message: fmt.Sprintf("periodSeconds should be between 0 and %d", MaxScalingPeriodSeconds),
+		})
+	}
+	if r.MaxChange != nil {
+		if v, err := intstr.GetValueFromIntOrPercent(r.MaxChange, 100, true); err != nil || v < 1 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".maxChange",
+				Message: "maxChange should be bigger than 0, or a percentage bigger than 0%",
+			})
+		}
+	}
+	return causes
+}
+
+// ValidateChainPolicy validates the FleetAutoscaler Chain policy settings
+func (c ChainPolicy) ValidateChainPolicy(causes []metav1.StatusCause) []metav1.StatusCause {
+	if len(c) == 0 {
+		return append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "chain",
+			Message: "at least one chain policy entry should be provided",
+		})
+	}
+	ids := map[string]bool{}
+	for i, e := range c {
+		field := fmt.Sprintf("chain[%d]", i)
+		if e.ID == "" || ids[e.ID] {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".id",
+				Message: "id should be provided, and be unique within the chain policy",
+			})
+		}
+		ids[e.ID] = true
+
+		switch e.Type {
+		case BufferPolicyType:
+			causes = e.Buffer.ValidateBufferPolicy(causes)
+		case WebhookPolicyType:
+			causes = e.Webhook.ValidateWebhookPolicy(causes)
+		case SchedulePolicyType:
+			causes = e.Schedule.validateSchedulePolicy(causes, false)
+		case PredictivePolicyType:
+			causes = e.Predictive.ValidatePredictivePolicy(causes)
+		default:
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".type",
+				Message: "chain policy entries should be of type Buffer, Webhook, Schedule or Predictive",
+			})
+		}
+	}
+	return causes
+}
+
+// ValidatePredictivePolicy validates the FleetAutoscaler Predictive policy settings
+func (p *PredictivePolicy) ValidatePredictivePolicy(causes []metav1.StatusCause) []metav1.StatusCause {
+	if p == nil {
+		return append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "predictive",
+			Message: "Predictive policy config params are missing",
+		})
+	}
+	if p.Window != nil && (p.Window.Duration < time.Minute || p.Window.Duration > MaxPredictiveWindow) {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "window",
+			Message: fmt.Sprintf("window should be between 1m and %s", MaxPredictiveWindow),
+		})
+	}
+	if p.LeadTime != nil && p.LeadTime.Duration <= 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "leadTime",
+			Message: "leadTime should be positive",
+		})
+	}
+	if p.MinBufferSize < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "minBufferSize",
+			Message: "minBufferSize should not be negative",
+		})
+	}
+	if p.MinReplicas > p.MaxReplicas {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "minReplicas",
+			Message: "minReplicas is bigger than maxReplicas",
+		})
+	}
+	if p.MaxReplicas < 1 || p.MaxReplicas < p.MinBufferSize {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "maxReplicas",
+			Message: "maxReplicas must be bigger than 0 and minBufferSize",
+		})
+	}
+	return causes
+}
+
+// GetWindow returns how far back the allocations of the fleet are counted
+func (p *PredictivePolicy) GetWindow() time.Duration {
+	if p.Window == nil {
+		return DefaultPredictiveWindow
+	}
+	return p.Window.Duration
+}
+
+// GetLeadTime returns how far ahead the allocations are projected
+func (p *PredictivePolicy) GetLeadTime() time.Duration {
+	if p.LeadTime == nil {
+		return DefaultPredictiveLeadTime
+	}
+	return p.LeadTime.Duration
+}
+
+// ValidateSchedulePolicy validates the FleetAutoscaler Schedule policy settings
+func (s *SchedulePolicy) ValidateSchedulePolicy(causes []metav1.StatusCause) []metav1.StatusCause {
+	return s.validateSchedulePolicy(causes, true)
+}
+
+// validateSchedulePolicy validates the schedule policy, which has to have a default if requireDefault is true
+func (s *SchedulePolicy) validateSchedulePolicy(causes []metav1.StatusCause, requireDefault bool) []metav1.StatusCause {
+	if s == nil {
+		return append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "schedule",
+			Message: "Schedule policy config params are missing",
+		})
+	}
+	if _, err := s.Location(); err != nil {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "timeZone",
+			Message: err.Error(),
+		})
+	}
+	if s.Default != nil {
+		causes = s.Default.validateScheduleTarget(causes, "default")
+	} else if requireDefault {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "default",
+			Message: "default should be provided",
+		})
+	}
+	if len(s.Windows) == 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "windows",
+			Message: "at least one window should be provided",
+		})
+	}
+	for i, w := range s.Windows {
+		field := fmt.Sprintf("windows[%d]", i)
+		if _, err := cron.Parse(w.Start); err != nil {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".start",
+				Message: err.Error(),
+			})
+		}
+		if w.Duration.Duration <= 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   field + ".duration",
+				Message: "duration should be positive",
+			})
+		}
+		causes = w.ScheduleTarget.validateScheduleTarget(causes, field)
+	}
+	return causes
+}
+
+// Location returns the time zone of the schedule policy
+func (s *SchedulePolicy) Location() (*time.Location, error) {
+	if s.TimeZone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(s.TimeZone)
+}
+
+// validateScheduleTarget validates that the target has either a valid buffer policy or replicas
+func (t *ScheduleTarget) validateScheduleTarget(causes []metav1.StatusCause, field string) []metav1.StatusCause {
+	if (t.Buffer == nil) == (t.Replicas == nil) {
+		return append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   field,
+			Message: "exactly one of buffer or replicas should be provided",
+		})
+	}
+	if t.Replicas != nil && *t.Replicas < 0 {
+		return append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   field + ".replicas",
+			Message: "replicas should not be negative",
+		})
+	}
+	if t.Buffer != nil {
+		causes = t.Buffer.ValidateBufferPolicy(causes)
+	}
+	return causes
+}
+
+// ValidateCombinedPolicy validates the FleetAutoscaler Combined policy settings
+func (c *CombinedPolicy) ValidateCombinedPolicy(causes []metav1.StatusCause) []metav1.StatusCause {
+	if c == nil {
+		return append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "combined",
+			Message: "Combined policy config params are missing",
+		})
+	}
+	if c.Combinator != "" && c.Combinator != MaxCombinator && c.Combinator != MinCombinator {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "combinator",
+			Message: "combinator should be one of: Max, Min",
+		})
+	}
+	if len(c.Policies) == 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "policies",
+			Message: "at least one policy should be provided",
+		})
+	}
+	for _, p := range c.Policies {
+		switch p.Type {
+		case BufferPolicyType:
+			causes = p.Buffer.ValidateBufferPolicy(causes)
+		case WebhookPolicyType:
+			causes = p.Webhook.ValidateWebhookPolicy(causes)
+		case SchedulePolicyType:
+			causes = p.Schedule.ValidateSchedulePolicy(causes)
+		case PredictivePolicyType:
+			causes = p.Predictive.ValidatePredictivePolicy(causes)
+		default:
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   "policies",
+				Message: "policies should be of type Buffer, Webhook, Schedule or Predictive",
+			})
+		}
 	}
 	return causes
 }
@@ -294,3 +851,57 @@ func (b *BufferPolicy) ValidateBufferPolicy(causes []metav1.StatusCause) []metav
 	}
 	return causes
 }
+
+// GetCondition returns the condition of the type, and false if it is not set
+func (fass *FleetAutoscalerStatus) GetCondition(t FleetAutoscalerConditionType) (FleetAutoscalerCondition, bool) {
+	i, ok := apis.GetCondition(fleetAutoscalerConditions{status: fass}, string(t))
+	if !ok {
+		return FleetAutoscalerCondition{}, false
+	}
+	return fass.Conditions[i], true
+}
+
+// SetCondition sets the condition, replacing the condition of the same type, and returns false if nothing changed.
+// The LastTransitionTime is kept if the Status of the condition did not change.
+func (fass *FleetAutoscalerStatus) SetCondition(condition FleetAutoscalerCondition) bool {
+	return apis.SetCondition(fleetAutoscalerConditions{status: fass}, condition.condition())
+}
+
+// condition converts the FleetAutoscalerCondition to the apis.Condition that is shared between resources
+func (c FleetAutoscalerCondition) condition() apis.Condition {
+	return apis.Condition{
+		Type:               string(c.Type),
+		Status:             c.Status,
+		LastTransitionTime: c.LastTransitionTime,
+		Reason:             c.Reason,
+		Message:            c.Message,
+	}
+}
+
+// fleetAutoscalerConditions adapts the conditions of a FleetAutoscalerStatus to apis.Conditions
+type fleetAutoscalerConditions struct {
+	status *FleetAutoscalerStatus
+}
+
+func (c fleetAutoscalerConditions) Len() int {
+	return len(c.status.Conditions)
+}
+
+func (c fleetAutoscalerConditions) At(i int) apis.Condition {
+	return c.status.Conditions[i].condition()
+}
+
+func (c fleetAutoscalerConditions) Set(i int, condition apis.Condition) {
+	converted := FleetAutoscalerCondition{
+		Type:               FleetAutoscalerConditionType(condition.Type),
+		Status:             condition.Status,
+		LastTransitionTime: condition.LastTransitionTime,
+		Reason:             condition.Reason,
+		Message:            condition.Message,
+	}
+	if i == len(c.status.Conditions) {
+		c.status.Conditions = append(c.status.Conditions, converted)
+		return
+	}
+	c.status.Conditions[i] = converted
+}
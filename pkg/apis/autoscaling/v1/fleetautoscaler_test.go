@@ -16,13 +16,30 @@ package v1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	admregv1b "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+func TestFleetAutoscalerStatusSetCondition(t *testing.T) {
+	t.Parallel()
+
+	status := &FleetAutoscalerStatus{}
+	_, ok := status.GetCondition(FleetAutoscalerConditionAbleToScale)
+	assert.False(t, ok)
+
+	now := metav1.Now()
+	assert.True(t, status.SetCondition(FleetAutoscalerCondition{Type: FleetAutoscalerConditionAbleToScale, Status: corev1.ConditionFalse, LastTransitionTime: now, Reason: "FailedGetFleet", Message: "not found"}))
+	c, ok := status.GetCondition(FleetAutoscalerConditionAbleToScale)
+	assert.True(t, ok)
+	assert.Equal(t, FleetAutoscalerCondition{Type: FleetAutoscalerConditionAbleToScale, Status: corev1.ConditionFalse, LastTransitionTime: now, Reason: "FailedGetFleet", Message: "not found"}, c)
+	assert.Equal(t, []FleetAutoscalerCondition{c}, status.Conditions)
+}
+
 func TestFleetAutoscalerValidateUpdate(t *testing.T) {
 	t.Parallel()
 
@@ -112,6 +129,42 @@ func TestFleetAutoscalerValidateUpdate(t *testing.T) {
 		assert.Len(t, causes, 1)
 		assert.Equal(t, "minReplicas", causes[0].Field)
 	})
+
+	t.Run("sync interval", func(t *testing.T) {
+		fas := defaultFixture()
+		assert.Equal(t, DefaultSyncInterval, fas.GetSyncInterval())
+
+		fas.Spec.SyncInterval = &metav1.Duration{Duration: 5 * time.Second}
+		assert.Len(t, fas.Validate(nil), 0)
+		assert.Equal(t, 5*time.Second, fas.GetSyncInterval())
+
+		fas.Spec.SyncInterval.Duration = 100 * time.Millisecond
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "syncInterval", causes[0].Field)
+	})
+
+	t.Run("behavior", func(t *testing.T) {
+		fas := defaultFixture()
+		pct := intstr.FromString("20%")
+		fas.Spec.Behavior = &FleetAutoscalerBehavior{
+			ScaleUp:   &ScalingRules{MaxChange: &pct},
+			ScaleDown: &ScalingRules{StabilizationWindowSeconds: 300, PeriodSeconds: 120},
+		}
+		assert.Len(t, fas.Validate(nil), 0)
+		assert.Equal(t, DefaultScalingPeriodSeconds*time.Second, fas.Spec.Behavior.ScaleUp.Period())
+		assert.Equal(t, 2*time.Minute, fas.Spec.Behavior.ScaleDown.Period())
+
+		zero := intstr.FromInt(0)
+		fas.Spec.Behavior.ScaleUp = &ScalingRules{MaxChange: &zero, PeriodSeconds: MaxScalingPeriodSeconds + 1}
+		fas.Spec.Behavior.ScaleDown = &ScalingRules{StabilizationWindowSeconds: -1}
+		causes := fas.Validate(nil)
+		var fields []string
+		for _, c := range causes {
+			fields = append(fields, c.Field)
+		}
+		assert.Equal(t, []string{"behavior.scaleUp.periodSeconds", "behavior.scaleUp.maxChange", "behavior.scaleDown.stabilizationWindowSeconds"}, fields)
+	})
 }
 func TestFleetAutoscalerWebhookValidateUpdate(t *testing.T) {
 	t.Parallel()
@@ -205,6 +258,258 @@ func TestFleetAutoscalerWebhookValidateUpdate(t *testing.T) {
 
 }
 
+func TestFleetAutoscalerCombinedValidateUpdate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("good combined policy", func(t *testing.T) {
+		fas := combinedFixture()
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 0)
+
+		fas.Spec.Policy.Combined.Combinator = MinCombinator
+		causes = fas.Validate(nil)
+		assert.Len(t, causes, 0)
+	})
+
+	t.Run("missing combined params", func(t *testing.T) {
+		fas := combinedFixture()
+		fas.Spec.Policy.Combined = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "combined", causes[0].Field)
+	})
+
+	t.Run("bad combinator", func(t *testing.T) {
+		fas := combinedFixture()
+		fas.Spec.Policy.Combined.Combinator = "Sum"
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "combinator", causes[0].Field)
+	})
+
+	t.Run("no policies", func(t *testing.T) {
+		fas := combinedFixture()
+		fas.Spec.Policy.Combined.Policies = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "policies", causes[0].Field)
+	})
+
+	t.Run("nested combined policy", func(t *testing.T) {
+		fas := combinedFixture()
+		fas.Spec.Policy.Combined.Policies = append(fas.Spec.Policy.Combined.Policies, combinedFixture().Spec.Policy)
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "policies", causes[0].Field)
+	})
+
+	t.Run("bad buffer policy", func(t *testing.T) {
+		fas := combinedFixture()
+		fas.Spec.Policy.Combined.Policies[0].Buffer.BufferSize = intstr.FromInt(0)
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "bufferSize", causes[0].Field)
+	})
+}
+
+func TestFleetAutoscalerScheduleValidateUpdate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("good schedule policy", func(t *testing.T) {
+		fas := scheduleFixture()
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 0)
+
+		fas.Spec.Policy.Schedule.TimeZone = "UTC"
+		causes = fas.Validate(nil)
+		assert.Len(t, causes, 0)
+	})
+
+	t.Run("missing schedule params", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "schedule", causes[0].Field)
+	})
+
+	t.Run("bad time zone", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule.TimeZone = "Not/AZone"
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "timeZone", causes[0].Field)
+	})
+
+	t.Run("no windows", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule.Windows = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "windows", causes[0].Field)
+	})
+
+	t.Run("bad start", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule.Windows[0].Start = "0 25 * * *"
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "windows[0].start", causes[0].Field)
+	})
+
+	t.Run("bad duration", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule.Windows[0].Duration.Duration = 0
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "windows[0].duration", causes[0].Field)
+	})
+
+	t.Run("both buffer and replicas", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule.Windows[0].Buffer = defaultFixture().Spec.Policy.Buffer
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "windows[0]", causes[0].Field)
+	})
+
+	t.Run("neither buffer nor replicas", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule.Default.Buffer = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "default", causes[0].Field)
+	})
+
+	t.Run("missing default", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule.Default = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "default", causes[0].Field)
+	})
+
+	t.Run("negative replicas", func(t *testing.T) {
+		fas := scheduleFixture()
+		replicas := int32(-1)
+		fas.Spec.Policy.Schedule.Windows[0].Replicas = &replicas
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "windows[0].replicas", causes[0].Field)
+	})
+
+	t.Run("bad buffer policy", func(t *testing.T) {
+		fas := scheduleFixture()
+		fas.Spec.Policy.Schedule.Default.Buffer.BufferSize = intstr.FromInt(0)
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "bufferSize", causes[0].Field)
+	})
+
+	t.Run("combined schedule policy", func(t *testing.T) {
+		fas := combinedFixture()
+		fas.Spec.Policy.Combined.Policies = append(fas.Spec.Policy.Combined.Policies, scheduleFixture().Spec.Policy)
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 0)
+	})
+}
+
+func TestFleetAutoscalerPredictiveValidateUpdate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("good predictive policy", func(t *testing.T) {
+		fas := predictiveFixture()
+		assert.Len(t, fas.Validate(nil), 0)
+		assert.Equal(t, 10*time.Minute, fas.Spec.Policy.Predictive.GetWindow())
+		assert.Equal(t, DefaultPredictiveLeadTime, fas.Spec.Policy.Predictive.GetLeadTime())
+
+		fas.Spec.Policy.Predictive.Window = nil
+		assert.Equal(t, DefaultPredictiveWindow, fas.Spec.Policy.Predictive.GetWindow())
+	})
+
+	t.Run("missing predictive policy", func(t *testing.T) {
+		fas := predictiveFixture()
+		fas.Spec.Policy.Predictive = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "predictive", causes[0].Field)
+	})
+
+	t.Run("bad predictive policy", func(t *testing.T) {
+		fas := predictiveFixture()
+		fas.Spec.Policy.Predictive.Window = &metav1.Duration{Duration: 2 * time.Hour}
+		fas.Spec.Policy.Predictive.LeadTime = &metav1.Duration{}
+		fas.Spec.Policy.Predictive.MinBufferSize = 200
+		fas.Spec.Policy.Predictive.MinReplicas = 150
+		causes := fas.Validate(nil)
+		var fields []string
+		for _, c := range causes {
+			fields = append(fields, c.Field)
+		}
+		assert.Equal(t, []string{"window", "leadTime", "minReplicas", "maxReplicas"}, fields)
+	})
+
+	t.Run("combined and chain policies", func(t *testing.T) {
+		fas := combinedFixture()
+		fas.Spec.Policy.Combined.Policies = append(fas.Spec.Policy.Combined.Policies, predictiveFixture().Spec.Policy)
+		assert.Len(t, fas.Validate(nil), 0)
+
+		fas = chainFixture()
+		fas.Spec.Policy.Chain = append(fas.Spec.Policy.Chain, ChainEntry{ID: "predictive", FleetAutoscalerPolicy: predictiveFixture().Spec.Policy})
+		assert.Len(t, fas.Validate(nil), 0)
+	})
+}
+
+func TestFleetAutoscalerChainValidateUpdate(t *testing.T) {
+	t.Parallel()
+
+	t.Run("good chain policy", func(t *testing.T) {
+		fas := chainFixture()
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 0)
+	})
+
+	t.Run("no entries", func(t *testing.T) {
+		fas := chainFixture()
+		fas.Spec.Policy.Chain = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "chain", causes[0].Field)
+	})
+
+	t.Run("missing id", func(t *testing.T) {
+		fas := chainFixture()
+		fas.Spec.Policy.Chain[1].ID = ""
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "chain[1].id", causes[0].Field)
+	})
+
+	t.Run("duplicate id", func(t *testing.T) {
+		fas := chainFixture()
+		fas.Spec.Policy.Chain[1].ID = fas.Spec.Policy.Chain[0].ID
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "chain[1].id", causes[0].Field)
+	})
+
+	t.Run("nested chain policy", func(t *testing.T) {
+		fas := chainFixture()
+		fas.Spec.Policy.Chain = append(fas.Spec.Policy.Chain, ChainEntry{ID: "nested", FleetAutoscalerPolicy: chainFixture().Spec.Policy})
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "chain[2].type", causes[0].Field)
+	})
+
+	t.Run("bad schedule policy", func(t *testing.T) {
+		fas := chainFixture()
+		fas.Spec.Policy.Chain[0].Schedule.Windows = nil
+		causes := fas.Validate(nil)
+		assert.Len(t, causes, 1)
+		assert.Equal(t, "windows", causes[0].Field)
+	})
+}
+
 func defaultFixture() *FleetAutoscaler {
 	return customFixture(BufferPolicyType)
 }
@@ -213,6 +518,22 @@ func webhookFixture() *FleetAutoscaler {
 	return customFixture(WebhookPolicyType)
 }
 
+func combinedFixture() *FleetAutoscaler {
+	return customFixture(CombinedPolicyType)
+}
+
+func scheduleFixture() *FleetAutoscaler {
+	return customFixture(SchedulePolicyType)
+}
+
+func chainFixture() *FleetAutoscaler {
+	return customFixture(ChainPolicyType)
+}
+
+func predictiveFixture() *FleetAutoscaler {
+	return customFixture(PredictivePolicyType)
+}
+
 func customFixture(t FleetAutoscalerPolicyType) *FleetAutoscaler {
 	res := &FleetAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{Name: "test"},
@@ -240,6 +561,46 @@ func customFixture(t FleetAutoscalerPolicyType) *FleetAutoscaler {
 				Path:      &url,
 			},
 		}
+	case CombinedPolicyType:
+		res.Spec.Policy = FleetAutoscalerPolicy{
+			Type: CombinedPolicyType,
+			Combined: &CombinedPolicy{
+				Policies: []FleetAutoscalerPolicy{res.Spec.Policy, webhookFixture().Spec.Policy},
+			},
+		}
+	case SchedulePolicyType:
+		replicas := int32(20)
+		res.Spec.Policy = FleetAutoscalerPolicy{
+			Type: SchedulePolicyType,
+			Schedule: &SchedulePolicy{
+				Default: &ScheduleTarget{Buffer: res.Spec.Policy.Buffer},
+				Windows: []ScheduleWindow{{
+					Start:          "0 18 * * 5",
+					Duration:       metav1.Duration{Duration: 4 * time.Hour},
+					ScheduleTarget: ScheduleTarget{Replicas: &replicas},
+				}},
+			},
+		}
+	case PredictivePolicyType:
+		res.Spec.Policy = FleetAutoscalerPolicy{
+			Type: PredictivePolicyType,
+			Predictive: &PredictivePolicy{
+				Window:        &metav1.Duration{Duration: 10 * time.Minute},
+				MinBufferSize: 2,
+				MaxReplicas:   100,
+			},
+		}
+	case ChainPolicyType:
+		// a schedule without a default, then a buffer
+		schedule := scheduleFixture().Spec.Policy
+		schedule.Schedule.Default = nil
+		res.Spec.Policy = FleetAutoscalerPolicy{
+			Type: ChainPolicyType,
+			Chain: ChainPolicy{
+				{ID: "weekends", FleetAutoscalerPolicy: schedule},
+				{ID: "buffer", FleetAutoscalerPolicy: res.Spec.Policy},
+			},
+		}
 	}
 	return res
 }
@@ -0,0 +1,69 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Condition is the condition of an Agones resource, which the conditions of
+// each resource type convert from and to, to share how they are updated
+type Condition struct {
+	Type               string
+	Status             corev1.ConditionStatus
+	LastTransitionTime metav1.Time
+	Reason             string
+	Message            string
+}
+
+// Conditions is the list of conditions in the status of an Agones resource
+type Conditions interface {
+	// Len is the number of conditions
+	Len() int
+	// At returns the condition at the index
+	At(i int) Condition
+	// Set replaces the condition at the index, or appends it if the index is Len()
+	Set(i int, c Condition)
+}
+
+// GetCondition returns the index of the condition of the type, and false if it is not set
+func GetCondition(conditions Conditions, t string) (int, bool) {
+	for i := 0; i < conditions.Len(); i++ {
+		if conditions.At(i).Type == t {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// SetCondition sets the condition, replacing the condition of the same type, and returns false if nothing changed.
+// The LastTransitionTime is kept if the Status of the condition did not change.
+func SetCondition(conditions Conditions, condition Condition) bool {
+	i, ok := GetCondition(conditions, condition.Type)
+	if !ok {
+		conditions.Set(conditions.Len(), condition)
+		return true
+	}
+	c := conditions.At(i)
+	if c.Status == condition.Status {
+		condition.LastTransitionTime = c.LastTransitionTime
+	}
+	if c == condition {
+		return false
+	}
+	conditions.Set(i, condition)
+	return true
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type testConditions []Condition
+
+func (tc *testConditions) Len() int           { return len(*tc) }
+func (tc *testConditions) At(i int) Condition { return (*tc)[i] }
+func (tc *testConditions) Set(i int, c Condition) {
+	if i == len(*tc) {
+		*tc = append(*tc, c)
+		return
+	}
+	(*tc)[i] = c
+}
+
+func TestSetCondition(t *testing.T) {
+	t.Parallel()
+
+	conditions := &testConditions{}
+	_, ok := GetCondition(conditions, "Ready")
+	assert.False(t, ok)
+
+	then := metav1.NewTime(time.Now().Add(-time.Minute))
+	assert.True(t, SetCondition(conditions, Condition{Type: "Ready", Status: corev1.ConditionTrue, LastTransitionTime: then}))
+
+	// the same status keeps its transition time
+	assert.False(t, SetCondition(conditions, Condition{Type: "Ready", Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()}))
+	i, ok := GetCondition(conditions, "Ready")
+	if assert.True(t, ok) {
+		assert.Equal(t, then, conditions.At(i).LastTransitionTime)
+	}
+
+	// a new reason on the same status is a change, that keeps the transition time
+	assert.True(t, SetCondition(conditions, Condition{Type: "Ready", Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now(), Reason: "Scheduled"}))
+	assert.Equal(t, then, conditions.At(i).LastTransitionTime)
+	assert.Equal(t, "Scheduled", conditions.At(i).Reason)
+
+	now := metav1.Now()
+	assert.True(t, SetCondition(conditions, Condition{Type: "Ready", Status: corev1.ConditionFalse, LastTransitionTime: now, Reason: "Unhealthy"}))
+	assert.True(t, SetCondition(conditions, Condition{Type: "Scheduled", Status: corev1.ConditionTrue, LastTransitionTime: now}))
+	if assert.Len(t, *conditions, 2) {
+		assert.Equal(t, Condition{Type: "Ready", Status: corev1.ConditionFalse, LastTransitionTime: now, Reason: "Unhealthy"}, (*conditions)[0])
+		assert.Equal(t, "Scheduled", (*conditions)[1].Type)
+	}
+}
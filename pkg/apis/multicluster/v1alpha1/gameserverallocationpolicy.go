@@ -15,16 +15,24 @@
 package v1alpha1
 
 import (
+	"fmt"
 	"math/rand"
+	"net/url"
 	"sort"
+	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // GameServerAllocationPolicySpec defines the desired state of GameServerAllocationPolicy
 type GameServerAllocationPolicySpec struct {
+	// Priority of the policy. Clusters are tried in ascending order of priority,
+	// so the clusters of policies with the lowest priority are tried first
 	// +kubebuilder:validation:Minimum=0
 	Priority int `json:"priority"`
+	// Weight of the policy among the policies with the same priority. Allocations are
+	// distributed across the clusters of the same priority in proportion to their weights,
+	// and a cluster with a weight of 0 is never chosen
 	// +kubebuilder:validation:Minimum=0
 	Weight         int                   `json:"weight"`
 	ConnectionInfo ClusterConnectionInfo `json:"connectionInfo,omitempty"`
@@ -42,6 +50,10 @@ type ClusterConnectionInfo struct {
 	SecretName string `json:"secretName"`
 	// The cluster namespace from which to allocate gameservers
 	Namespace string `json:"namespace"`
+	// Optional: the server name that is expected in the TLS certificates of the allocator service,
+	// and sent as SNI. Defaults to the host of the allocation endpoint. Set it when the endpoints
+	// are IP addresses, and the certificates are issued for a DNS name.
+	ServerName string `json:"serverName,omitempty"`
 }
 
 // +genclient
@@ -65,6 +77,63 @@ type GameServerAllocationPolicyList struct {
 	Items           []GameServerAllocationPolicy `json:"items"`
 }
 
+// Validate validates the GameServerAllocationPolicy settings, and returns the causes of
+// any invalid settings
+func (gsap *GameServerAllocationPolicy) Validate() ([]metav1.StatusCause, bool) {
+	var causes []metav1.StatusCause
+	if gsap.Spec.Priority < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "priority",
+			Message: "priority should be zero or greater",
+		})
+	}
+	if gsap.Spec.Weight < 0 {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueInvalid,
+			Field:   "weight",
+			Message: "weight should be zero or greater",
+		})
+	}
+	for i, endpoint := range gsap.Spec.ConnectionInfo.AllocationEndpoints {
+		if err := validateAllocationEndpoint(endpoint); err != nil {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   fmt.Sprintf("connectionInfo.allocationEndpoints[%d]", i),
+				Message: err.Error(),
+			})
+		}
+	}
+
+	return causes, len(causes) == 0
+}
+
+// validateAllocationEndpoint checks that an allocation endpoint is either a http(s) url,
+// or a host with an optional port
+func validateAllocationEndpoint(endpoint string) error {
+	if strings.TrimSpace(endpoint) == "" {
+		return fmt.Errorf("allocation endpoint should not be empty")
+	}
+	if strings.ContainsAny(endpoint, " \t\n") {
+		return fmt.Errorf("allocation endpoint %q should not contain whitespace", endpoint)
+	}
+	raw := endpoint
+	if !strings.Contains(raw, "://") {
+		raw = "https://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("allocation endpoint %q is not a valid url", endpoint)
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("allocation endpoint %q should use the https or http scheme", endpoint)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("allocation endpoint %q should have a host", endpoint)
+	}
+	return nil
+}
+
 // clusterToPolicy map type definition for cluster to policy map
 type clusterToPolicy map[string][]*GameServerAllocationPolicy
 
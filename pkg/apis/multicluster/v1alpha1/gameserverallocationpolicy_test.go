@@ -277,3 +277,102 @@ func TestConnectionInfoIterator(t *testing.T) {
 		})
 	}
 }
+
+func TestConnectionInfoIteratorWeightedDistribution(t *testing.T) {
+	t.Parallel()
+
+	policies := []*GameServerAllocationPolicy{
+		{
+			Spec: GameServerAllocationPolicySpec{
+				Priority:       1,
+				Weight:         70,
+				ConnectionInfo: ClusterConnectionInfo{ClusterName: "cluster1"},
+			},
+		},
+		{
+			Spec: GameServerAllocationPolicySpec{
+				Priority:       1,
+				Weight:         30,
+				ConnectionInfo: ClusterConnectionInfo{ClusterName: "cluster2"},
+			},
+		},
+		{
+			Spec: GameServerAllocationPolicySpec{
+				Priority:       2,
+				Weight:         100,
+				ConnectionInfo: ClusterConnectionInfo{ClusterName: "cluster3"},
+			},
+		},
+	}
+
+	const iterations = 10000
+	first := map[string]int{}
+	for i := 0; i < iterations; i++ {
+		iterator := NewConnectionInfoIterator(policies)
+		cluster := iterator.Next().ClusterName
+		first[cluster]++
+
+		// the other cluster of the same priority always comes next, before lower priorities
+		second := iterator.Next().ClusterName
+		assert.Contains(t, []string{"cluster1", "cluster2"}, second)
+		assert.NotEqual(t, cluster, second)
+		assert.Equal(t, "cluster3", iterator.Next().ClusterName)
+		assert.Nil(t, iterator.Next())
+	}
+
+	assert.Zero(t, first["cluster3"])
+	// 70/30 split, with a wide margin for randomness
+	assert.InDelta(t, 7000, first["cluster1"], 500)
+	assert.InDelta(t, 3000, first["cluster2"], 500)
+}
+
+func TestGameServerAllocationPolicyValidate(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]struct {
+		spec   GameServerAllocationPolicySpec
+		fields []string
+	}{
+		"valid": {
+			spec: GameServerAllocationPolicySpec{
+				Priority: 0,
+				Weight:   0,
+				ConnectionInfo: ClusterConnectionInfo{
+					AllocationEndpoints: []string{"https://allocator.example.com/v1alpha1/gameserverallocation", "allocator.example.com:8443", "10.0.0.1"},
+				},
+			},
+		},
+		"negative priority and weight": {
+			spec:   GameServerAllocationPolicySpec{Priority: -1, Weight: -5},
+			fields: []string{"priority", "weight"},
+		},
+		"invalid endpoints": {
+			spec: GameServerAllocationPolicySpec{
+				ConnectionInfo: ClusterConnectionInfo{
+					AllocationEndpoints: []string{"", "ftp://allocator.example.com", "https://", "allocator example.com", "https://[::1"},
+				},
+			},
+			fields: []string{
+				"connectionInfo.allocationEndpoints[0]",
+				"connectionInfo.allocationEndpoints[1]",
+				"connectionInfo.allocationEndpoints[2]",
+				"connectionInfo.allocationEndpoints[3]",
+				"connectionInfo.allocationEndpoints[4]",
+			},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			policy := &GameServerAllocationPolicy{Spec: v.spec}
+			causes, ok := policy.Validate()
+			assert.Equal(t, len(v.fields) == 0, ok)
+
+			var fields []string
+			for _, cause := range causes {
+				fields = append(fields, cause.Field)
+			}
+			assert.Equal(t, v.fields, fields)
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Reason is a machine readable reason for why an operation on an Agones resource
+// did not succeed. The same Reason is used in resource statuses, events, API responses
+// and metrics labels, so automation can branch on it rather than parsing error messages.
+type Reason string
+
+const (
+	// ReasonNoCapacity is when there is no Ready GameServer that matches an allocation
+	ReasonNoCapacity Reason = "NoCapacity"
+	// ReasonContention is when the GameServer chosen for an allocation was allocated by
+	// another request first. Retrying the allocation should succeed.
+	ReasonContention Reason = "Contention"
+	// ReasonStaleCache is when an allocation was rejected, because the cache of Ready
+	// GameServers is behind the API server. Retrying the allocation should succeed.
+	ReasonStaleCache Reason = "StaleCache"
+	// ReasonSelectorInvalid is when a label selector of a request can not be parsed
+	ReasonSelectorInvalid Reason = "SelectorInvalid"
+	// ReasonQuotaExceeded is when a resource could not be created, because it would exceed a ResourceQuota
+	ReasonQuotaExceeded Reason = "QuotaExceeded"
+	// ReasonPodInvalid is when the Pod of a GameServer is rejected by the API server as invalid
+	ReasonPodInvalid Reason = "PodInvalid"
+	// ReasonAcknowledgeTimeout is when the game server did not acknowledge its allocation in time
+	ReasonAcknowledgeTimeout Reason = "AcknowledgeTimeout"
+	// ReasonRateLimited is when an allocation was rejected, because the allocation rate limit
+	// of its namespace or Fleet was exceeded. Retrying the allocation later should succeed.
+	ReasonRateLimited Reason = "RateLimited"
+	// ReasonMetaPatchConflict is when the GameServer chosen for an allocation with the FailOnConflict
+	// merge policy already has one of the labels or annotations of the allocation, with a different value
+	ReasonMetaPatchConflict Reason = "MetaPatchConflict"
+	// ReasonMetaPatchProtected is when an allocation was rejected, because it patches a label or
+	// annotation with a prefix that is protected from being patched by allocations
+	ReasonMetaPatchProtected Reason = "MetaPatchProtected"
+	// ReasonSelectorMatchesNothing is when there is no Ready GameServer that matches an allocation, because its
+	// required selector matches no GameServer and no Fleet at all, such as when it has a typo in it
+	ReasonSelectorMatchesNothing Reason = "SelectorMatchesNothing"
+)
+
+// ReasonError is an error that happened for one of the known Reasons
+type ReasonError struct {
+	Reason Reason
+	Err    error
+}
+
+// NewReasonError returns an error that wraps err with the Reason it happened for
+func NewReasonError(reason Reason, err error) error {
+	return &ReasonError{Reason: reason, Err: err}
+}
+
+// Error returns the message of the wrapped error
+func (e *ReasonError) Error() string {
+	return e.Err.Error()
+}
+
+// Cause returns the wrapped error, so errors.Cause can unwrap a ReasonError
+func (e *ReasonError) Cause() error {
+	return e.Err
+}
+
+// ReasonForError returns the Reason of the first ReasonError in the chain of
+// causes of err, or an empty Reason if there is none
+func ReasonForError(err error) Reason {
+	for err != nil {
+		if re, ok := err.(*ReasonError); ok {
+			return re.Reason
+		}
+		causer, ok := err.(interface{ Cause() error })
+		if !ok {
+			return ""
+		}
+		err = causer.Cause()
+	}
+	return ""
+}
+
+// FromAPIError returns an error returned by the Kubernetes API server as a ReasonError if the
+// request was rejected for one of the known Reasons, or err as is otherwise
+func FromAPIError(err error) error {
+	if err == nil {
+		return nil
+	}
+	status, ok := errors.Cause(err).(k8serrors.APIStatus)
+	if !ok || status.Status().Reason != metav1.StatusReasonForbidden {
+		return err
+	}
+	// the ResourceQuota admission controller rejects requests as Forbidden, and only
+	// the message tells its rejections apart from the other Forbidden ones
+	if strings.Contains(status.Status().Message, "exceeded quota") {
+		return NewReasonError(ReasonQuotaExceeded, err)
+	}
+	return err
+}
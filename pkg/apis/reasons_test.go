@@ -0,0 +1,50 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestReasonForError(t *testing.T) {
+	t.Parallel()
+
+	err := NewReasonError(ReasonPodInvalid, errors.New("bad pod"))
+	assert.Equal(t, ReasonPodInvalid, ReasonForError(err))
+	assert.Equal(t, ReasonPodInvalid, ReasonForError(errors.Wrap(err, "wrapped")))
+	assert.Equal(t, "wrapped: bad pod", errors.Wrap(err, "wrapped").Error())
+	assert.Equal(t, Reason(""), ReasonForError(errors.New("no reason")))
+	assert.Equal(t, Reason(""), ReasonForError(nil))
+}
+
+func TestFromAPIError(t *testing.T) {
+	t.Parallel()
+
+	quota := k8serrors.NewForbidden(corev1.Resource("pods"), "test", errors.New("exceeded quota: compute-resources"))
+	assert.Equal(t, ReasonQuotaExceeded, ReasonForError(FromAPIError(quota)))
+	assert.Equal(t, ReasonQuotaExceeded, ReasonForError(FromAPIError(errors.Wrap(quota, "wrapped"))))
+
+	forbidden := k8serrors.NewForbidden(corev1.Resource("pods"), "test", errors.New("not allowed"))
+	assert.Equal(t, forbidden, FromAPIError(forbidden))
+
+	other := errors.New("exceeded quota")
+	assert.Equal(t, other, FromAPIError(other))
+	assert.Nil(t, FromAPIError(nil))
+}
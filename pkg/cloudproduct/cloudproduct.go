@@ -0,0 +1,178 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudproduct adapts the Pods of GameServers to the constraints of the
+// managed Kubernetes product that Agones runs on
+package cloudproduct
+
+import (
+	"fmt"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// AutoDetect detects the cloud product from the cluster
+	AutoDetect = "auto"
+	// Generic is any Kubernetes cluster that lets Pods use host ports
+	Generic = "generic"
+	// GKEAutopilot is a GKE Autopilot cluster, which does not let Pods use host ports and requires a seccomp profile
+	GKEAutopilot = "gke-autopilot"
+	// EKSFargate is an EKS cluster that runs its Pods on Fargate, which does not let Pods use host ports
+	EKSFargate = "eks-fargate"
+
+	// autopilotWebhook is the mutating webhook that GKE installs on Autopilot clusters to default their workloads
+	autopilotWebhook = "workload-defaulter.config.common-webhooks.networking.gke.io"
+	// fargateComputeTypeLabel is the label of the EKS nodes that tells if they are Fargate nodes
+	fargateComputeTypeLabel = "eks.amazonaws.com/compute-type"
+)
+
+// CloudProduct is the managed Kubernetes product that Agones runs on,
+// and the constraints it puts on the Pods of GameServers
+type CloudProduct interface {
+	// Name returns the name of the cloud product
+	Name() string
+	// UsesHostPorts returns whether GameServers are reached on the host ports that Agones allocates on their
+	// node, or else on the IP and container ports of their Pod
+	UsesHostPorts() bool
+	// ValidateGameServerSpec returns the causes why the GameServers of the spec can not run on the cloud product
+	ValidateGameServerSpec(gss *agonesv1.GameServerSpec) []metav1.StatusCause
+	// MutateGameServerPod adapts the Pod of a GameServer to the cloud product
+	MutateGameServerPod(pod *corev1.Pod)
+}
+
+// New returns the CloudProduct with the given name, or the one detected from the cluster for AutoDetect
+func New(product string, kubeClient kubernetes.Interface) (CloudProduct, error) {
+	if product == AutoDetect {
+		var err error
+		if product, err = detect(kubeClient); err != nil {
+			return nil, err
+		}
+	}
+
+	switch product {
+	case Generic:
+		return generic{}, nil
+	case GKEAutopilot:
+		return &restricted{name: GKEAutopilot, seccompProfile: corev1.SeccompProfileRuntimeDefault}, nil
+	case EKSFargate:
+		return &restricted{name: EKSFargate}, nil
+	}
+	return nil, errors.Errorf("unknown cloud product %q, must be one of %s, %s, %s or %s", product, AutoDetect, Generic, GKEAutopilot, EKSFargate)
+}
+
+// detect returns the name of the cloud product of the cluster: GKE Autopilot if it has the Autopilot workload
+// defaulting webhook, EKS Fargate if all of its nodes are Fargate nodes, and Generic otherwise
+func detect(kubeClient kubernetes.Interface) (string, error) {
+	_, err := kubeClient.AdmissionregistrationV1beta1().MutatingWebhookConfigurations().Get(autopilotWebhook, metav1.GetOptions{})
+	if err == nil {
+		return GKEAutopilot, nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return "", errors.Wrap(err, "error detecting whether the cluster is a GKE Autopilot cluster")
+	}
+
+	nodes, err := kubeClient.CoreV1().Nodes().List(metav1.ListOptions{})
+	if err != nil {
+		return "", errors.Wrap(err, "error listing nodes to detect the cloud product")
+	}
+	if len(nodes.Items) == 0 {
+		return Generic, nil
+	}
+	for _, n := range nodes.Items {
+		if n.ObjectMeta.Labels[fargateComputeTypeLabel] != "fargate" {
+			return Generic, nil
+		}
+	}
+	return EKSFargate, nil
+}
+
+// generic is a cloud product without constraints on the Pods of GameServers
+type generic struct{}
+
+// Name returns the name of the cloud product
+func (generic) Name() string {
+	return Generic
+}
+
+// UsesHostPorts returns true, as GameServers are reached on host ports
+func (generic) UsesHostPorts() bool {
+	return true
+}
+
+// ValidateGameServerSpec returns no causes, as any GameServer can run
+func (generic) ValidateGameServerSpec(*agonesv1.GameServerSpec) []metav1.StatusCause {
+	return nil
+}
+
+// MutateGameServerPod leaves the Pod as it is
+func (generic) MutateGameServerPod(*corev1.Pod) {}
+
+// restricted is a cloud product that does not let Pods use host ports, so that GameServers are reached on the IP
+// and container ports of their Pod, and can only have Dynamic ports. It may also require a seccomp profile.
+type restricted struct {
+	name           string
+	seccompProfile string
+}
+
+// Name returns the name of the cloud product
+func (r *restricted) Name() string {
+	return r.name
+}
+
+// UsesHostPorts returns false, as GameServers are reached on the IP of their Pod
+func (r *restricted) UsesHostPorts() bool {
+	return false
+}
+
+// ValidateGameServerSpec rejects the ports that are not Dynamic, as their host port
+// (or the container port, for Passthrough) could not be honoured
+func (r *restricted) ValidateGameServerSpec(gss *agonesv1.GameServerSpec) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	for _, p := range gss.Ports {
+		if p.PortPolicy != agonesv1.Dynamic {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueNotSupported,
+				Field:   fmt.Sprintf("%s.portPolicy", p.Name),
+				Message: fmt.Sprintf("PortPolicy must be %s on %s, as Pods can not use host ports", agonesv1.Dynamic, r.name),
+			})
+		}
+	}
+	return causes
+}
+
+// MutateGameServerPod removes the host ports of the containers of the Pod,
+// and sets its seccomp profile if the cloud product requires one and it is not set
+func (r *restricted) MutateGameServerPod(pod *corev1.Pod) {
+	for i := range pod.Spec.Containers {
+		for j := range pod.Spec.Containers[i].Ports {
+			pod.Spec.Containers[i].Ports[j].HostPort = 0
+		}
+	}
+
+	if r.seccompProfile == "" {
+		return
+	}
+	if pod.ObjectMeta.Annotations == nil {
+		pod.ObjectMeta.Annotations = map[string]string{}
+	}
+	if _, ok := pod.ObjectMeta.Annotations[corev1.SeccompPodAnnotationKey]; !ok {
+		pod.ObjectMeta.Annotations[corev1.SeccompPodAnnotationKey] = r.seccompProfile
+	}
+}
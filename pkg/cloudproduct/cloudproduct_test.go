@@ -0,0 +1,132 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudproduct
+
+import (
+	"testing"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"github.com/stretchr/testify/assert"
+	admregv1beta1 "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	fargateNode := func(name string) runtime.Object {
+		return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{fargateComputeTypeLabel: "fargate"}}}
+	}
+
+	fixtures := map[string]struct {
+		product       string
+		objects       []runtime.Object
+		expected      string
+		usesHostPorts bool
+		err           bool
+	}{
+		"generic":                 {product: Generic, expected: Generic, usesHostPorts: true},
+		"gke autopilot":           {product: GKEAutopilot, expected: GKEAutopilot},
+		"eks fargate":             {product: EKSFargate, expected: EKSFargate},
+		"unknown":                 {product: "openshift", err: true},
+		"detect without nodes":    {product: AutoDetect, expected: Generic, usesHostPorts: true},
+		"detect generic":          {product: AutoDetect, objects: []runtime.Object{&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node"}}}, expected: Generic, usesHostPorts: true},
+		"detect mixed eks nodes":  {product: AutoDetect, objects: []runtime.Object{fargateNode("fargate"), &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "ec2"}}}, expected: Generic, usesHostPorts: true},
+		"detect eks fargate":      {product: AutoDetect, objects: []runtime.Object{fargateNode("fargate-1"), fargateNode("fargate-2")}, expected: EKSFargate},
+		"detect gke autopilot":    {product: AutoDetect, objects: []runtime.Object{&admregv1beta1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: autopilotWebhook}}}, expected: GKEAutopilot},
+		"detect other webhooks":   {product: AutoDetect, objects: []runtime.Object{&admregv1beta1.MutatingWebhookConfiguration{ObjectMeta: metav1.ObjectMeta{Name: "agones-mutation-webhook"}}}, expected: Generic, usesHostPorts: true},
+		"configured over cluster": {product: Generic, objects: []runtime.Object{fargateNode("fargate")}, expected: Generic, usesHostPorts: true},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			product, err := New(v.product, kubefake.NewSimpleClientset(v.objects...))
+			if v.err {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, v.expected, product.Name())
+			assert.Equal(t, v.usesHostPorts, product.UsesHostPorts())
+		})
+	}
+}
+
+func TestValidateGameServerSpec(t *testing.T) {
+	t.Parallel()
+
+	gss := &agonesv1.GameServerSpec{Ports: []agonesv1.GameServerPort{
+		{Name: "dynamic", PortPolicy: agonesv1.Dynamic, ContainerPort: 7777},
+		{Name: "static", PortPolicy: agonesv1.Static, ContainerPort: 7778, HostPort: 7778},
+		{Name: "passthrough", PortPolicy: agonesv1.Passthrough},
+	}}
+
+	generic, err := New(Generic, nil)
+	assert.Nil(t, err)
+	assert.Empty(t, generic.ValidateGameServerSpec(gss))
+
+	for _, name := range []string{GKEAutopilot, EKSFargate} {
+		product, err := New(name, nil)
+		assert.Nil(t, err)
+		causes := product.ValidateGameServerSpec(gss)
+		if assert.Len(t, causes, 2, name) {
+			assert.Equal(t, "static.portPolicy", causes[0].Field)
+			assert.Equal(t, "passthrough.portPolicy", causes[1].Field)
+			assert.Equal(t, metav1.CauseTypeFieldValueNotSupported, causes[0].Type)
+		}
+		assert.Empty(t, product.ValidateGameServerSpec(&agonesv1.GameServerSpec{Ports: gss.Ports[:1]}), name)
+	}
+}
+
+func TestMutateGameServerPod(t *testing.T) {
+	t.Parallel()
+
+	newPod := func() *corev1.Pod {
+		return &corev1.Pod{Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "gameserver", Ports: []corev1.ContainerPort{{ContainerPort: 7777, HostPort: 7001}}},
+			{Name: "agones-gameserver-sidecar"},
+		}}}
+	}
+
+	generic, err := New(Generic, nil)
+	assert.Nil(t, err)
+	pod := newPod()
+	generic.MutateGameServerPod(pod)
+	assert.Equal(t, newPod(), pod)
+
+	autopilot, err := New(GKEAutopilot, nil)
+	assert.Nil(t, err)
+	pod = newPod()
+	autopilot.MutateGameServerPod(pod)
+	assert.Equal(t, int32(0), pod.Spec.Containers[0].Ports[0].HostPort)
+	assert.Equal(t, int32(7777), pod.Spec.Containers[0].Ports[0].ContainerPort)
+	assert.Equal(t, corev1.SeccompProfileRuntimeDefault, pod.ObjectMeta.Annotations[corev1.SeccompPodAnnotationKey])
+
+	// a profile set by the template is kept
+	pod = newPod()
+	pod.ObjectMeta.Annotations = map[string]string{corev1.SeccompPodAnnotationKey: corev1.DeprecatedSeccompProfileDockerDefault}
+	autopilot.MutateGameServerPod(pod)
+	assert.Equal(t, corev1.DeprecatedSeccompProfileDockerDefault, pod.ObjectMeta.Annotations[corev1.SeccompPodAnnotationKey])
+
+	fargate, err := New(EKSFargate, nil)
+	assert.Nil(t, err)
+	pod = newPod()
+	fargate.MutateGameServerPod(pod)
+	assert.Equal(t, int32(0), pod.Spec.Containers[0].Ports[0].HostPort)
+	assert.Empty(t, pod.ObjectMeta.Annotations)
+}
@@ -0,0 +1,112 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"sync"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
+	informeragonesv1 "agones.dev/agones/pkg/client/informers/externalversions/agones/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// AllocationHistory tells how many GameServers of a Fleet were allocated recently,
+// from which Predictive policies compute its allocation rate
+type AllocationHistory interface {
+	// AllocatedSince returns how many GameServers of the Fleet were moved to Allocated after the given time
+	AllocatedSince(namespace, fleetName string, since time.Time) int32
+}
+
+// allocationBucket is the number of GameServers of a Fleet that were allocated within a second
+type allocationBucket struct {
+	second int64
+	count  int32
+}
+
+// gameServerAllocations is the AllocationHistory of the GameServers that the controller sees move to Allocated,
+// for the longest window of a Predictive policy
+type gameServerAllocations struct {
+	mu sync.Mutex
+	// buckets are the allocations of each Fleet, by its namespace and name, in order
+	buckets map[string][]allocationBucket
+	now     func() time.Time
+}
+
+var _ AllocationHistory = &gameServerAllocations{}
+
+// newGameServerAllocations returns a gameServerAllocations that records the allocations of the GameServers
+// of the informer
+func newGameServerAllocations(gameServers informeragonesv1.GameServerInformer) *gameServerAllocations {
+	a := &gameServerAllocations{buckets: map[string][]allocationBucket{}, now: time.Now}
+	gameServers.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldGs := oldObj.(*agonesv1.GameServer)
+			newGs := newObj.(*agonesv1.GameServer)
+			if oldGs.Status.State == agonesv1.GameServerStateAllocated || newGs.Status.State != agonesv1.GameServerStateAllocated {
+				return
+			}
+			if fleetName, ok := newGs.ObjectMeta.Labels[agonesv1.FleetNameLabel]; ok {
+				a.record(newGs.ObjectMeta.Namespace, fleetName, a.now())
+			}
+		},
+	})
+	return a
+}
+
+// record records that a GameServer of the Fleet was allocated at the given time,
+// and forgets the allocations older than the longest window of a Predictive policy
+func (a *gameServerAllocations) record(namespace, fleetName string, at time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := namespace + "/" + fleetName
+	buckets := a.buckets[key]
+	second := at.Unix()
+	if n := len(buckets); n > 0 && buckets[n-1].second == second {
+		buckets[n-1].count++
+	} else {
+		buckets = append(buckets, allocationBucket{second: second, count: 1})
+	}
+
+	oldest := at.Add(-autoscalingv1.MaxPredictiveWindow).Unix()
+	i := 0
+	for ; i < len(buckets) && buckets[i].second < oldest; i++ {
+	}
+	a.buckets[key] = buckets[i:]
+}
+
+// AllocatedSince returns how many GameServers of the Fleet were moved to Allocated after the given time
+func (a *gameServerAllocations) AllocatedSince(namespace, fleetName string, since time.Time) int32 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var count int32
+	for _, b := range a.buckets[namespace+"/"+fleetName] {
+		if b.second >= since.Unix() {
+			count += b.count
+		}
+	}
+	return count
+}
+
+// forget forgets the allocations of a Fleet that was deleted
+func (a *gameServerAllocations) forget(namespace, fleetName string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	delete(a.buckets, namespace+"/"+fleetName)
+}
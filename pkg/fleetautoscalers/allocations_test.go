@@ -0,0 +1,95 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestGameServerAllocationsRecord(t *testing.T) {
+	t.Parallel()
+
+	m := agtesting.NewMocks()
+	a := newGameServerAllocations(m.AgonesInformerFactory.Agones().V1().GameServers())
+	now := time.Date(2019, time.July, 12, 18, 0, 0, 0, time.UTC)
+
+	a.record("default", "fleet-1", now.Add(-2*time.Hour))
+	a.record("default", "fleet-1", now.Add(-10*time.Minute))
+	a.record("default", "fleet-1", now.Add(-time.Minute))
+	a.record("default", "fleet-1", now.Add(-time.Minute))
+	a.record("default", "fleet-1", now)
+	a.record("default", "fleet-2", now)
+
+	// allocations older than the longest window are forgotten, and those within a second share a bucket
+	assert.Len(t, a.buckets["default/fleet-1"], 3)
+	assert.Equal(t, int32(4), a.AllocatedSince("default", "fleet-1", now.Add(-3*time.Hour)))
+	assert.Equal(t, int32(3), a.AllocatedSince("default", "fleet-1", now.Add(-5*time.Minute)))
+	assert.Equal(t, int32(1), a.AllocatedSince("default", "fleet-1", now))
+	assert.Equal(t, int32(1), a.AllocatedSince("default", "fleet-2", now.Add(-5*time.Minute)))
+	assert.Equal(t, int32(0), a.AllocatedSince("other", "fleet-1", now.Add(-5*time.Minute)))
+
+	a.forget("default", "fleet-1")
+	assert.Equal(t, int32(0), a.AllocatedSince("default", "fleet-1", now.Add(-5*time.Minute)))
+	assert.Equal(t, int32(1), a.AllocatedSince("default", "fleet-2", now.Add(-5*time.Minute)))
+}
+
+func TestGameServerAllocationsInformer(t *testing.T) {
+	t.Parallel()
+
+	m := agtesting.NewMocks()
+	gsWatch := watch.NewFake()
+	m.AgonesClient.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(gsWatch, nil))
+
+	gameServers := m.AgonesInformerFactory.Agones().V1().GameServers()
+	a := newGameServerAllocations(gameServers)
+	now := time.Date(2019, time.July, 12, 18, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	_, cancel := agtesting.StartInformers(m, gameServers.Informer().HasSynced)
+	defer cancel()
+
+	gs := func(name, fleetName string, state agonesv1.GameServerState) *agonesv1.GameServer {
+		gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{}},
+			Status: agonesv1.GameServerStatus{State: state}}
+		if fleetName != "" {
+			gs.ObjectMeta.Labels[agonesv1.FleetNameLabel] = fleetName
+		}
+		return gs
+	}
+
+	gsWatch.Add(gs("gs-1", "fleet-1", agonesv1.GameServerStateReady))
+	gsWatch.Add(gs("gs-2", "", agonesv1.GameServerStateReady))
+	gsWatch.Modify(gs("gs-1", "fleet-1", agonesv1.GameServerStateAllocated))
+	gsWatch.Modify(gs("gs-2", "", agonesv1.GameServerStateAllocated))
+	// a resync of an Allocated GameServer is not another allocation
+	gsWatch.Modify(gs("gs-1", "fleet-1", agonesv1.GameServerStateAllocated))
+	gsWatch.Add(gs("gs-3", "fleet-1", agonesv1.GameServerStateReady))
+
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := gameServers.Lister().GameServers("default").Get("gs-3")
+		return err == nil, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), a.AllocatedSince("default", "fleet-1", now.Add(-time.Minute)))
+}
@@ -0,0 +1,172 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"sync"
+	"time"
+
+	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// recommendation is the number of replicas that the policy of an autoscaler computed at a time
+type recommendation struct {
+	replicas  int32
+	timestamp time.Time
+}
+
+// scaleEvent is a change in the replicas of the fleet of an autoscaler, positive when it was scaled up
+type scaleEvent struct {
+	change    int32
+	timestamp time.Time
+}
+
+// scalingHistory remembers the recent recommendations and scale events of the autoscalers with a behavior,
+// by the key of the autoscaler, to apply their stabilization windows and max changes
+type scalingHistory struct {
+	mu              sync.Mutex
+	recommendations map[string][]recommendation
+	events          map[string][]scaleEvent
+}
+
+// newScalingHistory returns an empty scalingHistory
+func newScalingHistory() *scalingHistory {
+	return &scalingHistory{
+		recommendations: map[string][]recommendation{},
+		events:          map[string][]scaleEvent{},
+	}
+}
+
+// stabilize remembers the replicas that the policy of the autoscaler computed, and returns the replicas that the
+// fleet is scaled to within the stabilization windows and the max changes of its behavior
+func (h *scalingHistory) stabilize(key string, behavior *autoscalingv1.FleetAutoscalerBehavior, current, desired int32, now time.Time) int32 {
+	if behavior == nil {
+		// the behavior may have been removed from the autoscaler
+		h.forget(key)
+		return desired
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	upWindow := stabilizationWindow(behavior.ScaleUp)
+	downWindow := stabilizationWindow(behavior.ScaleDown)
+	longest := upWindow
+	if downWindow > longest {
+		longest = downWindow
+	}
+
+	// the fleet is scaled up to the fewest replicas recommended within the scale up window,
+	// and down to the most replicas recommended within the scale down window
+	recommendations := []recommendation{{replicas: desired, timestamp: now}}
+	up, down := desired, desired
+	for _, r := range h.recommendations[key] {
+		age := now.Sub(r.timestamp)
+		if age > longest {
+			continue
+		}
+		recommendations = append(recommendations, r)
+		if age <= upWindow && r.replicas < up {
+			up = r.replicas
+		}
+		if age <= downWindow && r.replicas > down {
+			down = r.replicas
+		}
+	}
+	h.recommendations[key] = recommendations
+
+	replicas := current
+	if replicas < up {
+		replicas = up
+	}
+	if replicas > down {
+		replicas = down
+	}
+
+	if replicas > current {
+		if allowed, ok := h.maxChange(key, behavior.ScaleUp, current, now, 1); ok && replicas-current > allowed {
+			replicas = current + allowed
+		}
+	} else if replicas < current {
+		if allowed, ok := h.maxChange(key, behavior.ScaleDown, current, now, -1); ok && current-replicas > allowed {
+			replicas = current - allowed
+		}
+	}
+	return replicas
+}
+
+// maxChange returns how many more replicas the fleet can be scaled by in the direction of sign, within the
+// period of the rules, and false if it is not limited
+func (h *scalingHistory) maxChange(key string, rules *autoscalingv1.ScalingRules, current int32, now time.Time, sign int32) (int32, bool) {
+	if rules == nil || rules.MaxChange == nil {
+		return 0, false
+	}
+	limit, err := intstr.GetValueFromIntOrPercent(rules.MaxChange, int(current), true)
+	if err != nil {
+		return 0, false
+	}
+	// a percentage of a fleet without replicas could never scale it up
+	if limit < 1 {
+		limit = 1
+	}
+
+	allowed := int32(limit)
+	period := rules.Period()
+	for _, e := range h.events[key] {
+		if now.Sub(e.timestamp) <= period && e.change*sign > 0 {
+			allowed -= e.change * sign
+		}
+	}
+	if allowed < 0 {
+		allowed = 0
+	}
+	return allowed, true
+}
+
+// recordScale remembers that the fleet of the autoscaler was scaled by change
+func (h *scalingHistory) recordScale(key string, behavior *autoscalingv1.FleetAutoscalerBehavior, change int32, now time.Time) {
+	if behavior == nil || change == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	events := []scaleEvent{{change: change, timestamp: now}}
+	for _, e := range h.events[key] {
+		if now.Sub(e.timestamp) <= autoscalingv1.MaxScalingPeriodSeconds*time.Second {
+			events = append(events, e)
+		}
+	}
+	h.events[key] = events
+}
+
+// forget forgets the recommendations and scale events of an autoscaler that was deleted, or no longer has a behavior
+func (h *scalingHistory) forget(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.recommendations, key)
+	delete(h.events, key)
+}
+
+// stabilizationWindow returns the stabilization window of the rules, which is none if they are not set
+func stabilizationWindow(rules *autoscalingv1.ScalingRules) time.Duration {
+	if rules == nil {
+		return 0
+	}
+	return time.Duration(rules.StabilizationWindowSeconds) * time.Second
+}
@@ -0,0 +1,100 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"testing"
+	"time"
+
+	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestScalingHistoryStabilize(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2019, time.July, 10, 10, 30, 0, 0, time.UTC)
+	at := func(seconds int) time.Time {
+		return start.Add(time.Duration(seconds) * time.Second)
+	}
+	maxChange := func(v intstr.IntOrString) *intstr.IntOrString {
+		return &v
+	}
+
+	t.Run("no behavior", func(t *testing.T) {
+		h := newScalingHistory()
+		assert.Equal(t, int32(2), h.stabilize("default/fas", nil, 10, 2, start))
+		assert.Empty(t, h.recommendations)
+	})
+
+	t.Run("scale down stabilization window", func(t *testing.T) {
+		h := newScalingHistory()
+		behavior := &autoscalingv1.FleetAutoscalerBehavior{ScaleDown: &autoscalingv1.ScalingRules{StabilizationWindowSeconds: 60}}
+
+		assert.Equal(t, int32(10), h.stabilize("default/fas", behavior, 10, 10, at(0)))
+		// a brief dip in allocations doesn't scale the fleet down
+		assert.Equal(t, int32(10), h.stabilize("default/fas", behavior, 10, 6, at(10)))
+		assert.Equal(t, int32(10), h.stabilize("default/fas", behavior, 10, 8, at(30)))
+		// scaling up is not stabilized
+		assert.Equal(t, int32(12), h.stabilize("default/fas", behavior, 10, 12, at(40)))
+		assert.Equal(t, int32(12), h.stabilize("default/fas", behavior, 12, 8, at(50)))
+		// down to the most replicas of the last 60 seconds
+		assert.Equal(t, int32(8), h.stabilize("default/fas", behavior, 12, 6, at(101)))
+		assert.Equal(t, int32(6), h.stabilize("default/fas", behavior, 8, 6, at(200)))
+
+		// other autoscalers are stabilized separately
+		assert.Equal(t, int32(6), h.stabilize("default/other", behavior, 10, 6, at(200)))
+
+		h.forget("default/fas")
+		assert.NotContains(t, h.recommendations, "default/fas")
+	})
+
+	t.Run("scale up stabilization window", func(t *testing.T) {
+		h := newScalingHistory()
+		behavior := &autoscalingv1.FleetAutoscalerBehavior{ScaleUp: &autoscalingv1.ScalingRules{StabilizationWindowSeconds: 30}}
+
+		assert.Equal(t, int32(5), h.stabilize("default/fas", behavior, 5, 5, at(0)))
+		assert.Equal(t, int32(5), h.stabilize("default/fas", behavior, 5, 9, at(10)))
+		assert.Equal(t, int32(9), h.stabilize("default/fas", behavior, 5, 9, at(31)))
+		// scaling down is not stabilized
+		assert.Equal(t, int32(2), h.stabilize("default/fas", behavior, 9, 2, at(40)))
+	})
+
+	t.Run("max change", func(t *testing.T) {
+		h := newScalingHistory()
+		behavior := &autoscalingv1.FleetAutoscalerBehavior{
+			ScaleUp:   &autoscalingv1.ScalingRules{MaxChange: maxChange(intstr.FromInt(4)), PeriodSeconds: 60},
+			ScaleDown: &autoscalingv1.ScalingRules{MaxChange: maxChange(intstr.FromString("10%"))},
+		}
+
+		assert.Equal(t, int32(14), h.stabilize("default/fas", behavior, 10, 20, at(0)))
+		h.recordScale("default/fas", behavior, 4, at(0))
+		// the change is used up for the period
+		assert.Equal(t, int32(14), h.stabilize("default/fas", behavior, 14, 20, at(30)))
+		assert.Equal(t, int32(18), h.stabilize("default/fas", behavior, 14, 20, at(61)))
+		h.recordScale("default/fas", behavior, 4, at(61))
+
+		// scaling down is limited to 10% of 18 replicas, rounded up, independently of scaling up
+		assert.Equal(t, int32(16), h.stabilize("default/fas", behavior, 18, 5, at(62)))
+		h.recordScale("default/fas", behavior, -2, at(62))
+		assert.Equal(t, int32(16), h.stabilize("default/fas", behavior, 16, 5, at(70)))
+		assert.Equal(t, int32(14), h.stabilize("default/fas", behavior, 16, 5, at(123)))
+
+		// a percentage of a fleet without replicas still scales it up
+		behavior.ScaleUp.MaxChange = maxChange(intstr.FromString("50%"))
+		assert.Equal(t, int32(1), h.stabilize("default/empty", behavior, 0, 5, at(0)))
+	})
+}
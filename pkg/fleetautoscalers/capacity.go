@@ -0,0 +1,169 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"math"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// nodeCapacity tells how many GameServers of a fleet the nodes of the cluster have room for, from the resources
+// requested by the Pods on them. It is an estimate: it only accounts for the node selector and tolerations of the
+// template of the fleet, and not for its affinities or host ports.
+type nodeCapacity struct {
+	nodeLister corelisterv1.NodeLister
+	nodeSynced cache.InformerSynced
+	podLister  corelisterv1.PodLister
+	podSynced  cache.InformerSynced
+}
+
+// newNodeCapacity returns a nodeCapacity for the nodes and Pods of the informer factory
+func newNodeCapacity(kubeInformerFactory informers.SharedInformerFactory) *nodeCapacity {
+	nodes := kubeInformerFactory.Core().V1().Nodes()
+	pods := kubeInformerFactory.Core().V1().Pods()
+	return &nodeCapacity{
+		nodeLister: nodes.Lister(),
+		nodeSynced: nodes.Informer().HasSynced,
+		podLister:  pods.Lister(),
+		podSynced:  pods.Informer().HasSynced,
+	}
+}
+
+// maxReplicas returns how many replicas the fleet can have with the capacity of the nodes: its GameServers whose
+// Pods are already scheduled, and as many more as there is room for. It returns false if it can not be told, as the
+// template of the fleet does not request any resources.
+func (n *nodeCapacity) maxReplicas(f *agonesv1.Fleet) (int32, bool, error) {
+	podSpec := &f.Spec.Template.Spec.Template.Spec
+	requests := podRequests(podSpec)
+	if len(requests) == 0 {
+		return 0, false, nil
+	}
+
+	pods, err := n.podLister.List(labels.Everything())
+	if err != nil {
+		return 0, false, errors.Wrap(err, "error listing pods for the capacity of the nodes")
+	}
+	nodes, err := n.nodeLister.List(labels.Everything())
+	if err != nil {
+		return 0, false, errors.Wrap(err, "error listing nodes for their capacity")
+	}
+
+	var replicas int64
+	used := map[string]corev1.ResourceList{}
+	podCounts := map[string]int64{}
+	for _, p := range pods {
+		if p.Spec.NodeName == "" || p.Status.Phase == corev1.PodSucceeded || p.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if p.ObjectMeta.Namespace == f.ObjectMeta.Namespace && p.ObjectMeta.Labels[agonesv1.FleetNameLabel] == f.ObjectMeta.Name {
+			replicas++
+		}
+		podCounts[p.Spec.NodeName]++
+		if used[p.Spec.NodeName] == nil {
+			used[p.Spec.NodeName] = corev1.ResourceList{}
+		}
+		addResources(used[p.Spec.NodeName], podRequests(&p.Spec))
+	}
+
+	selector := labels.SelectorFromSet(podSpec.NodeSelector)
+	for _, node := range nodes {
+		if !schedulable(node, podSpec) || !selector.Matches(labels.Set(node.ObjectMeta.Labels)) {
+			continue
+		}
+		replicas += nodeFit(node, requests, used[node.ObjectMeta.Name], podCounts[node.ObjectMeta.Name])
+	}
+
+	return int32(replicas), true, nil
+}
+
+// nodeFit returns how many more Pods with the requests the node has room for
+func nodeFit(node *corev1.Node, requests, used corev1.ResourceList, pods int64) int64 {
+	fit := int64(math.MaxInt64)
+	if allocatable, ok := node.Status.Allocatable[corev1.ResourcePods]; ok {
+		fit = allocatable.Value() - pods
+	}
+	for name, request := range requests {
+		allocatable := node.Status.Allocatable[name]
+		free := allocatable.DeepCopy()
+		free.Sub(used[name])
+		if n := free.MilliValue() / request.MilliValue(); n < fit {
+			fit = n
+		}
+	}
+	if fit < 0 {
+		return 0
+	}
+	return fit
+}
+
+// schedulable returns whether Pods with the spec can be scheduled on the node,
+// as it is Ready, not cordoned, and its taints are tolerated
+func schedulable(node *corev1.Node, podSpec *corev1.PodSpec) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	for _, c := range node.Status.Conditions {
+		if c.Type == corev1.NodeReady && c.Status != corev1.ConditionTrue {
+			return false
+		}
+	}
+	for i := range node.Spec.Taints {
+		taint := &node.Spec.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for j := range podSpec.Tolerations {
+			if podSpec.Tolerations[j].ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// podRequests returns the resources requested by the containers of the Pod spec
+func podRequests(podSpec *corev1.PodSpec) corev1.ResourceList {
+	requests := corev1.ResourceList{}
+	for _, c := range podSpec.Containers {
+		addResources(requests, c.Resources.Requests)
+	}
+	for name, q := range requests {
+		if q.IsZero() {
+			delete(requests, name)
+		}
+	}
+	return requests
+}
+
+// addResources adds the resources to the list
+func addResources(list, resources corev1.ResourceList) {
+	for name, q := range resources {
+		total := list[name]
+		total.Add(q)
+		list[name] = total
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"testing"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestNodeCapacityMaxReplicas(t *testing.T) {
+	t.Parallel()
+
+	_, f := defaultFixtures()
+	m := agtesting.NewMocks()
+	n := newNodeCapacity(m.KubeInformerFactory)
+
+	// without resource requests, the capacity of the nodes can't be told
+	_, ok, err := n.maxReplicas(f)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	setCapacityFixtures(m, f)
+	_, cancel := agtesting.StartInformers(m, n.nodeSynced, n.podSynced)
+	defer cancel()
+
+	// the 2 scheduled GameServers of the fleet, and room for 4 more on node-1, 1 on node-2 and 1 on tolerated
+	replicas, ok, err := n.maxReplicas(f)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int32(8), replicas)
+}
+
+// setCapacityFixtures sets resource requests on the template of the fleet, and nodes and Pods that have room for
+// 6 more of its GameServers
+func setCapacityFixtures(m agtesting.Mocks, f *agonesv1.Fleet) {
+	games := map[string]string{"pool": "games"}
+	podSpec := &f.Spec.Template.Spec.Template.Spec
+	podSpec.NodeSelector = games
+	podSpec.Tolerations = []corev1.Toleration{{Key: "agones.dev/gameservers", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoExecute}}
+	podSpec.Containers = []corev1.Container{{Name: "game", Resources: corev1.ResourceRequirements{Requests: resources("500m", "1Gi", "")}}}
+
+	ready := corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}}
+	node := func(name string, labels map[string]string, allocatable corev1.ResourceList) corev1.Node {
+		status := *ready.DeepCopy()
+		status.Allocatable = allocatable
+		return corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}, Status: status}
+	}
+	cordoned := node("cordoned", games, resources("8", "16Gi", "110"))
+	cordoned.Spec.Unschedulable = true
+	tainted := node("tainted", games, resources("8", "16Gi", "110"))
+	tainted.Spec.Taints = []corev1.Taint{{Key: "dedicated", Value: "other", Effect: corev1.TaintEffectNoSchedule}}
+	tolerated := node("tolerated", games, resources("1", "4Gi", "1"))
+	tolerated.Spec.Taints = []corev1.Taint{{Key: "agones.dev/gameservers", Value: "true", Effect: corev1.TaintEffectNoExecute}}
+	notReady := node("not-ready", games, resources("8", "16Gi", "110"))
+	notReady.Status.Conditions[0].Status = corev1.ConditionFalse
+	nodes := []corev1.Node{
+		node("node-1", games, resources("4", "8Gi", "110")),
+		node("node-2", games, resources("2", "1536Mi", "110")),
+		node("other-pool", map[string]string{"pool": "other"}, resources("8", "16Gi", "110")),
+		cordoned, tainted, tolerated, notReady,
+	}
+
+	pod := func(name, nodeName string, labels map[string]string, cpu string, phase corev1.PodPhase) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: f.ObjectMeta.Namespace, Labels: labels},
+			Spec: corev1.PodSpec{NodeName: nodeName,
+				Containers: []corev1.Container{{Name: "c", Resources: corev1.ResourceRequirements{Requests: resources(cpu, "1Gi", "")}}}},
+			Status: corev1.PodStatus{Phase: phase},
+		}
+	}
+	fleet := map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}
+	pods := []corev1.Pod{
+		pod("gs-1", "node-1", fleet, "500m", corev1.PodRunning),
+		pod("gs-2", "node-1", fleet, "500m", corev1.PodRunning),
+		pod("gs-3", "", fleet, "500m", corev1.PodPending),
+		pod("other", "node-1", nil, "1", corev1.PodRunning),
+		pod("done", "tolerated", nil, "1", corev1.PodSucceeded),
+	}
+
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: nodes}, nil
+	})
+	m.KubeClient.AddReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.PodList{Items: pods}, nil
+	})
+}
+
+// resources returns the cpu, memory and pods resources that are set
+func resources(cpu, memory, pods string) corev1.ResourceList {
+	list := corev1.ResourceList{}
+	for name, v := range map[corev1.ResourceName]string{corev1.ResourceCPU: cpu, corev1.ResourceMemory: memory, corev1.ResourcePods: pods} {
+		if v != "" {
+			list[name] = resource.MustParse(v)
+		}
+	}
+	return list
+}
@@ -17,7 +17,6 @@ package fleetautoscalers
 import (
 	"encoding/json"
 	"fmt"
-	"time"
 
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"agones.dev/agones/pkg/apis/autoscaling"
@@ -43,6 +42,9 @@ import (
 	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -62,6 +64,12 @@ type Controller struct {
 	fleetAutoscalerSynced cache.InformerSynced
 	workerqueue           *workerqueue.WorkerQueue
 	recorder              record.EventRecorder
+	syncLog               runtime.SampledLogger
+	scalingHistory        *scalingHistory
+	allocations           *gameServerAllocations
+	capacity              *nodeCapacity
+	// clock is the source of time of scaling decisions and status updates
+	clock clock.Clock
 }
 
 // NewController returns a controller for a FleetAutoscaler
@@ -69,6 +77,7 @@ func NewController(
 	wh *webhooks.WebHook,
 	health healthcheck.Handler,
 	kubeClient kubernetes.Interface,
+	kubeInformerFactory informers.SharedInformerFactory,
 	extClient extclientset.Interface,
 	agonesClient versioned.Interface,
 	agonesInformerFactory externalversions.SharedInformerFactory) *Controller {
@@ -83,6 +92,10 @@ func NewController(
 		fleetAutoscalerGetter: agonesClient.AutoscalingV1(),
 		fleetAutoscalerLister: autoscaler.Lister(),
 		fleetAutoscalerSynced: autoscaler.Informer().HasSynced,
+		scalingHistory:        newScalingHistory(),
+		allocations:           newGameServerAllocations(agonesInformerFactory.Agones().V1().GameServers()),
+		capacity:              newNodeCapacity(kubeInformerFactory),
+		clock:                 clock.RealClock{},
 	}
 	c.baseLogger = runtime.NewLoggerWithType(c)
 	c.workerqueue = workerqueue.NewWorkerQueue(c.syncFleetAutoscaler, c.baseLogger, logfields.FleetAutoscalerKey, autoscaling.GroupName+".FleetAutoscalerController")
@@ -99,14 +112,52 @@ func NewController(
 
 	autoscaler.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: c.workerqueue.Enqueue,
-		UpdateFunc: func(_, newObj interface{}) {
-			c.workerqueue.Enqueue(newObj)
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// periodic resyncs are ignored, as each autoscaler is re-evaluated on its own sync interval
+			oldFas := oldObj.(*autoscalingv1.FleetAutoscaler)
+			newFas := newObj.(*autoscalingv1.FleetAutoscaler)
+			if oldFas.ObjectMeta.ResourceVersion != newFas.ObjectMeta.ResourceVersion {
+				c.workerqueue.Enqueue(newFas)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj); err == nil {
+				c.scalingHistory.forget(key)
+			}
+		},
+	})
+
+	fleetInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			c.enqueueFleetAutoscalersForFleet(obj.(*agonesv1.Fleet))
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldFleet := oldObj.(*agonesv1.Fleet)
+			newFleet := newObj.(*agonesv1.Fleet)
+			if !apiequality.Semantic.DeepEqual(oldFleet.Status, newFleet.Status) {
+				c.enqueueFleetAutoscalersForFleet(newFleet)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			if fleet, ok := obj.(*agonesv1.Fleet); ok {
+				c.allocations.forget(fleet.ObjectMeta.Namespace, fleet.ObjectMeta.Name)
+			}
 		},
 	})
 
 	return c
 }
 
+// AllocationHistory returns how many GameServers of each Fleet the controller saw allocated recently
+func (c *Controller) AllocationHistory() AllocationHistory {
+	return c.allocations
+}
+
+// WorkQueues returns the work queues of the controller, for the statusz page
+func (c *Controller) WorkQueues() []*workerqueue.WorkerQueue {
+	return []*workerqueue.WorkerQueue{c.workerqueue}
+}
+
 // Run the FleetAutoscaler controller. Will block until stop is closed.
 // Runs threadiness number workers to process the rate limited queue
 func (c *Controller) Run(workers int, stop <-chan struct{}) error {
@@ -116,7 +167,7 @@ func (c *Controller) Run(workers int, stop <-chan struct{}) error {
 	}
 
 	c.baseLogger.Info("Wait for cache sync")
-	if !cache.WaitForCacheSync(stop, c.fleetSynced, c.fleetAutoscalerSynced) {
+	if !cache.WaitForCacheSync(stop, c.fleetSynced, c.fleetAutoscalerSynced, c.capacity.nodeSynced, c.capacity.podSynced) {
 		return errors.New("failed to wait for caches to sync")
 	}
 
@@ -124,6 +175,21 @@ func (c *Controller) Run(workers int, stop <-chan struct{}) error {
 	return nil
 }
 
+// enqueueFleetAutoscalersForFleet enqueues the autoscalers of the fleet, so they are
+// re-evaluated as soon as its status changes, rather than on their next sync interval
+func (c *Controller) enqueueFleetAutoscalersForFleet(fleet *agonesv1.Fleet) {
+	list, err := c.fleetAutoscalerLister.FleetAutoscalers(fleet.ObjectMeta.Namespace).List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(c.baseLogger.WithField("fleet", fleet.ObjectMeta.Name), errors.Wrap(err, "error listing fleetautoscalers for fleet"))
+		return
+	}
+	for _, fas := range list {
+		if fas.Spec.FleetName == fleet.ObjectMeta.Name {
+			c.workerqueue.Enqueue(fas)
+		}
+	}
+}
+
 func (c *Controller) loggerForFleetAutoscalerKey(key string) *logrus.Entry {
 	return logfields.AugmentLogEntry(c.baseLogger, logfields.FleetAutoscalerKey, key)
 }
@@ -133,7 +199,7 @@ func (c *Controller) loggerForFleetAutoscaler(fas *autoscalingv1.FleetAutoscaler
 	if fas != nil {
 		fasName = fas.Namespace + "/" + fas.Name
 	}
-	return c.loggerForFleetAutoscalerKey(fasName).WithField("fas", fas)
+	return logfields.WithObject(c.loggerForFleetAutoscalerKey(fasName), "fas", fas)
 }
 
 // validationHandler will intercept when a FleetAutoscaler is created, and
@@ -171,7 +237,7 @@ func (c *Controller) validationHandler(review admv1beta1.AdmissionReview) (admv1
 // syncFleetAutoscaler scales the attached fleet and
 // synchronizes the FleetAutoscaler CRD
 func (c *Controller) syncFleetAutoscaler(key string) error {
-	c.loggerForFleetAutoscalerKey(key).Info("Synchronising")
+	c.syncLog.Debug(c.loggerForFleetAutoscalerKey(key), "Synchronising")
 
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
@@ -190,6 +256,9 @@ func (c *Controller) syncFleetAutoscaler(key string) error {
 		return errors.Wrapf(err, "error retrieving FleetAutoscaler %s from namespace %s", name, namespace)
 	}
 
+	// evaluate the autoscaler again after its sync interval, if its fleet doesn't change before then
+	c.workerqueue.EnqueueAfter(fas, fas.GetSyncInterval())
+
 	// Retrieve the fleet by spec name
 	fleet, err := c.fleetLister.Fleets(namespace).Get(fas.Spec.FleetName)
 	if err != nil {
@@ -199,35 +268,62 @@ func (c *Controller) syncFleetAutoscaler(key string) error {
 			c.recorder.Eventf(fas, corev1.EventTypeWarning, "FailedGetFleet",
 				"could not fetch fleet: %s", fas.Spec.FleetName)
 
+			if err := c.updateStatusUnableToScale(fas, "FailedGetFleet", fmt.Sprintf("fleet %s was not found", fas.Spec.FleetName)); err != nil {
+				return err
+			}
 			// don't retry. Pick it up next sync.
-			err = nil
+			return nil
 		}
 
-		if err := c.updateStatusUnableToScale(fas); err != nil {
+		if err := c.updateStatusUnableToScale(fas, "FailedGetFleet", err.Error()); err != nil {
 			return err
 		}
 
 		return err
 	}
 
+	now := c.clock.Now()
 	currentReplicas := fleet.Status.Replicas
-	desiredReplicas, scalingLimited, err := computeDesiredFleetSize(fas, fleet)
+	desiredReplicas, scalingLimited, err := computeDesiredFleetSize(fas, fleet, now, c.allocations)
 	if err != nil {
 		c.recorder.Eventf(fas, corev1.EventTypeWarning, "FleetAutoscaler",
 			"Error calculating desired fleet size on FleetAutoscaler %s. Error: %s", fas.ObjectMeta.Name, err.Error())
+		c.recordPolicyError(fas)
 
-		if err := c.updateStatusUnableToScale(fas); err != nil {
+		if err := c.updateStatusUnableToScale(fas, "FailedComputeReplicas", err.Error()); err != nil {
 			return err
 		}
 		return errors.Wrapf(err, "error calculating autoscaling fleet: %s", fleet.ObjectMeta.Name)
 	}
 
+	var limitedReason autoscalingv1.ScalingLimitedReason
+	if scalingLimited {
+		limitedReason = autoscalingv1.ScalingLimitedByPolicy
+	}
+
+	// Limit how fast the fleet is scaled by the behavior of the autoscaler
+	desiredReplicas = c.scalingHistory.stabilize(key, fas.Spec.Behavior, fleet.Spec.Replicas, desiredReplicas, now)
+
+	// Don't scale the fleet up past the GameServers that the nodes have room for
+	if fas.Spec.LimitToNodeCapacity && desiredReplicas > fleet.Spec.Replicas {
+		if maxReplicas, ok, err := c.capacity.maxReplicas(fleet); err != nil {
+			runtime.HandleError(c.loggerForFleetAutoscaler(fas), err)
+		} else if ok && desiredReplicas > maxReplicas {
+			desiredReplicas = maxReplicas
+			if desiredReplicas < fleet.Spec.Replicas {
+				desiredReplicas = fleet.Spec.Replicas
+			}
+			limitedReason = autoscalingv1.ScalingLimitedByNodeCapacity
+		}
+	}
+
 	// Scale the fleet to the new size
 	if err = c.scaleFleet(fas, fleet, desiredReplicas); err != nil {
 		return errors.Wrapf(err, "error autoscaling fleet %s to %d replicas", fas.Spec.FleetName, desiredReplicas)
 	}
+	c.scalingHistory.recordScale(key, fas.Spec.Behavior, desiredReplicas-fleet.Spec.Replicas, now)
 
-	return c.updateStatus(fas, currentReplicas, desiredReplicas, desiredReplicas != fleet.Spec.Replicas, scalingLimited)
+	return c.updateStatus(fas, currentReplicas, desiredReplicas, desiredReplicas != fleet.Spec.Replicas, limitedReason)
 }
 
 // scaleFleet scales the fleet of the autoscaler to a new number of replicas
@@ -244,26 +340,44 @@ func (c *Controller) scaleFleet(fas *autoscalingv1.FleetAutoscaler, f *agonesv1.
 
 		c.recorder.Eventf(fas, corev1.EventTypeNormal, "AutoScalingFleet",
 			"Scaling fleet %s from %d to %d", fCopy.ObjectMeta.Name, f.Spec.Replicas, fCopy.Spec.Replicas)
+		c.recordScale(fas, f.Spec.Replicas, fCopy.Spec.Replicas)
 	}
 
 	return nil
 }
 
 // updateStatus updates the status of the given FleetAutoscaler
-func (c *Controller) updateStatus(fas *autoscalingv1.FleetAutoscaler, currentReplicas int32, desiredReplicas int32, scaled bool, scalingLimited bool) error {
+func (c *Controller) updateStatus(fas *autoscalingv1.FleetAutoscaler, currentReplicas int32, desiredReplicas int32, scaled bool, limitedReason autoscalingv1.ScalingLimitedReason) error {
 	fasCopy := fas.DeepCopy()
 	fasCopy.Status.AbleToScale = true
-	fasCopy.Status.ScalingLimited = scalingLimited
+	fasCopy.Status.ScalingLimited = limitedReason != ""
+	fasCopy.Status.ScalingLimitedReason = limitedReason
 	fasCopy.Status.CurrentReplicas = currentReplicas
 	fasCopy.Status.DesiredReplicas = desiredReplicas
+	now := metav1.NewTime(c.clock.Now())
 	if scaled {
-		now := metav1.NewTime(time.Now())
 		fasCopy.Status.LastScaleTime = &now
 	}
+	recovered := fasCopy.Status.SetCondition(autoscalingv1.FleetAutoscalerCondition{Type: autoscalingv1.FleetAutoscalerConditionAbleToScale,
+		Status: corev1.ConditionTrue, LastTransitionTime: now, Reason: "ReadyForNewScale", Message: "the size of the fleet was computed and applied"})
+	if limitedReason != "" {
+		fasCopy.Status.SetCondition(autoscalingv1.FleetAutoscalerCondition{Type: autoscalingv1.FleetAutoscalerConditionScalingLimited,
+			Status: corev1.ConditionTrue, LastTransitionTime: now, Reason: string(limitedReason),
+			Message: fmt.Sprintf("the desired replicas were limited to %d", desiredReplicas)})
+	} else {
+		fasCopy.Status.SetCondition(autoscalingv1.FleetAutoscalerCondition{Type: autoscalingv1.FleetAutoscalerConditionScalingLimited,
+			Status: corev1.ConditionFalse, LastTransitionTime: now, Reason: "DesiredWithinRange", Message: "the desired replicas are within the acceptable range"})
+	}
 
 	if !apiequality.Semantic.DeepEqual(fas.Status, fasCopy.Status) {
-		if scalingLimited {
+		if cond, ok := fas.Status.GetCondition(autoscalingv1.FleetAutoscalerConditionAbleToScale); recovered && ok && cond.Status == corev1.ConditionFalse {
+			c.recorder.Eventf(fas, corev1.EventTypeNormal, "AbleToScale", "Fleet %s can be scaled again", fas.Spec.FleetName)
+		}
+		switch limitedReason {
+		case autoscalingv1.ScalingLimitedByPolicy:
 			c.recorder.Eventf(fas, corev1.EventTypeWarning, "ScalingLimited", "Scaling fleet %s was limited to maximum size of %d", fas.Spec.FleetName, desiredReplicas)
+		case autoscalingv1.ScalingLimitedByNodeCapacity:
+			c.recorder.Eventf(fas, corev1.EventTypeWarning, "ScalingLimited", "Scaling fleet %s was limited to %d replicas by the capacity of the nodes", fas.Spec.FleetName, desiredReplicas)
 		}
 
 		_, err := c.fleetAutoscalerGetter.FleetAutoscalers(fas.ObjectMeta.Namespace).UpdateStatus(fasCopy)
@@ -275,13 +389,17 @@ func (c *Controller) updateStatus(fas *autoscalingv1.FleetAutoscaler, currentRep
 	return nil
 }
 
-// updateStatus updates the status of the given FleetAutoscaler in the case we're not able to scale
-func (c *Controller) updateStatusUnableToScale(fas *autoscalingv1.FleetAutoscaler) error {
+// updateStatus updates the status of the given FleetAutoscaler in the case we're not able to scale,
+// with the reason and message of its AbleToScale condition
+func (c *Controller) updateStatusUnableToScale(fas *autoscalingv1.FleetAutoscaler, reason, message string) error {
 	fasCopy := fas.DeepCopy()
 	fasCopy.Status.AbleToScale = false
 	fasCopy.Status.ScalingLimited = false
+	fasCopy.Status.ScalingLimitedReason = ""
 	fasCopy.Status.CurrentReplicas = 0
 	fasCopy.Status.DesiredReplicas = 0
+	fasCopy.Status.SetCondition(autoscalingv1.FleetAutoscalerCondition{Type: autoscalingv1.FleetAutoscalerConditionAbleToScale,
+		Status: corev1.ConditionFalse, LastTransitionTime: metav1.NewTime(c.clock.Now()), Reason: reason, Message: message})
 
 	if !apiequality.Semantic.DeepEqual(fas.Status, fasCopy.Status) {
 		_, err := c.fleetAutoscalerGetter.FleetAutoscalers(fas.ObjectMeta.Namespace).UpdateStatus(fasCopy)
@@ -19,15 +19,21 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
 	agtesting "agones.dev/agones/pkg/testing"
+	"agones.dev/agones/pkg/util/logfields"
 	"agones.dev/agones/pkg/util/webhooks"
+	"agones.dev/agones/pkg/util/workerqueue"
 	"github.com/heptiolabs/healthcheck"
 	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
 	admv1beta1 "k8s.io/api/admission/v1beta1"
 	admregv1b "k8s.io/api/admissionregistration/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -226,6 +232,107 @@ func TestControllerSyncFleetAutoscaler(t *testing.T) {
 		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
 	})
 
+	t.Run("scaling down limited by the behavior", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		fas, f := defaultFixtures()
+		fas.Spec.Policy.Buffer.BufferSize = intstr.FromInt(8)
+		maxChange := intstr.FromInt(2)
+		fas.Spec.Behavior = &autoscalingv1.FleetAutoscalerBehavior{ScaleDown: &autoscalingv1.ScalingRules{MaxChange: &maxChange}}
+
+		f.Spec.Replicas = 20
+		f.Status.Replicas = 20
+		f.Status.AllocatedReplicas = 5
+		f.Status.ReadyReplicas = 15
+
+		fUpdated := false
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &autoscalingv1.FleetAutoscalerList{Items: []autoscalingv1.FleetAutoscaler{*fas}}, nil
+		})
+
+		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			ca := action.(k8stesting.UpdateAction)
+			fas := ca.GetObject().(*autoscalingv1.FleetAutoscaler)
+			assert.Equal(t, fas.Status.DesiredReplicas, int32(18))
+			return true, fas, nil
+		})
+
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f}}, nil
+		})
+
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			fUpdated = true
+			ca := action.(k8stesting.UpdateAction)
+			f := ca.GetObject().(*agonesv1.Fleet)
+			assert.Equal(t, f.Spec.Replicas, int32(18))
+			return true, f, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetSynced, c.fleetAutoscalerSynced)
+		defer cancel()
+
+		err := c.syncFleetAutoscaler("default/fas-1")
+		assert.Nil(t, err)
+		assert.True(t, fUpdated, "fleet should have been updated")
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "AutoScalingFleet")
+		assert.Equal(t, int32(-2), c.scalingHistory.events["default/fas-1"][0].change)
+	})
+
+	t.Run("scaling up limited by the node capacity", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		fas, f := defaultFixtures()
+		fas.Spec.Policy.Buffer.BufferSize = intstr.FromInt(7)
+		fas.Spec.LimitToNodeCapacity = true
+
+		f.Spec.Replicas = 5
+		f.Status.Replicas = 5
+		f.Status.AllocatedReplicas = 5
+		f.Status.ReadyReplicas = 0
+		setCapacityFixtures(m, f)
+
+		fUpdated := false
+		fasUpdated := false
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &autoscalingv1.FleetAutoscalerList{Items: []autoscalingv1.FleetAutoscaler{*fas}}, nil
+		})
+
+		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			fasUpdated = true
+			ca := action.(k8stesting.UpdateAction)
+			fas := ca.GetObject().(*autoscalingv1.FleetAutoscaler)
+			assert.Equal(t, fas.Status.ScalingLimited, true)
+			assert.Equal(t, autoscalingv1.ScalingLimitedByNodeCapacity, fas.Status.ScalingLimitedReason)
+			assert.Equal(t, fas.Status.DesiredReplicas, int32(8))
+			return true, fas, nil
+		})
+
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f}}, nil
+		})
+
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			fUpdated = true
+			ca := action.(k8stesting.UpdateAction)
+			f := ca.GetObject().(*agonesv1.Fleet)
+			assert.Equal(t, f.Spec.Replicas, int32(8))
+			return true, f, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetSynced, c.fleetAutoscalerSynced, c.capacity.nodeSynced, c.capacity.podSynced)
+		defer cancel()
+
+		err := c.syncFleetAutoscaler("default/fas-1")
+		assert.Nil(t, err)
+		assert.True(t, fUpdated, "fleet should have been updated")
+		assert.True(t, fasUpdated, "fleetautoscaler should have been updated")
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "AutoScalingFleet")
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "by the capacity of the nodes")
+	})
+
 	t.Run("no scaling no update", func(t *testing.T) {
 		t.Parallel()
 		c, m := newFakeController()
@@ -290,6 +397,100 @@ func TestControllerSyncFleetAutoscaler(t *testing.T) {
 
 		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "FailedGetFleet")
 	})
+
+	t.Run("policy error", func(t *testing.T) {
+		t.Parallel()
+		c, m := newFakeController()
+		fas, f := defaultFixtures()
+		fas.ObjectMeta.Name = "fas-policy-error"
+		fas.Spec.Policy = autoscalingv1.FleetAutoscalerPolicy{Type: autoscalingv1.CombinedPolicyType, Combined: &autoscalingv1.CombinedPolicy{}}
+		updated := false
+
+		m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &autoscalingv1.FleetAutoscalerList{Items: []autoscalingv1.FleetAutoscaler{*fas}}, nil
+		})
+
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f}}, nil
+		})
+
+		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			fas := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.FleetAutoscaler)
+			cond, ok := fas.Status.GetCondition(autoscalingv1.FleetAutoscalerConditionAbleToScale)
+			if assert.True(t, ok) {
+				assert.Equal(t, corev1.ConditionFalse, cond.Status)
+				assert.Equal(t, "FailedComputeReplicas", cond.Reason)
+				assert.Contains(t, cond.Message, "combined policy has no policies")
+			}
+			return true, fas, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetSynced, c.fleetAutoscalerSynced)
+		defer cancel()
+
+		err := c.syncFleetAutoscaler("default/fas-policy-error")
+		assert.NotNil(t, err)
+		assert.True(t, updated)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Error calculating desired fleet size")
+
+		rows, err := view.RetrieveData("fleet_autoscalers_policy_errors_total")
+		assert.NoError(t, err)
+		found := false
+		for _, row := range rows {
+			for _, tag := range row.Tags {
+				if tag.Key == keyName && tag.Value == "fas-policy-error" {
+					found = true
+					assert.Contains(t, row.Tags, tagPair(keyType, string(autoscalingv1.CombinedPolicyType)))
+				}
+			}
+		}
+		assert.True(t, found, "policy error should be recorded")
+	})
+}
+
+// tagPair returns the tag of the key with the value
+func tagPair(key tag.Key, value string) tag.Tag {
+	return tag.Tag{Key: key, Value: value}
+}
+
+func TestControllerEnqueueFleetAutoscalersForFleet(t *testing.T) {
+	t.Parallel()
+
+	c, m := newFakeController()
+	fas, f := defaultFixtures()
+	fas.Spec.SyncInterval = &metav1.Duration{Duration: 10 * time.Millisecond}
+	other := fas.DeepCopy()
+	other.ObjectMeta.Name = "fas-2"
+	other.Spec.FleetName = "fleet-2"
+
+	m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &autoscalingv1.FleetAutoscalerList{Items: []autoscalingv1.FleetAutoscaler{*fas, *other}}, nil
+	})
+
+	_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
+	defer cancel()
+
+	// a fresh queue, without the autoscalers enqueued by the informer
+	c.workerqueue = workerqueue.NewWorkerQueue(c.syncFleetAutoscaler, c.baseLogger, logfields.FleetAutoscalerKey, "test")
+	c.enqueueFleetAutoscalersForFleet(f)
+	// enqueueing is rate limited
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 1, c.workerqueue.Len())
+
+	// syncing an autoscaler without a fleet still evaluates it again after its sync interval
+	c, m = newFakeController()
+	m.AgonesClient.AddReactor("list", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &autoscalingv1.FleetAutoscalerList{Items: []autoscalingv1.FleetAutoscaler{*fas}}, nil
+	})
+	_, cancel = agtesting.StartInformers(m, c.fleetSynced, c.fleetAutoscalerSynced)
+	defer cancel()
+
+	c.workerqueue = workerqueue.NewWorkerQueue(c.syncFleetAutoscaler, c.baseLogger, logfields.FleetAutoscalerKey, "test")
+	assert.Nil(t, c.syncFleetAutoscaler("default/fas-1"))
+	assert.Equal(t, 0, c.workerqueue.Len())
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 1, c.workerqueue.Len())
 }
 
 func TestControllerScaleFleet(t *testing.T) {
@@ -351,13 +552,20 @@ func TestControllerUpdateStatus(t *testing.T) {
 			assert.Equal(t, fas.Status.CurrentReplicas, int32(10))
 			assert.Equal(t, fas.Status.DesiredReplicas, int32(20))
 			assert.NotNil(t, fas.Status.LastScaleTime)
+			if cond, ok := fas.Status.GetCondition(autoscalingv1.FleetAutoscalerConditionAbleToScale); assert.True(t, ok) {
+				assert.Equal(t, corev1.ConditionTrue, cond.Status)
+			}
+			if cond, ok := fas.Status.GetCondition(autoscalingv1.FleetAutoscalerConditionScalingLimited); assert.True(t, ok) {
+				assert.Equal(t, corev1.ConditionFalse, cond.Status)
+				assert.Equal(t, "DesiredWithinRange", cond.Reason)
+			}
 			return true, fas, nil
 		})
 
 		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
 		defer cancel()
 
-		err := c.updateStatus(fas, 10, 20, true, false)
+		err := c.updateStatus(fas, 10, 20, true, "")
 		assert.Nil(t, err)
 		assert.True(t, fasUpdated)
 		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
@@ -372,6 +580,10 @@ func TestControllerUpdateStatus(t *testing.T) {
 		fas.Status.CurrentReplicas = 10
 		fas.Status.DesiredReplicas = 20
 		fas.Status.LastScaleTime = nil
+		fas.Status.Conditions = []autoscalingv1.FleetAutoscalerCondition{
+			{Type: autoscalingv1.FleetAutoscalerConditionAbleToScale, Status: corev1.ConditionTrue, Reason: "ReadyForNewScale", Message: "the size of the fleet was computed and applied"},
+			{Type: autoscalingv1.FleetAutoscalerConditionScalingLimited, Status: corev1.ConditionFalse, Reason: "DesiredWithinRange", Message: "the desired replicas are within the acceptable range"},
+		}
 
 		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
 			assert.FailNow(t, "should not update")
@@ -381,7 +593,7 @@ func TestControllerUpdateStatus(t *testing.T) {
 		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
 		defer cancel()
 
-		err := c.updateStatus(fas, fas.Status.CurrentReplicas, fas.Status.DesiredReplicas, false, fas.Status.ScalingLimited)
+		err := c.updateStatus(fas, fas.Status.CurrentReplicas, fas.Status.DesiredReplicas, false, fas.Status.ScalingLimitedReason)
 		assert.Nil(t, err)
 		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
 	})
@@ -390,10 +602,41 @@ func TestControllerUpdateStatus(t *testing.T) {
 		c, m := newFakeController()
 		fas, _ := defaultFixtures()
 
-		err := c.updateStatus(fas, 10, 20, true, true)
+		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			fas := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.FleetAutoscaler)
+			if cond, ok := fas.Status.GetCondition(autoscalingv1.FleetAutoscalerConditionScalingLimited); assert.True(t, ok) {
+				assert.Equal(t, corev1.ConditionTrue, cond.Status)
+				assert.Equal(t, string(autoscalingv1.ScalingLimitedByPolicy), cond.Reason)
+			}
+			return true, fas, nil
+		})
+
+		err := c.updateStatus(fas, 10, 20, true, autoscalingv1.ScalingLimitedByPolicy)
 		assert.Nil(t, err)
 		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "ScalingLimited")
 	})
+
+	t.Run("able to scale again", func(t *testing.T) {
+		c, m := newFakeController()
+		fas, _ := defaultFixtures()
+		transition := metav1.NewTime(time.Now().Add(-time.Hour))
+		fas.Status.Conditions = []autoscalingv1.FleetAutoscalerCondition{
+			{Type: autoscalingv1.FleetAutoscalerConditionAbleToScale, Status: corev1.ConditionFalse, LastTransitionTime: transition, Reason: "FailedGetFleet"},
+		}
+
+		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			fas := action.(k8stesting.UpdateAction).GetObject().(*autoscalingv1.FleetAutoscaler)
+			if cond, ok := fas.Status.GetCondition(autoscalingv1.FleetAutoscalerConditionAbleToScale); assert.True(t, ok) {
+				assert.Equal(t, corev1.ConditionTrue, cond.Status)
+				assert.True(t, cond.LastTransitionTime.After(transition.Time))
+			}
+			return true, fas, nil
+		})
+
+		err := c.updateStatus(fas, 10, 10, false, "")
+		assert.Nil(t, err)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "AbleToScale")
+	})
 }
 
 func TestControllerUpdateStatusUnableToScale(t *testing.T) {
@@ -415,13 +658,18 @@ func TestControllerUpdateStatusUnableToScale(t *testing.T) {
 			assert.Equal(t, fas.Status.CurrentReplicas, int32(0))
 			assert.Equal(t, fas.Status.DesiredReplicas, int32(0))
 			assert.Nil(t, fas.Status.LastScaleTime)
+			if cond, ok := fas.Status.GetCondition(autoscalingv1.FleetAutoscalerConditionAbleToScale); assert.True(t, ok) {
+				assert.Equal(t, corev1.ConditionFalse, cond.Status)
+				assert.Equal(t, "FailedGetFleet", cond.Reason)
+				assert.Equal(t, "fleet fleet-1 was not found", cond.Message)
+			}
 			return true, fas, nil
 		})
 
 		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
 		defer cancel()
 
-		err := c.updateStatusUnableToScale(fas)
+		err := c.updateStatusUnableToScale(fas, "FailedGetFleet", "fleet fleet-1 was not found")
 		assert.Nil(t, err)
 		assert.True(t, fasUpdated)
 		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
@@ -434,6 +682,9 @@ func TestControllerUpdateStatusUnableToScale(t *testing.T) {
 		fas.Status.ScalingLimited = false
 		fas.Status.CurrentReplicas = 0
 		fas.Status.DesiredReplicas = 0
+		fas.Status.Conditions = []autoscalingv1.FleetAutoscalerCondition{
+			{Type: autoscalingv1.FleetAutoscalerConditionAbleToScale, Status: corev1.ConditionFalse, Reason: "FailedGetFleet", Message: "fleet fleet-1 was not found"},
+		}
 
 		m.AgonesClient.AddReactor("update", "fleetautoscalers", func(action k8stesting.Action) (bool, runtime.Object, error) {
 			assert.FailNow(t, "fleetautoscaler should not update")
@@ -443,7 +694,7 @@ func TestControllerUpdateStatusUnableToScale(t *testing.T) {
 		_, cancel := agtesting.StartInformers(m, c.fleetAutoscalerSynced)
 		defer cancel()
 
-		err := c.updateStatusUnableToScale(fas)
+		err := c.updateStatusUnableToScale(fas, "FailedGetFleet", "fleet fleet-1 was not found")
 		assert.Nil(t, err)
 		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
 	})
@@ -507,7 +758,7 @@ func defaultWebhookFixtures() (*autoscalingv1.FleetAutoscaler, *agonesv1.Fleet)
 func newFakeController() (*Controller, agtesting.Mocks) {
 	m := agtesting.NewMocks()
 	wh := webhooks.NewWebHook(http.NewServeMux())
-	c := NewController(wh, healthcheck.NewHandler(), m.KubeClient, m.ExtClient, m.AgonesClient, m.AgonesInformerFactory)
+	c := NewController(wh, healthcheck.NewHandler(), m.KubeClient, m.KubeInformerFactory, m.ExtClient, m.AgonesClient, m.AgonesInformerFactory)
 	c.recorder = m.FakeRecorder
 	return c, m
 }
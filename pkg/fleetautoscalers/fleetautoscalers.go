@@ -30,6 +30,7 @@ import (
 
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
+	"agones.dev/agones/pkg/util/cron"
 	"github.com/pkg/errors"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/uuid"
@@ -39,19 +40,69 @@ var client = http.Client{
 	Timeout: 15 * time.Second,
 }
 
-// computeDesiredFleetSize computes the new desired size of the given fleet
-func computeDesiredFleetSize(fas *autoscalingv1.FleetAutoscaler, f *agonesv1.Fleet) (int32, bool, error) {
+// DesiredFleetSize computes the size the FleetAutoscaler scales the Fleet to at the given time, from the status
+// of the Fleet and its recent allocations, and whether it was limited by the minimum or maximum replicas of its policy
+func DesiredFleetSize(fas *autoscalingv1.FleetAutoscaler, f *agonesv1.Fleet, now time.Time, allocations AllocationHistory) (int32, bool, error) {
+	return computeDesiredFleetSize(fas, f, now, allocations)
+}
 
+// computeDesiredFleetSize computes the new desired size of the given fleet
+func computeDesiredFleetSize(fas *autoscalingv1.FleetAutoscaler, f *agonesv1.Fleet, now time.Time, allocations AllocationHistory) (int32, bool, error) {
 	switch fas.Spec.Policy.Type {
+	case autoscalingv1.CombinedPolicyType:
+		return applyCombinedPolicy(fas.Spec.Policy.Combined, f, now, allocations)
+	case autoscalingv1.ChainPolicyType:
+		return applyChainPolicy(fas.Spec.Policy.Chain, f, now, allocations)
+	}
+	return applyPolicy(&fas.Spec.Policy, f, now, allocations)
+}
+
+// applyPolicy computes the desired size of the fleet with a Buffer, Webhook, Schedule or Predictive policy
+func applyPolicy(p *autoscalingv1.FleetAutoscalerPolicy, f *agonesv1.Fleet, now time.Time, allocations AllocationHistory) (int32, bool, error) {
+	switch p.Type {
 	case autoscalingv1.BufferPolicyType:
-		return applyBufferPolicy(fas.Spec.Policy.Buffer, f)
+		return applyBufferPolicy(p.Buffer, f)
 	case autoscalingv1.WebhookPolicyType:
-		return applyWebhookPolicy(fas.Spec.Policy.Webhook, f)
+		return applyWebhookPolicy(p.Webhook, f)
+	case autoscalingv1.SchedulePolicyType:
+		return applySchedulePolicy(p.Schedule, f, now)
+	case autoscalingv1.PredictivePolicyType:
+		return applyPredictivePolicy(p.Predictive, f, now, allocations)
+	}
+
+	return f.Status.Replicas, false, errors.New("wrong policy type, should be one of: Buffer, Webhook, Combined, Schedule, Chain, Predictive")
+}
+
+// applyCombinedPolicy computes the desired size of the fleet with each policy, and returns the maximum
+// or minimum of them, depending on the combinator. The fleet is only scaled if all policies succeed.
+func applyCombinedPolicy(c *autoscalingv1.CombinedPolicy, f *agonesv1.Fleet, now time.Time, allocations AllocationHistory) (int32, bool, error) {
+	if c == nil || len(c.Policies) == 0 {
+		return f.Status.Replicas, false, errors.New("combined policy has no policies")
+	}
+
+	var replicas int32
+	var limited bool
+	for i := range c.Policies {
+		r, l, err := applyPolicy(&c.Policies[i], f, now, allocations)
+		if err != nil {
+			return f.Status.Replicas, false, errors.Wrapf(err, "error applying policy %d of combined policy", i)
+		}
+		var better bool
+		if c.Combinator == autoscalingv1.MinCombinator {
+			better = r < replicas
+		} else {
+			better = r > replicas
+		}
+		if i == 0 || better {
+			replicas, limited = r, l
+		}
 	}
 
-	return f.Status.Replicas, false, errors.New("wrong policy type, should be one of: Buffer, Webhook")
+	return replicas, limited, nil
 }
 
+// applyWebhookPolicy sends the status of the fleet to the webhook, either at its URL or at its in-cluster
+// service, over TLS if it has a CA bundle, and returns the replicas of its response
 func applyWebhookPolicy(w *autoscalingv1.WebhookPolicy, f *agonesv1.Fleet) (int32, bool, error) {
 	faReq := autoscalingv1.FleetAutoscaleReview{
 		Request: &autoscalingv1.FleetAutoscaleRequest{
@@ -63,6 +114,9 @@ func applyWebhookPolicy(w *autoscalingv1.WebhookPolicy, f *agonesv1.Fleet) (int3
 		Response: nil,
 	}
 	b, err := json.Marshal(faReq)
+	if err != nil {
+		return f.Status.Replicas, false, err
+	}
 	urlStr := ""
 	if w.URL != nil {
 		urlStr = *w.URL
@@ -73,18 +127,17 @@ func applyWebhookPolicy(w *autoscalingv1.WebhookPolicy, f *agonesv1.Fleet) (int3
 		if w.Service.Path != nil {
 			servicePath = *w.Service.Path
 		}
-		if err != nil {
-			return f.Status.Replicas, false, err
-		}
 
-		if w.Service.Namespace == "" {
-			w.Service.Namespace = "default"
+		// the policy is shared with the informer cache, so the namespace is not defaulted in place
+		namespace := w.Service.Namespace
+		if namespace == "" {
+			namespace = "default"
 		}
 		scheme := "http://"
 		if w.CABundle != nil {
 			scheme = "https://"
 		}
-		urlStr = fmt.Sprintf("%s%s.%s.svc:8000/%s", scheme, w.Service.Name, w.Service.Namespace, servicePath)
+		urlStr = fmt.Sprintf("%s%s.%s.svc:8000/%s", scheme, w.Service.Name, namespace, servicePath)
 	}
 	if urlStr == "" {
 		return f.Status.Replicas, false, errors.New("URL was not provided")
@@ -96,20 +149,20 @@ func applyWebhookPolicy(w *autoscalingv1.WebhookPolicy, f *agonesv1.Fleet) (int3
 	}
 
 	// We could have multiple fleetautoscalers with different CABundles defined,
-	// so we switch client.Transport before each POST request
+	// so each HTTPS request uses a copy of the client with its own transport
+	c := client
 	if u.Scheme == "https" {
 		rootCAs := x509.NewCertPool()
 		if ok := rootCAs.AppendCertsFromPEM(w.CABundle); !ok {
 			return f.Status.Replicas, false, errors.New("no certs were appended from caBundle")
 		}
-		tr := &http.Transport{
+		c.Transport = &http.Transport{
 			TLSClientConfig: &tls.Config{
 				RootCAs: rootCAs,
 			},
 		}
-		client.Transport = tr
 	}
-	res, err := client.Post(
+	res, err := c.Post(
 		urlStr,
 		"application/json",
 		strings.NewReader(string(b)),
@@ -129,12 +182,122 @@ func applyWebhookPolicy(w *autoscalingv1.WebhookPolicy, f *agonesv1.Fleet) (int3
 	if err != nil {
 		return f.Status.Replicas, false, err
 	}
+	if faResp.Response == nil {
+		return f.Status.Replicas, false, fmt.Errorf("no response in the review from the server: %s", urlStr)
+	}
 	if faResp.Response.Scale {
+		if faResp.Response.Replicas < 0 {
+			return f.Status.Replicas, false, fmt.Errorf("negative replicas %d from the server: %s", faResp.Response.Replicas, urlStr)
+		}
 		return faResp.Response.Replicas, false, nil
 	}
 	return f.Status.Replicas, false, nil
 }
 
+// applyChainPolicy computes the desired size of the fleet with the first entry of the chain that applies.
+// Schedule policies without an active window or a default do not apply, every other policy does.
+func applyChainPolicy(c autoscalingv1.ChainPolicy, f *agonesv1.Fleet, now time.Time, allocations AllocationHistory) (int32, bool, error) {
+	for i := range c {
+		e := &c[i]
+		if e.Type == autoscalingv1.SchedulePolicyType {
+			target, err := scheduleTarget(e.Schedule, now)
+			if err != nil {
+				return f.Status.Replicas, false, errors.Wrapf(err, "error applying entry %s of chain policy", e.ID)
+			}
+			if target == nil {
+				continue
+			}
+		}
+		replicas, limited, err := applyPolicy(&e.FleetAutoscalerPolicy, f, now, allocations)
+		if err != nil {
+			return f.Status.Replicas, false, errors.Wrapf(err, "error applying entry %s of chain policy", e.ID)
+		}
+		return replicas, limited, nil
+	}
+
+	return f.Status.Replicas, false, errors.New("no entry of the chain policy applies")
+}
+
+// applySchedulePolicy computes the desired size of the fleet with the target of the first window of the schedule
+// that is active at the given time, or with its default target
+func applySchedulePolicy(s *autoscalingv1.SchedulePolicy, f *agonesv1.Fleet, now time.Time) (int32, bool, error) {
+	target, err := scheduleTarget(s, now)
+	if err != nil {
+		return f.Status.Replicas, false, err
+	}
+	if target == nil {
+		return f.Status.Replicas, false, errors.New("schedule policy has no active window and no default")
+	}
+
+	if target.Replicas != nil {
+		return *target.Replicas, false, nil
+	}
+	if target.Buffer != nil {
+		return applyBufferPolicy(target.Buffer, f)
+	}
+	return f.Status.Replicas, false, errors.New("schedule policy target has neither buffer nor replicas")
+}
+
+// scheduleTarget returns the target of the first window of the schedule that is active at the given time,
+// or its default, which may be nil
+func scheduleTarget(s *autoscalingv1.SchedulePolicy, now time.Time) (*autoscalingv1.ScheduleTarget, error) {
+	if s == nil {
+		return nil, errors.New("schedule policy config params are missing")
+	}
+	loc, err := s.Location()
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading time zone of schedule policy")
+	}
+	now = now.In(loc)
+
+	for i := range s.Windows {
+		w := &s.Windows[i]
+		schedule, err := cron.Parse(w.Start)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error parsing start of window %d of schedule policy", i)
+		}
+		// the window is active if it last started less than its duration ago
+		start := schedule.Next(now.Add(-w.Duration.Duration))
+		if !start.IsZero() && !start.After(now) {
+			return &w.ScheduleTarget, nil
+		}
+	}
+	return s.Default, nil
+}
+
+// applyPredictivePolicy computes the desired size of the fleet with a buffer of as many Ready GameServers as are
+// projected to be allocated within the lead time, at the allocation rate of the fleet within the window
+func applyPredictivePolicy(p *autoscalingv1.PredictivePolicy, f *agonesv1.Fleet, now time.Time, allocations AllocationHistory) (int32, bool, error) {
+	if p == nil {
+		return f.Status.Replicas, false, errors.New("predictive policy config params are missing")
+	}
+	if allocations == nil {
+		return f.Status.Replicas, false, errors.New("predictive policy has no allocation history")
+	}
+
+	window := p.GetWindow()
+	allocated := allocations.AllocatedSince(f.ObjectMeta.Namespace, f.ObjectMeta.Name, now.Add(-window))
+	// use Math.Ceil to round the projection up
+	buffer := int32(math.Ceil(float64(allocated) * p.GetLeadTime().Seconds() / window.Seconds()))
+	if buffer < p.MinBufferSize {
+		buffer = p.MinBufferSize
+	}
+	replicas := f.Status.AllocatedReplicas + buffer
+
+	limited := false
+
+	if replicas < p.MinReplicas {
+		replicas = p.MinReplicas
+		limited = true
+	}
+	if replicas > p.MaxReplicas {
+		replicas = p.MaxReplicas
+		limited = true
+	}
+
+	return replicas, limited, nil
+}
+
 func applyBufferPolicy(b *autoscalingv1.BufferPolicy, f *agonesv1.Fleet) (int32, bool, error) {
 	var replicas int32
 
@@ -23,9 +23,12 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
+	agtesting "agones.dev/agones/pkg/testing"
 	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -45,7 +48,7 @@ func TestComputeDesiredFleetSize(t *testing.T) {
 	f.Status.AllocatedReplicas = 40
 	f.Status.ReadyReplicas = 10
 
-	replicas, limited, err := computeDesiredFleetSize(fas, f)
+	replicas, limited, err := computeDesiredFleetSize(fas, f, time.Now(), nil)
 	assert.Nil(t, err)
 	assert.Equal(t, replicas, int32(60))
 	assert.Equal(t, limited, false)
@@ -53,7 +56,7 @@ func TestComputeDesiredFleetSize(t *testing.T) {
 	// test empty Policy Type
 	f.Status.Replicas = 61
 	fas.Spec.Policy.Type = ""
-	replicas, limited, err = computeDesiredFleetSize(fas, f)
+	replicas, limited, err = computeDesiredFleetSize(fas, f, time.Now(), nil)
 	assert.NotNil(t, err)
 	assert.Equal(t, replicas, int32(61))
 	assert.Equal(t, limited, false)
@@ -123,6 +126,252 @@ func TestApplyBufferPolicy(t *testing.T) {
 	assert.Equal(t, limited, false)
 }
 
+func TestApplyCombinedPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, f := defaultFixtures()
+	f.Spec.Replicas = 50
+	f.Status.Replicas = f.Spec.Replicas
+	f.Status.AllocatedReplicas = 40
+	f.Status.ReadyReplicas = 10
+
+	fas := &autoscalingv1.FleetAutoscaler{
+		Spec: autoscalingv1.FleetAutoscalerSpec{
+			Policy: autoscalingv1.FleetAutoscalerPolicy{
+				Type: autoscalingv1.CombinedPolicyType,
+				Combined: &autoscalingv1.CombinedPolicy{
+					Policies: []autoscalingv1.FleetAutoscalerPolicy{
+						{
+							// 60 replicas
+							Type:   autoscalingv1.BufferPolicyType,
+							Buffer: &autoscalingv1.BufferPolicy{BufferSize: intstr.FromInt(20), MaxReplicas: 100},
+						},
+						{
+							// a baseline of 65 replicas
+							Type:   autoscalingv1.BufferPolicyType,
+							Buffer: &autoscalingv1.BufferPolicy{BufferSize: intstr.FromInt(5), MinReplicas: 65, MaxReplicas: 100},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	replicas, limited, err := computeDesiredFleetSize(fas, f, time.Now(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(65), replicas)
+	assert.Equal(t, true, limited)
+
+	fas.Spec.Policy.Combined.Combinator = autoscalingv1.MinCombinator
+	replicas, limited, err = computeDesiredFleetSize(fas, f, time.Now(), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(60), replicas)
+	assert.Equal(t, false, limited)
+
+	// the fleet is not scaled if any policy fails
+	fas.Spec.Policy.Combined.Policies = append(fas.Spec.Policy.Combined.Policies, autoscalingv1.FleetAutoscalerPolicy{Type: ""})
+	replicas, limited, err = computeDesiredFleetSize(fas, f, time.Now(), nil)
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(50), replicas)
+	assert.Equal(t, false, limited)
+
+	fas.Spec.Policy.Combined = nil
+	_, _, err = computeDesiredFleetSize(fas, f, time.Now(), nil)
+	assert.NotNil(t, err)
+}
+
+func TestApplySchedulePolicy(t *testing.T) {
+	t.Parallel()
+
+	_, f := defaultFixtures()
+	f.Status.Replicas = 10
+	f.Status.AllocatedReplicas = 8
+	f.Status.ReadyReplicas = 2
+
+	replicas := int32(50)
+	s := &autoscalingv1.SchedulePolicy{
+		TimeZone: "America/New_York",
+		Default:  &autoscalingv1.ScheduleTarget{Buffer: &autoscalingv1.BufferPolicy{BufferSize: intstr.FromInt(5), MaxReplicas: 100}},
+		Windows: []autoscalingv1.ScheduleWindow{
+			{
+				// Friday evenings
+				Start:          "0 18 * * 5",
+				Duration:       metav1.Duration{Duration: 4 * time.Hour},
+				ScheduleTarget: autoscalingv1.ScheduleTarget{Replicas: &replicas},
+			},
+			{
+				// every evening
+				Start:          "0 17 * * *",
+				Duration:       metav1.Duration{Duration: 6 * time.Hour},
+				ScheduleTarget: autoscalingv1.ScheduleTarget{Buffer: &autoscalingv1.BufferPolicy{BufferSize: intstr.FromInt(20), MaxReplicas: 100}},
+			},
+		},
+	}
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	fixtures := map[string]struct {
+		now      time.Time
+		expected int32
+	}{
+		"outside of the windows": {
+			now:      time.Date(2019, time.July, 12, 16, 59, 0, 0, ny),
+			expected: 13,
+		},
+		"first window": {
+			now:      time.Date(2019, time.July, 12, 18, 0, 0, 0, ny),
+			expected: 50,
+		},
+		"end of the first window": {
+			now:      time.Date(2019, time.July, 12, 21, 59, 0, 0, ny),
+			expected: 50,
+		},
+		"second window": {
+			now:      time.Date(2019, time.July, 12, 22, 0, 0, 0, ny),
+			expected: 28,
+		},
+		"second window on another day": {
+			now:      time.Date(2019, time.July, 11, 17, 30, 0, 0, ny),
+			expected: 28,
+		},
+		"in another time zone": {
+			now:      time.Date(2019, time.July, 12, 22, 30, 0, 0, time.UTC),
+			expected: 50,
+		},
+		"after the windows": {
+			now:      time.Date(2019, time.July, 12, 23, 0, 0, 0, ny),
+			expected: 13,
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			replicas, limited, err := applySchedulePolicy(s, f, v.now)
+			assert.Nil(t, err)
+			assert.Equal(t, v.expected, replicas)
+			assert.Equal(t, false, limited)
+		})
+	}
+
+	_, _, err = applySchedulePolicy(nil, f, time.Now())
+	assert.NotNil(t, err)
+}
+
+func TestApplyPredictivePolicy(t *testing.T) {
+	t.Parallel()
+
+	_, f := defaultFixtures()
+	f.Status.Replicas = 20
+	f.Status.AllocatedReplicas = 15
+	f.Status.ReadyReplicas = 5
+
+	now := time.Date(2019, time.July, 12, 18, 0, 0, 0, time.UTC)
+	allocations := newGameServerAllocations(agtesting.NewMocks().AgonesInformerFactory.Agones().V1().GameServers())
+	// 10 allocations 8 minutes ago, and 30 in the last 5 minutes
+	for i := 0; i < 10; i++ {
+		allocations.record(f.ObjectMeta.Namespace, f.ObjectMeta.Name, now.Add(-8*time.Minute))
+	}
+	for i := 29; i >= 0; i-- {
+		allocations.record(f.ObjectMeta.Namespace, f.ObjectMeta.Name, now.Add(-time.Duration(i)*10*time.Second))
+	}
+
+	p := &autoscalingv1.PredictivePolicy{MinBufferSize: 2, MaxReplicas: 100}
+
+	// 6 allocations a minute, projected for a minute
+	replicas, limited, err := applyPredictivePolicy(p, f, now, allocations)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(21), replicas)
+	assert.False(t, limited)
+
+	// 4 allocations a minute over 10 minutes, projected for 90 seconds
+	p.Window = &metav1.Duration{Duration: 10 * time.Minute}
+	p.LeadTime = &metav1.Duration{Duration: 90 * time.Second}
+	replicas, _, err = applyPredictivePolicy(p, f, now, allocations)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(21), replicas)
+
+	// no recent allocations keeps the minimum buffer
+	replicas, _, err = applyPredictivePolicy(p, f, now.Add(time.Hour), allocations)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(17), replicas)
+
+	p.MaxReplicas = 18
+	replicas, limited, err = applyPredictivePolicy(p, f, now, allocations)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(18), replicas)
+	assert.True(t, limited)
+
+	_, _, err = applyPredictivePolicy(p, f, now, nil)
+	assert.NotNil(t, err)
+	_, _, err = applyPredictivePolicy(nil, f, now, allocations)
+	assert.NotNil(t, err)
+}
+
+func TestApplyChainPolicy(t *testing.T) {
+	t.Parallel()
+
+	_, f := defaultFixtures()
+	f.Status.Replicas = 10
+	f.Status.AllocatedReplicas = 8
+	f.Status.ReadyReplicas = 2
+
+	replicas := int32(50)
+	fas := &autoscalingv1.FleetAutoscaler{
+		Spec: autoscalingv1.FleetAutoscalerSpec{
+			Policy: autoscalingv1.FleetAutoscalerPolicy{
+				Type: autoscalingv1.ChainPolicyType,
+				Chain: autoscalingv1.ChainPolicy{
+					{
+						ID: "friday-evenings",
+						FleetAutoscalerPolicy: autoscalingv1.FleetAutoscalerPolicy{
+							Type: autoscalingv1.SchedulePolicyType,
+							Schedule: &autoscalingv1.SchedulePolicy{
+								Windows: []autoscalingv1.ScheduleWindow{{
+									Start:          "0 18 * * 5",
+									Duration:       metav1.Duration{Duration: 4 * time.Hour},
+									ScheduleTarget: autoscalingv1.ScheduleTarget{Replicas: &replicas},
+								}},
+							},
+						},
+					},
+					{
+						ID: "buffer",
+						FleetAutoscalerPolicy: autoscalingv1.FleetAutoscalerPolicy{
+							Type:   autoscalingv1.BufferPolicyType,
+							Buffer: &autoscalingv1.BufferPolicy{BufferSize: intstr.FromInt(5), MaxReplicas: 100},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	// the schedule applies during its window
+	replicas, limited, err := computeDesiredFleetSize(fas, f, time.Date(2019, time.July, 12, 19, 0, 0, 0, time.UTC), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(50), replicas)
+	assert.Equal(t, false, limited)
+
+	// and the buffer outside of it
+	replicas, limited, err = computeDesiredFleetSize(fas, f, time.Date(2019, time.July, 12, 17, 0, 0, 0, time.UTC), nil)
+	assert.Nil(t, err)
+	assert.Equal(t, int32(13), replicas)
+	assert.Equal(t, false, limited)
+
+	// no entry applies
+	fas.Spec.Policy.Chain = fas.Spec.Policy.Chain[:1]
+	replicas, _, err = computeDesiredFleetSize(fas, f, time.Date(2019, time.July, 12, 17, 0, 0, 0, time.UTC), nil)
+	assert.EqualError(t, err, "no entry of the chain policy applies")
+	assert.Equal(t, int32(10), replicas)
+
+	// an entry fails
+	fas.Spec.Policy.Chain = append(autoscalingv1.ChainPolicy{{ID: "broken"}}, fas.Spec.Policy.Chain...)
+	_, _, err = computeDesiredFleetSize(fas, f, time.Now(), nil)
+	if assert.NotNil(t, err) {
+		assert.Contains(t, err.Error(), "error applying entry broken of chain policy")
+	}
+}
+
 type testServer struct{}
 
 func (t testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
@@ -204,3 +453,58 @@ func TestApplyWebhookPolicy(t *testing.T) {
 	assert.Equal(t, replicas, f.Spec.Replicas)
 	assert.Equal(t, limited, false)
 }
+
+func TestApplyWebhookPolicyInvalidResponse(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]struct {
+		body    string
+		wantErr string
+	}{
+		"no response": {
+			body:    `{"request": {}}`,
+			wantErr: "no response in the review from the server",
+		},
+		"negative replicas": {
+			body:    `{"response": {"scale": true, "replicas": -1}}`,
+			wantErr: "negative replicas -1 from the server",
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, err := io.WriteString(w, v.body)
+				assert.Nil(t, err)
+			}))
+			defer server.Close()
+
+			fas, f := defaultWebhookFixtures()
+			w := fas.Spec.Policy.Webhook
+			w.Service = nil
+			w.URL = &(server.URL)
+			f.Status.Replicas = 10
+
+			replicas, _, err := applyWebhookPolicy(w, f)
+			if assert.Error(t, err) {
+				assert.Contains(t, err.Error(), v.wantErr)
+			}
+			assert.Equal(t, int32(10), replicas)
+		})
+	}
+}
+
+func TestApplyWebhookPolicyServiceNamespace(t *testing.T) {
+	t.Parallel()
+
+	fas, f := defaultWebhookFixtures()
+	w := fas.Spec.Policy.Webhook
+	w.URL = nil
+	w.Service.Name = "invalid service"
+	w.Service.Namespace = ""
+
+	// the url of the service can't be parsed, so no request is sent
+	_, _, err := applyWebhookPolicy(w, f)
+	assert.Error(t, err)
+	assert.Equal(t, "", w.Service.Namespace)
+}
@@ -0,0 +1,79 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleetautoscalers
+
+import (
+	"context"
+
+	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
+	mt "agones.dev/agones/pkg/metrics"
+	"agones.dev/agones/pkg/util/runtime"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	keyName      = mt.MustTagKey("name")
+	keyFleetName = mt.MustTagKey("fleet_name")
+	keyType      = mt.MustTagKey("type")
+	keyDirection = mt.MustTagKey("direction")
+
+	policyErrorsStats = stats.Int64("fas/policy_errors", "The number of times the policy of a fleet autoscaler failed to compute the size of its fleet", "1")
+	scalesStats       = stats.Int64("fas/scales", "The number of times a fleet autoscaler scaled its fleet", "1")
+)
+
+func init() {
+	runtime.Must(view.Register(&view.View{
+		Name:        "fleet_autoscalers_policy_errors_total",
+		Measure:     policyErrorsStats,
+		Description: "The total of failed evaluations of the policies of fleet autoscalers, per policy type",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{keyName, keyFleetName, keyType},
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "fleet_autoscalers_scales_total",
+		Measure:     scalesStats,
+		Description: "The total of times fleet autoscalers scaled their fleet up or down",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{keyName, keyFleetName, keyDirection},
+	}))
+}
+
+// recordPolicyError records that the policy of the autoscaler failed to compute the size of its fleet
+func (c *Controller) recordPolicyError(fas *autoscalingv1.FleetAutoscaler) {
+	ctx, err := tag.New(context.Background(), tag.Insert(keyName, fas.ObjectMeta.Name),
+		tag.Insert(keyFleetName, fas.Spec.FleetName), tag.Insert(keyType, string(fas.Spec.Policy.Type)))
+	if err != nil {
+		c.loggerForFleetAutoscaler(fas).WithError(err).Warn("failed to tag fleet autoscaler policy error metric")
+		return
+	}
+	stats.Record(ctx, policyErrorsStats.M(1))
+}
+
+// recordScale records that the autoscaler scaled its fleet from the replicas to the other
+func (c *Controller) recordScale(fas *autoscalingv1.FleetAutoscaler, from, to int32) {
+	direction := "up"
+	if to < from {
+		direction = "down"
+	}
+	ctx, err := tag.New(context.Background(), tag.Insert(keyName, fas.ObjectMeta.Name),
+		tag.Insert(keyFleetName, fas.Spec.FleetName), tag.Insert(keyDirection, direction))
+	if err != nil {
+		c.loggerForFleetAutoscaler(fas).WithError(err).Warn("failed to tag fleet autoscaler scale metric")
+		return
+	}
+	stats.Record(ctx, scalesStats.M(1))
+}
@@ -0,0 +1,251 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
+	"agones.dev/agones/pkg/fleetautoscalers"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultSyncPeriod is how often the controller syncs a FleetAutoscaler, on every resync of its informer
+const DefaultSyncPeriod = 30 * time.Second
+
+// Event is a point in time of a recorded allocation trace
+type Event struct {
+	// Offset is the time of the event, since the start of the trace
+	Offset metav1.Duration `json:"offset"`
+	// Allocations are the number of GameServers that were requested to be allocated at the time
+	Allocations int32 `json:"allocations,omitempty"`
+	// Releases are the number of Allocated GameServers that shut down at the time, as their game ended
+	Releases int32 `json:"releases,omitempty"`
+}
+
+// Trace is a recorded allocation trace, in order of the offsets of its events
+type Trace []Event
+
+// ParseTrace reads a Trace from a json array of Events, and sorts it by offset
+func ParseTrace(r io.Reader) (Trace, error) {
+	var trace Trace
+	if err := json.NewDecoder(r).Decode(&trace); err != nil {
+		return nil, errors.Wrap(err, "error decoding allocation trace")
+	}
+	for i, e := range trace {
+		if e.Offset.Duration < 0 || e.Allocations < 0 || e.Releases < 0 {
+			return nil, fmt.Errorf("event %d of the allocation trace has a negative offset or count", i)
+		}
+	}
+	sort.SliceStable(trace, func(i, j int) bool {
+		return trace[i].Offset.Duration < trace[j].Offset.Duration
+	})
+	return trace, nil
+}
+
+// Config configures how a Fleet behaves in a simulation
+type Config struct {
+	// Replicas are the number of Ready GameServers of the Fleet at the start of the trace
+	Replicas int32
+	// SyncPeriod is how often the FleetAutoscaler is synced. Defaults to DefaultSyncPeriod.
+	SyncPeriod time.Duration
+	// StartupTime is how long it takes a new GameServer to move to Ready
+	StartupTime time.Duration
+	// Start is the time of the start of the trace, that Schedule policies are applied at
+	Start time.Time
+}
+
+// Sample is the state of the Fleet after a sync of the FleetAutoscaler
+type Sample struct {
+	// Offset is the time of the sync, since the start of the trace
+	Offset time.Duration
+	// DesiredReplicas are the replicas the FleetAutoscaler scaled the Fleet to
+	DesiredReplicas int32
+	// ScalingLimited is true if the replicas were limited by the minimum or maximum replicas of the policy
+	ScalingLimited bool
+	// Replicas, ReadyReplicas and AllocatedReplicas are the status of the Fleet the FleetAutoscaler saw
+	Replicas          int32
+	ReadyReplicas     int32
+	AllocatedReplicas int32
+	// UnAllocated are the number of allocations since the previous sync that found no Ready GameServer
+	UnAllocated int32
+}
+
+// Result is the outcome of replaying a Trace against a FleetAutoscaler
+type Result struct {
+	// Samples are the replica curve of the Fleet, one for each sync of the FleetAutoscaler
+	Samples []Sample
+	// Allocated are the total number of allocations that found a Ready GameServer
+	Allocated int32
+	// UnAllocated are the total number of allocations that found no Ready GameServer
+	UnAllocated int32
+}
+
+// fleet is the simulated state of the GameServers of a Fleet
+type fleet struct {
+	config    Config
+	replicas  int32
+	ready     int32
+	allocated int32
+	// starting are the offsets at which the GameServers that are starting up move to Ready, in order
+	starting []time.Duration
+	// allocations are the allocations that found a Ready GameServer, for Predictive policies
+	allocations allocationLog
+}
+
+// allocation is a number of GameServers that were allocated at a time
+type allocation struct {
+	at    time.Time
+	count int32
+}
+
+// allocationLog is the AllocationHistory of the simulated Fleet, in order
+type allocationLog []allocation
+
+// AllocatedSince returns how many GameServers of the simulated Fleet were allocated since the given time
+func (l allocationLog) AllocatedSince(_, _ string, since time.Time) int32 {
+	var count int32
+	for _, a := range l {
+		if !a.at.Before(since) {
+			count += a.count
+		}
+	}
+	return count
+}
+
+// Simulate replays the Trace against the policy of the FleetAutoscaler, which is synced at the start of the trace
+// and every SyncPeriod after, until it has seen the last event of the trace. The events up to the time of a sync
+// are replayed before it. Allocations take a Ready GameServer if there is one, and released GameServers are
+// replaced, as long as the Fleet is not over its replicas, like a GameServerSet does.
+// Webhook policies are sent requests, so their webhook has to be running.
+func Simulate(fas *autoscalingv1.FleetAutoscaler, trace Trace, config Config) (*Result, error) {
+	if config.SyncPeriod <= 0 {
+		config.SyncPeriod = DefaultSyncPeriod
+	}
+	f := &fleet{config: config, replicas: config.Replicas, ready: config.Replicas}
+
+	result := &Result{}
+	var unAllocated int32
+	next := 0
+	for sync := time.Duration(0); ; sync += config.SyncPeriod {
+		for ; next < len(trace) && trace[next].Offset.Duration <= sync; next++ {
+			e := trace[next]
+			f.startup(e.Offset.Duration)
+			allocated := f.allocate(e.Allocations)
+			if allocated > 0 {
+				f.allocations = append(f.allocations, allocation{at: config.Start.Add(e.Offset.Duration), count: allocated})
+			}
+			result.Allocated += allocated
+			unAllocated += e.Allocations - allocated
+			f.release(e.Releases, e.Offset.Duration)
+		}
+
+		f.startup(sync)
+		sample, err := f.autoscale(fas, sync)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error applying FleetAutoscaler policy at %v", sync)
+		}
+		sample.UnAllocated = unAllocated
+		result.Samples = append(result.Samples, sample)
+		result.UnAllocated += unAllocated
+		unAllocated = 0
+
+		if next == len(trace) {
+			return result, nil
+		}
+	}
+}
+
+// startup moves the GameServers that have started up by now to Ready
+func (f *fleet) startup(now time.Duration) {
+	i := 0
+	for ; i < len(f.starting) && f.starting[i] <= now; i++ {
+		f.ready++
+	}
+	f.starting = f.starting[i:]
+}
+
+// allocate allocates up to count Ready GameServers, and returns how many were allocated
+func (f *fleet) allocate(count int32) int32 {
+	if count > f.ready {
+		count = f.ready
+	}
+	f.ready -= count
+	f.allocated += count
+	return count
+}
+
+// release shuts down up to count Allocated GameServers, and replaces them
+func (f *fleet) release(count int32, now time.Duration) {
+	if count > f.allocated {
+		count = f.allocated
+	}
+	f.allocated -= count
+	f.scale(now)
+}
+
+// autoscale applies the policy of the FleetAutoscaler to the current status of the Fleet, and scales it
+func (f *fleet) autoscale(fas *autoscalingv1.FleetAutoscaler, now time.Duration) (Sample, error) {
+	status := agonesv1.FleetStatus{
+		Replicas:          f.ready + f.allocated + int32(len(f.starting)),
+		ReadyReplicas:     f.ready,
+		AllocatedReplicas: f.allocated,
+	}
+	af := &agonesv1.Fleet{
+		ObjectMeta: metav1.ObjectMeta{Name: fas.Spec.FleetName, Namespace: fas.ObjectMeta.Namespace},
+		Spec:       agonesv1.FleetSpec{Replicas: f.replicas},
+		Status:     status,
+	}
+	replicas, limited, err := fleetautoscalers.DesiredFleetSize(fas, af, f.config.Start.Add(now), f.allocations)
+	if err != nil {
+		return Sample{}, err
+	}
+	f.replicas = replicas
+	f.scale(now)
+
+	return Sample{
+		Offset:            now,
+		DesiredReplicas:   replicas,
+		ScalingLimited:    limited,
+		Replicas:          status.Replicas,
+		ReadyReplicas:     status.ReadyReplicas,
+		AllocatedReplicas: status.AllocatedReplicas,
+	}, nil
+}
+
+// scale starts up GameServers while the Fleet is under its replicas, and deletes the ones that are starting up,
+// then the Ready ones, while it is over, but never Allocated ones
+func (f *fleet) scale(now time.Duration) {
+	total := f.ready + f.allocated + int32(len(f.starting))
+	for ; total < f.replicas; total++ {
+		if f.config.StartupTime <= 0 {
+			f.ready++
+		} else {
+			f.starting = append(f.starting, now+f.config.StartupTime)
+		}
+	}
+	for ; total > f.replicas && len(f.starting) > 0; total-- {
+		f.starting = f.starting[:len(f.starting)-1]
+	}
+	for ; total > f.replicas && f.ready > 0; total-- {
+		f.ready--
+	}
+}
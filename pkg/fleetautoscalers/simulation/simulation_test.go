@@ -0,0 +1,193 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package simulation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	autoscalingv1 "agones.dev/agones/pkg/apis/autoscaling/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestParseTrace(t *testing.T) {
+	t.Parallel()
+
+	trace, err := ParseTrace(strings.NewReader(`[
+		{"offset": "1m", "releases": 2},
+		{"offset": "0s", "allocations": 1},
+		{"offset": "10s", "allocations": 3}
+	]`))
+	assert.NoError(t, err)
+	assert.Equal(t, Trace{
+		{Offset: metav1.Duration{Duration: 0}, Allocations: 1},
+		{Offset: metav1.Duration{Duration: 10 * time.Second}, Allocations: 3},
+		{Offset: metav1.Duration{Duration: time.Minute}, Releases: 2},
+	}, trace)
+
+	_, err = ParseTrace(strings.NewReader(`[{"offset": "10s", "allocations": -1}]`))
+	assert.EqualError(t, err, "event 0 of the allocation trace has a negative offset or count")
+
+	_, err = ParseTrace(strings.NewReader(`{"offset": "10s"}`))
+	assert.Error(t, err)
+}
+
+func TestSimulateBufferPolicy(t *testing.T) {
+	t.Parallel()
+
+	fas := fleetAutoscaler(autoscalingv1.FleetAutoscalerPolicy{
+		Type:   autoscalingv1.BufferPolicyType,
+		Buffer: &autoscalingv1.BufferPolicy{BufferSize: intstr.FromInt(2), MaxReplicas: 10},
+	})
+	trace := Trace{
+		event(0, 1, 0),
+		event(10*time.Second, 3, 0),
+		event(40*time.Second, 0, 2),
+		event(65*time.Second, 1, 0),
+	}
+
+	result, err := Simulate(fas, trace, Config{Replicas: 2, StartupTime: 10 * time.Second})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), result.Allocated)
+	assert.Equal(t, int32(1), result.UnAllocated)
+	assert.Equal(t, []Sample{
+		{Offset: 0, DesiredReplicas: 3, Replicas: 2, ReadyReplicas: 1, AllocatedReplicas: 1},
+		// the new GameServer is Ready in time for the first allocation, but not for all three
+		{Offset: 30 * time.Second, DesiredReplicas: 5, Replicas: 3, ReadyReplicas: 0, AllocatedReplicas: 3, UnAllocated: 1},
+		// the released GameServers are replaced, and then scaled down
+		{Offset: 60 * time.Second, DesiredReplicas: 3, Replicas: 5, ReadyReplicas: 4, AllocatedReplicas: 1},
+		{Offset: 90 * time.Second, DesiredReplicas: 4, Replicas: 3, ReadyReplicas: 1, AllocatedReplicas: 2},
+	}, result.Samples)
+
+	// limited by the maximum replicas, and without a startup time
+	fas.Spec.Policy.Buffer.MaxReplicas = 2
+	result, err = Simulate(fas, trace, Config{Replicas: 2, SyncPeriod: time.Minute})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), result.Allocated)
+	assert.Equal(t, int32(2), result.UnAllocated)
+	assert.Equal(t, []Sample{
+		{Offset: 0, DesiredReplicas: 2, ScalingLimited: true, Replicas: 2, ReadyReplicas: 1, AllocatedReplicas: 1},
+		{Offset: time.Minute, DesiredReplicas: 2, Replicas: 2, ReadyReplicas: 2, AllocatedReplicas: 0, UnAllocated: 2},
+		{Offset: 2 * time.Minute, DesiredReplicas: 2, ScalingLimited: true, Replicas: 2, ReadyReplicas: 1, AllocatedReplicas: 1},
+	}, result.Samples)
+}
+
+func TestSimulateWebhookPolicy(t *testing.T) {
+	t.Parallel()
+
+	// scales the Fleet to twice its Allocated GameServers
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var review autoscalingv1.FleetAutoscaleReview
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		assert.Equal(t, "fleet-1", review.Request.Name)
+		review.Response = &autoscalingv1.FleetAutoscaleResponse{
+			UID:      review.Request.UID,
+			Scale:    true,
+			Replicas: review.Request.Status.AllocatedReplicas * 2,
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(review))
+	}))
+	defer ts.Close()
+
+	url := ts.URL
+	fas := fleetAutoscaler(autoscalingv1.FleetAutoscalerPolicy{
+		Type:    autoscalingv1.WebhookPolicyType,
+		Webhook: &autoscalingv1.WebhookPolicy{URL: &url},
+	})
+	result, err := Simulate(fas, Trace{event(0, 2, 0), event(30*time.Second, 2, 0)}, Config{Replicas: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(4), result.Allocated)
+	assert.Equal(t, int32(0), result.UnAllocated)
+	assert.Equal(t, []Sample{
+		{Offset: 0, DesiredReplicas: 4, Replicas: 2, ReadyReplicas: 0, AllocatedReplicas: 2},
+		{Offset: 30 * time.Second, DesiredReplicas: 8, Replicas: 4, ReadyReplicas: 0, AllocatedReplicas: 4},
+	}, result.Samples)
+
+	// the webhook is not running
+	ts.Close()
+	_, err = Simulate(fas, Trace{event(0, 2, 0)}, Config{Replicas: 2})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "error applying FleetAutoscaler policy at 0s")
+	}
+}
+
+func TestSimulateSchedulePolicy(t *testing.T) {
+	t.Parallel()
+
+	replicas := int32(5)
+	fas := fleetAutoscaler(autoscalingv1.FleetAutoscalerPolicy{
+		Type: autoscalingv1.SchedulePolicyType,
+		Schedule: &autoscalingv1.SchedulePolicy{
+			Default: &autoscalingv1.ScheduleTarget{Buffer: &autoscalingv1.BufferPolicy{BufferSize: intstr.FromInt(1), MaxReplicas: 10}},
+			Windows: []autoscalingv1.ScheduleWindow{{
+				Start:          "1 18 * * *",
+				Duration:       metav1.Duration{Duration: time.Minute},
+				ScheduleTarget: autoscalingv1.ScheduleTarget{Replicas: &replicas},
+			}},
+		},
+	})
+	start := time.Date(2019, time.July, 12, 18, 0, 0, 0, time.UTC)
+
+	result, err := Simulate(fas, Trace{event(0, 1, 0), event(2*time.Minute, 0, 0)}, Config{Replicas: 2, SyncPeriod: time.Minute, Start: start})
+	assert.NoError(t, err)
+	assert.Equal(t, []Sample{
+		{Offset: 0, DesiredReplicas: 2, Replicas: 2, ReadyReplicas: 1, AllocatedReplicas: 1},
+		{Offset: time.Minute, DesiredReplicas: 5, Replicas: 2, ReadyReplicas: 1, AllocatedReplicas: 1},
+		{Offset: 2 * time.Minute, DesiredReplicas: 2, Replicas: 5, ReadyReplicas: 4, AllocatedReplicas: 1},
+	}, result.Samples)
+}
+
+func TestSimulatePredictivePolicy(t *testing.T) {
+	t.Parallel()
+
+	fas := fleetAutoscaler(autoscalingv1.FleetAutoscalerPolicy{
+		Type: autoscalingv1.PredictivePolicyType,
+		Predictive: &autoscalingv1.PredictivePolicy{
+			Window:        &metav1.Duration{Duration: 2 * time.Minute},
+			LeadTime:      &metav1.Duration{Duration: time.Minute},
+			MinBufferSize: 1,
+			MaxReplicas:   20,
+		},
+	})
+	trace := Trace{event(0, 2, 0), event(time.Minute, 4, 0), event(2*time.Minute, 0, 0)}
+
+	result, err := Simulate(fas, trace, Config{Replicas: 2, SyncPeriod: time.Minute})
+	assert.NoError(t, err)
+	assert.Equal(t, []Sample{
+		{Offset: 0, DesiredReplicas: 3, Replicas: 2, ReadyReplicas: 0, AllocatedReplicas: 2},
+		{Offset: time.Minute, DesiredReplicas: 5, Replicas: 3, ReadyReplicas: 0, AllocatedReplicas: 3, UnAllocated: 3},
+		{Offset: 2 * time.Minute, DesiredReplicas: 5, Replicas: 5, ReadyReplicas: 2, AllocatedReplicas: 3},
+	}, result.Samples)
+}
+
+func fleetAutoscaler(policy autoscalingv1.FleetAutoscalerPolicy) *autoscalingv1.FleetAutoscaler {
+	return &autoscalingv1.FleetAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Name: "fas-1", Namespace: "default"},
+		Spec:       autoscalingv1.FleetAutoscalerSpec{FleetName: "fleet-1", Policy: policy},
+	}
+}
+
+func event(offset time.Duration, allocations, releases int32) Event {
+	return Event{Offset: metav1.Duration{Duration: offset}, Allocations: allocations, Releases: releases}
+}
@@ -18,13 +18,18 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"sort"
+	"strconv"
 
+	"agones.dev/agones/pkg/apis"
 	"agones.dev/agones/pkg/apis/agones"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"agones.dev/agones/pkg/client/clientset/versioned"
 	getterv1 "agones.dev/agones/pkg/client/clientset/versioned/typed/agones/v1"
 	"agones.dev/agones/pkg/client/informers/externalversions"
 	listerv1 "agones.dev/agones/pkg/client/listers/agones/v1"
+	"agones.dev/agones/pkg/cloudproduct"
+	"agones.dev/agones/pkg/gameserversets"
 	"agones.dev/agones/pkg/util/crd"
 	"agones.dev/agones/pkg/util/logfields"
 	"agones.dev/agones/pkg/util/runtime"
@@ -41,51 +46,83 @@ import (
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1beta1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 )
 
+// changeCauseAnnotation is the annotation that kubectl records the command that changed a resource in,
+// which is copied from a Fleet to the GameServerSets created for the change
+const changeCauseAnnotation = "kubernetes.io/change-cause"
+
 // Controller is a the GameServerSet controller
 type Controller struct {
 	baseLogger          *logrus.Entry
+	cloudProduct        cloudproduct.CloudProduct
 	crdGetter           v1beta1.CustomResourceDefinitionInterface
+	gameServerGetter    getterv1.GameServersGetter
+	gameServerLister    listerv1.GameServerLister
+	gameServerSynced    cache.InformerSynced
 	gameServerSetGetter getterv1.GameServerSetsGetter
 	gameServerSetLister listerv1.GameServerSetLister
 	gameServerSetSynced cache.InformerSynced
 	fleetGetter         getterv1.FleetsGetter
 	fleetLister         listerv1.FleetLister
 	fleetSynced         cache.InformerSynced
+	serviceGetter       typedcorev1.ServicesGetter
+	serviceLister       corelisterv1.ServiceLister
+	serviceSynced       cache.InformerSynced
 	workerqueue         *workerqueue.WorkerQueue
 	recorder            record.EventRecorder
+	syncLog             runtime.SampledLogger
+	patchLog            runtime.SampledLogger
+	statusLog           runtime.SampledLogger
+	// clock is the source of time of the drain deadlines of rollouts
+	clock clock.Clock
 }
 
 // NewController returns a new fleets crd controller
 func NewController(
 	wh *webhooks.WebHook,
 	health healthcheck.Handler,
+	cloudProduct cloudproduct.CloudProduct,
 	kubeClient kubernetes.Interface,
 	extClient extclientset.Interface,
 	agonesClient versioned.Interface,
+	kubeInformerFactory informers.SharedInformerFactory,
 	agonesInformerFactory externalversions.SharedInformerFactory) *Controller {
 
+	gameServers := agonesInformerFactory.Agones().V1().GameServers()
 	gameServerSets := agonesInformerFactory.Agones().V1().GameServerSets()
 	gsSetInformer := gameServerSets.Informer()
 
 	fleets := agonesInformerFactory.Agones().V1().Fleets()
 	fInformer := fleets.Informer()
 
+	services := kubeInformerFactory.Core().V1().Services()
+
 	c := &Controller{
+		cloudProduct:        cloudProduct,
 		crdGetter:           extClient.ApiextensionsV1beta1().CustomResourceDefinitions(),
+		gameServerGetter:    agonesClient.AgonesV1(),
+		gameServerLister:    gameServers.Lister(),
+		gameServerSynced:    gameServers.Informer().HasSynced,
 		gameServerSetGetter: agonesClient.AgonesV1(),
 		gameServerSetLister: gameServerSets.Lister(),
 		gameServerSetSynced: gsSetInformer.HasSynced,
 		fleetGetter:         agonesClient.AgonesV1(),
 		fleetLister:         fleets.Lister(),
 		fleetSynced:         fInformer.HasSynced,
+		serviceGetter:       kubeClient.CoreV1(),
+		serviceLister:       services.Lister(),
+		serviceSynced:       services.Informer().HasSynced,
+		clock:               clock.RealClock{},
 	}
 
 	c.baseLogger = runtime.NewLoggerWithType(c)
@@ -102,9 +139,13 @@ func NewController(
 	wh.AddHandler("/validate", agonesv1.Kind("Fleet"), admv1beta1.Update, c.creationValidationHandler)
 
 	fInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
-		AddFunc: c.workerqueue.Enqueue,
+		AddFunc: func(obj interface{}) {
+			c.workerqueue.Enqueue(obj)
+			c.enqueueDependents(obj.(*agonesv1.Fleet))
+		},
 		UpdateFunc: func(_, newObj interface{}) {
 			c.workerqueue.Enqueue(newObj)
+			c.enqueueDependents(newObj.(*agonesv1.Fleet))
 		},
 	})
 
@@ -119,6 +160,13 @@ func NewController(
 		},
 	})
 
+	services.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, newObj interface{}) {
+			c.serviceEventHandler(newObj)
+		},
+		DeleteFunc: c.serviceEventHandler,
+	})
+
 	return c
 }
 
@@ -127,7 +175,7 @@ func NewController(
 // Should only be called on fleet create operations.
 // nolint:dupl
 func (c *Controller) creationMutationHandler(review admv1beta1.AdmissionReview) (admv1beta1.AdmissionReview, error) {
-	c.baseLogger.WithField("review", review).Info("creationMutationHandler")
+	c.baseLogger.WithField("review", review).Debug("creationMutationHandler")
 
 	obj := review.Request.Object
 	fleet := &agonesv1.Fleet{}
@@ -140,6 +188,14 @@ func (c *Controller) creationMutationHandler(review admv1beta1.AdmissionReview)
 	// the rest is really just json plumbing
 	fleet.ApplyDefaults()
 
+	// On a server side dry run, show the defaulted GameServer that would be created from the Fleet,
+	// so it can be reviewed before rolling out. It is never persisted.
+	if review.Request.DryRun != nil && *review.Request.DryRun {
+		if err := setDryRunTemplate(fleet); err != nil {
+			return review, err
+		}
+	}
+
 	newFleet, err := json.Marshal(fleet)
 	if err != nil {
 		return review, errors.Wrapf(err, "error marshalling default applied Fleet %s to json", fleet.ObjectMeta.Name)
@@ -155,7 +211,7 @@ func (c *Controller) creationMutationHandler(review admv1beta1.AdmissionReview)
 		return review, errors.Wrapf(err, "error creating json for patch for Fleet %s", fleet.ObjectMeta.Name)
 	}
 
-	c.loggerForFleet(fleet).WithField("patch", string(jsn)).Infof("patch created!")
+	c.patchLog.Debug(c.loggerForFleet(fleet).WithField("patch", string(jsn)), "patch created!")
 
 	pt := admv1beta1.PatchTypeJSONPatch
 	review.Response.PatchType = &pt
@@ -164,10 +220,31 @@ func (c *Controller) creationMutationHandler(review admv1beta1.AdmissionReview)
 	return review, nil
 }
 
+// setDryRunTemplate sets the FleetDryRunTemplateAnnotation on the Fleet, to the template of the
+// GameServers it would create, with the defaults of the GameServer mutating webhook applied
+func setDryRunTemplate(fleet *agonesv1.Fleet) error {
+	// the GameServerSet has no name until it is created, so give it one that shows it is not real
+	gsSet := fleet.GameServerSet()
+	gsSet.ObjectMeta.Name = gsSet.ObjectMeta.GenerateName + "dry-run"
+	gs := gsSet.GameServer()
+	gs.ApplyDefaults()
+
+	template, err := json.Marshal(agonesv1.GameServerTemplateSpec{ObjectMeta: gs.ObjectMeta, Spec: gs.Spec})
+	if err != nil {
+		return errors.Wrapf(err, "error marshalling dry run template for Fleet %s to json", fleet.ObjectMeta.Name)
+	}
+
+	if fleet.ObjectMeta.Annotations == nil {
+		fleet.ObjectMeta.Annotations = map[string]string{}
+	}
+	fleet.ObjectMeta.Annotations[agonesv1.FleetDryRunTemplateAnnotation] = string(template)
+	return nil
+}
+
 // creationValidationHandler that validates a Fleet when it is created
 // Should only be called on Fleet create and Update operations.
 func (c *Controller) creationValidationHandler(review admv1beta1.AdmissionReview) (admv1beta1.AdmissionReview, error) {
-	c.baseLogger.WithField("review", review).Info("creationValidationHandler")
+	c.baseLogger.WithField("review", review).Debug("creationValidationHandler")
 
 	obj := review.Request.Object
 	fleet := &agonesv1.Fleet{}
@@ -176,8 +253,10 @@ func (c *Controller) creationValidationHandler(review admv1beta1.AdmissionReview
 		return review, errors.Wrapf(err, "error unmarshalling original Fleet json: %s", obj.Raw)
 	}
 
-	causes, ok := fleet.Validate()
-	if !ok {
+	causes, _ := fleet.Validate()
+	causes = append(causes, c.cloudProduct.ValidateGameServerSpec(&fleet.Spec.Template.Spec)...)
+	causes = append(causes, c.validateDependencyCycle(fleet)...)
+	if len(causes) > 0 {
 		review.Response.Allowed = false
 		details := metav1.StatusDetails{
 			Name:   review.Request.Name,
@@ -199,6 +278,11 @@ func (c *Controller) creationValidationHandler(review admv1beta1.AdmissionReview
 	return review, nil
 }
 
+// WorkQueues returns the work queues of the controller, for the statusz page
+func (c *Controller) WorkQueues() []*workerqueue.WorkerQueue {
+	return []*workerqueue.WorkerQueue{c.workerqueue}
+}
+
 // Run the Fleet controller. Will block until stop is closed.
 // Runs threadiness number workers to process the rate limited queue
 func (c *Controller) Run(workers int, stop <-chan struct{}) error {
@@ -208,7 +292,7 @@ func (c *Controller) Run(workers int, stop <-chan struct{}) error {
 	}
 
 	c.baseLogger.Info("Wait for cache sync")
-	if !cache.WaitForCacheSync(stop, c.gameServerSetSynced, c.fleetSynced) {
+	if !cache.WaitForCacheSync(stop, c.gameServerSynced, c.gameServerSetSynced, c.fleetSynced, c.serviceSynced) {
 		return errors.New("failed to wait for caches to sync")
 	}
 
@@ -225,7 +309,7 @@ func (c *Controller) loggerForFleet(f *agonesv1.Fleet) *logrus.Entry {
 	if f != nil {
 		fleetName = f.ObjectMeta.Namespace + "/" + f.ObjectMeta.Name
 	}
-	return c.loggerForFleetKey(fleetName).WithField("fleet", f)
+	return logfields.WithObject(c.loggerForFleetKey(fleetName), "fleet", f)
 }
 
 // gameServerSetEventHandler enqueues the owning Fleet for this GameServerSet,
@@ -253,7 +337,7 @@ func (c *Controller) gameServerSetEventHandler(obj interface{}) {
 // syncFleet synchronised the fleet CRDs and configures/updates
 // backing GameServerSets
 func (c *Controller) syncFleet(key string) error {
-	c.loggerForFleetKey(key).Info("Synchronising")
+	c.syncLog.Debug(c.loggerForFleetKey(key), "Synchronising")
 
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
@@ -272,31 +356,465 @@ func (c *Controller) syncFleet(key string) error {
 		return errors.Wrapf(err, "error retrieving fleet %s from namespace %s", name, namespace)
 	}
 
+	if err := c.syncFleetMetricsService(fleet); err != nil {
+		return err
+	}
+
 	list, err := ListGameServerSetsByFleetOwner(c.gameServerSetLister, fleet)
 	if err != nil {
 		return err
 	}
 
+	// the Fleet is synced again once the rollout is paused or rolled back
+	if stopped, err := c.stopFailingRollout(fleet, list); err != nil || stopped {
+		return err
+	}
+
+	if _, ok := fleet.ObjectMeta.Annotations[agonesv1.FleetRollbackAnnotation]; ok {
+		// the Fleet is synced again once it has been updated with the templates of the revision
+		return c.rollback(fleet, list)
+	}
+
+	// the rollout of a Fleet is held like the one of a paused Fleet while the Fleets it depends on are rolling out,
+	// but it is still scaled
+	waiting, err := c.waitingDependencies(fleet, list)
+	if err != nil {
+		return err
+	}
+	held := fleet.Spec.Paused || len(waiting) > 0
+	if len(waiting) > 0 {
+		c.loggerForFleet(fleet).WithField("dependencies", waiting).Debug("rollout waiting for dependencies")
+	}
+
+	// group the GameServerSets by the Fleet template they were created from
+	byTemplate := map[string][]*agonesv1.GameServerSet{}
+	for _, gsSet := range list {
+		name := gsSet.ObjectMeta.Labels[agonesv1.FleetTemplateLabel]
+		byTemplate[name] = append(byTemplate[name], gsSet)
+	}
+
+	revision := nextRevision(list)
+	names, templates := templateFleets(fleet)
+	for _, name := range names {
+		if err := c.syncFleetTemplate(templates[name], name, byTemplate[name], revision, held); err != nil {
+			return err
+		}
+	}
+
+	// a paused Fleet, or one waiting for its dependencies, does not progress its rollout
+	if held {
+		return c.updateFleetStatus(fleet)
+	}
+
+	// scale down the GameServerSets of templates that have been removed from the Fleet
+	for name, rest := range byTemplate {
+		if _, ok := templates[name]; ok {
+			continue
+		}
+		if err := c.scaleDownRemovedTemplate(fleet, rest); err != nil {
+			return err
+		}
+		if err := c.deleteEmptyGameServerSets(fleet, rest, 0); err != nil {
+			return err
+		}
+	}
+
+	if err := c.deleteUndrainedGameServers(fleet, outdatedGameServerSets(fleet, list)); err != nil {
+		return err
+	}
+
+	return c.updateFleetStatus(fleet)
+}
+
+// rollback updates the GameServer templates of the Fleet to those of the revision that its rollback annotation
+// is set to, and removes the annotation. Each template is rolled back to its GameServerSet with the highest revision
+// up to that revision, so templates that were not changed in the revision are rolled back as well.
+func (c *Controller) rollback(fleet *agonesv1.Fleet, list []*agonesv1.GameServerSet) error {
+	fCopy := fleet.DeepCopy()
+	delete(fCopy.ObjectMeta.Annotations, agonesv1.FleetRollbackAnnotation)
+
+	revision, err := strconv.ParseInt(fleet.ObjectMeta.Annotations[agonesv1.FleetRollbackAnnotation], 10, 64)
+	if err == nil && revision == 0 {
+		revision = previousRevision(list)
+	}
+
+	found := false
+	if err == nil && revision > 0 {
+		byTemplate := map[string]*agonesv1.GameServerSet{}
+		for _, gsSet := range list {
+			r := gameServerSetRevision(gsSet)
+			if r == revision {
+				found = true
+			}
+			name := gsSet.ObjectMeta.Labels[agonesv1.FleetTemplateLabel]
+			if r > 0 && r <= revision && (byTemplate[name] == nil || r > gameServerSetRevision(byTemplate[name])) {
+				byTemplate[name] = gsSet
+			}
+		}
+		if found {
+			if gsSet, ok := byTemplate[""]; ok {
+				fCopy.Spec.Template = gsSet.Spec.Template
+			}
+			for i := range fCopy.Spec.Templates {
+				if gsSet, ok := byTemplate[fCopy.Spec.Templates[i].Name]; ok {
+					fCopy.Spec.Templates[i].Template = gsSet.Spec.Template
+				}
+			}
+		}
+	}
+
+	if _, err := c.fleetGetter.Fleets(fCopy.ObjectMeta.Namespace).Update(fCopy); err != nil {
+		return errors.Wrapf(err, "error rolling back fleet %s", fCopy.ObjectMeta.Name)
+	}
+	if !found {
+		c.recorder.Eventf(fleet, corev1.EventTypeWarning, "RollbackRevisionNotFound",
+			"Unable to find a GameServerSet for rollback revision %s", fleet.ObjectMeta.Annotations[agonesv1.FleetRollbackAnnotation])
+		return nil
+	}
+	c.recorder.Eventf(fleet, corev1.EventTypeNormal, "RollingBack", "Rolling back to revision %d", revision)
+	return nil
+}
+
+// stopFailingRollout pauses the rollout of the Fleet, or rolls it back to its previous revision, once the
+// GameServerSet of one of the templates that are being rolled out exceeds the failure threshold of the rollout,
+// and marks the GameServerSet with the rollout failed annotation, so that it is not stopped again. It returns true
+// if it stopped a rollout.
+func (c *Controller) stopFailingRollout(fleet *agonesv1.Fleet, list []*agonesv1.GameServerSet) (bool, error) {
+	threshold := fleet.Spec.Rollout.FailureThreshold
+	if threshold == nil {
+		return false, nil
+	}
+
+	// only the templates with outdated GameServerSets are being rolled out
+	rolledOut := map[string]bool{}
+	for _, gsSet := range outdatedGameServerSets(fleet, list) {
+		rolledOut[gsSet.ObjectMeta.Labels[agonesv1.FleetTemplateLabel]] = true
+	}
+	_, templates := templateFleets(fleet)
+	var failing *agonesv1.GameServerSet
+	for _, gsSet := range list {
+		name := gsSet.ObjectMeta.Labels[agonesv1.FleetTemplateLabel]
+		f, ok := templates[name]
+		if !ok || !rolledOut[name] || !reflect.DeepEqual(gsSet.Spec.Template, f.Spec.Template) {
+			continue
+		}
+		if _, ok := gsSet.ObjectMeta.Annotations[agonesv1.FleetRolloutFailedAnnotation]; ok {
+			continue
+		}
+		if threshold.Exceeded(gsSet.Status.FailedReplicas, gsSet.Status.Replicas) {
+			failing = gsSet
+			break
+		}
+	}
+	if failing == nil {
+		return false, nil
+	}
+
+	// a Fleet without a previous revision to roll back to is paused instead
+	action := threshold.Action
+	decision := "Rolling back to the previous revision"
+	fCopy := fleet.DeepCopy()
+	if action == agonesv1.FleetRolloutFailureAbort && previousRevision(list) > 0 {
+		if fCopy.ObjectMeta.Annotations == nil {
+			fCopy.ObjectMeta.Annotations = make(map[string]string, 1)
+		}
+		fCopy.ObjectMeta.Annotations[agonesv1.FleetRollbackAnnotation] = "0"
+	} else {
+		action = agonesv1.FleetRolloutFailurePause
+		decision = "Pausing the rollout"
+		fCopy.Spec.Paused = true
+	}
+
+	// the Fleet may already have been stopped, if marking the GameServerSet failed
+	if !reflect.DeepEqual(fleet.Spec, fCopy.Spec) || !reflect.DeepEqual(fleet.ObjectMeta.Annotations, fCopy.ObjectMeta.Annotations) {
+		if _, err := c.fleetGetter.Fleets(fCopy.ObjectMeta.Namespace).Update(fCopy); err != nil {
+			return false, errors.Wrapf(err, "error stopping the rollout of fleet %s", fCopy.ObjectMeta.Name)
+		}
+		c.loggerForFleet(fleet).WithField("gameserverset", failing.ObjectMeta.Name).WithField("action", action).
+			Info("stopped rollout that exceeded its failure threshold")
+		c.recorder.Eventf(fleet, corev1.EventTypeWarning, "RolloutFailed",
+			"%s, as %d GameServers of GameServerSet %s failed while %d are left, which exceeds the failure threshold of %d%%",
+			decision, failing.Status.FailedReplicas, failing.ObjectMeta.Name, failing.Status.Replicas, threshold.Percent)
+	}
+
+	gsSetCopy := failing.DeepCopy()
+	if gsSetCopy.ObjectMeta.Annotations == nil {
+		gsSetCopy.ObjectMeta.Annotations = make(map[string]string, 1)
+	}
+	gsSetCopy.ObjectMeta.Annotations[agonesv1.FleetRolloutFailedAnnotation] = string(action)
+	if _, err := c.gameServerSetGetter.GameServerSets(gsSetCopy.ObjectMeta.Namespace).Update(gsSetCopy); err != nil {
+		return false, errors.Wrapf(err, "error marking the rollout of gameserverset %s as failed", gsSetCopy.ObjectMeta.Name)
+	}
+	return true, nil
+}
+
+// rolloutFailedCondition returns the RolloutFailed condition of the Fleet, which is True if its newest GameServerSet
+// has the rollout failed annotation, and false if it is not set and does not need to be, as the Fleet never
+// stopped a rollout
+func rolloutFailedCondition(status agonesv1.FleetStatus, list []*agonesv1.GameServerSet) (agonesv1.FleetCondition, bool) {
+	var newest *agonesv1.GameServerSet
+	for _, gsSet := range list {
+		if newest == nil || gameServerSetRevision(gsSet) > gameServerSetRevision(newest) {
+			newest = gsSet
+		}
+	}
+
+	if newest != nil {
+		if action, ok := newest.ObjectMeta.Annotations[agonesv1.FleetRolloutFailedAnnotation]; ok {
+			reason := "RolloutPaused"
+			if agonesv1.FleetRolloutFailureAction(action) == agonesv1.FleetRolloutFailureAbort {
+				reason = "RolloutAborted"
+			}
+			return agonesv1.FleetCondition{
+				Type:               agonesv1.FleetConditionRolloutFailed,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Now(),
+				Reason:             reason,
+				Message:            fmt.Sprintf("The GameServers of GameServerSet %s exceeded the failure threshold of the rollout", newest.ObjectMeta.Name),
+			}, true
+		}
+	}
+
+	if _, ok := status.GetCondition(agonesv1.FleetConditionRolloutFailed); !ok {
+		return agonesv1.FleetCondition{}, false
+	}
+	return agonesv1.FleetCondition{
+		Type:               agonesv1.FleetConditionRolloutFailed,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "WithinFailureThreshold",
+		Message:            "The newest GameServerSet did not exceed the failure threshold of the rollout",
+	}, true
+}
+
+// gameServerSetRevision returns the revision of the Fleet that the GameServerSet was created for,
+// or 0 if it does not have one
+func gameServerSetRevision(gsSet *agonesv1.GameServerSet) int64 {
+	revision, err := strconv.ParseInt(gsSet.ObjectMeta.Annotations[agonesv1.FleetRevisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
+
+// nextRevision returns the revision that the GameServerSets created for a change to the Fleet's templates are given
+func nextRevision(list []*agonesv1.GameServerSet) int64 {
+	var revision int64
+	for _, gsSet := range list {
+		if r := gameServerSetRevision(gsSet); r > revision {
+			revision = r
+		}
+	}
+	return revision + 1
+}
+
+// previousRevision returns the highest revision of the GameServerSets below the current one, or 0 if there is none
+func previousRevision(list []*agonesv1.GameServerSet) int64 {
+	current := nextRevision(list) - 1
+	var revision int64
+	for _, gsSet := range list {
+		if r := gameServerSetRevision(gsSet); r < current && r > revision {
+			revision = r
+		}
+	}
+	return revision
+}
+
+// scaleDownRemovedTemplate scales down the GameServerSets of a template that has been removed from the Fleet,
+// following the deployment strategy of the Fleet
+func (c *Controller) scaleDownRemovedTemplate(fleet *agonesv1.Fleet, rest []*agonesv1.GameServerSet) error {
+	switch fleet.Spec.Strategy.Type {
+	case appsv1.RecreateDeploymentStrategyType, agonesv1.CanaryDeploymentStrategyType:
+		_, err := c.recreateDeployment(fleet, rest)
+		return err
+	case appsv1.RollingUpdateDeploymentStrategyType:
+		return c.rollingUpdateRest(fleet, rest)
+	}
+
+	return errors.Errorf("unexpected deployment strategy type: %s", fleet.Spec.Strategy.Type)
+}
+
+// outdatedGameServerSets returns the GameServerSets that were not created from one of the current templates of the Fleet,
+// and have not been scaled down
+func outdatedGameServerSets(fleet *agonesv1.Fleet, list []*agonesv1.GameServerSet) []*agonesv1.GameServerSet {
+	_, templates := templateFleets(fleet)
+	var outdated []*agonesv1.GameServerSet
+	for _, gsSet := range list {
+		// GameServerSets that are kept for the revision history are not part of a rollout
+		if gsSet.Spec.Replicas == 0 && gsSet.Status.Replicas == 0 {
+			continue
+		}
+		f, ok := templates[gsSet.ObjectMeta.Labels[agonesv1.FleetTemplateLabel]]
+		if !ok || !reflect.DeepEqual(gsSet.Spec.Template, f.Spec.Template) {
+			outdated = append(outdated, gsSet)
+		}
+	}
+	return outdated
+}
+
+// deleteUndrainedGameServers deletes the Allocated GameServers of the outdated GameServerSets once the drain deadline
+// of the rollout of a Fleet with the DrainAllocated rollout mode has passed
+func (c *Controller) deleteUndrainedGameServers(fleet *agonesv1.Fleet, outdated []*agonesv1.GameServerSet) error {
+	if fleet.Status.Rollout == nil || agonesv1.SumStatusAllocatedReplicas(outdated) == 0 {
+		return nil
+	}
+	// a GameServerSet that became outdated since the status was updated starts a new rollout,
+	// which is given the full drain timeout once the status is updated
+	if !rolloutIncludes(fleet.Status.Rollout, outdated) {
+		return nil
+	}
+	deadline := fleet.RolloutDrainDeadline(fleet.Status.Rollout.StartTime)
+	if deadline == nil {
+		return nil
+	}
+	if wait := deadline.Time.Sub(c.clock.Now()); wait > 0 {
+		// check again once the deadline has passed
+		c.workerqueue.EnqueueAfter(fleet, wait)
+		return nil
+	}
+
+	for _, gsSet := range outdated {
+		list, err := gameserversets.ListGameServersByGameServerSetOwner(c.gameServerLister, gsSet)
+		if err != nil {
+			return err
+		}
+		for _, gs := range list {
+			if gs.Status.State != agonesv1.GameServerStateAllocated || gs.IsBeingDeleted() {
+				continue
+			}
+			err := c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Delete(gs.ObjectMeta.Name, nil)
+			if k8serrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return errors.Wrapf(err, "error deleting allocated gameserver %s", gs.ObjectMeta.Name)
+			}
+			c.loggerForFleet(fleet).WithField("gameserver", gs.ObjectMeta.Name).WithField("gameserverset", gsSet.ObjectMeta.Name).
+				Info("deleted allocated gameserver after the rollout drain timeout")
+			c.recorder.Eventf(fleet, corev1.EventTypeWarning, "DrainTimeout",
+				"Deleting Allocated GameServer %s of inactive GameServerSet %s, as it did not shut down before the rollout drain timeout", gs.ObjectMeta.Name, gsSet.ObjectMeta.Name)
+		}
+	}
+	return nil
+}
+
+// templateFleets splits a Fleet into a Fleet per GameServer template, each with the template's share of the
+// replicas, keyed by the name of the template. Template has the empty name, and comes first in the returned
+// ordered list of names.
+func templateFleets(fleet *agonesv1.Fleet) ([]string, map[string]*agonesv1.Fleet) {
+	replicas, rest := fleet.TemplateReplicas()
+
+	main := fleet.DeepCopy()
+	main.Spec.Replicas = rest
+	main.Spec.Templates = nil
+
+	names := []string{""}
+	templates := map[string]*agonesv1.Fleet{"": main}
+	for i, ft := range fleet.Spec.Templates {
+		f := fleet.DeepCopy()
+		f.Spec.Replicas = replicas[i]
+		f.Spec.Template = ft.Template
+		f.Spec.Templates = nil
+
+		names = append(names, ft.Name)
+		templates[ft.Name] = f
+	}
+
+	return names, templates
+}
+
+// syncFleetTemplate configures/updates the backing GameServerSets of a single template
+// of the fleet, as returned by templateFleets. A GameServerSet that is created is given the revision.
+// If held, as the Fleet is paused or waits for its dependencies, the rollout of a changed template is not progressed.
+func (c *Controller) syncFleetTemplate(fleet *agonesv1.Fleet, name string, list []*agonesv1.GameServerSet, revision int64, held bool) error {
 	active, rest := c.filterGameServerSetByActive(fleet, list)
 
+	// a held Fleet does not create a GameServerSet for a changed template, nor progress its rollout,
+	// but still scales the GameServerSets of a template that is being rolled out, like a paused Deployment
+	if held && (active == nil || len(rest) > 0) {
+		return c.scalePausedGameServerSets(fleet, active, rest)
+	}
+
 	// if there isn't an active gameServerSet, create one (but don't persist yet)
 	if active == nil {
-		c.loggerForFleet(fleet).Info("could not find active GameServerSet, creating")
+		c.loggerForFleet(fleet).WithField("template", name).Info("could not find active GameServerSet, creating")
 		active = fleet.GameServerSet()
+		if name != "" {
+			active.ObjectMeta.GenerateName = fleet.ObjectMeta.Name + "-" + name + "-"
+			active.ObjectMeta.Labels[agonesv1.FleetTemplateLabel] = name
+		}
+		if active.ObjectMeta.Annotations == nil {
+			active.ObjectMeta.Annotations = make(map[string]string, 2)
+		}
+		active.ObjectMeta.Annotations[agonesv1.FleetRevisionAnnotation] = strconv.FormatInt(revision, 10)
+		if cause, ok := fleet.ObjectMeta.Annotations[changeCauseAnnotation]; ok {
+			active.ObjectMeta.Annotations[changeCauseAnnotation] = cause
+		}
 	}
 
 	replicas, err := c.applyDeploymentStrategy(fleet, active, rest)
 	if err != nil {
 		return err
 	}
-	if err := c.deleteEmptyGameServerSets(fleet, rest); err != nil {
+	if err := c.deleteEmptyGameServerSets(fleet, rest, fleet.Spec.RevisionHistoryLimit); err != nil {
 		return err
 	}
 
-	if err := c.upsertGameServerSet(fleet, active, replicas); err != nil {
-		return err
+	return c.upsertGameServerSet(fleet, active, replicas)
+}
+
+// scalePausedGameServerSets scales the GameServerSets of a template of a paused Fleet that is being rolled out
+// to the replicas of the template, in proportion to their current replicas, so the rollout stays where it was paused
+func (c *Controller) scalePausedGameServerSets(fleet *agonesv1.Fleet, active *agonesv1.GameServerSet, rest []*agonesv1.GameServerSet) error {
+	list := rest
+	if active != nil {
+		list = append([]*agonesv1.GameServerSet{active}, rest...)
 	}
-	return c.updateFleetStatus(fleet)
+	replicas := proportionalReplicas(fleet.Spec.Replicas, list)
+
+	for i, gsSet := range list {
+		if replicas[i] == gsSet.Spec.Replicas {
+			continue
+		}
+		gsSetCopy := gsSet.DeepCopy()
+		gsSetCopy.Spec.Replicas = replicas[i]
+		gsSetCopy, err := c.gameServerSetGetter.GameServerSets(fleet.ObjectMeta.Namespace).Update(gsSetCopy)
+		if err != nil {
+			return errors.Wrapf(err, "error updating replicas for gameserverset for fleet %s", fleet.ObjectMeta.Name)
+		}
+		c.recorder.Eventf(fleet, corev1.EventTypeNormal, "ScalingGameServerSet",
+			"Scaling paused GameServerSet %s from %d to %d", gsSetCopy.ObjectMeta.Name, gsSet.Spec.Replicas, gsSetCopy.Spec.Replicas)
+	}
+
+	return nil
+}
+
+// proportionalReplicas splits the replicas between the GameServerSets in proportion to their current replicas.
+// The remainder of the rounding goes to the largest GameServerSet, or the first one if they all have no replicas.
+func proportionalReplicas(replicas int32, list []*agonesv1.GameServerSet) []int32 {
+	result := make([]int32, len(list))
+	if len(list) == 0 {
+		return result
+	}
+
+	largest := 0
+	var total int64
+	for i, gsSet := range list {
+		total += int64(gsSet.Spec.Replicas)
+		if gsSet.Spec.Replicas > list[largest].Spec.Replicas {
+			largest = i
+		}
+	}
+
+	remainder := replicas
+	if total > 0 {
+		for i, gsSet := range list {
+			result[i] = int32(int64(gsSet.Spec.Replicas) * int64(replicas) / total)
+			remainder -= result[i]
+		}
+	}
+	result[largest] += remainder
+	return result
 }
 
 // upsertGameServerSet if the GameServerSet is new, insert it
@@ -308,6 +826,10 @@ func (c *Controller) upsertGameServerSet(fleet *agonesv1.Fleet, active *agonesv1
 		gsSets := c.gameServerSetGetter.GameServerSets(active.ObjectMeta.Namespace)
 		gsSet, err := gsSets.Create(active)
 		if err != nil {
+			err = apis.FromAPIError(err)
+			if reason := apis.ReasonForError(err); reason != "" {
+				c.recorder.Event(fleet, corev1.EventTypeWarning, string(reason), err.Error())
+			}
 			return errors.Wrapf(err, "error creating gameserverset for fleet %s", fleet.ObjectMeta.Name)
 		}
 
@@ -328,10 +850,12 @@ func (c *Controller) upsertGameServerSet(fleet *agonesv1.Fleet, active *agonesv1
 		return nil
 	}
 
-	if replicas != active.Spec.Replicas || active.Spec.Scheduling != fleet.Spec.Scheduling {
+	if replicas != active.Spec.Replicas || active.Spec.Scheduling != fleet.Spec.Scheduling ||
+		!reflect.DeepEqual(active.Spec.AllocationOverflow, fleet.Spec.AllocationOverflow) {
 		gsSetCopy := active.DeepCopy()
 		gsSetCopy.Spec.Replicas = replicas
 		gsSetCopy.Spec.Scheduling = fleet.Spec.Scheduling
+		gsSetCopy.Spec.AllocationOverflow = fleet.Spec.AllocationOverflow.DeepCopy()
 		gsSetCopy, err := c.gameServerSetGetter.GameServerSets(fleet.ObjectMeta.Namespace).Update(gsSetCopy)
 		if err != nil {
 			return errors.Wrapf(err, "error updating replicas for gameserverset for fleet %s", fleet.ObjectMeta.Name)
@@ -355,27 +879,47 @@ func (c *Controller) applyDeploymentStrategy(fleet *agonesv1.Fleet, active *agon
 
 	switch fleet.Spec.Strategy.Type {
 	case appsv1.RecreateDeploymentStrategyType:
-		return c.recreateDeployment(fleet, rest)
+		replicas, err := c.recreateDeployment(fleet, rest)
+		if err != nil || !scaledDown(rest) {
+			// the active GameServerSet is only scaled up once the non-active GameServerSets are scaled down,
+			// so that GameServers of different templates are never Ready at the same time
+			return 0, err
+		}
+		return replicas, nil
 	case appsv1.RollingUpdateDeploymentStrategyType:
 		return c.rollingUpdateDeployment(fleet, active, rest)
+	case agonesv1.CanaryDeploymentStrategyType:
+		return c.canaryDeployment(fleet, rest)
 	}
 
 	return 0, errors.Errorf("unexpected deployment strategy type: %s", fleet.Spec.Strategy.Type)
 }
 
 // deleteEmptyGameServerSets deletes all GameServerServerSets
-// That have `Status > Replicas` of 0
-func (c *Controller) deleteEmptyGameServerSets(fleet *agonesv1.Fleet, list []*agonesv1.GameServerSet) error {
-	p := metav1.DeletePropagationBackground
+// That have `Status > Replicas` of 0, except for the newest `keep` of them
+func (c *Controller) deleteEmptyGameServerSets(fleet *agonesv1.Fleet, list []*agonesv1.GameServerSet, keep int32) error {
+	var empty []*agonesv1.GameServerSet
 	for _, gsSet := range list {
 		if gsSet.Status.Replicas == 0 && gsSet.Status.ShutdownReplicas == 0 {
-			err := c.gameServerSetGetter.GameServerSets(gsSet.ObjectMeta.Namespace).Delete(gsSet.ObjectMeta.Name, &metav1.DeleteOptions{PropagationPolicy: &p})
-			if err != nil {
-				return errors.Wrapf(err, "error updating gameserverset %s", gsSet.ObjectMeta.Name)
-			}
+			empty = append(empty, gsSet)
+		}
+	}
+	if int(keep) >= len(empty) {
+		return nil
+	}
+	// newest first
+	sort.Slice(empty, func(i, j int) bool {
+		return empty[j].ObjectMeta.CreationTimestamp.Before(&empty[i].ObjectMeta.CreationTimestamp)
+	})
 
-			c.recorder.Eventf(fleet, corev1.EventTypeNormal, "DeletingGameServerSet", "Deleting inactive GameServerSet %s", gsSet.ObjectMeta.Name)
+	p := metav1.DeletePropagationBackground
+	for _, gsSet := range empty[keep:] {
+		err := c.gameServerSetGetter.GameServerSets(gsSet.ObjectMeta.Namespace).Delete(gsSet.ObjectMeta.Name, &metav1.DeleteOptions{PropagationPolicy: &p})
+		if err != nil {
+			return errors.Wrapf(err, "error updating gameserverset %s", gsSet.ObjectMeta.Name)
 		}
+
+		c.recorder.Eventf(fleet, corev1.EventTypeNormal, "DeletingGameServerSet", "Deleting inactive GameServerSet %s", gsSet.ObjectMeta.Name)
 	}
 
 	return nil
@@ -400,6 +944,67 @@ func (c *Controller) recreateDeployment(fleet *agonesv1.Fleet, rest []*agonesv1.
 	return fleet.LowerBoundReplicas(fleet.Spec.Replicas - agonesv1.SumStatusAllocatedReplicas(rest)), nil
 }
 
+// canaryDeployment applies the canary deployment strategy: the stable GameServerSet, which is the oldest non-active
+// GameServerSet that is not scaled down, is given the replicas that the Canary Ratio does not give to the
+// active GameServerSet, and all other non-active GameServerSets are scaled to 0. A Canary Ratio of 100 completes
+// the rollout like the recreate deployment strategy. It returns the replica count for the active GameServerSet
+func (c *Controller) canaryDeployment(fleet *agonesv1.Fleet, rest []*agonesv1.GameServerSet) (int32, error) {
+	if fleet.Spec.Canary.Ratio >= 100 {
+		return c.recreateDeployment(fleet, rest)
+	}
+
+	var stable *agonesv1.GameServerSet
+	for _, gsSet := range rest {
+		if gsSet.Spec.Replicas == 0 {
+			continue
+		}
+		if stable == nil || gsSet.ObjectMeta.CreationTimestamp.Before(&stable.ObjectMeta.CreationTimestamp) {
+			stable = gsSet
+		}
+	}
+
+	replicas := fleet.Spec.Replicas * fleet.Spec.Canary.Ratio / 100
+	if stable == nil {
+		return replicas, nil
+	}
+
+	var others []*agonesv1.GameServerSet
+	for _, gsSet := range rest {
+		if gsSet != stable {
+			others = append(others, gsSet)
+		}
+	}
+	if _, err := c.recreateDeployment(fleet, others); err != nil {
+		return 0, err
+	}
+
+	stableReplicas := fleet.LowerBoundReplicas(fleet.Spec.Replicas - replicas - agonesv1.SumStatusAllocatedReplicas(others))
+	if stableReplicas != stable.Spec.Replicas {
+		c.loggerForFleet(fleet).WithField("gameserverset", stable.ObjectMeta.Name).WithField("replicas", stableReplicas).
+			Info("applying canary deployment to stable gameserverset")
+		gsSetCopy := stable.DeepCopy()
+		gsSetCopy.Spec.Replicas = stableReplicas
+		if _, err := c.gameServerSetGetter.GameServerSets(gsSetCopy.ObjectMeta.Namespace).Update(gsSetCopy); err != nil {
+			return 0, errors.Wrapf(err, "error updating gameserverset %s", gsSetCopy.ObjectMeta.Name)
+		}
+		c.recorder.Eventf(fleet, corev1.EventTypeNormal, "ScalingGameServerSet",
+			"Scaling stable GameServerSet %s from %d to %d", gsSetCopy.ObjectMeta.Name, stable.Spec.Replicas, gsSetCopy.Spec.Replicas)
+	}
+
+	return replicas, nil
+}
+
+// scaledDown returns true once the GameServerSets have no GameServers left other than
+// their Allocated and Reserved ones, which scaling down does not remove
+func scaledDown(list []*agonesv1.GameServerSet) bool {
+	for _, gsSet := range list {
+		if gsSet.Status.Replicas > gsSet.Status.AllocatedReplicas+gsSet.Status.ReservedReplicas {
+			return false
+		}
+	}
+	return true
+}
+
 // rollingUpdateDeployment will do the rolling update of the old GameServers
 // through to the new ones, based on the fleet.Spec.Strategy.RollingUpdate configuration
 // and return the replica count for the active GameServerSet
@@ -470,6 +1075,7 @@ func (c *Controller) rollingUpdateRest(fleet *agonesv1.Fleet, rest []*agonesv1.G
 	}
 	unavailable := int32(r)
 
+	drain := fleet.Spec.Rollout.Mode == agonesv1.FleetRolloutDrainAllocated
 	for _, gsSet := range rest {
 		// if the status.Replicas are less than or equal to 0, then that means we are done
 		// scaling this GameServerSet down, and can therefore exit/move to the next one.
@@ -477,6 +1083,14 @@ func (c *Controller) rollingUpdateRest(fleet *agonesv1.Fleet, rest []*agonesv1.G
 			continue
 		}
 
+		// the Allocated and Reserved GameServers can't be scaled down, so while draining,
+		// only the other GameServers are scaled down, and a GameServerSet that has been
+		// scaled to 0 does not hold up the next one while its Allocated GameServers drain
+		pinned := gsSet.Status.AllocatedReplicas + gsSet.Status.ReservedReplicas
+		if drain && gsSet.Spec.Replicas == 0 && gsSet.Status.Replicas <= pinned {
+			continue
+		}
+
 		// If the Spec.Replicas does not equal the Status.Replicas for this GameServerSet, this means
 		// that the rolling down process is currently ongoing, and we should therefore exit so we can wait for it to finish
 		if gsSet.Spec.Replicas != gsSet.Status.Replicas {
@@ -485,6 +1099,10 @@ func (c *Controller) rollingUpdateRest(fleet *agonesv1.Fleet, rest []*agonesv1.G
 		gsSetCopy := gsSet.DeepCopy()
 		if gsSet.Status.ShutdownReplicas == 0 {
 			gsSetCopy.Spec.Replicas = fleet.LowerBoundReplicas(gsSetCopy.Spec.Replicas - unavailable)
+			if drain && gsSetCopy.Spec.Replicas <= pinned {
+				// scale to 0 once only the pinned GameServers are left, so that they are not replaced when they shut down
+				gsSetCopy.Spec.Replicas = 0
+			}
 
 			c.loggerForFleet(fleet).Info(fmt.Sprintf("Shutdownreplicas %d", gsSet.Status.ShutdownReplicas))
 			c.loggerForFleet(fleet).WithField("gameserverset", gsSet.ObjectMeta.Name).WithField("replicas", gsSetCopy.Spec.Replicas).
@@ -507,7 +1125,7 @@ func (c *Controller) rollingUpdateRest(fleet *agonesv1.Fleet, rest []*agonesv1.G
 // updateFleetStatus gets the GameServerSets for this Fleet and then
 // calculates the counts for the status, and updates the Fleet
 func (c *Controller) updateFleetStatus(fleet *agonesv1.Fleet) error {
-	c.loggerForFleet(fleet).Info("Update Fleet Status")
+	c.statusLog.Debug(c.loggerForFleet(fleet), "Update Fleet Status")
 
 	list, err := ListGameServerSetsByFleetOwner(c.gameServerSetLister, fleet)
 	if err != nil {
@@ -522,17 +1140,73 @@ func (c *Controller) updateFleetStatus(fleet *agonesv1.Fleet) error {
 	fCopy.Status.ReadyReplicas = 0
 	fCopy.Status.ReservedReplicas = 0
 	fCopy.Status.AllocatedReplicas = 0
+	fCopy.Status.ScheduledReplicas = 0
+	fCopy.Status.UnhealthyReplicas = 0
 
 	for _, gsSet := range list {
 		fCopy.Status.Replicas += gsSet.Status.Replicas
 		fCopy.Status.ReadyReplicas += gsSet.Status.ReadyReplicas
 		fCopy.Status.ReservedReplicas += gsSet.Status.ReservedReplicas
 		fCopy.Status.AllocatedReplicas += gsSet.Status.AllocatedReplicas
+		fCopy.Status.ScheduledReplicas += gsSet.Status.ScheduledReplicas
+		fCopy.Status.UnhealthyReplicas += gsSet.Status.UnhealthyReplicas
+	}
+	fCopy.Status.AllocationOverflowReplicas = 0
+	if overflow := fCopy.Status.AllocatedReplicas - fleet.Spec.Replicas; overflow > 0 {
+		fCopy.Status.AllocationOverflowReplicas = overflow
 	}
+	fCopy.Status.Rollout = rolloutStatus(fleet, fCopy.Status.Rollout, list)
+	if condition, ok := rolloutFailedCondition(fCopy.Status, list); ok {
+		fCopy.Status.SetCondition(condition)
+	}
+	waiting, err := c.waitingDependencies(fleet, list)
+	if err != nil {
+		return err
+	}
+	if condition, ok := rolloutWaitingCondition(fCopy.Status, waiting); ok {
+		fCopy.Status.SetCondition(condition)
+	}
+	fCopy.Status.LabelSelector = fleet.LabelSelector()
 	_, err = c.fleetGetter.Fleets(fCopy.ObjectMeta.Namespace).UpdateStatus(fCopy)
 	return errors.Wrapf(err, "error updating status of fleet %s", fCopy.ObjectMeta.Name)
 }
 
+// rolloutIncludes returns true if all the outdated GameServerSets are part of the rollout status
+func rolloutIncludes(status *agonesv1.FleetRolloutStatus, outdated []*agonesv1.GameServerSet) bool {
+	names := make(map[string]bool, len(status.OutdatedGameServerSets))
+	for _, name := range status.OutdatedGameServerSets {
+		names[name] = true
+	}
+	for _, gsSet := range outdated {
+		if !names[gsSet.ObjectMeta.Name] {
+			return false
+		}
+	}
+	return true
+}
+
+// rolloutStatus returns the progress of the rolling update of the Fleet, or nil if none of its GameServerSets are outdated.
+// The start time is kept from current, unless a GameServerSet has become outdated since, which starts a new rollout.
+func rolloutStatus(fleet *agonesv1.Fleet, current *agonesv1.FleetRolloutStatus, list []*agonesv1.GameServerSet) *agonesv1.FleetRolloutStatus {
+	outdated := outdatedGameServerSets(fleet, list)
+	if len(outdated) == 0 {
+		return nil
+	}
+
+	status := &agonesv1.FleetRolloutStatus{StartTime: metav1.Now()}
+	if current != nil && rolloutIncludes(current, outdated) {
+		status.StartTime = current.StartTime
+	}
+	for _, gsSet := range outdated {
+		status.OutdatedGameServerSets = append(status.OutdatedGameServerSets, gsSet.ObjectMeta.Name)
+	}
+	status.OutdatedReplicas = agonesv1.SumStatusReplicas(outdated)
+	status.OutdatedAllocatedReplicas = agonesv1.SumStatusAllocatedReplicas(outdated)
+	status.UpdatedReplicas = agonesv1.SumStatusReplicas(list) - status.OutdatedReplicas
+	status.DrainDeadline = fleet.RolloutDrainDeadline(status.StartTime)
+	return status
+}
+
 // filterGameServerSetByActive returns the active GameServerSet (or nil if it
 // doesn't exist) and then the rest of the GameServerSets that are controlled
 // by this Fleet
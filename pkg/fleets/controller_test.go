@@ -17,12 +17,15 @@ package fleets
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"testing"
 	"time"
 
 	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/cloudproduct"
 	agtesting "agones.dev/agones/pkg/testing"
 	"agones.dev/agones/pkg/util/webhooks"
 	"github.com/heptiolabs/healthcheck"
@@ -31,6 +34,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	admv1beta1 "k8s.io/api/admission/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/intstr"
@@ -58,6 +63,7 @@ func TestControllerSyncFleet(t *testing.T) {
 			created = true
 			assert.True(t, metav1.IsControlledBy(gsSet, f))
 			assert.Equal(t, f.Spec.Replicas, gsSet.Spec.Replicas)
+			assert.Equal(t, "1", gsSet.ObjectMeta.Annotations[agonesv1.FleetRevisionAnnotation])
 
 			return true, gsSet, nil
 		})
@@ -242,6 +248,410 @@ func TestControllerSyncFleet(t *testing.T) {
 		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "ScalingGameServerSet")
 		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "CreatingGameServerSet")
 	})
+
+	t.Run("fleet with templates, create a gameserverset per template", func(t *testing.T) {
+		f := defaultFixture()
+		f.Spec.Replicas = 10
+		large := agonesv1.GameServerTemplateSpec{}
+		large.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 7777}}
+		f.Spec.Templates = []agonesv1.FleetTemplate{{Name: "large", Ratio: 30, Template: large}}
+		c, m := newFakeController()
+
+		created := map[string]*agonesv1.GameServerSet{}
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f}}, nil
+		})
+
+		m.AgonesClient.AddReactor("create", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			ca := action.(k8stesting.CreateAction)
+			gsSet := ca.GetObject().(*agonesv1.GameServerSet)
+			assert.True(t, metav1.IsControlledBy(gsSet, f))
+			created[gsSet.ObjectMeta.Labels[agonesv1.FleetTemplateLabel]] = gsSet
+
+			return true, gsSet, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetSynced)
+		defer cancel()
+
+		err := c.syncFleet("default/fleet-1")
+		assert.Nil(t, err)
+		assert.Len(t, created, 2)
+		if gsSet, ok := created[""]; assert.True(t, ok, "gameserverset for the template should have been created") {
+			assert.Equal(t, int32(7), gsSet.Spec.Replicas)
+			assert.Equal(t, "fleet-1-", gsSet.ObjectMeta.GenerateName)
+			assert.Equal(t, f.Spec.Template, gsSet.Spec.Template)
+		}
+		if gsSet, ok := created["large"]; assert.True(t, ok, "gameserverset for the large template should have been created") {
+			assert.Equal(t, int32(3), gsSet.Spec.Replicas)
+			assert.Equal(t, "fleet-1-large-", gsSet.ObjectMeta.GenerateName)
+			assert.Equal(t, large, gsSet.Spec.Template)
+			assert.Equal(t, f.ObjectMeta.Name, gsSet.ObjectMeta.Labels[agonesv1.FleetNameLabel])
+		}
+	})
+
+	for name, tc := range map[string]struct {
+		strategy appsv1.DeploymentStrategyType
+		expected int32
+	}{
+		"gameserverset of a removed template is scaled down with recreate": {
+			strategy: appsv1.RecreateDeploymentStrategyType,
+			expected: 0,
+		},
+		// 25% of the 5 Fleet replicas, rounded up, are allowed to be unavailable
+		"gameserverset of a removed template is rolled down with rolling update": {
+			strategy: appsv1.RollingUpdateDeploymentStrategyType,
+			expected: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := defaultFixture()
+			f.Spec.Strategy.Type = tc.strategy
+			c, m := newFakeController()
+			gsSet := f.GameServerSet()
+			gsSet.ObjectMeta.Name = "gsSet1"
+			gsSet.ObjectMeta.UID = "4321"
+			gsSet.Spec.Replicas = f.Spec.Replicas
+
+			removed := f.GameServerSet()
+			removed.ObjectMeta.Name = "gsSet2"
+			removed.ObjectMeta.UID = "5678"
+			removed.ObjectMeta.Labels[agonesv1.FleetTemplateLabel] = "large"
+			removed.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 7777}}
+			removed.Spec.Replicas = 3
+			removed.Status.Replicas = 3
+			updated := false
+
+			m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f}}, nil
+			})
+
+			m.AgonesClient.AddReactor("list", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &agonesv1.GameServerSetList{Items: []agonesv1.GameServerSet{*gsSet, *removed}}, nil
+			})
+
+			m.AgonesClient.AddReactor("create", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				assert.FailNow(t, "gameserverset should not be created")
+				return true, nil, nil
+			})
+
+			m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updated = true
+				ua := action.(k8stesting.UpdateAction)
+				gsSet := ua.GetObject().(*agonesv1.GameServerSet)
+				assert.Equal(t, "gsSet2", gsSet.ObjectMeta.Name)
+				assert.Equal(t, tc.expected, gsSet.Spec.Replicas)
+
+				return true, gsSet, nil
+			})
+
+			_, cancel := agtesting.StartInformers(m, c.fleetSynced, c.gameServerSetSynced)
+			defer cancel()
+
+			err := c.syncFleet("default/fleet-1")
+			assert.Nil(t, err)
+			assert.True(t, updated, "gameserverset should have been updated")
+			agtesting.AssertEventContains(t, m.FakeRecorder.Events, "ScalingGameServerSet")
+		})
+	}
+
+	t.Run("paused fleet does not roll out a changed template", func(t *testing.T) {
+		f := defaultFixture()
+		f.Spec.Paused = true
+		c, m := newFakeController()
+		gsSet := f.GameServerSet()
+		gsSet.ObjectMeta.Name = "gsSet1"
+		gsSet.ObjectMeta.UID = "4321"
+		gsSet.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 7777}}
+		gsSet.Spec.Replicas = f.Spec.Replicas
+		gsSet.Status.Replicas = f.Spec.Replicas
+
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f}}, nil
+		})
+		m.AgonesClient.AddReactor("list", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.GameServerSetList{Items: []agonesv1.GameServerSet{*gsSet}}, nil
+		})
+		m.AgonesClient.AddReactor("create", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "gameserverset should not be created")
+			return true, nil, nil
+		})
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "gameserverset should not have been updated")
+			return true, nil, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetSynced, c.gameServerSetSynced)
+		defer cancel()
+
+		err := c.syncFleet("default/fleet-1")
+		assert.Nil(t, err)
+		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+	})
+
+	t.Run("paused fleet scales the gameserversets of its rollout", func(t *testing.T) {
+		f := defaultFixture()
+		f.Spec.Paused = true
+		f.Spec.Replicas = 20
+		c, m := newFakeController()
+		active := f.GameServerSet()
+		active.ObjectMeta.Name = "gsSet1"
+		active.ObjectMeta.UID = "1234"
+		active.Spec.Replicas = 3
+		old := f.GameServerSet()
+		old.ObjectMeta.Name = "gsSet2"
+		old.ObjectMeta.UID = "4321"
+		old.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 7777}}
+		old.Spec.Replicas = 7
+
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f}}, nil
+		})
+		m.AgonesClient.AddReactor("list", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.GameServerSetList{Items: []agonesv1.GameServerSet{*active, *old}}, nil
+		})
+		m.AgonesClient.AddReactor("create", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "gameserverset should not be created")
+			return true, nil, nil
+		})
+		updated := map[string]int32{}
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			gsSet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServerSet)
+			updated[gsSet.ObjectMeta.Name] = gsSet.Spec.Replicas
+			return true, gsSet, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetSynced, c.gameServerSetSynced)
+		defer cancel()
+
+		err := c.syncFleet("default/fleet-1")
+		assert.Nil(t, err)
+		assert.Equal(t, map[string]int32{"gsSet1": 6, "gsSet2": 14}, updated)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "ScalingGameServerSet")
+	})
+}
+
+func TestControllerRollback(t *testing.T) {
+	t.Parallel()
+
+	fixture := func(rollbackTo string) (*agonesv1.Fleet, []*agonesv1.GameServerSet) {
+		f := defaultFixture()
+		f.ObjectMeta.Annotations[agonesv1.FleetRollbackAnnotation] = rollbackTo
+		f.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 3}}
+
+		var list []*agonesv1.GameServerSet
+		for i := 1; i <= 3; i++ {
+			gsSet := f.GameServerSet()
+			gsSet.ObjectMeta.Name = fmt.Sprintf("gsSet%d", i)
+			gsSet.ObjectMeta.Annotations = map[string]string{agonesv1.FleetRevisionAnnotation: strconv.Itoa(i)}
+			gsSet.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: int32(i)}}
+			list = append(list, gsSet)
+		}
+		return f, list
+	}
+
+	for name, tc := range map[string]struct {
+		rollbackTo string
+		hostPort   int32
+	}{
+		"to a revision":            {rollbackTo: "1", hostPort: 1},
+		"to the previous revision": {rollbackTo: "0", hostPort: 2},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, list := fixture(tc.rollbackTo)
+			c, m := newFakeController()
+
+			updated := false
+			m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updated = true
+				fleet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.Fleet)
+				assert.NotContains(t, fleet.ObjectMeta.Annotations, agonesv1.FleetRollbackAnnotation)
+				assert.Equal(t, tc.hostPort, fleet.Spec.Template.Spec.Ports[0].HostPort)
+				return true, fleet, nil
+			})
+
+			err := c.rollback(f, list)
+			assert.NoError(t, err)
+			assert.True(t, updated, "fleet should have been updated")
+			agtesting.AssertEventContains(t, m.FakeRecorder.Events, "RollingBack")
+		})
+	}
+
+	t.Run("revision not found", func(t *testing.T) {
+		f, list := fixture("5")
+		c, m := newFakeController()
+
+		updated := false
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			fleet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.Fleet)
+			assert.NotContains(t, fleet.ObjectMeta.Annotations, agonesv1.FleetRollbackAnnotation)
+			assert.Equal(t, int32(3), fleet.Spec.Template.Spec.Ports[0].HostPort)
+			return true, fleet, nil
+		})
+
+		err := c.rollback(f, list)
+		assert.NoError(t, err)
+		assert.True(t, updated, "fleet should have been updated")
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "RollbackRevisionNotFound")
+	})
+}
+
+func TestControllerStopFailingRollout(t *testing.T) {
+	t.Parallel()
+
+	// the fleet is rolling out from revision 1 to revision 2, whose GameServerSet has the failures
+	fixture := func(action agonesv1.FleetRolloutFailureAction, failed int32) (*agonesv1.Fleet, []*agonesv1.GameServerSet) {
+		f := defaultFixture()
+		f.Spec.Rollout.FailureThreshold = &agonesv1.FleetRolloutFailureThreshold{Percent: 50, MinFailures: 2, Action: action}
+		f.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 2}}
+
+		previous := f.GameServerSet()
+		previous.ObjectMeta.Name = "gsSet1"
+		previous.ObjectMeta.Annotations = map[string]string{agonesv1.FleetRevisionAnnotation: "1"}
+		previous.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 1}}
+		previous.Spec.Replicas = 3
+		previous.Status.Replicas = 3
+
+		current := f.GameServerSet()
+		current.ObjectMeta.Name = "gsSet2"
+		current.ObjectMeta.Annotations = map[string]string{agonesv1.FleetRevisionAnnotation: "2"}
+		current.Spec.Replicas = 2
+		current.Status.Replicas = 2
+		current.Status.FailedReplicas = failed
+
+		return f, []*agonesv1.GameServerSet{previous, current}
+	}
+
+	run := func(t *testing.T, f *agonesv1.Fleet, list []*agonesv1.GameServerSet) (bool, *agonesv1.Fleet, *agonesv1.GameServerSet, agtesting.Mocks) {
+		c, m := newFakeController()
+		var updatedFleet *agonesv1.Fleet
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updatedFleet = action.(k8stesting.UpdateAction).GetObject().(*agonesv1.Fleet)
+			return true, updatedFleet, nil
+		})
+		var updatedGsSet *agonesv1.GameServerSet
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updatedGsSet = action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServerSet)
+			return true, updatedGsSet, nil
+		})
+
+		stopped, err := c.stopFailingRollout(f, list)
+		assert.NoError(t, err)
+		return stopped, updatedFleet, updatedGsSet, m
+	}
+
+	t.Run("pause", func(t *testing.T) {
+		f, list := fixture(agonesv1.FleetRolloutFailurePause, 2)
+		stopped, fleet, gsSet, m := run(t, f, list)
+		assert.True(t, stopped)
+		if assert.NotNil(t, fleet) {
+			assert.True(t, fleet.Spec.Paused)
+			assert.NotContains(t, fleet.ObjectMeta.Annotations, agonesv1.FleetRollbackAnnotation)
+		}
+		if assert.NotNil(t, gsSet) {
+			assert.Equal(t, "gsSet2", gsSet.ObjectMeta.Name)
+			assert.Equal(t, string(agonesv1.FleetRolloutFailurePause), gsSet.ObjectMeta.Annotations[agonesv1.FleetRolloutFailedAnnotation])
+		}
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "RolloutFailed Pausing the rollout, as 2 GameServers of GameServerSet gsSet2 failed")
+	})
+
+	t.Run("abort", func(t *testing.T) {
+		f, list := fixture(agonesv1.FleetRolloutFailureAbort, 3)
+		stopped, fleet, gsSet, m := run(t, f, list)
+		assert.True(t, stopped)
+		if assert.NotNil(t, fleet) {
+			assert.False(t, fleet.Spec.Paused)
+			assert.Equal(t, "0", fleet.ObjectMeta.Annotations[agonesv1.FleetRollbackAnnotation])
+		}
+		if assert.NotNil(t, gsSet) {
+			assert.Equal(t, string(agonesv1.FleetRolloutFailureAbort), gsSet.ObjectMeta.Annotations[agonesv1.FleetRolloutFailedAnnotation])
+		}
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "RolloutFailed Rolling back to the previous revision")
+	})
+
+	t.Run("abort without a previous revision", func(t *testing.T) {
+		f, list := fixture(agonesv1.FleetRolloutFailureAbort, 3)
+		delete(list[0].ObjectMeta.Annotations, agonesv1.FleetRevisionAnnotation)
+		stopped, fleet, gsSet, _ := run(t, f, list)
+		assert.True(t, stopped)
+		if assert.NotNil(t, fleet) {
+			assert.True(t, fleet.Spec.Paused)
+		}
+		if assert.NotNil(t, gsSet) {
+			assert.Equal(t, string(agonesv1.FleetRolloutFailurePause), gsSet.ObjectMeta.Annotations[agonesv1.FleetRolloutFailedAnnotation])
+		}
+	})
+
+	t.Run("already stopped", func(t *testing.T) {
+		f, list := fixture(agonesv1.FleetRolloutFailurePause, 2)
+		f.Spec.Paused = true
+		stopped, fleet, gsSet, _ := run(t, f, list)
+		assert.True(t, stopped)
+		assert.Nil(t, fleet, "the paused fleet should not be updated")
+		assert.NotNil(t, gsSet, "the gameserverset should be marked")
+
+		list[1].ObjectMeta.Annotations[agonesv1.FleetRolloutFailedAnnotation] = string(agonesv1.FleetRolloutFailurePause)
+		stopped, fleet, gsSet, _ = run(t, f, list)
+		assert.False(t, stopped)
+		assert.Nil(t, fleet)
+		assert.Nil(t, gsSet)
+	})
+
+	for name, tc := range map[string]func(f *agonesv1.Fleet, list []*agonesv1.GameServerSet){
+		"within the threshold": func(f *agonesv1.Fleet, list []*agonesv1.GameServerSet) {
+			list[1].Status.FailedReplicas = 1
+		},
+		"no threshold": func(f *agonesv1.Fleet, list []*agonesv1.GameServerSet) {
+			f.Spec.Rollout.FailureThreshold = nil
+		},
+		"no rollout": func(f *agonesv1.Fleet, list []*agonesv1.GameServerSet) {
+			list[0].Spec.Replicas = 0
+			list[0].Status.Replicas = 0
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, list := fixture(agonesv1.FleetRolloutFailurePause, 2)
+			tc(f, list)
+			stopped, fleet, gsSet, _ := run(t, f, list)
+			assert.False(t, stopped)
+			assert.Nil(t, fleet)
+			assert.Nil(t, gsSet)
+		})
+	}
+}
+
+func TestControllerRolloutFailedCondition(t *testing.T) {
+	t.Parallel()
+
+	f := defaultFixture()
+	gsSet1 := f.GameServerSet()
+	gsSet1.ObjectMeta.Name = "gsSet1"
+	gsSet1.ObjectMeta.Annotations = map[string]string{agonesv1.FleetRevisionAnnotation: "1"}
+	gsSet2 := f.GameServerSet()
+	gsSet2.ObjectMeta.Name = "gsSet2"
+	gsSet2.ObjectMeta.Annotations = map[string]string{agonesv1.FleetRevisionAnnotation: "2"}
+	list := []*agonesv1.GameServerSet{gsSet1, gsSet2}
+
+	_, ok := rolloutFailedCondition(agonesv1.FleetStatus{}, list)
+	assert.False(t, ok, "a fleet that never stopped a rollout should not have the condition")
+
+	gsSet2.ObjectMeta.Annotations[agonesv1.FleetRolloutFailedAnnotation] = string(agonesv1.FleetRolloutFailureAbort)
+	condition, ok := rolloutFailedCondition(agonesv1.FleetStatus{}, list)
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Equal(t, "RolloutAborted", condition.Reason)
+	assert.Contains(t, condition.Message, "gsSet2")
+
+	// a newer revision was rolled out since
+	status := agonesv1.FleetStatus{Conditions: []agonesv1.FleetCondition{condition}}
+	gsSet3 := f.GameServerSet()
+	gsSet3.ObjectMeta.Name = "gsSet3"
+	gsSet3.ObjectMeta.Annotations = map[string]string{agonesv1.FleetRevisionAnnotation: "3"}
+	condition, ok = rolloutFailedCondition(status, append(list, gsSet3))
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, "WithinFailureThreshold", condition.Reason)
 }
 
 func TestControllerCreationMutationHandler(t *testing.T) {
@@ -286,6 +696,105 @@ func TestControllerCreationMutationHandler(t *testing.T) {
 	}
 
 	assertContains(patch, jsonpatch.JsonPatchOperation{Operation: "add", Path: "/spec/strategy/type", Value: "RollingUpdate"})
+	for _, p := range *patch {
+		if p.Path == "/metadata/annotations" {
+			assert.NotContains(t, p.Value, agonesv1.FleetDryRunTemplateAnnotation)
+		}
+	}
+
+	t.Run("dry run", func(t *testing.T) {
+		fixture := agonesv1.Fleet{ObjectMeta: metav1.ObjectMeta{Name: "fleet", Namespace: "default"},
+			Spec: agonesv1.FleetSpec{Template: agonesv1.GameServerTemplateSpec{
+				Spec: agonesv1.GameServerSpec{Ports: []agonesv1.GameServerPort{{ContainerPort: 7777}},
+					Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "container", Image: "myimage"}}}}},
+			}}}
+		raw, err := json.Marshal(fixture)
+		assert.Nil(t, err)
+		dryRun := true
+		review := admv1beta1.AdmissionReview{
+			Request: &admv1beta1.AdmissionRequest{
+				Kind:      gvk,
+				Operation: admv1beta1.Create,
+				DryRun:    &dryRun,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+			Response: &admv1beta1.AdmissionResponse{Allowed: true},
+		}
+
+		result, err := c.creationMutationHandler(review)
+		assert.Nil(t, err)
+
+		patch := &jsonpatch.ByPath{}
+		assert.Nil(t, json.Unmarshal(result.Response.Patch, patch))
+		var annotation string
+		for _, p := range *patch {
+			if p.Path == "/metadata/annotations" {
+				annotation = p.Value.(map[string]interface{})[agonesv1.FleetDryRunTemplateAnnotation].(string)
+			}
+		}
+
+		template := agonesv1.GameServerTemplateSpec{}
+		assert.Nil(t, json.Unmarshal([]byte(annotation), &template))
+		assert.Equal(t, "fleet-dry-run-", template.ObjectMeta.GenerateName)
+		assert.Equal(t, "fleet", template.ObjectMeta.Labels[agonesv1.FleetNameLabel])
+		assert.Equal(t, "fleet-dry-run", template.ObjectMeta.Labels[agonesv1.GameServerSetGameServerLabel])
+		assert.Equal(t, "container", template.Spec.Container)
+		assert.Equal(t, agonesv1.Dynamic, template.Spec.Ports[0].PortPolicy)
+		assert.Equal(t, apis.Packed, template.Spec.Scheduling)
+		assert.Equal(t, agonesv1.SdkServerLogLevelInfo, template.Spec.SdkServer.LogLevel)
+	})
+}
+
+func TestControllerCreationValidationHandler(t *testing.T) {
+	t.Parallel()
+
+	gvk := metav1.GroupVersionKind(agonesv1.SchemeGroupVersion.WithKind("Fleet"))
+	review := func(policy agonesv1.PortPolicy) admv1beta1.AdmissionReview {
+		fixture := agonesv1.Fleet{ObjectMeta: metav1.ObjectMeta{Name: "fleet", Namespace: "default"},
+			Spec: agonesv1.FleetSpec{Template: agonesv1.GameServerTemplateSpec{
+				Spec: agonesv1.GameServerSpec{Ports: []agonesv1.GameServerPort{{Name: "gameport", ContainerPort: 7777, PortPolicy: policy}},
+					Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+						Containers: []corev1.Container{{Name: "container", Image: "myimage"}}}}},
+			}}}
+		if policy == agonesv1.Static {
+			fixture.Spec.Template.Spec.Ports[0].HostPort = 7777
+		}
+		fixture.ApplyDefaults()
+		raw, err := json.Marshal(fixture)
+		assert.Nil(t, err)
+		return admv1beta1.AdmissionReview{
+			Request: &admv1beta1.AdmissionRequest{
+				Kind:      gvk,
+				Operation: admv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+			Response: &admv1beta1.AdmissionResponse{Allowed: true},
+		}
+	}
+
+	t.Run("valid fleet", func(t *testing.T) {
+		c, _ := newFakeController()
+		result, err := c.creationValidationHandler(review(agonesv1.Static))
+		assert.Nil(t, err)
+		assert.True(t, result.Response.Allowed)
+	})
+
+	t.Run("fleet not supported by the cloud product", func(t *testing.T) {
+		c, m := newFakeController()
+		c.cloudProduct, _ = cloudproduct.New(cloudproduct.GKEAutopilot, m.KubeClient)
+
+		result, err := c.creationValidationHandler(review(agonesv1.Static))
+		assert.Nil(t, err)
+		assert.False(t, result.Response.Allowed)
+		assert.Equal(t, metav1.StatusReasonInvalid, result.Response.Result.Reason)
+		assert.Len(t, result.Response.Result.Details.Causes, 1)
+		assert.Equal(t, "gameport.portPolicy", result.Response.Result.Details.Causes[0].Field)
+
+		result, err = c.creationValidationHandler(review(agonesv1.Dynamic))
+		assert.Nil(t, err)
+		assert.True(t, result.Response.Allowed)
+	})
 }
 
 func TestControllerRun(t *testing.T) {
@@ -306,6 +815,9 @@ func TestControllerRun(t *testing.T) {
 	gsSetWatch := watch.NewFake()
 	m.AgonesClient.AddWatchReactor("gameserversets", k8stesting.DefaultWatchReactor(gsSetWatch, nil))
 
+	serviceWatch := watch.NewFake()
+	m.KubeClient.AddWatchReactor("services", k8stesting.DefaultWatchReactor(serviceWatch, nil))
+
 	c.workerqueue.SyncHandler = func(name string) error {
 		received <- name
 		return nil
@@ -352,6 +864,13 @@ func TestControllerRun(t *testing.T) {
 	gsSet.Spec.Replicas += 10
 	gsSetWatch.Modify(gsSet)
 	assert.Equal(t, expected, f())
+
+	// test deletion of the metrics service
+	fCopy.Spec.Metrics = &agonesv1.FleetMetrics{Port: 9090}
+	svc := fCopy.MetricsService()
+	serviceWatch.Add(svc)
+	serviceWatch.Delete(svc)
+	assert.Equal(t, expected, f())
 }
 
 func TestControllerUpdateFleetStatus(t *testing.T) {
@@ -366,6 +885,8 @@ func TestControllerUpdateFleetStatus(t *testing.T) {
 	gsSet1.Status.ReadyReplicas = 2
 	gsSet1.Status.ReservedReplicas = 4
 	gsSet1.Status.AllocatedReplicas = 1
+	gsSet1.Status.ScheduledReplicas = 2
+	gsSet1.Status.UnhealthyReplicas = 1
 
 	gsSet2 := fleet.GameServerSet()
 	// nolint:goconst
@@ -374,6 +895,7 @@ func TestControllerUpdateFleetStatus(t *testing.T) {
 	gsSet2.Status.ReadyReplicas = 5
 	gsSet2.Status.ReservedReplicas = 3
 	gsSet2.Status.AllocatedReplicas = 2
+	gsSet2.Status.UnhealthyReplicas = 3
 
 	m.AgonesClient.AddReactor("list", "gameserversets",
 		func(action k8stesting.Action) (bool, runtime.Object, error) {
@@ -391,6 +913,44 @@ func TestControllerUpdateFleetStatus(t *testing.T) {
 			assert.Equal(t, gsSet1.Status.ReadyReplicas+gsSet2.Status.ReadyReplicas, fleet.Status.ReadyReplicas)
 			assert.Equal(t, gsSet1.Status.ReservedReplicas+gsSet2.Status.ReservedReplicas, fleet.Status.ReservedReplicas)
 			assert.Equal(t, gsSet1.Status.AllocatedReplicas+gsSet2.Status.AllocatedReplicas, fleet.Status.AllocatedReplicas)
+			assert.Equal(t, gsSet1.Status.ScheduledReplicas+gsSet2.Status.ScheduledReplicas, fleet.Status.ScheduledReplicas)
+			assert.Equal(t, gsSet1.Status.UnhealthyReplicas+gsSet2.Status.UnhealthyReplicas, fleet.Status.UnhealthyReplicas)
+			assert.Equal(t, agonesv1.FleetNameLabel+"=fleet-1", fleet.Status.LabelSelector)
+			assert.Equal(t, int32(0), fleet.Status.AllocationOverflowReplicas)
+			return true, fleet, nil
+		})
+
+	_, cancel := agtesting.StartInformers(m, c.fleetSynced, c.gameServerSetSynced)
+	defer cancel()
+
+	err := c.updateFleetStatus(fleet)
+	assert.Nil(t, err)
+	assert.True(t, updated)
+}
+
+func TestControllerUpdateFleetStatusAllocationOverflow(t *testing.T) {
+	t.Parallel()
+
+	fleet := defaultFixture()
+	fleet.Spec.Replicas = 2
+	c, m := newFakeController()
+
+	gsSet := fleet.GameServerSet()
+	gsSet.ObjectMeta.Name = "gsSet1"
+	gsSet.Status.Replicas = 5
+	gsSet.Status.AllocatedReplicas = 5
+
+	m.AgonesClient.AddReactor("list", "gameserversets",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.GameServerSetList{Items: []agonesv1.GameServerSet{*gsSet}}, nil
+		})
+
+	updated := false
+	m.AgonesClient.AddReactor("update", "fleets",
+		func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			fleet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.Fleet)
+			assert.Equal(t, int32(3), fleet.Status.AllocationOverflowReplicas)
 			return true, fleet, nil
 		})
 
@@ -427,6 +987,31 @@ func TestControllerFilterGameServerSetByActive(t *testing.T) {
 	assert.Equal(t, []*agonesv1.GameServerSet{gsSet1, gsSet2}, rest)
 }
 
+func TestProportionalReplicas(t *testing.T) {
+	t.Parallel()
+
+	for name, tc := range map[string]struct {
+		replicas int32
+		current  []int32
+		expected []int32
+	}{
+		"scale up":                  {replicas: 20, current: []int32{3, 7}, expected: []int32{6, 14}},
+		"scale down":                {replicas: 5, current: []int32{3, 7}, expected: []int32{1, 4}},
+		"remainder to the largest":  {replicas: 10, current: []int32{1, 1, 2}, expected: []int32{2, 2, 6}},
+		"no replicas goes to first": {replicas: 4, current: []int32{0, 0}, expected: []int32{4, 0}},
+		"scale to zero":             {replicas: 0, current: []int32{3, 7}, expected: []int32{0, 0}},
+		"no gameserversets":         {replicas: 4, current: nil, expected: []int32{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var list []*agonesv1.GameServerSet
+			for _, r := range tc.current {
+				list = append(list, &agonesv1.GameServerSet{Spec: agonesv1.GameServerSetSpec{Replicas: r}})
+			}
+			assert.Equal(t, tc.expected, proportionalReplicas(tc.replicas, list))
+		})
+	}
+}
+
 func TestControllerRecreateDeployment(t *testing.T) {
 	t.Parallel()
 
@@ -461,6 +1046,83 @@ func TestControllerRecreateDeployment(t *testing.T) {
 	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "ScalingGameServerSet")
 }
 
+func TestControllerCanaryDeployment(t *testing.T) {
+	t.Parallel()
+
+	fixture := func(ratio int32) (*agonesv1.Fleet, *agonesv1.GameServerSet, *agonesv1.GameServerSet) {
+		f := defaultFixture()
+		f.Spec.Strategy.Type = agonesv1.CanaryDeploymentStrategyType
+		f.Spec.Canary.Ratio = ratio
+		f.Spec.Replicas = 10
+
+		now := metav1.Now()
+		stable := f.GameServerSet()
+		stable.ObjectMeta.Name = "stable"
+		stable.ObjectMeta.CreationTimestamp = metav1.NewTime(now.Add(-time.Hour))
+		stable.Spec.Replicas = 10
+		canary := f.GameServerSet()
+		canary.ObjectMeta.Name = "canary"
+		canary.ObjectMeta.CreationTimestamp = now
+		canary.Spec.Replicas = 2
+		canary.Status.AllocatedReplicas = 1
+		return f, stable, canary
+	}
+
+	t.Run("holds the stable gameserverset at the ratio", func(t *testing.T) {
+		f, stable, previous := fixture(20)
+		c, m := newFakeController()
+
+		updates := map[string]int32{}
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			gsSet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServerSet)
+			updates[gsSet.ObjectMeta.Name] = gsSet.Spec.Replicas
+			return true, gsSet, nil
+		})
+
+		replicas, err := c.canaryDeployment(f, []*agonesv1.GameServerSet{previous, stable})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), replicas)
+		// the previous canary is scaled down, and its allocated gameserver is left out of the stable replicas
+		assert.Equal(t, map[string]int32{"canary": 0, "stable": 7}, updates)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Scaling inactive GameServerSet canary from 2 to 0")
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Scaling stable GameServerSet stable from 10 to 7")
+	})
+
+	t.Run("stable gameserverset at the ratio", func(t *testing.T) {
+		f, stable, _ := fixture(20)
+		stable.Spec.Replicas = 8
+		c, m := newFakeController()
+
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "should not update")
+			return false, nil, nil
+		})
+
+		replicas, err := c.canaryDeployment(f, []*agonesv1.GameServerSet{stable})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(2), replicas)
+	})
+
+	t.Run("ratio of 100 completes the rollout", func(t *testing.T) {
+		f, stable, _ := fixture(100)
+		stable.Status.AllocatedReplicas = 3
+		c, m := newFakeController()
+
+		updated := false
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			gsSet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServerSet)
+			assert.Equal(t, int32(0), gsSet.Spec.Replicas)
+			return true, gsSet, nil
+		})
+
+		replicas, err := c.canaryDeployment(f, []*agonesv1.GameServerSet{stable})
+		assert.NoError(t, err)
+		assert.True(t, updated)
+		assert.Equal(t, int32(7), replicas)
+	})
+}
+
 func TestControllerApplyDeploymentStrategy(t *testing.T) {
 	t.Parallel()
 
@@ -484,13 +1146,13 @@ func TestControllerApplyDeploymentStrategy(t *testing.T) {
 				replicas:         10,
 			},
 		},
-		string(appsv1.RollingUpdateDeploymentStrategyType): {
+		"Recreate, waiting for the inactive gameserversets to scale down": {
 			strategyType:         appsv1.RecreateDeploymentStrategyType,
 			gsSet1StatusReplicas: 10,
 			gsSet2StatusReplicas: 1,
 			expected: expected{
-				inactiveReplicas: 7,
-				replicas:         2,
+				inactiveReplicas: 0,
+				replicas:         0,
 			},
 		},
 	}
@@ -527,7 +1189,7 @@ func TestControllerApplyDeploymentStrategy(t *testing.T) {
 			replicas, err := c.applyDeploymentStrategy(f, f.GameServerSet(), []*agonesv1.GameServerSet{gsSet1, gsSet2})
 			assert.Nil(t, err)
 			assert.True(t, updated, "update should happen")
-			assert.Equal(t, f.Spec.Replicas, replicas)
+			assert.Equal(t, v.expected.replicas, replicas)
 		})
 	}
 
@@ -594,6 +1256,28 @@ func TestControllerUpsertGameServerSet(t *testing.T) {
 		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "ScalingGameServerSet")
 	})
 
+	t.Run("update allocation overflow", func(t *testing.T) {
+		c, m := newFakeController()
+		f := f.DeepCopy()
+		f.Spec.AllocationOverflow = &agonesv1.AllocationOverflow{Labels: map[string]string{"stale": "true"}}
+		gsSet := f.GameServerSet()
+		gsSet.ObjectMeta.UID = "1234"
+		gsSet.Spec.Replicas = replicas
+		gsSet.Spec.AllocationOverflow = nil
+		update := false
+
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			update = true
+			gsSet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServerSet)
+			assert.Equal(t, f.Spec.AllocationOverflow, gsSet.Spec.AllocationOverflow)
+			return true, gsSet, nil
+		})
+
+		err := c.upsertGameServerSet(f, gsSet, replicas)
+		assert.Nil(t, err)
+		assert.True(t, update, "Should be update")
+	})
+
 	t.Run("noop", func(t *testing.T) {
 		t.Parallel()
 
@@ -640,11 +1324,36 @@ func TestControllerDeleteEmptyGameServerSets(t *testing.T) {
 		return true, nil, nil
 	})
 
-	err := c.deleteEmptyGameServerSets(f, []*agonesv1.GameServerSet{gsSet1, gsSet2})
+	err := c.deleteEmptyGameServerSets(f, []*agonesv1.GameServerSet{gsSet1, gsSet2}, 0)
 	assert.Nil(t, err)
 	assert.True(t, deleted, "delete should happen")
 }
 
+func TestControllerDeleteEmptyGameServerSetsRevisionHistory(t *testing.T) {
+	t.Parallel()
+
+	f := defaultFixture()
+	now := metav1.Now()
+	var list []*agonesv1.GameServerSet
+	for i := 0; i < 3; i++ {
+		gsSet := f.GameServerSet()
+		gsSet.ObjectMeta.Name = fmt.Sprintf("gsSet%d", i)
+		gsSet.ObjectMeta.CreationTimestamp = metav1.NewTime(now.Add(time.Duration(i) * time.Minute))
+		list = append(list, gsSet)
+	}
+
+	c, m := newFakeController()
+	var deleted []string
+	m.AgonesClient.AddReactor("delete", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleted = append(deleted, action.(k8stesting.DeleteAction).GetName())
+		return true, nil, nil
+	})
+
+	err := c.deleteEmptyGameServerSets(f, list, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"gsSet0"}, deleted)
+}
+
 func TestControllerRollingUpdateDeployment(t *testing.T) {
 	t.Parallel()
 
@@ -661,6 +1370,7 @@ func TestControllerRollingUpdateDeployment(t *testing.T) {
 		inactiveSpecReplicas             int32
 		inactiveStatusReplicas           int32
 		inactiveStatusAllocationReplicas int32
+		drain                            bool
 		expected                         expected
 	}{
 		"full inactive, empty inactive": {
@@ -713,6 +1423,35 @@ func TestControllerRollingUpdateDeployment(t *testing.T) {
 				updated:              false,
 			},
 		},
+		"draining inactive scales to 0 once only allocated are left": {
+			fleetSpecReplicas:                100,
+			activeSpecReplicas:               60,
+			activeStatusReplicas:             60,
+			inactiveSpecReplicas:             40,
+			inactiveStatusReplicas:           40,
+			inactiveStatusAllocationReplicas: 15,
+			drain:                            true,
+
+			expected: expected{
+				inactiveSpecReplicas: 0,
+				replicas:             85,
+				updated:              true,
+			},
+		},
+		"not draining inactive keeps allocated replicas": {
+			fleetSpecReplicas:                100,
+			activeSpecReplicas:               60,
+			activeStatusReplicas:             60,
+			inactiveSpecReplicas:             40,
+			inactiveStatusReplicas:           40,
+			inactiveStatusAllocationReplicas: 15,
+
+			expected: expected{
+				inactiveSpecReplicas: 10,
+				replicas:             85,
+				updated:              true,
+			},
+		},
 		"test smalled numbers of active and allocated": {
 			fleetSpecReplicas:                5,
 			activeSpecReplicas:               0,
@@ -736,6 +1475,9 @@ func TestControllerRollingUpdateDeployment(t *testing.T) {
 			mu := intstr.FromString("30%")
 			f.Spec.Strategy.RollingUpdate.MaxUnavailable = &mu
 			f.Spec.Replicas = v.fleetSpecReplicas
+			if v.drain {
+				f.Spec.Rollout.Mode = agonesv1.FleetRolloutDrainAllocated
+			}
 
 			// gate
 			assert.Equal(t, "25%", f.Spec.Strategy.RollingUpdate.MaxSurge.String())
@@ -780,11 +1522,204 @@ func TestControllerRollingUpdateDeployment(t *testing.T) {
 	}
 }
 
+func TestControllerRollingUpdateRestDrain(t *testing.T) {
+	t.Parallel()
+
+	f := defaultFixture()
+	f.Spec.Replicas = 100
+
+	draining := f.GameServerSet()
+	draining.ObjectMeta.Name = "draining"
+	draining.Spec.Replicas = 0
+	draining.Status.Replicas = 5
+	draining.Status.AllocatedReplicas = 5
+
+	inactive := f.GameServerSet()
+	inactive.ObjectMeta.Name = "inactive"
+	inactive.Spec.Replicas = 50
+	inactive.Status.Replicas = 50
+
+	t.Run("default mode waits for the draining GameServerSet", func(t *testing.T) {
+		c, m := newFakeController()
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "gameserverset should not be updated")
+			return true, nil, nil
+		})
+
+		err := c.rollingUpdateRest(f, []*agonesv1.GameServerSet{draining, inactive})
+		assert.Nil(t, err)
+		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+	})
+
+	t.Run("drain mode scales down the next GameServerSet", func(t *testing.T) {
+		fCopy := f.DeepCopy()
+		fCopy.Spec.Rollout.Mode = agonesv1.FleetRolloutDrainAllocated
+
+		c, m := newFakeController()
+		updated := false
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			gsSet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServerSet)
+			assert.Equal(t, inactive.ObjectMeta.Name, gsSet.ObjectMeta.Name)
+			assert.Equal(t, int32(25), gsSet.Spec.Replicas)
+			return true, gsSet, nil
+		})
+
+		err := c.rollingUpdateRest(fCopy, []*agonesv1.GameServerSet{draining, inactive})
+		assert.Nil(t, err)
+		assert.True(t, updated)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "ScalingGameServerSet")
+	})
+}
+
+func TestControllerRolloutStatus(t *testing.T) {
+	t.Parallel()
+
+	f := defaultFixture()
+	f.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{ContainerPort: 7777}}
+
+	active := f.GameServerSet()
+	active.ObjectMeta.Name = "active"
+	active.Status.Replicas = 3
+
+	assert.Nil(t, rolloutStatus(f, nil, []*agonesv1.GameServerSet{active}))
+
+	outdated := f.GameServerSet()
+	outdated.ObjectMeta.Name = "outdated"
+	outdated.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{ContainerPort: 6666}}
+	outdated.Status.Replicas = 4
+	outdated.Status.AllocatedReplicas = 2
+
+	status := rolloutStatus(f, nil, []*agonesv1.GameServerSet{active, outdated})
+	if assert.NotNil(t, status) {
+		assert.False(t, status.StartTime.IsZero())
+		assert.Equal(t, int32(3), status.UpdatedReplicas)
+		assert.Equal(t, int32(4), status.OutdatedReplicas)
+		assert.Equal(t, int32(2), status.OutdatedAllocatedReplicas)
+		assert.Nil(t, status.DrainDeadline)
+	}
+
+	start := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	f.Spec.Rollout = agonesv1.FleetRollout{Mode: agonesv1.FleetRolloutDrainAllocated, DrainTimeoutSeconds: 60}
+	current := &agonesv1.FleetRolloutStatus{StartTime: start, OutdatedGameServerSets: []string{"outdated"}}
+	status = rolloutStatus(f, current, []*agonesv1.GameServerSet{active, outdated})
+	if assert.NotNil(t, status) {
+		assert.Equal(t, start, status.StartTime)
+		assert.Equal(t, []string{"outdated"}, status.OutdatedGameServerSets)
+		if assert.NotNil(t, status.DrainDeadline) {
+			assert.Equal(t, start.Add(time.Minute), status.DrainDeadline.Time)
+		}
+	}
+
+	// a new rollout while the previous one is still draining starts again
+	newer := outdated.DeepCopy()
+	newer.ObjectMeta.Name = "newer"
+	status = rolloutStatus(f, current, []*agonesv1.GameServerSet{active, outdated, newer})
+	if assert.NotNil(t, status) {
+		assert.True(t, status.StartTime.After(start.Time))
+		assert.Equal(t, []string{"outdated", "newer"}, status.OutdatedGameServerSets)
+	}
+}
+
+func TestControllerDeleteUndrainedGameServers(t *testing.T) {
+	t.Parallel()
+
+	f := defaultFixture()
+	f.Spec.Rollout = agonesv1.FleetRollout{Mode: agonesv1.FleetRolloutDrainAllocated, DrainTimeoutSeconds: 60}
+
+	outdated := f.GameServerSet()
+	outdated.ObjectMeta.Name = "outdated"
+	outdated.ObjectMeta.UID = "5678"
+	outdated.Status.Replicas = 2
+	outdated.Status.AllocatedReplicas = 1
+
+	allocated := outdated.GameServer()
+	allocated.ObjectMeta.Name = "allocated"
+	allocated.Status.State = agonesv1.GameServerStateAllocated
+	ready := outdated.GameServer()
+	ready.ObjectMeta.Name = "ready"
+	ready.Status.State = agonesv1.GameServerStateReady
+
+	t.Run("before the deadline", func(t *testing.T) {
+		fCopy := f.DeepCopy()
+		fCopy.Status.Rollout = &agonesv1.FleetRolloutStatus{StartTime: metav1.Now(), OutdatedGameServerSets: []string{"outdated"}}
+
+		c, m := newFakeController()
+		m.AgonesClient.AddReactor("delete", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "gameserver should not be deleted")
+			return true, nil, nil
+		})
+
+		err := c.deleteUndrainedGameServers(fCopy, []*agonesv1.GameServerSet{outdated})
+		assert.Nil(t, err)
+		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+	})
+
+	t.Run("outdated since the status was updated", func(t *testing.T) {
+		fCopy := f.DeepCopy()
+		fCopy.Status.Rollout = &agonesv1.FleetRolloutStatus{StartTime: metav1.NewTime(time.Now().Add(-time.Hour)), OutdatedGameServerSets: []string{"older"}}
+
+		c, m := newFakeController()
+		m.AgonesClient.AddReactor("delete", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "gameserver should not be deleted")
+			return true, nil, nil
+		})
+
+		err := c.deleteUndrainedGameServers(fCopy, []*agonesv1.GameServerSet{outdated})
+		assert.Nil(t, err)
+		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+	})
+
+	t.Run("after the deadline", func(t *testing.T) {
+		fCopy := f.DeepCopy()
+		fCopy.Status.Rollout = &agonesv1.FleetRolloutStatus{StartTime: metav1.NewTime(time.Now().Add(-time.Hour)), OutdatedGameServerSets: []string{"outdated"}}
+
+		c, m := newFakeController()
+		m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{*allocated, *ready}}, nil
+		})
+		var deleted []string
+		m.AgonesClient.AddReactor("delete", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			deleted = append(deleted, action.(k8stesting.DeleteAction).GetName())
+			return true, nil, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.gameServerSynced)
+		defer cancel()
+
+		err := c.deleteUndrainedGameServers(fCopy, []*agonesv1.GameServerSet{outdated})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{allocated.ObjectMeta.Name}, deleted)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "DrainTimeout")
+	})
+
+	t.Run("already deleted", func(t *testing.T) {
+		fCopy := f.DeepCopy()
+		fCopy.Status.Rollout = &agonesv1.FleetRolloutStatus{StartTime: metav1.NewTime(time.Now().Add(-time.Hour)), OutdatedGameServerSets: []string{"outdated"}}
+
+		c, m := newFakeController()
+		m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{*allocated}}, nil
+		})
+		m.AgonesClient.AddReactor("delete", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, k8serrors.NewNotFound(agonesv1.Resource("gameserver"), allocated.ObjectMeta.Name)
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.gameServerSynced)
+		defer cancel()
+
+		err := c.deleteUndrainedGameServers(fCopy, []*agonesv1.GameServerSet{outdated})
+		assert.Nil(t, err)
+		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+	})
+}
+
 // newFakeController returns a controller, backed by the fake Clientset
 func newFakeController() (*Controller, agtesting.Mocks) {
 	m := agtesting.NewMocks()
 	wh := webhooks.NewWebHook(http.NewServeMux())
-	c := NewController(wh, healthcheck.NewHandler(), m.KubeClient, m.ExtClient, m.AgonesClient, m.AgonesInformerFactory)
+	product, _ := cloudproduct.New(cloudproduct.Generic, m.KubeClient)
+	c := NewController(wh, healthcheck.NewHandler(), product, m.KubeClient, m.ExtClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
 	c.recorder = m.FakeRecorder
 	return c, m
 }
@@ -0,0 +1,166 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleets
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/util/runtime"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// enqueueDependents enqueues the Fleets that depend on the Fleet, so that a rollout that waits for it continues
+// once the rollout of the Fleet completes
+func (c *Controller) enqueueDependents(fleet *agonesv1.Fleet) {
+	list, err := c.fleetLister.Fleets(fleet.ObjectMeta.Namespace).List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(c.loggerForFleet(fleet), errors.Wrap(err, "error listing the fleets that depend on the fleet"))
+		return
+	}
+	for _, f := range list {
+		for _, name := range f.Spec.DependsOn {
+			if name == fleet.ObjectMeta.Name {
+				c.workerqueue.Enqueue(f)
+				break
+			}
+		}
+	}
+}
+
+// waitingDependencies returns the names of the Fleets the Fleet depends on that have not completed their rollouts,
+// if the Fleet has a rollout to wait with. A Fleet that does not exist has not completed its rollout either.
+// A new Fleet, without GameServerSets yet, does not wait, so that it is created along with its dependencies.
+func (c *Controller) waitingDependencies(fleet *agonesv1.Fleet, list []*agonesv1.GameServerSet) ([]string, error) {
+	if len(fleet.Spec.DependsOn) == 0 || len(list) == 0 || !rollingOut(fleet, list) {
+		return nil, nil
+	}
+
+	var waiting []string
+	for _, name := range fleet.Spec.DependsOn {
+		dependency, err := c.fleetLister.Fleets(fleet.ObjectMeta.Namespace).Get(name)
+		if k8serrors.IsNotFound(err) {
+			waiting = append(waiting, name)
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error retrieving fleet %s that fleet %s depends on", name, fleet.ObjectMeta.Name)
+		}
+		dependencyList, err := ListGameServerSetsByFleetOwner(c.gameServerSetLister, dependency)
+		if err != nil {
+			return nil, err
+		}
+		if rollingOut(dependency, dependencyList) {
+			waiting = append(waiting, name)
+		}
+	}
+	return waiting, nil
+}
+
+// rollingOut returns true if one of the templates of the Fleet does not have a GameServerSet yet,
+// or if GameServerSets of previous templates are still being scaled down. Once it returns false the rollout
+// is complete, whatever the state of the GameServers of the current templates.
+func rollingOut(fleet *agonesv1.Fleet, list []*agonesv1.GameServerSet) bool {
+	if len(outdatedGameServerSets(fleet, list)) > 0 {
+		return true
+	}
+	_, templates := templateFleets(fleet)
+	for _, f := range templates {
+		if activeGameServerSet(f, list) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// activeGameServerSet returns the GameServerSet of the template of a Fleet as returned by templateFleets,
+// or nil if it does not have one
+func activeGameServerSet(fleet *agonesv1.Fleet, list []*agonesv1.GameServerSet) *agonesv1.GameServerSet {
+	for _, gsSet := range list {
+		if reflect.DeepEqual(gsSet.Spec.Template, fleet.Spec.Template) {
+			return gsSet
+		}
+	}
+	return nil
+}
+
+// rolloutWaitingCondition returns the RolloutWaiting condition of the Fleet, which is True while it waits for the
+// Fleets it depends on, and false if it is not set and does not need to be, as the Fleet never waited for them
+func rolloutWaitingCondition(status agonesv1.FleetStatus, waiting []string) (agonesv1.FleetCondition, bool) {
+	if len(waiting) > 0 {
+		return agonesv1.FleetCondition{
+			Type:               agonesv1.FleetConditionRolloutWaiting,
+			Status:             corev1.ConditionTrue,
+			LastTransitionTime: metav1.Now(),
+			Reason:             "WaitingForDependencies",
+			Message:            fmt.Sprintf("Waiting for the rollouts of Fleets %s to complete", strings.Join(waiting, ", ")),
+		}, true
+	}
+
+	if _, ok := status.GetCondition(agonesv1.FleetConditionRolloutWaiting); !ok {
+		return agonesv1.FleetCondition{}, false
+	}
+	return agonesv1.FleetCondition{
+		Type:               agonesv1.FleetConditionRolloutWaiting,
+		Status:             corev1.ConditionFalse,
+		LastTransitionTime: metav1.Now(),
+		Reason:             "DependenciesRolledOut",
+		Message:            "The Fleets the Fleet depends on are not rolling out",
+	}, true
+}
+
+// validateDependencyCycle rejects a Fleet whose dependencies, or their dependencies in turn, depend on the Fleet,
+// as none of their rollouts would ever start
+func (c *Controller) validateDependencyCycle(fleet *agonesv1.Fleet) []metav1.StatusCause {
+	visited := map[string]bool{}
+	var dependsOnFleet func(names []string) bool
+	dependsOnFleet = func(names []string) bool {
+		for _, name := range names {
+			if name == fleet.ObjectMeta.Name {
+				return true
+			}
+			if visited[name] {
+				continue
+			}
+			visited[name] = true
+			dependency, err := c.fleetLister.Fleets(fleet.ObjectMeta.Namespace).Get(name)
+			if err != nil {
+				continue
+			}
+			if dependsOnFleet(dependency.Spec.DependsOn) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var causes []metav1.StatusCause
+	for _, name := range fleet.Spec.DependsOn {
+		if name != fleet.ObjectMeta.Name && dependsOnFleet([]string{name}) {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   fmt.Sprintf("dependsOn[%s]", name),
+				Message: fmt.Sprintf("Fleet %s depends on Fleet %s in turn", name, fleet.ObjectMeta.Name),
+			})
+		}
+	}
+	return causes
+}
@@ -0,0 +1,224 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleets
+
+import (
+	"testing"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestControllerSyncFleetDependencies(t *testing.T) {
+	t.Parallel()
+
+	// lobby is the Fleet that match depends on, with a GameServerSet of its current template, and match has a
+	// GameServerSet of a previous template, so it has a rollout that may wait for lobby
+	fixtures := func() (*agonesv1.Fleet, *agonesv1.GameServerSet, *agonesv1.Fleet, *agonesv1.GameServerSet) {
+		lobby := defaultFixture()
+		lobby.ObjectMeta.Name = "lobby"
+		lobby.ObjectMeta.UID = "lobby"
+		gsSet := lobby.GameServerSet()
+		gsSet.ObjectMeta.Name = "lobby-1"
+		gsSet.Spec.Replicas = lobby.Spec.Replicas
+		gsSet.Status.Replicas = lobby.Spec.Replicas
+		gsSet.Status.ReadyReplicas = lobby.Spec.Replicas
+
+		match := defaultFixture()
+		match.ObjectMeta.Name = "match"
+		match.Spec.DependsOn = []string{"lobby"}
+		old := match.GameServerSet()
+		old.ObjectMeta.Name = "match-1"
+		old.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 7777}}
+		old.Spec.Replicas = match.Spec.Replicas
+		old.Status.Replicas = match.Spec.Replicas
+		return lobby, gsSet, match, old
+	}
+	// run syncs the match Fleet, and returns whether it created a GameServerSet, the replicas of the GameServerSets
+	// it updated, and the status it was updated to
+	run := func(t *testing.T, fleets []agonesv1.Fleet, gsSets []agonesv1.GameServerSet) (bool, map[string]int32, *agonesv1.FleetStatus) {
+		c, m := newFakeController()
+		m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.FleetList{Items: fleets}, nil
+		})
+		m.AgonesClient.AddReactor("list", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.GameServerSetList{Items: gsSets}, nil
+		})
+		created := false
+		m.AgonesClient.AddReactor("create", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			created = true
+			return true, action.(k8stesting.CreateAction).GetObject(), nil
+		})
+		updated := map[string]int32{}
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			gsSet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServerSet)
+			updated[gsSet.ObjectMeta.Name] = gsSet.Spec.Replicas
+			return true, gsSet, nil
+		})
+		var status *agonesv1.FleetStatus
+		m.AgonesClient.AddReactor("update", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			fleet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.Fleet)
+			status = &fleet.Status
+			return true, fleet, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.fleetSynced, c.gameServerSetSynced)
+		defer cancel()
+
+		assert.NoError(t, c.syncFleet("default/match"))
+		return created, updated, status
+	}
+	assertWaiting := func(t *testing.T, status *agonesv1.FleetStatus) {
+		if assert.NotNil(t, status) {
+			condition, ok := status.GetCondition(agonesv1.FleetConditionRolloutWaiting)
+			assert.True(t, ok)
+			assert.Equal(t, corev1.ConditionTrue, condition.Status)
+			assert.Equal(t, "WaitingForDependencies", condition.Reason)
+			assert.Contains(t, condition.Message, "lobby")
+		}
+	}
+	assertNotWaiting := func(t *testing.T, status *agonesv1.FleetStatus) {
+		if assert.NotNil(t, status) {
+			_, ok := status.GetCondition(agonesv1.FleetConditionRolloutWaiting)
+			assert.False(t, ok)
+		}
+	}
+
+	t.Run("dependency rolled out", func(t *testing.T) {
+		lobby, gsSet, match, old := fixtures()
+		created, _, status := run(t, []agonesv1.Fleet{*lobby, *match}, []agonesv1.GameServerSet{*gsSet, *old})
+		assert.True(t, created, "gameserverset should have been created")
+		assertNotWaiting(t, status)
+	})
+
+	t.Run("dependency rolled out with gameservers that are not ready", func(t *testing.T) {
+		lobby, gsSet, match, old := fixtures()
+		gsSet.Status.ReadyReplicas = 2
+		created, _, status := run(t, []agonesv1.Fleet{*lobby, *match}, []agonesv1.GameServerSet{*gsSet, *old})
+		assert.True(t, created, "gameserverset should have been created")
+		assertNotWaiting(t, status)
+	})
+
+	t.Run("dependency rolling out", func(t *testing.T) {
+		lobby, gsSet, match, old := fixtures()
+		outdated := lobby.GameServerSet()
+		outdated.ObjectMeta.Name = "lobby-0"
+		outdated.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{HostPort: 7777}}
+		outdated.Status.Replicas = 1
+		created, _, status := run(t, []agonesv1.Fleet{*lobby, *match}, []agonesv1.GameServerSet{*outdated, *gsSet, *old})
+		assert.False(t, created, "gameserverset should not have been created")
+		assertWaiting(t, status)
+	})
+
+	t.Run("dependency does not exist", func(t *testing.T) {
+		_, _, match, old := fixtures()
+		created, _, status := run(t, []agonesv1.Fleet{*match}, []agonesv1.GameServerSet{*old})
+		assert.False(t, created, "gameserverset should not have been created")
+		assertWaiting(t, status)
+	})
+
+	t.Run("waiting fleet is still scaled", func(t *testing.T) {
+		_, _, match, old := fixtures()
+		match.Spec.Replicas = 8
+		created, updated, status := run(t, []agonesv1.Fleet{*match}, []agonesv1.GameServerSet{*old})
+		assert.False(t, created, "gameserverset should not have been created")
+		assert.Equal(t, map[string]int32{"match-1": 8}, updated)
+		assertWaiting(t, status)
+	})
+
+	t.Run("new fleet does not wait", func(t *testing.T) {
+		_, _, match, _ := fixtures()
+		created, _, status := run(t, []agonesv1.Fleet{*match}, nil)
+		assert.True(t, created, "gameserverset should have been created")
+		assertNotWaiting(t, status)
+	})
+}
+
+func TestRollingOut(t *testing.T) {
+	t.Parallel()
+
+	f := defaultFixture()
+	assert.True(t, rollingOut(f, nil), "a fleet without gameserversets is rolling out")
+
+	gsSet := f.GameServerSet()
+	gsSet.Spec.Replicas = f.Spec.Replicas
+	gsSet.Status.Replicas = f.Spec.Replicas
+	gsSet.Status.ReadyReplicas = 3
+	gsSet.Status.ScheduledReplicas = 1
+	gsSet.Status.UnhealthyReplicas = 1
+	assert.False(t, rollingOut(f, []*agonesv1.GameServerSet{gsSet}), "gameservers that are not ready do not hold the rollout")
+
+	outdated := f.GameServerSet()
+	outdated.Spec.Template.Spec.Ports = []agonesv1.GameServerPort{{ContainerPort: 7777}}
+	outdated.Status.Replicas = 1
+	outdated.Status.AllocatedReplicas = 1
+	assert.True(t, rollingOut(f, []*agonesv1.GameServerSet{gsSet, outdated}), "an outdated gameserverset is not scaled down")
+
+	outdated.Status.Replicas = 0
+	outdated.Status.AllocatedReplicas = 0
+	assert.False(t, rollingOut(f, []*agonesv1.GameServerSet{gsSet, outdated}), "an outdated gameserverset is kept for the revision history")
+}
+
+func TestRolloutWaitingCondition(t *testing.T) {
+	t.Parallel()
+
+	_, ok := rolloutWaitingCondition(agonesv1.FleetStatus{}, nil)
+	assert.False(t, ok, "a fleet that never waited should not have the condition")
+
+	condition, ok := rolloutWaitingCondition(agonesv1.FleetStatus{}, []string{"lobby", "chat"})
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionTrue, condition.Status)
+	assert.Contains(t, condition.Message, "lobby, chat")
+
+	status := agonesv1.FleetStatus{Conditions: []agonesv1.FleetCondition{condition}}
+	condition, ok = rolloutWaitingCondition(status, nil)
+	assert.True(t, ok)
+	assert.Equal(t, corev1.ConditionFalse, condition.Status)
+	assert.Equal(t, "DependenciesRolledOut", condition.Reason)
+}
+
+func TestControllerValidateDependencyCycle(t *testing.T) {
+	t.Parallel()
+
+	fleet := func(name string, dependsOn ...string) agonesv1.Fleet {
+		return agonesv1.Fleet{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       agonesv1.FleetSpec{DependsOn: dependsOn},
+		}
+	}
+
+	c, m := newFakeController()
+	m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{
+			fleet("lobby", "chat"), fleet("chat", "match"), fleet("other"),
+		}}, nil
+	})
+	_, cancel := agtesting.StartInformers(m, c.fleetSynced)
+	defer cancel()
+
+	f := fleet("match", "other", "missing")
+	assert.Empty(t, c.validateDependencyCycle(&f))
+
+	f = fleet("match", "other", "lobby")
+	causes := c.validateDependencyCycle(&f)
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, "dependsOn[lobby]", causes[0].Field)
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleets
+
+import (
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// serviceEventHandler enqueues the owning Fleet of a metrics Service that was changed or deleted,
+// so that it is restored
+func (c *Controller) serviceEventHandler(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return
+	}
+	ref := metav1.GetControllerOf(svc)
+	if ref == nil || ref.APIVersion != agonesv1.SchemeGroupVersion.String() || ref.Kind != "Fleet" {
+		return
+	}
+	c.workerqueue.Enqueue(cache.ExplicitKey(svc.ObjectMeta.Namespace + "/" + ref.Name))
+}
+
+// syncFleetMetricsService creates, updates or deletes the headless Service for the metrics of the GameServers
+// of the Fleet, depending on its Metrics. A Service of the same name that the Fleet does not control is left alone.
+func (c *Controller) syncFleetMetricsService(fleet *agonesv1.Fleet) error {
+	svc, err := c.serviceLister.Services(fleet.ObjectMeta.Namespace).Get(fleet.MetricsServiceName())
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error retrieving metrics Service of Fleet %s", fleet.ObjectMeta.Name)
+	}
+	if k8serrors.IsNotFound(err) {
+		svc = nil
+	}
+	desired := fleet.MetricsService()
+
+	if svc != nil && !metav1.IsControlledBy(svc, fleet) {
+		if desired != nil {
+			c.recorder.Eventf(fleet, corev1.EventTypeWarning, "MetricsServiceConflict",
+				"Service %s already exists and is not controlled by the Fleet", svc.ObjectMeta.Name)
+		}
+		return nil
+	}
+
+	switch {
+	case desired == nil && svc == nil:
+		return nil
+	case desired == nil:
+		if err := c.serviceGetter.Services(svc.ObjectMeta.Namespace).Delete(svc.ObjectMeta.Name, nil); err != nil && !k8serrors.IsNotFound(err) {
+			return errors.Wrapf(err, "error deleting metrics Service %s of Fleet %s", svc.ObjectMeta.Name, fleet.ObjectMeta.Name)
+		}
+		c.recorder.Eventf(fleet, corev1.EventTypeNormal, "DeletingMetricsService", "Deleting metrics Service %s", svc.ObjectMeta.Name)
+		return nil
+	case svc == nil:
+		if _, err := c.serviceGetter.Services(desired.ObjectMeta.Namespace).Create(desired); err != nil {
+			return errors.Wrapf(err, "error creating metrics Service for Fleet %s", fleet.ObjectMeta.Name)
+		}
+		c.recorder.Eventf(fleet, corev1.EventTypeNormal, "CreatingMetricsService", "Created metrics Service %s", desired.ObjectMeta.Name)
+		return nil
+	}
+
+	svcCopy := svc.DeepCopy()
+	if svcCopy.ObjectMeta.Labels == nil {
+		svcCopy.ObjectMeta.Labels = map[string]string{}
+	}
+	if svcCopy.ObjectMeta.Annotations == nil {
+		svcCopy.ObjectMeta.Annotations = map[string]string{}
+	}
+	// labels and annotations that others added to the Service are kept
+	for k, v := range desired.ObjectMeta.Labels {
+		svcCopy.ObjectMeta.Labels[k] = v
+	}
+	for k, v := range desired.ObjectMeta.Annotations {
+		svcCopy.ObjectMeta.Annotations[k] = v
+	}
+	svcCopy.Spec.Selector = desired.Spec.Selector
+	svcCopy.Spec.Ports = desired.Spec.Ports
+	if equality.Semantic.DeepEqual(svc, svcCopy) {
+		return nil
+	}
+	if _, err := c.serviceGetter.Services(svcCopy.ObjectMeta.Namespace).Update(svcCopy); err != nil {
+		return errors.Wrapf(err, "error updating metrics Service %s of Fleet %s", svcCopy.ObjectMeta.Name, fleet.ObjectMeta.Name)
+	}
+	c.loggerForFleet(fleet).WithField("service", svcCopy.ObjectMeta.Name).Debug("Updated metrics Service")
+	return nil
+}
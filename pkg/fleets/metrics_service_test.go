@@ -0,0 +1,120 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fleets
+
+import (
+	"testing"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestControllerSyncFleetMetricsService(t *testing.T) {
+	t.Parallel()
+
+	withMetrics := func() *agonesv1.Fleet {
+		f := defaultFixture()
+		f.Spec.Metrics = &agonesv1.FleetMetrics{Port: 9090}
+		f.ApplyDefaults()
+		return f
+	}
+	// run syncs the metrics Service of the Fleet against the existing Services, and returns the verbs of the
+	// actions on Services
+	run := func(t *testing.T, f *agonesv1.Fleet, services ...corev1.Service) ([]string, *corev1.Service, agtesting.Mocks) {
+		c, m := newFakeController()
+		m.KubeClient.AddReactor("list", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &corev1.ServiceList{Items: services}, nil
+		})
+		var verbs []string
+		var written *corev1.Service
+		for _, verb := range []string{"create", "update", "delete"} {
+			m.KubeClient.AddReactor(verb, "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				verbs = append(verbs, action.GetVerb())
+				if a, ok := action.(k8stesting.CreateAction); ok {
+					written = a.GetObject().(*corev1.Service)
+				}
+				return true, written, nil
+			})
+		}
+
+		_, cancel := agtesting.StartInformers(m, c.serviceSynced)
+		defer cancel()
+
+		assert.NoError(t, c.syncFleetMetricsService(f))
+		return verbs, written, m
+	}
+
+	t.Run("creates the Service", func(t *testing.T) {
+		f := withMetrics()
+		verbs, svc, m := run(t, f)
+		assert.Equal(t, []string{"create"}, verbs)
+		if assert.NotNil(t, svc) {
+			assert.Equal(t, f.MetricsService(), svc)
+		}
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "CreatingMetricsService")
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		f := withMetrics()
+		svc := f.MetricsService()
+		svc.ObjectMeta.Annotations["other"] = "annotation"
+		verbs, _, _ := run(t, f, *svc)
+		assert.Empty(t, verbs)
+	})
+
+	t.Run("updates the Service", func(t *testing.T) {
+		f := withMetrics()
+		svc := f.MetricsService()
+		f.Spec.Metrics.Port = 8080
+		verbs, written, _ := run(t, f, *svc)
+		assert.Equal(t, []string{"update"}, verbs)
+		if assert.NotNil(t, written) {
+			assert.Equal(t, "8080", written.ObjectMeta.Annotations["prometheus.io/port"])
+			assert.Equal(t, int32(8080), written.Spec.Ports[0].Port)
+		}
+	})
+
+	t.Run("deletes the Service", func(t *testing.T) {
+		f := withMetrics()
+		svc := f.MetricsService()
+		f.Spec.Metrics = nil
+		verbs, _, m := run(t, f, *svc)
+		assert.Equal(t, []string{"delete"}, verbs)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "DeletingMetricsService")
+	})
+
+	t.Run("Service of someone else", func(t *testing.T) {
+		f := withMetrics()
+		svc := f.MetricsService()
+		svc.ObjectMeta.OwnerReferences = nil
+		verbs, _, m := run(t, f, *svc)
+		assert.Empty(t, verbs)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "MetricsServiceConflict")
+
+		f.Spec.Metrics = nil
+		verbs, _, m = run(t, f, *svc)
+		assert.Empty(t, verbs)
+		agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+	})
+
+	t.Run("no metrics", func(t *testing.T) {
+		verbs, _, _ := run(t, defaultFixture())
+		assert.Empty(t, verbs)
+	})
+}
@@ -16,31 +16,43 @@ package gameserverallocations
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"math/rand"
+	"math"
 	"net/http"
+	"reflect"
 	"strconv"
+	"sync"
 	"time"
 
+	pb "agones.dev/agones/pkg/allocation/go/v1alpha1"
+	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
 	multiclusterv1alpha1 "agones.dev/agones/pkg/apis/multicluster/v1alpha1"
+	informerv1 "agones.dev/agones/pkg/client/informers/externalversions/agones/v1"
 	multiclusterinformerv1alpha1 "agones.dev/agones/pkg/client/informers/externalversions/multicluster/v1alpha1"
+	listerv1 "agones.dev/agones/pkg/client/listers/agones/v1"
 	multiclusterlisterv1alpha1 "agones.dev/agones/pkg/client/listers/multicluster/v1alpha1"
 	"agones.dev/agones/pkg/util/apiserver"
 	"agones.dev/agones/pkg/util/logfields"
 	"agones.dev/agones/pkg/util/runtime"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/wait"
 	informercorev1 "k8s.io/client-go/informers/core/v1"
 	"k8s.io/client-go/kubernetes"
@@ -57,6 +69,8 @@ var (
 	ErrNoGameServerReady = errors.New("Could not find a Ready GameServer")
 	// ErrConflictInGameServerSelection is returned when the candidate gameserver already allocated
 	ErrConflictInGameServerSelection = errors.New("The Gameserver was already allocated")
+	// ErrAcknowledgeTimeout is returned when the allocated game server did not acknowledge its allocation in time
+	ErrAcknowledgeTimeout = errors.New("The GameServer did not acknowledge its allocation")
 )
 
 const (
@@ -64,17 +78,71 @@ const (
 	secretClientKeyName  = "tls.key"
 	secretCaCertName     = "ca.crt"
 
-	// Instead of selecting the top one, controller selects a random one
-	// from the topNGameServerCount of Ready gameservers
-	// to reduce the contention while allocating gameservers.
-	topNGameServerDefaultCount = 100
+	// RemoteAllocationTransportHTTP forwards multi-cluster allocation requests as
+	// GameServerAllocation json over https
+	RemoteAllocationTransportHTTP = "http"
+	// RemoteAllocationTransportGRPC forwards multi-cluster allocation requests to the
+	// AllocationService gRPC endpoint of the remote allocator service
+	RemoteAllocationTransportGRPC = "grpc"
+
+	// keepalive settings for gRPC connections to remote allocator services
+	remoteAllocationKeepaliveTime    = 30 * time.Second
+	remoteAllocationKeepaliveTimeout = 10 * time.Second
+
+	// connection pool settings for https clients to remote allocator services
+	remoteAllocationMaxIdleConns    = 100
+	remoteAllocationIdleConnTimeout = 90 * time.Second
+
+	// a remote allocation endpoint is taken out of rotation for remoteEndpointOpenDuration
+	// after failing remoteEndpointFailureThreshold times in a row
+	remoteEndpointFailureThreshold = 5
+	remoteEndpointOpenDuration     = 30 * time.Second
+
+	// the allocation endpoints of all allocation policies are probed every remoteEndpointProbePeriod
+	remoteEndpointProbePeriod  = 10 * time.Second
+	remoteEndpointProbeTimeout = 5 * time.Second
+
+	// length of the random suffix appended to metadata.generateName, as the Kubernetes api server does
+	generatedNameSuffixLength = 5
+
+	// length of the random identifier of an allocation that waits to be acknowledged by the game server
+	allocationIDLength = 16
+	// how often the GameServer is checked for whether it acknowledged its allocation
+	acknowledgePollInterval = 100 * time.Millisecond
 )
 
-const (
-	maxBatchQueue         = 100
-	maxBatchBeforeRefresh = 100
-	batchWaitTime         = 500 * time.Millisecond
-)
+// BatchConfig configures how allocation requests are batched
+type BatchConfig struct {
+	// MaxQueue is the number of allocation requests that can be queued for a batch,
+	// and the number of workers that move the allocated GameServers to Allocated
+	MaxQueue int
+	// MaxBatchBeforeRefresh is the number of allocations in a batch after which
+	// the sorted list of Ready GameServers is refreshed from the cache
+	MaxBatchBeforeRefresh int
+	// WaitTime is how long to wait for more allocation requests when the queue is empty
+	WaitTime time.Duration
+}
+
+// DefaultBatchConfig is the default configuration of allocation batching
+var DefaultBatchConfig = BatchConfig{
+	MaxQueue:              100,
+	MaxBatchBeforeRefresh: 100,
+	WaitTime:              500 * time.Millisecond,
+}
+
+// Validate returns an error if the BatchConfig is invalid
+func (b BatchConfig) Validate() error {
+	if b.MaxQueue <= 0 {
+		return errors.New("allocation batch queue size must be greater than 0")
+	}
+	if b.MaxBatchBeforeRefresh <= 0 {
+		return errors.New("allocation batch size before refresh must be greater than 0")
+	}
+	if b.WaitTime <= 0 {
+		return errors.New("allocation batch wait time must be greater than 0")
+	}
+	return nil
+}
 
 var allocationRetry = wait.Backoff{
 	Steps:    5,
@@ -83,6 +151,15 @@ var allocationRetry = wait.Backoff{
 	Jitter:   0.1,
 }
 
+// remoteAllocationRetry is the backoff for retrying a remote cluster
+// when all of its allocation endpoints fail with a transient error
+var remoteAllocationRetry = wait.Backoff{
+	Steps:    3,
+	Duration: 100 * time.Millisecond,
+	Factor:   2.0,
+	Jitter:   0.1,
+}
+
 // Allocator handles game server allocation
 type Allocator struct {
 	baseLogger             *logrus.Entry
@@ -90,39 +167,119 @@ type Allocator struct {
 	allocationPolicySynced cache.InformerSynced
 	secretLister           corev1lister.SecretLister
 	secretSynced           cache.InformerSynced
+	fleetLister            listerv1.FleetLister
+	fleetSynced            cache.InformerSynced
 	recorder               record.EventRecorder
 	pendingRequests        chan request
+	batchConfig            BatchConfig
 	readyGameServerCache   *ReadyGameServerCache
-	topNGameServerCount    int
+	// selector selects which of the best matching GameServers is allocated
+	selector *gameServerSelector
+	// remoteAllocationHedgeDelay is how long to wait for an allocation endpoint of a remote cluster
+	// before also sending the request to the next endpoint. Zero disables hedging.
+	remoteAllocationHedgeDelay time.Duration
+	// remoteAllocationTransport is either RemoteAllocationTransportHTTP or RemoteAllocationTransportGRPC
+	remoteAllocationTransport string
+	remoteClientsMutex        sync.Mutex
+	// remoteClients are the clients to remote allocator services, reused across allocations
+	remoteClients map[remoteClientKey]*remoteClient
+	// remoteCerts are the certificates for remote allocator services, loaded from secrets
+	remoteCerts *certStore
+	// remoteRetry is the backoff for retrying transient failures of a remote cluster
+	remoteRetry wait.Backoff
+	// remoteEndpointBreaker takes failing remote allocation endpoints out of rotation
+	remoteEndpointBreaker *circuitBreaker
+	// remoteEndpointHealth is the health of remote allocation endpoints, as seen by the background prober
+	remoteEndpointHealth *endpointHealth
+	// filterWebhook filters and orders the candidate GameServers of allocations, nil if it is disabled
+	filterWebhook *filterWebhook
+	// rateLimiter limits the rate of allocations per namespace and per Fleet
+	rateLimiter *rateLimiter
+	// degradedWrites queues the moves of allocated GameServers to Allocated while the apiserver is failing
+	degradedWrites *degradedWrites
+	// protectedMetadataPrefixes are the prefixes of the labels and annotations that allocations can't patch
+	protectedMetadataPrefixes []string
+	// selectorLint is true if allocations whose required selector matches nothing are told apart from NoCapacity
+	selectorLint bool
+	// sharedRequests are the in-flight allocations with a request ID, that retries of them wait for
+	sharedRequests *sharedRequests
+	// clock is the source of time of batching, acknowledgements, hedging and pre-allocations
+	clock clock.Clock
 }
 
 // request is an async request for allocation
 type request struct {
+	ctx      context.Context
 	gsa      *allocationv1.GameServerAllocation
 	response chan response
+	// filtered is true if the allocation filter webhook decided which GameServers may be allocated
+	filtered bool
+	// gameServers are the names of the GameServers that may be allocated, in order, if filtered is true
+	gameServers []string
+	// queued is when the request was queued for batching
+	queued time.Time
 }
 
 // response is an async response for a matching request
 type response struct {
 	request request
 	gs      *agonesv1.GameServer
-	err     error
+	// ready is the GameServer as it was Ready in the cache, before it was allocated
+	ready *agonesv1.GameServer
+	err   error
 }
 
 // NewAllocator creates an instance off Allocator
 func NewAllocator(policyInformer multiclusterinformerv1alpha1.GameServerAllocationPolicyInformer, secretInformer informercorev1.SecretInformer,
-	kubeClient kubernetes.Interface, readyGameServerCache *ReadyGameServerCache) *Allocator {
+	fleetInformer informerv1.FleetInformer, kubeClient kubernetes.Interface, readyGameServerCache *ReadyGameServerCache, config Config) *Allocator {
 	ah := &Allocator{
-		pendingRequests:        make(chan request, maxBatchQueue),
-		allocationPolicyLister: policyInformer.Lister(),
-		allocationPolicySynced: policyInformer.Informer().HasSynced,
-		secretLister:           secretInformer.Lister(),
-		secretSynced:           secretInformer.Informer().HasSynced,
-		readyGameServerCache:   readyGameServerCache,
-		topNGameServerCount:    topNGameServerDefaultCount,
+		pendingRequests:            make(chan request, config.Batch.MaxQueue),
+		batchConfig:                config.Batch,
+		allocationPolicyLister:     policyInformer.Lister(),
+		allocationPolicySynced:     policyInformer.Informer().HasSynced,
+		secretLister:               secretInformer.Lister(),
+		secretSynced:               secretInformer.Informer().HasSynced,
+		fleetLister:                fleetInformer.Lister(),
+		fleetSynced:                fleetInformer.Informer().HasSynced,
+		readyGameServerCache:       readyGameServerCache,
+		selector:                   newGameServerSelector(config.Selection),
+		remoteAllocationHedgeDelay: config.RemoteAllocationHedgeDelay,
+		remoteAllocationTransport:  config.RemoteAllocationTransport,
+		remoteClients:              map[remoteClientKey]*remoteClient{},
+		remoteCerts:                newCertStore(secretInformer.Lister()),
+		remoteRetry:                remoteAllocationRetry,
+		remoteEndpointBreaker:      newCircuitBreaker(remoteEndpointFailureThreshold, remoteEndpointOpenDuration),
+		remoteEndpointHealth:       newEndpointHealth(),
+		filterWebhook:              newFilterWebhook(config.FilterWebhook),
+		rateLimiter:                newRateLimiter(config.RateLimit),
+		degradedWrites:             newDegradedWrites(config.DegradedMode),
+		protectedMetadataPrefixes:  config.ProtectedMetadataPrefixes,
+		selectorLint:               config.SelectorLint,
+		sharedRequests:             newSharedRequests(),
+		clock:                      clock.RealClock{},
 	}
 
 	ah.baseLogger = runtime.NewLoggerWithType(ah)
+
+	// reload the certs and replace the cached remote clients when the secret holding the certs changes
+	secretInformer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldSecret := oldObj.(*corev1.Secret)
+			newSecret := newObj.(*corev1.Secret)
+			if oldSecret.ObjectMeta.ResourceVersion != newSecret.ObjectMeta.ResourceVersion {
+				ah.remoteCerts.invalidate(newSecret.ObjectMeta.Namespace, newSecret.ObjectMeta.Name)
+				ah.invalidateRemoteClients(newSecret.ObjectMeta.Namespace, newSecret.ObjectMeta.Name)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			// Could be a DeletedFinalStateUnknown, in which case, just ignore it
+			secret, ok := obj.(*corev1.Secret)
+			if ok {
+				ah.remoteCerts.invalidate(secret.ObjectMeta.Namespace, secret.ObjectMeta.Name)
+				ah.invalidateRemoteClients(secret.ObjectMeta.Namespace, secret.ObjectMeta.Name)
+			}
+		},
+	})
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(ah.baseLogger.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
@@ -142,7 +299,18 @@ func (c *Allocator) Start(stop <-chan struct{}) error {
 	}
 
 	// workers and logic for batching allocations
-	go c.ListenAndAllocate(maxBatchQueue, stop)
+	go c.ListenAndAllocate(c.batchConfig.MaxQueue, stop)
+
+	// health checks of remote allocation endpoints
+	go wait.Until(c.probeRemoteEndpoints, remoteEndpointProbePeriod, stop)
+
+	go wait.Until(c.rateLimiter.cleanup, rateLimiterCleanupPeriod, stop)
+
+	go wait.Until(c.expirePreAllocations, preAllocationExpiryPeriod, stop)
+
+	if c.degradedWrites.config.FailureThreshold > 0 {
+		go wait.Until(c.reconcilePendingWrites, c.degradedWrites.config.RetryPeriod, stop)
+	}
 
 	return nil
 }
@@ -150,16 +318,22 @@ func (c *Allocator) Start(stop <-chan struct{}) error {
 // Sync waits for cache to sync
 func (c *Allocator) Sync(stop <-chan struct{}) error {
 	c.baseLogger.Info("Wait for Allocator cache sync")
-	if !cache.WaitForCacheSync(stop, c.secretSynced, c.allocationPolicySynced) {
+	if !cache.WaitForCacheSync(stop, c.secretSynced, c.allocationPolicySynced, c.fleetSynced) {
 		return errors.New("failed to wait for caches to sync")
 	}
 	return nil
 }
 
 // Allocate CRDHandler for allocating a gameserver.
-func (c *Allocator) Allocate(gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (k8sruntime.Object, error) {
+// If ctx is done before the allocation is made, it is cancelled, and a GameServer that was
+// already matched for it is returned to the Ready GameServers.
+func (c *Allocator) Allocate(ctx context.Context, gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (k8sruntime.Object, error) {
 	// server side validation
-	if causes, ok := gsa.Validate(); !ok {
+	causes, _ := gsa.Validate()
+	causes = append(causes, validateScheduling(gsa)...)
+	causes = append(causes, validateMetaPatch(gsa, c.protectedMetadataPrefixes)...)
+	causes = append(causes, c.validateFleetName(gsa)...)
+	if len(causes) > 0 {
 		status := &metav1.Status{
 			Status:  metav1.StatusFailure,
 			Message: fmt.Sprintf("GameServerAllocation is invalid: Invalid value: %#v", gsa),
@@ -171,33 +345,119 @@ func (c *Allocator) Allocate(gsa *allocationv1.GameServerAllocation, stop <-chan
 			},
 			Code: http.StatusUnprocessableEntity,
 		}
-
-		var gvks []schema.GroupVersionKind
-		gvks, _, err := apiserver.Scheme.ObjectKinds(status)
-		if err != nil {
-			return nil, errors.Wrap(err, "could not find objectkinds for status")
-		}
-
-		status.TypeMeta = metav1.TypeMeta{Kind: gvks[0].Kind, APIVersion: gvks[0].Version}
-		return status, nil
+		return withStatusTypeMeta(status)
 	}
 
 	// If multi-cluster setting is enabled, allocate base on the multicluster allocation policy.
 	var out *allocationv1.GameServerAllocation
 	var err error
-	if gsa.Spec.MultiClusterSetting.Enabled {
-		out, err = c.applyMultiClusterAllocation(gsa, stop)
-	} else {
-		out, err = c.allocateFromLocalCluster(gsa, stop)
+	switch {
+	case gsa.Spec.ClaimToken != "":
+		if err = c.rateLimiter.start(gsa); err == nil {
+			out, err = c.claimPreAllocated(gsa)
+		}
+	case gsa.Spec.PreAllocate != nil:
+		if err = c.rateLimiter.start(gsa); err == nil {
+			out, err = c.preAllocate(ctx, gsa, stop)
+		}
+	case gsa.Spec.MultiClusterSetting.Enabled:
+		if err = c.rateLimiter.start(gsa); err == nil {
+			out, err = c.applyMultiClusterAllocation(ctx, gsa, stop)
+		}
+	default:
+		// only rate limited once it starts an allocation, as a retry joins the allocation in flight instead
+		out, err = c.allocateFromLocalCluster(ctx, gsa, c.rateLimiter.start, stop)
 	}
 
 	if err != nil {
+		if limited, ok := err.(*rateLimitError); ok {
+			return c.rateLimited(gsa, limited)
+		}
+		if ctx.Err() == context.DeadlineExceeded {
+			status := &metav1.Status{
+				Status:  metav1.StatusFailure,
+				Message: "GameServerAllocation was not made before the deadline of the request",
+				Reason:  metav1.StatusReasonTimeout,
+				Details: &metav1.StatusDetails{
+					Kind:  "GameServerAllocation",
+					Group: allocationv1.SchemeGroupVersion.Group,
+				},
+				Code: http.StatusGatewayTimeout,
+			}
+			return withStatusTypeMeta(status)
+		}
 		return nil, err
 	}
 
+	setResultName(out)
 	return out, nil
 }
 
+// rateLimited returns the Status of a GameServerAllocation that was rejected, as it exceeds a rate limit
+func (c *Allocator) rateLimited(gsa *allocationv1.GameServerAllocation, limited *rateLimitError) (k8sruntime.Object, error) {
+	c.loggerForGameServerAllocation(gsa).WithField("scope", limited.scope).Debug("Allocation rate limit exceeded")
+	status := &metav1.Status{
+		Status:  metav1.StatusFailure,
+		Message: limited.Error(),
+		Reason:  metav1.StatusReasonTooManyRequests,
+		Details: &metav1.StatusDetails{
+			Kind:              "GameServerAllocation",
+			Group:             allocationv1.SchemeGroupVersion.Group,
+			Causes:            []metav1.StatusCause{{Type: metav1.CauseType(apis.ReasonRateLimited), Message: limited.Error()}},
+			RetryAfterSeconds: int32(math.Ceil(limited.delay.Seconds())),
+		},
+		Code: http.StatusTooManyRequests,
+	}
+	return withStatusTypeMeta(status)
+}
+
+// validateFleetName returns a cause if the Fleet of the fleetName shortcut, or one of the fallback fleets,
+// does not exist in the namespace of the GameServerAllocation. Multi-cluster allocations are not checked,
+// as the Fleet may only exist in remote clusters.
+func (c *Allocator) validateFleetName(gsa *allocationv1.GameServerAllocation) []metav1.StatusCause {
+	if gsa.Spec.FleetName == "" || gsa.Spec.MultiClusterSetting.Enabled {
+		return nil
+	}
+	var causes []metav1.StatusCause
+	for i, name := range append([]string{gsa.Spec.FleetName}, gsa.Spec.FallbackFleetNames...) {
+		field := "spec.fleetName"
+		if i > 0 {
+			field = fmt.Sprintf("spec.fallbackFleetNames[%d]", i-1)
+		}
+		if _, err := c.fleetLister.Fleets(gsa.ObjectMeta.Namespace).Get(name); err != nil {
+			causes = append(causes, metav1.StatusCause{Type: metav1.CauseTypeFieldValueNotFound,
+				Field:   field,
+				Message: fmt.Sprintf("fleet %s does not exist in namespace %s", name, gsa.ObjectMeta.Namespace)})
+		}
+	}
+	return causes
+}
+
+// withStatusTypeMeta sets the TypeMeta of a Status that is returned instead of a GameServerAllocation
+func withStatusTypeMeta(status *metav1.Status) (k8sruntime.Object, error) {
+	gvks, _, err := apiserver.Scheme.ObjectKinds(status)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not find objectkinds for status")
+	}
+
+	status.TypeMeta = metav1.TypeMeta{Kind: gvks[0].Kind, APIVersion: gvks[0].Version}
+	return status, nil
+}
+
+// setResultName names the result of an allocation. A name set on the request is kept,
+// otherwise the result is named after the allocated GameServer, or generated from
+// `metadata.generateName` when no GameServer could be allocated.
+func setResultName(gsa *allocationv1.GameServerAllocation) {
+	if gsa.ObjectMeta.Name != "" {
+		return
+	}
+	if gsa.Status.GameServerName != "" {
+		gsa.ObjectMeta.Name = gsa.Status.GameServerName
+	} else if gsa.ObjectMeta.GenerateName != "" {
+		gsa.ObjectMeta.Name = gsa.ObjectMeta.GenerateName + utilrand.String(generatedNameSuffixLength)
+	}
+}
+
 func (c *Allocator) loggerForGameServerAllocationKey(key string) *logrus.Entry {
 	return logfields.AugmentLogEntry(c.baseLogger, logfields.GameServerAllocationKey, key)
 }
@@ -207,43 +467,205 @@ func (c *Allocator) loggerForGameServerAllocation(gsa *allocationv1.GameServerAl
 	if gsa != nil {
 		gsaName = gsa.Namespace + "/" + gsa.Name
 	}
-	return c.loggerForGameServerAllocationKey(gsaName).WithField("gsa", gsa)
+	return logfields.WithObject(c.loggerForGameServerAllocationKey(gsaName), "gsa", gsa)
 }
 
-// allocateFromLocalCluster allocates gameservers from the local cluster.
-func (c *Allocator) allocateFromLocalCluster(gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (*allocationv1.GameServerAllocation, error) {
-	var gs *agonesv1.GameServer
+// allocateFromLocalCluster allocates gameservers from the local cluster. If limit is set, it is called with the
+// GameServerAllocation the first time it starts a new allocation, rather than joining one in flight,
+// and a rateLimitError it returns is returned.
+func (c *Allocator) allocateFromLocalCluster(ctx context.Context, gsa *allocationv1.GameServerAllocation, limit func(*allocationv1.GameServerAllocation) error, stop <-chan struct{}) (*allocationv1.GameServerAllocation, error) {
+	if c.readyGameServerCache.Stale() {
+		// the cache may contain GameServers that were deleted or allocated already, so the allocation is
+		// rejected as contention for the client to retry, while the cache is refreshed from the apiserver
+		c.loggerForGameServerAllocation(gsa).WithField("lag", c.readyGameServerCache.Lag()).Warn("Ready GameServer cache is stale, rejecting allocation")
+		stats.Record(context.Background(), staleCacheRejectionsStats.M(1))
+		c.readyGameServerCache.Resync()
+		gsa.Status.State = allocationv1.GameServerAllocationContention
+		gsa.Status.Reason = apis.ReasonStaleCache
+		return gsa, nil
+	}
+
+	start := func() error {
+		if limit == nil {
+			return nil
+		}
+		// the retries of a contended allocation are not limited again
+		l := limit
+		limit = nil
+		return l(gsa)
+	}
+
+	var res response
 	err := Retry(allocationRetry, func() error {
 		var err error
-		gs, err = c.allocate(gsa, stop)
+		res, err = c.requestSharedAllocation(ctx, gsa, start, stop)
+		if err == ErrConflictInGameServerSelection {
+			// the retries hide contention from the client, so it is recorded separately
+			stats.Record(context.Background(), contentionRetriesStats.M(1))
+		}
 		return err
 	})
 
-	if err != nil && err != ErrNoGameServerReady && err != ErrConflictInGameServerSelection {
+	if ctx.Err() != nil {
+		// the request was cancelled, so there is no reason to think the cache is out of date
+		return nil, errors.Wrap(ctx.Err(), "allocation cancelled")
+	}
+	if isRateLimitError(err) {
+		return nil, err
+	}
+	if err != nil && err != ErrNoGameServerReady && err != ErrConflictInGameServerSelection && err != ErrMetaPatchConflict && err != ErrAcknowledgeTimeout {
 		c.readyGameServerCache.Resync()
 		return nil, err
 	}
 
 	if err == ErrNoGameServerReady {
 		gsa.Status.State = allocationv1.GameServerAllocationUnAllocated
+		gsa.Status.Reason = apis.ReasonNoCapacity
+		c.lintSelector(gsa)
 	} else if err == ErrConflictInGameServerSelection {
 		gsa.Status.State = allocationv1.GameServerAllocationContention
+		gsa.Status.Reason = apis.ReasonContention
+	} else if err == ErrMetaPatchConflict {
+		gsa.Status.State = allocationv1.GameServerAllocationUnAllocated
+		gsa.Status.Reason = apis.ReasonMetaPatchConflict
+	} else if err == ErrAcknowledgeTimeout {
+		gsa.Status.State = allocationv1.GameServerAllocationUnAllocated
+		gsa.Status.Reason = apis.ReasonAcknowledgeTimeout
 	} else {
-		gsa.ObjectMeta.Name = gs.ObjectMeta.Name
-		gsa.Status.State = allocationv1.GameServerAllocationAllocated
-		gsa.Status.GameServerName = gs.ObjectMeta.Name
-		gsa.Status.Ports = gs.Status.Ports
-		gsa.Status.Address = gs.Status.Address
-		gsa.Status.NodeName = gs.Status.NodeName
+		setAllocatedStatus(gsa, res.gs)
 	}
 
 	c.loggerForGameServerAllocation(gsa).Info("game server allocation")
 	return gsa, nil
 }
 
+// setAllocatedStatus sets the status of the GameServerAllocation to the GameServer that was allocated for it
+func setAllocatedStatus(gsa *allocationv1.GameServerAllocation, gs *agonesv1.GameServer) {
+	gsa.Status.State = allocationv1.GameServerAllocationAllocated
+	gsa.Status.GameServerName = gs.ObjectMeta.Name
+	gsa.Status.GameServerUID = gs.ObjectMeta.UID
+	gsa.Status.GameServerCreationTimestamp = gs.ObjectMeta.CreationTimestamp.DeepCopy()
+	gsa.Status.Ports = gs.Status.Ports
+	gsa.Status.Address = gs.Status.Address
+	gsa.Status.Addresses = gs.Status.Addresses
+	gsa.Status.NodeName = gs.Status.NodeName
+	if gsa.Spec.IncludeGameServer {
+		gsa.Status.GameServer = gs
+	}
+}
+
+// requestAcknowledgedAllocation requests the allocation from the batch process like requestAllocation, and if the
+// GameServerAllocation has an acknowledge timeout, waits for the game server to acknowledge its allocation
+func (c *Allocator) requestAcknowledgedAllocation(ctx context.Context, gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (response, error) {
+	res, err := c.requestAllocation(ctx, gsa, stop)
+	if err != nil || gsa.Spec.AcknowledgeTimeoutSeconds <= 0 {
+		return res, err
+	}
+	return res, c.waitForAcknowledgement(ctx, res, time.Duration(gsa.Spec.AcknowledgeTimeoutSeconds)*time.Second)
+}
+
+// waitForAcknowledgement waits for the game server to acknowledge its allocation through the SDK, by setting
+// the AllocationAcknowledgedAnnotation to the value of the AllocationAnnotation. If it does not within the timeout,
+// the GameServer is moved to Unhealthy, so that it is replaced, and ErrAcknowledgeTimeout is returned. If the
+// allocation is cancelled while waiting, the GameServer is returned to Ready, and the error of the context is returned.
+func (c *Allocator) waitForAcknowledgement(ctx context.Context, res response, timeout time.Duration) error {
+	gs := res.gs
+	id := gs.ObjectMeta.Annotations[agonesv1.AllocationAnnotation]
+	lister := c.readyGameServerCache.gameServerLister.GameServers(gs.ObjectMeta.Namespace)
+	acknowledged := func() bool {
+		current, err := lister.Get(gs.ObjectMeta.Name)
+		// the informer may not have seen the GameServer yet
+		return err == nil && current.ObjectMeta.Annotations[agonesv1.AllocationAcknowledgedAnnotation] == id
+	}
+
+	timer := c.clock.NewTimer(timeout)
+	defer timer.Stop()
+	ticker := c.clock.NewTicker(acknowledgePollInterval)
+	defer ticker.Stop()
+	for !acknowledged() {
+		select {
+		case <-ticker.C():
+		case <-ctx.Done():
+			// nobody is waiting for the allocation anymore, so it is not leaked
+			c.returnGameServer(res)
+			return ctx.Err()
+		case <-timer.C():
+			c.acknowledgeTimedOut(gs, timeout)
+			return ErrAcknowledgeTimeout
+		}
+	}
+	return nil
+}
+
+// acknowledgeTimedOut moves an allocated GameServer that did not acknowledge its allocation to Unhealthy
+func (c *Allocator) acknowledgeTimedOut(gs *agonesv1.GameServer, timeout time.Duration) {
+	msg := fmt.Sprintf("Allocation was not acknowledged within %v", timeout)
+	logger := logfields.AugmentLogEntry(c.baseLogger, logfields.GameServerKey, gs.ObjectMeta.Namespace+"/"+gs.ObjectMeta.Name)
+	logger.Warn(msg)
+	c.recorder.Event(gs, corev1.EventTypeWarning, string(apis.ReasonAcknowledgeTimeout), msg)
+
+	current, err := c.readyGameServerCache.gameServerLister.GameServers(gs.ObjectMeta.Namespace).Get(gs.ObjectMeta.Name)
+	if err != nil || current.Status.State != agonesv1.GameServerStateAllocated || current.ObjectMeta.UID != gs.ObjectMeta.UID {
+		// the GameServer has moved on already
+		return
+	}
+	gsCopy := current.DeepCopy()
+	gsCopy.Status.State = agonesv1.GameServerStateUnhealthy
+	if _, err := c.readyGameServerCache.gameServerGetter.GameServers(gsCopy.ObjectMeta.Namespace).Update(gsCopy); err != nil {
+		runtime.HandleError(logger, errors.Wrap(err, "error moving GameServer that did not acknowledge its allocation to Unhealthy"))
+	}
+}
+
+// validatePolicyReferences validates the parts of a GameServerAllocationPolicy that depend on other resources
+// in its namespace: the secret for the remote allocation endpoints has to exist, and other policies for the
+// same cluster have to use the same connection information, as only one of them is used for allocation.
+func (c *Allocator) validatePolicyReferences(namespace string, policy *multiclusterv1alpha1.GameServerAllocationPolicy) ([]metav1.StatusCause, error) {
+	var causes []metav1.StatusCause
+	info := policy.Spec.ConnectionInfo
+
+	if info.SecretName == "" {
+		if len(info.AllocationEndpoints) > 0 {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueRequired,
+				Field:   "connectionInfo.secretName",
+				Message: "secretName is required when allocationEndpoints are set",
+			})
+		}
+	} else if _, err := c.secretLister.Secrets(namespace).Get(info.SecretName); err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return nil, errors.Wrapf(err, "error retrieving secret %s", info.SecretName)
+		}
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueNotFound,
+			Field:   "connectionInfo.secretName",
+			Message: fmt.Sprintf("secret %s does not exist in namespace %s", info.SecretName, namespace),
+		})
+	}
+
+	policies, err := c.allocationPolicyLister.GameServerAllocationPolicies(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing GameServerAllocationPolicies")
+	}
+	for _, p := range policies {
+		if p.ObjectMeta.Name == policy.ObjectMeta.Name || p.Spec.ConnectionInfo.ClusterName != info.ClusterName {
+			continue
+		}
+		if !reflect.DeepEqual(p.Spec.ConnectionInfo, info) {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueDuplicate,
+				Field:   "connectionInfo.clusterName",
+				Message: fmt.Sprintf("cluster %q is already defined by GameServerAllocationPolicy %s with different connection information", info.ClusterName, p.ObjectMeta.Name),
+			})
+			break
+		}
+	}
+
+	return causes, nil
+}
+
 // applyMultiClusterAllocation retrieves allocation policies and iterate on policies.
 // Then allocate gameservers from local or remote cluster accordingly.
-func (c *Allocator) applyMultiClusterAllocation(gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (result *allocationv1.GameServerAllocation, err error) {
+func (c *Allocator) applyMultiClusterAllocation(ctx context.Context, gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (result *allocationv1.GameServerAllocation, err error) {
 	selector := labels.Everything()
 	if len(gsa.Spec.MultiClusterSetting.PolicySelector.MatchLabels)+len(gsa.Spec.MultiClusterSetting.PolicySelector.MatchExpressions) != 0 {
 		selector, err = metav1.LabelSelectorAsSelector(&gsa.Spec.MultiClusterSetting.PolicySelector)
@@ -259,6 +681,17 @@ func (c *Allocator) applyMultiClusterAllocation(gsa *allocationv1.GameServerAllo
 		return nil, errors.New("no multi-cluster allocation policy is specified")
 	}
 
+	// the result of the local cluster, if it was tried first
+	var localResult *allocationv1.GameServerAllocation
+	if gsa.Spec.MultiClusterSetting.PreferLocal {
+		// only spill over to remote clusters if there is no Ready GameServer in the local cluster
+		localResult, err = c.allocateFromLocalCluster(ctx, gsa, nil, stop)
+		if err != nil || localResult.Status.State != allocationv1.GameServerAllocationUnAllocated {
+			return localResult, err
+		}
+		c.recordMultiClusterFallback(gsa.ObjectMeta.ClusterName)
+	}
+
 	it := multiclusterv1alpha1.NewConnectionInfoIterator(policies)
 	for {
 		connectionInfo := it.Next()
@@ -266,141 +699,370 @@ func (c *Allocator) applyMultiClusterAllocation(gsa *allocationv1.GameServerAllo
 			break
 		}
 		if connectionInfo.ClusterName == gsa.ObjectMeta.ClusterName {
-			result, err = c.allocateFromLocalCluster(gsa, stop)
+			if localResult != nil {
+				// the local cluster was already tried
+				continue
+			}
+			result, err = c.allocateFromLocalCluster(ctx, gsa, nil, stop)
 			c.baseLogger.Error(err)
+		} else if !c.remoteEndpointHealth.anyHealthy(connectionInfo.AllocationEndpoints) {
+			err = fmt.Errorf("all allocation endpoints of cluster %s are unhealthy", connectionInfo.ClusterName)
+			c.baseLogger.WithField("cluster", connectionInfo.ClusterName).Debug("Skipping cluster with unhealthy allocation endpoints")
 		} else {
-			result, err = c.allocateFromRemoteCluster(*gsa, connectionInfo, gsa.ObjectMeta.Namespace)
+			result, err = c.allocateFromRemoteCluster(ctx, *gsa, connectionInfo, gsa.ObjectMeta.Namespace)
 			c.baseLogger.Error(err)
 		}
 		if result != nil {
 			return result, nil
 		}
+		if ctx.Err() != nil {
+			return nil, errors.Wrap(ctx.Err(), "allocation cancelled")
+		}
+		c.recordMultiClusterFallback(connectionInfo.ClusterName)
+	}
+	if localResult != nil && err == nil {
+		// there are no remote clusters to spill over to
+		return localResult, nil
 	}
 	return nil, err
 }
 
 // allocateFromRemoteCluster allocates gameservers from a remote cluster by making
-// an http call to allocation service in that cluster.
-func (c *Allocator) allocateFromRemoteCluster(gsa allocationv1.GameServerAllocation, connectionInfo *multiclusterv1alpha1.ClusterConnectionInfo, namespace string) (*allocationv1.GameServerAllocation, error) {
-	var gsaResult allocationv1.GameServerAllocation
-
-	// TODO: handle converting error to apiserver error
-	// TODO: cache the client
-	client, err := c.createRemoteClusterRestClient(namespace, connectionInfo.SecretName)
-	if err != nil {
-		return nil, err
-	}
-
+// an http or gRPC call to allocation service in that cluster.
+func (c *Allocator) allocateFromRemoteCluster(ctx context.Context, gsa allocationv1.GameServerAllocation, connectionInfo *multiclusterv1alpha1.ClusterConnectionInfo, namespace string) (*allocationv1.GameServerAllocation, error) {
 	// Forward the game server allocation request to another cluster,
 	// and disable multicluster settings to avoid the target cluster
 	// forward the allocation request again.
 	gsa.Spec.MultiClusterSetting.Enabled = false
 	gsa.Namespace = connectionInfo.Namespace
-	body, err := json.Marshal(gsa)
-	if err != nil {
-		return nil, err
-	}
 
-	// TODO: Retry on transient error --> response.StatusCode >= 500
-	for i, endpoint := range connectionInfo.AllocationEndpoints {
-		response, err := client.Post(endpoint, "application/json", bytes.NewBuffer(body))
-		if err != nil {
-			return nil, err
+	var send func(ctx context.Context, endpoint string) endpointResult
+	if c.remoteAllocationTransport == RemoteAllocationTransportGRPC {
+		request := ConvertGSAToAllocationRequest(&gsa)
+		send = func(ctx context.Context, endpoint string) endpointResult {
+			conn, release, err := c.getRemoteClusterConn(namespace, connectionInfo.SecretName, connectionInfo.ServerName, endpoint)
+			if err != nil {
+				return endpointResult{endpoint: endpoint, err: err, status: remoteStatusError}
+			}
+			defer release()
+			res := c.allocateFromEndpoint(ctx, conn, endpoint, request)
+			if res.gsa != nil {
+				res.gsa.ObjectMeta = gsa.ObjectMeta
+				res.gsa.Spec = gsa.Spec
+			}
+			return res
 		}
-		defer response.Body.Close() // nolint: errcheck
-
-		data, err := ioutil.ReadAll(response.Body)
+	} else {
+		// TODO: handle converting error to apiserver error
+		body, err := json.Marshal(gsa)
 		if err != nil {
 			return nil, err
 		}
-		// If there are multiple enpoints for the allocator connection and the current one is
-		// failing with 5xx http status, try the next endpoint. Otherwise, return the error response.
-		if response.StatusCode >= 500 && (i+1) < len(connectionInfo.AllocationEndpoints) {
-			// If there is a server error try a different endpoint
-			c.baseLogger.WithError(err).WithField("endpoint", endpoint).Warn("The request sent failed, trying next endpoint")
-			continue
+		send = func(ctx context.Context, endpoint string) endpointResult {
+			client, release, err := c.getRemoteClusterRestClient(namespace, connectionInfo.SecretName, connectionInfo.ServerName, endpoint)
+			if err != nil {
+				return endpointResult{endpoint: endpoint, err: err, status: remoteStatusError}
+			}
+			defer release()
+			return c.postToEndpoint(ctx, client, endpoint, body)
 		}
-		if response.StatusCode >= 400 {
-			// For error responses return the body without deserializing to an object.
-			return nil, errors.New(string(data))
+	}
+
+	// record the outcome and latency of every request sent to the cluster
+	sendToEndpoint := send
+	send = func(ctx context.Context, endpoint string) endpointResult {
+		start := time.Now()
+		res := sendToEndpoint(ctx, endpoint)
+		c.recordRemoteAllocation(connectionInfo.ClusterName, res.status, start)
+		return res
+	}
+
+	var res endpointResult
+	// Retry the cluster with backoff while all its endpoints are failing with a transient error,
+	// the result of the last attempt is returned once the retries are exhausted, or the request is cancelled.
+	_ = wait.ExponentialBackoff(c.remoteRetry, func() (bool, error) {
+		res = c.sendToEndpoints(ctx, connectionInfo.AllocationEndpoints, send)
+		return !res.transient || ctx.Err() != nil, nil
+	})
+	return res.gsa, res.err
+}
+
+// endpointResult is the outcome of sending an allocation request to a single endpoint
+type endpointResult struct {
+	endpoint string
+	gsa      *allocationv1.GameServerAllocation
+	err      error
+	// transient is true if the endpoint failed in a way that another endpoint may succeed,
+	// e.g. a connection error or a 5xx http status
+	transient bool
+	// status is the class of the http status, or the gRPC status code, of the response,
+	// or remoteStatusError if no response was received
+	status string
+}
+
+// sendToEndpoints sends the allocation request to the allocation endpoints of a cluster.
+// The next endpoint is tried when the previous one fails with a transient error. Endpoints that failed
+// their last health probe are skipped, as are endpoints that have been failing repeatedly, until
+// their circuit breaker allows them again.
+// If remoteAllocationHedgeDelay is set, a hedged request is also sent to the next endpoint when the
+// previous one has not responded within that delay. The first non-transient result wins, and all
+// requests that are still in flight are cancelled, as are all requests when ctx is done.
+func (c *Allocator) sendToEndpoints(ctx context.Context, endpoints []string, send func(ctx context.Context, endpoint string) endpointResult) endpointResult {
+	if len(endpoints) == 0 {
+		return endpointResult{err: errors.New("no allocation endpoints are specified")}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// buffered, so that requests that lose the race do not block on return
+	results := make(chan endpointResult, len(endpoints))
+	next := 0
+	inFlight := 0
+	sendNext := func() bool {
+		for next < len(endpoints) {
+			endpoint := endpoints[next]
+			next++
+			if !c.remoteEndpointHealth.healthy(endpoint) {
+				c.baseLogger.WithField("endpoint", endpoint).Debug("Skipping unhealthy allocation endpoint")
+				continue
+			}
+			if !c.remoteEndpointBreaker.allow(endpoint) {
+				c.baseLogger.WithField("endpoint", endpoint).Debug("Skipping failing allocation endpoint")
+				continue
+			}
+			inFlight++
+			go func() {
+				results <- send(ctx, endpoint)
+			}()
+			return true
 		}
+		return false
+	}
 
-		err = json.Unmarshal(data, &gsaResult)
-		if err != nil {
-			return nil, err
+	if !sendNext() {
+		return endpointResult{err: errors.New("all allocation endpoints are failing, skipping the cluster")}
+	}
+	var last endpointResult
+	for inFlight > 0 {
+		var hedge <-chan time.Time
+		var timer clock.Timer
+		if c.remoteAllocationHedgeDelay > 0 && next < len(endpoints) {
+			timer = c.clock.NewTimer(c.remoteAllocationHedgeDelay)
+			hedge = timer.C()
+		}
+
+		select {
+		case <-hedge:
+			c.baseLogger.WithField("endpoint", endpoints[next-1]).Debug("Allocation request is slow, sending hedged request to next endpoint")
+			sendNext()
+		case res := <-results:
+			if timer != nil {
+				timer.Stop()
+			}
+			inFlight--
+			if !res.transient {
+				c.remoteEndpointBreaker.success(res.endpoint)
+				return res
+			}
+			c.remoteEndpointBreaker.failure(res.endpoint)
+			last = res
+			if next < len(endpoints) {
+				// If there is a server error try a different endpoint
+				c.baseLogger.WithError(res.err).WithField("endpoint", res.endpoint).Warn("The request sent failed, trying next endpoint")
+				sendNext()
+			}
 		}
-		break
 	}
-	return &gsaResult, nil
+
+	return last
 }
 
-// createRemoteClusterRestClient creates a rest client with proper certs to make a remote call.
-func (c *Allocator) createRemoteClusterRestClient(namespace, secretName string) (*http.Client, error) {
-	clientCert, clientKey, caCert, err := c.getClientCertificates(namespace, secretName)
+// postToEndpoint posts the allocation request body to a single allocation endpoint
+func (c *Allocator) postToEndpoint(ctx context.Context, client *http.Client, endpoint string, body []byte) endpointResult {
+	res := endpointResult{endpoint: endpoint, status: remoteStatusError}
+	req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewBuffer(body))
 	if err != nil {
-		return nil, err
+		res.err = err
+		return res
 	}
-	if clientCert == nil || clientKey == nil {
-		return nil, fmt.Errorf("missing client certificate key pair in secret %s", secretName)
+	req.Header.Set("Content-Type", "application/json")
+
+	response, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		res.err = err
+		res.transient = true
+		return res
 	}
+	defer response.Body.Close() // nolint: errcheck
+	res.status = strconv.Itoa(response.StatusCode/100) + "xx"
 
-	// Load client cert
-	cert, err := tls.X509KeyPair(clientCert, clientKey)
+	data, err := ioutil.ReadAll(response.Body)
 	if err != nil {
-		return nil, err
+		res.err = err
+		res.transient = true
+		return res
+	}
+	if response.StatusCode >= 400 {
+		// For error responses return the body without deserializing to an object.
+		res.err = errors.New(string(data))
+		res.transient = response.StatusCode >= 500
+		return res
 	}
 
-	tlsConfig := &tls.Config{Certificates: []tls.Certificate{cert}}
-	if len(caCert) != 0 {
-		// Load CA cert, if provided and trust the server certificate.
-		// This is required for self-signed certs.
-		tlsConfig.RootCAs = x509.NewCertPool()
-		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
-			return nil, errors.New("only PEM format is accepted for server CA")
+	gsa := &allocationv1.GameServerAllocation{}
+	if err := json.Unmarshal(data, gsa); err != nil {
+		res.err = err
+		return res
+	}
+	res.gsa = gsa
+	return res
+}
+
+// allocateFromEndpoint calls the AllocationService of a single remote allocation endpoint over gRPC
+func (c *Allocator) allocateFromEndpoint(ctx context.Context, conn *grpc.ClientConn, endpoint string, request *pb.AllocationRequest) endpointResult {
+	res := endpointResult{endpoint: endpoint}
+	response, err := pb.NewAllocationServiceClient(conn).PostAllocate(ctx, request)
+	res.status = status.Code(err).String()
+	if err != nil {
+		res.err = err
+		switch status.Code(err) {
+		case codes.Unavailable, codes.DeadlineExceeded, codes.Internal, codes.Unknown:
+			res.transient = true
 		}
+		return res
+	}
+	res.gsa = ConvertAllocationResponseToGSA(response)
+	return res
+}
+
+// createRemoteClusterRestClient creates a rest client with proper certs to make a remote call.
+func (c *Allocator) createRemoteClusterRestClient(namespace, secretName, serverName string) (*http.Client, error) {
+	tlsConfig, err := c.createRemoteClusterTLSConfig(namespace, secretName, serverName)
+	if err != nil {
+		return nil, err
 	}
 
-	// Setup HTTPS client
+	// Setup HTTPS client, the idle connections are pooled so that the client can be reused
+	// across allocations without a tls handshake per request.
 	return &http.Client{
 		Transport: &http.Transport{
-			TLSClientConfig: tlsConfig,
+			TLSClientConfig:     tlsConfig,
+			MaxIdleConnsPerHost: remoteAllocationMaxIdleConns,
+			IdleConnTimeout:     remoteAllocationIdleConnTimeout,
 		},
 	}, nil
 }
 
-// getClientCertificates returns the client certificates and CA cert for remote allocation cluster call
-func (c *Allocator) getClientCertificates(namespace, secretName string) (clientCert, clientKey, caCert []byte, err error) {
-	secret, err := c.secretLister.Secrets(namespace).Get(secretName)
+// createRemoteClusterTLSConfig creates the client tls config with proper certs to make a remote call.
+// If serverName is not empty, the server certificate is verified against it instead of the host of the endpoint.
+func (c *Allocator) createRemoteClusterTLSConfig(namespace, secretName, serverName string) (*tls.Config, error) {
+	certs, err := c.remoteCerts.get(namespace, secretName)
 	if err != nil {
-		return nil, nil, nil, err
-	}
-	if secret == nil || len(secret.Data) == 0 {
-		return nil, nil, nil, fmt.Errorf("secert %s does not have data", secretName)
+		return nil, err
 	}
-
-	// Create http client using cert
-	clientCert = secret.Data[secretClientCertName]
-	clientKey = secret.Data[secretClientKeyName]
-	caCert = secret.Data[secretCaCertName]
-	return clientCert, clientKey, caCert, nil
+	return &tls.Config{Certificates: []tls.Certificate{certs.clientCert}, RootCAs: certs.rootCAs, ServerName: serverName}, nil
 }
 
 // allocate allocated a GameServer from a given GameServerAllocation
 // this sets up allocation through a batch process.
-func (c *Allocator) allocate(gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (*agonesv1.GameServer, error) {
+func (c *Allocator) allocate(ctx context.Context, gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (*agonesv1.GameServer, error) {
+	res, err := c.requestAllocation(ctx, gsa, stop)
+	return res.gs, err
+}
+
+// requestAllocation pushes the GameServerAllocation into the batch process, and returns its response,
+// which also has the GameServer as it was Ready, before it was allocated
+func (c *Allocator) requestAllocation(ctx context.Context, gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (response, error) {
 	// creates an allocation request. This contains the requested GameServerAllocation, as well as the
 	// channel we expect the return values to come back for this GameServerAllocation
-	req := request{gsa: gsa, response: make(chan response)}
+	req := request{ctx: ctx, gsa: gsa, response: make(chan response)}
+	if c.filterWebhook != nil {
+		req.gameServers, req.filtered = c.filterGameServers(gsa)
+	}
 
 	// this pushes the request into the batching process
-	c.pendingRequests <- req
+	req.queued = c.clock.Now()
+	select {
+	case c.pendingRequests <- req:
+	case <-ctx.Done():
+		return response{}, ctx.Err()
+	case <-stop:
+		return response{}, errors.New("shutting down")
+	}
 
 	select {
 	case res := <-req.response: // wait for the batch to be completed
-		return res.gs, res.err
+		return res, res.err
+	case <-ctx.Done():
+		// the batch still responds, so the GameServer it may have allocated is returned once it does
+		go func() {
+			select {
+			case res := <-req.response:
+				if res.err == nil {
+					c.returnGameServer(res)
+				}
+			case <-stop:
+			}
+		}()
+		return response{}, ctx.Err()
 	case <-stop:
-		return nil, errors.New("shutting down")
+		return response{}, errors.New("shutting down")
+	}
+}
+
+// returnGameServer returns the GameServer of an allocation that was cancelled after it was moved to Allocated
+// back to Ready, with the metadata it had before, so that the allocation is not leaked
+func (c *Allocator) returnGameServer(res response) {
+	if c.degradedWrites.remove(res.gs) {
+		// the allocation was never written
+		c.readyGameServerCache.releasePendingWrite(res.gs)
+		c.readyGameServerCache.AddToReadyGameServer(res.ready)
+		return
+	}
+
+	logger := logfields.AugmentLogEntry(c.baseLogger, logfields.GameServerKey, res.gs.ObjectMeta.Namespace+"/"+res.gs.ObjectMeta.Name)
+	gs := res.ready.DeepCopy()
+	gs.ObjectMeta.ResourceVersion = res.gs.ObjectMeta.ResourceVersion
+	result, err := c.readyGameServerCache.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(gs)
+	if err != nil {
+		runtime.HandleError(logger, errors.Wrap(err, "error returning GameServer of cancelled allocation to Ready"))
+		return
 	}
+	logger.Info("Returned GameServer of cancelled allocation to Ready")
+	c.readyGameServerCache.ObserveResourceVersion(result.ObjectMeta.ResourceVersion)
+	c.readyGameServerCache.AddToReadyGameServer(result)
+}
+
+// filterGameServers asks the allocation filter webhook which of the Ready GameServers that match the selectors
+// of the GameServerAllocation may be allocated, and in what order. If the webhook fails, the allocation
+// is not filtered, and false is returned.
+func (c *Allocator) filterGameServers(gsa *allocationv1.GameServerAllocation) ([]string, bool) {
+	list := c.readyGameServerCache.indexedSortedReadyGameServers()
+	var candidates []*agonesv1.GameServer
+	// the candidates are those of the first of the fleet and the fallback fleets that has any
+	for _, fleetGsa := range fleetAllocations(gsa) {
+		var err error
+		candidates, err = findCandidatesForAllocation(fleetGsa, list, c.filterWebhook.config.MaxCandidates)
+		if err != nil {
+			// the same error is returned by the allocation itself
+			return nil, false
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, true
+	}
+
+	names, err := c.filterWebhook.filter(gsa, candidates)
+	if err != nil {
+		c.loggerForGameServerAllocation(gsa).WithError(err).Warn("Allocation filter webhook failed, allocating without it")
+		stats.Record(context.Background(), filterWebhookFailuresStats.M(1))
+		return nil, false
+	}
+	return names, true
 }
 
 // ListenAndAllocate is a blocking function that runs in a loop
@@ -419,10 +1081,12 @@ func (c *Allocator) ListenAndAllocate(updateWorkerCount int, stop <-chan struct{
 
 	// Assuming this is the first run (either entirely, or for a while), list will be nil, and therefore the first
 	// thing that will be done is retrieving the Ready GameSerers and sorting them for this batch via
-	// c.listSortedReadyGameServers(). This list is maintained as we flow through the batch.
+	// c.readyGameServerCache.indexedSortedReadyGameServers(). This list is maintained as we flow through the batch.
+	// It is indexed on the namespace, the Fleet name label and any other indexed labels of the GameServers.
 
-	// We then use findGameServerForAllocation to loop around the sorted list of Ready GameServers to look for matches
-	// against the preferred and required selectors of the GameServerAllocation. If there is an error, we immediately
+	// We then use findGameServerForAllocation to loop around the GameServers in the sorted list that the index says
+	// may match the preferred and required selectors of the GameServerAllocation, to look for matches
+	// against those selectors. If there is an error, we immediately
 	// pass that straight back to the response channel for this GameServerAllocation.
 
 	// Assuming we find a matching GameServer to our GameServerAllocation, we remove it from the list and the backing
@@ -436,35 +1100,55 @@ func (c *Allocator) ListenAndAllocate(updateWorkerCount int, stop <-chan struct{
 	// an already sorted list of GameServers, so we only need to find one that matches our GameServerAllocation
 	// selectors, and put it into updateQueue
 
-	// The tracking of requestCount >= maxBatchBeforeRefresh is necessary, because without it, at high enough load
+	// The tracking of requestCount >= c.batchConfig.MaxBatchBeforeRefresh is necessary, because without it, at high enough load
 	// the list of GameServers that we are using to allocate would never get refreshed (list = nil) with an updated
 	// list of Ready GameServers, and you would eventually never be able to Allocate anything as long as the load
 	// continued.
 
-	var list []*agonesv1.GameServer
+	var list *sortedGameServers
 	requestCount := 0
 
 	for {
 		select {
 		case req := <-c.pendingRequests:
-			// refresh the list after every 100 allocations made in a single batch
+			stats.Record(context.Background(), queueLatency.M(c.clock.Since(req.queued).Seconds()))
+
+			// don't allocate for requests that were cancelled while they were queued
+			if err := req.ctx.Err(); err != nil {
+				req.response <- response{request: req, gs: nil, err: err}
+				continue
+			}
+
+			// refresh the list after every MaxBatchBeforeRefresh allocations made in a single batch
 			requestCount++
-			if requestCount >= maxBatchBeforeRefresh {
+			if requestCount >= c.batchConfig.MaxBatchBeforeRefresh {
 				list = nil
 				requestCount = 0
 			}
 
 			if list == nil {
-				list = c.readyGameServerCache.ListSortedReadyGameServers()
+				list = c.readyGameServerCache.indexedSortedReadyGameServers()
 			}
 
-			gs, index, err := findGameServerForAllocation(req.gsa, list)
+			var gs *agonesv1.GameServer
+			var index int
+			var err error
+			if req.filtered {
+				gs, index, err = findFilteredGameServerForAllocation(req.gsa, req.gameServers, list)
+			} else {
+				gs, index, err = findWithFallbackFleets(req.gsa, list, c.selector.find)
+			}
 			if err != nil {
 				req.response <- response{request: req, gs: nil, err: err}
 				continue
 			}
+			if err := metaPatchConflict(req.gsa.Spec.MetaPatch, gs); err != nil {
+				// the game server is not allocated, so it stays in the list
+				req.response <- response{request: req, gs: nil, err: err}
+				continue
+			}
 			// remove the game server that has been allocated
-			list = append(list[:index], list[index+1:]...)
+			list.remove(index)
 
 			if err := c.readyGameServerCache.RemoveFromReadyGameServer(gs); err != nil {
 				// this seems unlikely, but lets handle it just in case
@@ -472,7 +1156,7 @@ func (c *Allocator) ListenAndAllocate(updateWorkerCount int, stop <-chan struct{
 				continue
 			}
 
-			updateQueue <- response{request: req, gs: gs.DeepCopy(), err: nil}
+			updateQueue <- response{request: req, ready: gs, err: nil}
 
 		case <-stop:
 			return
@@ -480,7 +1164,7 @@ func (c *Allocator) ListenAndAllocate(updateWorkerCount int, stop <-chan struct{
 			list = nil
 			requestCount = 0
 			// slow down cpu churn, and allow items to batch
-			time.Sleep(batchWaitTime)
+			c.clock.Sleep(c.batchConfig.WaitTime)
 		}
 	}
 }
@@ -498,14 +1182,47 @@ func (c *Allocator) allocationUpdateWorkers(workerCount int, stop <-chan struct{
 			for {
 				select {
 				case res := <-updateQueue:
-					gs, err := c.readyGameServerCache.PatchGameServerMetadata(res.request.gsa.Spec.MetaPatch, *res.gs)
+					if err := res.request.ctx.Err(); err != nil {
+						// the request was cancelled while it waited for a worker, so the GameServer is put back
+						c.readyGameServerCache.AddToReadyGameServer(res.ready)
+						res.gs = nil
+						res.err = err
+						res.request.response <- res
+						continue
+					}
+					// the cache only has a projection of the GameServer that was found
+					full, err := c.readyGameServerCache.fullGameServer(res.ready)
+					if err != nil {
+						c.readyGameServerCache.AddToReadyGameServer(res.ready)
+						res.gs = nil
+						res.err = errors.Wrap(err, "error retrieving allocated gameserver")
+						res.request.response <- res
+						continue
+					}
+					res.gs, res.ready = full.DeepCopy(), full
+					if res.request.gsa.Spec.AcknowledgeTimeoutSeconds > 0 {
+						// identify this allocation, for the game server to acknowledge it
+						if res.gs.ObjectMeta.Annotations == nil {
+							res.gs.ObjectMeta.Annotations = map[string]string{}
+						}
+						res.gs.ObjectMeta.Annotations[agonesv1.AllocationAnnotation] = utilrand.String(allocationIDLength)
+					}
+					start := time.Now()
+					write, msg := c.writeAllocatedGameServer, "Allocated"
+					if res.request.gsa.Spec.PreAllocate != nil {
+						write, msg = c.writePreAllocatedGameServer, "Pre-allocated"
+					}
+					gs, status, err := write(res.request.gsa, *res.gs)
+					c.recordUpdate(status, start)
 					if err != nil {
 						// since we could not allocate, we should put it back
 						c.readyGameServerCache.AddToReadyGameServer(gs)
 						res.err = errors.Wrap(err, "error updating allocated gameserver")
 					} else {
 						res.gs = gs
-						c.recorder.Event(res.gs, corev1.EventTypeNormal, string(res.gs.Status.State), "Allocated")
+						if status != writeDeferred {
+							c.recorder.Event(res.gs, corev1.EventTypeNormal, string(res.gs.Status.State), msg)
+						}
 					}
 
 					res.request.response <- res
@@ -527,7 +1244,7 @@ func Retry(backoff wait.Backoff, fn func() error) error {
 		switch {
 		case err == nil:
 			return true, nil
-		case err == ErrNoGameServerReady:
+		case err == ErrNoGameServerReady, err == ErrMetaPatchConflict, err == ErrAcknowledgeTimeout, isRateLimitError(err):
 			return true, err
 		default:
 			lastConflictErr = err
@@ -539,21 +1256,3 @@ func Retry(backoff wait.Backoff, fn func() error) error {
 	}
 	return err
 }
-
-// getRandomlySelectedGS selects a GS from the set of Gameservers randomly. This will reduce the contentions
-func (c *Allocator) getRandomlySelectedGS(gsa *allocationv1.GameServerAllocation, bestGSList []agonesv1.GameServer) *agonesv1.GameServer {
-	seed, err := strconv.Atoi(gsa.ObjectMeta.ResourceVersion)
-	if err != nil {
-		seed = 1234567
-	}
-
-	ln := c.topNGameServerCount
-	if ln > len(bestGSList) {
-		ln = len(bestGSList)
-	}
-
-	startIndex := len(bestGSList) - ln
-	bestGSList = bestGSList[startIndex:]
-	index := rand.New(rand.NewSource(int64(seed))).Intn(ln)
-	return &bestGSList[index]
-}
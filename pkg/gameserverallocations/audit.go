@@ -0,0 +1,251 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"agones.dev/agones/pkg/apis"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// AuditSinkNone disables the allocation audit log
+	AuditSinkNone = "none"
+	// AuditSinkStdout writes the allocation audit records to stdout, as JSON lines
+	AuditSinkStdout = "stdout"
+	// AuditSinkFile appends the allocation audit records to a file, as JSON lines
+	AuditSinkFile = "file"
+	// AuditSinkWebhook posts each allocation audit record to a webhook, as JSON
+	AuditSinkWebhook = "webhook"
+
+	// auditQueueSize is the number of audit records that can wait to be written to the sink,
+	// after which records are dropped rather than slowing down allocations
+	auditQueueSize = 1000
+)
+
+// AuditConfig configures the audit log of allocations
+type AuditConfig struct {
+	// Sink is one of AuditSinkNone, AuditSinkStdout, AuditSinkFile or AuditSinkWebhook
+	Sink string
+	// File is the path of the file the records are appended to, for AuditSinkFile
+	File string
+	// WebhookURL is the url the records are posted to, for AuditSinkWebhook
+	WebhookURL string
+	// WebhookTimeout is the timeout of a request to the webhook
+	WebhookTimeout time.Duration
+}
+
+// DefaultAuditConfig is the default configuration of the allocation audit log, which is disabled
+var DefaultAuditConfig = AuditConfig{
+	Sink:           AuditSinkNone,
+	WebhookTimeout: 2 * time.Second,
+}
+
+// Validate returns an error if the AuditConfig is invalid
+func (a AuditConfig) Validate() error {
+	switch a.Sink {
+	case AuditSinkNone, AuditSinkStdout:
+	case AuditSinkFile:
+		if a.File == "" {
+			return errors.New("allocation audit file must be set for the file sink")
+		}
+	case AuditSinkWebhook:
+		u, err := url.Parse(a.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("allocation audit webhook url %q must be an absolute http or https url", a.WebhookURL)
+		}
+		if a.WebhookTimeout <= 0 {
+			return errors.New("allocation audit webhook timeout must be greater than 0")
+		}
+	default:
+		return errors.Errorf("allocation audit sink must be one of %s, %s, %s or %s", AuditSinkNone, AuditSinkStdout, AuditSinkFile, AuditSinkWebhook)
+	}
+	return nil
+}
+
+// AuditRecord is the audit record of a GameServerAllocation request
+type AuditRecord struct {
+	Time time.Time `json:"time"`
+	// User is the user that made the request, as authenticated by the Kubernetes API server
+	User string `json:"user,omitempty"`
+	// Groups are the groups of the User
+	Groups []string `json:"groups,omitempty"`
+	// RemoteAddr is the address the request came from
+	RemoteAddr string                  `json:"remoteAddr,omitempty"`
+	Namespace  string                  `json:"namespace"`
+	Required   metav1.LabelSelector    `json:"required"`
+	Preferred  []metav1.LabelSelector  `json:"preferred,omitempty"`
+	Scheduling apis.SchedulingStrategy `json:"scheduling,omitempty"`
+	// MultiCluster is whether the request was for a multi-cluster allocation
+	MultiCluster bool `json:"multiCluster,omitempty"`
+	// Result is the state of the GameServerAllocation, e.g. Allocated or UnAllocated,
+	// or Failure if the request failed
+	Result string `json:"result"`
+	// Reason is the reason of the result, if any
+	Reason         string `json:"reason,omitempty"`
+	GameServerName string `json:"gameServerName,omitempty"`
+	NodeName       string `json:"nodeName,omitempty"`
+	// Error is the error of a failed request
+	Error string `json:"error,omitempty"`
+	// LatencySeconds is how long the request took
+	LatencySeconds float64 `json:"latencySeconds"`
+}
+
+// AuditSink writes the audit records of allocations
+type AuditSink interface {
+	Write(record *AuditRecord) error
+}
+
+// NewAuditSink returns the AuditSink for the config, or nil if the audit log is disabled
+func NewAuditSink(config AuditConfig) (AuditSink, error) {
+	switch config.Sink {
+	case AuditSinkStdout:
+		return &jsonAuditSink{writer: os.Stdout}, nil
+	case AuditSinkFile:
+		f, err := os.OpenFile(config.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not open allocation audit file %s", config.File)
+		}
+		return &jsonAuditSink{writer: f}, nil
+	case AuditSinkWebhook:
+		return &webhookAuditSink{url: config.WebhookURL, client: &http.Client{Timeout: config.WebhookTimeout}}, nil
+	}
+	return nil, nil
+}
+
+// jsonAuditSink writes each audit record as a line of JSON
+type jsonAuditSink struct {
+	mutex  sync.Mutex
+	writer io.Writer
+}
+
+// Write writes the record as a line of JSON
+func (s *jsonAuditSink) Write(record *AuditRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return json.NewEncoder(s.writer).Encode(record)
+}
+
+// webhookAuditSink posts each audit record to a webhook
+type webhookAuditSink struct {
+	url    string
+	client *http.Client
+}
+
+// Write posts the record as JSON, and returns an error if the webhook does not respond with a 2xx status code
+func (s *webhookAuditSink) Write(record *AuditRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return errors.Wrap(err, "could not marshal allocation audit record")
+	}
+	res, err := s.client.Post(s.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "allocation audit webhook request failed")
+	}
+	defer res.Body.Close() // nolint: errcheck
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.Errorf("bad status code %d from the allocation audit webhook", res.StatusCode)
+	}
+	return nil
+}
+
+// auditor queues the audit records of allocations, and writes them to the sink in the background,
+// so that a slow sink does not slow down allocations
+type auditor struct {
+	sink    AuditSink
+	records chan *AuditRecord
+	logger  *logrus.Entry
+}
+
+// newAuditor returns an auditor that writes to the sink
+func newAuditor(sink AuditSink, logger *logrus.Entry) *auditor {
+	return &auditor{sink: sink, records: make(chan *AuditRecord, auditQueueSize), logger: logger}
+}
+
+// audit queues the record to be written. The record is dropped if the queue is full.
+func (a *auditor) audit(record *AuditRecord) {
+	select {
+	case a.records <- record:
+	default:
+		a.logger.WithField("record", record).Error("Dropping allocation audit record, as the queue is full")
+		stats.Record(context.Background(), auditDroppedStats.M(1))
+	}
+}
+
+// run writes the queued records to the sink. Will block until stop is closed.
+func (a *auditor) run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case record := <-a.records:
+			if err := a.sink.Write(record); err != nil {
+				a.logger.WithError(err).WithField("record", record).Error("Could not write allocation audit record")
+				stats.Record(context.Background(), auditDroppedStats.M(1))
+			}
+		}
+	}
+}
+
+// newAuditRecord returns the audit record of the allocation request made by the http request,
+// with the result, or the error, of the request
+func newAuditRecord(r *http.Request, gsa *allocationv1.GameServerAllocation, result k8sruntime.Object, err error, start time.Time) *AuditRecord {
+	record := &AuditRecord{
+		Time: start.UTC(),
+		// set by the Kubernetes API server when it proxies the request to the aggregated allocation API
+		User:           r.Header.Get("X-Remote-User"),
+		Groups:         r.Header["X-Remote-Group"],
+		RemoteAddr:     r.RemoteAddr,
+		LatencySeconds: time.Since(start).Seconds(),
+	}
+	if gsa != nil {
+		record.Namespace = gsa.ObjectMeta.Namespace
+		record.Required = gsa.Spec.Required
+		record.Preferred = gsa.Spec.Preferred
+		record.Scheduling = gsa.Spec.Scheduling
+		record.MultiCluster = gsa.Spec.MultiClusterSetting.Enabled
+	}
+
+	switch out := result.(type) {
+	case *allocationv1.GameServerAllocation:
+		record.Result = string(out.Status.State)
+		record.Reason = string(out.Status.Reason)
+		record.GameServerName = out.Status.GameServerName
+		record.NodeName = out.Status.NodeName
+	case *metav1.Status:
+		record.Result = metav1.StatusFailure
+		record.Reason = string(out.Reason)
+		record.Error = out.Message
+	}
+	if err != nil {
+		record.Result = metav1.StatusFailure
+		record.Error = err.Error()
+	}
+	return record
+}
@@ -0,0 +1,239 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAuditConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, DefaultAuditConfig.Validate())
+	assert.NoError(t, AuditConfig{Sink: AuditSinkStdout}.Validate())
+	assert.NoError(t, AuditConfig{Sink: AuditSinkFile, File: "/home/agones/logs/audit.log"}.Validate())
+	assert.NoError(t, AuditConfig{Sink: AuditSinkWebhook, WebhookURL: "https://audit.example.com", WebhookTimeout: time.Second}.Validate())
+	assert.Error(t, AuditConfig{}.Validate())
+	assert.Error(t, AuditConfig{Sink: "syslog"}.Validate())
+	assert.Error(t, AuditConfig{Sink: AuditSinkFile}.Validate())
+	assert.Error(t, AuditConfig{Sink: AuditSinkWebhook, WebhookURL: "audit.example.com", WebhookTimeout: time.Second}.Validate())
+	assert.Error(t, AuditConfig{Sink: AuditSinkWebhook, WebhookURL: "https://audit.example.com"}.Validate())
+}
+
+func TestNewAuditSink(t *testing.T) {
+	t.Parallel()
+
+	sink, err := NewAuditSink(DefaultAuditConfig)
+	assert.NoError(t, err)
+	assert.Nil(t, sink)
+
+	t.Run("file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "audit")
+		assert.NoError(t, err)
+		defer os.RemoveAll(dir) // nolint: errcheck
+		file := filepath.Join(dir, "audit.log")
+
+		sink, err := NewAuditSink(AuditConfig{Sink: AuditSinkFile, File: file})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, sink.Write(&AuditRecord{Namespace: defaultNs, Result: "Allocated", GameServerName: "gs1"}))
+		assert.NoError(t, sink.Write(&AuditRecord{Namespace: defaultNs, Result: "UnAllocated"}))
+
+		data, err := ioutil.ReadFile(file)
+		assert.NoError(t, err)
+		lines := bytes.Split(bytes.TrimSpace(data), []byte("\n"))
+		if assert.Len(t, lines, 2) {
+			record := AuditRecord{}
+			assert.NoError(t, json.Unmarshal(lines[0], &record))
+			assert.Equal(t, "gs1", record.GameServerName)
+		}
+
+		_, err = NewAuditSink(AuditConfig{Sink: AuditSinkFile, File: filepath.Join(dir, "missing", "audit.log")})
+		assert.Error(t, err)
+	})
+
+	t.Run("webhook", func(t *testing.T) {
+		var received AuditRecord
+		status := http.StatusOK
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method)
+			assert.NoError(t, json.NewDecoder(r.Body).Decode(&received))
+			w.WriteHeader(status)
+		}))
+		defer ts.Close()
+
+		sink, err := NewAuditSink(AuditConfig{Sink: AuditSinkWebhook, WebhookURL: ts.URL, WebhookTimeout: time.Second})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.NoError(t, sink.Write(&AuditRecord{User: "matchmaker", Result: "Allocated"}))
+		assert.Equal(t, "matchmaker", received.User)
+
+		status = http.StatusInternalServerError
+		assert.EqualError(t, sink.Write(&AuditRecord{}), "bad status code 500 from the allocation audit webhook")
+	})
+}
+
+func TestNewAuditRecord(t *testing.T) {
+	t.Parallel()
+
+	r, err := http.NewRequest(http.MethodPost, "/", nil)
+	assert.NoError(t, err)
+	r.Header.Set("X-Remote-User", "system:serviceaccount:default:matchmaker")
+	r.Header.Add("X-Remote-Group", "system:serviceaccounts")
+	r.Header.Add("X-Remote-Group", "system:authenticated")
+	r.RemoteAddr = "10.0.0.1:1234"
+
+	gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:   metav1.LabelSelector{MatchLabels: map[string]string{"mode": "deathmatch"}},
+			Preferred:  []metav1.LabelSelector{{MatchLabels: map[string]string{"map": "searide"}}},
+			Scheduling: apis.Packed,
+		}}
+	start := time.Now().Add(-time.Second)
+
+	result := gsa.DeepCopy()
+	result.Status = allocationv1.GameServerAllocationStatus{State: allocationv1.GameServerAllocationAllocated, GameServerName: "gs1", NodeName: "node1"}
+	record := newAuditRecord(r, gsa, result, nil, start)
+	assert.Equal(t, start.UTC(), record.Time)
+	assert.Equal(t, "system:serviceaccount:default:matchmaker", record.User)
+	assert.Equal(t, []string{"system:serviceaccounts", "system:authenticated"}, record.Groups)
+	assert.Equal(t, "10.0.0.1:1234", record.RemoteAddr)
+	assert.Equal(t, defaultNs, record.Namespace)
+	assert.Equal(t, gsa.Spec.Required, record.Required)
+	assert.Equal(t, gsa.Spec.Preferred, record.Preferred)
+	assert.Equal(t, apis.Packed, record.Scheduling)
+	assert.Equal(t, "Allocated", record.Result)
+	assert.Equal(t, "gs1", record.GameServerName)
+	assert.Equal(t, "node1", record.NodeName)
+	assert.True(t, record.LatencySeconds >= 1)
+
+	status := &metav1.Status{Status: metav1.StatusFailure, Reason: metav1.StatusReasonTimeout, Message: "allocation timed out"}
+	record = newAuditRecord(r, gsa, status, nil, start)
+	assert.Equal(t, metav1.StatusFailure, record.Result)
+	assert.Equal(t, string(metav1.StatusReasonTimeout), record.Reason)
+	assert.Equal(t, "allocation timed out", record.Error)
+
+	record = newAuditRecord(r, gsa, nil, errors.New("boom"), start)
+	assert.Equal(t, metav1.StatusFailure, record.Result)
+	assert.Equal(t, "boom", record.Error)
+}
+
+// fakeAuditSink records the audit records written to it
+type fakeAuditSink struct {
+	records chan *AuditRecord
+}
+
+func (s *fakeAuditSink) Write(record *AuditRecord) error {
+	s.records <- record
+	return nil
+}
+
+func TestControllerAllocationAudit(t *testing.T) {
+	t.Parallel()
+
+	f, _, gsList := defaultFixtures(1)
+	sink := &fakeAuditSink{records: make(chan *AuditRecord, 10)}
+	c, m := newFakeController()
+	c.auditor = newAuditor(sink, c.baseLogger)
+
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, action.(k8stesting.UpdateAction).GetObject(), nil
+	})
+
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	allocate := func() *AuditRecord {
+		gsa := &allocationv1.GameServerAllocation{Spec: allocationv1.GameServerAllocationSpec{
+			Required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}},
+		}}
+		buf := bytes.NewBuffer(nil)
+		assert.NoError(t, json.NewEncoder(buf).Encode(gsa))
+		r, err := http.NewRequest(http.MethodPost, "/", buf)
+		assert.NoError(t, err)
+		r.Header.Set("Content-Type", k8sruntime.ContentTypeJSON)
+		r.Header.Set("X-Remote-User", "matchmaker")
+		assert.NoError(t, c.processAllocationRequest(httptest.NewRecorder(), r, defaultNs, stop))
+
+		select {
+		case record := <-sink.records:
+			return record
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "timeout waiting for the audit record")
+		}
+		return nil
+	}
+
+	record := allocate()
+	assert.Equal(t, "matchmaker", record.User)
+	assert.Equal(t, defaultNs, record.Namespace)
+	assert.Equal(t, "Allocated", record.Result)
+	assert.Equal(t, gsList[0].ObjectMeta.Name, record.GameServerName)
+
+	record = allocate()
+	assert.Equal(t, "UnAllocated", record.Result)
+	assert.Empty(t, record.GameServerName)
+}
+
+func TestAuditorDropsWhenFull(t *testing.T) {
+	t.Parallel()
+
+	sink := &fakeAuditSink{records: make(chan *AuditRecord, 1)}
+	c, _ := newFakeController()
+	a := newAuditor(sink, c.baseLogger)
+	a.records = make(chan *AuditRecord, 1)
+
+	a.audit(&AuditRecord{GameServerName: "gs1"})
+	// dropped, rather than blocking the allocation
+	a.audit(&AuditRecord{GameServerName: "gs2"})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go a.run(stop)
+
+	select {
+	case record := <-sink.records:
+		assert.Equal(t, "gs1", record.GameServerName)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "timeout waiting for the audit record")
+	}
+	assert.Empty(t, sink.records)
+}
@@ -18,22 +18,68 @@ import (
 	"sync"
 
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-// gameserver cache to keep the Ready state gameserver.
+// gameserver cache to keep the Ready state gameserver, as a projection of the parts that
+// finding a GameServer for an allocation looks at.
 type gameServerCacheEntry struct {
 	mu    sync.RWMutex
 	cache map[string]*agonesv1.GameServer
 }
 
-// Store saves the data in the cache.
+// Store saves the projection of the GameServer in the cache.
 func (e *gameServerCacheEntry) Store(key string, gs *agonesv1.GameServer) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 	if e.cache == nil {
 		e.cache = map[string]*agonesv1.GameServer{}
 	}
-	e.cache[key] = gs.DeepCopy()
+	e.cache[key] = readyProjection(gs)
+}
+
+// readyProjection returns a copy of the parts of a GameServer that finding a GameServer for an allocation looks at,
+// leaving out its spec, with the Pod template, and its conditions. As the cache holds every Ready GameServer, this
+// keeps its memory from growing with the size of the Pod templates. The full GameServer is fetched for the one
+// that is allocated, see ReadyGameServerCache.fullGameServer.
+func readyProjection(gs *agonesv1.GameServer) *agonesv1.GameServer {
+	p := &agonesv1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              gs.ObjectMeta.Name,
+			Namespace:         gs.ObjectMeta.Namespace,
+			UID:               gs.ObjectMeta.UID,
+			ResourceVersion:   gs.ObjectMeta.ResourceVersion,
+			CreationTimestamp: gs.ObjectMeta.CreationTimestamp,
+			DeletionTimestamp: gs.ObjectMeta.DeletionTimestamp.DeepCopy(),
+			Labels:            copyMetadata(gs.ObjectMeta.Labels),
+			Annotations:       copyMetadata(gs.ObjectMeta.Annotations),
+		},
+		Status: agonesv1.GameServerStatus{
+			State:    gs.Status.State,
+			Address:  gs.Status.Address,
+			NodeName: gs.Status.NodeName,
+		},
+	}
+	if gs.Status.Ports != nil {
+		p.Status.Ports = append([]agonesv1.GameServerStatusPort(nil), gs.Status.Ports...)
+	}
+	if gs.Status.Addresses != nil {
+		p.Status.Addresses = append([]corev1.NodeAddress(nil), gs.Status.Addresses...)
+	}
+	return p
+}
+
+// copyMetadata returns a copy of labels or annotations
+func copyMetadata(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
 }
 
 // Delete deletes the data. If it exists returns true.
@@ -15,11 +15,15 @@
 package gameserverallocations
 
 import (
+	"fmt"
 	"testing"
 
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 func TestGameServerCacheEntry(t *testing.T) {
@@ -58,3 +62,103 @@ func TestGameServerCacheEntry(t *testing.T) {
 	assert.Nil(t, gs)
 	assert.False(t, ok)
 }
+
+func TestReadyProjection(t *testing.T) {
+	t.Parallel()
+
+	gs := readyFixture("gs1", "node1")
+	p := readyProjection(gs)
+
+	assert.Equal(t, gs.ObjectMeta.Name, p.ObjectMeta.Name)
+	assert.Equal(t, gs.ObjectMeta.UID, p.ObjectMeta.UID)
+	assert.Equal(t, gs.ObjectMeta.ResourceVersion, p.ObjectMeta.ResourceVersion)
+	assert.Equal(t, gs.ObjectMeta.Labels, p.ObjectMeta.Labels)
+	assert.Equal(t, gs.ObjectMeta.Annotations, p.ObjectMeta.Annotations)
+	assert.Equal(t, gs.Status.NodeName, p.Status.NodeName)
+	assert.Equal(t, gs.Status.Ports, p.Status.Ports)
+	assert.Equal(t, gs.Status.Addresses, p.Status.Addresses)
+	assert.Equal(t, agonesv1.GameServerSpec{}, p.Spec)
+	assert.Empty(t, p.Status.Conditions)
+
+	// the projection does not share the metadata of the GameServer
+	p.ObjectMeta.Labels["mode"] = "ctf"
+	assert.Equal(t, "deathmatch", gs.ObjectMeta.Labels["mode"])
+}
+
+// readyFixture returns a Ready GameServer with a Pod template, like those of a Fleet
+func readyFixture(name, node string) *agonesv1.GameServer {
+	gs := &agonesv1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs, UID: types.UID(name), ResourceVersion: "1",
+			Labels:      map[string]string{agonesv1.FleetNameLabel: "fleet", "mode": "deathmatch"},
+			Annotations: map[string]string{"map": "dust"}},
+		Spec: agonesv1.GameServerSpec{
+			Ports: []agonesv1.GameServerPort{{Name: "default", ContainerPort: 7777}},
+			Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name:  "game-server",
+				Image: "gcr.io/agones-images/udp-server:0.17",
+				Env:   []corev1.EnvVar{{Name: "MODE", Value: "deathmatch"}, {Name: "MAX_PLAYERS", Value: "16"}},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+					Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("20m"), corev1.ResourceMemory: resource.MustParse("64Mi")},
+				},
+			}}}},
+		},
+		Status: agonesv1.GameServerStatus{
+			State:   agonesv1.GameServerStateReady,
+			Address: "10.0.0.1",
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeExternalIP, Address: "10.0.0.1"},
+				{Type: corev1.NodeExternalIP, Address: "2001:db8::1"},
+			},
+			NodeName: node,
+			Ports:    []agonesv1.GameServerStatusPort{{Name: "default", Port: 7001}},
+			Conditions: []agonesv1.GameServerCondition{
+				{Type: agonesv1.GameServerConditionSDKConnected, Status: corev1.ConditionTrue, Reason: "Connected"},
+			},
+		},
+	}
+	gs.ApplyDefaults()
+	return gs
+}
+
+// BenchmarkGameServerCacheEntryStore compares storing full copies of the GameServers in the cache with
+// storing their projections. The memory held by the cache is reported as B/op.
+func BenchmarkGameServerCacheEntryStore(b *testing.B) {
+	gs := readyFixture("gs1", "node1")
+	b.Run("full", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = gs.DeepCopy()
+		}
+	})
+	b.Run("projection", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = readyProjection(gs)
+		}
+	})
+}
+
+// BenchmarkListSortedReadyGameServers compares sorting the full GameServers with sorting their projections,
+// which fit more of the GameServers into the CPU caches
+func BenchmarkListSortedReadyGameServers(b *testing.B) {
+	const gameServers, nodes = 10000, 100
+	for _, mode := range []string{"full", "projection"} {
+		b.Run(mode, func(b *testing.B) {
+			c, _ := newFakeController()
+			rc := c.allocator.readyGameServerCache
+			rc.readyGameServers.cache = make(map[string]*agonesv1.GameServer, gameServers)
+			for i := 0; i < gameServers; i++ {
+				gs := readyFixture(fmt.Sprintf("gs%d", i), fmt.Sprintf("node%d", i%nodes))
+				if mode == "projection" {
+					gs = readyProjection(gs)
+				}
+				rc.readyGameServers.cache[gs.ObjectMeta.Name] = gs
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rc.ListSortedReadyGameServers()
+			}
+		})
+	}
+}
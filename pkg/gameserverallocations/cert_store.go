@@ -0,0 +1,112 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"sync"
+
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+)
+
+// remoteCerts are the client certificate and the CA bundle loaded from a secret,
+// to make remote calls to the allocator service of another cluster
+type remoteCerts struct {
+	resourceVersion string
+	clientCert      tls.Certificate
+	// rootCAs is nil if the secret has no CA bundle, in which case the host's root CAs are used
+	rootCAs *x509.CertPool
+}
+
+// certStore caches the certificates loaded from secrets, so that they are only parsed once per
+// version of the secret. Entries are replaced when a newer version of the secret is seen, and removed
+// when the secret informer reports that the secret changed or was deleted.
+type certStore struct {
+	mutex        sync.RWMutex
+	secretLister corev1lister.SecretLister
+	certs        map[string]*remoteCerts
+}
+
+// newCertStore returns an empty certStore that loads certificates from secrets in the lister
+func newCertStore(secretLister corev1lister.SecretLister) *certStore {
+	return &certStore{secretLister: secretLister, certs: map[string]*remoteCerts{}}
+}
+
+// get returns the certificates in the current version of the secret
+func (cs *certStore) get(namespace, secretName string) (*remoteCerts, error) {
+	secret, err := cs.secretLister.Secrets(namespace).Get(secretName)
+	if err != nil {
+		return nil, err
+	}
+
+	key := namespace + "/" + secretName
+	cs.mutex.RLock()
+	certs, ok := cs.certs[key]
+	cs.mutex.RUnlock()
+	if ok && certs.resourceVersion == secret.ObjectMeta.ResourceVersion {
+		return certs, nil
+	}
+
+	certs, err = loadRemoteCerts(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.certs[key] = certs
+	return certs, nil
+}
+
+// invalidate removes the certificates of the secret from the store
+func (cs *certStore) invalidate(namespace, secretName string) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	delete(cs.certs, namespace+"/"+secretName)
+}
+
+// loadRemoteCerts parses the client certificate key pair, and the CA bundle if there is one, in the secret
+func loadRemoteCerts(secret *corev1.Secret) (*remoteCerts, error) {
+	if len(secret.Data) == 0 {
+		return nil, fmt.Errorf("secert %s does not have data", secret.ObjectMeta.Name)
+	}
+
+	clientCert := secret.Data[secretClientCertName]
+	clientKey := secret.Data[secretClientKeyName]
+	if clientCert == nil || clientKey == nil {
+		return nil, fmt.Errorf("missing client certificate key pair in secret %s", secret.ObjectMeta.Name)
+	}
+
+	cert, err := tls.X509KeyPair(clientCert, clientKey)
+	if err != nil {
+		return nil, err
+	}
+	certs := &remoteCerts{resourceVersion: secret.ObjectMeta.ResourceVersion, clientCert: cert}
+
+	if caCert := secret.Data[secretCaCertName]; len(caCert) != 0 {
+		// Load CA cert, if provided and trust the server certificate.
+		// This is required for self-signed certs.
+		certs.rootCAs = x509.NewCertPool()
+		if !certs.rootCAs.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("only PEM format is accepted for server CA")
+		}
+	}
+
+	return certs, nil
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1lister "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCertStore(t *testing.T) {
+	t.Parallel()
+
+	const secretName = "secret-name"
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	cs := newCertStore(corev1lister.NewSecretLister(indexer))
+
+	secret := getTestSecret(secretName, clientCert).Items[0]
+	secret.ObjectMeta.ResourceVersion = "1"
+	assert.NoError(t, indexer.Add(secret.DeepCopy()))
+
+	certs1, err := cs.get(defaultNs, secretName)
+	assert.NoError(t, err)
+	assert.NotNil(t, certs1.rootCAs)
+	assert.Len(t, certs1.clientCert.Certificate, 1)
+
+	// the same version of the secret is only loaded once
+	certs2, err := cs.get(defaultNs, secretName)
+	assert.NoError(t, err)
+	assert.True(t, certs1 == certs2, "certs should be cached")
+
+	// a new version of the secret is reloaded
+	secret.ObjectMeta.ResourceVersion = "2"
+	delete(secret.Data, secretCaCertName)
+	assert.NoError(t, indexer.Update(secret.DeepCopy()))
+	certs3, err := cs.get(defaultNs, secretName)
+	assert.NoError(t, err)
+	assert.False(t, certs1 == certs3, "certs should be reloaded")
+	assert.Nil(t, certs3.rootCAs)
+
+	cs.invalidate(defaultNs, secretName)
+	assert.Empty(t, cs.certs)
+
+	// invalid secrets
+	secret.ObjectMeta.ResourceVersion = "3"
+	delete(secret.Data, secretClientKeyName)
+	assert.NoError(t, indexer.Update(secret.DeepCopy()))
+	_, err = cs.get(defaultNs, secretName)
+	assert.EqualError(t, err, "missing client certificate key pair in secret "+secretName)
+
+	secret.ObjectMeta.ResourceVersion = "4"
+	secret.Data = map[string][]byte{
+		secretClientCertName: clientCert,
+		secretClientKeyName:  clientKey,
+		secretCaCertName:     []byte("not a pem"),
+	}
+	assert.NoError(t, indexer.Update(secret.DeepCopy()))
+	_, err = cs.get(defaultNs, secretName)
+	assert.EqualError(t, err, "only PEM format is accepted for server CA")
+
+	_, err = cs.get(defaultNs, "missing")
+	assert.Error(t, err)
+}
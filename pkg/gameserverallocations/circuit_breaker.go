@@ -0,0 +1,90 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"sync"
+	"time"
+)
+
+// circuitBreaker tracks consecutive failures of remote allocation endpoints, and takes
+// an endpoint out of rotation for a while once it has failed too many times in a row.
+type circuitBreaker struct {
+	mutex            sync.Mutex
+	failureThreshold int
+	openDuration     time.Duration
+	endpoints        map[string]*endpointState
+	now              func() time.Time
+}
+
+// endpointState is the failure state of a single endpoint
+type endpointState struct {
+	failures  int
+	openUntil time.Time
+}
+
+// newCircuitBreaker returns a circuitBreaker that opens after failureThreshold consecutive
+// failures of an endpoint, and keeps it open for openDuration.
+func newCircuitBreaker(failureThreshold int, openDuration time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		failureThreshold: failureThreshold,
+		openDuration:     openDuration,
+		endpoints:        map[string]*endpointState{},
+		now:              time.Now,
+	}
+}
+
+// allow returns true if a request can be sent to the endpoint.
+// Once the open duration of a failing endpoint has passed, a single trial request is allowed
+// through, and the endpoint stays open for another period unless that request succeeds.
+func (cb *circuitBreaker) allow(endpoint string) bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	s, ok := cb.endpoints[endpoint]
+	if !ok || s.failures < cb.failureThreshold {
+		return true
+	}
+	now := cb.now()
+	if now.Before(s.openUntil) {
+		return false
+	}
+	s.openUntil = now.Add(cb.openDuration)
+	return true
+}
+
+// success records a successful request to the endpoint, closing its circuit
+func (cb *circuitBreaker) success(endpoint string) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	delete(cb.endpoints, endpoint)
+}
+
+// failure records a failed request to the endpoint, opening its circuit
+// when the failure threshold is reached
+func (cb *circuitBreaker) failure(endpoint string) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+
+	s, ok := cb.endpoints[endpoint]
+	if !ok {
+		s = &endpointState{}
+		cb.endpoints[endpoint] = s
+	}
+	s.failures++
+	if s.failures >= cb.failureThreshold {
+		s.openUntil = cb.now().Add(cb.openDuration)
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	cb := newCircuitBreaker(3, time.Minute)
+	cb.now = func() time.Time { return now }
+
+	// closed until the threshold is reached
+	for i := 0; i < 2; i++ {
+		cb.failure("a")
+		assert.True(t, cb.allow("a"))
+	}
+	cb.failure("a")
+	assert.False(t, cb.allow("a"))
+	// other endpoints are not affected
+	assert.True(t, cb.allow("b"))
+
+	// a single trial request is allowed once the open duration has passed
+	now = now.Add(time.Minute)
+	assert.True(t, cb.allow("a"))
+	assert.False(t, cb.allow("a"))
+
+	// a failed trial keeps it open for another period
+	cb.failure("a")
+	assert.False(t, cb.allow("a"))
+	now = now.Add(30 * time.Second)
+	assert.False(t, cb.allow("a"))
+	now = now.Add(30 * time.Second)
+	assert.True(t, cb.allow("a"))
+
+	// a successful trial closes it
+	cb.success("a")
+	assert.True(t, cb.allow("a"))
+	assert.True(t, cb.allow("a"))
+
+	// a success resets the consecutive failures
+	cb.failure("a")
+	cb.failure("a")
+	cb.success("a")
+	cb.failure("a")
+	assert.True(t, cb.allow("a"))
+}
@@ -16,21 +16,27 @@ package gameserverallocations
 
 import (
 	"context"
+	"encoding/json"
 	"io/ioutil"
 	"net/http"
 	"time"
 
+	"agones.dev/agones/pkg/apis"
 	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	multiclusterv1alpha1 "agones.dev/agones/pkg/apis/multicluster/v1alpha1"
 	"agones.dev/agones/pkg/client/clientset/versioned"
 	"agones.dev/agones/pkg/client/informers/externalversions"
 	"agones.dev/agones/pkg/gameservers"
 	"agones.dev/agones/pkg/util/apiserver"
 	"agones.dev/agones/pkg/util/https"
 	"agones.dev/agones/pkg/util/runtime"
+	"agones.dev/agones/pkg/util/webhooks"
+	"agones.dev/agones/pkg/util/workerqueue"
 	"github.com/heptiolabs/healthcheck"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"go.opencensus.io/tag"
+	admv1beta1 "k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
@@ -48,35 +54,119 @@ type Controller struct {
 	baseLogger *logrus.Entry
 	recorder   record.EventRecorder
 	allocator  *Allocator
+	// auditor writes the audit records of allocations, or is nil if the audit log is disabled
+	auditor *auditor
+	// defaultScheduling is the scheduling strategy of GameServerAllocations that don't set one
+	defaultScheduling apis.SchedulingStrategy
+}
+
+// Config configures how GameServers are allocated
+type Config struct {
+	// Batch configures how allocation requests are batched
+	Batch BatchConfig
+	// FilterWebhook configures the webhook that can veto allocation candidates
+	FilterWebhook FilterWebhookConfig
+	// RateLimit configures the rate limits of allocation requests
+	RateLimit RateLimitConfig
+	// DegradedMode configures how allocations behave when the api server is unavailable
+	DegradedMode DegradedModeConfig
+	// Selection configures how a GameServer is selected from the candidates
+	Selection SelectionConfig
+	// AuditSink receives the audit records of allocations, or is nil if the audit log is disabled
+	AuditSink AuditSink
+	// IndexLabels are the labels by which Ready GameServers are indexed for label selectors
+	IndexLabels []string
+	// ProtectedMetadataPrefixes are the label and annotation prefixes that allocation metadata can't set
+	ProtectedMetadataPrefixes []string
+	// SelectorLint reports allocations whose required selector matches no GameServer or Fleet as
+	// SelectorMatchesNothing, rather than NoCapacity
+	SelectorLint bool
+	// DefaultScheduling is the scheduling strategy of GameServerAllocations that don't set one
+	DefaultScheduling apis.SchedulingStrategy
+	// RemoteAllocationHedgeDelay is how long to wait for an allocation endpoint of a remote cluster
+	// before also sending the request to the next one, or 0 to disable hedging
+	RemoteAllocationHedgeDelay time.Duration
+	// RemoteAllocationTransport is either RemoteAllocationTransportHTTP or RemoteAllocationTransportGRPC
+	RemoteAllocationTransport string
 }
 
 // NewController returns a controller for a GameServerAllocation
 func NewController(apiServer *apiserver.APIServer,
+	wh *webhooks.WebHook,
 	health healthcheck.Handler,
 	counter *gameservers.PerNodeCounter,
+	preemptions *gameservers.NodePreemptions,
+	maintenance *gameservers.NodeMaintenance,
 	kubeClient kubernetes.Interface,
 	kubeInformerFactory informers.SharedInformerFactory,
 	agonesClient versioned.Interface,
 	agonesInformerFactory externalversions.SharedInformerFactory,
+	config Config,
 ) *Controller {
 	c := &Controller{
 		api: apiServer,
 		allocator: NewAllocator(
 			agonesInformerFactory.Multicluster().V1alpha1().GameServerAllocationPolicies(),
 			kubeInformerFactory.Core().V1().Secrets(),
+			agonesInformerFactory.Agones().V1().Fleets(),
 			kubeClient,
-			NewReadyGameServerCache(agonesInformerFactory.Agones().V1().GameServers(), agonesClient.AgonesV1(), counter, health)),
+			NewReadyGameServerCache(agonesInformerFactory.Agones().V1().GameServers(), agonesClient.AgonesV1(), counter, preemptions, maintenance, health, config.IndexLabels),
+			config),
+		defaultScheduling: config.DefaultScheduling,
 	}
 	c.baseLogger = runtime.NewLoggerWithType(c)
+	if config.AuditSink != nil {
+		c.auditor = newAuditor(config.AuditSink, c.baseLogger)
+	}
 
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(c.baseLogger.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
 	c.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "GameServerAllocation-controller"})
 
+	kind := multiclusterv1alpha1.Kind("GameServerAllocationPolicy")
+	wh.AddHandler("/validate", kind, admv1beta1.Create, c.policyValidationHandler)
+	wh.AddHandler("/validate", kind, admv1beta1.Update, c.policyValidationHandler)
+
 	return c
 }
 
+// policyValidationHandler will intercept when a GameServerAllocationPolicy is created or updated,
+// and validate its settings, so that invalid policies are rejected rather than failing at allocation time.
+func (c *Controller) policyValidationHandler(review admv1beta1.AdmissionReview) (admv1beta1.AdmissionReview, error) {
+	obj := review.Request.Object
+	policy := &multiclusterv1alpha1.GameServerAllocationPolicy{}
+	if err := json.Unmarshal(obj.Raw, policy); err != nil {
+		c.baseLogger.WithField("review", review).WithError(err).Info("policyValidationHandler")
+		return review, errors.Wrapf(err, "error unmarshalling original GameServerAllocationPolicy json: %s", obj.Raw)
+	}
+
+	causes, _ := policy.Validate()
+	refCauses, err := c.allocator.validatePolicyReferences(review.Request.Namespace, policy)
+	if err != nil {
+		return review, err
+	}
+	causes = append(causes, refCauses...)
+
+	if len(causes) != 0 {
+		review.Response.Allowed = false
+		details := metav1.StatusDetails{
+			Name:   review.Request.Name,
+			Group:  review.Request.Kind.Group,
+			Kind:   review.Request.Kind.Kind,
+			Causes: causes,
+		}
+		review.Response.Result = &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Message: "GameServerAllocationPolicy is invalid",
+			Reason:  metav1.StatusReasonInvalid,
+			Details: &details,
+		}
+	}
+
+	return review, nil
+}
+
 // registers the api resource for gameserverallocation
 func (c *Controller) registerAPIResource(stop <-chan struct{}) {
 	resource := metav1.APIResource{
@@ -94,12 +184,37 @@ func (c *Controller) registerAPIResource(stop <-chan struct{}) {
 	})
 }
 
+// CacheStatus is the state of the cache of Ready GameServers, as shown on the statusz page
+type CacheStatus struct {
+	// ReadyGameServers are the GameServers in the cache
+	ReadyGameServers int `json:"readyGameServers"`
+	// PendingWrites are the GameServers allocated while degraded, whose move to Allocated is not written yet
+	PendingWrites int `json:"pendingWrites"`
+}
+
+// CacheStatus returns the state of the cache of Ready GameServers
+func (c *Controller) CacheStatus() CacheStatus {
+	cache := c.allocator.readyGameServerCache
+	return CacheStatus{
+		ReadyGameServers: cache.readyGameServers.Len(),
+		PendingWrites:    cache.pendingWrites.Len(),
+	}
+}
+
+// WorkQueues returns the work queues of the controller, for the statusz page
+func (c *Controller) WorkQueues() []*workerqueue.WorkerQueue {
+	return []*workerqueue.WorkerQueue{c.allocator.readyGameServerCache.workerqueue}
+}
+
 // Run runs this controller. Will block until stop is closed.
 // Ignores threadiness, as we only needs 1 worker for cache sync
 func (c *Controller) Run(_ int, stop <-chan struct{}) error {
 	if err := c.allocator.Start(stop); err != nil {
 		return err
 	}
+	if c.auditor != nil {
+		go c.auditor.run(stop)
+	}
 
 	c.registerAPIResource(stop)
 
@@ -108,11 +223,16 @@ func (c *Controller) Run(_ int, stop <-chan struct{}) error {
 
 func (c *Controller) processAllocationRequest(w http.ResponseWriter, r *http.Request, namespace string, stop <-chan struct{}) (err error) {
 	latency := c.newMetrics(r.Context())
+	var gsa *allocationv1.GameServerAllocation
+	var result k8sruntime.Object
 	defer func() {
 		if err != nil {
 			latency.setError()
 		}
 		latency.record()
+		if c.auditor != nil && gsa != nil {
+			c.auditor.audit(newAuditRecord(r, gsa, result, err, latency.start))
+		}
 	}()
 
 	if r.Body != nil {
@@ -128,14 +248,16 @@ func (c *Controller) processAllocationRequest(w http.ResponseWriter, r *http.Req
 		return
 	}
 
-	gsa, err := c.allocationDeserialization(r, namespace)
+	gsa, err = c.allocationDeserialization(r, namespace)
 	if err != nil {
 		return err
 	}
 
 	latency.setRequest(gsa)
 
-	result, err := c.allocator.Allocate(gsa, stop)
+	ctx, cancel := requestContext(r)
+	defer cancel()
+	result, err = c.allocator.Allocate(ctx, gsa, stop)
 	if err != nil {
 		return err
 	}
@@ -148,6 +270,15 @@ func (c *Controller) processAllocationRequest(w http.ResponseWriter, r *http.Req
 	return err
 }
 
+// requestContext returns the context of an allocation request, which is done when the client goes away,
+// or when the timeout that Kubernetes clients send in the timeout query parameter is reached
+func requestContext(r *http.Request) (context.Context, context.CancelFunc) {
+	if timeout, err := time.ParseDuration(r.URL.Query().Get("timeout")); err == nil && timeout > 0 {
+		return context.WithTimeout(r.Context(), timeout)
+	}
+	return context.WithCancel(r.Context())
+}
+
 // newMetrics creates a new gsa latency recorder.
 func (c *Controller) newMetrics(ctx context.Context) *metrics {
 	ctx, err := tag.New(ctx, latencyTags...)
@@ -194,6 +325,9 @@ func (c *Controller) allocationDeserialization(r *http.Request, namespace string
 
 	gsa.ObjectMeta.Namespace = namespace
 	gsa.ObjectMeta.CreationTimestamp = metav1.Now()
+	if gsa.Spec.Scheduling == "" {
+		gsa.Spec.Scheduling = c.defaultScheduling
+	}
 	gsa.ApplyDefaults()
 
 	return gsa, nil
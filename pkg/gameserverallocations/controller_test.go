@@ -16,17 +16,21 @@ package gameserverallocations
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	pb "agones.dev/agones/pkg/allocation/go/v1alpha1"
 	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
@@ -35,13 +39,20 @@ import (
 	agtesting "agones.dev/agones/pkg/testing"
 	"agones.dev/agones/pkg/util/apiserver"
 	"agones.dev/agones/pkg/util/signals"
+	"agones.dev/agones/pkg/util/webhooks"
 	"github.com/heptiolabs/healthcheck"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/stats/view"
+	"google.golang.org/grpc"
+	admv1beta1 "k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	k8stesting "k8s.io/client-go/testing"
@@ -115,6 +126,110 @@ func TestControllerAllocator(t *testing.T) {
 		test(gsa.DeepCopy(), allocationv1.GameServerAllocationUnAllocated)
 	})
 
+	t.Run("include gameserver", func(t *testing.T) {
+		c, m := newFakeController()
+		fleetName := addReactorForGameServer(&m)
+
+		stop, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		if err := c.Run(1, stop); err != nil {
+			assert.FailNow(t, err.Error())
+		}
+		err := wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+			return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+		})
+		assert.NoError(t, err)
+
+		gsa := &allocationv1.GameServerAllocation{
+			Spec: allocationv1.GameServerAllocationSpec{
+				Required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: fleetName}},
+			}}
+
+		ret, err := executeAllocation(gsa.DeepCopy(), c)
+		assert.NoError(t, err)
+		assert.Equal(t, allocationv1.GameServerAllocationAllocated, ret.Status.State)
+		assert.Nil(t, ret.Status.GameServer)
+
+		gsa.Spec.IncludeGameServer = true
+		ret, err = executeAllocation(gsa.DeepCopy(), c)
+		assert.NoError(t, err)
+		assert.Equal(t, allocationv1.GameServerAllocationAllocated, ret.Status.State)
+		if assert.NotNil(t, ret.Status.GameServer) {
+			assert.Equal(t, ret.Status.GameServerName, ret.Status.GameServer.ObjectMeta.Name)
+			assert.Equal(t, fleetName, ret.Status.GameServer.ObjectMeta.Labels[agonesv1.FleetNameLabel])
+			assert.Equal(t, agonesv1.GameServerStateAllocated, ret.Status.GameServer.Status.State)
+		}
+	})
+
+	t.Run("result naming and gameserver uid", func(t *testing.T) {
+		f, _, gsList := defaultFixtures(2)
+		created := metav1.Unix(1576000000, 0)
+		for i := range gsList {
+			gsList[i].ObjectMeta.UID = types.UID("uid-" + gsList[i].ObjectMeta.Name)
+			gsList[i].ObjectMeta.CreationTimestamp = created
+		}
+
+		c, m := newFakeController()
+		gsWatch := watch.NewFake()
+		m.AgonesClient.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(gsWatch, nil))
+		m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, &agonesv1.GameServerList{Items: gsList}, nil
+		})
+		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			ua := action.(k8stesting.UpdateAction)
+			gs := ua.GetObject().(*agonesv1.GameServer)
+			gsWatch.Modify(gs)
+			return true, gs, nil
+		})
+
+		stop, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		if err := c.Run(1, stop); err != nil {
+			assert.FailNow(t, err.Error())
+		}
+		err := wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+			return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+		})
+		assert.NoError(t, err)
+
+		gsa := &allocationv1.GameServerAllocation{
+			ObjectMeta: metav1.ObjectMeta{GenerateName: "alloc-"},
+			Spec: allocationv1.GameServerAllocationSpec{
+				Required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}},
+			}}
+
+		// generateName: the result is named after the allocated GameServer
+		ret, err := executeAllocation(gsa.DeepCopy(), c)
+		assert.NoError(t, err)
+		assert.Equal(t, allocationv1.GameServerAllocationAllocated, ret.Status.State)
+		assert.Equal(t, ret.Status.GameServerName, ret.ObjectMeta.Name)
+		assert.Equal(t, "alloc-", ret.ObjectMeta.GenerateName)
+		assert.Equal(t, types.UID("uid-"+ret.Status.GameServerName), ret.Status.GameServerUID)
+		if assert.NotNil(t, ret.Status.GameServerCreationTimestamp) {
+			assert.True(t, created.Equal(ret.Status.GameServerCreationTimestamp))
+		}
+
+		// an explicit name is kept
+		named := gsa.DeepCopy()
+		named.ObjectMeta.Name = "my-allocation"
+		ret, err = executeAllocation(named, c)
+		assert.NoError(t, err)
+		assert.Equal(t, allocationv1.GameServerAllocationAllocated, ret.Status.State)
+		assert.Equal(t, "my-allocation", ret.ObjectMeta.Name)
+		assert.NotEmpty(t, ret.Status.GameServerUID)
+
+		// nothing to allocate, so the name is generated
+		ret, err = executeAllocation(gsa.DeepCopy(), c)
+		assert.NoError(t, err)
+		assert.Equal(t, allocationv1.GameServerAllocationUnAllocated, ret.Status.State)
+		assert.Equal(t, apis.ReasonNoCapacity, ret.Status.Reason)
+		assert.Regexp(t, "^alloc-[a-z0-9]{5}$", ret.ObjectMeta.Name)
+		assert.Empty(t, ret.Status.GameServerUID)
+		assert.Nil(t, ret.Status.GameServerCreationTimestamp)
+	})
+
 	t.Run("method not allowed", func(t *testing.T) {
 		c, _ := newFakeController()
 		r, err := http.NewRequest(http.MethodGet, "/", nil)
@@ -150,7 +265,150 @@ func TestControllerAllocator(t *testing.T) {
 		assert.NoError(t, err)
 
 		assert.Equal(t, metav1.StatusReasonInvalid, s.Reason)
+		if assert.Len(t, s.Details.Causes, 1) {
+			assert.Equal(t, "spec.scheduling", s.Details.Causes[0].Field)
+		}
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		c, _ := newFakeController()
+		c.allocator.rateLimiter = newRateLimiter(RateLimitConfig{NamespaceQPS: 0.5, NamespaceBurst: 1})
+		gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs}}
+		// use up the burst of the namespace
+		scope, _ := c.allocator.rateLimiter.allow(gsa)
+		assert.Empty(t, scope)
+
+		buf := bytes.NewBuffer(nil)
+		err := json.NewEncoder(buf).Encode(gsa)
+		assert.NoError(t, err)
+		r, err := http.NewRequest(http.MethodPost, "/", buf)
+		r.Header.Set("Content-Type", k8sruntime.ContentTypeJSON)
+		assert.NoError(t, err)
+		rec := httptest.NewRecorder()
+		err = c.processAllocationRequest(rec, r, defaultNs, stop)
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+		s := &metav1.Status{}
+		err = json.NewDecoder(rec.Body).Decode(s)
+		assert.NoError(t, err)
+
+		assert.Equal(t, metav1.StatusReasonTooManyRequests, s.Reason)
+		assert.Equal(t, int32(2), s.Details.RetryAfterSeconds)
+		if assert.Len(t, s.Details.Causes, 1) {
+			assert.Equal(t, metav1.CauseType(apis.ReasonRateLimited), s.Details.Causes[0].Type)
+		}
+	})
+}
+
+func TestControllerAllocateAcknowledgement(t *testing.T) {
+	t.Parallel()
+
+	_, _, gsList := defaultFixtures(2)
+	gsList[0].ObjectMeta.Labels["ack"] = "true"
+	gsList[1].ObjectMeta.Labels["ack"] = "false"
+	c, m := newFakeController()
+
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+
+	unhealthy := false
+	gsWatch := watch.NewFake()
+	m.AgonesClient.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(gsWatch, nil))
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		ua := action.(k8stesting.UpdateAction)
+		gs := ua.GetObject().(*agonesv1.GameServer)
+
+		switch gs.Status.State {
+		case agonesv1.GameServerStateAllocated:
+			assert.NotEmpty(t, gs.ObjectMeta.Annotations[agonesv1.AllocationAnnotation])
+			if gs.ObjectMeta.Labels["ack"] == "true" {
+				// the game server acknowledges the allocation straight away
+				gs.ObjectMeta.Annotations[agonesv1.AllocationAcknowledgedAnnotation] = gs.ObjectMeta.Annotations[agonesv1.AllocationAnnotation]
+			}
+		case agonesv1.GameServerStateUnhealthy:
+			assert.Equal(t, "false", gs.ObjectMeta.Labels["ack"])
+			unhealthy = true
+		}
+		gsWatch.Modify(gs)
+
+		return true, gs, nil
+	})
+
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	err := wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+		return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+	})
+	assert.NoError(t, err)
+
+	newGsa := func(ack string) *allocationv1.GameServerAllocation {
+		gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+			Spec: allocationv1.GameServerAllocationSpec{
+				Required:                  metav1.LabelSelector{MatchLabels: map[string]string{"ack": ack}},
+				AcknowledgeTimeoutSeconds: 1,
+			}}
+		gsa.ApplyDefaults()
+		return gsa
+	}
+
+	result, err := c.allocator.Allocate(context.Background(), newGsa("true"), stop)
+	assert.NoError(t, err)
+	gsa := result.(*allocationv1.GameServerAllocation)
+	assert.Equal(t, allocationv1.GameServerAllocationAllocated, gsa.Status.State)
+	assert.Equal(t, gsList[0].ObjectMeta.Name, gsa.Status.GameServerName)
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Allocated")
+
+	result, err = c.allocator.Allocate(context.Background(), newGsa("false"), stop)
+	assert.NoError(t, err)
+	gsa = result.(*allocationv1.GameServerAllocation)
+	assert.Equal(t, allocationv1.GameServerAllocationUnAllocated, gsa.Status.State)
+	assert.Equal(t, apis.ReasonAcknowledgeTimeout, gsa.Status.Reason)
+	assert.Empty(t, gsa.Status.GameServerName)
+	assert.True(t, unhealthy)
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Allocated")
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Warning AcknowledgeTimeout")
+}
+
+func TestAllocatorWaitForAcknowledgementCancelled(t *testing.T) {
+	t.Parallel()
+
+	c, m := newFakeController()
+	_, _, gsList := defaultFixtures(1)
+	ready := gsList[0].DeepCopy()
+	allocated := ready.DeepCopy()
+	allocated.ObjectMeta.ResourceVersion = "2"
+	allocated.ObjectMeta.Annotations = map[string]string{agonesv1.AllocationAnnotation: "1234"}
+	allocated.ObjectMeta.Labels["mode"] = "deathmatch"
+	allocated.Status.State = agonesv1.GameServerStateAllocated
+
+	var returned *agonesv1.GameServer
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		returned = action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		return true, returned, nil
 	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := c.allocator.waitForAcknowledgement(ctx, response{gs: allocated, ready: ready}, time.Minute)
+	assert.Equal(t, context.Canceled, err)
+
+	// the GameServer is returned to Ready, as it was before it was allocated
+	if assert.NotNil(t, returned) {
+		assert.Equal(t, agonesv1.GameServerStateReady, returned.Status.State)
+		assert.Equal(t, "2", returned.ObjectMeta.ResourceVersion)
+		assert.NotContains(t, returned.ObjectMeta.Labels, "mode")
+		assert.NotContains(t, returned.ObjectMeta.Annotations, agonesv1.AllocationAnnotation)
+	}
+	_, ok := c.allocator.readyGameServerCache.readyGameServers.Load(defaultNs + "/" + ready.ObjectMeta.Name)
+	assert.True(t, ok)
+	agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
 }
 
 func TestControllerAllocate(t *testing.T) {
@@ -164,6 +422,9 @@ func TestControllerAllocate(t *testing.T) {
 	fam := allocationv1.MetaPatch{Labels: l, Annotations: a}
 
 	gsList[3].ObjectMeta.DeletionTimestamp = &n
+	for i := range gsList {
+		gsList[i].Spec.Session.MaxDuration = &metav1.Duration{Duration: time.Hour}
+	}
 
 	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
 		return true, &agonesv1.GameServerList{Items: gsList}, nil
@@ -178,6 +439,9 @@ func TestControllerAllocate(t *testing.T) {
 
 		updated = true
 		assert.Equal(t, agonesv1.GameServerStateAllocated, gs.Status.State)
+		if assert.NotNil(t, gs.Status.AllocatedUntil) {
+			assert.WithinDuration(t, time.Now().Add(time.Hour), gs.Status.AllocatedUntil.Time, 5*time.Second)
+		}
 		gsWatch.Modify(gs)
 
 		return true, gs, nil
@@ -202,7 +466,7 @@ func TestControllerAllocate(t *testing.T) {
 		}}
 	gsa.ApplyDefaults()
 
-	gs, err := c.allocator.allocate(&gsa, stop)
+	gs, err := c.allocator.allocate(context.Background(), &gsa, stop)
 	assert.Nil(t, err)
 	assert.Equal(t, agonesv1.GameServerStateAllocated, gs.Status.State)
 	assert.True(t, updated)
@@ -217,25 +481,124 @@ func TestControllerAllocate(t *testing.T) {
 		assert.Equal(t, v, value)
 	}
 
+	// the time in the queue, and the update of the gameserver are measured
+	rows, err := view.RetrieveData("gameserver_allocations_queue_duration_seconds")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, rows)
+	rows, err = view.RetrieveData("gameserver_allocations_update_duration_seconds")
+	assert.NoError(t, err)
+	statuses := map[string]bool{}
+	for _, row := range rows {
+		for _, tag := range row.Tags {
+			if tag.Key == keyStatus {
+				statuses[tag.Value] = true
+			}
+		}
+	}
+	assert.True(t, statuses[string(writeSuccess)])
+
 	updated = false
-	gs, err = c.allocator.allocate(&gsa, stop)
+	gs, err = c.allocator.allocate(context.Background(), &gsa, stop)
 	assert.Nil(t, err)
 	assert.Equal(t, agonesv1.GameServerStateAllocated, gs.Status.State)
 	assert.True(t, updated)
 
 	updated = false
-	gs, err = c.allocator.allocate(&gsa, stop)
+	gs, err = c.allocator.allocate(context.Background(), &gsa, stop)
 	assert.Nil(t, err)
 	assert.Equal(t, agonesv1.GameServerStateAllocated, gs.Status.State)
 	assert.True(t, updated)
 
 	updated = false
-	_, err = c.allocator.allocate(&gsa, stop)
+	_, err = c.allocator.allocate(context.Background(), &gsa, stop)
 	assert.NotNil(t, err)
 	assert.Equal(t, ErrNoGameServerReady, err)
 	assert.False(t, updated)
 }
 
+func TestControllerAllocateCancelled(t *testing.T) {
+	t.Parallel()
+
+	f, _, gsList := defaultFixtures(1)
+	c, m := newFakeController()
+	// batch quickly, so the request is matched before its deadline
+	c.allocator.batchConfig.WaitTime = 10 * time.Millisecond
+	key, err := cache.MetaNamespaceKeyFunc(&gsList[0])
+	assert.NoError(t, err)
+
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+
+	release := make(chan struct{})
+	returned := make(chan *agonesv1.GameServer, 1)
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		if gs.Status.State == agonesv1.GameServerStateAllocated {
+			// the write takes longer than the deadline of the request
+			<-release
+		} else {
+			returned <- gs
+		}
+		return true, gs, nil
+	})
+
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	err = wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+		return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+	})
+	assert.NoError(t, err)
+
+	gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Name: "gsa-1", Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:  metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}},
+			MetaPatch: allocationv1.MetaPatch{Labels: map[string]string{"mode": "deathmatch"}},
+		}}
+	gsa.ApplyDefaults()
+
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := c.allocator.Allocate(ctx, gsa.DeepCopy(), stop)
+		assert.EqualError(t, err, "allocation cancelled: context canceled")
+		_, ok := c.allocator.readyGameServerCache.readyGameServers.Load(key)
+		assert.True(t, ok)
+	})
+
+	t.Run("deadline exceeded", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+
+		result, err := c.allocator.Allocate(ctx, gsa.DeepCopy(), stop)
+		assert.NoError(t, err)
+		if status, ok := result.(*metav1.Status); assert.True(t, ok) {
+			assert.Equal(t, int32(http.StatusGatewayTimeout), status.Code)
+			assert.Equal(t, metav1.StatusReasonTimeout, status.Reason)
+		}
+
+		// once the GameServer is Allocated, it is returned to Ready
+		close(release)
+		select {
+		case gs := <-returned:
+			assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
+			assert.NotContains(t, gs.ObjectMeta.Labels, "mode")
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "GameServer of the cancelled allocation was not returned")
+		}
+		err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			_, ok := c.allocator.readyGameServerCache.readyGameServers.Load(key)
+			return ok, nil
+		})
+		assert.NoError(t, err)
+	})
+}
+
 func TestControllerAllocatePriority(t *testing.T) {
 	t.Parallel()
 	stop := signals.NewStopChannel()
@@ -288,27 +651,27 @@ func TestControllerAllocatePriority(t *testing.T) {
 
 	run(t, "packed", func(t *testing.T, c *Controller, gas *allocationv1.GameServerAllocation) {
 		// priority should be node1, then node2
-		gs1, err := c.allocator.allocate(gas, stop)
+		gs1, err := c.allocator.allocate(context.Background(), gas, stop)
 		assert.NoError(t, err)
 		assert.Equal(t, n1, gs1.Status.NodeName)
 
-		gs2, err := c.allocator.allocate(gas, stop)
+		gs2, err := c.allocator.allocate(context.Background(), gas, stop)
 		assert.NoError(t, err)
 		assert.Equal(t, n1, gs2.Status.NodeName)
 		assert.NotEqual(t, gs1.ObjectMeta.Name, gs2.ObjectMeta.Name)
 
-		gs3, err := c.allocator.allocate(gas, stop)
+		gs3, err := c.allocator.allocate(context.Background(), gas, stop)
 		assert.NoError(t, err)
 		assert.Equal(t, n1, gs3.Status.NodeName)
 		assert.NotContains(t, []string{gs1.ObjectMeta.Name, gs2.ObjectMeta.Name}, gs3.ObjectMeta.Name)
 
-		gs4, err := c.allocator.allocate(gas, stop)
+		gs4, err := c.allocator.allocate(context.Background(), gas, stop)
 		assert.NoError(t, err)
 		assert.Equal(t, n2, gs4.Status.NodeName)
 		assert.NotContains(t, []string{gs1.ObjectMeta.Name, gs2.ObjectMeta.Name, gs3.ObjectMeta.Name}, gs4.ObjectMeta.Name)
 
 		// should have none left
-		_, err = c.allocator.allocate(gas, stop)
+		_, err = c.allocator.allocate(context.Background(), gas, stop)
 		assert.Equal(t, err, ErrNoGameServerReady)
 	})
 
@@ -319,23 +682,23 @@ func TestControllerAllocatePriority(t *testing.T) {
 
 		// distributed is randomised, so no set pattern
 
-		gs1, err := c.allocator.allocate(gas, stop)
+		gs1, err := c.allocator.allocate(context.Background(), gas, stop)
 		assert.NoError(t, err)
 
-		gs2, err := c.allocator.allocate(gas, stop)
+		gs2, err := c.allocator.allocate(context.Background(), gas, stop)
 		assert.NoError(t, err)
 		assert.NotEqual(t, gs1.ObjectMeta.Name, gs2.ObjectMeta.Name)
 
-		gs3, err := c.allocator.allocate(gas, stop)
+		gs3, err := c.allocator.allocate(context.Background(), gas, stop)
 		assert.NoError(t, err)
 		assert.NotContains(t, []string{gs1.ObjectMeta.Name, gs2.ObjectMeta.Name}, gs3.ObjectMeta.Name)
 
-		gs4, err := c.allocator.allocate(gas, stop)
+		gs4, err := c.allocator.allocate(context.Background(), gas, stop)
 		assert.NoError(t, err)
 		assert.NotContains(t, []string{gs1.ObjectMeta.Name, gs2.ObjectMeta.Name, gs3.ObjectMeta.Name}, gs4.ObjectMeta.Name)
 
 		// should have none left
-		_, err = c.allocator.allocate(gas, stop)
+		_, err = c.allocator.allocate(context.Background(), gas, stop)
 		assert.Equal(t, err, ErrNoGameServerReady)
 	})
 }
@@ -381,11 +744,11 @@ func TestControllerRunLocalAllocations(t *testing.T) {
 		gsa.ApplyDefaults()
 
 		// line up 3 in a batch
-		j1 := request{gsa: gsa.DeepCopy(), response: make(chan response)}
+		j1 := request{ctx: context.Background(), gsa: gsa.DeepCopy(), response: make(chan response)}
 		c.allocator.pendingRequests <- j1
-		j2 := request{gsa: gsa.DeepCopy(), response: make(chan response)}
+		j2 := request{ctx: context.Background(), gsa: gsa.DeepCopy(), response: make(chan response)}
 		c.allocator.pendingRequests <- j2
-		j3 := request{gsa: gsa.DeepCopy(), response: make(chan response)}
+		j3 := request{ctx: context.Background(), gsa: gsa.DeepCopy(), response: make(chan response)}
 		c.allocator.pendingRequests <- j3
 
 		go c.allocator.ListenAndAllocate(3, stop)
@@ -435,7 +798,7 @@ func TestControllerRunLocalAllocations(t *testing.T) {
 			}}
 		gsa.ApplyDefaults()
 
-		j1 := request{gsa: gsa.DeepCopy(), response: make(chan response)}
+		j1 := request{ctx: context.Background(), gsa: gsa.DeepCopy(), response: make(chan response)}
 		c.allocator.pendingRequests <- j1
 
 		go c.allocator.ListenAndAllocate(3, stop)
@@ -553,43 +916,155 @@ func TestControllerRunCacheSync(t *testing.T) {
 	watch.Modify(gs.DeepCopy())
 	assertCacheEntries(1)
 
+	// the SDK of the game server disconnected
+	gs.Status.SetCondition(agonesv1.GameServerCondition{Type: agonesv1.GameServerConditionSDKConnected, Status: corev1.ConditionFalse})
+	watch.Modify(gs.DeepCopy())
+	assertCacheEntries(0)
+
+	// and reconnected
+	gs.Status.SetCondition(agonesv1.GameServerCondition{Type: agonesv1.GameServerConditionSDKConnected, Status: corev1.ConditionTrue})
+	watch.Modify(gs.DeepCopy())
+	assertCacheEntries(1)
+
 	// now actually delete it
 	watch.Delete(gs.DeepCopy())
 	assertCacheEntries(0)
 }
 
-func TestGetRandomlySelectedGS(t *testing.T) {
-	c, _ := newFakeController()
-	c.allocator.topNGameServerCount = 5
-	gsa := &allocationv1.GameServerAllocation{
-		ObjectMeta: metav1.ObjectMeta{
-			Namespace: defaultNs,
-			Name:      "allocation",
-		},
-		Status: allocationv1.GameServerAllocationStatus{
-			State: allocationv1.GameServerAllocationUnAllocated,
-		},
+func TestReadyGameServerCacheStaleness(t *testing.T) {
+	t.Parallel()
+
+	setup := func(informerResourceVersion string) (*Controller, agtesting.Mocks, *clock.FakeClock) {
+		c, m := newFakeController()
+		fc := clock.NewFakeClock(time.Now())
+		c.allocator.readyGameServerCache.clock = fc
+		c.allocator.readyGameServerCache.informerResourceVersion = func() string { return informerResourceVersion }
+		return c, m, fc
 	}
 
-	_, _, gsList := defaultFixtures(10)
+	t.Run("informer catches up", func(t *testing.T) {
+		c, _, fc := setup("10")
+		rc := c.allocator.readyGameServerCache
 
-	selectedGS := c.allocator.getRandomlySelectedGS(gsa, gsList)
-	assert.NotNil(t, "selectedGS can't be nil", selectedGS)
-	for i := 1; i <= 5; i++ {
-		expectedName := "gs" + strconv.Itoa(i)
-		assert.NotEqual(t, expectedName, selectedGS.ObjectMeta.Name)
-	}
+		rc.ObserveResourceVersion("8")
+		assert.Equal(t, time.Duration(0), rc.Lag())
+
+		rc.ObserveResourceVersion("12")
+		fc.Step(time.Second)
+		assert.Equal(t, time.Second, rc.Lag())
+		assert.False(t, rc.Stale())
+
+		// a newer watermark does not replace one not caught up with yet
+		rc.ObserveResourceVersion("14")
+		fc.Step(time.Second)
+		assert.Equal(t, 2*time.Second, rc.Lag())
+
+		rc.informerResourceVersion = func() string { return "12" }
+		assert.Equal(t, time.Duration(0), rc.Lag())
+		assert.False(t, rc.informerBehind())
+	})
+
+	t.Run("stale rc rejects allocations", func(t *testing.T) {
+		c, _, fc := setup("10")
+		rc := c.allocator.readyGameServerCache
+
+		rc.ObserveResourceVersion("12")
+		fc.Step(maxReadyCacheLag + time.Second)
+		assert.True(t, rc.Stale())
+
+		gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs, Name: "gsa-1"}}
+		stop, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		result, err := c.allocator.allocateFromLocalCluster(context.Background(), gsa, nil, stop.Done())
+		assert.NoError(t, err)
+		assert.Equal(t, allocationv1.GameServerAllocationContention, result.Status.State)
+	})
+
+	t.Run("refresh from the apiserver", func(t *testing.T) {
+		c, m, fc := setup("10")
+		rc := c.allocator.readyGameServerCache
+
+		m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, &agonesv1.GameServerList{
+				ListMeta: metav1.ListMeta{ResourceVersion: "20"},
+				Items: []agonesv1.GameServer{{
+					ObjectMeta: metav1.ObjectMeta{Name: "gs1", Namespace: defaultNs, ResourceVersion: "15"},
+					Status:     agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady},
+				}},
+			}, nil
+		})
+
+		rc.ObserveResourceVersion("12")
+		fc.Step(maxReadyCacheLag + time.Second)
+		assert.True(t, rc.informerBehind())
+
+		err := rc.SyncGameServers("")
+		assert.NoError(t, err)
+		_, ok := rc.readyGameServers.Load("default/gs1")
+		assert.True(t, ok)
+		assert.False(t, rc.Stale())
+		// the informer still has to catch up with the refresh
+		assert.True(t, rc.informerBehind())
+
+		// changes seen by the refresh are not applied again from the informer
+		assert.True(t, rc.refreshedSince(&agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "15"}}))
+		assert.False(t, rc.refreshedSince(&agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{ResourceVersion: "21"}}))
+	})
+
+	t.Run("opaque resource versions are ignored", func(t *testing.T) {
+		c, _, fc := setup("abc")
+		rc := c.allocator.readyGameServerCache
 
-	_, _, gsList = defaultFixtures(5)
+		rc.ObserveResourceVersion("xyz")
+		rc.ObserveResourceVersion("12")
+		fc.Step(maxReadyCacheLag + time.Second)
+		assert.False(t, rc.Stale())
+		assert.False(t, rc.informerBehind())
+	})
+}
+
+func TestReadyGameServerCacheFullGameServer(t *testing.T) {
+	t.Parallel()
+
+	gs := readyFixture("gs1", "node1")
+	// run fetches the full GameServer of the projection, with the informer and the apiserver having their versions of it
+	run := func(projection *agonesv1.GameServer, informer, apiserver *agonesv1.GameServer) (*agonesv1.GameServer, int, error) {
+		c, m := newFakeController()
+		m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			list := &agonesv1.GameServerList{}
+			if informer != nil {
+				list.Items = append(list.Items, *informer)
+			}
+			return true, list, nil
+		})
+		gets := 0
+		m.AgonesClient.AddReactor("get", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			gets++
+			return true, apiserver.DeepCopy(), nil
+		})
+		_, cancel := agtesting.StartInformers(m, c.allocator.readyGameServerCache.gameServerSynced)
+		defer cancel()
+
+		full, err := c.allocator.readyGameServerCache.fullGameServer(projection)
+		return full, gets, err
+	}
 
-	selectedGS = c.allocator.getRandomlySelectedGS(gsa, gsList)
-	assert.NotNil(t, "selectedGS can't be nil", selectedGS)
+	full, gets, err := run(readyProjection(gs), gs, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, gets, "the full GameServer should come from the informer")
+	assert.Equal(t, gs, full)
 
-	_, _, gsList = defaultFixtures(1)
+	// the cache was refreshed from the apiserver, and the informer is behind
+	newer := gs.DeepCopy()
+	newer.ObjectMeta.ResourceVersion = "2"
+	full, gets, err = run(readyProjection(newer), gs, newer)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, gets)
+	assert.Equal(t, newer, full)
 
-	selectedGS = c.allocator.getRandomlySelectedGS(gsa, gsList)
-	assert.NotNil(t, "selectedGS can't be nil", selectedGS)
-	assert.Equal(t, "gs1", selectedGS.ObjectMeta.Name)
+	// the GameServer changed since it was found
+	_, _, err = run(readyProjection(gs), newer, newer)
+	assert.True(t, k8serrors.IsConflict(err))
 }
 
 func TestControllerAllocationUpdateWorkers(t *testing.T) {
@@ -603,12 +1078,16 @@ func TestControllerAllocationUpdateWorkers(t *testing.T) {
 		}
 		r := response{
 			request: request{
+				ctx:      context.Background(),
 				gsa:      &allocationv1.GameServerAllocation{},
 				response: make(chan response),
 			},
-			gs: gs1,
+			ready: gs1,
 		}
 
+		m.AgonesClient.AddReactor("get", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, gs1.DeepCopy(), nil
+		})
 		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
 			updated = true
 
@@ -642,10 +1121,11 @@ func TestControllerAllocationUpdateWorkers(t *testing.T) {
 		}
 		r = response{
 			request: request{
+				ctx:      context.Background(),
 				gsa:      &allocationv1.GameServerAllocation{},
 				response: make(chan response),
 			},
-			gs: gs2,
+			ready: gs2,
 		}
 
 		go func() {
@@ -677,12 +1157,16 @@ func TestControllerAllocationUpdateWorkers(t *testing.T) {
 
 		r := response{
 			request: request{
+				ctx:      context.Background(),
 				gsa:      &allocationv1.GameServerAllocation{},
 				response: make(chan response),
 			},
-			gs: gs1,
+			ready: gs1,
 		}
 
+		m.AgonesClient.AddReactor("get", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, gs1.DeepCopy(), nil
+		})
 		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
 			updated = true
 
@@ -723,8 +1207,9 @@ func TestControllerListSortedReadyGameServers(t *testing.T) {
 	gs4 := agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "gs4", Namespace: defaultNs, UID: "4"}, Status: agonesv1.GameServerStatus{NodeName: "node2", State: agonesv1.GameServerStateReady}}
 
 	fixtures := map[string]struct {
-		list []agonesv1.GameServer
-		test func(*testing.T, []*agonesv1.GameServer)
+		list  []agonesv1.GameServer
+		nodes []corev1.Node
+		test  func(*testing.T, []*agonesv1.GameServer)
 	}{
 		"most allocated": {
 			// node1: 1 ready, 1 allocated, node2: 1 ready
@@ -748,6 +1233,22 @@ func TestControllerListSortedReadyGameServers(t *testing.T) {
 				assert.Equal(t, &gs1, list[2])
 			},
 		},
+		"preempted node": {
+			list: []agonesv1.GameServer{gs1, gs2, gs4},
+			nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node2"},
+				Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: gameservers.DefaultPreemptionTaints[0], Effect: corev1.TaintEffectNoSchedule}}}}},
+			test: func(t *testing.T, list []*agonesv1.GameServer) {
+				assert.Equal(t, []*agonesv1.GameServer{&gs1}, list)
+			},
+		},
+		"cordoned node": {
+			list:  []agonesv1.GameServer{gs1, gs2, gs4},
+			nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Spec: corev1.NodeSpec{Unschedulable: true}}},
+			test: func(t *testing.T, list []*agonesv1.GameServer) {
+				assert.Len(t, list, 2)
+				assert.NotContains(t, list, &gs1)
+			},
+		},
 		"lexicographical (node name)": {
 			list: []agonesv1.GameServer{gs2, gs1},
 			test: func(t *testing.T, list []*agonesv1.GameServer) {
@@ -770,8 +1271,12 @@ func TestControllerListSortedReadyGameServers(t *testing.T) {
 			m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
 				return true, &agonesv1.GameServerList{Items: gsList}, nil
 			})
+			m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, &corev1.NodeList{Items: v.nodes}, nil
+			})
 
-			stop, cancel := agtesting.StartInformers(m, c.allocator.readyGameServerCache.gameServerSynced)
+			stop, cancel := agtesting.StartInformers(m, c.allocator.readyGameServerCache.gameServerSynced,
+				m.KubeInformerFactory.Core().V1().Nodes().Informer().HasSynced)
 			defer cancel()
 
 			// This call initializes the cache
@@ -987,13 +1492,116 @@ func TestMultiClusterAllocationFromRemote(t *testing.T) {
 		}
 	})
 
-	t.Run("Remote server returns error", func(t *testing.T) {
+	t.Run("Handle allocation request remotely over gRPC", func(t *testing.T) {
 		c, m := newFakeController()
+		c.allocator.remoteAllocationTransport = RemoteAllocationTransportGRPC
 		fleetName := addReactorForGameServer(&m)
 
-		// Mock server to return error
-		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			http.Error(w, "test error message", 500)
+		// Mock gRPC server
+		targetedNamespace := "tns"
+		requests := make(chan *pb.AllocationRequest, 2)
+		grpcServer := grpc.NewServer()
+		pb.RegisterAllocationServiceServer(grpcServer, allocationServiceFunc(func(ctx context.Context, in *pb.AllocationRequest) (*pb.AllocationResponse, error) {
+			requests <- in
+			return &pb.AllocationResponse{
+				State:          pb.AllocationResponse_Allocated,
+				GameServerName: "remote-gs",
+				Address:        "address",
+				NodeName:       "node",
+				Ports:          []*pb.AllocationResponse_GameServerStatusPort{{Name: "default", Port: 7777}},
+			}, nil
+		}))
+		server := httptest.NewUnstartedServer(grpcServer)
+		server.EnableHTTP2 = true
+		server.StartTLS()
+		defer server.Close()
+
+		// Set client CA for server
+		certpool := x509.NewCertPool()
+		certpool.AppendCertsFromPEM(clientCert)
+		server.TLS.ClientCAs = certpool
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+
+		// Allocation policy reactor
+		secretName := clusterName + "secret"
+		m.AgonesClient.AddReactor("list", "gameserverallocationpolicies", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, &multiclusterv1alpha1.GameServerAllocationPolicyList{
+				Items: []multiclusterv1alpha1.GameServerAllocationPolicy{
+					{
+						Spec: multiclusterv1alpha1.GameServerAllocationPolicySpec{
+							Priority: 1,
+							Weight:   200,
+							ConnectionInfo: multiclusterv1alpha1.ClusterConnectionInfo{
+								AllocationEndpoints: []string{server.URL},
+								ClusterName:         clusterName,
+								SecretName:          secretName,
+								Namespace:           targetedNamespace,
+							},
+						},
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: defaultNs,
+						},
+					},
+				},
+			}, nil
+		})
+
+		m.KubeClient.AddReactor("list", "secrets",
+			func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, getTestSecret(secretName, getPEMFromDER(server.TLS.Certificates[0].Certificate[0])), nil
+			})
+
+		stop, cancel := agtesting.StartInformers(m, c.allocator.allocationPolicySynced, c.allocator.secretSynced, c.allocator.readyGameServerCache.gameServerSynced)
+		defer cancel()
+
+		// This call initializes the cache
+		err := c.allocator.readyGameServerCache.syncReadyGSServerCache()
+		assert.Nil(t, err)
+
+		err = c.allocator.readyGameServerCache.counter.Run(0, stop)
+		assert.Nil(t, err)
+
+		for i := 0; i < 2; i++ {
+			gsa := &allocationv1.GameServerAllocation{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   defaultNs,
+					Name:        "alloc1",
+					ClusterName: "localcluster",
+				},
+				Spec: allocationv1.GameServerAllocationSpec{
+					MultiClusterSetting: allocationv1.MultiClusterSetting{
+						Enabled: true,
+					},
+					Required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: fleetName}},
+				},
+			}
+
+			result, err := executeAllocation(gsa, c)
+			if assert.NoError(t, err) {
+				assert.Equal(t, allocationv1.GameServerAllocationAllocated, result.Status.State)
+				assert.Equal(t, "remote-gs", result.Status.GameServerName)
+				assert.Equal(t, "address", result.Status.Address)
+				assert.Equal(t, "node", result.Status.NodeName)
+				assert.Equal(t, []agonesv1.GameServerStatusPort{{Name: "default", Port: 7777}}, result.Status.Ports)
+			}
+
+			request := <-requests
+			assert.Equal(t, targetedNamespace, request.GetNamespace())
+			assert.False(t, request.GetMultiClusterSetting().GetEnabled())
+			assert.Equal(t, map[string]string{agonesv1.FleetNameLabel: fleetName}, request.GetRequiredGameServerSelector().GetMatchLabels())
+		}
+
+		// the connection is reused across allocations
+		assert.Len(t, c.allocator.remoteClients, 1)
+	})
+
+	t.Run("Remote server returns error", func(t *testing.T) {
+		c, m := newFakeController()
+		fleetName := addReactorForGameServer(&m)
+
+		// Mock server to return error
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "test error message", 500)
 		}))
 		defer server.Close()
 
@@ -1060,6 +1668,211 @@ func TestMultiClusterAllocationFromRemote(t *testing.T) {
 		assert.Contains(t, err.Error(), "test error message")
 	})
 
+	t.Run("Transient failures are retried", func(t *testing.T) {
+		c, m := newFakeController()
+		c.allocator.remoteRetry = wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1.0}
+
+		var count int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&count, 1) < 3 {
+				http.Error(w, "test error message", 503)
+				return
+			}
+			response, _ := json.Marshal(allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Name: "mocked"}})
+			_, _ = w.Write(response)
+		}))
+		defer server.Close()
+
+		// Set client CA for server
+		certpool := x509.NewCertPool()
+		certpool.AppendCertsFromPEM(clientCert)
+		server.TLS.ClientCAs = certpool
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+
+		secretName := clusterName + "secret"
+		m.KubeClient.AddReactor("list", "secrets",
+			func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, getTestSecret(secretName, getPEMFromDER(server.TLS.Certificates[0].Certificate[0])), nil
+			})
+
+		_, cancel := agtesting.StartInformers(m, c.allocator.secretSynced)
+		defer cancel()
+
+		connectionInfo := &multiclusterv1alpha1.ClusterConnectionInfo{
+			AllocationEndpoints: []string{server.URL},
+			ClusterName:         clusterName,
+			SecretName:          secretName,
+			Namespace:           defaultNs,
+		}
+		result, err := c.allocator.allocateFromRemoteCluster(context.Background(), allocationv1.GameServerAllocation{}, connectionInfo, defaultNs)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "mocked", result.ObjectMeta.Name)
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+
+		// gives up once the retries are exhausted
+		atomic.StoreInt32(&count, -10)
+		_, err = c.allocator.allocateFromRemoteCluster(context.Background(), allocationv1.GameServerAllocation{}, connectionInfo, defaultNs)
+		assert.EqualError(t, err, "test error message\n")
+		assert.Equal(t, int32(-7), atomic.LoadInt32(&count))
+	})
+
+	t.Run("Remote requests are measured per cluster and status", func(t *testing.T) {
+		c, m := newFakeController()
+		c.allocator.remoteRetry = wait.Backoff{Steps: 3, Duration: time.Millisecond, Factor: 1.0}
+
+		var count int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&count, 1) < 3 {
+				http.Error(w, "test error message", 503)
+				return
+			}
+			response, _ := json.Marshal(allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Name: "mocked"}})
+			_, _ = w.Write(response)
+		}))
+		defer server.Close()
+
+		certpool := x509.NewCertPool()
+		certpool.AppendCertsFromPEM(clientCert)
+		server.TLS.ClientCAs = certpool
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+
+		secretName := clusterName + "secret"
+		m.KubeClient.AddReactor("list", "secrets",
+			func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, getTestSecret(secretName, getPEMFromDER(server.TLS.Certificates[0].Certificate[0])), nil
+			})
+
+		_, cancel := agtesting.StartInformers(m, c.allocator.secretSynced)
+		defer cancel()
+
+		connectionInfo := &multiclusterv1alpha1.ClusterConnectionInfo{
+			AllocationEndpoints: []string{server.URL},
+			ClusterName:         "measuredcluster",
+			SecretName:          secretName,
+			Namespace:           defaultNs,
+		}
+		_, err := c.allocator.allocateFromRemoteCluster(context.Background(), allocationv1.GameServerAllocation{}, connectionInfo, defaultNs)
+		assert.NoError(t, err)
+
+		counts := map[string]int64{}
+		rows, err := view.RetrieveData("gameserver_allocations_remote_total")
+		assert.NoError(t, err)
+		for _, row := range rows {
+			tags := map[string]string{}
+			for _, tag := range row.Tags {
+				tags[tag.Key.Name()] = tag.Value
+			}
+			if tags[keyClusterName.Name()] == "measuredcluster" {
+				counts[tags[keyStatus.Name()]] = row.Data.(*view.CountData).Value
+			}
+		}
+		assert.Equal(t, map[string]int64{"5xx": 2, "2xx": 1}, counts)
+	})
+
+	t.Run("Prefer the local cluster", func(t *testing.T) {
+		c, m := newFakeController()
+		fleetName := addReactorForGameServer(&m)
+
+		var count int32
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&count, 1)
+			response, _ := json.Marshal(allocationv1.GameServerAllocation{
+				ObjectMeta: metav1.ObjectMeta{Name: "mocked"},
+				Status:     allocationv1.GameServerAllocationStatus{State: allocationv1.GameServerAllocationAllocated},
+			})
+			_, _ = w.Write(response)
+		}))
+		defer server.Close()
+
+		certpool := x509.NewCertPool()
+		certpool.AppendCertsFromPEM(clientCert)
+		server.TLS.ClientCAs = certpool
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+
+		// the remote cluster has a higher priority than the local cluster
+		secretName := clusterName + "secret"
+		m.AgonesClient.AddReactor("list", "gameserverallocationpolicies", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, &multiclusterv1alpha1.GameServerAllocationPolicyList{
+				Items: []multiclusterv1alpha1.GameServerAllocationPolicy{
+					{
+						Spec: multiclusterv1alpha1.GameServerAllocationPolicySpec{
+							Priority: 1,
+							Weight:   100,
+							ConnectionInfo: multiclusterv1alpha1.ClusterConnectionInfo{
+								AllocationEndpoints: []string{server.URL},
+								ClusterName:         clusterName,
+								SecretName:          secretName,
+								Namespace:           "tns",
+							},
+						},
+						ObjectMeta: metav1.ObjectMeta{Name: "remote", Namespace: defaultNs},
+					},
+					{
+						Spec: multiclusterv1alpha1.GameServerAllocationPolicySpec{
+							Priority: 2,
+							Weight:   100,
+							ConnectionInfo: multiclusterv1alpha1.ClusterConnectionInfo{
+								AllocationEndpoints: []string{"localhost"},
+								ClusterName:         "localcluster",
+								SecretName:          "localhostsecret",
+								Namespace:           defaultNs,
+							},
+						},
+						ObjectMeta: metav1.ObjectMeta{Name: "local", Namespace: defaultNs},
+					},
+				},
+			}, nil
+		})
+
+		m.KubeClient.AddReactor("list", "secrets",
+			func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, getTestSecret(secretName, getPEMFromDER(server.TLS.Certificates[0].Certificate[0])), nil
+			})
+
+		stop, cancel := agtesting.StartInformers(m)
+		defer cancel()
+
+		if err := c.Run(1, stop); err != nil {
+			assert.FailNow(t, err.Error())
+		}
+		// wait for it to be up and running
+		err := wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+			return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+		})
+		assert.NoError(t, err)
+
+		gsa := &allocationv1.GameServerAllocation{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   defaultNs,
+				Name:        "alloc1",
+				ClusterName: "localcluster",
+			},
+			Spec: allocationv1.GameServerAllocationSpec{
+				MultiClusterSetting: allocationv1.MultiClusterSetting{
+					Enabled:     true,
+					PreferLocal: true,
+				},
+				Required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: fleetName}},
+			},
+		}
+
+		result, err := executeAllocation(gsa.DeepCopy(), c)
+		if assert.NoError(t, err) {
+			assert.Equal(t, allocationv1.GameServerAllocationAllocated, result.Status.State)
+			assert.Equal(t, "alloc1", result.ObjectMeta.Name)
+		}
+		assert.Equal(t, int32(0), atomic.LoadInt32(&count))
+
+		// spills over to the remote cluster when there is no Ready GameServer in the local cluster
+		gsa.Spec.Required = metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "missing"}}
+		result, err = executeAllocation(gsa.DeepCopy(), c)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "mocked", result.ObjectMeta.Name)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+
 	t.Run("First server fails and second server succeeds", func(t *testing.T) {
 		c, m := newFakeController()
 		fleetName := addReactorForGameServer(&m)
@@ -1149,11 +1962,147 @@ func TestMultiClusterAllocationFromRemote(t *testing.T) {
 	})
 }
 
+func TestAllocatorSendToEndpoints(t *testing.T) {
+	t.Parallel()
+
+	post := func(c *Controller) func(ctx context.Context, endpoint string) endpointResult {
+		return func(ctx context.Context, endpoint string) endpointResult {
+			return c.allocator.postToEndpoint(ctx, http.DefaultClient, endpoint, []byte("{}"))
+		}
+	}
+	writeGSA := func(w http.ResponseWriter, name string) {
+		response, _ := json.Marshal(allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Name: name}})
+		_, _ = w.Write(response)
+	}
+
+	t.Run("No endpoints", func(t *testing.T) {
+		c, _ := newFakeController()
+		res := c.allocator.sendToEndpoints(context.Background(), nil, post(c))
+		assert.Error(t, res.err)
+	})
+
+	t.Run("Slow endpoint is hedged and cancelled", func(t *testing.T) {
+		c, _ := newFakeController()
+		c.allocator.remoteAllocationHedgeDelay = 10 * time.Millisecond
+
+		cancelled := make(chan struct{})
+		slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the request context is only cancelled on disconnect once the body is consumed
+			_, _ = ioutil.ReadAll(r.Body)
+			select {
+			case <-r.Context().Done():
+				close(cancelled)
+			case <-time.After(10 * time.Second):
+				writeGSA(w, "slow")
+			}
+		}))
+		defer slowServer.Close()
+		fastServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeGSA(w, "fast")
+		}))
+		defer fastServer.Close()
+
+		res := c.allocator.sendToEndpoints(context.Background(), []string{slowServer.URL, fastServer.URL}, post(c))
+		assert.NoError(t, res.err)
+		assert.Equal(t, fastServer.URL, res.endpoint)
+		if assert.NotNil(t, res.gsa) {
+			assert.Equal(t, "fast", res.gsa.ObjectMeta.Name)
+		}
+
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "slow request should have been cancelled")
+		}
+	})
+
+	t.Run("No hedging without delay", func(t *testing.T) {
+		c, _ := newFakeController()
+
+		var count int32
+		slowServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+			writeGSA(w, "slow")
+		}))
+		defer slowServer.Close()
+		otherServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&count, 1)
+		}))
+		defer otherServer.Close()
+
+		res := c.allocator.sendToEndpoints(context.Background(), []string{slowServer.URL, otherServer.URL}, post(c))
+		assert.NoError(t, res.err)
+		if assert.NotNil(t, res.gsa) {
+			assert.Equal(t, "slow", res.gsa.ObjectMeta.Name)
+		}
+		assert.Equal(t, int32(0), atomic.LoadInt32(&count))
+	})
+
+	t.Run("Client error is not retried", func(t *testing.T) {
+		c, _ := newFakeController()
+
+		var count int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&count, 1)
+			http.Error(w, "test error message", 400)
+		}))
+		defer server.Close()
+
+		res := c.allocator.sendToEndpoints(context.Background(), []string{server.URL, server.URL}, post(c))
+		assert.EqualError(t, res.err, "test error message\n")
+		assert.False(t, res.transient)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	})
+
+	t.Run("Failing endpoint is taken out of rotation", func(t *testing.T) {
+		c, _ := newFakeController()
+
+		var failing int32
+		failingServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&failing, 1)
+			http.Error(w, "test error message", 503)
+		}))
+		defer failingServer.Close()
+		healthyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			writeGSA(w, "healthy")
+		}))
+		defer healthyServer.Close()
+
+		for i := 0; i < remoteEndpointFailureThreshold+2; i++ {
+			res := c.allocator.sendToEndpoints(context.Background(), []string{failingServer.URL, healthyServer.URL}, post(c))
+			assert.NoError(t, res.err)
+			assert.Equal(t, healthyServer.URL, res.endpoint)
+		}
+		assert.Equal(t, int32(remoteEndpointFailureThreshold), atomic.LoadInt32(&failing))
+
+		res := c.allocator.sendToEndpoints(context.Background(), []string{failingServer.URL}, post(c))
+		assert.EqualError(t, res.err, "all allocation endpoints are failing, skipping the cluster")
+		assert.False(t, res.transient)
+	})
+
+	t.Run("All endpoints fail", func(t *testing.T) {
+		c, _ := newFakeController()
+		c.allocator.remoteAllocationHedgeDelay = 10 * time.Millisecond
+
+		var count int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&count, 1)
+			http.Error(w, "test error message", 503)
+		}))
+		defer server.Close()
+
+		res := c.allocator.sendToEndpoints(context.Background(), []string{server.URL, server.URL}, post(c))
+		assert.EqualError(t, res.err, "test error message\n")
+		assert.True(t, res.transient)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+	})
+}
+
 func TestCreateRestClientError(t *testing.T) {
 	t.Parallel()
 	t.Run("Missing secret", func(t *testing.T) {
 		c, _ := newFakeController()
-		_, err := c.allocator.createRemoteClusterRestClient(defaultNs, "secret-name")
+		_, err := c.allocator.createRemoteClusterRestClient(defaultNs, "secret-name", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "secret-name")
 	})
@@ -1177,7 +2126,7 @@ func TestCreateRestClientError(t *testing.T) {
 		_, cancel := agtesting.StartInformers(m, c.allocator.secretSynced)
 		defer cancel()
 
-		_, err := c.allocator.createRemoteClusterRestClient(defaultNs, "secret-name")
+		_, err := c.allocator.createRemoteClusterRestClient(defaultNs, "secret-name", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "missing client certificate key pair in secret secret-name")
 	})
@@ -1202,7 +2151,7 @@ func TestCreateRestClientError(t *testing.T) {
 		_, cancel := agtesting.StartInformers(m, c.allocator.secretSynced)
 		defer cancel()
 
-		_, err := c.allocator.createRemoteClusterRestClient(defaultNs, "secret-name")
+		_, err := c.allocator.createRemoteClusterRestClient(defaultNs, "secret-name", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "failed to find any PEM data in certificate input")
 	})
@@ -1217,12 +2166,24 @@ func TestCreateRestClientError(t *testing.T) {
 		_, cancel := agtesting.StartInformers(m, c.allocator.secretSynced)
 		defer cancel()
 
-		_, err := c.allocator.createRemoteClusterRestClient(defaultNs, "secret-name")
+		_, err := c.allocator.createRemoteClusterRestClient(defaultNs, "secret-name", "")
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "PEM format")
 	})
 }
 
+// allocationServiceFunc implements the AllocationService gRPC API with a function
+type allocationServiceFunc func(ctx context.Context, in *pb.AllocationRequest) (*pb.AllocationResponse, error)
+
+func (f allocationServiceFunc) PostAllocate(ctx context.Context, in *pb.AllocationRequest) (*pb.AllocationResponse, error) {
+	return f(ctx, in)
+}
+
+// WatchAllocate is not used to forward allocations between clusters
+func (f allocationServiceFunc) WatchAllocate(in *pb.AllocationRequest, stream pb.AllocationService_WatchAllocateServer) error {
+	return errors.New("not implemented")
+}
+
 func executeAllocation(gsa *allocationv1.GameServerAllocation, c *Controller) (*allocationv1.GameServerAllocation, error) {
 	stop := signals.NewStopChannel()
 	r, err := createRequest(gsa)
@@ -1296,13 +2257,228 @@ func defaultFixtures(gsLen int) (*agonesv1.Fleet, *agonesv1.GameServerSet, []ago
 }
 
 // newFakeController returns a controller, backed by the fake Clientset
+func TestControllerPolicyValidationHandler(t *testing.T) {
+	t.Parallel()
+
+	const secretName = "secret-name"
+	newPolicy := func(name, clusterName string) *multiclusterv1alpha1.GameServerAllocationPolicy {
+		return &multiclusterv1alpha1.GameServerAllocationPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs},
+			Spec: multiclusterv1alpha1.GameServerAllocationPolicySpec{
+				Priority: 1,
+				Weight:   100,
+				ConnectionInfo: multiclusterv1alpha1.ClusterConnectionInfo{
+					ClusterName:         clusterName,
+					AllocationEndpoints: []string{"https://allocator.example.com"},
+					SecretName:          secretName,
+					Namespace:           defaultNs,
+				},
+			},
+		}
+	}
+
+	fixtures := map[string]struct {
+		policy  func(*multiclusterv1alpha1.GameServerAllocationPolicy)
+		allowed bool
+		fields  []string
+	}{
+		"valid": {
+			policy:  func(*multiclusterv1alpha1.GameServerAllocationPolicy) {},
+			allowed: true,
+		},
+		"update of an existing policy": {
+			policy:  func(p *multiclusterv1alpha1.GameServerAllocationPolicy) { p.ObjectMeta.Name = "existing" },
+			allowed: true,
+		},
+		"same cluster with the same connection info": {
+			policy: func(p *multiclusterv1alpha1.GameServerAllocationPolicy) {
+				p.Spec.ConnectionInfo.ClusterName = "existing-cluster"
+			},
+			allowed: true,
+		},
+		"same cluster with different connection info": {
+			policy: func(p *multiclusterv1alpha1.GameServerAllocationPolicy) {
+				p.Spec.ConnectionInfo.ClusterName = "existing-cluster"
+				p.Spec.ConnectionInfo.AllocationEndpoints = []string{"https://other.example.com"}
+			},
+			fields: []string{"connectionInfo.clusterName"},
+		},
+		"missing secret": {
+			policy: func(p *multiclusterv1alpha1.GameServerAllocationPolicy) { p.Spec.ConnectionInfo.SecretName = "missing" },
+			fields: []string{"connectionInfo.secretName"},
+		},
+		"endpoints without a secret": {
+			policy: func(p *multiclusterv1alpha1.GameServerAllocationPolicy) { p.Spec.ConnectionInfo.SecretName = "" },
+			fields: []string{"connectionInfo.secretName"},
+		},
+		"invalid settings": {
+			policy: func(p *multiclusterv1alpha1.GameServerAllocationPolicy) {
+				p.Spec.Priority = -1
+				p.Spec.Weight = -1
+				p.Spec.ConnectionInfo.AllocationEndpoints = []string{"ftp://allocator.example.com"}
+			},
+			fields: []string{"priority", "weight", "connectionInfo.allocationEndpoints[0]"},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			c, m := newFakeController()
+			m.KubeClient.AddReactor("list", "secrets", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, getTestSecret(secretName, clientCert), nil
+			})
+			m.AgonesClient.AddReactor("list", "gameserverallocationpolicies", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, &multiclusterv1alpha1.GameServerAllocationPolicyList{
+					Items: []multiclusterv1alpha1.GameServerAllocationPolicy{*newPolicy("existing", "existing-cluster")},
+				}, nil
+			})
+			_, cancel := agtesting.StartInformers(m, c.allocator.secretSynced, c.allocator.allocationPolicySynced)
+			defer cancel()
+
+			policy := newPolicy("policy", "cluster")
+			v.policy(policy)
+			raw, err := json.Marshal(policy)
+			assert.NoError(t, err)
+			review := admv1beta1.AdmissionReview{
+				Request: &admv1beta1.AdmissionRequest{
+					Kind:      metav1.GroupVersionKind(multiclusterv1alpha1.SchemeGroupVersion.WithKind("GameServerAllocationPolicy")),
+					Operation: admv1beta1.Create,
+					Object:    k8sruntime.RawExtension{Raw: raw},
+					Namespace: defaultNs,
+				},
+				Response: &admv1beta1.AdmissionResponse{Allowed: true},
+			}
+
+			result, err := c.policyValidationHandler(review)
+			assert.NoError(t, err)
+			assert.Equal(t, v.allowed, result.Response.Allowed, fmt.Sprintf("%#v", result.Response))
+			if !v.allowed {
+				assert.Equal(t, metav1.StatusReasonInvalid, result.Response.Result.Reason)
+				var fields []string
+				for _, cause := range result.Response.Result.Details.Causes {
+					fields = append(fields, cause.Field)
+				}
+				assert.Equal(t, v.fields, fields)
+			}
+		})
+	}
+}
+
+func TestBatchConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, DefaultBatchConfig.Validate())
+
+	fixtures := map[string]func(*BatchConfig){
+		"queue":    func(b *BatchConfig) { b.MaxQueue = 0 },
+		"refresh":  func(b *BatchConfig) { b.MaxBatchBeforeRefresh = -1 },
+		"waitTime": func(b *BatchConfig) { b.WaitTime = 0 },
+	}
+	for k, f := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			b := DefaultBatchConfig
+			f(&b)
+			assert.Error(t, b.Validate())
+		})
+	}
+}
+
+func TestControllerAllocateFleetName(t *testing.T) {
+	t.Parallel()
+
+	f, _, gsList := defaultFixtures(3)
+	// a fleet without Ready GameServers
+	empty := f.DeepCopy()
+	empty.ObjectMeta.Name = "empty"
+	c, m := newFakeController()
+
+	m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f, *empty}}, nil
+	})
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+	gsWatch := watch.NewFake()
+	m.AgonesClient.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(gsWatch, nil))
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		gsWatch.Modify(gs)
+		return true, gs, nil
+	})
+
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	err := wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+		return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+	})
+	assert.NoError(t, err)
+
+	allocate := func(fleetName string, fallbacks ...string) k8sruntime.Object {
+		gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+			Spec: allocationv1.GameServerAllocationSpec{FleetName: fleetName, FallbackFleetNames: fallbacks}}
+		gsa.ApplyDefaults()
+		result, err := c.allocator.Allocate(context.Background(), gsa, stop)
+		assert.NoError(t, err)
+		return result
+	}
+
+	result := allocate("missing")
+	status, ok := result.(*metav1.Status)
+	if assert.True(t, ok) {
+		assert.Equal(t, int32(http.StatusUnprocessableEntity), status.Code)
+		assert.Equal(t, "spec.fleetName", status.Details.Causes[0].Field)
+	}
+
+	result = allocate(f.ObjectMeta.Name)
+	gsa, ok := result.(*allocationv1.GameServerAllocation)
+	if assert.True(t, ok) {
+		assert.Equal(t, allocationv1.GameServerAllocationAllocated, gsa.Status.State)
+		assert.Equal(t, f.ObjectMeta.Name, gsa.Spec.Required.MatchLabels[agonesv1.FleetNameLabel])
+	}
+
+	result = allocate(empty.ObjectMeta.Name, "missing")
+	status, ok = result.(*metav1.Status)
+	if assert.True(t, ok) {
+		assert.Equal(t, int32(http.StatusUnprocessableEntity), status.Code)
+		assert.Equal(t, "spec.fallbackFleetNames[0]", status.Details.Causes[0].Field)
+	}
+
+	result = allocate(empty.ObjectMeta.Name)
+	gsa, ok = result.(*allocationv1.GameServerAllocation)
+	if assert.True(t, ok) {
+		assert.Equal(t, allocationv1.GameServerAllocationUnAllocated, gsa.Status.State)
+	}
+
+	result = allocate(empty.ObjectMeta.Name, f.ObjectMeta.Name)
+	gsa, ok = result.(*allocationv1.GameServerAllocation)
+	if assert.True(t, ok) {
+		assert.Equal(t, allocationv1.GameServerAllocationAllocated, gsa.Status.State)
+		// the allocation still requires the fleet of its fleetName
+		assert.Equal(t, empty.ObjectMeta.Name, gsa.Spec.Required.MatchLabels[agonesv1.FleetNameLabel])
+	}
+}
+
 func newFakeController() (*Controller, agtesting.Mocks) {
 	m := agtesting.NewMocks()
 	m.Mux = http.NewServeMux()
 	counter := gameservers.NewPerNodeCounter(m.KubeInformerFactory, m.AgonesInformerFactory)
+	preemptions := gameservers.NewNodePreemptions(m.KubeInformerFactory, gameservers.DefaultPreemptionTaints)
+	maintenance := gameservers.NewNodeMaintenance(m.KubeInformerFactory, true, nil)
 	api := apiserver.NewAPIServer(m.Mux)
-	c := NewController(api, healthcheck.NewHandler(), counter, m.KubeClient, m.KubeInformerFactory, m.AgonesClient, m.AgonesInformerFactory)
-	c.allocator.topNGameServerCount = 1
+	config := Config{
+		Batch:                     DefaultBatchConfig,
+		FilterWebhook:             DefaultFilterWebhookConfig,
+		DegradedMode:              DefaultDegradedModeConfig,
+		Selection:                 DefaultSelectionConfig,
+		ProtectedMetadataPrefixes: DefaultProtectedMetadataPrefixes,
+		DefaultScheduling:         apis.Packed,
+		RemoteAllocationTransport: RemoteAllocationTransportHTTP,
+	}
+	c := NewController(api, webhooks.NewWebHook(http.NewServeMux()), healthcheck.NewHandler(), counter, preemptions, maintenance, m.KubeClient, m.KubeInformerFactory, m.AgonesClient, m.AgonesInformerFactory, config)
 	c.recorder = m.FakeRecorder
 	c.allocator.recorder = m.FakeRecorder
 	return c, m
@@ -0,0 +1,334 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	pb "agones.dev/agones/pkg/allocation/go/v1alpha1"
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ConvertAllocationRequestToGSA converts AllocationRequest to GameServerAllocation V1 (GSA)
+func ConvertAllocationRequestToGSA(in *pb.AllocationRequest) *allocationv1.GameServerAllocation {
+	if in == nil {
+		return nil
+	}
+
+	gsa := &allocationv1.GameServerAllocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: in.GetNamespace(),
+		},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Preferred:                 convertAllocationLabelSelectorsToGSALabelSelectors(in.GetPreferredGameServerSelectors()),
+			Scheduling:                convertAllocationSchedulingToGSASchedulingStrategy(in.GetScheduling()),
+			AcknowledgeTimeoutSeconds: in.GetAcknowledgeTimeoutSeconds(),
+			FleetName:                 in.GetFleetName(),
+			RequestID:                 in.GetRequestID(),
+		},
+	}
+
+	if in.GetMultiClusterSetting() != nil {
+		gsa.Spec.MultiClusterSetting = allocationv1.MultiClusterSetting{
+			Enabled:     in.GetMultiClusterSetting().GetEnabled(),
+			PreferLocal: in.GetMultiClusterSetting().GetPreferLocal(),
+		}
+		if selector := convertAllocationLabelSelectorToGSALabelSelector(in.GetMultiClusterSetting().GetPolicySelector()); selector != nil {
+			gsa.Spec.MultiClusterSetting.PolicySelector = *selector
+		}
+	}
+
+	if selector := convertAllocationLabelSelectorToGSALabelSelector(in.GetRequiredGameServerSelector()); selector != nil {
+		gsa.Spec.Required = *selector
+	}
+
+	if in.GetMetaPatch() != nil {
+		gsa.Spec.MetaPatch = allocationv1.MetaPatch{
+			Labels:      in.GetMetaPatch().GetLabels(),
+			Annotations: in.GetMetaPatch().GetAnnotations(),
+		}
+	}
+
+	return gsa
+}
+
+// ConvertGSAToAllocationRequest converts GameServerAllocation V1 (GSA) to AllocationRequest
+func ConvertGSAToAllocationRequest(in *allocationv1.GameServerAllocation) *pb.AllocationRequest {
+	if in == nil {
+		return nil
+	}
+
+	out := &pb.AllocationRequest{
+		Namespace: in.GetNamespace(),
+		MultiClusterSetting: &pb.MultiClusterSetting{
+			Enabled:        in.Spec.MultiClusterSetting.Enabled,
+			PolicySelector: convertGSALabelSelectorToAllocationLabelSelector(&in.Spec.MultiClusterSetting.PolicySelector),
+			PreferLocal:    in.Spec.MultiClusterSetting.PreferLocal,
+		},
+		RequiredGameServerSelector:   convertGSALabelSelectorToAllocationLabelSelector(&in.Spec.Required),
+		PreferredGameServerSelectors: convertGSALabelSelectorsToAllocationLabelSelectors(in.Spec.Preferred),
+		Scheduling:                   convertGSASchedulingStrategyToAllocationScheduling(in.Spec.Scheduling),
+		AcknowledgeTimeoutSeconds:    in.Spec.AcknowledgeTimeoutSeconds,
+		FleetName:                    in.Spec.FleetName,
+		RequestID:                    in.Spec.RequestID,
+	}
+
+	if len(in.Spec.MetaPatch.Labels) != 0 || len(in.Spec.MetaPatch.Annotations) != 0 {
+		out.MetaPatch = &pb.MetaPatch{
+			Labels:      in.Spec.MetaPatch.Labels,
+			Annotations: in.Spec.MetaPatch.Annotations,
+		}
+	}
+
+	return out
+}
+
+// ConvertGSAToAllocationResponse converts GameServerAllocation V1 (GSA) to AllocationResponse
+func ConvertGSAToAllocationResponse(in *allocationv1.GameServerAllocation) *pb.AllocationResponse {
+	if in == nil {
+		return nil
+	}
+
+	return &pb.AllocationResponse{
+		State:                       convertGSAStateToAllocationState(in.Status.State),
+		GameServerName:              in.Status.GameServerName,
+		Address:                     in.Status.Address,
+		Addresses:                   convertAddressesToAllocationAddresses(in.Status.Addresses),
+		NodeName:                    in.Status.NodeName,
+		Ports:                       convertGSAAgonesPortsToAllocationPorts(in.Status.Ports),
+		GameServerUid:               string(in.Status.GameServerUID),
+		GameServerCreationTimestamp: convertK8sTimeToTimestamp(in.Status.GameServerCreationTimestamp),
+		Reason:                      string(in.Status.Reason),
+	}
+}
+
+// ConvertAllocationResponseToGSA converts AllocationResponse to GameServerAllocation V1 (GSA)
+func ConvertAllocationResponseToGSA(in *pb.AllocationResponse) *allocationv1.GameServerAllocation {
+	if in == nil {
+		return nil
+	}
+
+	return &allocationv1.GameServerAllocation{
+		Status: allocationv1.GameServerAllocationStatus{
+			State:                       convertAllocationStateToGSAState(in.GetState()),
+			GameServerName:              in.GetGameServerName(),
+			Address:                     in.GetAddress(),
+			Addresses:                   convertAllocationAddressesToAddresses(in.GetAddresses()),
+			NodeName:                    in.GetNodeName(),
+			Ports:                       convertAllocationPortsToGSAAgonesPorts(in.GetPorts()),
+			GameServerUID:               types.UID(in.GetGameServerUid()),
+			GameServerCreationTimestamp: convertTimestampToK8sTime(in.GetGameServerCreationTimestamp()),
+			Reason:                      apis.Reason(in.GetReason()),
+		},
+	}
+}
+
+// ConvertGameServerToAllocationResponse converts an allocated GameServer to the AllocationResponse that
+// WatchAllocate sends for each of its changes
+func ConvertGameServerToAllocationResponse(in *agonesv1.GameServer) *pb.AllocationResponse {
+	if in == nil {
+		return nil
+	}
+
+	creationTimestamp := in.ObjectMeta.CreationTimestamp
+	return &pb.AllocationResponse{
+		State:                       pb.AllocationResponse_Allocated,
+		GameServerName:              in.ObjectMeta.Name,
+		Address:                     in.Status.Address,
+		Addresses:                   convertAddressesToAllocationAddresses(in.Status.Addresses),
+		NodeName:                    in.Status.NodeName,
+		Ports:                       convertGSAAgonesPortsToAllocationPorts(in.Status.Ports),
+		GameServerUid:               string(in.ObjectMeta.UID),
+		GameServerCreationTimestamp: convertK8sTimeToTimestamp(&creationTimestamp),
+		GameServerState:             string(in.Status.State),
+		GameServerLabels:            in.ObjectMeta.Labels,
+		GameServerAnnotations:       in.ObjectMeta.Annotations,
+	}
+}
+
+// convertK8sTimeToTimestamp converts a k8s Time to a protobuf Timestamp
+func convertK8sTimeToTimestamp(in *metav1.Time) *timestamp.Timestamp {
+	if in == nil {
+		return nil
+	}
+	return &timestamp.Timestamp{Seconds: in.Unix(), Nanos: int32(in.Nanosecond())}
+}
+
+// convertTimestampToK8sTime converts a protobuf Timestamp to a k8s Time
+func convertTimestampToK8sTime(in *timestamp.Timestamp) *metav1.Time {
+	if in == nil {
+		return nil
+	}
+	t := metav1.Unix(in.GetSeconds(), int64(in.GetNanos()))
+	return &t
+}
+
+// convertGSAAgonesPortsToAllocationPorts converts GameServerStatusPort V1 (GSA) to AllocationResponse_GameServerStatusPort
+func convertGSAAgonesPortsToAllocationPorts(in []agonesv1.GameServerStatusPort) []*pb.AllocationResponse_GameServerStatusPort {
+	var pbPorts []*pb.AllocationResponse_GameServerStatusPort
+	for _, port := range in {
+		pbPort := &pb.AllocationResponse_GameServerStatusPort{
+			Name: port.Name,
+			Port: port.Port,
+		}
+		pbPorts = append(pbPorts, pbPort)
+	}
+	return pbPorts
+}
+
+// convertAllocationPortsToGSAAgonesPorts converts AllocationResponse_GameServerStatusPort to GameServerStatusPort V1 (GSA)
+func convertAllocationPortsToGSAAgonesPorts(in []*pb.AllocationResponse_GameServerStatusPort) []agonesv1.GameServerStatusPort {
+	var out []agonesv1.GameServerStatusPort
+	for _, port := range in {
+		p := agonesv1.GameServerStatusPort{
+			Name: port.GetName(),
+			Port: port.GetPort(),
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// convertAddressesToAllocationAddresses converts the addresses of a GameServer to AllocationResponse_GameServerStatusAddress
+func convertAddressesToAllocationAddresses(in []corev1.NodeAddress) []*pb.AllocationResponse_GameServerStatusAddress {
+	var out []*pb.AllocationResponse_GameServerStatusAddress
+	for _, addr := range in {
+		out = append(out, &pb.AllocationResponse_GameServerStatusAddress{
+			Type:    string(addr.Type),
+			Address: addr.Address,
+		})
+	}
+	return out
+}
+
+// convertAllocationAddressesToAddresses converts AllocationResponse_GameServerStatusAddress to the addresses of a GameServer
+func convertAllocationAddressesToAddresses(in []*pb.AllocationResponse_GameServerStatusAddress) []corev1.NodeAddress {
+	var out []corev1.NodeAddress
+	for _, addr := range in {
+		out = append(out, corev1.NodeAddress{
+			Type:    corev1.NodeAddressType(addr.GetType()),
+			Address: addr.GetAddress(),
+		})
+	}
+	return out
+}
+
+// convertGSAStateToAllocationState converts GameServerAllocationState V1 (GSA) to AllocationResponse_GameServerAllocationState
+func convertGSAStateToAllocationState(in allocationv1.GameServerAllocationState) pb.AllocationResponse_GameServerAllocationState {
+	switch in {
+	case allocationv1.GameServerAllocationAllocated:
+		return pb.AllocationResponse_Allocated
+	case allocationv1.GameServerAllocationUnAllocated:
+		return pb.AllocationResponse_UnAllocated
+	case allocationv1.GameServerAllocationContention:
+		return pb.AllocationResponse_Contention
+	}
+	return pb.AllocationResponse_Unknown
+}
+
+// convertAllocationStateToGSAState converts AllocationResponse_GameServerAllocationState to GameServerAllocationState V1 (GSA)
+func convertAllocationStateToGSAState(in pb.AllocationResponse_GameServerAllocationState) allocationv1.GameServerAllocationState {
+	switch in {
+	case pb.AllocationResponse_Allocated:
+		return allocationv1.GameServerAllocationAllocated
+	case pb.AllocationResponse_UnAllocated:
+		return allocationv1.GameServerAllocationUnAllocated
+	case pb.AllocationResponse_Contention:
+		return allocationv1.GameServerAllocationContention
+	}
+	return ""
+}
+
+// convertGSASchedulingStrategyToAllocationScheduling converts SchedulingStrategy to AllocationRequest_SchedulingStrategy
+func convertGSASchedulingStrategyToAllocationScheduling(in apis.SchedulingStrategy) pb.AllocationRequest_SchedulingStrategy {
+	switch in {
+	case apis.Distributed:
+		return pb.AllocationRequest_Distributed
+	}
+	return pb.AllocationRequest_Packed
+}
+
+// convertAllocationSchedulingToGSASchedulingStrategy converts AllocationRequest_SchedulingStrategy to SchedulingStrategy
+func convertAllocationSchedulingToGSASchedulingStrategy(in pb.AllocationRequest_SchedulingStrategy) apis.SchedulingStrategy {
+	switch in {
+	case pb.AllocationRequest_Distributed:
+		return apis.Distributed
+	}
+	return apis.Packed
+}
+
+// convertGSALabelSelectorToAllocationLabelSelector converts a metav1.LabelSelector to an allocation LabelSelector
+func convertGSALabelSelectorToAllocationLabelSelector(in *metav1.LabelSelector) *pb.LabelSelector {
+	if in == nil || (len(in.MatchLabels) == 0 && len(in.MatchExpressions) == 0) {
+		return nil
+	}
+
+	out := &pb.LabelSelector{MatchLabels: in.MatchLabels}
+	for _, req := range in.MatchExpressions {
+		out.MatchExpressions = append(out.MatchExpressions, &pb.LabelSelectorRequirement{
+			Key:      req.Key,
+			Operator: string(req.Operator),
+			Values:   req.Values,
+		})
+	}
+	return out
+}
+
+// convertAllocationLabelSelectorToGSALabelSelector converts an allocation LabelSelector to a metav1.LabelSelector
+func convertAllocationLabelSelectorToGSALabelSelector(in *pb.LabelSelector) *metav1.LabelSelector {
+	if in == nil {
+		return nil
+	}
+
+	out := &metav1.LabelSelector{MatchLabels: in.GetMatchLabels()}
+	for _, req := range in.GetMatchExpressions() {
+		out.MatchExpressions = append(out.MatchExpressions, metav1.LabelSelectorRequirement{
+			Key:      req.GetKey(),
+			Operator: metav1.LabelSelectorOperator(req.GetOperator()),
+			Values:   req.GetValues(),
+		})
+	}
+	return out
+}
+
+// convertGSALabelSelectorsToAllocationLabelSelectors converts a list of metav1.LabelSelector to a list of allocation LabelSelector
+func convertGSALabelSelectorsToAllocationLabelSelectors(in []metav1.LabelSelector) []*pb.LabelSelector {
+	var result []*pb.LabelSelector
+	for i := range in {
+		if selector := convertGSALabelSelectorToAllocationLabelSelector(&in[i]); selector != nil {
+			result = append(result, selector)
+		} else {
+			result = append(result, &pb.LabelSelector{})
+		}
+	}
+	return result
+}
+
+// convertAllocationLabelSelectorsToGSALabelSelectors converts a list of allocation LabelSelector to a list of metav1.LabelSelector
+func convertAllocationLabelSelectorsToGSALabelSelectors(in []*pb.LabelSelector) []metav1.LabelSelector {
+	var result []metav1.LabelSelector
+	for _, l := range in {
+		if selector := convertAllocationLabelSelectorToGSALabelSelector(l); selector != nil {
+			result = append(result, *selector)
+		} else {
+			result = append(result, metav1.LabelSelector{})
+		}
+	}
+	return result
+}
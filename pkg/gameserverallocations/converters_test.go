@@ -0,0 +1,319 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"testing"
+
+	pb "agones.dev/agones/pkg/allocation/go/v1alpha1"
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestConvertAllocationRequestToGameServerAllocation(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]struct {
+		in   *pb.AllocationRequest
+		want *allocationv1.GameServerAllocation
+	}{
+		"all fields are set": {
+			in: &pb.AllocationRequest{
+				Namespace: "ns",
+				MultiClusterSetting: &pb.MultiClusterSetting{
+					Enabled:     true,
+					PreferLocal: true,
+					PolicySelector: &pb.LabelSelector{
+						MatchLabels: map[string]string{"a": "b"},
+					},
+				},
+				RequiredGameServerSelector: &pb.LabelSelector{
+					MatchLabels: map[string]string{"c": "d"},
+					MatchExpressions: []*pb.LabelSelectorRequirement{
+						{Key: "e", Operator: "In", Values: []string{"f", "g"}},
+					},
+				},
+				PreferredGameServerSelectors: []*pb.LabelSelector{
+					{MatchLabels: map[string]string{"h": "i"}},
+					{},
+				},
+				Scheduling: pb.AllocationRequest_Distributed,
+				MetaPatch: &pb.MetaPatch{
+					Labels:      map[string]string{"j": "k"},
+					Annotations: map[string]string{"l": "m"},
+				},
+				AcknowledgeTimeoutSeconds: 30,
+				FleetName:                 "fleet",
+				RequestID:                 "request-1",
+			},
+			want: &allocationv1.GameServerAllocation{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: "ns",
+				},
+				Spec: allocationv1.GameServerAllocationSpec{
+					MultiClusterSetting: allocationv1.MultiClusterSetting{
+						Enabled:     true,
+						PreferLocal: true,
+						PolicySelector: metav1.LabelSelector{
+							MatchLabels: map[string]string{"a": "b"},
+						},
+					},
+					Required: metav1.LabelSelector{
+						MatchLabels: map[string]string{"c": "d"},
+						MatchExpressions: []metav1.LabelSelectorRequirement{
+							{Key: "e", Operator: metav1.LabelSelectorOpIn, Values: []string{"f", "g"}},
+						},
+					},
+					Preferred: []metav1.LabelSelector{
+						{MatchLabels: map[string]string{"h": "i"}},
+						{},
+					},
+					Scheduling: apis.Distributed,
+					MetaPatch: allocationv1.MetaPatch{
+						Labels:      map[string]string{"j": "k"},
+						Annotations: map[string]string{"l": "m"},
+					},
+					AcknowledgeTimeoutSeconds: 30,
+					FleetName:                 "fleet",
+					RequestID:                 "request-1",
+				},
+			},
+		},
+		"empty fields": {
+			in: &pb.AllocationRequest{},
+			want: &allocationv1.GameServerAllocation{
+				Spec: allocationv1.GameServerAllocationSpec{
+					Scheduling: apis.Packed,
+				},
+			},
+		},
+		"nil request": {
+			in:   nil,
+			want: nil,
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, v.want, ConvertAllocationRequestToGSA(v.in))
+		})
+	}
+}
+
+func TestConvertGameServerAllocationToAllocationRequest(t *testing.T) {
+	t.Parallel()
+
+	gsa := &allocationv1.GameServerAllocation{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "ns",
+		},
+		Spec: allocationv1.GameServerAllocationSpec{
+			MultiClusterSetting: allocationv1.MultiClusterSetting{
+				Enabled:     true,
+				PreferLocal: true,
+				PolicySelector: metav1.LabelSelector{
+					MatchLabels: map[string]string{"a": "b"},
+				},
+			},
+			Required: metav1.LabelSelector{
+				MatchLabels: map[string]string{"c": "d"},
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "e", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"f"}},
+				},
+			},
+			Preferred: []metav1.LabelSelector{
+				{MatchLabels: map[string]string{"h": "i"}},
+				{},
+			},
+			Scheduling: apis.Distributed,
+			MetaPatch: allocationv1.MetaPatch{
+				Labels:      map[string]string{"j": "k"},
+				Annotations: map[string]string{"l": "m"},
+			},
+			AcknowledgeTimeoutSeconds: 30,
+			FleetName:                 "fleet",
+			RequestID:                 "request-1",
+		},
+	}
+
+	out := ConvertGSAToAllocationRequest(gsa)
+	assert.Equal(t, &pb.AllocationRequest{
+		Namespace: "ns",
+		MultiClusterSetting: &pb.MultiClusterSetting{
+			Enabled:        true,
+			PolicySelector: &pb.LabelSelector{MatchLabels: map[string]string{"a": "b"}},
+			PreferLocal:    true,
+		},
+		RequiredGameServerSelector: &pb.LabelSelector{
+			MatchLabels: map[string]string{"c": "d"},
+			MatchExpressions: []*pb.LabelSelectorRequirement{
+				{Key: "e", Operator: "NotIn", Values: []string{"f"}},
+			},
+		},
+		PreferredGameServerSelectors: []*pb.LabelSelector{
+			{MatchLabels: map[string]string{"h": "i"}},
+			{},
+		},
+		Scheduling: pb.AllocationRequest_Distributed,
+		MetaPatch: &pb.MetaPatch{
+			Labels:      map[string]string{"j": "k"},
+			Annotations: map[string]string{"l": "m"},
+		},
+		AcknowledgeTimeoutSeconds: 30,
+		FleetName:                 "fleet",
+		RequestID:                 "request-1",
+	}, out)
+
+	// round trip
+	assert.Equal(t, gsa, ConvertAllocationRequestToGSA(out))
+
+	assert.Nil(t, ConvertGSAToAllocationRequest(nil))
+}
+
+func TestConvertGameServerAllocationToAllocationResponse(t *testing.T) {
+	t.Parallel()
+
+	created := metav1.Unix(1576000000, 100)
+
+	fixtures := map[string]struct {
+		in   *allocationv1.GameServerAllocation
+		want *pb.AllocationResponse
+	}{
+		"allocated": {
+			in: &allocationv1.GameServerAllocation{
+				Status: allocationv1.GameServerAllocationStatus{
+					State:          allocationv1.GameServerAllocationAllocated,
+					GameServerName: "GSN",
+					Ports: []agonesv1.GameServerStatusPort{
+						{Name: "default", Port: 123},
+						{Name: "game", Port: 456},
+					},
+					Address:                     "address",
+					NodeName:                    "node-name",
+					GameServerUID:               "1234",
+					GameServerCreationTimestamp: &created,
+					Addresses: []corev1.NodeAddress{
+						{Type: corev1.NodeExternalIP, Address: "address"},
+						{Type: corev1.NodeExternalIP, Address: "2001:db8::1"},
+					},
+				},
+			},
+			want: &pb.AllocationResponse{
+				State:          pb.AllocationResponse_Allocated,
+				GameServerName: "GSN",
+				Address:        "address",
+				NodeName:       "node-name",
+				Ports: []*pb.AllocationResponse_GameServerStatusPort{
+					{Name: "default", Port: 123},
+					{Name: "game", Port: 456},
+				},
+				GameServerUid:               "1234",
+				GameServerCreationTimestamp: &timestamp.Timestamp{Seconds: 1576000000, Nanos: 100},
+				Addresses: []*pb.AllocationResponse_GameServerStatusAddress{
+					{Type: "ExternalIP", Address: "address"},
+					{Type: "ExternalIP", Address: "2001:db8::1"},
+				},
+			},
+		},
+		"unallocated": {
+			in: &allocationv1.GameServerAllocation{
+				Status: allocationv1.GameServerAllocationStatus{
+					State:  allocationv1.GameServerAllocationUnAllocated,
+					Reason: apis.ReasonNoCapacity,
+				},
+			},
+			want: &pb.AllocationResponse{
+				State:  pb.AllocationResponse_UnAllocated,
+				Reason: string(apis.ReasonNoCapacity),
+			},
+		},
+		"contention": {
+			in: &allocationv1.GameServerAllocation{
+				Status: allocationv1.GameServerAllocationStatus{
+					State: allocationv1.GameServerAllocationContention,
+				},
+			},
+			want: &pb.AllocationResponse{
+				State: pb.AllocationResponse_Contention,
+			},
+		},
+		"nil allocation": {
+			in:   nil,
+			want: nil,
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			out := ConvertGSAToAllocationResponse(v.in)
+			assert.Equal(t, v.want, out)
+
+			if v.in != nil {
+				// round trip
+				assert.Equal(t, v.in, ConvertAllocationResponseToGSA(out))
+			}
+		})
+	}
+
+	assert.Equal(t, allocationv1.GameServerAllocationState(""), ConvertAllocationResponseToGSA(&pb.AllocationResponse{}).Status.State)
+}
+
+func TestConvertGameServerToAllocationResponse(t *testing.T) {
+	t.Parallel()
+
+	created := metav1.Unix(1576000000, 100)
+	gs := &agonesv1.GameServer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "GSN",
+			UID:               "1234",
+			CreationTimestamp: created,
+			Labels:            map[string]string{"players": "3"},
+			Annotations:       map[string]string{"match": "m1"},
+		},
+		Status: agonesv1.GameServerStatus{
+			State:    agonesv1.GameServerStateAllocated,
+			Ports:    []agonesv1.GameServerStatusPort{{Name: "default", Port: 123}},
+			Address:  "address",
+			NodeName: "node-name",
+			Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeExternalIP, Address: "address"},
+				{Type: agonesv1.NodePodIP, Address: "10.0.0.1"},
+			},
+		},
+	}
+
+	assert.Equal(t, &pb.AllocationResponse{
+		State:                       pb.AllocationResponse_Allocated,
+		GameServerName:              "GSN",
+		Address:                     "address",
+		NodeName:                    "node-name",
+		Ports:                       []*pb.AllocationResponse_GameServerStatusPort{{Name: "default", Port: 123}},
+		GameServerUid:               "1234",
+		GameServerCreationTimestamp: &timestamp.Timestamp{Seconds: 1576000000, Nanos: 100},
+		GameServerState:             "Allocated",
+		GameServerLabels:            map[string]string{"players": "3"},
+		GameServerAnnotations:       map[string]string{"match": "m1"},
+		Addresses: []*pb.AllocationResponse_GameServerStatusAddress{
+			{Type: "ExternalIP", Address: "address"},
+			{Type: "PodIP", Address: "10.0.0.1"},
+		},
+	}, ConvertGameServerToAllocationResponse(gs))
+	assert.Nil(t, ConvertGameServerToAllocationResponse(nil))
+}
@@ -0,0 +1,287 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"agones.dev/agones/pkg/util/logfields"
+	"github.com/pkg/errors"
+	"go.opencensus.io/stats"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	// errTooManyPendingWrites is returned for allocations while the allocator is degraded, and can't queue more writes
+	errTooManyPendingWrites = errors.New("allocator is degraded and has too many pending writes")
+	// errPendingWriteObsolete is returned when a queued write is for a GameServer that can no longer be allocated
+	errPendingWriteObsolete = errors.New("GameServer is no longer Ready")
+)
+
+// writeStatus is the outcome of moving an allocated GameServer to Allocated
+type writeStatus string
+
+const (
+	// writeSuccess is a write the apiserver accepted
+	writeSuccess writeStatus = "success"
+	// writeError is a write that failed, so the GameServer was not allocated
+	writeError writeStatus = "error"
+	// writeDeferred is a write queued while the allocator is degraded
+	writeDeferred writeStatus = "deferred"
+)
+
+// DegradedModeConfig configures how allocations are made while the apiserver fails to move allocated
+// GameServers to Allocated. Degraded mode is disabled if FailureThreshold is 0.
+type DegradedModeConfig struct {
+	// FailureThreshold is the number of consecutive writes failed by the apiserver after which the allocator
+	// is degraded, and returns allocations from its cache while it queues the writes
+	FailureThreshold int
+	// MaxPendingWrites is the number of writes that can be queued while degraded, after which allocations fail
+	MaxPendingWrites int
+	// MaxRetries is the number of times a queued write is retried before it is dropped
+	MaxRetries int
+	// RetryPeriod is how often the queued writes are retried
+	RetryPeriod time.Duration
+}
+
+// DefaultDegradedModeConfig is the default configuration of the degraded mode of the allocator, which is disabled
+var DefaultDegradedModeConfig = DegradedModeConfig{
+	MaxPendingWrites: 100,
+	MaxRetries:       30,
+	RetryPeriod:      time.Second,
+}
+
+// Validate returns an error if the DegradedModeConfig is invalid
+func (d DegradedModeConfig) Validate() error {
+	if d.FailureThreshold < 0 {
+		return errors.New("allocation degraded failure threshold must not be negative")
+	}
+	if d.FailureThreshold == 0 {
+		return nil
+	}
+	if d.MaxPendingWrites <= 0 {
+		return errors.New("allocation degraded max pending writes must be greater than 0")
+	}
+	if d.MaxRetries <= 0 {
+		return errors.New("allocation degraded max retries must be greater than 0")
+	}
+	if d.RetryPeriod <= 0 {
+		return errors.New("allocation degraded retry period must be greater than 0")
+	}
+	return nil
+}
+
+// pendingWrite is an allocated GameServer whose move to Allocated has not been written to the apiserver yet
+type pendingWrite struct {
+	gs       *agonesv1.GameServer
+	attempts int
+}
+
+// degradedWrites counts the consecutive writes the apiserver failed, and queues the writes while degraded
+type degradedWrites struct {
+	config   DegradedModeConfig
+	mutex    sync.Mutex
+	failures int
+	pending  []*pendingWrite
+}
+
+// newDegradedWrites returns a degradedWrites for the config
+func newDegradedWrites(config DegradedModeConfig) *degradedWrites {
+	return &degradedWrites{config: config}
+}
+
+// degraded returns true if the apiserver failed FailureThreshold consecutive writes, and has not recovered since
+func (d *degradedWrites) degraded() bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return d.config.FailureThreshold > 0 && d.failures >= d.config.FailureThreshold
+}
+
+// observe records the result of a write to the apiserver. Any response that is not
+// a failure of the apiserver itself shows it has recovered.
+func (d *degradedWrites) observe(err error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if err != nil && isAPIServerFailure(err) {
+		d.failures++
+	} else {
+		d.failures = 0
+	}
+}
+
+// enqueue queues the write of an allocated GameServer, and returns false if the queue is full
+func (d *degradedWrites) enqueue(gs *agonesv1.GameServer) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if len(d.pending) >= d.config.MaxPendingWrites {
+		return false
+	}
+	d.pending = append(d.pending, &pendingWrite{gs: gs})
+	return true
+}
+
+// remove removes the queued write of the GameServer, and returns false if it is not queued
+func (d *degradedWrites) remove(gs *agonesv1.GameServer) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	for i, w := range d.pending {
+		if w.gs.ObjectMeta.UID == gs.ObjectMeta.UID && w.gs.ObjectMeta.Namespace == gs.ObjectMeta.Namespace && w.gs.ObjectMeta.Name == gs.ObjectMeta.Name {
+			d.pending = append(d.pending[:i], d.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// take removes all the queued writes, and returns them in the order they were queued
+func (d *degradedWrites) take() []*pendingWrite {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	writes := d.pending
+	d.pending = nil
+	return writes
+}
+
+// requeue puts writes that were taken back at the front of the queue, ahead of the writes queued since
+func (d *degradedWrites) requeue(writes []*pendingWrite) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.pending = append(writes, d.pending...)
+}
+
+// len returns the number of queued writes
+func (d *degradedWrites) len() int {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	return len(d.pending)
+}
+
+// isAPIServerFailure returns true if the error is from the apiserver failing or being unreachable,
+// rather than from it rejecting the write
+func isAPIServerFailure(err error) bool {
+	if _, ok := err.(k8serrors.APIStatus); !ok {
+		return true
+	}
+	return k8serrors.IsServerTimeout(err) || k8serrors.IsTimeout(err) || k8serrors.IsInternalError(err) ||
+		k8serrors.IsServiceUnavailable(err) || k8serrors.IsTooManyRequests(err) || k8serrors.IsUnexpectedServerError(err)
+}
+
+// writeAllocatedGameServer moves the allocated GameServer to Allocated, and returns the status of the write.
+// While the allocator is degraded the write is queued instead, and the GameServer is returned as it will be
+// written, so that allocations keep being made from the cache. Allocations that wait for an acknowledgement
+// are never queued, as the game server can't see its allocation until it is written.
+func (c *Allocator) writeAllocatedGameServer(gsa *allocationv1.GameServerAllocation, gs agonesv1.GameServer) (*agonesv1.GameServer, writeStatus, error) {
+	if !c.degradedWrites.degraded() {
+		result, err := c.readyGameServerCache.PatchGameServerMetadata(gsa.Spec.MetaPatch, gs)
+		c.degradedWrites.observe(err)
+		if err == nil {
+			return result, writeSuccess, nil
+		}
+		if !c.degradedWrites.degraded() || gsa.Spec.AcknowledgeTimeoutSeconds > 0 {
+			return result, writeError, err
+		}
+		// the write that degraded the allocator is queued as well
+	} else if gsa.Spec.AcknowledgeTimeoutSeconds > 0 {
+		return &gs, writeError, errors.New("allocator is degraded and can't wait for the allocation to be acknowledged")
+	}
+
+	allocated := c.readyGameServerCache.allocatedGameServer(gsa.Spec.MetaPatch, *gs.DeepCopy())
+	if !c.degradedWrites.enqueue(allocated) {
+		return &gs, writeError, errTooManyPendingWrites
+	}
+	c.readyGameServerCache.holdPendingWrite(allocated)
+	stats.Record(context.Background(), pendingWritesStats.M(int64(c.degradedWrites.len())))
+	return allocated, writeDeferred, nil
+}
+
+// reconcilePendingWrites retries the writes queued while the allocator is degraded, in order. Retrying stops at the
+// first write the apiserver fails, and writes are dropped after MaxRetries attempts. Once a write gets a response
+// from the apiserver, the allocator is no longer degraded.
+func (c *Allocator) reconcilePendingWrites() {
+	writes := c.degradedWrites.take()
+	var retry []*pendingWrite
+	for i, w := range writes {
+		err := c.writePending(w.gs)
+		c.degradedWrites.observe(err)
+
+		logger := logfields.AugmentLogEntry(c.baseLogger, logfields.GameServerKey, w.gs.ObjectMeta.Namespace+"/"+w.gs.ObjectMeta.Name)
+		switch {
+		case err == nil:
+			c.readyGameServerCache.releasePendingWrite(w.gs)
+			continue
+		case err == errPendingWriteObsolete || k8serrors.IsNotFound(err):
+			logger.WithError(err).Warn("Dropping pending write of allocated GameServer")
+			c.dropPendingWrite(w)
+			continue
+		}
+
+		w.attempts++
+		if w.attempts >= c.degradedWrites.config.MaxRetries {
+			logger.WithError(err).Errorf("Dropping pending write of allocated GameServer after %d attempts", w.attempts)
+			c.dropPendingWrite(w)
+			continue
+		}
+		retry = append(retry, w)
+		if isAPIServerFailure(err) {
+			// the apiserver has not recovered, so the other writes wait for the next retry
+			retry = append(retry, writes[i+1:]...)
+			break
+		}
+	}
+	c.degradedWrites.requeue(retry)
+	stats.Record(context.Background(), pendingWritesStats.M(int64(c.degradedWrites.len())))
+}
+
+// writePending writes an allocated GameServer that was queued while degraded. If the GameServer changed since it
+// was allocated, the allocation is applied again to its current version, as long as it is still Ready.
+func (c *Allocator) writePending(gs *agonesv1.GameServer) error {
+	getter := c.readyGameServerCache.gameServerGetter.GameServers(gs.ObjectMeta.Namespace)
+	result, err := getter.Update(gs)
+	if k8serrors.IsConflict(err) {
+		var current *agonesv1.GameServer
+		current, err = getter.Get(gs.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if current.ObjectMeta.UID != gs.ObjectMeta.UID || current.Status.State != agonesv1.GameServerStateReady || current.IsBeingDeleted() {
+			return errPendingWriteObsolete
+		}
+		current = current.DeepCopy()
+		c.readyGameServerCache.patchMetadata(current, allocationv1.MetaPatch{Labels: gs.ObjectMeta.Labels, Annotations: gs.ObjectMeta.Annotations})
+		current.Status.State = agonesv1.GameServerStateAllocated
+		current.Status.AllocatedUntil = gs.Status.AllocatedUntil
+		result, err = getter.Update(current)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.readyGameServerCache.ObserveResourceVersion(result.ObjectMeta.ResourceVersion)
+	c.recorder.Event(result, corev1.EventTypeNormal, string(result.Status.State), "Allocated")
+	return nil
+}
+
+// dropPendingWrite gives up on writing an allocated GameServer. It is kept out of the cache until it is seen to
+// leave Ready, as its allocation was already returned.
+func (c *Allocator) dropPendingWrite(w *pendingWrite) {
+	c.readyGameServerCache.dropPendingWrite(w.gs)
+	stats.Record(context.Background(), droppedWritesStats.M(1))
+}
@@ -0,0 +1,278 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"agones.dev/agones/pkg/util/signals"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var testDegradedModeConfig = DegradedModeConfig{FailureThreshold: 1, MaxPendingWrites: 1, MaxRetries: 2, RetryPeriod: time.Second}
+
+func TestDegradedModeConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, DefaultDegradedModeConfig.Validate())
+	assert.NoError(t, testDegradedModeConfig.Validate())
+	assert.NoError(t, DegradedModeConfig{}.Validate())
+	assert.Error(t, DegradedModeConfig{FailureThreshold: -1}.Validate())
+	assert.Error(t, DegradedModeConfig{FailureThreshold: 1, MaxRetries: 1, RetryPeriod: time.Second}.Validate())
+	assert.Error(t, DegradedModeConfig{FailureThreshold: 1, MaxPendingWrites: 1, RetryPeriod: time.Second}.Validate())
+	assert.Error(t, DegradedModeConfig{FailureThreshold: 1, MaxPendingWrites: 1, MaxRetries: 1}.Validate())
+}
+
+func TestIsAPIServerFailure(t *testing.T) {
+	t.Parallel()
+
+	gr := schema.GroupResource{Group: "agones.dev", Resource: "gameservers"}
+	assert.True(t, isAPIServerFailure(errors.New("connection refused")))
+	assert.True(t, isAPIServerFailure(k8serrors.NewServiceUnavailable("brownout")))
+	assert.True(t, isAPIServerFailure(k8serrors.NewServerTimeout(gr, "update", 1)))
+	assert.True(t, isAPIServerFailure(k8serrors.NewInternalError(errors.New("etcd"))))
+	assert.True(t, isAPIServerFailure(k8serrors.NewTooManyRequests("slow down", 1)))
+	assert.False(t, isAPIServerFailure(k8serrors.NewConflict(gr, "gs1", errors.New("changed"))))
+	assert.False(t, isAPIServerFailure(k8serrors.NewNotFound(gr, "gs1")))
+}
+
+func TestAllocatorDegradedWrites(t *testing.T) {
+	t.Parallel()
+
+	stop := signals.NewStopChannel()
+	c, m := newFakeController()
+	c.allocator.degradedWrites = newDegradedWrites(testDegradedModeConfig)
+
+	var failing int32 = 1
+	var updates int32
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		atomic.AddInt32(&updates, 1)
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		assert.Equal(t, agonesv1.GameServerStateAllocated, gs.Status.State)
+		if atomic.LoadInt32(&failing) == 1 {
+			return true, &agonesv1.GameServer{}, k8serrors.NewServiceUnavailable("brownout")
+		}
+		return true, gs, nil
+	})
+
+	m.AgonesClient.AddReactor("get", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		name := action.(k8stesting.GetAction).GetName()
+		return true, &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs},
+			Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}}, nil
+	})
+	updateQueue := c.allocator.allocationUpdateWorkers(1, stop)
+	allocate := func(name string) response {
+		r := response{
+			request: request{
+				ctx: context.Background(),
+				gsa: &allocationv1.GameServerAllocation{Spec: allocationv1.GameServerAllocationSpec{
+					MetaPatch: allocationv1.MetaPatch{Labels: map[string]string{"mode": "deathmatch"}}}},
+				response: make(chan response),
+			},
+			ready: &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs},
+				Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}},
+		}
+		go func() {
+			updateQueue <- r
+		}()
+		return <-r.request.response
+	}
+
+	// the failed write degrades the allocator, and is queued
+	r := allocate("gs1")
+	assert.NoError(t, r.err)
+	assert.Equal(t, agonesv1.GameServerStateAllocated, r.gs.Status.State)
+	assert.Equal(t, "deathmatch", r.gs.ObjectMeta.Labels["mode"])
+	assert.True(t, c.allocator.degradedWrites.degraded())
+	assert.Equal(t, 1, c.allocator.degradedWrites.len())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&updates))
+	agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+
+	// a queued GameServer is not allocated again
+	c.allocator.readyGameServerCache.updateReadyGameServers([]*agonesv1.GameServer{
+		{ObjectMeta: metav1.ObjectMeta{Name: "gs1", Namespace: defaultNs}, Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}},
+	})
+	_, ok := c.allocator.readyGameServerCache.readyGameServers.Load(defaultNs + "/gs1")
+	assert.False(t, ok)
+
+	// while degraded, writes are not tried, and the queue is bounded
+	r = allocate("gs2")
+	assert.EqualError(t, r.err, "error updating allocated gameserver: "+errTooManyPendingWrites.Error())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&updates))
+	cached, ok := c.allocator.readyGameServerCache.readyGameServers.Load(defaultNs + "/gs2")
+	assert.True(t, ok)
+	assert.Empty(t, cached.ObjectMeta.Labels)
+
+	// retried while the apiserver is failing
+	c.allocator.reconcilePendingWrites()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&updates))
+	assert.Equal(t, 1, c.allocator.degradedWrites.len())
+	assert.True(t, c.allocator.degradedWrites.degraded())
+
+	// written once the apiserver recovers
+	atomic.StoreInt32(&failing, 0)
+	c.allocator.reconcilePendingWrites()
+	assert.Equal(t, int32(3), atomic.LoadInt32(&updates))
+	assert.Equal(t, 0, c.allocator.degradedWrites.len())
+	assert.False(t, c.allocator.degradedWrites.degraded())
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Allocated")
+	_, ok = c.allocator.readyGameServerCache.pendingWrites.Load(defaultNs + "/gs1")
+	assert.False(t, ok)
+}
+
+func TestAllocatorReconcilePendingWrites(t *testing.T) {
+	t.Parallel()
+
+	newGs := func(name string, state agonesv1.GameServerState) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs, UID: "1234", ResourceVersion: "1"},
+			Status: agonesv1.GameServerStatus{State: state}}
+	}
+	conflict := k8serrors.NewConflict(schema.GroupResource{Group: "agones.dev", Resource: "gameservers"}, "gs1", errors.New("changed"))
+
+	t.Run("changed and still ready", func(t *testing.T) {
+		c, m := newFakeController()
+		c.allocator.degradedWrites = newDegradedWrites(testDegradedModeConfig)
+
+		pending := newGs("gs1", agonesv1.GameServerStateAllocated)
+		pending.ObjectMeta.Labels = map[string]string{"mode": "deathmatch"}
+		current := newGs("gs1", agonesv1.GameServerStateReady)
+		current.ObjectMeta.ResourceVersion = "2"
+		current.ObjectMeta.Annotations = map[string]string{"health": "ok"}
+
+		m.AgonesClient.AddReactor("get", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, current, nil
+		})
+		var written *agonesv1.GameServer
+		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+			if gs.ObjectMeta.ResourceVersion != current.ObjectMeta.ResourceVersion {
+				return true, nil, conflict
+			}
+			written = gs
+			return true, gs, nil
+		})
+
+		assert.True(t, c.allocator.degradedWrites.enqueue(pending))
+		c.allocator.reconcilePendingWrites()
+
+		assert.Equal(t, 0, c.allocator.degradedWrites.len())
+		if assert.NotNil(t, written) {
+			assert.Equal(t, agonesv1.GameServerStateAllocated, written.Status.State)
+			assert.Equal(t, "deathmatch", written.ObjectMeta.Labels["mode"])
+			assert.Equal(t, "ok", written.ObjectMeta.Annotations["health"])
+		}
+	})
+
+	t.Run("no longer ready", func(t *testing.T) {
+		c, m := newFakeController()
+		c.allocator.degradedWrites = newDegradedWrites(testDegradedModeConfig)
+
+		m.AgonesClient.AddReactor("get", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, newGs("gs1", agonesv1.GameServerStateUnhealthy), nil
+		})
+		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, nil, conflict
+		})
+
+		pending := newGs("gs1", agonesv1.GameServerStateAllocated)
+		c.allocator.readyGameServerCache.holdPendingWrite(pending)
+		assert.True(t, c.allocator.degradedWrites.enqueue(pending))
+		c.allocator.reconcilePendingWrites()
+
+		assert.Equal(t, 0, c.allocator.degradedWrites.len())
+		_, ok := c.allocator.readyGameServerCache.pendingWrites.Load(defaultNs + "/gs1")
+		assert.False(t, ok)
+	})
+
+	t.Run("max retries", func(t *testing.T) {
+		c, m := newFakeController()
+		c.allocator.degradedWrites = newDegradedWrites(DegradedModeConfig{FailureThreshold: 1, MaxPendingWrites: 2, MaxRetries: 2, RetryPeriod: time.Second})
+
+		var updates int32
+		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			atomic.AddInt32(&updates, 1)
+			return true, nil, k8serrors.NewServiceUnavailable("brownout")
+		})
+
+		assert.True(t, c.allocator.degradedWrites.enqueue(newGs("gs1", agonesv1.GameServerStateAllocated)))
+		assert.True(t, c.allocator.degradedWrites.enqueue(newGs("gs2", agonesv1.GameServerStateAllocated)))
+
+		// only the first write is tried, while the apiserver is failing
+		c.allocator.reconcilePendingWrites()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&updates))
+		assert.Equal(t, 2, c.allocator.degradedWrites.len())
+
+		// the first write is dropped, so the next one is tried
+		c.allocator.reconcilePendingWrites()
+		assert.Equal(t, int32(3), atomic.LoadInt32(&updates))
+		writes := c.allocator.degradedWrites.take()
+		if assert.Len(t, writes, 1) {
+			assert.Equal(t, "gs2", writes[0].gs.ObjectMeta.Name)
+			assert.Equal(t, 1, writes[0].attempts)
+		}
+	})
+
+	t.Run("dropped write is not allocated again", func(t *testing.T) {
+		c, m := newFakeController()
+		c.allocator.degradedWrites = newDegradedWrites(DegradedModeConfig{FailureThreshold: 1, MaxPendingWrites: 1, MaxRetries: 1, RetryPeriod: time.Second})
+
+		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, nil, k8serrors.NewServiceUnavailable("brownout")
+		})
+
+		pending := newGs("gs1", agonesv1.GameServerStateAllocated)
+		c.allocator.readyGameServerCache.holdPendingWrite(pending)
+		assert.True(t, c.allocator.degradedWrites.enqueue(pending))
+		c.allocator.reconcilePendingWrites()
+		assert.Equal(t, 0, c.allocator.degradedWrites.len())
+
+		// the write never happened, so the informer still has it as Ready
+		cache := c.allocator.readyGameServerCache
+		cache.updateReadyGameServers([]*agonesv1.GameServer{newGs("gs1", agonesv1.GameServerStateReady)})
+		_, ok := cache.readyGameServers.Load(defaultNs + "/gs1")
+		assert.False(t, ok)
+
+		// until it leaves Ready
+		cache.updateReadyGameServers([]*agonesv1.GameServer{newGs("gs1", agonesv1.GameServerStateShutdown)})
+		_, ok = cache.droppedWrites.Load(defaultNs + "/gs1")
+		assert.False(t, ok)
+		cache.updateReadyGameServers([]*agonesv1.GameServer{newGs("gs1", agonesv1.GameServerStateReady)})
+		_, ok = cache.readyGameServers.Load(defaultNs + "/gs1")
+		assert.True(t, ok)
+
+		// a new GameServer with the same name can be allocated
+		cache.dropPendingWrite(pending)
+		replaced := newGs("gs1", agonesv1.GameServerStateReady)
+		replaced.ObjectMeta.UID = "5678"
+		replaced.ObjectMeta.ResourceVersion = "2"
+		cache.updateReadyGameServers([]*agonesv1.GameServer{replaced})
+		cached, ok := cache.readyGameServers.Load(defaultNs + "/gs1")
+		if assert.True(t, ok) {
+			assert.Equal(t, replaced.ObjectMeta.UID, cached.ObjectMeta.UID)
+		}
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/util/uuid"
+)
+
+// FilterWebhookConfig configures the webhook that filters and orders the Ready GameServers
+// that may be allocated for a GameServerAllocation
+type FilterWebhookConfig struct {
+	// URL of the webhook. The webhook is disabled if it is empty.
+	URL string
+	// Timeout of a request to the webhook
+	Timeout time.Duration
+	// MaxCandidates is the maximum number of GameServers that are sent to the webhook
+	MaxCandidates int
+}
+
+// DefaultFilterWebhookConfig is the default configuration of the allocation filter webhook, which is disabled
+var DefaultFilterWebhookConfig = FilterWebhookConfig{
+	Timeout:       time.Second,
+	MaxCandidates: 100,
+}
+
+// Validate returns an error if the FilterWebhookConfig is invalid
+func (w FilterWebhookConfig) Validate() error {
+	if w.URL == "" {
+		return nil
+	}
+	u, err := url.Parse(w.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.Errorf("allocation filter webhook url %q must be an absolute http or https url", w.URL)
+	}
+	if w.Timeout <= 0 {
+		return errors.New("allocation filter webhook timeout must be greater than 0")
+	}
+	if w.MaxCandidates <= 0 {
+		return errors.New("allocation filter webhook candidates must be greater than 0")
+	}
+	return nil
+}
+
+// filterWebhook sends the candidate GameServers of an allocation to an external webhook,
+// which decides which of them may be allocated, and in what order
+type filterWebhook struct {
+	config FilterWebhookConfig
+	client *http.Client
+}
+
+// newFilterWebhook returns the filterWebhook for the config, or nil if the webhook is disabled
+func newFilterWebhook(config FilterWebhookConfig) *filterWebhook {
+	if config.URL == "" {
+		return nil
+	}
+	return &filterWebhook{config: config, client: &http.Client{Timeout: config.Timeout}}
+}
+
+// filter sends the candidates to the webhook, and returns the names of the candidates that may be allocated
+// for the GameServerAllocation, in the order they should be tried.
+// Names in the response that are not candidates are ignored.
+func (w *filterWebhook) filter(gsa *allocationv1.GameServerAllocation, candidates []*agonesv1.GameServer) ([]string, error) {
+	review := allocationv1.AllocationFilterReview{
+		Request: &allocationv1.AllocationFilterRequest{
+			UID:        uuid.NewUUID(),
+			Namespace:  gsa.ObjectMeta.Namespace,
+			Spec:       gsa.Spec,
+			Candidates: make([]allocationv1.AllocationCandidate, 0, len(candidates)),
+		},
+	}
+	names := make(map[string]bool, len(candidates))
+	for _, gs := range candidates {
+		review.Request.Candidates = append(review.Request.Candidates, allocationv1.AllocationCandidate{
+			Name:        gs.ObjectMeta.Name,
+			NodeName:    gs.Status.NodeName,
+			Labels:      gs.ObjectMeta.Labels,
+			Annotations: gs.ObjectMeta.Annotations,
+		})
+		names[gs.ObjectMeta.Name] = true
+	}
+
+	body, err := json.Marshal(review)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not marshal allocation filter request")
+	}
+	res, err := w.client.Post(w.config.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "allocation filter webhook request failed")
+	}
+	defer res.Body.Close() // nolint: errcheck
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("bad status code %d from the allocation filter webhook", res.StatusCode)
+	}
+	result, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read allocation filter response")
+	}
+
+	var out allocationv1.AllocationFilterReview
+	if err := json.Unmarshal(result, &out); err != nil {
+		return nil, errors.Wrap(err, "could not unmarshal allocation filter response")
+	}
+	if out.Response == nil {
+		return nil, errors.New("allocation filter webhook returned no response")
+	}
+
+	allowed := make([]string, 0, len(out.Response.GameServers))
+	for _, name := range out.Response.GameServers {
+		if names[name] {
+			allowed = append(allowed, name)
+		}
+	}
+	return allowed, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFilterWebhookConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, DefaultFilterWebhookConfig.Validate())
+	assert.NoError(t, FilterWebhookConfig{URL: "https://filter.example.com/allocate", Timeout: time.Second, MaxCandidates: 10}.Validate())
+	assert.Error(t, FilterWebhookConfig{URL: "filter.example.com", Timeout: time.Second, MaxCandidates: 10}.Validate())
+	assert.Error(t, FilterWebhookConfig{URL: "ftp://filter.example.com", Timeout: time.Second, MaxCandidates: 10}.Validate())
+	assert.Error(t, FilterWebhookConfig{URL: "http://filter.example.com", MaxCandidates: 10}.Validate())
+	assert.Error(t, FilterWebhookConfig{URL: "http://filter.example.com", Timeout: time.Second}.Validate())
+}
+
+func TestFilterWebhookFilter(t *testing.T) {
+	t.Parallel()
+
+	gsa := &allocationv1.GameServerAllocation{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec:       allocationv1.GameServerAllocationSpec{Scheduling: apis.Packed},
+	}
+	candidates := []*agonesv1.GameServer{
+		{ObjectMeta: metav1.ObjectMeta{Name: "gs1", Labels: map[string]string{"mode": "ctf"}}, Status: agonesv1.GameServerStatus{NodeName: "node1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "gs2"}, Status: agonesv1.GameServerStatus{NodeName: "node2"}},
+	}
+
+	t.Run("filtered", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var review allocationv1.AllocationFilterReview
+			if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&review)) {
+				return
+			}
+			assert.Equal(t, defaultNs, review.Request.Namespace)
+			assert.Equal(t, []allocationv1.AllocationCandidate{
+				{Name: "gs1", NodeName: "node1", Labels: map[string]string{"mode": "ctf"}},
+				{Name: "gs2", NodeName: "node2"},
+			}, review.Request.Candidates)
+
+			review.Response = &allocationv1.AllocationFilterResponse{UID: review.Request.UID, GameServers: []string{"gs2", "unknown", "gs1"}}
+			assert.NoError(t, json.NewEncoder(w).Encode(review))
+		}))
+		defer server.Close()
+
+		w := newFilterWebhook(FilterWebhookConfig{URL: server.URL, Timeout: time.Second, MaxCandidates: 10})
+		names, err := w.filter(gsa, candidates)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"gs2", "gs1"}, names)
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		w := newFilterWebhook(FilterWebhookConfig{URL: server.URL, Timeout: time.Second, MaxCandidates: 10})
+		_, err := w.filter(gsa, candidates)
+		assert.EqualError(t, err, "bad status code 500 from the allocation filter webhook")
+	})
+
+	t.Run("no response", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{}`))
+		}))
+		defer server.Close()
+
+		w := newFilterWebhook(FilterWebhookConfig{URL: server.URL, Timeout: time.Second, MaxCandidates: 10})
+		_, err := w.filter(gsa, candidates)
+		assert.EqualError(t, err, "allocation filter webhook returned no response")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		assert.Nil(t, newFilterWebhook(DefaultFilterWebhookConfig))
+	})
+}
+
+func TestFindCandidatesForAllocation(t *testing.T) {
+	t.Parallel()
+
+	newGs := func(name string, labels map[string]string) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs, Labels: labels},
+			Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}}
+	}
+	list := newSortedGameServers([]*agonesv1.GameServer{
+		newGs("gs0", map[string]string{"mode": "ctf"}),
+		newGs("gs1", map[string]string{"mode": "deathmatch"}),
+		newGs("gs2", map[string]string{"mode": "ctf", "map": "garden"}),
+		newGs("gs3", map[string]string{"mode": "ctf"}),
+		newGs("gs4", nil),
+	}, nil)
+
+	gsa := &allocationv1.GameServerAllocation{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:   metav1.LabelSelector{MatchLabels: map[string]string{"mode": "ctf"}},
+			Preferred:  []metav1.LabelSelector{{MatchLabels: map[string]string{"mode": "deathmatch"}}},
+			Scheduling: apis.Packed,
+		},
+	}
+
+	names := func(list []*agonesv1.GameServer) []string {
+		var result []string
+		for _, gs := range list {
+			result = append(result, gs.ObjectMeta.Name)
+		}
+		return result
+	}
+
+	candidates, err := findCandidatesForAllocation(gsa, list, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gs0", "gs1", "gs2", "gs3"}, names(candidates))
+
+	candidates, err = findCandidatesForAllocation(gsa, list, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gs0", "gs1"}, names(candidates))
+
+	list.remove(0)
+	candidates, err = findCandidatesForAllocation(gsa, list, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"gs1", "gs2"}, names(candidates))
+
+	gs, index, err := findFilteredGameServerForAllocation(gsa, []string{"gs0", "gs3", "gs2"}, list)
+	assert.NoError(t, err)
+	assert.Equal(t, "gs3", gs.ObjectMeta.Name)
+	assert.Equal(t, 3, index)
+
+	_, _, err = findFilteredGameServerForAllocation(gsa, []string{"gs0", "unknown"}, list)
+	assert.Equal(t, ErrNoGameServerReady, err)
+
+	_, _, err = findFilteredGameServerForAllocation(gsa, nil, list)
+	assert.Equal(t, ErrNoGameServerReady, err)
+}
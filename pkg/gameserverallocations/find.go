@@ -15,9 +15,6 @@
 package gameserverallocations
 
 import (
-	"math/rand"
-
-	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
 	"github.com/pkg/errors"
@@ -26,15 +23,26 @@ import (
 )
 
 // findGameServerForAllocation finds an optimal gameserver, given the
-// set of preferred and required selectors on the GameServerAllocation. This also returns the index
+// set of preferred and required selectors on the GameServerAllocation. This also returns the position
 // that the gameserver was found at in `list`, in case you want to remove it from the list
+// The order in which the list is searched is decided by the Strategy registered for the scheduling strategy, e.g.
 // Packed: will search list from start to finish
 // Distributed: will search in a random order through the list
+// Only the GameServers in the index of `list` that can match the selectors are searched.
 // It is assumed that all gameservers passed in, are Ready and not being deleted, and are sorted in Packed priority order
-func findGameServerForAllocation(gsa *allocationv1.GameServerAllocation, list []*agonesv1.GameServer) (*agonesv1.GameServer, int, error) {
+func findGameServerForAllocation(gsa *allocationv1.GameServerAllocation, list *sortedGameServers) (*agonesv1.GameServer, int, error) {
+	return findSelectedGameServerForAllocation(gsa, list, 1, nil)
+}
+
+// findSelectedGameServerForAllocation finds the gameservers that match the best of the preferred and required
+// selectors on the GameServerAllocation, like findGameServerForAllocation, but takes up to topN of them, in the
+// order of the Strategy, and allocates the one at the position returned by pick(number of gameservers taken).
+func findSelectedGameServerForAllocation(gsa *allocationv1.GameServerAllocation, list *sortedGameServers, topN int, pick func(n int) int) (*agonesv1.GameServer, int, error) {
 	type result struct {
 		gs    *agonesv1.GameServer
 		index int
+		// pos is the position of the gameserver in the order it was searched in
+		pos int
 	}
 
 	requiredSelector, err := metav1.LabelSelectorAsSelector(&gsa.Spec.Required)
@@ -50,41 +58,21 @@ func findGameServerForAllocation(gsa *allocationv1.GameServerAllocation, list []
 	var required *result
 	preferred := make([]*result, len(preferredSelector))
 
-	var loop func(list []*agonesv1.GameServer, f func(i int, gs *agonesv1.GameServer))
-
-	// packed is forward looping, distributed is random looping
-	switch gsa.Spec.Scheduling {
-	case apis.Packed:
-		loop = func(list []*agonesv1.GameServer, f func(i int, gs *agonesv1.GameServer)) {
-			for i, gs := range list {
-				f(i, gs)
-			}
-		}
-	case apis.Distributed:
-		// randomised looping - make a list of indices, and then randomise them
-		// as we don't want to change the order of the gameserver slice
-		l := len(list)
-		indices := make([]int, l)
-		for i := 0; i < l; i++ {
-			indices[i] = i
-		}
-		rand.Shuffle(l, func(i, j int) {
-			indices[i], indices[j] = indices[j], indices[i]
-		})
-
-		loop = func(list []*agonesv1.GameServer, f func(i int, gs *agonesv1.GameServer)) {
-			for _, i := range indices {
-				f(i, list[i])
-			}
-		}
-	default:
+	strategy, ok := strategyFor(gsa.Spec.Scheduling)
+	if !ok {
 		return nil, -1, errors.Errorf("scheduling strategy of '%s' is not supported", gsa.Spec.Scheduling)
 	}
-
-	loop(list, func(i int, gs *agonesv1.GameServer) {
-		// only search the same namespace
-		if gs.ObjectMeta.Namespace != gsa.ObjectMeta.Namespace {
-			return
+	next := strategy.Order(list.list, list.candidates(gsa))
+	// the positions that have been tried, so the top candidates can be picked from them
+	var tried []int
+
+	for i, more := next(); more; i, more = next() {
+		pos := len(tried)
+		tried = append(tried, i)
+		gs := list.list[i]
+		// skip gameservers that have been removed from the list
+		if gs == nil {
+			continue
 		}
 
 		set := labels.Set(gs.ObjectMeta.Labels)
@@ -92,25 +80,148 @@ func findGameServerForAllocation(gsa *allocationv1.GameServerAllocation, list []
 		// first look at preferred
 		for j, sel := range preferredSelector {
 			if preferred[j] == nil && sel.Matches(set) {
-				preferred[j] = &result{gs: gs, index: i}
+				preferred[j] = &result{gs: gs, index: i, pos: pos}
 			}
 		}
 
 		// then look at required
 		if required == nil && requiredSelector.Matches(set) {
-			required = &result{gs: gs, index: i}
+			required = &result{gs: gs, index: i, pos: pos}
+		}
+
+		// there can't be a better match than the first preferred selector, or the required selector if there are no preferred
+		if len(preferred) > 0 {
+			if preferred[0] != nil {
+				break
+			}
+		} else if required != nil {
+			break
 		}
-	})
+	}
 
-	for _, r := range preferred {
+	var best *result
+	var selector labels.Selector
+	for j, r := range preferred {
 		if r != nil {
-			return r.gs, r.index, nil
+			best, selector = r, preferredSelector[j]
+			break
 		}
 	}
 
-	if required == nil {
-		return nil, 0, ErrNoGameServerReady
+	if best == nil {
+		if required == nil {
+			return nil, 0, ErrNoGameServerReady
+		}
+		best, selector = required, requiredSelector
 	}
 
-	return required.gs, required.index, nil
+	if topN <= 1 || pick == nil {
+		return best.gs, best.index, nil
+	}
+
+	// no gameserver later in the order matches a better selector, as the search would have found it
+	top := []*result{best}
+	for pos := best.pos + 1; len(top) < topN; pos++ {
+		var i int
+		if pos < len(tried) {
+			i = tried[pos]
+		} else if n, more := next(); more {
+			i = n
+			tried = append(tried, i)
+		} else {
+			break
+		}
+		if gs := list.list[i]; gs != nil && selector.Matches(labels.Set(gs.ObjectMeta.Labels)) {
+			top = append(top, &result{gs: gs, index: i, pos: pos})
+		}
+	}
+	r := top[pick(len(top))]
+	return r.gs, r.index, nil
+}
+
+// findWithFallbackFleets finds a GameServer for the GameServerAllocation with find, and if none of the Fleet of its
+// fleetName is Ready, for each of its fallback fleets in turn, so the fallbacks are tried within the same batch.
+func findWithFallbackFleets(gsa *allocationv1.GameServerAllocation, list *sortedGameServers,
+	find func(*allocationv1.GameServerAllocation, *sortedGameServers) (*agonesv1.GameServer, int, error)) (*agonesv1.GameServer, int, error) {
+	var gs *agonesv1.GameServer
+	var index int
+	var err error
+	for _, fleetGsa := range fleetAllocations(gsa) {
+		gs, index, err = find(fleetGsa, list)
+		if err != ErrNoGameServerReady {
+			break
+		}
+	}
+	return gs, index, err
+}
+
+// fleetAllocations returns the GameServerAllocation, followed by a copy of it for each of its fallback fleets, in order,
+// which requires the Fleet name label of the fallback fleet instead
+func fleetAllocations(gsa *allocationv1.GameServerAllocation) []*allocationv1.GameServerAllocation {
+	result := []*allocationv1.GameServerAllocation{gsa}
+	for _, name := range gsa.Spec.FallbackFleetNames {
+		fallback := *gsa
+		fallback.Spec.FleetName = name
+		fallback.Spec.Required = *gsa.Spec.Required.DeepCopy()
+		if fallback.Spec.Required.MatchLabels == nil {
+			fallback.Spec.Required.MatchLabels = map[string]string{}
+		}
+		fallback.Spec.Required.MatchLabels[agonesv1.FleetNameLabel] = name
+		result = append(result, &fallback)
+	}
+	return result
+}
+
+// findCandidatesForAllocation returns up to max GameServers in `list` that match the required or any of the
+// preferred selectors of the GameServerAllocation, in the order of the Strategy registered for its scheduling strategy.
+// These are the candidates that are sent to the allocation filter webhook.
+func findCandidatesForAllocation(gsa *allocationv1.GameServerAllocation, list *sortedGameServers, max int) ([]*agonesv1.GameServer, error) {
+	requiredSelector, err := metav1.LabelSelectorAsSelector(&gsa.Spec.Required)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not convert GameServerAllocation selector")
+	}
+
+	preferredSelector, err := gsa.Spec.PreferredSelectors()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not convert preferred selectors for GameServerAllocation")
+	}
+
+	strategy, ok := strategyFor(gsa.Spec.Scheduling)
+	if !ok {
+		return nil, errors.Errorf("scheduling strategy of '%s' is not supported", gsa.Spec.Scheduling)
+	}
+
+	var result []*agonesv1.GameServer
+	next := strategy.Order(list.list, list.candidates(gsa))
+	for i, more := next(); more; i, more = next() {
+		gs := list.list[i]
+		if gs == nil {
+			continue
+		}
+		set := labels.Set(gs.ObjectMeta.Labels)
+		match := requiredSelector.Matches(set)
+		for _, sel := range preferredSelector {
+			match = match || sel.Matches(set)
+		}
+		if !match {
+			continue
+		}
+		result = append(result, gs)
+		if len(result) >= max {
+			break
+		}
+	}
+	return result, nil
+}
+
+// findFilteredGameServerForAllocation finds the first GameServer in `names` that is still in `list`, where `names`
+// are the GameServers that the allocation filter webhook allowed for the GameServerAllocation, in the order they
+// should be tried. This also returns the position that the gameserver was found at in `list`.
+func findFilteredGameServerForAllocation(gsa *allocationv1.GameServerAllocation, names []string, list *sortedGameServers) (*agonesv1.GameServer, int, error) {
+	for _, name := range names {
+		if i, ok := list.position(gsa.ObjectMeta.Namespace, name); ok {
+			return list.list[i], i, nil
+		}
+	}
+	return nil, 0, ErrNoGameServerReady
 }
@@ -52,7 +52,7 @@ func TestFindGameServerForAllocationPacked(t *testing.T) {
 
 	fixtures := map[string]struct {
 		list []agonesv1.GameServer
-		test func(*testing.T, []*agonesv1.GameServer)
+		test func(*testing.T, *sortedGameServers)
 	}{
 		"required": {
 			list: []agonesv1.GameServer{
@@ -64,8 +64,8 @@ func TestFindGameServerForAllocationPacked(t *testing.T) {
 				{ObjectMeta: metav1.ObjectMeta{Name: "gs5", Namespace: defaultNs, Labels: labels}, Status: agonesv1.GameServerStatus{NodeName: "node1", State: agonesv1.GameServerStateError}},
 				{ObjectMeta: metav1.ObjectMeta{Name: "gs6", Namespace: "does-not-apply", Labels: labels}, Status: agonesv1.GameServerStatus{NodeName: "node1", State: agonesv1.GameServerStateReady}},
 			},
-			test: func(t *testing.T, list []*agonesv1.GameServer) {
-				assert.Len(t, list, 3)
+			test: func(t *testing.T, list *sortedGameServers) {
+				assert.Len(t, list.list, 3)
 
 				gs, index, err := findGameServerForAllocation(gsa, list)
 				assert.NoError(t, err)
@@ -74,13 +74,12 @@ func TestFindGameServerForAllocationPacked(t *testing.T) {
 				}
 				assert.Equal(t, "node1", gs.Status.NodeName)
 				assert.Equal(t, "gs1", gs.ObjectMeta.Name)
-				assert.Equal(t, gs, list[index])
+				assert.Equal(t, gs, list.list[index])
 				assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
 
 				// mock that the first found game server is allocated
-				list = append(list[:index], list[index+1:]...)
-				assert.Equal(t, agonesv1.GameServerStateReady, list[0].Status.State)
-				assert.Len(t, list, 2)
+				list.remove(index)
+				assert.Nil(t, list.list[index])
 
 				gs, index, err = findGameServerForAllocation(gsa, list)
 				assert.NoError(t, err)
@@ -89,10 +88,10 @@ func TestFindGameServerForAllocationPacked(t *testing.T) {
 				}
 				assert.Equal(t, "node2", gs.Status.NodeName)
 				assert.Equal(t, "gs2", gs.ObjectMeta.Name)
-				assert.Equal(t, gs, list[index])
+				assert.Equal(t, gs, list.list[index])
 				assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
 
-				list = nil
+				list = newSortedGameServers(nil, nil)
 				gs, _, err = findGameServerForAllocation(gsa, list)
 				assert.Error(t, err)
 				assert.Equal(t, ErrNoGameServerReady, err)
@@ -108,30 +107,30 @@ func TestFindGameServerForAllocationPacked(t *testing.T) {
 				{ObjectMeta: metav1.ObjectMeta{Name: "gs5", Namespace: defaultNs, Labels: labels}, Status: agonesv1.GameServerStatus{NodeName: "node1", State: agonesv1.GameServerStateReady}},
 				{ObjectMeta: metav1.ObjectMeta{Name: "gs6", Namespace: defaultNs, Labels: labels}, Status: agonesv1.GameServerStatus{NodeName: "node1", State: agonesv1.GameServerStateReady}},
 			},
-			test: func(t *testing.T, list []*agonesv1.GameServer) {
-				assert.Len(t, list, 6)
+			test: func(t *testing.T, list *sortedGameServers) {
+				assert.Len(t, list.list, 6)
 
 				gs, index, err := findGameServerForAllocation(prefGsa, list)
 				assert.NoError(t, err)
 				assert.Equal(t, "node1", gs.Status.NodeName)
 				assert.Equal(t, "gs1", gs.ObjectMeta.Name)
-				assert.Equal(t, gs, list[index])
+				assert.Equal(t, gs, list.list[index])
 				assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
 
-				list = append(list[:index], list[index+1:]...)
+				list.remove(index)
 				gs, index, err = findGameServerForAllocation(prefGsa, list)
 				assert.NoError(t, err)
 				assert.Equal(t, "node2", gs.Status.NodeName)
 				assert.Equal(t, "gs4", gs.ObjectMeta.Name)
-				assert.Equal(t, gs, list[index])
+				assert.Equal(t, gs, list.list[index])
 				assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
 
-				list = append(list[:index], list[index+1:]...)
+				list.remove(index)
 				gs, index, err = findGameServerForAllocation(prefGsa, list)
 				assert.NoError(t, err)
 				assert.Equal(t, "node1", gs.Status.NodeName)
 				assert.Contains(t, []string{"gs3", "gs5", "gs6"}, gs.ObjectMeta.Name)
-				assert.Equal(t, gs, list[index])
+				assert.Equal(t, gs, list.list[index])
 				assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
 			},
 		},
@@ -146,13 +145,13 @@ func TestFindGameServerForAllocationPacked(t *testing.T) {
 				{ObjectMeta: metav1.ObjectMeta{Name: "gs7", Labels: labels, Namespace: defaultNs}, Status: agonesv1.GameServerStatus{NodeName: "node2", State: agonesv1.GameServerStateReady}},
 				{ObjectMeta: metav1.ObjectMeta{Name: "gs8", Labels: labels, Namespace: defaultNs}, Status: agonesv1.GameServerStatus{NodeName: "node2", State: agonesv1.GameServerStateReady}},
 			},
-			test: func(t *testing.T, list []*agonesv1.GameServer) {
-				assert.Len(t, list, 4)
+			test: func(t *testing.T, list *sortedGameServers) {
+				assert.Len(t, list.list, 4)
 
 				gs, index, err := findGameServerForAllocation(gsa, list)
 				assert.Nil(t, err)
 				assert.Equal(t, "node2", gs.Status.NodeName)
-				assert.Equal(t, gs, list[index])
+				assert.Equal(t, gs, list.list[index])
 				assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
 			},
 		},
@@ -177,7 +176,7 @@ func TestFindGameServerForAllocationPacked(t *testing.T) {
 			err = c.counter.Run(0, stop)
 			assert.Nil(t, err)
 
-			list := c.ListSortedReadyGameServers()
+			list := c.indexedSortedReadyGameServers()
 			v.test(t, list)
 		})
 	}
@@ -231,27 +230,60 @@ func TestFindGameServerForAllocationDistributed(t *testing.T) {
 	err = c.counter.Run(0, stop)
 	assert.Nil(t, err)
 
-	list := c.ListSortedReadyGameServers()
-	assert.Len(t, list, 6)
+	list := c.indexedSortedReadyGameServers()
+	assert.Len(t, list.list, 6)
 
 	gs, index, err := findGameServerForAllocation(gsa, list)
 	assert.NoError(t, err)
-	assert.Equal(t, gs, list[index])
+	assert.Equal(t, gs, list.list[index])
 	assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
 
-	past := gs
-	// we should get a different result in 10 tries, so we can see we get some randomness.
-	for i := 0; i < 10; i++ {
-		gs, index, err = findGameServerForAllocation(gsa, list)
-		assert.NoError(t, err)
-		assert.Equal(t, gs, list[index])
-		assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
-
-		if gs.ObjectMeta.Name != past.ObjectMeta.Name {
-			return
-		}
+	// node3 has the fewest GameServers on it, so it is tried first
+	assert.Equal(t, "gs7", gs.ObjectMeta.Name)
+}
+
+func TestFindWithFallbackFleets(t *testing.T) {
+	t.Parallel()
+
+	fleetGameServer := func(name, fleet string) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs,
+			Labels: map[string]string{agonesv1.FleetNameLabel: fleet, "mode": "ranked"}},
+			Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}}
 	}
+	list := newSortedGameServers([]*agonesv1.GameServer{
+		fleetGameServer("us-1", "region-us"),
+		fleetGameServer("eu-backup-1", "region-eu-backup"),
+	}, []string{agonesv1.FleetNameLabel})
 
-	assert.FailNow(t, "We should get a different gameserver by now")
+	gsa := &allocationv1.GameServerAllocation{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:           metav1.LabelSelector{MatchLabels: map[string]string{"mode": "ranked"}},
+			FleetName:          "region-eu",
+			FallbackFleetNames: []string{"region-eu-backup", "region-us"},
+			Scheduling:         apis.Packed,
+		},
+	}
+	gsa.ApplyDefaults()
+
+	allocations := fleetAllocations(gsa)
+	if assert.Len(t, allocations, 3) {
+		assert.Equal(t, gsa, allocations[0])
+		assert.Equal(t, map[string]string{agonesv1.FleetNameLabel: "region-us", "mode": "ranked"}, allocations[2].Spec.Required.MatchLabels)
+	}
+	// the fallbacks don't change the GameServerAllocation
+	assert.Equal(t, "region-eu", gsa.Spec.Required.MatchLabels[agonesv1.FleetNameLabel])
+
+	gs, index, err := findWithFallbackFleets(gsa, list, findGameServerForAllocation)
+	assert.NoError(t, err)
+	assert.Equal(t, "eu-backup-1", gs.ObjectMeta.Name)
+	list.remove(index)
+
+	gs, index, err = findWithFallbackFleets(gsa, list, findGameServerForAllocation)
+	assert.NoError(t, err)
+	assert.Equal(t, "us-1", gs.ObjectMeta.Name)
+	list.remove(index)
 
+	_, _, err = findWithFallbackFleets(gsa, list, findGameServerForAllocation)
+	assert.Equal(t, ErrNoGameServerReady, err)
 }
@@ -0,0 +1,129 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"agones.dev/agones/pkg/apis"
+	"agones.dev/agones/pkg/apis/agones"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// annotationListSeparator separates the values of an annotation patched with the AppendToList merge policy
+	annotationListSeparator = ","
+	// labelListSeparator separates the values of a label patched with the AppendToList merge policy,
+	// as label values can't contain commas
+	labelListSeparator = "."
+)
+
+// ErrMetaPatchConflict is returned when the GameServer chosen for an allocation with the FailOnConflict merge policy
+// already has one of the labels or annotations of the allocation, with a different value
+var ErrMetaPatchConflict = errors.New("The GameServer has a label or annotation that conflicts with the allocation")
+
+// DefaultProtectedMetadataPrefixes are the prefixes of the labels and annotations that allocations can't patch
+// by default, which are the ones owned by the Agones controllers
+var DefaultProtectedMetadataPrefixes = []string{agones.GroupName + "/"}
+
+// validateMetaPatch returns a cause with the MetaPatchProtected reason for each label and annotation
+// of the GameServerAllocation that has one of the protected prefixes
+func validateMetaPatch(gsa *allocationv1.GameServerAllocation, protectedPrefixes []string) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	check := func(field string, metadata map[string]string) {
+		for _, key := range sortedKeys(metadata) {
+			for _, prefix := range protectedPrefixes {
+				if strings.HasPrefix(key, prefix) {
+					causes = append(causes, metav1.StatusCause{Type: metav1.CauseType(apis.ReasonMetaPatchProtected),
+						Field:   fmt.Sprintf("spec.metadata.%s[%s]", field, key),
+						Message: fmt.Sprintf("%s with the prefix %s can't be patched by allocations", field, prefix)})
+					break
+				}
+			}
+		}
+	}
+	check("labels", gsa.Spec.MetaPatch.Labels)
+	check("annotations", gsa.Spec.MetaPatch.Annotations)
+	return causes
+}
+
+// metaPatchConflict returns ErrMetaPatchConflict if the MetaPatch has the FailOnConflict merge policy,
+// and the GameServer already has one of its labels or annotations with a different value
+func metaPatchConflict(fam allocationv1.MetaPatch, gs *agonesv1.GameServer) error {
+	if fam.MergePolicy != allocationv1.MetaPatchFailOnConflict {
+		return nil
+	}
+	conflicts := func(patch, metadata map[string]string) bool {
+		for key, value := range patch {
+			if current, ok := metadata[key]; ok && current != value {
+				return true
+			}
+		}
+		return false
+	}
+	if conflicts(fam.Labels, gs.ObjectMeta.Labels) || conflicts(fam.Annotations, gs.ObjectMeta.Annotations) {
+		return ErrMetaPatchConflict
+	}
+	return nil
+}
+
+// mergeMetadata merges the patch into the labels or annotations in metadata, with the merge policy,
+// and returns the merged metadata. Conflicts of the FailOnConflict merge policy are checked beforehand,
+// so the values of the patch are set as they are.
+func mergeMetadata(metadata, patch map[string]string, policy allocationv1.MetaPatchMergePolicy, separator string) map[string]string {
+	if patch == nil {
+		return metadata
+	}
+	if metadata == nil {
+		metadata = make(map[string]string, len(patch))
+	}
+	for key, value := range patch {
+		current, ok := metadata[key]
+		if policy == allocationv1.MetaPatchAppendToList && ok && current != "" {
+			metadata[key] = appendToList(current, value, separator)
+			continue
+		}
+		metadata[key] = value
+	}
+	return metadata
+}
+
+// appendToList appends value to the list of values separated by separator, unless it is already in the list
+func appendToList(list, value, separator string) string {
+	if value == "" {
+		return list
+	}
+	for _, v := range strings.Split(list, separator) {
+		if v == value {
+			return list
+		}
+	}
+	return list + separator + value
+}
+
+// sortedKeys returns the keys of the map in order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestValidateMetaPatch(t *testing.T) {
+	t.Parallel()
+
+	gsa := &allocationv1.GameServerAllocation{Spec: allocationv1.GameServerAllocationSpec{
+		MetaPatch: allocationv1.MetaPatch{
+			Labels:      map[string]string{"mode": "deathmatch", agonesv1.FleetNameLabel: "other", "internal.example.com/owner": "me"},
+			Annotations: map[string]string{"map": "searide", agonesv1.AllocationAnnotation: "1234"},
+		},
+	}}
+
+	assert.Empty(t, validateMetaPatch(gsa, nil))
+
+	causes := validateMetaPatch(gsa, DefaultProtectedMetadataPrefixes)
+	if assert.Len(t, causes, 2) {
+		assert.Equal(t, metav1.CauseType(apis.ReasonMetaPatchProtected), causes[0].Type)
+		assert.Equal(t, "spec.metadata.labels[agones.dev/fleet]", causes[0].Field)
+		assert.Equal(t, "spec.metadata.annotations[agones.dev/allocation]", causes[1].Field)
+	}
+
+	causes = validateMetaPatch(gsa, []string{"agones.dev/", "internal.example.com/"})
+	if assert.Len(t, causes, 3) {
+		assert.Equal(t, "spec.metadata.labels[agones.dev/fleet]", causes[0].Field)
+		assert.Equal(t, "spec.metadata.labels[internal.example.com/owner]", causes[1].Field)
+	}
+}
+
+func TestMetaPatchConflict(t *testing.T) {
+	t.Parallel()
+
+	gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{
+		Labels:      map[string]string{"mode": "deathmatch"},
+		Annotations: map[string]string{"map": "searide"},
+	}}
+
+	fam := allocationv1.MetaPatch{Labels: map[string]string{"mode": "ctf"}, MergePolicy: allocationv1.MetaPatchMerge}
+	assert.NoError(t, metaPatchConflict(fam, gs))
+	fam.MergePolicy = allocationv1.MetaPatchAppendToList
+	assert.NoError(t, metaPatchConflict(fam, gs))
+	fam.MergePolicy = allocationv1.MetaPatchFailOnConflict
+	assert.Equal(t, ErrMetaPatchConflict, metaPatchConflict(fam, gs))
+
+	fam = allocationv1.MetaPatch{Annotations: map[string]string{"map": "garden22"}, MergePolicy: allocationv1.MetaPatchFailOnConflict}
+	assert.Equal(t, ErrMetaPatchConflict, metaPatchConflict(fam, gs))
+
+	// the same values, or new keys, don't conflict
+	fam = allocationv1.MetaPatch{Labels: map[string]string{"mode": "deathmatch", "region": "eu"},
+		Annotations: map[string]string{"map": "searide", "players": "8"}, MergePolicy: allocationv1.MetaPatchFailOnConflict}
+	assert.NoError(t, metaPatchConflict(fam, gs))
+	assert.NoError(t, metaPatchConflict(fam, &agonesv1.GameServer{}))
+}
+
+func TestMergeMetadata(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, mergeMetadata(nil, nil, allocationv1.MetaPatchMerge, annotationListSeparator))
+	assert.Equal(t, map[string]string{"a": "1"}, mergeMetadata(nil, map[string]string{"a": "1"}, allocationv1.MetaPatchAppendToList, annotationListSeparator))
+
+	existing := func() map[string]string {
+		return map[string]string{"players": "p1,p2", "map": "searide", "empty": ""}
+	}
+	patch := map[string]string{"players": "p3", "map": "searide", "empty": "e1", "new": "n1"}
+
+	assert.Equal(t, map[string]string{"players": "p3", "map": "searide", "empty": "e1", "new": "n1"},
+		mergeMetadata(existing(), patch, allocationv1.MetaPatchMerge, annotationListSeparator))
+	assert.Equal(t, map[string]string{"players": "p1,p2,p3", "map": "searide", "empty": "e1", "new": "n1"},
+		mergeMetadata(existing(), patch, allocationv1.MetaPatchAppendToList, annotationListSeparator))
+	assert.Equal(t, map[string]string{"players": "p1.p2", "new": "n1"},
+		mergeMetadata(map[string]string{"players": "p1.p2"}, map[string]string{"players": "p2", "new": "n1"}, allocationv1.MetaPatchAppendToList, labelListSeparator))
+	assert.Equal(t, "p1.p2.p3", appendToList("p1.p2", "p3", labelListSeparator))
+	assert.Equal(t, "p1", appendToList("p1", "", labelListSeparator))
+}
+
+func TestAllocatorMetaPatchMergePolicy(t *testing.T) {
+	t.Parallel()
+
+	f, _, gsList := defaultFixtures(1)
+	gsList[0].ObjectMeta.Labels["mode"] = "ctf"
+	gsList[0].ObjectMeta.Annotations = map[string]string{"players": "p1"}
+	c, m := newFakeController()
+
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+	updates := 0
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		updates++
+		return true, action.(k8stesting.UpdateAction).GetObject(), nil
+	})
+
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+
+	allocate := func(fam allocationv1.MetaPatch) k8sruntime.Object {
+		gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+			Spec: allocationv1.GameServerAllocationSpec{
+				Required:          metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}},
+				MetaPatch:         fam,
+				IncludeGameServer: true,
+			}}
+		gsa.ApplyDefaults()
+		result, err := c.allocator.Allocate(context.Background(), gsa, stop)
+		assert.NoError(t, err)
+		return result
+	}
+
+	// controller owned labels can't be patched
+	result := allocate(allocationv1.MetaPatch{Labels: map[string]string{agonesv1.FleetNameLabel: "other"}})
+	status, ok := result.(*metav1.Status)
+	if assert.True(t, ok) {
+		assert.Equal(t, int32(http.StatusUnprocessableEntity), status.Code)
+		assert.Equal(t, metav1.CauseType(apis.ReasonMetaPatchProtected), status.Details.Causes[0].Type)
+	}
+	assert.Equal(t, 0, updates)
+
+	// the conflicting GameServer is not allocated, and stays Ready
+	result = allocate(allocationv1.MetaPatch{Labels: map[string]string{"mode": "deathmatch"}, MergePolicy: allocationv1.MetaPatchFailOnConflict})
+	gsa, ok := result.(*allocationv1.GameServerAllocation)
+	if assert.True(t, ok) {
+		assert.Equal(t, allocationv1.GameServerAllocationUnAllocated, gsa.Status.State)
+		assert.Equal(t, apis.ReasonMetaPatchConflict, gsa.Status.Reason)
+	}
+	assert.Equal(t, 0, updates)
+	_, ok = c.allocator.readyGameServerCache.readyGameServers.Load(defaultNs + "/" + gsList[0].ObjectMeta.Name)
+	assert.True(t, ok)
+
+	result = allocate(allocationv1.MetaPatch{Labels: map[string]string{"mode": "ctf"}, Annotations: map[string]string{"players": "p2"},
+		MergePolicy: allocationv1.MetaPatchAppendToList})
+	gsa, ok = result.(*allocationv1.GameServerAllocation)
+	if assert.True(t, ok) && assert.Equal(t, allocationv1.GameServerAllocationAllocated, gsa.Status.State) {
+		assert.Equal(t, "ctf", gsa.Status.GameServer.ObjectMeta.Labels["mode"])
+		assert.Equal(t, "p1,p2", gsa.Status.GameServer.ObjectMeta.Annotations["players"])
+	}
+	assert.Equal(t, 1, updates)
+}
@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"time"
 
+	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
 	listerv1 "agones.dev/agones/pkg/client/listers/agones/v1"
@@ -28,6 +29,7 @@ import (
 	"go.opencensus.io/stats"
 	"go.opencensus.io/stats/view"
 	"go.opencensus.io/tag"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	k8sruntime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -37,18 +39,116 @@ var (
 	keyClusterName        = mt.MustTagKey("cluster_name")
 	keyMultiCluster       = mt.MustTagKey("is_multicluster")
 	keyStatus             = mt.MustTagKey("status")
+	keyReason             = mt.MustTagKey("reason")
 	keySchedulingStrategy = mt.MustTagKey("scheduling_strategy")
 
 	gameServerAllocationsLatency = stats.Float64("gameserver_allocations/latency", "The duration of gameserver allocations", "s")
+	gameServerCacheLagStats      = stats.Float64("gameserver_allocations/cache_lag", "How long the cache of Ready gameservers has been behind the apiserver", "s")
+	staleCacheRejectionsStats    = stats.Int64("gameserver_allocations/stale_cache_rejections", "The number of gameserver allocations rejected because the cache was stale", "1")
+	remoteAllocationsLatency     = stats.Float64("gameserver_allocations/remote_latency", "The duration of allocation requests sent to remote clusters", "s")
+	multiClusterFallbacksStats   = stats.Int64("gameserver_allocations/multicluster_fallbacks", "The number of times a cluster could not allocate and the next cluster was tried", "1")
+	queueLatency                 = stats.Float64("gameserver_allocations/queue_latency", "How long gameserver allocation requests waited to be batched", "s")
+	updateLatency                = stats.Float64("gameserver_allocations/update_latency", "The duration of moving allocated gameservers to Allocated", "s")
+	contentionRetriesStats       = stats.Int64("gameserver_allocations/contention_retries", "The number of gameserver allocation attempts that were retried, because the chosen gameserver was already allocated", "1")
+	filterWebhookFailuresStats   = stats.Int64("gameserver_allocations/filter_webhook_failures", "The number of gameserver allocations made without the allocation filter webhook, because it failed", "1")
+	pendingWritesStats           = stats.Int64("gameserver_allocations/pending_writes", "The number of allocated gameservers waiting to be moved to Allocated, while the apiserver is failing", "1")
+	droppedWritesStats           = stats.Int64("gameserver_allocations/dropped_writes", "The number of allocated gameservers that were never moved to Allocated, because the apiserver kept failing", "1")
+	auditDroppedStats            = stats.Int64("gameserver_allocations/audit_dropped", "The number of allocation audit records that could not be written to the audit sink", "1")
+	sharedRequestsStats          = stats.Int64("gameserver_allocations/shared_requests", "The number of gameserver allocations that joined an identical in-flight allocation with the same request ID", "1")
 )
 
+// remoteStatusError is the status of remote allocation requests that did not get a response
+const remoteStatusError = "error"
+
 func init() {
 	runtime.Must(view.Register(&view.View{
 		Name:        "gameserver_allocations_duration_seconds",
 		Measure:     gameServerAllocationsLatency,
 		Description: "The distribution of gameserver allocation requests latencies.",
 		Aggregation: view.Distribution(0, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2, 3),
-		TagKeys:     []tag.Key{keyFleetName, keyNodeName, keyClusterName, keyMultiCluster, keyStatus, keySchedulingStrategy},
+		TagKeys:     []tag.Key{keyFleetName, keyNodeName, keyClusterName, keyMultiCluster, keyStatus, keyReason, keySchedulingStrategy},
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_cache_lag_seconds",
+		Measure:     gameServerCacheLagStats,
+		Description: "How long the cache of Ready gameservers used for allocations has been behind the apiserver",
+		Aggregation: view.LastValue(),
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_stale_cache_rejections_total",
+		Measure:     staleCacheRejectionsStats,
+		Description: "The total of gameserver allocations rejected because the cache of Ready gameservers was stale",
+		Aggregation: view.Count(),
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_remote_duration_seconds",
+		Measure:     remoteAllocationsLatency,
+		Description: "The distribution of the latencies of allocation requests sent to remote clusters, per cluster and status",
+		Aggregation: view.Distribution(0, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2, 3),
+		TagKeys:     []tag.Key{keyClusterName, keyStatus},
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_remote_total",
+		Measure:     remoteAllocationsLatency,
+		Description: "The total of allocation requests sent to remote clusters, per cluster and status",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{keyClusterName, keyStatus},
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_multicluster_fallbacks_total",
+		Measure:     multiClusterFallbacksStats,
+		Description: "The total of multi-cluster allocations that fell back from a cluster to the next one, per cluster",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{keyClusterName},
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_queue_duration_seconds",
+		Measure:     queueLatency,
+		Description: "The distribution of how long gameserver allocation requests waited in the queue before being batched",
+		Aggregation: view.Distribution(0, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2, 3),
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_update_duration_seconds",
+		Measure:     updateLatency,
+		Description: "The distribution of the latencies of moving allocated gameservers to Allocated, per status",
+		Aggregation: view.Distribution(0, 0.01, 0.025, 0.05, 0.075, 0.1, 0.25, 0.5, 0.75, 1, 2, 3),
+		TagKeys:     []tag.Key{keyStatus},
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_contention_retries_total",
+		Measure:     contentionRetriesStats,
+		Description: "The total of gameserver allocation attempts that were retried, because the chosen gameserver was already allocated",
+		Aggregation: view.Count(),
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_filter_webhook_failures_total",
+		Measure:     filterWebhookFailuresStats,
+		Description: "The total of gameserver allocations made without the allocation filter webhook, because it failed",
+		Aggregation: view.Count(),
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_pending_writes",
+		Measure:     pendingWritesStats,
+		Description: "The number of allocated gameservers queued to be moved to Allocated, while the allocator is degraded because the apiserver is failing",
+		Aggregation: view.LastValue(),
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_dropped_writes_total",
+		Measure:     droppedWritesStats,
+		Description: "The total of allocated gameservers queued while the allocator was degraded, that were never moved to Allocated",
+		Aggregation: view.Count(),
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_audit_dropped_total",
+		Measure:     auditDroppedStats,
+		Description: "The total of allocation audit records that were dropped, because the queue was full or the audit sink failed",
+		Aggregation: view.Count(),
+	}))
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserver_allocations_shared_requests_total",
+		Measure:     sharedRequestsStats,
+		Description: "The total of gameserver allocations that were given the result of an identical in-flight allocation with the same request ID",
+		Aggregation: view.Count(),
 	}))
 }
 
@@ -60,6 +160,7 @@ var latencyTags = []tag.Mutator{
 	tag.Insert(keyFleetName, "none"),
 	tag.Insert(keyNodeName, "none"),
 	tag.Insert(keyStatus, "none"),
+	tag.Insert(keyReason, "none"),
 }
 
 type metrics struct {
@@ -102,12 +203,25 @@ func (r *metrics) setRequest(in *allocationv1.GameServerAllocation) {
 
 // setResponse set response metric tags.
 func (r *metrics) setResponse(o k8sruntime.Object) {
+	if status, ok := o.(*metav1.Status); ok && status.Details != nil {
+		// an invalid request
+		for _, cause := range status.Details.Causes {
+			switch cause.Type {
+			case metav1.CauseType(apis.ReasonSelectorInvalid), metav1.CauseType(apis.ReasonRateLimited), metav1.CauseType(apis.ReasonMetaPatchProtected):
+				r.mutate(tag.Update(keyReason, string(cause.Type)))
+			}
+		}
+		return
+	}
 	out, ok := o.(*allocationv1.GameServerAllocation)
 	if out == nil || !ok {
 		return
 	}
 	r.setStatus(string(out.Status.State))
 	var tags []tag.Mutator
+	if out.Status.Reason != "" {
+		tags = append(tags, tag.Update(keyReason, string(out.Status.Reason)))
+	}
 	if out.Status.NodeName != "" {
 		tags = append(tags, tag.Update(keyNodeName, out.Status.NodeName))
 	}
@@ -115,10 +229,9 @@ func (r *metrics) setResponse(o k8sruntime.Object) {
 	if out.Status.State == allocationv1.GameServerAllocationAllocated {
 		gs, err := r.gameServerLister.GameServers(out.Namespace).Get(out.Status.GameServerName)
 		if err != nil {
+			// the gameserver may have been allocated from a remote cluster
 			r.logger.WithError(err).Warnf("failed to get gameserver:%s namespace:%s", out.Status.GameServerName, out.Namespace)
-		}
-		fleetName := gs.Labels[agonesv1.FleetNameLabel]
-		if fleetName != "" {
+		} else if fleetName := gs.Labels[agonesv1.FleetNameLabel]; fleetName != "" {
 			tags = append(tags, tag.Update(keyFleetName, fleetName))
 		}
 	}
@@ -129,3 +242,36 @@ func (r *metrics) setResponse(o k8sruntime.Object) {
 func (r *metrics) record() {
 	stats.Record(r.ctx, gameServerAllocationsLatency.M(time.Since(r.start).Seconds()))
 }
+
+// recordRemoteAllocation records the latency of an allocation request sent to a remote cluster,
+// with the status of the response. status is the http status class, e.g. 2xx, or the gRPC status code.
+func (c *Allocator) recordRemoteAllocation(clusterName, status string, start time.Time) {
+	ctx, err := tag.New(context.Background(), tag.Insert(keyClusterName, clusterName), tag.Insert(keyStatus, status))
+	if err != nil {
+		c.baseLogger.WithError(err).Warn("failed to tag remote allocation metric")
+		return
+	}
+	stats.Record(ctx, remoteAllocationsLatency.M(time.Since(start).Seconds()))
+}
+
+// recordMultiClusterFallback records that the cluster could not allocate a gameserver,
+// and that multi-cluster allocation moved on to the next cluster
+func (c *Allocator) recordMultiClusterFallback(clusterName string) {
+	ctx, err := tag.New(context.Background(), tag.Insert(keyClusterName, clusterName))
+	if err != nil {
+		c.baseLogger.WithError(err).Warn("failed to tag multi-cluster fallback metric")
+		return
+	}
+	stats.Record(ctx, multiClusterFallbacksStats.M(1))
+}
+
+// recordUpdate records the latency of moving an allocated gameserver to Allocated,
+// with the status of the write
+func (c *Allocator) recordUpdate(status writeStatus, start time.Time) {
+	ctx, err := tag.New(context.Background(), tag.Insert(keyStatus, string(status)))
+	if err != nil {
+		c.baseLogger.WithError(err).Warn("failed to tag allocation update metric")
+		return
+	}
+	stats.Record(ctx, updateLatency.M(time.Since(start).Seconds()))
+}
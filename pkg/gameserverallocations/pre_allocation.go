@@ -0,0 +1,202 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"time"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"agones.dev/agones/pkg/util/logfields"
+	"agones.dev/agones/pkg/util/runtime"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	utilrand "k8s.io/apimachinery/pkg/util/rand"
+)
+
+const (
+	// length of the random claim token of a pre-allocation
+	claimTokenLength = 20
+	// how often the GameServers of expired pre-allocations are moved back to Ready
+	preAllocationExpiryPeriod = 5 * time.Second
+)
+
+// preAllocate reserves the Count of the pre-allocation of Ready GameServers that match the GameServerAllocation,
+// one at a time through the batch process, for a new claim token. Fewer GameServers are reserved if there are
+// not enough Ready ones, and the allocation is UnAllocated if none are.
+func (c *Allocator) preAllocate(ctx context.Context, gsa *allocationv1.GameServerAllocation, stop <-chan struct{}) (*allocationv1.GameServerAllocation, error) {
+	// the update workers read the claim token and expiry from the status
+	until := metav1.NewTime(c.clock.Now().Add(time.Duration(gsa.Spec.PreAllocate.DurationSeconds) * time.Second))
+	gsa.Status.ClaimToken = utilrand.String(claimTokenLength)
+	gsa.Status.ReservedUntil = &until
+
+	var reserved int32
+	var err error
+	for reserved < gsa.Spec.PreAllocate.Count {
+		err = Retry(allocationRetry, func() error {
+			_, err := c.requestAllocation(ctx, gsa, stop)
+			return err
+		})
+		if ctx.Err() != nil {
+			// the GameServers that were reserved already are moved back to Ready once they expire
+			return nil, errors.Wrap(ctx.Err(), "pre-allocation cancelled")
+		}
+		if err != nil {
+			break
+		}
+		reserved++
+	}
+	if err != nil && err != ErrNoGameServerReady && err != ErrConflictInGameServerSelection && err != ErrMetaPatchConflict {
+		c.readyGameServerCache.Resync()
+		if reserved == 0 {
+			return nil, err
+		}
+	}
+
+	if reserved == 0 {
+		gsa.Status.ClaimToken = ""
+		gsa.Status.ReservedUntil = nil
+		gsa.Status.State = allocationv1.GameServerAllocationUnAllocated
+		gsa.Status.Reason = apis.ReasonNoCapacity
+		if err == ErrConflictInGameServerSelection {
+			gsa.Status.State = allocationv1.GameServerAllocationContention
+			gsa.Status.Reason = apis.ReasonContention
+		} else {
+			c.lintSelector(gsa)
+		}
+	} else {
+		gsa.Status.State = allocationv1.GameServerAllocationPreAllocated
+		gsa.Status.PreAllocatedReplicas = reserved
+	}
+
+	c.loggerForGameServerAllocation(gsa).Info("game server pre-allocation")
+	return gsa, nil
+}
+
+// writePreAllocatedGameServer moves a GameServer that was matched for a pre-allocation to Reserved, until the expiry
+// of the pre-allocation, with its claim token label. Unlike allocations, pre-allocations are not deferred while
+// the allocator is degraded, as there is no Ready GameServer to hand out in the meantime.
+func (c *Allocator) writePreAllocatedGameServer(gsa *allocationv1.GameServerAllocation, gs agonesv1.GameServer) (*agonesv1.GameServer, writeStatus, error) {
+	if c.degradedWrites.degraded() {
+		return &gs, writeError, errors.New("allocator is degraded and can't pre-allocate")
+	}
+
+	reserved := gs.DeepCopy()
+	c.readyGameServerCache.patchMetadata(reserved, gsa.Spec.MetaPatch)
+	if reserved.ObjectMeta.Labels == nil {
+		reserved.ObjectMeta.Labels = make(map[string]string, 1)
+	}
+	reserved.ObjectMeta.Labels[agonesv1.ClaimTokenLabel] = gsa.Status.ClaimToken
+	reserved.Status.State = agonesv1.GameServerStateReserved
+	reserved.Status.ReservedUntil = gsa.Status.ReservedUntil.DeepCopy()
+
+	result, err := c.readyGameServerCache.gameServerGetter.GameServers(reserved.ObjectMeta.Namespace).Update(reserved)
+	c.degradedWrites.observe(err)
+	if err != nil {
+		return &gs, writeError, err
+	}
+	c.readyGameServerCache.ObserveResourceVersion(result.ObjectMeta.ResourceVersion)
+	return result, writeSuccess, nil
+}
+
+// claimPreAllocated allocates a GameServer that was reserved for the claim token of the GameServerAllocation, and
+// matches its required selector. This does not go through the batch process, as the reserved GameServers are not
+// in the Ready GameServer cache. The allocation is UnAllocated if all of them have been claimed, or have expired.
+func (c *Allocator) claimPreAllocated(gsa *allocationv1.GameServerAllocation) (*allocationv1.GameServerAllocation, error) {
+	selector, err := metav1.LabelSelectorAsSelector(&gsa.Spec.Required)
+	if err != nil {
+		return nil, errors.Wrap(err, "error converting required selector")
+	}
+	token, err := labels.NewRequirement(agonesv1.ClaimTokenLabel, selection.Equals, []string{gsa.Spec.ClaimToken})
+	if err != nil {
+		// the claim token is not a valid label value, so no GameServer has it
+		gsa.Status.State = allocationv1.GameServerAllocationUnAllocated
+		gsa.Status.Reason = apis.ReasonNoCapacity
+		return gsa, nil
+	}
+	list, err := c.readyGameServerCache.gameServerLister.GameServers(gsa.ObjectMeta.Namespace).List(selector.Add(*token))
+	if err != nil {
+		return nil, errors.Wrap(err, "error listing pre-allocated gameservers")
+	}
+
+	for _, gs := range list {
+		if gs.Status.State != agonesv1.GameServerStateReserved || gs.IsBeingDeleted() {
+			continue
+		}
+		if metaPatchConflict(gsa.Spec.MetaPatch, gs) != nil {
+			continue
+		}
+		claimed := gs.DeepCopy()
+		claimed.Status.ReservedUntil = nil
+		result, err := c.readyGameServerCache.PatchGameServerMetadata(gsa.Spec.MetaPatch, *claimed)
+		if k8serrors.IsConflict(err) || k8serrors.IsNotFound(err) {
+			// claimed by another allocation, or deleted, since the informer saw it
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error allocating pre-allocated gameserver %s", gs.ObjectMeta.Name)
+		}
+		c.recorder.Event(result, corev1.EventTypeNormal, string(result.Status.State), "Allocated")
+		setAllocatedStatus(gsa, result)
+		c.loggerForGameServerAllocation(gsa).Info("game server allocation from pre-allocation")
+		return gsa, nil
+	}
+
+	gsa.Status.State = allocationv1.GameServerAllocationUnAllocated
+	gsa.Status.Reason = apis.ReasonNoCapacity
+	return gsa, nil
+}
+
+// expirePreAllocations moves the GameServers of pre-allocations that were not claimed before they expired
+// back to Ready, without their claim token label
+func (c *Allocator) expirePreAllocations() {
+	claimed, err := labels.NewRequirement(agonesv1.ClaimTokenLabel, selection.Exists, nil)
+	if err != nil {
+		runtime.HandleError(c.baseLogger, errors.Wrap(err, "error creating claim token selector"))
+		return
+	}
+	list, err := c.readyGameServerCache.gameServerLister.List(labels.NewSelector().Add(*claimed))
+	if err != nil {
+		runtime.HandleError(c.baseLogger, errors.Wrap(err, "error listing pre-allocated gameservers"))
+		return
+	}
+
+	now := c.clock.Now()
+	for _, gs := range list {
+		if gs.Status.State != agonesv1.GameServerStateReserved || gs.Status.ReservedUntil == nil ||
+			gs.Status.ReservedUntil.After(now) || gs.IsBeingDeleted() {
+			continue
+		}
+		logger := logfields.AugmentLogEntry(c.baseLogger, logfields.GameServerKey, gs.ObjectMeta.Namespace+"/"+gs.ObjectMeta.Name)
+		gsCopy := gs.DeepCopy()
+		delete(gsCopy.ObjectMeta.Labels, agonesv1.ClaimTokenLabel)
+		gsCopy.Status.State = agonesv1.GameServerStateReady
+		gsCopy.Status.ReservedUntil = nil
+		result, err := c.readyGameServerCache.gameServerGetter.GameServers(gsCopy.ObjectMeta.Namespace).Update(gsCopy)
+		if err != nil {
+			runtime.HandleError(logger, errors.Wrap(err, "error moving GameServer of expired pre-allocation to Ready"))
+			continue
+		}
+		logger.Info("Moved GameServer of expired pre-allocation to Ready")
+		c.readyGameServerCache.ObserveResourceVersion(result.ObjectMeta.ResourceVersion)
+		c.recorder.Event(result, corev1.EventTypeNormal, string(result.Status.State), "Pre-allocation expired")
+	}
+}
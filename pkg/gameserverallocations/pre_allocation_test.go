@@ -0,0 +1,152 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAllocatorPreAllocateAndClaim(t *testing.T) {
+	t.Parallel()
+
+	f, _, gsList := defaultFixtures(3)
+	c, m := newFakeController()
+
+	gsWatch := watch.NewFake()
+	m.AgonesClient.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(gsWatch, nil))
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		gsWatch.Modify(gs)
+		return true, gs, nil
+	})
+
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	err := wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+		return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+	})
+	assert.NoError(t, err)
+
+	required := metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}}
+	gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:    required,
+			PreAllocate: &allocationv1.PreAllocation{Count: 2, DurationSeconds: 60},
+		}}
+	gsa.ApplyDefaults()
+
+	gsa, err = c.allocator.preAllocate(context.Background(), gsa, stop)
+	assert.NoError(t, err)
+	assert.Equal(t, allocationv1.GameServerAllocationPreAllocated, gsa.Status.State)
+	assert.Equal(t, int32(2), gsa.Status.PreAllocatedReplicas)
+	assert.NotEmpty(t, gsa.Status.ClaimToken)
+	assert.NotNil(t, gsa.Status.ReservedUntil)
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Pre-allocated")
+
+	// wait for the informer to see the reserved GameServers
+	lister := c.allocator.readyGameServerCache.gameServerLister
+	err = wait.PollImmediate(100*time.Millisecond, 10*time.Second, func() (bool, error) {
+		list, err := lister.List(labels.SelectorFromSet(labels.Set{agonesv1.ClaimTokenLabel: gsa.Status.ClaimToken}))
+		return len(list) == 2, err
+	})
+	assert.NoError(t, err)
+
+	claim := func() *allocationv1.GameServerAllocation {
+		claim := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+			Spec: allocationv1.GameServerAllocationSpec{Required: required, ClaimToken: gsa.Status.ClaimToken}}
+		claim.ApplyDefaults()
+		result, err := c.allocator.claimPreAllocated(claim)
+		assert.NoError(t, err)
+		return result
+	}
+
+	claimed := map[string]bool{}
+	for i := 0; i < 2; i++ {
+		result := claim()
+		assert.Equal(t, allocationv1.GameServerAllocationAllocated, result.Status.State)
+		claimed[result.Status.GameServerName] = true
+		err = wait.PollImmediate(100*time.Millisecond, 10*time.Second, func() (bool, error) {
+			gs, err := lister.GameServers(defaultNs).Get(result.Status.GameServerName)
+			return err == nil && gs.Status.State == agonesv1.GameServerStateAllocated, nil
+		})
+		assert.NoError(t, err)
+	}
+	assert.Len(t, claimed, 2)
+
+	result := claim()
+	assert.Equal(t, allocationv1.GameServerAllocationUnAllocated, result.Status.State)
+	assert.Equal(t, apis.ReasonNoCapacity, result.Status.Reason)
+}
+
+func TestAllocatorExpirePreAllocations(t *testing.T) {
+	t.Parallel()
+
+	_, _, gsList := defaultFixtures(3)
+	past := metav1.NewTime(time.Now().Add(-time.Minute))
+	future := metav1.NewTime(time.Now().Add(time.Minute))
+	for i := range gsList {
+		gsList[i].Status.State = agonesv1.GameServerStateReserved
+		gsList[i].ObjectMeta.Labels[agonesv1.ClaimTokenLabel] = "token"
+	}
+	gsList[0].Status.ReservedUntil = &past
+	gsList[1].Status.ReservedUntil = &future
+	// reserved through the SDK, not by a pre-allocation
+	delete(gsList[2].ObjectMeta.Labels, agonesv1.ClaimTokenLabel)
+	gsList[2].Status.ReservedUntil = &past
+
+	c, m := newFakeController()
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+	var updated []*agonesv1.GameServer
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		updated = append(updated, gs)
+		return true, gs, nil
+	})
+
+	_, cancel := agtesting.StartInformers(m, c.allocator.readyGameServerCache.gameServerSynced)
+	defer cancel()
+
+	c.allocator.expirePreAllocations()
+	if assert.Len(t, updated, 1) {
+		assert.Equal(t, gsList[0].ObjectMeta.Name, updated[0].ObjectMeta.Name)
+		assert.Equal(t, agonesv1.GameServerStateReady, updated[0].Status.State)
+		assert.Nil(t, updated[0].Status.ReservedUntil)
+		assert.NotContains(t, updated[0].ObjectMeta.Labels, agonesv1.ClaimTokenLabel)
+	}
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Pre-allocation expired")
+}
@@ -0,0 +1,184 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/pkg/errors"
+	"golang.org/x/time/rate"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+const (
+	// rateLimitScopeNamespace limits the allocations per namespace
+	rateLimitScopeNamespace = "namespace"
+	// rateLimitScopeFleet limits the allocations per Fleet, as selected by the Fleet name label of the required selector
+	rateLimitScopeFleet = "fleet"
+
+	// how often the token buckets that are full again are removed
+	rateLimiterCleanupPeriod = time.Minute
+)
+
+// RateLimitConfig configures the token bucket rate limits of allocations. A limit is disabled if its QPS is 0.
+type RateLimitConfig struct {
+	// NamespaceQPS is the sustained number of allocations per second in a namespace
+	NamespaceQPS float64
+	// NamespaceBurst is the number of allocations in a namespace that can be made at once
+	NamespaceBurst int
+	// FleetQPS is the sustained number of allocations per second from a Fleet
+	FleetQPS float64
+	// FleetBurst is the number of allocations from a Fleet that can be made at once
+	FleetBurst int
+}
+
+// Validate returns an error if the RateLimitConfig is invalid
+func (r RateLimitConfig) Validate() error {
+	if r.NamespaceQPS < 0 || r.FleetQPS < 0 {
+		return errors.New("allocation rate limits must not be negative")
+	}
+	if r.NamespaceQPS > 0 && r.NamespaceBurst <= 0 {
+		return errors.New("allocation namespace burst must be greater than 0")
+	}
+	if r.FleetQPS > 0 && r.FleetBurst <= 0 {
+		return errors.New("allocation fleet burst must be greater than 0")
+	}
+	return nil
+}
+
+// limiter is the token bucket of a namespace or a Fleet, and when it was last used
+type limiter struct {
+	stamp time.Time
+	limit *rate.Limiter
+}
+
+// rateLimiter limits the rate of allocations per namespace and per Fleet
+type rateLimiter struct {
+	config   RateLimitConfig
+	clock    clock.Clock
+	mutex    sync.Mutex
+	limiters map[rateLimitKey]*limiter
+}
+
+// rateLimitKey identifies the token bucket of a namespace, or of a Fleet in a namespace
+type rateLimitKey struct {
+	scope     string
+	namespace string
+	fleet     string
+}
+
+// rateLimitError is returned when an allocation is rejected, as it exceeds a rate limit
+type rateLimitError struct {
+	// scope is the scope of the limit that was exceeded
+	scope string
+	// delay is how long to wait before retrying
+	delay time.Duration
+}
+
+// Error returns the message of the rateLimitError
+func (e *rateLimitError) Error() string {
+	return fmt.Sprintf("GameServerAllocation rate limit of the %s exceeded", e.scope)
+}
+
+// isRateLimitError returns true if the error is a rateLimitError
+func isRateLimitError(err error) bool {
+	_, ok := err.(*rateLimitError)
+	return ok
+}
+
+// newRateLimiter returns a rateLimiter for the config
+func newRateLimiter(config RateLimitConfig) *rateLimiter {
+	return &rateLimiter{config: config, clock: clock.RealClock{}, limiters: map[rateLimitKey]*limiter{}}
+}
+
+// allow takes a token from the buckets of the namespace and the Fleet of the GameServerAllocation.
+// If a bucket is empty, no token is taken, and the scope of the limit that was exceeded is returned,
+// with how long to wait before retrying.
+func (r *rateLimiter) allow(gsa *allocationv1.GameServerAllocation) (string, time.Duration) {
+	var keys []rateLimitKey
+	if r.config.NamespaceQPS > 0 {
+		keys = append(keys, rateLimitKey{scope: rateLimitScopeNamespace, namespace: gsa.ObjectMeta.Namespace})
+	}
+	if fleet, ok := gsa.Spec.Required.MatchLabels[agonesv1.FleetNameLabel]; ok && r.config.FleetQPS > 0 {
+		keys = append(keys, rateLimitKey{scope: rateLimitScopeFleet, namespace: gsa.ObjectMeta.Namespace, fleet: fleet})
+	}
+	if len(keys) == 0 {
+		return "", 0
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.clock.Now()
+	reservations := make([]*rate.Reservation, 0, len(keys))
+	for _, key := range keys {
+		res := r.limiter(key, now).ReserveN(now, 1)
+		if delay := res.DelayFrom(now); delay > 0 {
+			// give back the tokens, as the allocation is not made
+			res.CancelAt(now)
+			for _, taken := range reservations {
+				taken.CancelAt(now)
+			}
+			return key.scope, delay
+		}
+		reservations = append(reservations, res)
+	}
+	return "", 0
+}
+
+// start takes a token for a GameServerAllocation that starts a new allocation like allow,
+// and returns a rateLimitError if a limit was exceeded
+func (r *rateLimiter) start(gsa *allocationv1.GameServerAllocation) error {
+	if scope, delay := r.allow(gsa); scope != "" {
+		return &rateLimitError{scope: scope, delay: delay}
+	}
+	return nil
+}
+
+// limiter returns the token bucket for the key, creating it if needed.
+// r.mutex must be held.
+func (r *rateLimiter) limiter(key rateLimitKey, now time.Time) *rate.Limiter {
+	l, ok := r.limiters[key]
+	if !ok {
+		if key.scope == rateLimitScopeNamespace {
+			l = &limiter{limit: rate.NewLimiter(rate.Limit(r.config.NamespaceQPS), r.config.NamespaceBurst)}
+		} else {
+			l = &limiter{limit: rate.NewLimiter(rate.Limit(r.config.FleetQPS), r.config.FleetBurst)}
+		}
+		r.limiters[key] = l
+	}
+	l.stamp = now
+	return l.limit
+}
+
+// cleanup removes the token buckets that have not been used for long enough to be full again,
+// as they would be created in the same state
+func (r *rateLimiter) cleanup() {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.clock.Now()
+	for key, l := range r.limiters {
+		refill := time.Duration(math.Ceil(float64(l.limit.Burst())/float64(l.limit.Limit()))) * time.Second
+		if now.Sub(l.stamp) > refill {
+			delete(r.limiters, key)
+		}
+	}
+}
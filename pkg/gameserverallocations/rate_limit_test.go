@@ -0,0 +1,114 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
+)
+
+func TestRateLimitConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, RateLimitConfig{}.Validate())
+	assert.NoError(t, RateLimitConfig{NamespaceQPS: 10, NamespaceBurst: 20, FleetQPS: 0.5, FleetBurst: 1}.Validate())
+	assert.Error(t, RateLimitConfig{NamespaceQPS: -1}.Validate())
+	assert.Error(t, RateLimitConfig{NamespaceQPS: 10}.Validate())
+	assert.Error(t, RateLimitConfig{FleetQPS: 10}.Validate())
+}
+
+func TestRateLimiterAllow(t *testing.T) {
+	t.Parallel()
+
+	newGsa := func(namespace, fleet string) *allocationv1.GameServerAllocation {
+		gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}}
+		if fleet != "" {
+			gsa.Spec.Required.MatchLabels = map[string]string{agonesv1.FleetNameLabel: fleet}
+		}
+		return gsa
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		r := newRateLimiter(RateLimitConfig{})
+		for i := 0; i < 100; i++ {
+			scope, _ := r.allow(newGsa(defaultNs, "fleet"))
+			assert.Empty(t, scope)
+		}
+		assert.Empty(t, r.limiters)
+	})
+
+	t.Run("namespace", func(t *testing.T) {
+		fc := clock.NewFakeClock(time.Now())
+		r := newRateLimiter(RateLimitConfig{NamespaceQPS: 1, NamespaceBurst: 2})
+		r.clock = fc
+
+		for i := 0; i < 2; i++ {
+			scope, _ := r.allow(newGsa(defaultNs, ""))
+			assert.Empty(t, scope)
+		}
+		scope, delay := r.allow(newGsa(defaultNs, ""))
+		assert.Equal(t, rateLimitScopeNamespace, scope)
+		assert.Equal(t, time.Second, delay)
+
+		// other namespaces have their own limit
+		scope, _ = r.allow(newGsa("other", ""))
+		assert.Empty(t, scope)
+
+		fc.Step(time.Second)
+		scope, _ = r.allow(newGsa(defaultNs, ""))
+		assert.Empty(t, scope)
+	})
+
+	t.Run("fleet", func(t *testing.T) {
+		fc := clock.NewFakeClock(time.Now())
+		r := newRateLimiter(RateLimitConfig{NamespaceQPS: 1, NamespaceBurst: 2, FleetQPS: 1, FleetBurst: 1})
+		r.clock = fc
+
+		scope, _ := r.allow(newGsa(defaultNs, "a"))
+		assert.Empty(t, scope)
+		scope, _ = r.allow(newGsa(defaultNs, "a"))
+		assert.Equal(t, rateLimitScopeFleet, scope)
+
+		// the rejected allocation did not use up a token of the namespace
+		scope, _ = r.allow(newGsa(defaultNs, "b"))
+		assert.Empty(t, scope)
+		scope, _ = r.allow(newGsa(defaultNs, ""))
+		assert.Equal(t, rateLimitScopeNamespace, scope)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		fc := clock.NewFakeClock(time.Now())
+		r := newRateLimiter(RateLimitConfig{NamespaceQPS: 1, NamespaceBurst: 2, FleetQPS: 0.1, FleetBurst: 1})
+		r.clock = fc
+
+		scope, _ := r.allow(newGsa(defaultNs, "a"))
+		assert.Empty(t, scope)
+		assert.Len(t, r.limiters, 2)
+
+		fc.Step(3 * time.Second)
+		r.cleanup()
+		assert.Len(t, r.limiters, 1)
+
+		fc.Step(10 * time.Second)
+		r.cleanup()
+		assert.Empty(t, r.limiters)
+	})
+}
@@ -15,7 +15,11 @@
 package gameserverallocations
 
 import (
+	"context"
 	"sort"
+	"strconv"
+	"sync"
+	"time"
 
 	"agones.dev/agones/pkg/apis/agones"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
@@ -30,28 +34,75 @@ import (
 	"github.com/heptiolabs/healthcheck"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"go.opencensus.io/stats"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 )
 
+const (
+	// allocations are rejected when the cache has been behind the apiserver for longer than maxReadyCacheLag
+	maxReadyCacheLag = 5 * time.Second
+	// the lag of the cache is recorded every readyCacheLagRecordPeriod
+	readyCacheLagRecordPeriod = time.Second
+)
+
+// resourceVersionWatermark is a resourceVersion returned by the apiserver, that the cache
+// should have caught up with soon after it was observed
+type resourceVersionWatermark struct {
+	resourceVersion uint64
+	observed        time.Time
+}
+
 // ReadyGameServerCache handles the gameserver sync operations for cache
 type ReadyGameServerCache struct {
 	baseLogger       *logrus.Entry
 	readyGameServers gameServerCacheEntry
+	// pendingWrites are the GameServers allocated while degraded, that are kept out of the cache
+	// until their move to Allocated is written
+	pendingWrites gameServerCacheEntry
+	// droppedWrites are the GameServers allocated while degraded whose move to Allocated was given up on.
+	// Their allocation was already returned, so they are kept out of the cache until they are seen to leave Ready
+	droppedWrites    gameServerCacheEntry
 	gameServerGetter getterv1.GameServersGetter
 	gameServerLister listerv1.GameServerLister
 	gameServerSynced cache.InformerSynced
 	workerqueue      *workerqueue.WorkerQueue
 	counter          *gameservers.PerNodeCounter
+	preemptions      *gameservers.NodePreemptions
+	maintenance      *gameservers.NodeMaintenance
+	clock            clock.Clock
+	// indexLabels are the labels that the sorted list of Ready GameServers is indexed on
+	indexLabels []string
+	// informerResourceVersion returns the last resourceVersion the GameServer informer has seen
+	informerResourceVersion func() string
+
+	watermarkMutex sync.Mutex
+	// watermark is the oldest resourceVersion returned by the apiserver that the cache has not caught up with
+	watermark *resourceVersionWatermark
+	// refreshedResourceVersion is the resourceVersion of the last refresh of the cache from the apiserver
+	refreshedResourceVersion uint64
 }
 
-// NewReadyGameServerCache creates a new instance of ReadyGameServerCache
-func NewReadyGameServerCache(informer informerv1.GameServerInformer, gameServerGetter getterv1.GameServersGetter, counter *gameservers.PerNodeCounter, health healthcheck.Handler) *ReadyGameServerCache {
+// NewReadyGameServerCache creates a new instance of ReadyGameServerCache.
+// The sorted list of Ready GameServers is indexed on the Fleet name label, and on indexLabels.
+// The GameServers on nodes that are about to be preempted, or are under maintenance, are left out of the list.
+func NewReadyGameServerCache(informer informerv1.GameServerInformer, gameServerGetter getterv1.GameServersGetter, counter *gameservers.PerNodeCounter,
+	preemptions *gameservers.NodePreemptions, maintenance *gameservers.NodeMaintenance, health healthcheck.Handler, indexLabels []string) *ReadyGameServerCache {
 	c := &ReadyGameServerCache{
 		gameServerSynced: informer.Informer().HasSynced,
 		gameServerGetter: gameServerGetter,
 		gameServerLister: informer.Lister(),
 		counter:          counter,
+		preemptions:      preemptions,
+		maintenance:      maintenance,
+		clock:            clock.RealClock{},
+		indexLabels:      append([]string{agonesv1.FleetNameLabel}, indexLabels...),
+
+		informerResourceVersion: informer.Informer().LastSyncResourceVersion,
 	}
 
 	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -64,12 +115,15 @@ func NewReadyGameServerCache(informer informerv1.GameServerInformer, gameServerG
 				return
 			}
 			if newGs.IsBeingDeleted() {
-				c.readyGameServers.Delete(key)
+				c.removeReady(key)
+			} else if c.refreshedSince(newGs) {
+				// the cache was refreshed from the apiserver after this change, the informer is behind
+				return
 			} else if oldGs.Status.State == agonesv1.GameServerStateReady || newGs.Status.State == agonesv1.GameServerStateReady {
 				if newGs.Status.State == agonesv1.GameServerStateReady {
-					c.readyGameServers.Store(key, newGs)
+					c.storeReady(key, newGs)
 				} else {
-					c.readyGameServers.Delete(key)
+					c.removeReady(key)
 				}
 			}
 		},
@@ -80,7 +134,7 @@ func NewReadyGameServerCache(informer informerv1.GameServerInformer, gameServerG
 			}
 			var key string
 			if key, ok = c.getKey(gs); ok {
-				c.readyGameServers.Delete(key)
+				c.removeReady(key)
 			}
 		},
 	})
@@ -130,6 +184,7 @@ func (c *ReadyGameServerCache) Start(stop <-chan struct{}) error {
 	// we don't want mutiple workers refresh cache at the same time so one worker will be better.
 	// Also we don't expect to have too many failures when allocating
 	go c.workerqueue.Run(1, stop)
+	go wait.Until(c.recordLag, readyCacheLagRecordPeriod, stop)
 	return nil
 }
 
@@ -140,16 +195,70 @@ func (c *ReadyGameServerCache) AddToReadyGameServer(gs *agonesv1.GameServer) {
 	c.readyGameServers.Store(key, gs)
 }
 
-// getReadyGameServers returns a list of ready game servers
+// storeReady stores a Ready GameServer in the cache, unless it was allocated while degraded,
+// and its move to Allocated has not been written yet, or was dropped. A GameServer whose SDK is
+// disconnected is removed from the cache, as its game server may be hung.
+func (c *ReadyGameServerCache) storeReady(key string, gs *agonesv1.GameServer) {
+	if gs.IsSDKDisconnected() {
+		c.readyGameServers.Delete(key)
+		return
+	}
+	if _, ok := c.pendingWrites.Load(key); ok {
+		return
+	}
+	if dropped, ok := c.droppedWrites.Load(key); ok {
+		if dropped.ObjectMeta.UID == gs.ObjectMeta.UID {
+			return
+		}
+		// a new GameServer with the same name
+		c.droppedWrites.Delete(key)
+	}
+	c.readyGameServers.Store(key, gs)
+}
+
+// removeReady removes a GameServer that is no longer Ready from the cache. Once a GameServer
+// whose write was dropped leaves Ready, it is no longer held.
+func (c *ReadyGameServerCache) removeReady(key string) {
+	c.readyGameServers.Delete(key)
+	c.droppedWrites.Delete(key)
+}
+
+// holdPendingWrite keeps a GameServer allocated while degraded out of the cache, until its move to Allocated is written
+func (c *ReadyGameServerCache) holdPendingWrite(gs *agonesv1.GameServer) {
+	key, _ := cache.MetaNamespaceKeyFunc(gs)
+	c.pendingWrites.Store(key, gs)
+}
+
+// releasePendingWrite lets a GameServer that was held by holdPendingWrite back into the cache
+func (c *ReadyGameServerCache) releasePendingWrite(gs *agonesv1.GameServer) {
+	key, _ := cache.MetaNamespaceKeyFunc(gs)
+	c.pendingWrites.Delete(key)
+}
+
+// dropPendingWrite keeps a GameServer that was held by holdPendingWrite out of the cache after its write is
+// given up on, until it is seen to leave Ready. Its allocation was already returned, so letting it back into
+// the cache while the informer still has it as Ready would allocate the same game server twice.
+func (c *ReadyGameServerCache) dropPendingWrite(gs *agonesv1.GameServer) {
+	key, _ := cache.MetaNamespaceKeyFunc(gs)
+	c.droppedWrites.Store(key, gs)
+	c.pendingWrites.Delete(key)
+}
+
+// getReadyGameServers returns a list of ready game servers, other than those on nodes that are about to be preempted
+// or are under maintenance
 func (c *ReadyGameServerCache) getReadyGameServers() []*agonesv1.GameServer {
 	length := c.readyGameServers.Len()
 	if length == 0 {
 		return nil
 	}
 
+	preempted := c.preemptions.PreemptedNodes()
+	maintained := c.maintenance.NodesInMaintenance()
 	list := make([]*agonesv1.GameServer, 0, length)
 	c.readyGameServers.Range(func(_ string, gs *agonesv1.GameServer) bool {
-		list = append(list, gs)
+		if !preempted[gs.Status.NodeName] && !maintained[gs.Status.NodeName] {
+			list = append(list, gs)
+		}
 		return true
 	})
 	return list
@@ -201,41 +310,64 @@ func (c *ReadyGameServerCache) ListSortedReadyGameServers() []*agonesv1.GameServ
 	return list
 }
 
+// fullGameServer returns the full GameServer of its projection in the cache, as the projection was found for an
+// allocation. It is fetched from the apiserver if the informer does not have the version of the projection, e.g.
+// as the cache was refreshed from the apiserver, and a conflict is returned if the GameServer has changed since.
+func (c *ReadyGameServerCache) fullGameServer(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+	full, err := c.gameServerLister.GameServers(gs.ObjectMeta.Namespace).Get(gs.ObjectMeta.Name)
+	if err != nil || full.ObjectMeta.ResourceVersion != gs.ObjectMeta.ResourceVersion {
+		full, err = c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Get(gs.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get GameServer %s", gs.ObjectMeta.Name)
+		}
+	}
+	if full.ObjectMeta.UID != gs.ObjectMeta.UID || full.ObjectMeta.ResourceVersion != gs.ObjectMeta.ResourceVersion {
+		return nil, k8serrors.NewConflict(agonesv1.Resource("gameservers"), gs.ObjectMeta.Name,
+			errors.New("the GameServer has changed since it was found Ready"))
+	}
+	return full.DeepCopy(), nil
+}
+
+// indexedSortedReadyGameServers returns the list of the cache ready gameservers sorted by
+// most allocated to least, indexed on the namespace and the indexed labels of the gameservers
+func (c *ReadyGameServerCache) indexedSortedReadyGameServers() *sortedGameServers {
+	return newSortedGameServers(c.ListSortedReadyGameServers(), c.indexLabels)
+}
+
 // PatchGameServerMetadata patches the input gameserver with allocation meta patch and returns the updated gameserver
 func (c *ReadyGameServerCache) PatchGameServerMetadata(fam allocationv1.MetaPatch, gs agonesv1.GameServer) (*agonesv1.GameServer, error) {
+	result, err := c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(c.allocatedGameServer(fam, gs))
+	if err == nil {
+		c.ObserveResourceVersion(result.ObjectMeta.ResourceVersion)
+	}
+	return result, err
+}
+
+// allocatedGameServer returns the input gameserver patched with allocation meta patch, and moved to Allocated
+func (c *ReadyGameServerCache) allocatedGameServer(fam allocationv1.MetaPatch, gs agonesv1.GameServer) *agonesv1.GameServer {
 	c.patchMetadata(&gs, fam)
 	gs.Status.State = agonesv1.GameServerStateAllocated
-
-	return c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(&gs)
+	gs.StartSession(c.clock.Now())
+	return &gs
 }
 
-// patch the labels and annotations of an allocated GameServer with metadata from a GameServerAllocation
+// patch the labels and annotations of an allocated GameServer with metadata from a GameServerAllocation,
+// with its merge policy
 func (c *ReadyGameServerCache) patchMetadata(gs *agonesv1.GameServer, fam allocationv1.MetaPatch) {
-	// patch ObjectMeta labels
-	if fam.Labels != nil {
-		if gs.ObjectMeta.Labels == nil {
-			gs.ObjectMeta.Labels = make(map[string]string, len(fam.Labels))
-		}
-		for key, value := range fam.Labels {
-			gs.ObjectMeta.Labels[key] = value
-		}
-	}
-	// apply annotations patch
-	if fam.Annotations != nil {
-		if gs.ObjectMeta.Annotations == nil {
-			gs.ObjectMeta.Annotations = make(map[string]string, len(fam.Annotations))
-		}
-		for key, value := range fam.Annotations {
-			gs.ObjectMeta.Annotations[key] = value
-		}
-	}
+	gs.ObjectMeta.Labels = mergeMetadata(gs.ObjectMeta.Labels, fam.Labels, fam.MergePolicy, labelListSeparator)
+	gs.ObjectMeta.Annotations = mergeMetadata(gs.ObjectMeta.Annotations, fam.Annotations, fam.MergePolicy, annotationListSeparator)
 }
 
 // SyncGameServers synchronises the GameServers to Gameserver cache. This is called when a failure
 // happened during the allocation. This method will sync and make sure the cache is up to date.
+// If the informer is behind the apiserver, the cache is refreshed from the apiserver instead.
 func (c *ReadyGameServerCache) SyncGameServers(key string) error {
-	c.loggerForGameServerKey(key).Info("Refreshing Ready Gameserver cache")
+	if c.informerBehind() {
+		c.loggerForGameServerKey(key).Warn("Refreshing Ready Gameserver cache from the apiserver, as the informer is behind")
+		return c.refreshFromAPIServer()
+	}
 
+	c.loggerForGameServerKey(key).Info("Refreshing Ready Gameserver cache")
 	return c.syncReadyGSServerCache()
 }
 
@@ -247,6 +379,36 @@ func (c *ReadyGameServerCache) syncReadyGSServerCache() error {
 		return errors.Wrap(err, "could not list GameServers")
 	}
 
+	c.updateReadyGameServers(gsList)
+	return nil
+}
+
+// refreshFromAPIServer syncs the gameserver cache with a list of GameServers from the apiserver, and records
+// the resourceVersion of the list, so that older changes still to be seen by the informer are ignored.
+func (c *ReadyGameServerCache) refreshFromAPIServer() error {
+	list, err := c.gameServerGetter.GameServers(metav1.NamespaceAll).List(metav1.ListOptions{})
+	if err != nil {
+		return errors.Wrap(err, "could not list GameServers from the apiserver")
+	}
+
+	gsList := make([]*agonesv1.GameServer, len(list.Items))
+	for i := range list.Items {
+		gsList[i] = &list.Items[i]
+	}
+	c.updateReadyGameServers(gsList)
+
+	if rv, ok := parseResourceVersion(list.ListMeta.ResourceVersion); ok {
+		c.watermarkMutex.Lock()
+		defer c.watermarkMutex.Unlock()
+		if rv > c.refreshedResourceVersion {
+			c.refreshedResourceVersion = rv
+		}
+	}
+	return nil
+}
+
+// updateReadyGameServers updates the local cache with the Ready GameServers in the list
+func (c *ReadyGameServerCache) updateReadyGameServers(gsList []*agonesv1.GameServer) {
 	// convert list of current gameservers to map for faster access
 	currGameservers := make(map[string]*agonesv1.GameServer)
 	for _, gs := range gsList {
@@ -264,24 +426,111 @@ func (c *ReadyGameServerCache) syncReadyGSServerCache() error {
 		return true
 	})
 
+	c.droppedWrites.Range(func(key string, _ *agonesv1.GameServer) bool {
+		if gs, ok := currGameservers[key]; !ok || !(gs.DeletionTimestamp.IsZero() && gs.Status.State == agonesv1.GameServerStateReady) {
+			tobeDeletedGSInCache = append(tobeDeletedGSInCache, key)
+		}
+		return true
+	})
+
 	for _, staleGSKey := range tobeDeletedGSInCache {
-		c.readyGameServers.Delete(staleGSKey)
+		c.removeReady(staleGSKey)
 	}
 
 	// refresh the cache of possible allocatable GameServers
 	for key, gs := range currGameservers {
 		if gsCache, ok := c.readyGameServers.Load(key); ok {
 			if !(gs.DeletionTimestamp.IsZero() && gs.Status.State == agonesv1.GameServerStateReady) {
-				c.readyGameServers.Delete(key)
+				c.removeReady(key)
 			} else if gs.ObjectMeta.ResourceVersion != gsCache.ObjectMeta.ResourceVersion {
-				c.readyGameServers.Store(key, gs)
+				c.storeReady(key, gs)
 			}
 		} else if gs.DeletionTimestamp.IsZero() && gs.Status.State == agonesv1.GameServerStateReady {
-			c.readyGameServers.Store(key, gs)
+			c.storeReady(key, gs)
 		}
 	}
+}
 
-	return nil
+// ObserveResourceVersion records a resourceVersion of a GameServer returned by the apiserver, as a
+// watermark that the cache should catch up with. Only the oldest watermark not caught up with is kept.
+func (c *ReadyGameServerCache) ObserveResourceVersion(resourceVersion string) {
+	rv, ok := parseResourceVersion(resourceVersion)
+	if !ok {
+		return
+	}
+
+	c.watermarkMutex.Lock()
+	defer c.watermarkMutex.Unlock()
+	if c.watermark == nil || c.caughtUp(c.watermark.resourceVersion) {
+		c.watermark = &resourceVersionWatermark{resourceVersion: rv, observed: c.clock.Now()}
+	}
+}
+
+// Lag returns how long the cache has been behind the apiserver, or zero if it has caught up
+func (c *ReadyGameServerCache) Lag() time.Duration {
+	c.watermarkMutex.Lock()
+	defer c.watermarkMutex.Unlock()
+	if c.watermark == nil {
+		return 0
+	}
+	if c.caughtUp(c.watermark.resourceVersion) {
+		c.watermark = nil
+		return 0
+	}
+	return c.clock.Since(c.watermark.observed)
+}
+
+// Stale returns true if the cache has been behind the apiserver for longer than maxReadyCacheLag,
+// in which case allocations from it may pick GameServers that are gone or no longer Ready
+func (c *ReadyGameServerCache) Stale() bool {
+	return c.Lag() > maxReadyCacheLag
+}
+
+// informerBehind returns true if the informer has not caught up with the apiserver,
+// or with the last refresh of the cache from the apiserver
+func (c *ReadyGameServerCache) informerBehind() bool {
+	if c.Lag() > 0 {
+		return true
+	}
+	c.watermarkMutex.Lock()
+	defer c.watermarkMutex.Unlock()
+	rv, ok := parseResourceVersion(c.informerResourceVersion())
+	return ok && rv < c.refreshedResourceVersion
+}
+
+// caughtUp returns true if the cache has seen the changes up to the resourceVersion,
+// either through the informer or a refresh from the apiserver.
+// watermarkMutex must be held by the caller.
+func (c *ReadyGameServerCache) caughtUp(rv uint64) bool {
+	if c.refreshedResourceVersion >= rv {
+		return true
+	}
+	current, ok := parseResourceVersion(c.informerResourceVersion())
+	// resourceVersions that can't be compared can't tell the cache is behind
+	return !ok || current >= rv
+}
+
+// refreshedSince returns true if the cache was refreshed from the apiserver after the change to the GameServer
+func (c *ReadyGameServerCache) refreshedSince(gs *agonesv1.GameServer) bool {
+	rv, ok := parseResourceVersion(gs.ObjectMeta.ResourceVersion)
+	if !ok {
+		return false
+	}
+	c.watermarkMutex.Lock()
+	defer c.watermarkMutex.Unlock()
+	return rv <= c.refreshedResourceVersion
+}
+
+// recordLag records how long the cache has been behind the apiserver
+func (c *ReadyGameServerCache) recordLag() {
+	stats.Record(context.Background(), gameServerCacheLagStats.M(c.Lag().Seconds()))
+}
+
+// parseResourceVersion parses a resourceVersion as the etcd revision that backs it. resourceVersions
+// are opaque to clients, so ok is false for any that are not a revision, and they are not compared.
+func parseResourceVersion(resourceVersion string) (uint64, bool) {
+	rv, err := strconv.ParseUint(resourceVersion, 10, 64)
+	return rv, err == nil
 }
 
 // getKey extract the key of gameserver object
@@ -0,0 +1,139 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// indexKey is a key of the index of a sortedGameServers, which is either a namespace,
+// or a value of an indexed label in a namespace
+type indexKey struct {
+	namespace string
+	label     string
+	value     string
+}
+
+// sortedGameServers is a list of Ready GameServers sorted in Packed priority order, with an index of the
+// positions of the GameServers in each namespace, and with each value of the indexed labels.
+// This means finding a GameServer for an allocation only looks at the GameServers that can match its selectors,
+// rather than the whole list.
+// GameServers are removed by clearing their position in the list, so the positions in the index stay valid.
+type sortedGameServers struct {
+	list   []*agonesv1.GameServer
+	labels []string
+	index  map[indexKey][]int
+	// names are the positions of the GameServers by namespace and name
+	names map[indexKey]int
+}
+
+// newSortedGameServers indexes a sorted list of Ready GameServers on their namespace and indexLabels
+func newSortedGameServers(list []*agonesv1.GameServer, indexLabels []string) *sortedGameServers {
+	s := &sortedGameServers{list: list, labels: indexLabels, index: map[indexKey][]int{}, names: make(map[indexKey]int, len(list))}
+	for i, gs := range list {
+		s.names[indexKey{namespace: gs.ObjectMeta.Namespace, value: gs.ObjectMeta.Name}] = i
+		key := indexKey{namespace: gs.ObjectMeta.Namespace}
+		s.index[key] = append(s.index[key], i)
+		for _, label := range indexLabels {
+			if value, ok := gs.ObjectMeta.Labels[label]; ok {
+				key := indexKey{namespace: gs.ObjectMeta.Namespace, label: label, value: value}
+				s.index[key] = append(s.index[key], i)
+			}
+		}
+	}
+	return s
+}
+
+// remove removes the GameServer at position i from the list
+func (s *sortedGameServers) remove(i int) {
+	s.list[i] = nil
+}
+
+// position returns the position of the GameServer with the name in the namespace,
+// or false if it is not in the list, or has been removed from it
+func (s *sortedGameServers) position(namespace, name string) (int, bool) {
+	i, ok := s.names[indexKey{namespace: namespace, value: name}]
+	if !ok || s.list[i] == nil {
+		return -1, false
+	}
+	return i, true
+}
+
+// candidates returns the positions, in ascending order, of the GameServers that may match
+// the required or any of the preferred selectors of the GameServerAllocation.
+// Removed GameServers may still be included.
+func (s *sortedGameServers) candidates(gsa *allocationv1.GameServerAllocation) []int {
+	all := s.index[indexKey{namespace: gsa.ObjectMeta.Namespace}]
+
+	positions := make([][]int, 0, len(gsa.Spec.Preferred)+1)
+	for _, sel := range append([]metav1.LabelSelector{gsa.Spec.Required}, gsa.Spec.Preferred...) {
+		p := s.positions(gsa.ObjectMeta.Namespace, sel, all)
+		// one of the selectors can't be narrowed down, so any GameServer in the namespace may match
+		if len(p) == len(all) {
+			return all
+		}
+		positions = append(positions, p)
+	}
+
+	if len(positions) == 1 {
+		return positions[0]
+	}
+	return mergePositions(positions)
+}
+
+// positions returns the smallest list of positions of the GameServers that are indexed with
+// one of the match labels of the selector, or all positions if none of the match labels are indexed
+func (s *sortedGameServers) positions(namespace string, sel metav1.LabelSelector, all []int) []int {
+	result := all
+	for _, label := range s.labels {
+		if value, ok := sel.MatchLabels[label]; ok {
+			if p := s.index[indexKey{namespace: namespace, label: label, value: value}]; len(p) < len(result) {
+				result = p
+			}
+		}
+	}
+	return result
+}
+
+// mergePositions merges lists of positions in ascending order into a single list
+// of positions in ascending order, without duplicates
+func mergePositions(positions [][]int) []int {
+	length := 0
+	for _, p := range positions {
+		length += len(p)
+	}
+
+	result := make([]int, 0, length)
+	next := make([]int, len(positions))
+	for {
+		min := -1
+		for i, p := range positions {
+			if next[i] < len(p) && (min == -1 || p[next[i]] < min) {
+				min = p[next[i]]
+			}
+		}
+		if min == -1 {
+			return result
+		}
+		result = append(result, min)
+		for i, p := range positions {
+			if next[i] < len(p) && p[next[i]] == min {
+				next[i]++
+			}
+		}
+	}
+}
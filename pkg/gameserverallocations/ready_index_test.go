@@ -0,0 +1,140 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"testing"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSortedGameServersCandidates(t *testing.T) {
+	t.Parallel()
+
+	newGs := func(name, namespace string, labels map[string]string) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+			Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}}
+	}
+	fleetA := map[string]string{agonesv1.FleetNameLabel: "a", "mode": "deathmatch"}
+	fleetB := map[string]string{agonesv1.FleetNameLabel: "b", "mode": "ctf"}
+
+	list := newSortedGameServers([]*agonesv1.GameServer{
+		newGs("gs0", defaultNs, fleetA),
+		newGs("gs1", defaultNs, fleetB),
+		newGs("gs2", "other", fleetA),
+		newGs("gs3", defaultNs, fleetA),
+		newGs("gs4", defaultNs, map[string]string{"mode": "ctf"}),
+		newGs("gs5", defaultNs, fleetB),
+	}, []string{agonesv1.FleetNameLabel, "mode"})
+
+	fixtures := map[string]struct {
+		required  metav1.LabelSelector
+		preferred []metav1.LabelSelector
+		expected  []int
+	}{
+		"no selector": {
+			expected: []int{0, 1, 3, 4, 5},
+		},
+		"not indexed label": {
+			required: metav1.LabelSelector{MatchLabels: map[string]string{"role": "gameserver"}},
+			expected: []int{0, 1, 3, 4, 5},
+		},
+		"fleet": {
+			required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "a"}},
+			expected: []int{0, 3},
+		},
+		"smallest index": {
+			required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "b", "mode": "ctf"}},
+			expected: []int{1, 5},
+		},
+		"no match": {
+			required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "c"}},
+			expected: nil,
+		},
+		"preferred": {
+			required:  metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "b"}},
+			preferred: []metav1.LabelSelector{{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "a"}}},
+			expected:  []int{0, 1, 3, 5},
+		},
+		"preferred not indexed": {
+			required:  metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "b"}},
+			preferred: []metav1.LabelSelector{{MatchLabels: map[string]string{"role": "gameserver"}}},
+			expected:  []int{0, 1, 3, 4, 5},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			gsa := &allocationv1.GameServerAllocation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+				Spec:       allocationv1.GameServerAllocationSpec{Required: v.required, Preferred: v.preferred},
+			}
+			assert.Equal(t, v.expected, list.candidates(gsa))
+		})
+	}
+}
+
+func TestFindGameServerForAllocationIndexed(t *testing.T) {
+	t.Parallel()
+
+	newGs := func(name, fleet string) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs,
+			Labels: map[string]string{agonesv1.FleetNameLabel: fleet}},
+			Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}}
+	}
+	list := newSortedGameServers([]*agonesv1.GameServer{
+		newGs("gs0", "a"), newGs("gs1", "b"), newGs("gs2", "a"), newGs("gs3", "b"),
+	}, []string{agonesv1.FleetNameLabel})
+
+	gsa := &allocationv1.GameServerAllocation{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:   metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "b"}},
+			Scheduling: apis.Packed,
+		},
+	}
+
+	gs, index, err := findGameServerForAllocation(gsa, list)
+	assert.NoError(t, err)
+	assert.Equal(t, "gs1", gs.ObjectMeta.Name)
+	assert.Equal(t, 1, index)
+
+	list.remove(index)
+	gs, index, err = findGameServerForAllocation(gsa, list)
+	assert.NoError(t, err)
+	assert.Equal(t, "gs3", gs.ObjectMeta.Name)
+	assert.Equal(t, 3, index)
+
+	list.remove(index)
+	_, _, err = findGameServerForAllocation(gsa, list)
+	assert.Equal(t, ErrNoGameServerReady, err)
+
+	// the other fleet is still there
+	gsa.Spec.Required.MatchLabels[agonesv1.FleetNameLabel] = "a"
+	gs, _, err = findGameServerForAllocation(gsa, list)
+	assert.NoError(t, err)
+	assert.Equal(t, "gs0", gs.ObjectMeta.Name)
+}
+
+func TestMergePositions(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, []int{}, mergePositions(nil))
+	assert.Equal(t, []int{0, 1, 2, 4, 5, 7}, mergePositions([][]int{{1, 4, 7}, {0, 1, 5}, {}, {2, 4}}))
+}
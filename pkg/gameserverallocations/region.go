@@ -0,0 +1,127 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"net"
+	"net/http"
+
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RegionHinter derives the region that an allocation request should preferably be served from,
+// from the metadata of its client, such as a header set by a load balancer or the address of the client.
+// It returns an empty string if it has no hint for the request.
+type RegionHinter interface {
+	RegionHint(header http.Header, remoteAddr string) string
+}
+
+// RegionHinters is a RegionHinter that returns the first hint of its RegionHinters
+type RegionHinters []RegionHinter
+
+// RegionHint returns the first hint of the RegionHinters
+func (r RegionHinters) RegionHint(header http.Header, remoteAddr string) string {
+	for _, hinter := range r {
+		if region := hinter.RegionHint(header, remoteAddr); region != "" {
+			return region
+		}
+	}
+	return ""
+}
+
+// HeaderRegionHinter is a RegionHinter that returns the value of the header with its name
+type HeaderRegionHinter string
+
+// RegionHint returns the value of the header
+func (h HeaderRegionHinter) RegionHint(header http.Header, _ string) string {
+	if h == "" {
+		return ""
+	}
+	return header.Get(string(h))
+}
+
+// CIDRRegion is the region of the clients whose address is in a network
+type CIDRRegion struct {
+	Network *net.IPNet
+	Region  string
+}
+
+// CIDRRegionHinter is a RegionHinter that returns the region of the first network the address of the client is in
+type CIDRRegionHinter []CIDRRegion
+
+// RegionHint returns the region of the first network that contains the remote address
+func (c CIDRRegionHinter) RegionHint(_ http.Header, remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return ""
+	}
+	for _, r := range c {
+		if r.Network.Contains(ip) {
+			return r.Region
+		}
+	}
+	return ""
+}
+
+// ApplyRegionHint prefers the GameServers whose label has the region to the other ones the GameServerAllocation
+// selects, by adding a copy of each preferred selector that also matches the label, and a selector of the label,
+// ahead of the preferred selectors. Returns false, and leaves the GameServerAllocation unchanged, if the region
+// is not a valid label value, or the GameServerAllocation already selects on the label, as its client then
+// chose the region itself.
+func ApplyRegionHint(gsa *allocationv1.GameServerAllocation, label, region string) bool {
+	if label == "" || region == "" || len(validation.IsValidLabelValue(region)) > 0 {
+		return false
+	}
+	if selectsLabel(gsa.Spec.Required, label) {
+		return false
+	}
+	for _, p := range gsa.Spec.Preferred {
+		if selectsLabel(p, label) {
+			return false
+		}
+	}
+
+	preferred := make([]metav1.LabelSelector, 0, 2*len(gsa.Spec.Preferred)+1)
+	for _, p := range gsa.Spec.Preferred {
+		sel := *p.DeepCopy()
+		if sel.MatchLabels == nil {
+			sel.MatchLabels = make(map[string]string, 1)
+		}
+		sel.MatchLabels[label] = region
+		preferred = append(preferred, sel)
+	}
+	preferred = append(preferred, metav1.LabelSelector{MatchLabels: map[string]string{label: region}})
+	gsa.Spec.Preferred = append(preferred, gsa.Spec.Preferred...)
+	return true
+}
+
+// selectsLabel returns true if the selector matches on the label
+func selectsLabel(sel metav1.LabelSelector, label string) bool {
+	if _, ok := sel.MatchLabels[label]; ok {
+		return true
+	}
+	for _, e := range sel.MatchExpressions {
+		if e.Key == label {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRegionHinters(t *testing.T) {
+	t.Parallel()
+
+	_, eu, err := net.ParseCIDR("10.0.0.0/16")
+	assert.NoError(t, err)
+	_, us, err := net.ParseCIDR("2001:db8::/32")
+	assert.NoError(t, err)
+
+	hinter := RegionHinters{
+		HeaderRegionHinter("X-Agones-Region"),
+		CIDRRegionHinter{{Network: eu, Region: "eu"}, {Network: us, Region: "us"}},
+	}
+	header := func(region string) http.Header {
+		h := http.Header{}
+		if region != "" {
+			h.Set("x-agones-region", region)
+		}
+		return h
+	}
+
+	fixtures := map[string]struct {
+		header     http.Header
+		remoteAddr string
+		want       string
+	}{
+		"header":                 {header: header("asia"), remoteAddr: "10.0.1.1:4000", want: "asia"},
+		"ipv4 address with port": {header: header(""), remoteAddr: "10.0.1.1:4000", want: "eu"},
+		"ipv6 address with port": {header: header(""), remoteAddr: "[2001:db8::1]:4000", want: "us"},
+		"address without port":   {header: header(""), remoteAddr: "10.0.1.1", want: "eu"},
+		"other network":          {header: header(""), remoteAddr: "192.168.0.1:4000", want: ""},
+		"invalid address":        {header: header(""), remoteAddr: "pipe", want: ""},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			assert.Equal(t, v.want, hinter.RegionHint(v.header, v.remoteAddr))
+		})
+	}
+
+	assert.Equal(t, "", HeaderRegionHinter("").RegionHint(header("asia"), ""))
+}
+
+func TestApplyRegionHint(t *testing.T) {
+	t.Parallel()
+
+	fleet := metav1.LabelSelector{MatchLabels: map[string]string{"agones.dev/fleet": "fleet"}}
+	blue := metav1.LabelSelector{MatchLabels: map[string]string{"color": "blue"}}
+
+	fixtures := map[string]struct {
+		spec    allocationv1.GameServerAllocationSpec
+		region  string
+		applied bool
+		want    []metav1.LabelSelector
+	}{
+		"no preferred": {
+			spec:    allocationv1.GameServerAllocationSpec{Required: fleet},
+			region:  "eu",
+			applied: true,
+			want:    []metav1.LabelSelector{{MatchLabels: map[string]string{"region": "eu"}}},
+		},
+		"preferred": {
+			spec:    allocationv1.GameServerAllocationSpec{Required: fleet, Preferred: []metav1.LabelSelector{blue, {}}},
+			region:  "eu",
+			applied: true,
+			want: []metav1.LabelSelector{
+				{MatchLabels: map[string]string{"color": "blue", "region": "eu"}},
+				{MatchLabels: map[string]string{"region": "eu"}},
+				{MatchLabels: map[string]string{"region": "eu"}},
+				blue,
+				{},
+			},
+		},
+		"required selects the region": {
+			spec: allocationv1.GameServerAllocationSpec{Required: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "region", Operator: metav1.LabelSelectorOpIn, Values: []string{"us"}}}}},
+			region: "eu",
+		},
+		"preferred selects the region": {
+			spec:   allocationv1.GameServerAllocationSpec{Preferred: []metav1.LabelSelector{{MatchLabels: map[string]string{"region": "us"}}}},
+			region: "eu",
+			want:   []metav1.LabelSelector{{MatchLabels: map[string]string{"region": "us"}}},
+		},
+		"no region": {
+			spec: allocationv1.GameServerAllocationSpec{Required: fleet},
+		},
+		"invalid region": {
+			spec:   allocationv1.GameServerAllocationSpec{Required: fleet},
+			region: "eu west",
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			gsa := &allocationv1.GameServerAllocation{Spec: *v.spec.DeepCopy()}
+			assert.Equal(t, v.applied, ApplyRegionHint(gsa, "region", v.region))
+			assert.Equal(t, v.want, gsa.Spec.Preferred)
+			assert.Equal(t, v.spec.Required, gsa.Spec.Required)
+		})
+	}
+
+	// the preferred selectors of the request are not modified
+	gsa := &allocationv1.GameServerAllocation{Spec: allocationv1.GameServerAllocationSpec{Preferred: []metav1.LabelSelector{blue}}}
+	assert.True(t, ApplyRegionHint(gsa, "region", "eu"))
+	assert.Equal(t, map[string]string{"color": "blue"}, blue.MatchLabels)
+}
@@ -0,0 +1,189 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+)
+
+// remoteClientKey identifies a cached client to a remote allocator service
+type remoteClientKey struct {
+	namespace  string
+	secretName string
+	serverName string
+	endpoint   string
+}
+
+// remoteClient is a client to the allocator service behind a single allocation endpoint,
+// along with the resource version of the secret its client certificates were loaded from
+type remoteClient struct {
+	httpClient      *http.Client
+	conn            *grpc.ClientConn
+	resourceVersion string
+	// users is the number of allocations that are currently using the client
+	users int
+	// retired is true once the client has been removed from the cache, it is closed
+	// when the last allocation that is using it is done
+	retired bool
+}
+
+// close releases the connections held by the client
+func (rc *remoteClient) close() error {
+	if rc.httpClient != nil {
+		if t, ok := rc.httpClient.Transport.(*http.Transport); ok {
+			t.CloseIdleConnections()
+		}
+	}
+	if rc.conn != nil {
+		return rc.conn.Close()
+	}
+	return nil
+}
+
+// getRemoteClusterRestClient returns a cached rest client with proper certs to make a remote call to the endpoint.
+// The returned release function must be called once the remote call is done.
+func (c *Allocator) getRemoteClusterRestClient(namespace, secretName, serverName, endpoint string) (*http.Client, func(), error) {
+	rc, release, err := c.getRemoteClient(remoteClientKey{namespace: namespace, secretName: secretName, serverName: serverName, endpoint: endpoint}, func(rc *remoteClient) (err error) {
+		rc.httpClient, err = c.createRemoteClusterRestClient(namespace, secretName, serverName)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc.httpClient, release, nil
+}
+
+// getRemoteClusterConn returns a cached gRPC connection with proper certs to the allocator service behind the endpoint.
+// The returned release function must be called once the remote call is done.
+func (c *Allocator) getRemoteClusterConn(namespace, secretName, serverName, endpoint string) (*grpc.ClientConn, func(), error) {
+	target, err := remoteClusterTarget(endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+	rc, release, err := c.getRemoteClient(remoteClientKey{namespace: namespace, secretName: secretName, serverName: serverName, endpoint: endpoint}, func(rc *remoteClient) error {
+		tlsConfig, err := c.createRemoteClusterTLSConfig(namespace, secretName, serverName)
+		if err != nil {
+			return err
+		}
+		rc.conn, err = grpc.Dial(target,
+			grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+			grpc.WithKeepaliveParams(keepalive.ClientParameters{
+				Time:                remoteAllocationKeepaliveTime,
+				Timeout:             remoteAllocationKeepaliveTimeout,
+				PermitWithoutStream: true,
+			}))
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc.conn, release, nil
+}
+
+// getRemoteClient returns the cached client for the endpoint, or creates one with the create function,
+// along with a function that releases the client once the caller is done with it.
+// A cached client is replaced if the secret holding its certs has changed since it was created.
+func (c *Allocator) getRemoteClient(key remoteClientKey, create func(rc *remoteClient) error) (*remoteClient, func(), error) {
+	secret, err := c.secretLister.Secrets(key.namespace).Get(key.secretName)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	c.remoteClientsMutex.Lock()
+	defer c.remoteClientsMutex.Unlock()
+
+	rc, ok := c.remoteClients[key]
+	if ok && rc.resourceVersion != secret.ObjectMeta.ResourceVersion {
+		c.retireRemoteClient(key, rc)
+		ok = false
+	}
+	if !ok {
+		rc = &remoteClient{resourceVersion: secret.ObjectMeta.ResourceVersion}
+		if err := create(rc); err != nil {
+			return nil, nil, err
+		}
+		c.remoteClients[key] = rc
+	}
+
+	rc.users++
+	return rc, func() { c.releaseRemoteClient(key, rc) }, nil
+}
+
+// releaseRemoteClient is called when an allocation is done with a client,
+// and closes the client if it has been retired and this was its last user
+func (c *Allocator) releaseRemoteClient(key remoteClientKey, rc *remoteClient) {
+	c.remoteClientsMutex.Lock()
+	defer c.remoteClientsMutex.Unlock()
+
+	rc.users--
+	if rc.retired && rc.users == 0 {
+		c.closeRemoteClient(key, rc)
+	}
+}
+
+// invalidateRemoteClients retires all cached clients that use the certs in the given secret,
+// so that the next allocations create new clients with the current certs
+func (c *Allocator) invalidateRemoteClients(namespace, secretName string) {
+	c.remoteClientsMutex.Lock()
+	defer c.remoteClientsMutex.Unlock()
+
+	for key, rc := range c.remoteClients {
+		if key.namespace == namespace && key.secretName == secretName {
+			c.retireRemoteClient(key, rc)
+		}
+	}
+}
+
+// retireRemoteClient removes a client from the cache. The client is closed right away if it is not in use,
+// otherwise allocations that are in flight are not dropped, and it is closed once the last of them is done.
+// c.remoteClientsMutex must be held.
+func (c *Allocator) retireRemoteClient(key remoteClientKey, rc *remoteClient) {
+	delete(c.remoteClients, key)
+	rc.retired = true
+	if rc.users == 0 {
+		c.closeRemoteClient(key, rc)
+	}
+}
+
+// closeRemoteClient closes a client to a remote cluster.
+// c.remoteClientsMutex must be held.
+func (c *Allocator) closeRemoteClient(key remoteClientKey, rc *remoteClient) {
+	if err := rc.close(); err != nil {
+		c.baseLogger.WithError(err).WithField("endpoint", key.endpoint).Warn("Could not close client to remote cluster")
+	}
+}
+
+// remoteClusterTarget returns the host:port gRPC target of an allocation endpoint, which can either be
+// a url (the same endpoint that is used for http) or a host with an optional port.
+func remoteClusterTarget(endpoint string) (string, error) {
+	host := endpoint
+	if u, err := url.Parse(endpoint); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	if host == "" {
+		return "", fmt.Errorf("invalid allocation endpoint %q", endpoint)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(host, "443")
+	}
+	return host, nil
+}
@@ -0,0 +1,219 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/connectivity"
+	corev1 "k8s.io/api/core/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAllocatorRemoteClients(t *testing.T) {
+	t.Parallel()
+
+	const secretName = "secret-name"
+	setup := func(transport string) (*Controller, *watch.FakeWatcher, corev1.Secret, func()) {
+		c, m := newFakeController()
+		c.allocator.remoteAllocationTransport = transport
+
+		secret := getTestSecret(secretName, clientCert).Items[0]
+		secret.ObjectMeta.ResourceVersion = "1"
+		m.KubeClient.AddReactor("list", "secrets",
+			func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, &corev1.SecretList{Items: []corev1.Secret{secret}}, nil
+			})
+		secretWatch := watch.NewFake()
+		m.KubeClient.AddWatchReactor("secrets", k8stesting.DefaultWatchReactor(secretWatch, nil))
+
+		_, cancel := agtesting.StartInformers(m, c.allocator.secretSynced)
+		return c, secretWatch, secret, cancel
+	}
+
+	t.Run("http clients are reused per endpoint", func(t *testing.T) {
+		c, _, _, cancel := setup(RemoteAllocationTransportHTTP)
+		defer cancel()
+
+		client1, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+		client2, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+		client3, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://endpoint2")
+		assert.NoError(t, err)
+
+		assert.True(t, client1 == client2, "client should be reused for the same endpoint")
+		assert.False(t, client1 == client3, "client should not be reused for a different endpoint")
+		assert.Len(t, c.allocator.remoteClients, 2)
+	})
+
+	t.Run("grpc connections are reused per endpoint", func(t *testing.T) {
+		c, _, _, cancel := setup(RemoteAllocationTransportGRPC)
+		defer cancel()
+
+		conn1, _, err := c.allocator.getRemoteClusterConn(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+		conn2, _, err := c.allocator.getRemoteClusterConn(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+
+		assert.True(t, conn1 == conn2, "connection should be reused for the same endpoint")
+		assert.Len(t, c.allocator.remoteClients, 1)
+	})
+
+	t.Run("secret update invalidates clients", func(t *testing.T) {
+		c, secretWatch, secret, cancel := setup(RemoteAllocationTransportHTTP)
+		defer cancel()
+
+		client1, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+		_, _, err = c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://endpoint2")
+		assert.NoError(t, err)
+
+		secret.ObjectMeta.ResourceVersion = "2"
+		secretWatch.Modify(secret.DeepCopy())
+
+		err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			c.allocator.remoteClientsMutex.Lock()
+			defer c.allocator.remoteClientsMutex.Unlock()
+			return len(c.allocator.remoteClients) == 0, nil
+		})
+		assert.NoError(t, err)
+
+		client2, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+		assert.False(t, client1 == client2, "client should be recreated after the secret changed")
+	})
+
+	t.Run("secret delete invalidates clients", func(t *testing.T) {
+		c, secretWatch, secret, cancel := setup(RemoteAllocationTransportGRPC)
+		defer cancel()
+
+		_, _, err := c.allocator.getRemoteClusterConn(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+
+		secretWatch.Delete(secret.DeepCopy())
+
+		err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			c.allocator.remoteClientsMutex.Lock()
+			defer c.allocator.remoteClientsMutex.Unlock()
+			return len(c.allocator.remoteClients) == 0, nil
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("client in use is closed once released", func(t *testing.T) {
+		c, secretWatch, secret, cancel := setup(RemoteAllocationTransportGRPC)
+		defer cancel()
+
+		conn1, release1, err := c.allocator.getRemoteClusterConn(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+		_, release2, err := c.allocator.getRemoteClusterConn(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+
+		secret.ObjectMeta.ResourceVersion = "2"
+		secretWatch.Modify(secret.DeepCopy())
+
+		err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+			c.allocator.remoteClientsMutex.Lock()
+			defer c.allocator.remoteClientsMutex.Unlock()
+			return len(c.allocator.remoteClients) == 0, nil
+		})
+		assert.NoError(t, err)
+
+		// in flight allocations keep using the connection until they are done
+		release1()
+		assert.NotEqual(t, connectivity.Shutdown, conn1.GetState())
+		release2()
+		assert.Equal(t, connectivity.Shutdown, conn1.GetState())
+
+		conn2, release, err := c.allocator.getRemoteClusterConn(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+		defer release()
+		assert.False(t, conn1 == conn2, "connection should be recreated after the secret changed")
+	})
+
+	t.Run("stale client is replaced", func(t *testing.T) {
+		c, _, _, cancel := setup(RemoteAllocationTransportHTTP)
+		defer cancel()
+
+		client1, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+
+		// simulate a client that was created from an older version of the secret
+		c.allocator.remoteClients[remoteClientKey{namespace: defaultNs, secretName: secretName, endpoint: "https://endpoint1"}].resourceVersion = "0"
+
+		client2, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://endpoint1")
+		assert.NoError(t, err)
+		assert.False(t, client1 == client2, "client should be recreated for a newer secret")
+		assert.Len(t, c.allocator.remoteClients, 1)
+	})
+
+	t.Run("server name override", func(t *testing.T) {
+		c, _, _, cancel := setup(RemoteAllocationTransportHTTP)
+		defer cancel()
+
+		client1, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "", "https://10.0.0.1")
+		assert.NoError(t, err)
+		client2, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, secretName, "allocator.example.com", "https://10.0.0.1")
+		assert.NoError(t, err)
+
+		assert.False(t, client1 == client2, "client should not be reused for a different server name")
+		assert.Equal(t, "", client1.Transport.(*http.Transport).TLSClientConfig.ServerName)
+		assert.Equal(t, "allocator.example.com", client2.Transport.(*http.Transport).TLSClientConfig.ServerName)
+	})
+
+	t.Run("missing secret", func(t *testing.T) {
+		c, _, _, cancel := setup(RemoteAllocationTransportHTTP)
+		defer cancel()
+
+		_, _, err := c.allocator.getRemoteClusterRestClient(defaultNs, "missing", "", "https://endpoint1")
+		assert.Error(t, err)
+		assert.Len(t, c.allocator.remoteClients, 0)
+	})
+}
+
+func TestRemoteClusterTarget(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]struct {
+		endpoint string
+		target   string
+	}{
+		"url":              {endpoint: "https://allocator.example.com/v1alpha1/gameserverallocation", target: "allocator.example.com:443"},
+		"url with port":    {endpoint: "https://127.0.0.1:8443/v1alpha1/gameserverallocation", target: "127.0.0.1:8443"},
+		"host":             {endpoint: "allocator.example.com", target: "allocator.example.com:443"},
+		"host with port":   {endpoint: "allocator.example.com:8443", target: "allocator.example.com:8443"},
+		"ip with port":     {endpoint: "10.0.0.1:8443", target: "10.0.0.1:8443"},
+		"ipv6 without url": {endpoint: "[::1]:8443", target: "[::1]:8443"},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			target, err := remoteClusterTarget(v.endpoint)
+			assert.NoError(t, err)
+			assert.Equal(t, v.target, target)
+		})
+	}
+
+	_, err := remoteClusterTarget("")
+	assert.Error(t, err)
+}
@@ -0,0 +1,129 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"crypto/tls"
+	"net"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// endpointHealth is the health of the remote allocation endpoints, as seen by the last probe.
+// Endpoints that have not been probed yet are considered healthy.
+type endpointHealth struct {
+	mutex     sync.RWMutex
+	unhealthy map[string]bool
+}
+
+// newEndpointHealth returns an endpointHealth with all endpoints healthy
+func newEndpointHealth() *endpointHealth {
+	return &endpointHealth{unhealthy: map[string]bool{}}
+}
+
+// healthy returns false if the endpoint failed the last probe
+func (eh *endpointHealth) healthy(endpoint string) bool {
+	eh.mutex.RLock()
+	defer eh.mutex.RUnlock()
+	return !eh.unhealthy[endpoint]
+}
+
+// anyHealthy returns true if any of the endpoints is healthy, or if there are no endpoints,
+// so that a cluster is only skipped when all of its endpoints are known to be down
+func (eh *endpointHealth) anyHealthy(endpoints []string) bool {
+	if len(endpoints) == 0 {
+		return true
+	}
+	for _, endpoint := range endpoints {
+		if eh.healthy(endpoint) {
+			return true
+		}
+	}
+	return false
+}
+
+// set replaces the health of all endpoints with the results of a probe,
+// which maps each probed endpoint to whether it is healthy
+func (eh *endpointHealth) set(results map[string]bool) {
+	unhealthy := map[string]bool{}
+	for endpoint, ok := range results {
+		if !ok {
+			unhealthy[endpoint] = true
+		}
+	}
+
+	eh.mutex.Lock()
+	defer eh.mutex.Unlock()
+	eh.unhealthy = unhealthy
+}
+
+// probeRemoteEndpoints checks the health of the allocation endpoints of all GameServerAllocationPolicies,
+// so that allocations skip the endpoints and clusters that are known to be down.
+// An endpoint is healthy if a mutual tls connection can be made to it with the client certificates
+// of its policy.
+func (c *Allocator) probeRemoteEndpoints() {
+	policies, err := c.allocationPolicyLister.List(labels.Everything())
+	if err != nil {
+		c.baseLogger.WithError(err).Error("Could not list allocation policies to probe")
+		return
+	}
+
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	results := map[string]bool{}
+	for _, policy := range policies {
+		info := policy.Spec.ConnectionInfo
+		for _, endpoint := range info.AllocationEndpoints {
+			wg.Add(1)
+			go func(namespace, secretName, serverName, endpoint string) {
+				defer wg.Done()
+				err := c.probeEndpoint(namespace, secretName, serverName, endpoint)
+				if err != nil {
+					c.baseLogger.WithError(err).WithField("endpoint", endpoint).Debug("Allocation endpoint failed health probe")
+				}
+				mutex.Lock()
+				defer mutex.Unlock()
+				// the same endpoint may be used by several policies, it is healthy if any of them can reach it
+				results[endpoint] = results[endpoint] || err == nil
+			}(policy.ObjectMeta.Namespace, info.SecretName, info.ServerName, endpoint)
+		}
+	}
+	wg.Wait()
+
+	for endpoint, ok := range results {
+		if ok != c.remoteEndpointHealth.healthy(endpoint) {
+			c.baseLogger.WithField("endpoint", endpoint).WithField("healthy", ok).Info("Allocation endpoint health changed")
+		}
+	}
+	c.remoteEndpointHealth.set(results)
+}
+
+// probeEndpoint makes a mutual tls connection to the allocator service behind the endpoint
+func (c *Allocator) probeEndpoint(namespace, secretName, serverName, endpoint string) error {
+	target, err := remoteClusterTarget(endpoint)
+	if err != nil {
+		return err
+	}
+	tlsConfig, err := c.createRemoteClusterTLSConfig(namespace, secretName, serverName)
+	if err != nil {
+		return err
+	}
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: remoteEndpointProbeTimeout}, "tcp", target, tlsConfig)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
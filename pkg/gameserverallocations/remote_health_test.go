@@ -0,0 +1,148 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	multiclusterv1alpha1 "agones.dev/agones/pkg/apis/multicluster/v1alpha1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestEndpointHealth(t *testing.T) {
+	t.Parallel()
+
+	eh := newEndpointHealth()
+	assert.True(t, eh.healthy("a"))
+	assert.True(t, eh.anyHealthy(nil))
+
+	eh.set(map[string]bool{"a": false, "b": true, "c": false})
+	assert.False(t, eh.healthy("a"))
+	assert.True(t, eh.healthy("b"))
+	assert.True(t, eh.healthy("unknown"))
+	assert.True(t, eh.anyHealthy([]string{"a", "b"}))
+	assert.False(t, eh.anyHealthy([]string{"a", "c"}))
+
+	// endpoints that are no longer probed are forgotten
+	eh.set(map[string]bool{"b": true})
+	assert.True(t, eh.healthy("a"))
+	assert.True(t, eh.anyHealthy([]string{"a", "c"}))
+}
+
+func TestAllocatorProbeRemoteEndpoints(t *testing.T) {
+	t.Parallel()
+
+	const (
+		clusterName = "remotecluster"
+		secretName  = "remotecluster-secret"
+	)
+
+	setup := func(endpoints func(url string) []string) (*Controller, string, func()) {
+		c, m := newFakeController()
+
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "test error message", 400)
+		}))
+		certpool := x509.NewCertPool()
+		certpool.AppendCertsFromPEM(clientCert)
+		server.TLS.ClientCAs = certpool
+		server.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+
+		m.AgonesClient.AddReactor("list", "gameserverallocationpolicies", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+			return true, &multiclusterv1alpha1.GameServerAllocationPolicyList{
+				Items: []multiclusterv1alpha1.GameServerAllocationPolicy{
+					{
+						Spec: multiclusterv1alpha1.GameServerAllocationPolicySpec{
+							Priority: 1,
+							Weight:   100,
+							ConnectionInfo: multiclusterv1alpha1.ClusterConnectionInfo{
+								AllocationEndpoints: endpoints(server.URL),
+								ClusterName:         clusterName,
+								SecretName:          secretName,
+								Namespace:           defaultNs,
+							},
+						},
+						ObjectMeta: metav1.ObjectMeta{
+							Namespace: defaultNs,
+						},
+					},
+				},
+			}, nil
+		})
+		m.KubeClient.AddReactor("list", "secrets",
+			func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+				return true, getTestSecret(secretName, getPEMFromDER(server.TLS.Certificates[0].Certificate[0])), nil
+			})
+
+		_, cancel := agtesting.StartInformers(m, c.allocator.allocationPolicySynced, c.allocator.secretSynced)
+		return c, server.URL, func() {
+			cancel()
+			server.Close()
+		}
+	}
+
+	// the url of a server that is no longer listening
+	closed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	closedURL := closed.URL
+	closed.Close()
+
+	t.Run("endpoints are probed", func(t *testing.T) {
+		c, url, cancel := setup(func(url string) []string { return []string{url, closedURL} })
+		defer cancel()
+
+		c.allocator.probeRemoteEndpoints()
+		assert.True(t, c.allocator.remoteEndpointHealth.healthy(url))
+		assert.False(t, c.allocator.remoteEndpointHealth.healthy(closedURL))
+
+		// unhealthy endpoints are skipped when sending allocations
+		res := c.allocator.sendToEndpoints(context.Background(), []string{closedURL}, func(ctx context.Context, endpoint string) endpointResult {
+			assert.FailNow(t, "unhealthy endpoint should not be called")
+			return endpointResult{}
+		})
+		assert.EqualError(t, res.err, "all allocation endpoints are failing, skipping the cluster")
+	})
+
+	t.Run("cluster with no healthy endpoints is skipped", func(t *testing.T) {
+		c, _, cancel := setup(func(url string) []string { return []string{closedURL} })
+		defer cancel()
+
+		c.allocator.probeRemoteEndpoints()
+
+		gsa := &allocationv1.GameServerAllocation{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace:   defaultNs,
+				Name:        "alloc1",
+				ClusterName: "localcluster",
+			},
+			Spec: allocationv1.GameServerAllocationSpec{
+				MultiClusterSetting: allocationv1.MultiClusterSetting{
+					Enabled: true,
+				},
+			},
+		}
+		_, err := c.allocator.applyMultiClusterAllocation(context.Background(), gsa, make(chan struct{}))
+		assert.EqualError(t, err, "all allocation endpoints of cluster "+clusterName+" are unhealthy")
+	})
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// startScaleController runs a controller that waits batchWait between batches against the GameServers of the
+// fixture, once its cache is full
+func startScaleController(t testing.TB, fixture *agtesting.ScaleFixture, batchWait time.Duration) (*Controller, <-chan struct{}, context.CancelFunc) {
+	c, m := newFakeController()
+	c.allocator.batchConfig.WaitTime = batchWait
+	c.recorder = agtesting.NewDiscardingRecorder()
+	c.allocator.recorder = c.recorder
+	fixture.AddReactors(m)
+
+	stop, cancel := agtesting.StartInformers(m)
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	err := wait.PollImmediate(100*time.Millisecond, 30*time.Second, func() (bool, error) {
+		return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+	})
+	if err != nil {
+		assert.FailNow(t, "ready GameServer cache never synced")
+	}
+
+	return c, stop, cancel
+}
+
+func scaleAllocation(fleet string) *allocationv1.GameServerAllocation {
+	gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: fleet}},
+		}}
+	gsa.ApplyDefaults()
+	return gsa
+}
+
+func TestAllocatorScale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping scale test in short mode")
+	}
+	t.Parallel()
+
+	const fleets, replicas, allocations = 5, 1000, 2000
+	fixture := agtesting.NewScaleFixture(defaultNs, fleets, replicas, 100)
+	c, stop, cancel := startScaleController(t, fixture, DefaultBatchConfig.WaitTime)
+	defer cancel()
+
+	var mutex sync.Mutex
+	allocated := map[string]bool{}
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < allocations; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := c.allocator.Allocate(context.Background(), scaleAllocation(fixture.Fleets[i%fleets].ObjectMeta.Name), stop)
+			if !assert.NoError(t, err) {
+				return
+			}
+			gsa, ok := result.(*allocationv1.GameServerAllocation)
+			if !assert.True(t, ok) || !assert.Equal(t, allocationv1.GameServerAllocationAllocated, gsa.Status.State) {
+				return
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+			assert.False(t, allocated[gsa.Status.GameServerName], "GameServer %s allocated twice", gsa.Status.GameServerName)
+			allocated[gsa.Status.GameServerName] = true
+		}(i)
+	}
+	wg.Wait()
+
+	t.Logf("allocated %d of %d GameServers in %v", len(allocated), len(fixture.GameServers), time.Since(start))
+	assert.Len(t, allocated, allocations)
+	assert.Equal(t, allocations, fixture.Updates())
+}
+
+func BenchmarkAllocatorAllocate(b *testing.B) {
+	fixture := agtesting.NewScaleFixture(defaultNs, 1, b.N, 100)
+	// batch quickly, so that the benchmark measures the allocations rather than the wait between batches
+	c, stop, cancel := startScaleController(b, fixture, time.Millisecond)
+	defer cancel()
+	gsa := scaleAllocation(fixture.Fleets[0].ObjectMeta.Name)
+
+	b.SetParallelism(100)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := c.allocator.Allocate(context.Background(), gsa.DeepCopy(), stop); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
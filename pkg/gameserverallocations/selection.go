@@ -0,0 +1,88 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"math/rand"
+	"sync/atomic"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/pkg/errors"
+)
+
+const (
+	// SelectionBest always allocates the best matching GameServer, for the best packing
+	SelectionBest = "best"
+	// SelectionRandom allocates a random one of the TopN best matching GameServers,
+	// so that concurrent allocators are less likely to choose the same GameServer
+	SelectionRandom = "random"
+	// SelectionRoundRobin allocates each of the TopN best matching GameServers in turn
+	SelectionRoundRobin = "round-robin"
+)
+
+// SelectionConfig configures which of the best matching Ready GameServers is allocated for a GameServerAllocation,
+// trading the packing quality of always allocating the best one for less contention between allocators
+type SelectionConfig struct {
+	// Strategy is one of SelectionBest, SelectionRandom or SelectionRoundRobin
+	Strategy string
+	// TopN is the number of best matching GameServers that are selected from
+	TopN int
+}
+
+// DefaultSelectionConfig is the default configuration of the GameServer selection, which allocates the best match
+var DefaultSelectionConfig = SelectionConfig{
+	Strategy: SelectionBest,
+	TopN:     100,
+}
+
+// Validate returns an error if the SelectionConfig is invalid
+func (s SelectionConfig) Validate() error {
+	switch s.Strategy {
+	case SelectionBest, SelectionRandom, SelectionRoundRobin:
+	default:
+		return errors.Errorf("allocation selection must be one of %s, %s or %s", SelectionBest, SelectionRandom, SelectionRoundRobin)
+	}
+	if s.TopN <= 0 {
+		return errors.New("allocation top n must be greater than 0")
+	}
+	return nil
+}
+
+// gameServerSelector selects the GameServer that is allocated for a GameServerAllocation
+type gameServerSelector struct {
+	config SelectionConfig
+	// next is the count of round robin selections
+	next uint64
+}
+
+// newGameServerSelector returns a gameServerSelector for the config
+func newGameServerSelector(config SelectionConfig) *gameServerSelector {
+	return &gameServerSelector{config: config}
+}
+
+// find finds the GameServer to allocate for the GameServerAllocation in `list`, and the position it was found at
+func (s *gameServerSelector) find(gsa *allocationv1.GameServerAllocation, list *sortedGameServers) (*agonesv1.GameServer, int, error) {
+	switch s.config.Strategy {
+	case SelectionRandom:
+		return findSelectedGameServerForAllocation(gsa, list, s.config.TopN, rand.Intn)
+	case SelectionRoundRobin:
+		return findSelectedGameServerForAllocation(gsa, list, s.config.TopN, func(n int) int {
+			return int((atomic.AddUint64(&s.next, 1) - 1) % uint64(n))
+		})
+	default:
+		return findGameServerForAllocation(gsa, list)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"testing"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSelectionConfigValidate(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, DefaultSelectionConfig.Validate())
+	assert.NoError(t, SelectionConfig{Strategy: SelectionRandom, TopN: 1}.Validate())
+	assert.NoError(t, SelectionConfig{Strategy: SelectionRoundRobin, TopN: 10}.Validate())
+	assert.Error(t, SelectionConfig{Strategy: "worst", TopN: 10}.Validate())
+	assert.Error(t, SelectionConfig{Strategy: SelectionRandom}.Validate())
+}
+
+func TestGameServerSelectorFind(t *testing.T) {
+	t.Parallel()
+
+	newGs := func(name string, labels map[string]string) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs, Labels: labels},
+			Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}}
+	}
+	labels := map[string]string{"role": "gameserver"}
+	prefLabels := map[string]string{"role": "gameserver", "preferred": "true"}
+
+	// the list is sorted in Packed priority order
+	list := newSortedGameServers([]*agonesv1.GameServer{
+		newGs("gs1", labels),
+		newGs("gs2", prefLabels),
+		newGs("gs3", labels),
+		newGs("gs4", prefLabels),
+		newGs("gs5", prefLabels),
+		newGs("gs6", prefLabels),
+	}, nil)
+
+	gsa := &allocationv1.GameServerAllocation{
+		ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:   metav1.LabelSelector{MatchLabels: labels},
+			Preferred:  []metav1.LabelSelector{{MatchLabels: map[string]string{"preferred": "true"}}},
+			Scheduling: apis.Packed,
+		},
+	}
+
+	find := func(s *gameServerSelector) string {
+		gs, index, err := s.find(gsa, list)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		assert.Equal(t, gs, list.list[index])
+		return gs.ObjectMeta.Name
+	}
+
+	t.Run("best", func(t *testing.T) {
+		s := newGameServerSelector(SelectionConfig{Strategy: SelectionBest, TopN: 3})
+		for i := 0; i < 5; i++ {
+			assert.Equal(t, "gs2", find(s))
+		}
+	})
+
+	t.Run("round robin", func(t *testing.T) {
+		s := newGameServerSelector(SelectionConfig{Strategy: SelectionRoundRobin, TopN: 3})
+		var names []string
+		for i := 0; i < 4; i++ {
+			names = append(names, find(s))
+		}
+		// only the gameservers that match the preferred selector are selected from
+		assert.Equal(t, []string{"gs2", "gs4", "gs5", "gs2"}, names)
+	})
+
+	t.Run("random", func(t *testing.T) {
+		s := newGameServerSelector(SelectionConfig{Strategy: SelectionRandom, TopN: 3})
+		for i := 0; i < 20; i++ {
+			assert.Contains(t, []string{"gs2", "gs4", "gs5"}, find(s))
+		}
+	})
+
+	t.Run("fewer than top n", func(t *testing.T) {
+		s := newGameServerSelector(SelectionConfig{Strategy: SelectionRoundRobin, TopN: 10})
+		var names []string
+		for i := 0; i < 5; i++ {
+			names = append(names, find(s))
+		}
+		assert.Equal(t, []string{"gs2", "gs4", "gs5", "gs6", "gs2"}, names)
+	})
+}
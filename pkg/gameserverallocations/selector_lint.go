@@ -0,0 +1,76 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// lintSelector sets the reason of a GameServerAllocation that found no Ready GameServer to SelectorMatchesNothing,
+// if selector linting is enabled, and its required selector can never match a GameServer, which is most likely
+// a typo in the selector, rather than the Fleet running out of Ready GameServers.
+func (c *Allocator) lintSelector(gsa *allocationv1.GameServerAllocation) {
+	if !c.selectorLint || !c.selectorMatchesNothing(gsa) {
+		return
+	}
+	c.loggerForGameServerAllocation(gsa).WithField("selector", metav1.FormatLabelSelector(&gsa.Spec.Required)).
+		Warn("Required selector of allocation matches no GameServer or Fleet")
+	gsa.Status.Reason = apis.ReasonSelectorMatchesNothing
+}
+
+// selectorMatchesNothing returns true if the required selector of the GameServerAllocation matches none of the
+// GameServers in its namespace, in any state, and none of the GameServer templates of the Fleets in its namespace.
+// An empty selector matches everything, so it is never reported.
+func (c *Allocator) selectorMatchesNothing(gsa *allocationv1.GameServerAllocation) bool {
+	selector, err := metav1.LabelSelectorAsSelector(&gsa.Spec.Required)
+	if err != nil || selector.Empty() {
+		return false
+	}
+
+	list, err := c.readyGameServerCache.gameServerLister.GameServers(gsa.ObjectMeta.Namespace).List(selector)
+	if err != nil || len(list) > 0 {
+		return false
+	}
+
+	fleets, err := c.fleetLister.Fleets(gsa.ObjectMeta.Namespace).List(labels.Everything())
+	if err != nil {
+		return false
+	}
+	for _, f := range fleets {
+		if selector.Matches(fleetTemplateLabels(f, &f.Spec.Template)) {
+			return false
+		}
+		for i := range f.Spec.Templates {
+			if selector.Matches(fleetTemplateLabels(f, &f.Spec.Templates[i].Template)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// fleetTemplateLabels returns the labels that the GameServers of a template of the Fleet are created with
+func fleetTemplateLabels(f *agonesv1.Fleet, template *agonesv1.GameServerTemplateSpec) labels.Set {
+	set := make(labels.Set, len(template.ObjectMeta.Labels)+1)
+	for k, v := range template.ObjectMeta.Labels {
+		set[k] = v
+	}
+	set[agonesv1.FleetNameLabel] = f.ObjectMeta.Name
+	return set
+}
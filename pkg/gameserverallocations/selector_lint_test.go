@@ -0,0 +1,112 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"testing"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestAllocatorLintSelector(t *testing.T) {
+	t.Parallel()
+
+	f, _, gsList := defaultFixtures(2)
+	for i := range gsList {
+		gsList[i].Status.State = agonesv1.GameServerStateAllocated
+	}
+	f.Spec.Templates = []agonesv1.FleetTemplate{{
+		Ratio:    50,
+		Template: agonesv1.GameServerTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"mode": "deathmatch"}}},
+	}}
+	// a Fleet that has not created any GameServers yet
+	empty := &agonesv1.Fleet{
+		ObjectMeta: metav1.ObjectMeta{Name: "empty", Namespace: defaultNs},
+		Spec: agonesv1.FleetSpec{
+			Template: agonesv1.GameServerTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"mode": "ctf"}}},
+		},
+	}
+
+	c, m := newFakeController()
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+	m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.FleetList{Items: []agonesv1.Fleet{*f, *empty}}, nil
+	})
+
+	_, cancel := agtesting.StartInformers(m, c.allocator.readyGameServerCache.gameServerSynced, c.allocator.fleetSynced)
+	defer cancel()
+
+	fixtures := map[string]struct {
+		selector metav1.LabelSelector
+		lint     bool
+		expected apis.Reason
+	}{
+		"allocated fleet": {
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}},
+			lint:     true,
+			expected: apis.ReasonNoCapacity,
+		},
+		"fleet without gameservers": {
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "empty"}},
+			lint:     true,
+			expected: apis.ReasonNoCapacity,
+		},
+		"label of additional template": {
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{"mode": "deathmatch"}},
+			lint:     true,
+			expected: apis.ReasonNoCapacity,
+		},
+		"empty selector": {
+			lint:     true,
+			expected: apis.ReasonNoCapacity,
+		},
+		"typo in fleet name": {
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "flet-1"}},
+			lint:     true,
+			expected: apis.ReasonSelectorMatchesNothing,
+		},
+		"unknown label key": {
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{"mdoe": "ctf"}},
+			lint:     true,
+			expected: apis.ReasonSelectorMatchesNothing,
+		},
+		"linting disabled": {
+			selector: metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: "flet-1"}},
+			expected: apis.ReasonNoCapacity,
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			gsa := &allocationv1.GameServerAllocation{
+				ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+				Spec:       allocationv1.GameServerAllocationSpec{Required: v.selector},
+				Status:     allocationv1.GameServerAllocationStatus{Reason: apis.ReasonNoCapacity},
+			}
+			c.allocator.selectorLint = v.lint
+			c.allocator.lintSelector(gsa)
+			assert.Equal(t, v.expected, gsa.Status.Reason)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"sync"
+
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/pkg/errors"
+	"go.opencensus.io/stats"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+)
+
+// sharedRequest is an allocation in the batch process that the allocations with the same request ID
+// and the same spec wait for, so that a client retrying an allocation gets the same GameServer
+type sharedRequest struct {
+	spec allocationv1.GameServerAllocationSpec
+	// ctx is the context of the allocation, which outlives the request that started it,
+	// for as long as another one waits for it
+	ctx context.Context
+	// cancel cancels the allocation once no allocation waits for it anymore
+	cancel context.CancelFunc
+	// waiters is how many allocations still wait for the result
+	waiters int
+	// done is closed once the result is set
+	done     chan struct{}
+	finished bool
+	// taken is true once an allocation was given the result
+	taken bool
+	res   response
+	err   error
+}
+
+// sharedRequests are the in-flight allocations that have a request ID, by their namespace and request ID
+type sharedRequests struct {
+	mutex    sync.Mutex
+	requests map[string]*sharedRequest
+}
+
+// newSharedRequests returns an empty sharedRequests
+func newSharedRequests() *sharedRequests {
+	return &sharedRequests{requests: map[string]*sharedRequest{}}
+}
+
+// join returns the in-flight allocation with the key, and false if there was none, in which case start is called,
+// if set, and unless it returns an error, the allocation is created and the caller must start it. It returns nil
+// if the in-flight allocation has a different spec, as it is then not a retry of the same allocation.
+func (s *sharedRequests) join(key string, spec allocationv1.GameServerAllocationSpec, start func() error) (*sharedRequest, bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if r, ok := s.requests[key]; ok {
+		if !apiequality.Semantic.DeepEqual(r.spec, spec) {
+			return nil, false, nil
+		}
+		r.waiters++
+		return r, true, nil
+	}
+
+	if start != nil {
+		if err := start(); err != nil {
+			return nil, false, err
+		}
+	}
+	r := &sharedRequest{spec: spec, waiters: 1, done: make(chan struct{})}
+	r.ctx, r.cancel = context.WithCancel(context.Background())
+	s.requests[key] = r
+	return r, false, nil
+}
+
+// finish sets the result of the allocation, and returns true if no allocation waits for it anymore,
+// in which case the GameServer it may have allocated must be returned
+func (s *sharedRequests) finish(key string, r *sharedRequest, res response, err error) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r.res, r.err, r.finished = res, err, true
+	r.cancel()
+	if s.requests[key] == r {
+		delete(s.requests, key)
+	}
+	close(r.done)
+	return r.waiters == 0
+}
+
+// take returns the result of a finished allocation to one of the allocations that waited for it
+func (s *sharedRequests) take(r *sharedRequest) (response, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r.waiters--
+	r.taken = true
+	return r.res, r.err
+}
+
+// leave removes an allocation that was cancelled or stopped from those that wait for the result. Once none waits
+// anymore, the allocation is cancelled, or if it already finished and nobody took its result, true is returned,
+// in which case the GameServer it allocated must be returned.
+func (s *sharedRequests) leave(key string, r *sharedRequest) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	r.waiters--
+	if r.waiters > 0 {
+		return false
+	}
+	if !r.finished {
+		r.cancel()
+		if s.requests[key] == r {
+			delete(s.requests, key)
+		}
+		return false
+	}
+	return !r.taken && r.err == nil
+}
+
+// requestSharedAllocation requests the allocation like requestAcknowledgedAllocation, unless an allocation with the
+// same request ID and the same spec is already in flight in the namespace, in which case it waits for its result
+// instead, so that clients that retry while the first request is still in flight do not allocate another GameServer
+// each time. The wait for the acknowledgement is part of the shared allocation, so a GameServer is only returned
+// when all of the allocations that share it were cancelled. start, if set, is called before a new allocation is
+// requested, but not when the in-flight allocation is joined, and an error it returns is returned.
+func (c *Allocator) requestSharedAllocation(ctx context.Context, gsa *allocationv1.GameServerAllocation, start func() error, stop <-chan struct{}) (response, error) {
+	if gsa.Spec.RequestID == "" {
+		return c.requestStartedAllocation(ctx, gsa, start, stop)
+	}
+
+	key := gsa.ObjectMeta.Namespace + "/" + gsa.Spec.RequestID
+	r, joined, err := c.sharedRequests.join(key, gsa.Spec, start)
+	if err != nil {
+		return response{}, err
+	}
+	if r == nil {
+		c.loggerForGameServerAllocation(gsa).WithField("requestID", gsa.Spec.RequestID).Warn("Allocation with the same request ID and a different spec is in flight, not sharing it")
+		return c.requestStartedAllocation(ctx, gsa, start, stop)
+	}
+
+	if joined {
+		stats.Record(context.Background(), sharedRequestsStats.M(1))
+	} else {
+		shared := gsa.DeepCopy()
+		go func() {
+			res, err := c.requestAcknowledgedAllocation(r.ctx, shared, stop)
+			if c.sharedRequests.finish(key, r, res, err) && err == nil {
+				c.returnGameServer(res)
+			}
+		}()
+	}
+
+	select {
+	case <-r.done:
+		return c.sharedRequests.take(r)
+	case <-ctx.Done():
+		if c.sharedRequests.leave(key, r) {
+			c.returnGameServer(r.res)
+		}
+		return response{}, ctx.Err()
+	case <-stop:
+		if c.sharedRequests.leave(key, r) {
+			c.returnGameServer(r.res)
+		}
+		return response{}, errors.New("shutting down")
+	}
+}
+
+// requestStartedAllocation calls start, if set, and unless it returns an error, requests the allocation like
+// requestAcknowledgedAllocation
+func (c *Allocator) requestStartedAllocation(ctx context.Context, gsa *allocationv1.GameServerAllocation, start func() error, stop <-chan struct{}) (response, error) {
+	if start != nil {
+		if err := start(); err != nil {
+			return response{}, err
+		}
+	}
+	return c.requestAcknowledgedAllocation(ctx, gsa, stop)
+}
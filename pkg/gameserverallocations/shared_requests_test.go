@@ -0,0 +1,332 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestSharedRequests(t *testing.T) {
+	t.Parallel()
+
+	spec := allocationv1.GameServerAllocationSpec{RequestID: "id", FleetName: "fleet"}
+	res := response{gs: &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "gs"}}}
+
+	t.Run("retry is given the same result", func(t *testing.T) {
+		s := newSharedRequests()
+		r, joined, _ := s.join("ns/id", spec, nil)
+		assert.False(t, joined)
+		retry, joined, _ := s.join("ns/id", *spec.DeepCopy(), nil)
+		assert.True(t, joined)
+		assert.Equal(t, r, retry)
+
+		assert.False(t, s.finish("ns/id", r, res, nil))
+		assert.Empty(t, s.requests)
+		for i := 0; i < 2; i++ {
+			got, err := s.take(r)
+			assert.NoError(t, err)
+			assert.Equal(t, res, got)
+		}
+	})
+
+	t.Run("different spec is not shared", func(t *testing.T) {
+		s := newSharedRequests()
+		_, joined, _ := s.join("ns/id", spec, nil)
+		assert.False(t, joined)
+		other := spec
+		other.FleetName = "other"
+		r, _, _ := s.join("ns/id", other, nil)
+		assert.Nil(t, r)
+	})
+
+	t.Run("all cancelled before the result", func(t *testing.T) {
+		s := newSharedRequests()
+		r, _, _ := s.join("ns/id", spec, nil)
+		s.join("ns/id", spec, nil) // nolint: errcheck
+		assert.False(t, s.leave("ns/id", r))
+		assert.NoError(t, r.ctx.Err())
+		assert.False(t, s.leave("ns/id", r))
+		assert.Equal(t, context.Canceled, r.ctx.Err())
+		assert.Empty(t, s.requests)
+
+		// nobody waits for the result, so its GameServer is returned
+		assert.True(t, s.finish("ns/id", r, res, nil))
+
+		// a new request with the same ID is not given the result of the cancelled one
+		_, joined, _ := s.join("ns/id", spec, nil)
+		assert.False(t, joined)
+	})
+
+	t.Run("only a new allocation is started", func(t *testing.T) {
+		s := newSharedRequests()
+		starts := 0
+		r, _, err := s.join("ns/id", spec, func() error {
+			starts++
+			return nil
+		})
+		assert.NoError(t, err)
+		limited := func() error { return errors.New("rate limited") }
+		_, joined, err := s.join("ns/id", spec, limited)
+		assert.NoError(t, err)
+		assert.True(t, joined)
+		assert.Equal(t, 1, starts)
+
+		assert.False(t, s.finish("ns/id", r, res, nil))
+		// an allocation that can't be started is not shared
+		r, joined, err = s.join("ns/id", spec, limited)
+		assert.EqualError(t, err, "rate limited")
+		assert.Nil(t, r)
+		assert.False(t, joined)
+		assert.Empty(t, s.requests)
+	})
+
+	t.Run("cancelled once the result is set", func(t *testing.T) {
+		s := newSharedRequests()
+		r, _, _ := s.join("ns/id", spec, nil)
+		assert.False(t, s.finish("ns/id", r, res, nil))
+		assert.True(t, s.leave("ns/id", r))
+
+		r, _, _ = s.join("ns/id", spec, nil)
+		s.join("ns/id", spec, nil) // nolint: errcheck
+		assert.False(t, s.finish("ns/id", r, res, nil))
+		_, err := s.take(r)
+		assert.NoError(t, err)
+		// the result was taken by the other request, so its GameServer is not returned
+		assert.False(t, s.leave("ns/id", r))
+	})
+}
+
+func TestControllerAllocateSharedRequest(t *testing.T) {
+	t.Parallel()
+
+	f, _, gsList := defaultFixtures(2)
+	c, m := newFakeController()
+	c.allocator.batchConfig.WaitTime = 10 * time.Millisecond
+
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+
+	release := make(chan struct{})
+	var updates int32
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		atomic.AddInt32(&updates, 1)
+		// the write is slow, so that the client retries while the allocation is in flight
+		<-release
+		return true, gs, nil
+	})
+
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	err := wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+		return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+	})
+	assert.NoError(t, err)
+
+	gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Name: "gsa-1", Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:  metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}},
+			RequestID: "request-1",
+		}}
+	gsa.ApplyDefaults()
+
+	results := make(chan k8sruntime.Object, 2)
+	allocate := func() {
+		result, err := c.allocator.Allocate(context.Background(), gsa.DeepCopy(), stop)
+		assert.NoError(t, err)
+		results <- result
+	}
+
+	go allocate()
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return atomic.LoadInt32(&updates) == 1, nil
+	})
+	assert.NoError(t, err)
+
+	// the retry waits for the allocation in flight
+	go allocate()
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		c.allocator.sharedRequests.mutex.Lock()
+		defer c.allocator.sharedRequests.mutex.Unlock()
+		r, ok := c.allocator.sharedRequests.requests[defaultNs+"/request-1"]
+		return ok && r.waiters == 2, nil
+	})
+	assert.NoError(t, err)
+	close(release)
+
+	var names []string
+	for i := 0; i < 2; i++ {
+		select {
+		case result := <-results:
+			if out, ok := result.(*allocationv1.GameServerAllocation); assert.True(t, ok) {
+				assert.Equal(t, allocationv1.GameServerAllocationAllocated, out.Status.State)
+				names = append(names, out.Status.GameServerName)
+			}
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "allocation did not complete")
+		}
+	}
+	if assert.Len(t, names, 2) {
+		assert.Equal(t, names[0], names[1])
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&updates))
+	_, ok := c.allocator.readyGameServerCache.readyGameServers.Load(defaultNs + "/" + otherName(gsList, names[0]))
+	assert.True(t, ok)
+}
+
+func TestControllerAllocateSharedRequestAcknowledgement(t *testing.T) {
+	t.Parallel()
+
+	f, _, gsList := defaultFixtures(1)
+	c, m := newFakeController()
+	c.allocator.batchConfig.WaitTime = 10 * time.Millisecond
+
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: gsList}, nil
+	})
+
+	updated := make(chan *agonesv1.GameServer, 2)
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		updated <- gs
+		return true, gs, nil
+	})
+
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+
+	if err := c.Run(1, stop); err != nil {
+		assert.FailNow(t, err.Error())
+	}
+	err := wait.PollImmediate(time.Second, 10*time.Second, func() (done bool, err error) {
+		return c.allocator.readyGameServerCache.workerqueue.RunCount() == 1, nil
+	})
+	assert.NoError(t, err)
+
+	gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Name: "gsa-1", Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{
+			Required:                  metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}},
+			RequestID:                 "request-1",
+			AcknowledgeTimeoutSeconds: 60,
+		}}
+	gsa.ApplyDefaults()
+
+	errs := make(chan error, 2)
+	allocate := func(ctx context.Context) {
+		_, err := c.allocator.Allocate(ctx, gsa.DeepCopy(), stop)
+		errs <- err
+	}
+	received := func() error {
+		select {
+		case err := <-errs:
+			return err
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "allocation did not complete")
+			return nil
+		}
+	}
+
+	first, cancelFirst := context.WithCancel(context.Background())
+	defer cancelFirst()
+	go allocate(first)
+	select {
+	case gs := <-updated:
+		assert.Equal(t, agonesv1.GameServerStateAllocated, gs.Status.State)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "GameServer was not allocated")
+	}
+
+	// the retry waits for the acknowledgement of the allocation in flight
+	second, cancelSecond := context.WithCancel(context.Background())
+	defer cancelSecond()
+	go allocate(second)
+	err = wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		c.allocator.sharedRequests.mutex.Lock()
+		defer c.allocator.sharedRequests.mutex.Unlock()
+		r, ok := c.allocator.sharedRequests.requests[defaultNs+"/request-1"]
+		return ok && r.waiters == 2, nil
+	})
+	assert.NoError(t, err)
+
+	// the GameServer is still given to the retry, so it is not returned when the first request is cancelled
+	cancelFirst()
+	assert.Error(t, received())
+	select {
+	case gs := <-updated:
+		assert.FailNow(t, "GameServer was returned while another allocation waits for it", gs.Status.State)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// once nobody waits for it anymore, it is returned
+	cancelSecond()
+	assert.Error(t, received())
+	select {
+	case gs := <-updated:
+		assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "GameServer was not returned")
+	}
+}
+
+func TestAllocatorRequestSharedAllocationStopped(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newFakeController()
+	gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Name: "gsa-1", Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{RequestID: "request-1"}}
+	gsa.ApplyDefaults()
+
+	// the allocation in flight
+	r, joined, _ := c.allocator.sharedRequests.join(defaultNs+"/request-1", gsa.Spec, nil)
+	assert.False(t, joined)
+
+	stop := make(chan struct{})
+	close(stop)
+	_, err := c.allocator.requestSharedAllocation(context.Background(), gsa, nil, stop)
+	assert.EqualError(t, err, "shutting down")
+
+	// the stopped allocation no longer waits for the result
+	c.allocator.sharedRequests.mutex.Lock()
+	defer c.allocator.sharedRequests.mutex.Unlock()
+	assert.Equal(t, 1, r.waiters)
+}
+
+// otherName returns the name of the GameServer of the list that does not have the name
+func otherName(list []agonesv1.GameServer, name string) string {
+	for _, gs := range list {
+		if gs.ObjectMeta.Name != name {
+			return gs.ObjectMeta.Name
+		}
+	}
+	return ""
+}
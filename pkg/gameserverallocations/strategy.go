@@ -0,0 +1,212 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Strategy orders the Ready GameServers that may be allocated for a GameServerAllocation,
+// for the scheduling strategy it is registered for.
+type Strategy interface {
+	// Order returns the positions of the GameServers in list, in the order they should be tried for allocation.
+	// list is sorted in Packed priority order, and may contain nil entries for GameServers that have already been allocated.
+	// positions are in ascending order, and must not be modified.
+	// An allocation usually only needs the first few positions, so the order should be worked out lazily,
+	// as the Iterator is advanced.
+	Order(list []*agonesv1.GameServer, positions []int) Iterator
+}
+
+// Iterator returns the next position of an order, or false once there are no more positions
+type Iterator func() (int, bool)
+
+// StrategyFunc is an adapter to use an ordinary function as a Strategy
+type StrategyFunc func(list []*agonesv1.GameServer, positions []int) Iterator
+
+// Order calls f(list, positions)
+func (f StrategyFunc) Order(list []*agonesv1.GameServer, positions []int) Iterator {
+	return f(list, positions)
+}
+
+// sliceIterator returns an Iterator over the positions of order
+func sliceIterator(order []int) Iterator {
+	i := 0
+	return func() (int, bool) {
+		if i >= len(order) {
+			return 0, false
+		}
+		i++
+		return order[i-1], true
+	}
+}
+
+var (
+	// strategiesMutex guards strategies, as custom Strategies can be registered while allocations run
+	strategiesMutex sync.RWMutex
+	// strategies are the Strategies that can be selected through the spec.scheduling of a GameServerAllocation
+	strategies = map[apis.SchedulingStrategy]Strategy{
+		apis.Packed:              StrategyFunc(packedOrder),
+		apis.Distributed:         StrategyFunc(distributedOrder),
+		allocationv1.LeastUptime: StrategyFunc(uptimeOrder(false)),
+		allocationv1.MostUptime:  StrategyFunc(uptimeOrder(true)),
+	}
+)
+
+// RegisterStrategy registers a custom Strategy, so it can be selected through the spec.scheduling of
+// a GameServerAllocation, or as the default scheduling strategy of the controller.
+// It must be called before the controller is created, and replaces any Strategy registered under the same name.
+func RegisterStrategy(name apis.SchedulingStrategy, strategy Strategy) {
+	strategiesMutex.Lock()
+	defer strategiesMutex.Unlock()
+	strategies[name] = strategy
+}
+
+// strategyFor returns the Strategy registered for the scheduling strategy
+func strategyFor(name apis.SchedulingStrategy) (Strategy, bool) {
+	strategiesMutex.RLock()
+	defer strategiesMutex.RUnlock()
+	strategy, ok := strategies[name]
+	return strategy, ok
+}
+
+// ValidateStrategy returns an error if there is no Strategy registered for the scheduling strategy
+func ValidateStrategy(name apis.SchedulingStrategy) error {
+	if _, ok := strategyFor(name); !ok {
+		return fmt.Errorf("scheduling strategy of '%s' is not supported", name)
+	}
+	return nil
+}
+
+// validateScheduling returns a cause if the scheduling strategy of the GameServerAllocation is not supported
+func validateScheduling(gsa *allocationv1.GameServerAllocation) []metav1.StatusCause {
+	if err := ValidateStrategy(gsa.Spec.Scheduling); err != nil {
+		return []metav1.StatusCause{{Type: metav1.CauseTypeFieldValueInvalid,
+			Field:   "spec.scheduling",
+			Message: err.Error()}}
+	}
+	return nil
+}
+
+// packedOrder tries the GameServers from start to finish, which is on the most Allocated nodes first
+func packedOrder(_ []*agonesv1.GameServer, positions []int) Iterator {
+	return sliceIterator(positions)
+}
+
+// distributedOrder spreads allocations across nodes, by trying one GameServer of each node in turn,
+// starting with the least Allocated nodes. GameServers on the same node are tried in Packed order.
+func distributedOrder(list []*agonesv1.GameServer, positions []int) Iterator {
+	// list is in Packed order, so walking it backwards finds the least Allocated nodes first
+	var nodes [][]int
+	index := map[string]int{}
+	for k := len(positions) - 1; k >= 0; k-- {
+		i := positions[k]
+		if list[i] == nil {
+			continue
+		}
+		n, ok := index[list[i].Status.NodeName]
+		if !ok {
+			n = len(nodes)
+			index[list[i].Status.NodeName] = n
+			nodes = append(nodes, nil)
+		}
+		nodes[n] = append(nodes[n], i)
+	}
+	for _, node := range nodes {
+		// back to Packed order within the node
+		for a, b := 0, len(node)-1; a < b; a, b = a+1, b-1 {
+			node[a], node[b] = node[b], node[a]
+		}
+	}
+
+	n := 0
+	return func() (int, bool) {
+		for len(nodes) > 0 {
+			if n >= len(nodes) {
+				n = 0
+			}
+			node := nodes[n]
+			if len(node) == 0 {
+				// drop nodes that have run out of GameServers, keeping the order of the others
+				nodes = append(nodes[:n], nodes[n+1:]...)
+				continue
+			}
+			nodes[n] = node[1:]
+			n++
+			return node[0], true
+		}
+		return 0, false
+	}
+}
+
+// uptimeOrder tries the GameServers that were created first, if oldest is true, or last otherwise.
+// GameServers created at the same time are tried in Packed order.
+// The positions are kept in a heap, so only the positions that are tried are sorted.
+func uptimeOrder(oldest bool) func(list []*agonesv1.GameServer, positions []int) Iterator {
+	return func(list []*agonesv1.GameServer, positions []int) Iterator {
+		h := &uptimeHeap{list: list, oldest: oldest, order: make([]int, 0, len(positions))}
+		for _, i := range positions {
+			if list[i] != nil {
+				h.order = append(h.order, i)
+			}
+		}
+		heap.Init(h)
+		return func() (int, bool) {
+			if h.Len() == 0 {
+				return 0, false
+			}
+			return heap.Pop(h).(int), true
+		}
+	}
+}
+
+// uptimeHeap is a heap of positions in list, by the creation time of their GameServers,
+// and then by position, which is the Packed order
+type uptimeHeap struct {
+	list   []*agonesv1.GameServer
+	oldest bool
+	order  []int
+}
+
+func (h *uptimeHeap) Len() int { return len(h.order) }
+
+func (h *uptimeHeap) Less(i, j int) bool {
+	t1 := h.list[h.order[i]].ObjectMeta.CreationTimestamp
+	t2 := h.list[h.order[j]].ObjectMeta.CreationTimestamp
+	if t1.Equal(&t2) {
+		return h.order[i] < h.order[j]
+	}
+	if h.oldest {
+		return t1.Before(&t2)
+	}
+	return t2.Before(&t1)
+}
+
+func (h *uptimeHeap) Swap(i, j int) { h.order[i], h.order[j] = h.order[j], h.order[i] }
+
+func (h *uptimeHeap) Push(x interface{}) { h.order = append(h.order, x.(int)) }
+
+func (h *uptimeHeap) Pop() interface{} {
+	n := len(h.order)
+	x := h.order[n-1]
+	h.order = h.order[:n-1]
+	return x
+}
@@ -0,0 +1,161 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserverallocations
+
+import (
+	"testing"
+	"time"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	allocationv1 "agones.dev/agones/pkg/apis/allocation/v1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStrategies(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	newGs := func(name string, age time.Duration) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs,
+			CreationTimestamp: metav1.NewTime(now.Add(-age))}}
+	}
+	list := []*agonesv1.GameServer{newGs("gs0", time.Minute), nil, newGs("gs2", time.Hour), newGs("gs3", time.Second), newGs("gs4", time.Hour)}
+	positions := []int{0, 1, 2, 3, 4}
+
+	order := func(name apis.SchedulingStrategy) []int {
+		strategy, ok := strategyFor(name)
+		assert.True(t, ok)
+		var result []int
+		next := strategy.Order(list, positions)
+		for i, more := next(); more; i, more = next() {
+			result = append(result, i)
+		}
+		return result
+	}
+
+	assert.Equal(t, positions, order(apis.Packed))
+	assert.Equal(t, []int{0, 2, 3, 4}, order(apis.Distributed), "all on one node, so Packed order")
+	assert.Equal(t, []int{3, 0, 2, 4}, order(allocationv1.LeastUptime))
+	assert.Equal(t, []int{2, 4, 0, 3}, order(allocationv1.MostUptime))
+	assert.Equal(t, []int{0, 1, 2, 3, 4}, positions, "positions should not be modified")
+}
+
+func TestDistributedOrder(t *testing.T) {
+	t.Parallel()
+
+	newGs := func(name, node string) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs},
+			Status: agonesv1.GameServerStatus{NodeName: node}}
+	}
+	// in Packed order, so node1 is the most Allocated node, and node3 the least
+	list := []*agonesv1.GameServer{newGs("gs0", "node1"), newGs("gs1", "node1"), nil, newGs("gs3", "node1"),
+		newGs("gs4", "node2"), newGs("gs5", "node2"), newGs("gs6", "node3")}
+
+	var result []int
+	next := distributedOrder(list, []int{0, 1, 2, 3, 4, 5, 6})
+	for i, more := next(); more; i, more = next() {
+		result = append(result, i)
+	}
+	assert.Equal(t, []int{6, 4, 0, 5, 1, 3}, result)
+
+	// only the given positions are tried
+	result = nil
+	next = distributedOrder(list, []int{0, 1, 5})
+	for i, more := next(); more; i, more = next() {
+		result = append(result, i)
+	}
+	assert.Equal(t, []int{5, 0, 1}, result)
+}
+
+func TestRegisterStrategy(t *testing.T) {
+	t.Parallel()
+
+	name := apis.SchedulingStrategy("TestReversed")
+	reversed := StrategyFunc(func(_ []*agonesv1.GameServer, positions []int) Iterator {
+		i := len(positions)
+		return func() (int, bool) {
+			if i == 0 {
+				return 0, false
+			}
+			i--
+			return positions[i], true
+		}
+	})
+
+	// registering while strategies are looked up should not race
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			_ = ValidateStrategy(apis.Packed)
+		}
+	}()
+	RegisterStrategy(name, reversed)
+	<-done
+
+	assert.NoError(t, ValidateStrategy(name))
+	strategy, ok := strategyFor(name)
+	if assert.True(t, ok) {
+		next := strategy.Order(nil, []int{1, 2})
+		i, more := next()
+		assert.True(t, more)
+		assert.Equal(t, 2, i)
+	}
+}
+
+func TestFindGameServerForAllocationMostUptime(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	newGs := func(name string, age time.Duration) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: defaultNs,
+			CreationTimestamp: metav1.NewTime(now.Add(-age))}}
+	}
+	list := newSortedGameServers([]*agonesv1.GameServer{newGs("gs0", time.Minute), newGs("gs1", time.Hour), newGs("gs2", time.Second)}, nil)
+
+	gsa := &allocationv1.GameServerAllocation{ObjectMeta: metav1.ObjectMeta{Namespace: defaultNs},
+		Spec: allocationv1.GameServerAllocationSpec{Scheduling: allocationv1.MostUptime}}
+
+	gs, index, err := findGameServerForAllocation(gsa, list)
+	assert.NoError(t, err)
+	assert.Equal(t, "gs1", gs.ObjectMeta.Name)
+	list.remove(index)
+
+	gs, _, err = findGameServerForAllocation(gsa, list)
+	assert.NoError(t, err)
+	assert.Equal(t, "gs0", gs.ObjectMeta.Name)
+
+	gsa.Spec.Scheduling = "FLERG"
+	_, _, err = findGameServerForAllocation(gsa, list)
+	assert.Error(t, err)
+}
+
+func TestValidateScheduling(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []apis.SchedulingStrategy{apis.Packed, apis.Distributed, allocationv1.LeastUptime, allocationv1.MostUptime} {
+		assert.NoError(t, ValidateStrategy(s))
+		assert.Empty(t, validateScheduling(&allocationv1.GameServerAllocation{Spec: allocationv1.GameServerAllocationSpec{Scheduling: s}}))
+	}
+
+	assert.Error(t, ValidateStrategy("FLERG"))
+	causes := validateScheduling(&allocationv1.GameServerAllocation{Spec: allocationv1.GameServerAllocationSpec{Scheduling: "FLERG"}})
+	if assert.Len(t, causes, 1) {
+		assert.Equal(t, metav1.CauseTypeFieldValueInvalid, causes[0].Type)
+		assert.Equal(t, "spec.scheduling", causes[0].Field)
+	}
+}
@@ -21,12 +21,14 @@ import (
 	"sync"
 	"time"
 
+	"agones.dev/agones/pkg/apis"
 	"agones.dev/agones/pkg/apis/agones"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"agones.dev/agones/pkg/client/clientset/versioned"
 	getterv1 "agones.dev/agones/pkg/client/clientset/versioned/typed/agones/v1"
 	"agones.dev/agones/pkg/client/informers/externalversions"
 	listerv1 "agones.dev/agones/pkg/client/listers/agones/v1"
+	"agones.dev/agones/pkg/cloudproduct"
 	"agones.dev/agones/pkg/util/crd"
 	"agones.dev/agones/pkg/util/logfields"
 	"agones.dev/agones/pkg/util/runtime"
@@ -36,6 +38,7 @@ import (
 	"github.com/mattbaird/jsonpatch"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 	admv1beta1 "k8s.io/api/admission/v1beta1"
 	corev1 "k8s.io/api/core/v1"
 	extclientset "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -44,34 +47,70 @@ import (
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	typedpolicyv1beta1 "k8s.io/client-go/kubernetes/typed/policy/v1beta1"
 	corelisterv1 "k8s.io/client-go/listers/core/v1"
+	policylisterv1beta1 "k8s.io/client-go/listers/policy/v1beta1"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 )
 
+// podForceDeleteTimeout is how long after a GameServer's Pod was due to be deleted it is force deleted,
+// if it is stuck terminating on a node that is gone or not Ready
+const podForceDeleteTimeout = 5 * time.Minute
+
+// reservedExpiryGracePeriod is how long after the ReservedUntil of a Reserved GameServer it is moved back to Ready,
+// if its SDK server, which normally does so right when the reservation expires, did not
+const reservedExpiryGracePeriod = 5 * time.Second
+
+const (
+	// sidecarContainerName is the name of the SDK server container of the Pods of GameServers
+	sidecarContainerName = "agones-gameserver-sidecar"
+	// sidecarCommand is the SDK server binary in the sidecar image
+	sidecarCommand = "/home/agones/sdk-server"
+	// defaultContainerAnnotation tells kubectl which container of a Pod to use when none is given
+	defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+)
+
 // Controller is a the main GameServer crd controller
 type Controller struct {
 	baseLogger             *logrus.Entry
 	sidecarImage           string
 	alwaysPullSidecarImage bool
+	sidecarFirst           bool
+	sidecarUpgradeInPlace  bool
+	sidecarUpgradeLimiter  *rate.Limiter
+	sidecarLogTail         bool
 	sidecarCPURequest      resource.Quantity
 	sidecarCPULimit        resource.Quantity
 	sdkServiceAccount      string
+	imagePullSecrets       ImagePullSecrets
+	safeToEvict            string
+	disruption             DisruptionProtection
+	preemptions            *NodePreemptions
+	maintenance            *NodeMaintenance
+	cloudProduct           cloudproduct.CloudProduct
 	crdGetter              v1beta1.CustomResourceDefinitionInterface
 	podGetter              typedcorev1.PodsGetter
 	podLister              corelisterv1.PodLister
 	podSynced              cache.InformerSynced
+	pdbGetter              typedpolicyv1beta1.PodDisruptionBudgetsGetter
+	pdbLister              policylisterv1beta1.PodDisruptionBudgetLister
+	pdbSynced              cache.InformerSynced
 	gameServerGetter       getterv1.GameServersGetter
 	gameServerLister       listerv1.GameServerLister
 	gameServerSynced       cache.InformerSynced
 	nodeLister             corelisterv1.NodeLister
 	nodeSynced             cache.InformerSynced
+	serviceGetter          typedcorev1.ServicesGetter
+	serviceLister          corelisterv1.ServiceLister
+	serviceSynced          cache.InformerSynced
 	portAllocator          *PortAllocator
 	healthController       *HealthController
 	workerqueue            *workerqueue.WorkerQueue
@@ -79,6 +118,11 @@ type Controller struct {
 	deletionWorkerQueue    *workerqueue.WorkerQueue // handles deletion only
 	stop                   <-chan struct{}
 	recorder               record.EventRecorder
+	syncLog                runtime.SampledLogger
+	patchLog               runtime.SampledLogger
+	// clock is the source of time of session expiries, preemption deadlines, disruption protection and
+	// force deletions
+	clock clock.Clock
 }
 
 // NewController returns a new gameserver crd controller
@@ -86,11 +130,22 @@ func NewController(
 	wh *webhooks.WebHook,
 	health healthcheck.Handler,
 	minPort, maxPort int32,
+	namespacePortRanges map[string]PortRange,
+	imagePullSecrets ImagePullSecrets,
+	safeToEvict string,
+	disruption DisruptionProtection,
+	nodeNotReady NodeNotReadyRemediation,
 	sidecarImage string,
 	alwaysPullSidecarImage bool,
+	sidecarFirst bool,
+	sidecarUpgradeInPlace bool,
+	sidecarLogTail bool,
 	sidecarCPURequest resource.Quantity,
 	sidecarCPULimit resource.Quantity,
 	sdkServiceAccount string,
+	preemptions *NodePreemptions,
+	maintenance *NodeMaintenance,
+	cloudProduct cloudproduct.CloudProduct,
 	kubeClient kubernetes.Interface,
 	kubeInformerFactory informers.SharedInformerFactory,
 	extClient extclientset.Interface,
@@ -98,6 +153,7 @@ func NewController(
 	agonesInformerFactory externalversions.SharedInformerFactory) *Controller {
 
 	pods := kubeInformerFactory.Core().V1().Pods()
+	services := kubeInformerFactory.Core().V1().Services()
 	gameServers := agonesInformerFactory.Agones().V1().GameServers()
 	gsInformer := gameServers.Informer()
 
@@ -106,18 +162,40 @@ func NewController(
 		sidecarCPULimit:        sidecarCPULimit,
 		sidecarCPURequest:      sidecarCPURequest,
 		alwaysPullSidecarImage: alwaysPullSidecarImage,
+		sidecarFirst:           sidecarFirst,
+		sidecarUpgradeInPlace:  sidecarUpgradeInPlace,
+		sidecarUpgradeLimiter:  rate.NewLimiter(sidecarUpgradesPerSecond, 1),
+		sidecarLogTail:         sidecarLogTail,
 		sdkServiceAccount:      sdkServiceAccount,
+		imagePullSecrets:       imagePullSecrets,
+		safeToEvict:            safeToEvict,
+		disruption:             disruption,
+		preemptions:            preemptions,
+		maintenance:            maintenance,
+		cloudProduct:           cloudProduct,
 		crdGetter:              extClient.ApiextensionsV1beta1().CustomResourceDefinitions(),
 		podGetter:              kubeClient.CoreV1(),
 		podLister:              pods.Lister(),
 		podSynced:              pods.Informer().HasSynced,
+		pdbGetter:              kubeClient.PolicyV1beta1(),
 		gameServerGetter:       agonesClient.AgonesV1(),
 		gameServerLister:       gameServers.Lister(),
 		gameServerSynced:       gsInformer.HasSynced,
 		nodeLister:             kubeInformerFactory.Core().V1().Nodes().Lister(),
 		nodeSynced:             kubeInformerFactory.Core().V1().Nodes().Informer().HasSynced,
-		portAllocator:          NewPortAllocator(minPort, maxPort, kubeInformerFactory, agonesInformerFactory),
-		healthController:       NewHealthController(health, kubeClient, agonesClient, kubeInformerFactory, agonesInformerFactory),
+		serviceGetter:          kubeClient.CoreV1(),
+		serviceLister:          services.Lister(),
+		serviceSynced:          services.Informer().HasSynced,
+		portAllocator:          NewPortAllocator(minPort, maxPort, namespacePortRanges, kubeInformerFactory, agonesInformerFactory),
+		healthController:       NewHealthController(health, nodeNotReady, kubeClient, agonesClient, kubeInformerFactory, agonesInformerFactory),
+		clock:                  clock.RealClock{},
+	}
+
+	// the PodDisruptionBudgets are only watched when they are managed
+	if disruption.Enabled {
+		pdbs := kubeInformerFactory.Policy().V1beta1().PodDisruptionBudgets()
+		c.pdbLister = pdbs.Lister()
+		c.pdbSynced = pdbs.Informer().HasSynced
 	}
 
 	c.baseLogger = runtime.NewLoggerWithType(c)
@@ -149,14 +227,30 @@ func NewController(
 		},
 	})
 
+	// tell the Allocated GameServers on a node that it is about to be preempted,
+	// and the GameServers on a node that it is under maintenance
+	kubeInformerFactory.Core().V1().Nodes().Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldNode := oldObj.(*corev1.Node)
+			newNode := newObj.(*corev1.Node)
+			if preemptions.preemptionTaint(oldNode) == nil && preemptions.preemptionTaint(newNode) != nil {
+				c.enqueueAllocatedGameServersOnNode(newNode.ObjectMeta.Name)
+			}
+			if oldReason, newReason := maintenance.reason(oldNode), maintenance.reason(newNode); oldReason != newReason {
+				c.recordNodeMaintenance(newNode.ObjectMeta.Name, newReason)
+			}
+		},
+	})
+
 	// track pod deletions, for when GameServers are deleted
 	pods.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(oldObj, newObj interface{}) {
 			oldPod := oldObj.(*corev1.Pod)
 			if isGameServerPod(oldPod) {
 				newPod := newObj.(*corev1.Pod)
-				//  node name has changed -- i.e. it has been scheduled
-				if oldPod.Spec.NodeName != newPod.Spec.NodeName {
+				//  node name has changed -- i.e. it has been scheduled,
+				// or the pod has become ready, for GameServers that are ReadyOnPodReady
+				if oldPod.Spec.NodeName != newPod.Spec.NodeName || isPodReady(oldPod) != isPodReady(newPod) {
 					owner := metav1.GetControllerOf(newPod)
 					c.workerqueue.Enqueue(cache.ExplicitKey(newPod.ObjectMeta.Namespace + "/" + owner.Name))
 				}
@@ -172,6 +266,13 @@ func NewController(
 		},
 	})
 
+	// the address of a GameServer behind a LoadBalancer Service is known once the Service has an ingress
+	services.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(_, newObj interface{}) {
+			c.serviceEventHandler(newObj)
+		},
+	})
+
 	return c
 }
 
@@ -191,6 +292,40 @@ func (c *Controller) enqueueGameServerBasedOnState(item interface{}) {
 	}
 }
 
+// enqueueAllocatedGameServersOnNode enqueues the Allocated GameServers on the node
+func (c *Controller) enqueueAllocatedGameServersOnNode(nodeName string) {
+	list, err := c.gameServerLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(c.baseLogger.WithField("node", nodeName), errors.Wrap(err, "error listing GameServers"))
+		return
+	}
+	for _, gs := range list {
+		if gs.Status.NodeName == nodeName && gs.Status.State == agonesv1.GameServerStateAllocated {
+			c.workerqueue.Enqueue(gs)
+		}
+	}
+}
+
+// recordNodeMaintenance records an event on each GameServer on the node, that the node is under maintenance
+// for the reason, or is no longer under maintenance if the reason is empty
+func (c *Controller) recordNodeMaintenance(nodeName, reason string) {
+	list, err := c.gameServerLister.List(labels.Everything())
+	if err != nil {
+		runtime.HandleError(c.baseLogger.WithField("node", nodeName), errors.Wrap(err, "error listing GameServers"))
+		return
+	}
+	for _, gs := range list {
+		if gs.Status.NodeName != nodeName || gs.IsBeingDeleted() {
+			continue
+		}
+		if reason == "" {
+			c.recorder.Eventf(gs, corev1.EventTypeNormal, "NodeMaintenanceEnded", "Node %s is no longer under maintenance", nodeName)
+			continue
+		}
+		c.recorder.Eventf(gs, corev1.EventTypeWarning, "NodeMaintenance", "Node %s %s, so its Ready GameServers are not allocated", nodeName, reason)
+	}
+}
+
 // fastRateLimiter returns a fast rate limiter, without exponential back-off.
 func fastRateLimiter() workqueue.RateLimiter {
 	const numFastRetries = 5
@@ -216,6 +351,7 @@ func (c *Controller) creationMutationHandler(review admv1beta1.AdmissionReview)
 	// This is the main logic of this function
 	// the rest is really just json plumbing
 	gs.ApplyDefaults()
+	c.applyImagePullSecrets(gs)
 
 	newGS, err := json.Marshal(gs)
 	if err != nil {
@@ -232,7 +368,7 @@ func (c *Controller) creationMutationHandler(review admv1beta1.AdmissionReview)
 		return review, errors.Wrapf(err, "error creating json for patch for GameServer %s", gs.ObjectMeta.Name)
 	}
 
-	c.loggerForGameServer(gs).WithField("patch", string(json)).Infof("patch created!")
+	c.patchLog.Debug(c.loggerForGameServer(gs).WithField("patch", string(json)), "patch created!")
 
 	pt := admv1beta1.PatchTypeJSONPatch
 	review.Response.PatchType = &pt
@@ -250,7 +386,7 @@ func (c *Controller) loggerForGameServer(gs *agonesv1.GameServer) *logrus.Entry
 	if gs != nil {
 		gsName = gs.Namespace + "/" + gs.Name
 	}
-	return c.loggerForGameServerKey(gsName).WithField("gs", gs)
+	return logfields.WithObject(c.loggerForGameServerKey(gsName), "gs", gs)
 }
 
 // creationValidationHandler that validates a GameServer when it is created
@@ -264,9 +400,26 @@ func (c *Controller) creationValidationHandler(review admv1beta1.AdmissionReview
 		return review, errors.Wrapf(err, "error unmarshalling original GameServer json: %s", obj.Raw)
 	}
 
-	c.loggerForGameServer(gs).WithField("review", review).Info("creationValidationHandler")
+	c.loggerForGameServer(gs).WithField("review", review).Debug("creationValidationHandler")
 
 	causes, ok := gs.Validate()
+	if _, isDev := gs.GetDevAddress(); !isDev {
+		productCauses := c.cloudProduct.ValidateGameServerSpec(&gs.Spec)
+		causes = append(causes, productCauses...)
+		ok = ok && len(productCauses) == 0
+
+		portCauses := c.validateStaticPorts(gs)
+		causes = append(causes, portCauses...)
+		ok = ok && len(portCauses) == 0
+	}
+	if gs.Spec.SdkServer.LogTail && !c.sidecarLogTail {
+		causes = append(causes, metav1.StatusCause{
+			Type:    metav1.CauseTypeFieldValueNotSupported,
+			Field:   "sdkServer.logTail",
+			Message: "serving the log tail from the SDK Server is not enabled in this Agones installation",
+		})
+		ok = false
+	}
 	if !ok {
 		review.Response.Allowed = false
 		details := metav1.StatusDetails{
@@ -289,6 +442,87 @@ func (c *Controller) creationValidationHandler(review admv1beta1.AdmissionReview
 	return review, nil
 }
 
+// validateStaticPorts rejects Static ports whose Pod could never be scheduled, or could take a port that is allocated
+// to another GameServer: host ports in the dynamic port range, host ports used by more than one port of the
+// GameServer, and, if the GameServer is pinned to a node, host ports used by the GameServers on that node.
+// Other conflicts still surface as Pods that cannot be scheduled.
+func (c *Controller) validateStaticPorts(gs *agonesv1.GameServer) []metav1.StatusCause {
+	var causes []metav1.StatusCause
+	seen := map[hostPort]bool{}
+	var static []agonesv1.GameServerPort
+	for _, p := range gs.Spec.Ports {
+		if p.PortPolicy != agonesv1.Static || p.HostPort == 0 {
+			continue
+		}
+		static = append(static, p)
+		hp := hostPort{port: p.HostPort, protocol: portProtocol(p)}
+		if c.portAllocator.allocatable(p.HostPort) {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   fmt.Sprintf("%s.hostPort", p.Name),
+				Message: fmt.Sprintf("Static hostPort %d is in the port range of Dynamic and Passthrough ports", p.HostPort),
+			})
+		} else if seen[hp] {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueDuplicate,
+				Field:   fmt.Sprintf("%s.hostPort", p.Name),
+				Message: fmt.Sprintf("Static hostPort %d is used by another port of the GameServer", p.HostPort),
+			})
+		}
+		seen[hp] = true
+	}
+
+	node := gs.Spec.Template.Spec.NodeName
+	if node == "" {
+		node = gs.Spec.Template.Spec.NodeSelector["kubernetes.io/hostname"]
+	}
+	if node == "" || len(static) == 0 || len(causes) > 0 {
+		return causes
+	}
+
+	// best effort, as GameServers that are scheduled at the same time are not on the node yet
+	list, err := c.gameServerLister.List(labels.Everything())
+	if err != nil {
+		c.loggerForGameServer(gs).WithError(err).Warn("error listing GameServers to validate Static ports against")
+		return causes
+	}
+	used := map[hostPort]string{}
+	for _, other := range list {
+		if other.Status.NodeName != node || other.IsBeingDeleted() {
+			continue
+		}
+		for _, p := range other.Spec.Ports {
+			if p.HostPort != 0 {
+				used[hostPort{port: p.HostPort, protocol: portProtocol(p)}] = other.ObjectMeta.Namespace + "/" + other.ObjectMeta.Name
+			}
+		}
+	}
+	for _, p := range static {
+		if other, ok := used[hostPort{port: p.HostPort, protocol: portProtocol(p)}]; ok {
+			causes = append(causes, metav1.StatusCause{
+				Type:    metav1.CauseTypeFieldValueInvalid,
+				Field:   fmt.Sprintf("%s.hostPort", p.Name),
+				Message: fmt.Sprintf("Static hostPort %d is used by GameServer %s on node %s", p.HostPort, other, node),
+			})
+		}
+	}
+	return causes
+}
+
+// WorkQueues returns the work queues of the controller, for the statusz page
+func (c *Controller) WorkQueues() []*workerqueue.WorkerQueue {
+	queues := []*workerqueue.WorkerQueue{c.workerqueue, c.creationWorkerQueue, c.deletionWorkerQueue, c.healthController.workerqueue}
+	if c.healthController.nodeWorkerqueue != nil {
+		queues = append(queues, c.healthController.nodeWorkerqueue)
+	}
+	return queues
+}
+
+// PortUtilization returns how many of the dynamic ports of the schedulable nodes are allocated
+func (c *Controller) PortUtilization() PortUtilization {
+	return c.portAllocator.Utilization()
+}
+
 // Run the GameServer controller. Will block until stop is closed.
 // Runs threadiness number workers to process the rate limited queue
 func (c *Controller) Run(workers int, stop <-chan struct{}) error {
@@ -300,7 +534,11 @@ func (c *Controller) Run(workers int, stop <-chan struct{}) error {
 	}
 
 	c.baseLogger.Info("Wait for cache sync")
-	if !cache.WaitForCacheSync(stop, c.gameServerSynced, c.podSynced, c.nodeSynced) {
+	synced := []cache.InformerSynced{c.gameServerSynced, c.podSynced, c.nodeSynced, c.serviceSynced}
+	if c.pdbSynced != nil {
+		synced = append(synced, c.pdbSynced)
+	}
+	if !cache.WaitForCacheSync(stop, synced...) {
 		return errors.New("failed to wait for caches to sync")
 	}
 
@@ -338,7 +576,7 @@ func (c *Controller) Run(workers int, stop <-chan struct{}) error {
 // syncGameServer synchronises the Pods for the GameServers.
 // and reacts to status changes that can occur through the client SDK
 func (c *Controller) syncGameServer(key string) error {
-	c.loggerForGameServerKey(key).Info("Synchronising")
+	c.syncLog.Debug(c.loggerForGameServerKey(key), "Synchronising")
 
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
@@ -369,12 +607,30 @@ func (c *Controller) syncGameServer(key string) error {
 	if gs, err = c.syncGameServerStartingState(gs); err != nil {
 		return err
 	}
+	if gs, err = c.syncGameServerScheduledState(gs); err != nil {
+		return err
+	}
 	if gs, err = c.syncGameServerRequestReadyState(gs); err != nil {
 		return err
 	}
 	if gs, err = c.syncDevelopmentGameServer(gs); err != nil {
 		return err
 	}
+	if gs, err = c.syncGameServerPreemption(gs); err != nil {
+		return err
+	}
+	if gs, err = c.syncGameServerSessionExpiry(gs); err != nil {
+		return err
+	}
+	if gs, err = c.syncGameServerReservedExpiry(gs); err != nil {
+		return err
+	}
+	if gs, err = c.syncGameServerSidecarUpgrade(gs); err != nil {
+		return err
+	}
+	if gs, err = c.syncGameServerDisruptionProtection(gs); err != nil {
+		return err
+	}
 	if err = c.syncGameServerShutdownState(gs); err != nil {
 		return err
 	}
@@ -407,6 +663,8 @@ func (c *Controller) syncGameServerDeletionTimestamp(gs *agonesv1.GameServer) (*
 				return gs, errors.Wrapf(err, "error deleting pod for GameServer %s, %s", gs.ObjectMeta.Name, pod.ObjectMeta.Name)
 			}
 			c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State), fmt.Sprintf("Deleting Pod %s", pod.ObjectMeta.Name))
+		} else if err := c.forceDeleteLostPod(gs, pod); err != nil {
+			return gs, err
 		}
 
 		// but no removing finalizers until it's truly gone
@@ -427,23 +685,78 @@ func (c *Controller) syncGameServerDeletionTimestamp(gs *agonesv1.GameServer) (*
 	return gs, errors.Wrapf(err, "error removing finalizer for GameServer %s", gsCopy.ObjectMeta.Name)
 }
 
+// forceDeleteLostPod force deletes a Pod that is stuck terminating because the node it was scheduled on is gone
+// or not Ready, as its kubelet can then never confirm the deletion. To give a node that is only briefly
+// unavailable time to come back, this waits until podForceDeleteTimeout after the Pod was due to be deleted.
+func (c *Controller) forceDeleteLostPod(gs *agonesv1.GameServer, pod *corev1.Pod) error {
+	if pod.Spec.NodeName == "" {
+		return nil
+	}
+	lost, err := c.isNodeLost(pod.Spec.NodeName)
+	if err != nil || !lost {
+		return err
+	}
+
+	if wait := pod.ObjectMeta.DeletionTimestamp.Add(podForceDeleteTimeout).Sub(c.clock.Now()); wait > 0 {
+		// check again once the timeout has passed
+		c.workerqueue.EnqueueAfter(gs, wait)
+		return nil
+	}
+
+	grace := int64(0)
+	err = c.podGetter.Pods(pod.ObjectMeta.Namespace).Delete(pod.ObjectMeta.Name, &metav1.DeleteOptions{GracePeriodSeconds: &grace})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error force deleting pod for GameServer %s, %s", gs.ObjectMeta.Name, pod.ObjectMeta.Name)
+	}
+	c.loggerForGameServer(gs).WithField("node", pod.Spec.NodeName).Warn("Force deleted pod stuck terminating on a lost node")
+	c.recorder.Event(gs, corev1.EventTypeWarning, string(gs.Status.State),
+		fmt.Sprintf("Force deleting Pod %s, as its node %s is gone or not ready", pod.ObjectMeta.Name, pod.Spec.NodeName))
+	return nil
+}
+
+// isNodeLost returns true if the node no longer exists, or its Ready condition is not true
+func (c *Controller) isNodeLost(name string) (bool, error) {
+	node, err := c.nodeLister.Get(name)
+	if k8serrors.IsNotFound(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "error retrieving node %s", name)
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status != corev1.ConditionTrue, nil
+		}
+	}
+	return true, nil
+}
+
 // syncGameServerPortAllocationState gives a port to a dynamically allocating GameServer
 func (c *Controller) syncGameServerPortAllocationState(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
 	if !(gs.Status.State == agonesv1.GameServerStatePortAllocation && gs.ObjectMeta.DeletionTimestamp.IsZero()) {
 		return gs, nil
 	}
 
-	gsCopy := c.portAllocator.Allocate(gs.DeepCopy())
-
+	// GameServers are reached on the container ports of their Pod when the cloud product does not let it use host ports,
+	// and through their Service when they have one
+	gsCopy := gs.DeepCopy()
+	if c.usesHostPorts(gs) {
+		gsCopy = c.portAllocator.Allocate(gsCopy)
+		c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State), "Port allocated")
+	}
 	gsCopy.Status.State = agonesv1.GameServerStateCreating
-	c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State), "Port allocated")
 
 	c.loggerForGameServer(gsCopy).Info("Syncing Port Allocation GameServerState")
 	gs, err := c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(gsCopy)
 	if err != nil {
 		// if the GameServer doesn't get updated with the port data, then put the port
 		// back in the pool, as it will get retried on the next pass
-		c.portAllocator.DeAllocate(gsCopy)
+		if c.usesHostPorts(gsCopy) {
+			c.portAllocator.DeAllocate(gsCopy)
+		}
 		return gs, errors.Wrapf(err, "error updating GameServer %s to default values", gs.Name)
 	}
 
@@ -462,6 +775,10 @@ func (c *Controller) syncGameServerCreatingState(gs *agonesv1.GameServer) (*agon
 
 	c.loggerForGameServer(gs).Info("Syncing Create State")
 
+	if err := c.syncGameServerService(gs); err != nil {
+		return gs, err
+	}
+
 	// Maybe something went wrong, and the pod was created, but the state was never moved to Starting, so let's check
 	_, err := c.gameServerPod(gs)
 	if k8serrors.IsNotFound(err) {
@@ -509,6 +826,7 @@ func (c *Controller) syncDevelopmentGameServer(gs *agonesv1.GameServer) (*agones
 	gsCopy.Status.State = agonesv1.GameServerStateReady
 	gsCopy.Status.Ports = ports
 	gsCopy.Status.Address = devIPAddress
+	gsCopy.Status.Addresses = []corev1.NodeAddress{{Type: corev1.NodeExternalIP, Address: devIPAddress}}
 	gsCopy.Status.NodeName = devIPAddress
 	gs, err := c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(gsCopy)
 	if err != nil {
@@ -519,28 +837,38 @@ func (c *Controller) syncDevelopmentGameServer(gs *agonesv1.GameServer) (*agones
 
 // createGameServerPod creates the backing Pod for a given GameServer
 func (c *Controller) createGameServerPod(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
-	sidecar := c.sidecar(gs)
+	var sidecars []corev1.Container
+	if !gs.Spec.SdkServer.Disabled {
+		sidecars = append(sidecars, c.sidecar(gs))
+	}
 	var pod *corev1.Pod
-	pod, err := gs.Pod(sidecar)
+	pod, err := gs.Pod(sidecars...)
 
 	// this shouldn't happen, but if it does.
 	if err != nil {
 		c.loggerForGameServer(gs).WithError(err).Error("error creating pod from Game Server")
-		gs, err = c.moveToErrorState(gs, err.Error())
+		gs, err = c.moveToErrorState(gs, apis.ReasonPodInvalid, err.Error())
 		return gs, err
 	}
 
+	if c.sidecarFirst && !gs.Spec.SdkServer.Disabled {
+		startSidecarFirst(gs, pod)
+	}
+	c.applySafeToEvict(gs, pod)
+
 	// if the service account is not set, then you are in the "opinionated"
 	// mode. If the user sets the service account, we assume they know what they are
 	// doing, and don't disable the gameserver container.
-	if pod.Spec.ServiceAccountName == "" {
+	// Without the SDK Server, the Pod does not need the SDK service account.
+	if pod.Spec.ServiceAccountName == "" && !gs.Spec.SdkServer.Disabled {
 		pod.Spec.ServiceAccountName = c.sdkServiceAccount
 		gs.DisableServiceAccount(pod)
 	}
 
 	c.addGameServerHealthCheck(gs, pod)
+	c.cloudProduct.MutateGameServerPod(pod)
 
-	c.loggerForGameServer(gs).WithField("pod", pod).Info("creating Pod for GameServer")
+	logfields.WithObject(c.loggerForGameServer(gs), "pod", pod).Info("creating Pod for GameServer")
 	pod, err = c.podGetter.Pods(gs.ObjectMeta.Namespace).Create(pod)
 	if k8serrors.IsAlreadyExists(err) {
 		c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State), "Pod already exists, reused")
@@ -549,9 +877,13 @@ func (c *Controller) createGameServerPod(gs *agonesv1.GameServer) (*agonesv1.Gam
 	if err != nil {
 		if k8serrors.IsInvalid(err) {
 			c.loggerForGameServer(gs).WithField("pod", pod).Errorf("Pod created is invalid")
-			gs, err = c.moveToErrorState(gs, err.Error())
+			gs, err = c.moveToErrorState(gs, apis.ReasonPodInvalid, err.Error())
 			return gs, err
 		}
+		err = apis.FromAPIError(err)
+		if reason := apis.ReasonForError(err); reason != "" {
+			c.recorder.Event(gs, corev1.EventTypeWarning, string(reason), err.Error())
+		}
 		return gs, errors.Wrapf(err, "error creating Pod for GameServer %s", gs.Name)
 	}
 	c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State),
@@ -563,7 +895,7 @@ func (c *Controller) createGameServerPod(gs *agonesv1.GameServer) (*agonesv1.Gam
 // sidecar creates the sidecar container for a given GameServer
 func (c *Controller) sidecar(gs *agonesv1.GameServer) corev1.Container {
 	sidecar := corev1.Container{
-		Name:  "agones-gameserver-sidecar",
+		Name:  sidecarContainerName,
 		Image: c.sidecarImage,
 		Env: []corev1.EnvVar{
 			{
@@ -600,6 +932,10 @@ func (c *Controller) sidecar(gs *agonesv1.GameServer) corev1.Container {
 		sidecar.Args = append(sidecar.Args, fmt.Sprintf("--http-port=%d", gs.Spec.SdkServer.HTTPPort))
 	}
 
+	if gs.Spec.SdkServer.LogTail {
+		sidecar.Args = append(sidecar.Args, fmt.Sprintf("--log-tail-container=%s", gs.Spec.Container))
+	}
+
 	if !c.sidecarCPURequest.IsZero() {
 		sidecar.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: c.sidecarCPURequest}
 	}
@@ -611,9 +947,47 @@ func (c *Controller) sidecar(gs *agonesv1.GameServer) corev1.Container {
 	if c.alwaysPullSidecarImage {
 		sidecar.ImagePullPolicy = corev1.PullAlways
 	}
+
+	if c.sidecarFirst {
+		// the kubelet does not start the next container of the Pod until the post start hook of this one returned,
+		// so the game server container only starts once the SDK server is serving
+		sidecar.Lifecycle = &corev1.Lifecycle{
+			PostStart: &corev1.Handler{
+				Exec: &corev1.ExecAction{Command: append([]string{sidecarCommand, "--wait-ready"}, sidecar.Args...)},
+			},
+		}
+	}
 	return sidecar
 }
 
+// startSidecarFirst moves the sidecar container to the front of the containers of the Pod, so that the kubelet
+// starts it first, and keeps the game server container as the default one for kubectl
+func startSidecarFirst(gs *agonesv1.GameServer, pod *corev1.Pod) {
+	containers := make([]corev1.Container, 0, len(pod.Spec.Containers))
+	for _, c := range pod.Spec.Containers {
+		if c.Name == sidecarContainerName {
+			containers = append([]corev1.Container{c}, containers...)
+		} else {
+			containers = append(containers, c)
+		}
+	}
+	pod.Spec.Containers = containers
+	pod.ObjectMeta.Annotations[defaultContainerAnnotation] = gs.Spec.Container
+}
+
+// applySafeToEvict sets the safe-to-evict annotation of the cluster autoscaler on the Pod of a GameServer that is
+// not Packed, to the configured value. The Pods of Packed GameServers are never safe to evict, so that the cluster
+// autoscaler does not remove their node from under live games, and the annotation of the template is kept as is.
+func (c *Controller) applySafeToEvict(gs *agonesv1.GameServer, pod *corev1.Pod) {
+	if c.safeToEvict == "" || gs.Spec.Scheduling == apis.Packed {
+		return
+	}
+	if _, ok := gs.Spec.Template.ObjectMeta.Annotations[agonesv1.SafeToEvictAnnotation]; ok {
+		return
+	}
+	pod.ObjectMeta.Annotations[agonesv1.SafeToEvictAnnotation] = c.safeToEvict
+}
+
 // addGameServerHealthCheck adds the http health check to the GameServer container
 func (c *Controller) addGameServerHealthCheck(gs *agonesv1.GameServer, pod *corev1.Pod) {
 	if gs.Spec.Health.Disabled {
@@ -678,23 +1052,60 @@ func (c *Controller) syncGameServerStartingState(gs *agonesv1.GameServer) (*agon
 // applyGameServerAddressAndPort gets the backing Pod for the GamesServer,
 // and sets the allocated Address and Port values to it and returns it.
 func (c *Controller) applyGameServerAddressAndPort(gs *agonesv1.GameServer, pod *corev1.Pod) (*agonesv1.GameServer, error) {
-	addr, err := c.address(gs, pod)
+	if gs.Spec.ServiceType != "" {
+		return c.applyGameServerServiceAddressAndPort(gs, pod)
+	}
+
+	addr, addresses, err := c.address(gs, pod)
 	if err != nil {
 		return gs, errors.Wrapf(err, "error getting external address for GameServer %s", gs.ObjectMeta.Name)
 	}
 
 	gs.Status.Address = addr
+	gs.Status.Addresses = addresses
 	gs.Status.NodeName = pod.Spec.NodeName
 	// HostPort is always going to be populated, even when dynamic
 	// This will be a double up of information, but it will be easier to read
 	gs.Status.Ports = make([]agonesv1.GameServerStatusPort, len(gs.Spec.Ports))
 	for i, p := range gs.Spec.Ports {
 		gs.Status.Ports[i] = p.Status()
+		if !c.cloudProduct.UsesHostPorts() {
+			gs.Status.Ports[i].Port = p.ContainerPort
+		}
 	}
 
 	return gs, nil
 }
 
+// syncGameServerScheduledState moves a Scheduled GameServer that is ReadyOnPodReady
+// to RequestReady once its Pod is Ready, in place of a call to SDK.Ready()
+func (c *Controller) syncGameServerScheduledState(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+	if !(gs.Status.State == agonesv1.GameServerStateScheduled && gs.ObjectMeta.DeletionTimestamp.IsZero() && gs.Spec.ReadyOnPodReady) {
+		return gs, nil
+	}
+
+	pod, err := c.gameServerPod(gs)
+	if err != nil {
+		return gs, err
+	}
+	// the pod update will enqueue the GameServer again once it is Ready
+	if !isPodReady(pod) {
+		return gs, nil
+	}
+
+	c.loggerForGameServer(gs).Info("Syncing Scheduled GameServerState with a Ready Pod")
+
+	gsCopy := gs.DeepCopy()
+	gsCopy.Status.State = agonesv1.GameServerStateRequestReady
+	gs, err = c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(gsCopy)
+	if err != nil {
+		return gs, errors.Wrapf(err, "error updating GameServer %s to RequestReady state", gsCopy.ObjectMeta.Name)
+	}
+	c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State), "Pod is Ready")
+
+	return gs, nil
+}
+
 // syncGameServerRequestReadyState checks if the Game Server is Requesting to be ready,
 // and then adds the IP and Port information to the Status and marks the GameServer
 // as Ready
@@ -730,6 +1141,8 @@ func (c *Controller) syncGameServerRequestReadyState(gs *agonesv1.GameServer) (*
 	}
 
 	gsCopy.Status.State = agonesv1.GameServerStateReady
+	gsCopy.Status.SetCondition(agonesv1.GameServerCondition{Type: agonesv1.GameServerConditionReady,
+		Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()})
 	gs, err := c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(gsCopy)
 	if err != nil {
 		return gs, errors.Wrapf(err, "error setting Ready, Port and address on GameServer %s Status", gs.ObjectMeta.Name)
@@ -742,6 +1155,87 @@ func (c *Controller) syncGameServerRequestReadyState(gs *agonesv1.GameServer) (*
 	return gs, nil
 }
 
+// syncGameServerPreemption sets the PreemptionDeadlineAnnotation on an Allocated GameServer whose node has been
+// given notice that it is about to be preempted, so that the game server is told through the SDK watch, and can
+// wrap up its game. The deadline is only set once, from the first notice that is seen.
+func (c *Controller) syncGameServerPreemption(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+	if gs.Status.State != agonesv1.GameServerStateAllocated || gs.IsBeingDeleted() {
+		return gs, nil
+	}
+	if _, ok := gs.ObjectMeta.Annotations[agonesv1.PreemptionDeadlineAnnotation]; ok {
+		return gs, nil
+	}
+	deadline, ok := c.preemptions.Deadline(gs.Status.NodeName, c.clock.Now())
+	if !ok {
+		return gs, nil
+	}
+
+	c.loggerForGameServer(gs).WithField("deadline", deadline).Info("Node is about to be preempted")
+	gsCopy := gs.DeepCopy()
+	if gsCopy.ObjectMeta.Annotations == nil {
+		gsCopy.ObjectMeta.Annotations = map[string]string{}
+	}
+	gsCopy.ObjectMeta.Annotations[agonesv1.PreemptionDeadlineAnnotation] = deadline.UTC().Format(time.RFC3339)
+	gs, err := c.gameServerGetter.GameServers(gsCopy.ObjectMeta.Namespace).Update(gsCopy)
+	if err != nil {
+		return gs, errors.Wrapf(err, "error setting the preemption deadline of GameServer %s", gsCopy.ObjectMeta.Name)
+	}
+	c.recorder.Event(gs, corev1.EventTypeWarning, string(gs.Status.State),
+		fmt.Sprintf("Node %s is preempted at %s", gs.Status.NodeName, deadline.UTC().Format(time.RFC3339)))
+	return gs, nil
+}
+
+// syncGameServerSessionExpiry moves an Allocated GameServer whose session reached the MaxDuration of its Session
+// to Shutdown, or back to Ready, depending on the ExpiryPolicy of its Session
+func (c *Controller) syncGameServerSessionExpiry(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+	if gs.Status.State != agonesv1.GameServerStateAllocated || gs.Status.AllocatedUntil == nil || gs.IsBeingDeleted() {
+		return gs, nil
+	}
+	if wait := gs.Status.AllocatedUntil.Time.Sub(c.clock.Now()); wait > 0 {
+		// check again once the session has expired
+		c.workerqueue.EnqueueAfter(gs, wait)
+		return gs, nil
+	}
+
+	c.loggerForGameServer(gs).Info("Session reached its max duration")
+	gsCopy := gs.DeepCopy()
+	gsCopy.Status.AllocatedUntil = nil
+	gsCopy.Status.State = agonesv1.GameServerStateShutdown
+	if gs.Spec.Session.ExpiryPolicy == agonesv1.SessionExpiryReady {
+		gsCopy.Status.State = agonesv1.GameServerStateReady
+	}
+	gs, err := c.gameServerGetter.GameServers(gsCopy.ObjectMeta.Namespace).Update(gsCopy)
+	if err != nil {
+		return gs, errors.Wrapf(err, "error updating GameServer %s to %s after its session expired", gsCopy.ObjectMeta.Name, gsCopy.Status.State)
+	}
+	c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State), "Session reached its max duration")
+	return gs, nil
+}
+
+// syncGameServerReservedExpiry moves a Reserved GameServer whose reservation expired back to Ready, when its SDK
+// server could not, e.g. because its container was restarting when the reservation expired
+func (c *Controller) syncGameServerReservedExpiry(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+	if gs.Status.State != agonesv1.GameServerStateReserved || gs.Status.ReservedUntil == nil || gs.IsBeingDeleted() {
+		return gs, nil
+	}
+	if wait := gs.Status.ReservedUntil.Time.Add(reservedExpiryGracePeriod).Sub(c.clock.Now()); wait > 0 {
+		// check again once the SDK server had the chance to move it back to Ready
+		c.workerqueue.EnqueueAfter(gs, wait)
+		return gs, nil
+	}
+
+	c.loggerForGameServer(gs).Info("Reservation expired")
+	gsCopy := gs.DeepCopy()
+	gsCopy.Status.ReservedUntil = nil
+	gsCopy.Status.State = agonesv1.GameServerStateReady
+	gs, err := c.gameServerGetter.GameServers(gsCopy.ObjectMeta.Namespace).Update(gsCopy)
+	if err != nil {
+		return gs, errors.Wrapf(err, "error updating GameServer %s to Ready after its reservation expired", gsCopy.ObjectMeta.Name)
+	}
+	c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State), "Reservation expired")
+	return gs, nil
+}
+
 // syncGameServerShutdownState deletes the GameServer (and therefore the backing Pod) if it is in shutdown state
 func (c *Controller) syncGameServerShutdownState(gs *agonesv1.GameServer) error {
 	if !(gs.Status.State == agonesv1.GameServerStateShutdown && gs.ObjectMeta.DeletionTimestamp.IsZero()) {
@@ -759,17 +1253,19 @@ func (c *Controller) syncGameServerShutdownState(gs *agonesv1.GameServer) error
 	return nil
 }
 
-// moveToErrorState moves the GameServer to the error state
-func (c *Controller) moveToErrorState(gs *agonesv1.GameServer, msg string) (*agonesv1.GameServer, error) {
+// moveToErrorState moves the GameServer to the error state, and records the reason
+// in its status and in the message of the Error event
+func (c *Controller) moveToErrorState(gs *agonesv1.GameServer, reason apis.Reason, msg string) (*agonesv1.GameServer, error) {
 	copy := gs.DeepCopy()
 	copy.Status.State = agonesv1.GameServerStateError
+	copy.Status.Reason = reason
 
 	gs, err := c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(copy)
 	if err != nil {
 		return gs, errors.Wrapf(err, "error moving GameServer %s to Error State", gs.ObjectMeta.Name)
 	}
 
-	c.recorder.Event(gs, corev1.EventTypeWarning, string(gs.Status.State), msg)
+	c.recorder.Event(gs, corev1.EventTypeWarning, string(gs.Status.State), fmt.Sprintf("%s: %s", reason, msg))
 	return gs, nil
 }
 
@@ -795,19 +1291,30 @@ func (c *Controller) gameServerPod(gs *agonesv1.GameServer) (*corev1.Pod, error)
 	return pod, errors.Wrapf(err, "error retrieving pod for GameServer %s", gs.ObjectMeta.Name)
 }
 
-// address returns the IP that the given Pod is being run on
+// address returns the IP that the given Pod is being run on, and all the addresses of its node,
+// so that game clients on dual-stack and IPv6-only networks can pick the one they can connect to.
 // This should be the externalIP, but if the externalIP is
 // not set, it will fall back to the internalIP with a warning.
 // (basically because minikube only has an internalIP)
-func (c *Controller) address(gs *agonesv1.GameServer, pod *corev1.Pod) (string, error) {
+// On cloud products that do not let Pods use host ports, it is the IP of the Pod, unless the GameServer
+// is exposed through a NodePort Service.
+func (c *Controller) address(gs *agonesv1.GameServer, pod *corev1.Pod) (string, []corev1.NodeAddress, error) {
+	if !c.cloudProduct.UsesHostPorts() && gs.Spec.ServiceType == "" {
+		if pod.Status.PodIP == "" {
+			return "", nil, errors.Errorf("Pod %s does not have an IP yet", pod.ObjectMeta.Name)
+		}
+		return pod.Status.PodIP, []corev1.NodeAddress{{Type: agonesv1.NodePodIP, Address: pod.Status.PodIP}}, nil
+	}
+
 	node, err := c.nodeLister.Get(pod.Spec.NodeName)
 	if err != nil {
-		return "", errors.Wrapf(err, "error retrieving node %s for Pod %s", pod.Spec.NodeName, pod.ObjectMeta.Name)
+		return "", nil, errors.Wrapf(err, "error retrieving node %s for Pod %s", pod.Spec.NodeName, pod.ObjectMeta.Name)
 	}
+	addresses := append([]corev1.NodeAddress(nil), node.Status.Addresses...)
 
 	for _, a := range node.Status.Addresses {
 		if a.Type == corev1.NodeExternalIP && net.ParseIP(a.Address) != nil {
-			return a.Address, nil
+			return a.Address, addresses, nil
 		}
 	}
 
@@ -815,11 +1322,11 @@ func (c *Controller) address(gs *agonesv1.GameServer, pod *corev1.Pod) (string,
 	c.loggerForGameServer(gs).WithField("node", node.ObjectMeta.Name).Warn("Could not find ExternalIP. Falling back to Internal")
 	for _, a := range node.Status.Addresses {
 		if a.Type == corev1.NodeInternalIP && net.ParseIP(a.Address) != nil {
-			return a.Address, nil
+			return a.Address, addresses, nil
 		}
 	}
 
-	return "", errors.Errorf("Could not find an address for Node: %s", node.ObjectMeta.Name)
+	return "", nil, errors.Errorf("Could not find an address for Node: %s", node.ObjectMeta.Name)
 }
 
 // isGameServerPod returns if this Pod is a Pod that comes from a GameServer
@@ -831,3 +1338,13 @@ func isGameServerPod(pod *corev1.Pod) bool {
 
 	return false
 }
+
+// isPodReady returns true if the Ready condition of the pod is true
+func isPodReady(pod *corev1.Pod) bool {
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -20,13 +20,17 @@ import (
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
+	"agones.dev/agones/pkg/apis"
 	"agones.dev/agones/pkg/apis/agones"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/cloudproduct"
 	agtesting "agones.dev/agones/pkg/testing"
 	"agones.dev/agones/pkg/util/webhooks"
 	"github.com/heptiolabs/healthcheck"
 	"github.com/mattbaird/jsonpatch"
+	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	admv1beta1 "k8s.io/api/admission/v1beta1"
@@ -36,6 +40,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"k8s.io/apimachinery/pkg/watch"
@@ -439,6 +444,131 @@ func TestControllerCreationValidationHandler(t *testing.T) {
 		assert.Equal(t, review.Request.Kind.Group, result.Response.Result.Details.Group)
 		assert.NotEmpty(t, result.Response.Result.Details.Causes)
 	})
+
+	t.Run("gameserver not supported by the cloud product", func(t *testing.T) {
+		c, m := newFakeController()
+		c.cloudProduct, _ = cloudproduct.New(cloudproduct.GKEAutopilot, m.KubeClient)
+		fixture := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: newSingleContainerSpec()}
+		fixture.Spec.Ports[0].Name = "gameport"
+		fixture.ApplyDefaults()
+
+		raw, err := json.Marshal(fixture)
+		assert.Nil(t, err)
+		review := admv1beta1.AdmissionReview{
+			Request: &admv1beta1.AdmissionRequest{
+				Kind:      GameServerKind,
+				Operation: admv1beta1.Create,
+				Object: runtime.RawExtension{
+					Raw: raw,
+				},
+			},
+			Response: &admv1beta1.AdmissionResponse{Allowed: true},
+		}
+
+		result, err := c.creationValidationHandler(review)
+		assert.Nil(t, err)
+		assert.False(t, result.Response.Allowed)
+		assert.Len(t, result.Response.Result.Details.Causes, 1)
+		assert.Equal(t, "gameport.portPolicy", result.Response.Result.Details.Causes[0].Field)
+	})
+
+	t.Run("log tail", func(t *testing.T) {
+		c, _ := newFakeController()
+		fixture := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: newSingleContainerSpec()}
+		fixture.Spec.SdkServer.LogTail = true
+		fixture.ApplyDefaults()
+
+		raw, err := json.Marshal(fixture)
+		assert.Nil(t, err)
+		review := func() admv1beta1.AdmissionReview {
+			return admv1beta1.AdmissionReview{
+				Request: &admv1beta1.AdmissionRequest{
+					Kind:      GameServerKind,
+					Operation: admv1beta1.Create,
+					Object: runtime.RawExtension{
+						Raw: raw,
+					},
+				},
+				Response: &admv1beta1.AdmissionResponse{Allowed: true},
+			}
+		}
+
+		result, err := c.creationValidationHandler(review())
+		assert.Nil(t, err)
+		assert.False(t, result.Response.Allowed)
+		assert.Len(t, result.Response.Result.Details.Causes, 1)
+		assert.Equal(t, "sdkServer.logTail", result.Response.Result.Details.Causes[0].Field)
+
+		c.sidecarLogTail = true
+		result, err = c.creationValidationHandler(review())
+		assert.Nil(t, err)
+		assert.True(t, result.Response.Allowed)
+	})
+
+	t.Run("static port conflicts", func(t *testing.T) {
+		c, m := newFakeController()
+		other := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default"},
+			Spec:   newSingleContainerSpec(),
+			Status: agonesv1.GameServerStatus{NodeName: "node1"}}
+		other.Spec.Ports[0].Protocol = corev1.ProtocolUDP
+		m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{*other}}, nil
+		})
+		_, cancel := agtesting.StartInformers(m, c.gameServerSynced)
+		defer cancel()
+
+		validate := func(t *testing.T, ports []agonesv1.GameServerPort, node string) []metav1.StatusCause {
+			fixture := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: newSingleContainerSpec()}
+			fixture.Spec.Ports = ports
+			fixture.Spec.Template.Spec.NodeSelector = map[string]string{"kubernetes.io/hostname": node}
+			fixture.ApplyDefaults()
+
+			raw, err := json.Marshal(fixture)
+			assert.Nil(t, err)
+			review := admv1beta1.AdmissionReview{
+				Request: &admv1beta1.AdmissionRequest{
+					Kind:      GameServerKind,
+					Operation: admv1beta1.Create,
+					Object:    runtime.RawExtension{Raw: raw},
+				},
+				Response: &admv1beta1.AdmissionResponse{Allowed: true},
+			}
+			result, err := c.creationValidationHandler(review)
+			assert.Nil(t, err)
+			if result.Response.Allowed {
+				return nil
+			}
+			return result.Response.Result.Details.Causes
+		}
+		static := func(name string, hostPort int32, protocol corev1.Protocol) agonesv1.GameServerPort {
+			return agonesv1.GameServerPort{Name: name, ContainerPort: 7777, HostPort: hostPort, PortPolicy: agonesv1.Static, Protocol: protocol}
+		}
+
+		assert.Empty(t, validate(t, []agonesv1.GameServerPort{static("a", 9999, corev1.ProtocolUDP)}, "node2"))
+		// a TCP port does not conflict with the UDP port of the same number
+		assert.Empty(t, validate(t, []agonesv1.GameServerPort{static("a", 9999, corev1.ProtocolTCP)}, "node1"))
+
+		causes := validate(t, []agonesv1.GameServerPort{static("a", 15, corev1.ProtocolUDP)}, "")
+		if assert.Len(t, causes, 1) {
+			assert.Equal(t, "a.hostPort", causes[0].Field)
+			assert.Contains(t, causes[0].Message, "port range")
+		}
+
+		causes = validate(t, []agonesv1.GameServerPort{static("a", 8888, corev1.ProtocolUDP), static("b", 8888, corev1.ProtocolUDP)}, "")
+		if assert.Len(t, causes, 1) {
+			assert.Equal(t, "b.hostPort", causes[0].Field)
+			assert.Equal(t, metav1.CauseTypeFieldValueDuplicate, causes[0].Type)
+		}
+
+		causes = validate(t, []agonesv1.GameServerPort{static("a", 9999, corev1.ProtocolUDP)}, "node1")
+		if assert.Len(t, causes, 1) {
+			assert.Equal(t, "a.hostPort", causes[0].Field)
+			assert.Contains(t, causes[0].Message, "default/other")
+		}
+	})
 }
 
 func TestControllerSyncGameServerDeletionTimestamp(t *testing.T) {
@@ -533,6 +663,71 @@ func TestControllerSyncGameServerDeletionTimestamp(t *testing.T) {
 		assert.Equal(t, fixture.ObjectMeta.Name, result.ObjectMeta.Name)
 		assert.Empty(t, result.ObjectMeta.Finalizers)
 	})
+
+	t.Run("GameServer's Pod is stuck terminating on a lost node", func(t *testing.T) {
+		fixtures := map[string]struct {
+			nodes           []corev1.Node
+			deletedAgo      time.Duration
+			wantForceDelete bool
+		}{
+			"node is gone": {
+				deletedAgo:      10 * time.Minute,
+				wantForceDelete: true,
+			},
+			"node is not ready": {
+				nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: nodeFixtureName},
+					Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionUnknown}}}}},
+				deletedAgo:      10 * time.Minute,
+				wantForceDelete: true,
+			},
+			"node is ready": {
+				nodes: []corev1.Node{{ObjectMeta: metav1.ObjectMeta{Name: nodeFixtureName},
+					Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}}}}},
+				deletedAgo: 10 * time.Minute,
+			},
+			"timeout has not passed": {
+				deletedAgo: time.Minute,
+			},
+		}
+
+		for k, v := range fixtures {
+			t.Run(k, func(t *testing.T) {
+				c, mocks := newFakeController()
+				now := metav1.Now()
+				fixture := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", DeletionTimestamp: &now},
+					Spec: newSingleContainerSpec()}
+				fixture.ApplyDefaults()
+				pod, err := fixture.Pod()
+				assert.Nil(t, err)
+				pod.Spec.NodeName = nodeFixtureName
+				deleted := metav1.NewTime(now.Add(-v.deletedAgo))
+				pod.ObjectMeta.DeletionTimestamp = &deleted
+
+				forceDeleted := false
+				mocks.KubeClient.AddReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, &corev1.PodList{Items: []corev1.Pod{*pod}}, nil
+				})
+				mocks.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					return true, &corev1.NodeList{Items: v.nodes}, nil
+				})
+				mocks.KubeClient.AddReactor("delete", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					forceDeleted = true
+					assert.Equal(t, pod.ObjectMeta.Name, action.(k8stesting.DeleteAction).GetName())
+					return true, nil, nil
+				})
+
+				_, cancel := agtesting.StartInformers(mocks, c.podSynced, c.nodeSynced)
+				defer cancel()
+
+				_, err = c.syncGameServerDeletionTimestamp(fixture)
+				assert.NoError(t, err)
+				assert.Equal(t, v.wantForceDelete, forceDeleted)
+				if v.wantForceDelete {
+					agtesting.AssertEventContains(t, mocks.FakeRecorder.Events, "Force deleting Pod "+pod.ObjectMeta.Name)
+				}
+			})
+		}
+	})
 }
 
 func TestControllerSyncGameServerPortAllocationState(t *testing.T) {
@@ -586,6 +781,32 @@ func TestControllerSyncGameServerPortAllocationState(t *testing.T) {
 		assert.True(t, 10 <= port.HostPort && port.HostPort <= 20, "%s not in range", port.HostPort)
 	})
 
+	t.Run("Gameserver on a cloud product without host ports", func(t *testing.T) {
+		t.Parallel()
+		c, mocks := newFakeController()
+		c.cloudProduct, _ = cloudproduct.New(cloudproduct.GKEAutopilot, mocks.KubeClient)
+		fixture := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec:   newSingleContainerSpec(),
+			Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStatePortAllocation},
+		}
+		fixture.Spec.Ports[0] = agonesv1.GameServerPort{ContainerPort: 7777, PortPolicy: agonesv1.Dynamic}
+
+		updated := false
+		mocks.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			ua := action.(k8stesting.UpdateAction)
+			gs := ua.GetObject().(*agonesv1.GameServer)
+			assert.Equal(t, agonesv1.GameServerStateCreating, gs.Status.State)
+			assert.Equal(t, int32(0), gs.Spec.Ports[0].HostPort)
+			return true, gs, nil
+		})
+
+		_, err := c.syncGameServerPortAllocationState(fixture)
+		assert.Nil(t, err, "sync should not error")
+		assert.True(t, updated, "update should occur")
+		assert.Empty(t, mocks.FakeRecorder.Events)
+	})
+
 	t.Run("Gameserver with unknown state", func(t *testing.T) {
 		testNoChange(t, "Unknown", func(c *Controller, fixture *agonesv1.GameServer) (*agonesv1.GameServer, error) {
 			return c.syncGameServerPortAllocationState(fixture)
@@ -782,6 +1003,81 @@ func TestControllerSyncGameServerStartingState(t *testing.T) {
 	})
 }
 
+func TestControllerSyncGameServerScheduledState(t *testing.T) {
+	t.Parallel()
+
+	newFixture := func() *agonesv1.GameServer {
+		fixture := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: newSingleContainerSpec(), Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateScheduled}}
+		fixture.Spec.ReadyOnPodReady = true
+		fixture.Spec.Health.Disabled = true
+		fixture.ApplyDefaults()
+		return fixture
+	}
+
+	setup := func(gs *agonesv1.GameServer, ready corev1.ConditionStatus) (*Controller, agtesting.Mocks, *bool, context.CancelFunc) {
+		c, m := newFakeController()
+		pod, err := gs.Pod()
+		assert.Nil(t, err)
+		pod.Spec.NodeName = nodeFixtureName
+		pod.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}}
+		gsUpdated := false
+
+		m.KubeClient.AddReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &corev1.PodList{Items: []corev1.Pod{*pod}}, nil
+		})
+		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			gsUpdated = true
+			ua := action.(k8stesting.UpdateAction)
+			gs := ua.GetObject().(*agonesv1.GameServer)
+			assert.Equal(t, agonesv1.GameServerStateRequestReady, gs.Status.State)
+			return true, gs, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.gameServerSynced, c.podSynced)
+		return c, m, &gsUpdated, cancel
+	}
+
+	t.Run("pod is ready", func(t *testing.T) {
+		c, m, gsUpdated, cancel := setup(newFixture(), corev1.ConditionTrue)
+		defer cancel()
+
+		gs, err := c.syncGameServerScheduledState(newFixture())
+		assert.Nil(t, err)
+		assert.True(t, *gsUpdated)
+		assert.Equal(t, agonesv1.GameServerStateRequestReady, gs.Status.State)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Pod is Ready")
+	})
+
+	t.Run("pod is not ready", func(t *testing.T) {
+		c, _, gsUpdated, cancel := setup(newFixture(), corev1.ConditionFalse)
+		defer cancel()
+
+		gs, err := c.syncGameServerScheduledState(newFixture())
+		assert.Nil(t, err)
+		assert.False(t, *gsUpdated)
+		assert.Equal(t, agonesv1.GameServerStateScheduled, gs.Status.State)
+	})
+
+	t.Run("GameServer waits for SDK.Ready()", func(t *testing.T) {
+		fixture := newFixture()
+		fixture.Spec.ReadyOnPodReady = false
+		c, _, gsUpdated, cancel := setup(fixture, corev1.ConditionTrue)
+		defer cancel()
+
+		gs, err := c.syncGameServerScheduledState(fixture)
+		assert.Nil(t, err)
+		assert.False(t, *gsUpdated)
+		assert.Equal(t, agonesv1.GameServerStateScheduled, gs.Status.State)
+	})
+
+	t.Run("GameServer with unknown state", func(t *testing.T) {
+		testNoChange(t, "Unknown", func(c *Controller, fixture *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+			return c.syncGameServerScheduledState(fixture)
+		})
+	})
+}
+
 func TestControllerCreateGameServerPod(t *testing.T) {
 	t.Parallel()
 
@@ -831,6 +1127,7 @@ func TestControllerCreateGameServerPod(t *testing.T) {
 			assert.Equal(t, "GAMESERVER_NAME", pod.Spec.Containers[1].Env[0].Name)
 			assert.Equal(t, fixture.ObjectMeta.Name, pod.Spec.Containers[1].Env[0].Value)
 			assert.Equal(t, "POD_NAMESPACE", pod.Spec.Containers[1].Env[1].Name)
+			assert.Nil(t, pod.Spec.Containers[1].Lifecycle)
 			return true, pod, nil
 		})
 
@@ -842,6 +1139,28 @@ func TestControllerCreateGameServerPod(t *testing.T) {
 		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Pod")
 	})
 
+	t.Run("cloud product without host ports", func(t *testing.T) {
+		c, m := newFakeController()
+		c.cloudProduct, _ = cloudproduct.New(cloudproduct.GKEAutopilot, m.KubeClient)
+		fixture := newFixture()
+		created := false
+
+		m.KubeClient.AddReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			created = true
+			ca := action.(k8stesting.CreateAction)
+			pod := ca.GetObject().(*corev1.Pod)
+
+			assert.Equal(t, int32(0), pod.Spec.Containers[0].Ports[0].HostPort)
+			assert.Equal(t, fixture.Spec.Ports[0].ContainerPort, pod.Spec.Containers[0].Ports[0].ContainerPort)
+			assert.Equal(t, corev1.SeccompProfileRuntimeDefault, pod.ObjectMeta.Annotations[corev1.SeccompPodAnnotationKey])
+			return true, pod, nil
+		})
+
+		_, err := c.createGameServerPod(fixture)
+		assert.Nil(t, err)
+		assert.True(t, created)
+	})
+
 	t.Run("service account", func(t *testing.T) {
 		c, m := newFakeController()
 		fixture := newFixture()
@@ -864,6 +1183,121 @@ func TestControllerCreateGameServerPod(t *testing.T) {
 		assert.True(t, created)
 	})
 
+	t.Run("sdk server disabled", func(t *testing.T) {
+		c, m := newFakeController()
+		fixture := newFixture()
+		fixture.Spec.SdkServer.Disabled = true
+		fixture.Spec.Health.Disabled = true
+
+		created := false
+
+		m.KubeClient.AddReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			created = true
+			ca := action.(k8stesting.CreateAction)
+			pod := ca.GetObject().(*corev1.Pod)
+			assert.Len(t, pod.Spec.Containers, 1, "Should not have a sidecar container")
+			assert.Empty(t, pod.Spec.ServiceAccountName)
+			assert.Nil(t, pod.Spec.Containers[0].LivenessProbe)
+
+			return true, pod, nil
+		})
+
+		_, err := c.createGameServerPod(fixture)
+		assert.Nil(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("sdk server log tail", func(t *testing.T) {
+		c, m := newFakeController()
+		fixture := newFixture()
+		fixture.Spec.SdkServer.LogTail = true
+
+		created := false
+
+		m.KubeClient.AddReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			created = true
+			ca := action.(k8stesting.CreateAction)
+			pod := ca.GetObject().(*corev1.Pod)
+			assert.Len(t, pod.Spec.Containers, 2, "Should have a sidecar container")
+			assert.Contains(t, pod.Spec.Containers[1].Args, "--log-tail-container="+fixture.Spec.Container)
+
+			return true, pod, nil
+		})
+
+		_, err := c.createGameServerPod(fixture)
+		assert.Nil(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("sidecar first", func(t *testing.T) {
+		c, m := newFakeController()
+		c.sidecarFirst = true
+		fixture := newFixture()
+		fixture.Spec.SdkServer.GRPCPort = 9357
+
+		created := false
+
+		m.KubeClient.AddReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			created = true
+			ca := action.(k8stesting.CreateAction)
+			pod := ca.GetObject().(*corev1.Pod)
+			if !assert.Len(t, pod.Spec.Containers, 2, "Should have a sidecar container") {
+				return true, pod, nil
+			}
+			sidecar := pod.Spec.Containers[0]
+			assert.Equal(t, sidecarContainerName, sidecar.Name)
+			if assert.NotNil(t, sidecar.Lifecycle) && assert.NotNil(t, sidecar.Lifecycle.PostStart) {
+				assert.Equal(t, []string{sidecarCommand, "--wait-ready", "--grpc-port=9357", "--http-port=59358"}, sidecar.Lifecycle.PostStart.Exec.Command)
+			}
+			assert.Equal(t, fixture.Spec.Container, pod.Spec.Containers[1].Name)
+			assert.Equal(t, "/gshealthz", pod.Spec.Containers[1].LivenessProbe.HTTPGet.Path)
+			assert.Len(t, pod.Spec.Containers[1].VolumeMounts, 1)
+			assert.Equal(t, fixture.Spec.Container, pod.ObjectMeta.Annotations[defaultContainerAnnotation])
+
+			return true, pod, nil
+		})
+
+		_, err := c.createGameServerPod(fixture)
+		assert.Nil(t, err)
+		assert.True(t, created)
+	})
+
+	t.Run("safe to evict", func(t *testing.T) {
+		fixtures := map[string]struct {
+			scheduling  apis.SchedulingStrategy
+			safeToEvict string
+			template    map[string]string
+			expected    string
+		}{
+			"packed":                {scheduling: apis.Packed, safeToEvict: "true", expected: "false"},
+			"distributed":           {scheduling: apis.Distributed, safeToEvict: "true", expected: "true"},
+			"distributed, not set":  {scheduling: apis.Distributed, expected: ""},
+			"distributed, template": {scheduling: apis.Distributed, safeToEvict: "true", template: map[string]string{agonesv1.SafeToEvictAnnotation: "false"}, expected: "false"},
+		}
+
+		for k, v := range fixtures {
+			t.Run(k, func(t *testing.T) {
+				c, m := newFakeController()
+				c.safeToEvict = v.safeToEvict
+				fixture := newFixture()
+				fixture.Spec.Scheduling = v.scheduling
+				fixture.Spec.Template.ObjectMeta.Annotations = v.template
+
+				created := false
+				m.KubeClient.AddReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+					created = true
+					pod := action.(k8stesting.CreateAction).GetObject().(*corev1.Pod)
+					assert.Equal(t, v.expected, pod.ObjectMeta.Annotations[agonesv1.SafeToEvictAnnotation])
+					return true, pod, nil
+				})
+
+				_, err := c.createGameServerPod(fixture)
+				assert.Nil(t, err)
+				assert.True(t, created)
+			})
+		}
+	})
+
 	t.Run("invalid podspec", func(t *testing.T) {
 		c, mocks := newFakeController()
 		fixture := newFixture()
@@ -888,6 +1322,23 @@ func TestControllerCreateGameServerPod(t *testing.T) {
 		assert.True(t, podCreated, "attempt should have been made to create a pod")
 		assert.True(t, gsUpdated, "GameServer should be updated")
 		assert.Equal(t, agonesv1.GameServerStateError, gs.Status.State)
+		assert.Equal(t, apis.ReasonPodInvalid, gs.Status.Reason)
+		agtesting.AssertEventContains(t, mocks.FakeRecorder.Events, "Warning Error PodInvalid: ")
+	})
+
+	t.Run("exceeded quota", func(t *testing.T) {
+		c, mocks := newFakeController()
+		fixture := newFixture()
+
+		mocks.KubeClient.AddReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, nil, k8serrors.NewForbidden(corev1.Resource("pods"), "test", errors.New("exceeded quota: compute-resources"))
+		})
+
+		gs, err := c.createGameServerPod(fixture)
+		assert.Error(t, err)
+		assert.Equal(t, apis.ReasonQuotaExceeded, apis.ReasonForError(err))
+		assert.Equal(t, agonesv1.GameServerStateCreating, gs.Status.State)
+		agtesting.AssertEventContains(t, mocks.FakeRecorder.Events, "Warning QuotaExceeded")
 	})
 }
 
@@ -914,7 +1365,25 @@ func TestControllerApplyGameServerAddressAndPort(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, gs.Spec.Ports[0].HostPort, gs.Status.Ports[0].Port)
 	assert.Equal(t, ipFixture, gs.Status.Address)
+	assert.Equal(t, node.Status.Addresses, gs.Status.Addresses)
 	assert.Equal(t, node.ObjectMeta.Name, gs.Status.NodeName)
+
+	t.Run("cloud product without host ports", func(t *testing.T) {
+		c, m := newFakeController()
+		c.cloudProduct, _ = cloudproduct.New(cloudproduct.EKSFargate, m.KubeClient)
+
+		_, err := c.applyGameServerAddressAndPort(gsFixture.DeepCopy(), pod)
+		assert.NotNil(t, err, "Pod without an IP")
+
+		podCopy := pod.DeepCopy()
+		podCopy.Status.PodIP = "10.0.0.5"
+		gs, err := c.applyGameServerAddressAndPort(gsFixture.DeepCopy(), podCopy)
+		assert.Nil(t, err)
+		assert.Equal(t, "10.0.0.5", gs.Status.Address)
+		assert.Equal(t, []corev1.NodeAddress{{Type: agonesv1.NodePodIP, Address: "10.0.0.5"}}, gs.Status.Addresses)
+		assert.Equal(t, gs.Spec.Ports[0].ContainerPort, gs.Status.Ports[0].Port)
+		assert.Equal(t, node.ObjectMeta.Name, gs.Status.NodeName)
+	})
 }
 
 func TestControllerSyncGameServerRequestReadyState(t *testing.T) {
@@ -949,6 +1418,10 @@ func TestControllerSyncGameServerRequestReadyState(t *testing.T) {
 		assert.Nil(t, err, "should not error")
 		assert.True(t, gsUpdated, "GameServer wasn't updated")
 		assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
+		condition, ok := gs.Status.GetCondition(agonesv1.GameServerConditionReady)
+		assert.True(t, ok, "Ready condition should be set")
+		assert.Equal(t, corev1.ConditionTrue, condition.Status)
+		assert.False(t, condition.LastTransitionTime.IsZero())
 		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "SDK.Ready() complete")
 	})
 
@@ -1053,6 +1526,230 @@ func TestControllerSyncGameServerShutdownState(t *testing.T) {
 	})
 }
 
+func TestControllerSyncGameServerPreemption(t *testing.T) {
+	t.Parallel()
+
+	node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1", Annotations: map[string]string{agonesv1.PreemptionDeadlineAnnotation: "2019-07-10T10:32:00Z"}},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: DefaultPreemptionTaints[0], Effect: corev1.TaintEffectNoSchedule}}}}
+	newFixture := func(state agonesv1.GameServerState, nodeName string) *agonesv1.GameServer {
+		gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: newSingleContainerSpec(), Status: agonesv1.GameServerStatus{State: state, NodeName: nodeName}}
+		gs.ApplyDefaults()
+		return gs
+	}
+
+	fixtures := map[string]struct {
+		gs      *agonesv1.GameServer
+		updated bool
+	}{
+		"allocated on a preempted node": {gs: newFixture(agonesv1.GameServerStateAllocated, "node1"), updated: true},
+		"ready on a preempted node":     {gs: newFixture(agonesv1.GameServerStateReady, "node1")},
+		"allocated on another node":     {gs: newFixture(agonesv1.GameServerStateAllocated, "node2")},
+		"already annotated": {gs: func() *agonesv1.GameServer {
+			gs := newFixture(agonesv1.GameServerStateAllocated, "node1")
+			gs.ObjectMeta.Annotations[agonesv1.PreemptionDeadlineAnnotation] = "2019-07-10T10:31:00Z"
+			return gs
+		}()},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			c, mocks := newFakeController()
+			mocks.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &corev1.NodeList{Items: []corev1.Node{node}}, nil
+			})
+			updated := false
+			mocks.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updated = true
+				gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+				assert.Equal(t, "2019-07-10T10:32:00Z", gs.ObjectMeta.Annotations[agonesv1.PreemptionDeadlineAnnotation])
+				return true, gs, nil
+			})
+			_, cancel := agtesting.StartInformers(mocks, c.nodeSynced)
+			defer cancel()
+
+			_, err := c.syncGameServerPreemption(v.gs)
+			assert.NoError(t, err)
+			assert.Equal(t, v.updated, updated)
+			if v.updated {
+				assert.Contains(t, <-mocks.FakeRecorder.Events, "Node node1 is preempted at 2019-07-10T10:32:00Z")
+			}
+		})
+	}
+}
+
+func TestControllerRecordNodeMaintenance(t *testing.T) {
+	t.Parallel()
+
+	c, mocks := newFakeController()
+	gs1 := agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "gs1", Namespace: "default"},
+		Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady, NodeName: "node1"}}
+	gs2 := agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "gs2", Namespace: "default"},
+		Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady, NodeName: "node2"}}
+	mocks.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{gs1, gs2}}, nil
+	})
+	_, cancel := agtesting.StartInformers(mocks, c.gameServerSynced)
+	defer cancel()
+
+	c.recordNodeMaintenance("node1", "is cordoned")
+	assert.Equal(t, "Warning NodeMaintenance Node node1 is cordoned, so its Ready GameServers are not allocated", <-mocks.FakeRecorder.Events)
+	c.recordNodeMaintenance("node1", "")
+	assert.Equal(t, "Normal NodeMaintenanceEnded Node node1 is no longer under maintenance", <-mocks.FakeRecorder.Events)
+	agtesting.AssertNoEvent(t, mocks.FakeRecorder.Events)
+}
+
+func TestControllerSyncGameServerSessionExpiry(t *testing.T) {
+	t.Parallel()
+
+	newFixture := func(until time.Time, policy agonesv1.SessionExpiryPolicy) *agonesv1.GameServer {
+		gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: newSingleContainerSpec(), Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateAllocated}}
+		gs.Spec.Session = agonesv1.Session{MaxDuration: &metav1.Duration{Duration: time.Hour}, ExpiryPolicy: policy}
+		gs.ApplyDefaults()
+		u := metav1.NewTime(until)
+		gs.Status.AllocatedUntil = &u
+		return gs
+	}
+
+	for _, policy := range []agonesv1.SessionExpiryPolicy{agonesv1.SessionExpiryShutdown, agonesv1.SessionExpiryReady} {
+		t.Run(string(policy), func(t *testing.T) {
+			c, mocks := newFakeController()
+			updated := false
+			mocks.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updated = true
+				gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+				assert.Equal(t, agonesv1.GameServerState(policy), gs.Status.State)
+				assert.Nil(t, gs.Status.AllocatedUntil)
+				return true, gs, nil
+			})
+
+			gs, err := c.syncGameServerSessionExpiry(newFixture(time.Now().Add(-time.Second), policy))
+			assert.NoError(t, err)
+			assert.True(t, updated, "GameServer should be updated")
+			assert.Equal(t, agonesv1.GameServerState(policy), gs.Status.State)
+			assert.Contains(t, <-mocks.FakeRecorder.Events, "Session reached its max duration")
+		})
+	}
+
+	t.Run("session not expired", func(t *testing.T) {
+		c, mocks := newFakeController()
+		mocks.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "should not update")
+			return false, nil, nil
+		})
+
+		fixture := newFixture(time.Now().Add(time.Hour), agonesv1.SessionExpiryShutdown)
+		gs, err := c.syncGameServerSessionExpiry(fixture)
+		assert.NoError(t, err)
+		assert.Equal(t, fixture, gs)
+	})
+
+	t.Run("session expires once the clock passes it", func(t *testing.T) {
+		c, mocks := newFakeController()
+		fc := clock.NewFakeClock(time.Now())
+		c.clock = fc
+		updated := false
+		mocks.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+			return true, gs, nil
+		})
+
+		fixture := newFixture(fc.Now().Add(time.Hour), agonesv1.SessionExpiryShutdown)
+		_, err := c.syncGameServerSessionExpiry(fixture)
+		assert.NoError(t, err)
+		assert.False(t, updated, "GameServer should not be updated before its session expires")
+
+		fc.Step(time.Hour + time.Second)
+		gs, err := c.syncGameServerSessionExpiry(fixture)
+		assert.NoError(t, err)
+		assert.True(t, updated, "GameServer should be updated once its session expires")
+		assert.Equal(t, agonesv1.GameServerStateShutdown, gs.Status.State)
+	})
+
+	t.Run("GameServer with unknown state", func(t *testing.T) {
+		testNoChange(t, "Unknown", func(c *Controller, fixture *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+			return c.syncGameServerSessionExpiry(fixture)
+		})
+	})
+}
+
+func TestControllerSyncGameServerReservedExpiry(t *testing.T) {
+	t.Parallel()
+
+	newFixture := func(until time.Time) *agonesv1.GameServer {
+		gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+			Spec: newSingleContainerSpec(), Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReserved}}
+		gs.ApplyDefaults()
+		u := metav1.NewTime(until)
+		gs.Status.ReservedUntil = &u
+		return gs
+	}
+
+	t.Run("reservation expired", func(t *testing.T) {
+		c, mocks := newFakeController()
+		updated := false
+		mocks.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+			assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
+			assert.Nil(t, gs.Status.ReservedUntil)
+			return true, gs, nil
+		})
+
+		gs, err := c.syncGameServerReservedExpiry(newFixture(time.Now().Add(-time.Minute)))
+		assert.NoError(t, err)
+		assert.True(t, updated, "GameServer should be updated")
+		assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
+		assert.Contains(t, <-mocks.FakeRecorder.Events, "Reservation expired")
+	})
+
+	t.Run("left to the SDK server within the grace period", func(t *testing.T) {
+		c, mocks := newFakeController()
+		fc := clock.NewFakeClock(time.Now())
+		c.clock = fc
+		updated := false
+		mocks.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+			return true, gs, nil
+		})
+
+		fixture := newFixture(fc.Now().Add(-time.Second))
+		gs, err := c.syncGameServerReservedExpiry(fixture)
+		assert.NoError(t, err)
+		assert.False(t, updated, "GameServer should not be updated within the grace period")
+		assert.Equal(t, fixture, gs)
+
+		fc.Step(reservedExpiryGracePeriod)
+		gs, err = c.syncGameServerReservedExpiry(fixture)
+		assert.NoError(t, err)
+		assert.True(t, updated, "GameServer should be updated after the grace period")
+		assert.Equal(t, agonesv1.GameServerStateReady, gs.Status.State)
+	})
+
+	t.Run("reserved forever", func(t *testing.T) {
+		c, mocks := newFakeController()
+		mocks.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			assert.FailNow(t, "should not update")
+			return false, nil, nil
+		})
+
+		fixture := newFixture(time.Now())
+		fixture.Status.ReservedUntil = nil
+		gs, err := c.syncGameServerReservedExpiry(fixture)
+		assert.NoError(t, err)
+		assert.Equal(t, fixture, gs)
+	})
+
+	t.Run("GameServer with unknown state", func(t *testing.T) {
+		testNoChange(t, "Unknown", func(c *Controller, fixture *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+			return c.syncGameServerReservedExpiry(fixture)
+		})
+	})
+}
+
 func TestControllerAddress(t *testing.T) {
 	t.Parallel()
 
@@ -1076,6 +1773,22 @@ func TestControllerAddress(t *testing.T) {
 				}}},
 			expectedAddress: "9.9.9.8",
 		},
+		"dual-stack node": {
+			node: corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeFixtureName},
+				Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+					{Address: "9.9.9.8", Type: corev1.NodeExternalIP},
+					{Address: "2001:db8::8", Type: corev1.NodeExternalIP},
+					{Address: "12.12.12.12", Type: corev1.NodeInternalIP},
+				}}},
+			expectedAddress: "9.9.9.8",
+		},
+		"ipv6 node": {
+			node: corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeFixtureName},
+				Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+					{Address: "2001:db8::8", Type: corev1.NodeExternalIP},
+				}}},
+			expectedAddress: "2001:db8::8",
+		},
 	}
 
 	dummyGS := &agonesv1.GameServer{}
@@ -1100,9 +1813,10 @@ func TestControllerAddress(t *testing.T) {
 			_, cancel := agtesting.StartInformers(mocks, c.gameServerSynced, podSynced, nodeSynced)
 			defer cancel()
 
-			addr, err := c.address(dummyGS, &pod)
+			addr, addresses, err := c.address(dummyGS, &pod)
 			assert.Nil(t, err)
 			assert.Equal(t, fixture.expectedAddress, addr)
+			assert.Equal(t, fixture.node.Status.Addresses, addresses)
 		})
 	}
 }
@@ -1269,10 +1983,11 @@ func testWithNonZeroDeletionTimestamp(t *testing.T, f func(*Controller, *agonesv
 func newFakeController() (*Controller, agtesting.Mocks) {
 	m := agtesting.NewMocks()
 	wh := webhooks.NewWebHook(http.NewServeMux())
+	product, _ := cloudproduct.New(cloudproduct.Generic, m.KubeClient)
 	c := NewController(wh, healthcheck.NewHandler(),
-		10, 20, "sidecar:dev", false,
+		10, 20, nil, nil, "", DisruptionProtection{}, NodeNotReadyRemediation{}, "sidecar:dev", false, false, false, false,
 		resource.MustParse("0.05"), resource.MustParse("0.1"), "sdk-service-account",
-		m.KubeClient, m.KubeInformerFactory, m.ExtClient, m.AgonesClient, m.AgonesInformerFactory)
+		NewNodePreemptions(m.KubeInformerFactory, DefaultPreemptionTaints), NewNodeMaintenance(m.KubeInformerFactory, true, nil), product, m.KubeClient, m.KubeInformerFactory, m.ExtClient, m.AgonesClient, m.AgonesInformerFactory)
 	c.recorder = m.FakeRecorder
 	return c, m
 }
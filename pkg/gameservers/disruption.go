@@ -0,0 +1,146 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// disruptionBudgetName is the name of the PodDisruptionBudget that protects the Pods of the Allocated GameServers
+// of a namespace
+const disruptionBudgetName = "agones-allocated-gameservers"
+
+// DisruptionProtection configures the protection of the Pods of Allocated GameServers from voluntary disruptions,
+// such as node upgrades, so that they do not end matches in progress
+type DisruptionProtection struct {
+	// Enabled creates a PodDisruptionBudget in each namespace that covers the Pods of its Allocated GameServers
+	Enabled bool
+	// MaxGrace is how long the Pod of an Allocated GameServer is protected for at most, so that a GameServer that
+	// stays Allocated does not block node upgrades forever. 0 protects it for as long as it is Allocated.
+	MaxGrace time.Duration
+}
+
+// syncGameServerDisruptionProtection labels the Pod of an Allocated GameServer to be covered by the
+// PodDisruptionBudget of its namespace, which is created if it does not exist, until its MaxGrace has passed,
+// and removes the label from the Pods of GameServers that are no longer Allocated
+func (c *Controller) syncGameServerDisruptionProtection(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+	if !c.disruption.Enabled || gs.IsBeingDeleted() {
+		return gs, nil
+	}
+	if _, isDev := gs.GetDevAddress(); isDev {
+		return gs, nil
+	}
+	pod, err := c.gameServerPod(gs)
+	if k8serrors.IsNotFound(err) {
+		return gs, nil
+	}
+	if err != nil {
+		return gs, err
+	}
+
+	now := c.clock.Now()
+	allocated := gs.Status.State == agonesv1.GameServerStateAllocated
+	since := now
+	if v, ok := pod.ObjectMeta.Annotations[agonesv1.DisruptionProtectedSinceAnnotation]; ok && allocated {
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			since = t
+		}
+	}
+	protected := allocated
+	if allocated && c.disruption.MaxGrace > 0 {
+		wait := since.Add(c.disruption.MaxGrace).Sub(now)
+		protected = wait > 0
+		if protected {
+			// check again once its grace has passed
+			c.workerqueue.EnqueueAfter(gs, wait)
+		}
+	}
+
+	podCopy := pod.DeepCopy()
+	if protected {
+		if err := c.ensureDisruptionBudget(gs.ObjectMeta.Namespace); err != nil {
+			return gs, err
+		}
+		if podCopy.ObjectMeta.Labels == nil {
+			podCopy.ObjectMeta.Labels = make(map[string]string, 1)
+		}
+		podCopy.ObjectMeta.Labels[agonesv1.DisruptionProtectedLabel] = "true"
+	} else {
+		delete(podCopy.ObjectMeta.Labels, agonesv1.DisruptionProtectedLabel)
+	}
+	if allocated {
+		if podCopy.ObjectMeta.Annotations == nil {
+			podCopy.ObjectMeta.Annotations = make(map[string]string, 1)
+		}
+		podCopy.ObjectMeta.Annotations[agonesv1.DisruptionProtectedSinceAnnotation] = since.UTC().Format(time.RFC3339)
+	} else {
+		delete(podCopy.ObjectMeta.Annotations, agonesv1.DisruptionProtectedSinceAnnotation)
+	}
+
+	if podCopy.ObjectMeta.Labels[agonesv1.DisruptionProtectedLabel] == pod.ObjectMeta.Labels[agonesv1.DisruptionProtectedLabel] &&
+		podCopy.ObjectMeta.Annotations[agonesv1.DisruptionProtectedSinceAnnotation] == pod.ObjectMeta.Annotations[agonesv1.DisruptionProtectedSinceAnnotation] {
+		return gs, nil
+	}
+	if _, err := c.podGetter.Pods(podCopy.ObjectMeta.Namespace).Update(podCopy); err != nil {
+		return gs, errors.Wrapf(err, "error updating the disruption protection of the Pod of GameServer %s", gs.ObjectMeta.Name)
+	}
+
+	_, wasProtected := pod.ObjectMeta.Labels[agonesv1.DisruptionProtectedLabel]
+	switch {
+	case protected && !wasProtected:
+		c.recorder.Event(gs, corev1.EventTypeNormal, string(gs.Status.State), "Pod protected from voluntary disruptions")
+	case !protected && wasProtected && allocated:
+		c.recorder.Eventf(gs, corev1.EventTypeNormal, string(gs.Status.State), "Pod no longer protected from voluntary disruptions, after %s", c.disruption.MaxGrace)
+	}
+	return gs, nil
+}
+
+// ensureDisruptionBudget creates the PodDisruptionBudget that covers the Pods of the Allocated GameServers
+// of the namespace, if it does not exist
+func (c *Controller) ensureDisruptionBudget(namespace string) error {
+	_, err := c.pdbLister.PodDisruptionBudgets(namespace).Get(disruptionBudgetName)
+	if err == nil {
+		return nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error retrieving PodDisruptionBudget %s in namespace %s", disruptionBudgetName, namespace)
+	}
+
+	maxUnavailable := intstr.FromInt(0)
+	pdb := &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      disruptionBudgetName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app": "agones"},
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{agonesv1.DisruptionProtectedLabel: "true"}},
+		},
+	}
+	_, err = c.pdbGetter.PodDisruptionBudgets(namespace).Create(pdb)
+	if err != nil && !k8serrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "error creating PodDisruptionBudget %s in namespace %s", disruptionBudgetName, namespace)
+	}
+	return nil
+}
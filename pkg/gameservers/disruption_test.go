@@ -0,0 +1,166 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestControllerSyncGameServerDisruptionProtection(t *testing.T) {
+	t.Parallel()
+
+	since := time.Now().Add(-30 * time.Minute).UTC().Format(time.RFC3339)
+	expired := time.Now().Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+
+	fixtures := map[string]struct {
+		state       agonesv1.GameServerState
+		labels      map[string]string
+		annotations map[string]string
+		pdbExists   bool
+		// updated is whether the Pod is updated, and then whether it is protected, and the annotation it has
+		updated    bool
+		protected  bool
+		annotation string
+		pdbCreated bool
+		event      string
+	}{
+		"allocated": {
+			state: agonesv1.GameServerStateAllocated, updated: true, protected: true, pdbCreated: true,
+			event: "Pod protected from voluntary disruptions",
+		},
+		"allocated with a budget": {
+			state: agonesv1.GameServerStateAllocated, pdbExists: true, updated: true, protected: true,
+			event: "Pod protected from voluntary disruptions",
+		},
+		"allocated and protected": {
+			state:       agonesv1.GameServerStateAllocated,
+			labels:      map[string]string{agonesv1.DisruptionProtectedLabel: "true"},
+			annotations: map[string]string{agonesv1.DisruptionProtectedSinceAnnotation: since},
+			pdbExists:   true,
+		},
+		"grace passed": {
+			state:       agonesv1.GameServerStateAllocated,
+			labels:      map[string]string{agonesv1.DisruptionProtectedLabel: "true"},
+			annotations: map[string]string{agonesv1.DisruptionProtectedSinceAnnotation: expired},
+			updated:     true, annotation: expired,
+			event: "Pod no longer protected from voluntary disruptions, after 1h0m0s",
+		},
+		"grace passed and unprotected": {
+			state:       agonesv1.GameServerStateAllocated,
+			annotations: map[string]string{agonesv1.DisruptionProtectedSinceAnnotation: expired},
+		},
+		"no longer allocated": {
+			state:       agonesv1.GameServerStateReady,
+			labels:      map[string]string{agonesv1.DisruptionProtectedLabel: "true"},
+			annotations: map[string]string{agonesv1.DisruptionProtectedSinceAnnotation: since},
+			updated:     true,
+		},
+		"ready": {state: agonesv1.GameServerStateReady},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			c, mocks := newFakeController()
+			c.disruption = DisruptionProtection{Enabled: true, MaxGrace: time.Hour}
+			c.pdbLister = mocks.KubeInformerFactory.Policy().V1beta1().PodDisruptionBudgets().Lister()
+			c.pdbSynced = mocks.KubeInformerFactory.Policy().V1beta1().PodDisruptionBudgets().Informer().HasSynced
+
+			gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: newSingleContainerSpec(), Status: agonesv1.GameServerStatus{State: v.state}}
+			gs.ApplyDefaults()
+			pod, err := gs.Pod()
+			assert.NoError(t, err)
+			for key, value := range v.labels {
+				pod.ObjectMeta.Labels[key] = value
+			}
+			for key, value := range v.annotations {
+				pod.ObjectMeta.Annotations[key] = value
+			}
+
+			mocks.KubeClient.AddReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &corev1.PodList{Items: []corev1.Pod{*pod}}, nil
+			})
+			mocks.KubeClient.AddReactor("list", "poddisruptionbudgets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				list := &policyv1beta1.PodDisruptionBudgetList{}
+				if v.pdbExists {
+					list.Items = append(list.Items, policyv1beta1.PodDisruptionBudget{ObjectMeta: metav1.ObjectMeta{Name: disruptionBudgetName, Namespace: "default"}})
+				}
+				return true, list, nil
+			})
+			var created *policyv1beta1.PodDisruptionBudget
+			mocks.KubeClient.AddReactor("create", "poddisruptionbudgets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				created = action.(k8stesting.CreateAction).GetObject().(*policyv1beta1.PodDisruptionBudget)
+				return true, created, nil
+			})
+			var updated *corev1.Pod
+			mocks.KubeClient.AddReactor("update", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updated = action.(k8stesting.UpdateAction).GetObject().(*corev1.Pod)
+				return true, updated, nil
+			})
+			_, cancel := agtesting.StartInformers(mocks, c.podSynced, c.pdbSynced)
+			defer cancel()
+
+			_, err = c.syncGameServerDisruptionProtection(gs)
+			assert.NoError(t, err)
+
+			if !v.updated {
+				assert.Nil(t, updated)
+			} else if assert.NotNil(t, updated) {
+				_, protected := updated.ObjectMeta.Labels[agonesv1.DisruptionProtectedLabel]
+				assert.Equal(t, v.protected, protected)
+				annotation, ok := updated.ObjectMeta.Annotations[agonesv1.DisruptionProtectedSinceAnnotation]
+				if v.state != agonesv1.GameServerStateAllocated {
+					assert.False(t, ok)
+				} else if v.annotation != "" {
+					assert.Equal(t, v.annotation, annotation)
+				} else {
+					assert.True(t, ok)
+				}
+			}
+
+			if v.pdbCreated {
+				if assert.NotNil(t, created) {
+					assert.Equal(t, disruptionBudgetName, created.ObjectMeta.Name)
+					assert.Equal(t, int32(0), created.Spec.MaxUnavailable.IntVal)
+					assert.Equal(t, map[string]string{agonesv1.DisruptionProtectedLabel: "true"}, created.Spec.Selector.MatchLabels)
+				}
+			} else {
+				assert.Nil(t, created)
+			}
+
+			if v.event != "" {
+				agtesting.AssertEventContains(t, mocks.FakeRecorder.Events, v.event)
+			} else {
+				agtesting.AssertNoEvent(t, mocks.FakeRecorder.Events)
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		testNoChange(t, agonesv1.GameServerStateAllocated, func(c *Controller, fixture *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+			return c.syncGameServerDisruptionProtection(fixture)
+		})
+	})
+}
@@ -32,6 +32,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/clock"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -53,10 +54,19 @@ type HealthController struct {
 	gameServerLister listerv1.GameServerLister
 	workerqueue      *workerqueue.WorkerQueue
 	recorder         record.EventRecorder
+	syncLog          runtime.SampledLogger
+	// nodeNotReady configures how the GameServers of NotReady nodes are moved to Unhealthy
+	nodeNotReady NodeNotReadyRemediation
+	nodeLister   corelisterv1.NodeLister
+	nodeSynced   cache.InformerSynced
+	// nodeWorkerqueue processes the NotReady nodes, nil if their GameServers are not remediated
+	nodeWorkerqueue *workerqueue.WorkerQueue
+	clock           clock.Clock
 }
 
 // NewHealthController returns a HealthController
 func NewHealthController(health healthcheck.Handler,
+	nodeNotReady NodeNotReadyRemediation,
 	kubeClient kubernetes.Interface,
 	agonesClient versioned.Interface,
 	kubeInformerFactory informers.SharedInformerFactory,
@@ -64,18 +74,41 @@ func NewHealthController(health healthcheck.Handler,
 
 	podInformer := kubeInformerFactory.Core().V1().Pods().Informer()
 	gameserverInformer := agonesInformerFactory.Agones().V1().GameServers()
+	nodes := kubeInformerFactory.Core().V1().Nodes()
 	hc := &HealthController{
 		podSynced:        podInformer.HasSynced,
 		podLister:        kubeInformerFactory.Core().V1().Pods().Lister(),
 		gameServerSynced: gameserverInformer.Informer().HasSynced,
 		gameServerGetter: agonesClient.AgonesV1(),
 		gameServerLister: gameserverInformer.Lister(),
+		nodeNotReady:     nodeNotReady,
+		nodeLister:       nodes.Lister(),
+		nodeSynced:       nodes.Informer().HasSynced,
+		clock:            clock.RealClock{},
 	}
 
 	hc.baseLogger = runtime.NewLoggerWithType(hc)
 	hc.workerqueue = workerqueue.NewWorkerQueue(hc.syncGameServer, hc.baseLogger, logfields.GameServerKey, agones.GroupName+".HealthController")
 	health.AddLivenessCheck("gameserver-health-workerqueue", healthcheck.Check(hc.workerqueue.Healthy))
 
+	// the nodes are only processed when the GameServers of NotReady nodes are remediated
+	if nodeNotReady.Threshold > 0 {
+		hc.nodeWorkerqueue = workerqueue.NewWorkerQueue(hc.syncNode, hc.baseLogger, logfields.NodeKey, agones.GroupName+".HealthController.Nodes")
+		health.AddLivenessCheck("gameserver-health-node-workerqueue", healthcheck.Check(hc.nodeWorkerqueue.Healthy))
+		nodes.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(obj interface{}) {
+				if _, notReady := notReadySince(obj.(*corev1.Node)); notReady {
+					hc.nodeWorkerqueue.Enqueue(obj)
+				}
+			},
+			UpdateFunc: func(oldObj, newObj interface{}) {
+				if _, notReady := notReadySince(newObj.(*corev1.Node)); notReady {
+					hc.nodeWorkerqueue.Enqueue(newObj)
+				}
+			},
+		})
+	}
+
 	eventBroadcaster := record.NewBroadcaster()
 	eventBroadcaster.StartLogging(hc.baseLogger.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: kubeClient.CoreV1().Events("")})
@@ -143,10 +176,13 @@ func (hc *HealthController) failedContainer(pod *corev1.Pod) bool {
 // Will block until stop is closed
 func (hc *HealthController) Run(stop <-chan struct{}) error {
 	hc.baseLogger.Info("Wait for cache sync")
-	if !cache.WaitForCacheSync(stop, hc.gameServerSynced, hc.podSynced) {
+	if !cache.WaitForCacheSync(stop, hc.gameServerSynced, hc.podSynced, hc.nodeSynced) {
 		return errors.New("failed to wait for caches to sync")
 	}
 
+	if hc.nodeWorkerqueue != nil {
+		go hc.nodeWorkerqueue.Run(1, stop)
+	}
 	hc.workerqueue.Run(1, stop)
 
 	return nil
@@ -161,12 +197,12 @@ func (hc *HealthController) loggerForGameServer(gs *agonesv1.GameServer) *logrus
 	if gs != nil {
 		gsName = gs.Namespace + "/" + gs.Name
 	}
-	return hc.loggerForGameServerKey(gsName).WithField("gs", gs)
+	return logfields.WithObject(hc.loggerForGameServerKey(gsName), "gs", gs)
 }
 
 // syncGameServer sets the GameSerer to Unhealthy, if its state is Ready
 func (hc *HealthController) syncGameServer(key string) error {
-	hc.loggerForGameServerKey(key).Info("Synchronising")
+	hc.syncLog.Debug(hc.loggerForGameServerKey(key), "Synchronising")
 
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
@@ -34,7 +34,7 @@ func TestHealthControllerFailedContainer(t *testing.T) {
 	t.Parallel()
 
 	m := agtesting.NewMocks()
-	hc := NewHealthController(healthcheck.NewHandler(), m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
+	hc := NewHealthController(healthcheck.NewHandler(), NodeNotReadyRemediation{}, m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
 
 	gs := agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test"}, Spec: newSingleContainerSpec()}
 	gs.ApplyDefaults()
@@ -61,7 +61,7 @@ func TestHealthUnschedulableWithNoFreePorts(t *testing.T) {
 	t.Parallel()
 
 	m := agtesting.NewMocks()
-	hc := NewHealthController(healthcheck.NewHandler(), m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
+	hc := NewHealthController(healthcheck.NewHandler(), NodeNotReadyRemediation{}, m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
 
 	gs := agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test"}, Spec: newSingleContainerSpec()}
 	gs.ApplyDefaults()
@@ -124,7 +124,7 @@ func TestHealthControllerSyncGameServer(t *testing.T) {
 	for name, test := range fixtures {
 		t.Run(name, func(t *testing.T) {
 			m := agtesting.NewMocks()
-			hc := NewHealthController(healthcheck.NewHandler(), m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
+			hc := NewHealthController(healthcheck.NewHandler(), NodeNotReadyRemediation{}, m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
 			hc.recorder = m.FakeRecorder
 
 			gs := agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "test"}, Spec: newSingleContainerSpec(),
@@ -159,7 +159,7 @@ func TestHealthControllerSyncGameServer(t *testing.T) {
 
 func TestHealthControllerRun(t *testing.T) {
 	m := agtesting.NewMocks()
-	hc := NewHealthController(healthcheck.NewHandler(), m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
+	hc := NewHealthController(healthcheck.NewHandler(), NodeNotReadyRemediation{}, m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
 	hc.recorder = m.FakeRecorder
 
 	gsWatch := watch.NewFake()
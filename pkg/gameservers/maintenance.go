@@ -0,0 +1,84 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"agones.dev/agones/pkg/util/runtime"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+)
+
+// NodeMaintenance tracks the nodes that are under maintenance, as they are cordoned, if cordoned nodes are
+// tracked, or have one of the maintenance taints, so that their Ready GameServers are not allocated
+// before they are drained.
+type NodeMaintenance struct {
+	logger     *logrus.Entry
+	cordoned   bool
+	taints     map[string]bool
+	nodeLister corelisterv1.NodeLister
+}
+
+// NewNodeMaintenance returns a NodeMaintenance for the nodes that are cordoned, if cordoned is true,
+// or have one of the taints
+func NewNodeMaintenance(kubeInformerFactory informers.SharedInformerFactory, cordoned bool, taints []string) *NodeMaintenance {
+	m := &NodeMaintenance{
+		cordoned:   cordoned,
+		taints:     map[string]bool{},
+		nodeLister: kubeInformerFactory.Core().V1().Nodes().Lister(),
+	}
+	m.logger = runtime.NewLoggerWithType(m)
+	for _, t := range taints {
+		m.taints[t] = true
+	}
+	return m
+}
+
+// NodesInMaintenance returns the names of the nodes that are under maintenance
+func (m *NodeMaintenance) NodesInMaintenance() map[string]bool {
+	result := map[string]bool{}
+	if m == nil || (!m.cordoned && len(m.taints) == 0) {
+		return result
+	}
+	nodes, err := m.nodeLister.List(labels.Everything())
+	if err != nil {
+		m.logger.WithError(err).Warn("error listing nodes for their maintenance")
+		return result
+	}
+	for _, n := range nodes {
+		if m.reason(n) != "" {
+			result[n.ObjectMeta.Name] = true
+		}
+	}
+	return result
+}
+
+// reason returns why the node is under maintenance, or an empty string if it is not
+func (m *NodeMaintenance) reason(node *corev1.Node) string {
+	if m == nil {
+		return ""
+	}
+	if m.cordoned && node.Spec.Unschedulable {
+		return "is cordoned"
+	}
+	for _, t := range node.Spec.Taints {
+		if m.taints[t.Key] {
+			return "has the maintenance taint " + t.Key
+		}
+	}
+	return ""
+}
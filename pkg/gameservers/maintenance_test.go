@@ -0,0 +1,61 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"testing"
+
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestNodeMaintenance(t *testing.T) {
+	t.Parallel()
+
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "healthy"},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "agones.dev/gameservers", Effect: corev1.TaintEffectNoSchedule}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cordoned"},
+			Spec: corev1.NodeSpec{Unschedulable: true}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "tainted"},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "example.com/maintenance", Effect: corev1.TaintEffectNoSchedule}}}},
+	}
+
+	m := agtesting.NewMocks()
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: nodes}, nil
+	})
+	all := NewNodeMaintenance(m.KubeInformerFactory, true, []string{"example.com/maintenance"})
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+	assert.True(t, cache.WaitForCacheSync(stop, m.KubeInformerFactory.Core().V1().Nodes().Informer().HasSynced))
+
+	assert.Equal(t, map[string]bool{"cordoned": true, "tainted": true}, all.NodesInMaintenance())
+	assert.Equal(t, "", all.reason(&nodes[0]))
+	assert.Equal(t, "is cordoned", all.reason(&nodes[1]))
+	assert.Equal(t, "has the maintenance taint example.com/maintenance", all.reason(&nodes[2]))
+
+	assert.Equal(t, map[string]bool{"tainted": true}, NewNodeMaintenance(m.KubeInformerFactory, false, []string{"example.com/maintenance"}).NodesInMaintenance())
+	assert.Empty(t, NewNodeMaintenance(m.KubeInformerFactory, false, nil).NodesInMaintenance())
+
+	var none *NodeMaintenance
+	assert.Empty(t, none.NodesInMaintenance())
+	assert.Equal(t, "", none.reason(&nodes[1]))
+}
@@ -0,0 +1,41 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"context"
+
+	"agones.dev/agones/pkg/util/runtime"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+)
+
+var reclaimedPortsStats = stats.Int64("gameservers/reclaimed_ports", "The ports reclaimed from GameServers that no longer exist", "1")
+
+func init() {
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameservers_reclaimed_ports_total",
+		Measure:     reclaimedPortsStats,
+		Description: "The total of ports that were leaked by GameServers that no longer exist, and then reclaimed",
+		Aggregation: view.Count(),
+	}))
+}
+
+// recordReclaimedPorts records the number of ports that were reclaimed
+func recordReclaimedPorts(count int) {
+	for i := 0; i < count; i++ {
+		stats.Record(context.Background(), reclaimedPortsStats.M(1))
+	}
+}
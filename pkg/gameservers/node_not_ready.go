@@ -0,0 +1,107 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/util/logfields"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// NodeNotReadyRemediation configures how the GameServers of nodes that are NotReady are moved to Unhealthy, so
+// that their Fleets replace them on healthy nodes, rather than waiting for their Pods to be garbage collected
+type NodeNotReadyRemediation struct {
+	// Threshold is how long a node is NotReady for before its GameServers that are not Allocated are moved to
+	// Unhealthy. 0 disables the remediation.
+	Threshold time.Duration
+	// AllocatedThreshold is how long a node is NotReady for before its Allocated GameServers are moved to
+	// Unhealthy too. 0 leaves them Allocated.
+	AllocatedThreshold time.Duration
+}
+
+// syncNode moves the GameServers of a node that has been NotReady for longer than the thresholds to Unhealthy,
+// and checks the node again once the next threshold passes
+func (hc *HealthController) syncNode(key string) error {
+	logger := logfields.AugmentLogEntry(hc.baseLogger, logfields.NodeKey, key)
+	node, err := hc.nodeLister.Get(key)
+	if k8serrors.IsNotFound(err) {
+		// the Pods of a deleted node are garbage collected
+		return nil
+	}
+	if err != nil {
+		return errors.Wrapf(err, "error retrieving node %s", key)
+	}
+	since, notReady := notReadySince(node)
+	if !notReady {
+		return nil
+	}
+
+	notReadyFor := hc.clock.Since(since)
+	if wait := hc.nodeNotReady.Threshold - notReadyFor; wait > 0 {
+		hc.nodeWorkerqueue.EnqueueAfter(node, wait)
+		return nil
+	}
+	allocatedThreshold := hc.nodeNotReady.AllocatedThreshold
+	allocated := allocatedThreshold > 0 && notReadyFor >= allocatedThreshold
+	if allocatedThreshold > 0 && !allocated {
+		// check again once its Allocated GameServers are to be moved to Unhealthy too
+		hc.nodeWorkerqueue.EnqueueAfter(node, allocatedThreshold-notReadyFor)
+	}
+
+	list, err := hc.gameServerLister.List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "error listing GameServers")
+	}
+	for _, gs := range list {
+		if gs.Status.NodeName != node.ObjectMeta.Name || gs.IsBeingDeleted() {
+			continue
+		}
+		threshold := hc.nodeNotReady.Threshold
+		switch gs.Status.State {
+		case agonesv1.GameServerStateUnhealthy, agonesv1.GameServerStateShutdown, agonesv1.GameServerStateError:
+			continue
+		case agonesv1.GameServerStateAllocated:
+			if !allocated {
+				continue
+			}
+			threshold = allocatedThreshold
+		}
+
+		gsCopy := gs.DeepCopy()
+		gsCopy.Status.State = agonesv1.GameServerStateUnhealthy
+		if _, err := hc.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(gsCopy); err != nil {
+			return errors.Wrapf(err, "error updating GameServer %s of NotReady node %s to unhealthy", gs.ObjectMeta.Name, key)
+		}
+		logger.WithField("gs", gs.ObjectMeta.Namespace+"/"+gs.ObjectMeta.Name).Warn("Node is NotReady, marking GameServer as GameServerStateUnhealthy")
+		hc.recorder.Eventf(gs, corev1.EventTypeWarning, string(gsCopy.Status.State), "Node %s has been NotReady for more than %s", key, threshold)
+	}
+	return nil
+}
+
+// notReadySince returns since when the node is NotReady, and whether it is. A node whose Ready condition is
+// Unknown is NotReady, as the node controller sets it when the kubelet stops reporting.
+func notReadySince(node *corev1.Node) (time.Time, bool) {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.LastTransitionTime.Time, cond.Status != corev1.ConditionTrue
+		}
+	}
+	return time.Time{}, false
+}
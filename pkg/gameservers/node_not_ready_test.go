@@ -0,0 +1,135 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/heptiolabs/healthcheck"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/clock"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestHealthControllerSyncNode(t *testing.T) {
+	t.Parallel()
+
+	newGameServer := func(name, node string, state agonesv1.GameServerState) agonesv1.GameServer {
+		gs := agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}, Spec: newSingleContainerSpec(),
+			Status: agonesv1.GameServerStatus{State: state, NodeName: node}}
+		gs.ApplyDefaults()
+		return gs
+	}
+	list := []agonesv1.GameServer{
+		newGameServer("ready", "node1", agonesv1.GameServerStateReady),
+		newGameServer("scheduled", "node1", agonesv1.GameServerStateScheduled),
+		newGameServer("allocated", "node1", agonesv1.GameServerStateAllocated),
+		newGameServer("unhealthy", "node1", agonesv1.GameServerStateUnhealthy),
+		newGameServer("shutdown", "node1", agonesv1.GameServerStateShutdown),
+		newGameServer("other node", "node2", agonesv1.GameServerStateReady),
+	}
+
+	fixtures := map[string]struct {
+		status      corev1.ConditionStatus
+		notReadyFor time.Duration
+		remediation NodeNotReadyRemediation
+		expected    []string
+	}{
+		"ready node": {
+			status: corev1.ConditionTrue, notReadyFor: time.Hour,
+			remediation: NodeNotReadyRemediation{Threshold: time.Minute, AllocatedThreshold: 10 * time.Minute},
+		},
+		"within the threshold": {
+			status: corev1.ConditionFalse, notReadyFor: 30 * time.Second,
+			remediation: NodeNotReadyRemediation{Threshold: time.Minute, AllocatedThreshold: 10 * time.Minute},
+		},
+		"past the threshold": {
+			status: corev1.ConditionFalse, notReadyFor: 2 * time.Minute,
+			remediation: NodeNotReadyRemediation{Threshold: time.Minute, AllocatedThreshold: 10 * time.Minute},
+			expected:    []string{"ready", "scheduled"},
+		},
+		"unknown past the threshold": {
+			status: corev1.ConditionUnknown, notReadyFor: 2 * time.Minute,
+			remediation: NodeNotReadyRemediation{Threshold: time.Minute},
+			expected:    []string{"ready", "scheduled"},
+		},
+		"past the allocated threshold": {
+			status: corev1.ConditionFalse, notReadyFor: 11 * time.Minute,
+			remediation: NodeNotReadyRemediation{Threshold: time.Minute, AllocatedThreshold: 10 * time.Minute},
+			expected:    []string{"allocated", "ready", "scheduled"},
+		},
+		"allocated left alone": {
+			status: corev1.ConditionFalse, notReadyFor: time.Hour,
+			remediation: NodeNotReadyRemediation{Threshold: time.Minute},
+			expected:    []string{"ready", "scheduled"},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			m := agtesting.NewMocks()
+			hc := NewHealthController(healthcheck.NewHandler(), v.remediation, m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
+			hc.recorder = m.FakeRecorder
+			fc := clock.NewFakeClock(time.Now())
+			hc.clock = fc
+
+			node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: v.status, LastTransitionTime: metav1.NewTime(fc.Now().Add(-v.notReadyFor))},
+			}}}
+			m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &corev1.NodeList{Items: []corev1.Node{node}}, nil
+			})
+			m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &agonesv1.GameServerList{Items: list}, nil
+			})
+			var updated []string
+			m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+				assert.Equal(t, agonesv1.GameServerStateUnhealthy, gs.Status.State)
+				updated = append(updated, gs.ObjectMeta.Name)
+				return true, gs, nil
+			})
+
+			_, cancel := agtesting.StartInformers(m, hc.nodeSynced, hc.gameServerSynced)
+			defer cancel()
+
+			err := hc.syncNode("node1")
+			assert.NoError(t, err)
+			sort.Strings(updated)
+			assert.Equal(t, v.expected, updated)
+			if len(v.expected) > 0 {
+				agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Node node1 has been NotReady for more than")
+			} else {
+				agtesting.AssertNoEvent(t, m.FakeRecorder.Events)
+			}
+		})
+	}
+
+	t.Run("deleted node", func(t *testing.T) {
+		m := agtesting.NewMocks()
+		hc := NewHealthController(healthcheck.NewHandler(), NodeNotReadyRemediation{Threshold: time.Minute}, m.KubeClient, m.AgonesClient, m.KubeInformerFactory, m.AgonesInformerFactory)
+		_, cancel := agtesting.StartInformers(m, hc.nodeSynced)
+		defer cancel()
+
+		assert.NoError(t, hc.syncNode("node1"))
+	})
+}
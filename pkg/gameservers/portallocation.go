@@ -0,0 +1,166 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+// portAllocation is the set of port allocations of a node for a protocol.
+// The ports that are taken are tracked in a bitmap, and the ports that are not are kept in a free list
+// for each port range they are in, so that taking, releasing and finding a free port are all O(1).
+type portAllocation struct {
+	// base is the lowest port that can be allocated, which is the first bit of taken
+	base  int32
+	taken []uint64
+	// free are the free lists of the port ranges
+	free map[PortRange]*freeList
+	// ports is how many ports can be allocated, and allocated is how many of them are
+	ports     int
+	allocated int
+}
+
+// freeList is the set of free ports of a port range, which any port can be added to or removed from in O(1)
+type freeList struct {
+	r     PortRange
+	ports []int32
+	// index is the position of each port of the range in ports, or -1 if the port is not free
+	index []int32
+}
+
+// newPortAllocation returns the port allocations of a node with all the ports of the port ranges free
+func newPortAllocation(ranges []PortRange) *portAllocation {
+	n := &portAllocation{free: make(map[PortRange]*freeList, len(ranges))}
+	if len(ranges) == 0 {
+		return n
+	}
+
+	n.base = ranges[0].MinPort
+	top := ranges[0].MaxPort
+	for _, r := range ranges {
+		if r.MinPort < n.base {
+			n.base = r.MinPort
+		}
+		if r.MaxPort > top {
+			top = r.MaxPort
+		}
+	}
+	n.taken = make([]uint64, (top-n.base)/64+1)
+
+	for _, r := range ranges {
+		if _, ok := n.free[r]; ok {
+			continue
+		}
+		l := &freeList{r: r, ports: make([]int32, 0, r.MaxPort-r.MinPort+1), index: make([]int32, r.MaxPort-r.MinPort+1)}
+		// add the ports from the top, so that they are taken from the bottom of the range
+		for p := r.MaxPort; p >= r.MinPort; p-- {
+			l.add(p)
+		}
+		n.free[r] = l
+	}
+
+	for p := n.base; p <= top; p++ {
+		if n.allocatable(p) {
+			n.ports++
+		}
+	}
+
+	return n
+}
+
+// allocatable returns true if the port is in one of the port ranges of the node
+func (n *portAllocation) allocatable(port int32) bool {
+	for r := range n.free {
+		if r.contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// isTaken returns true if the port is allocated
+func (n *portAllocation) isTaken(port int32) bool {
+	if !n.allocatable(port) {
+		return false
+	}
+	i := port - n.base
+	return n.taken[i/64]&(1<<uint(i%64)) != 0
+}
+
+// take marks the port as allocated, and returns false if it already was, or cannot be allocated
+func (n *portAllocation) take(port int32) bool {
+	if !n.allocatable(port) || n.isTaken(port) {
+		return false
+	}
+	i := port - n.base
+	n.taken[i/64] |= 1 << uint(i%64)
+	n.allocated++
+	for _, l := range n.free {
+		if l.r.contains(port) {
+			l.remove(port)
+		}
+	}
+	return true
+}
+
+// release marks the port as no longer allocated, and returns false if it was not allocated
+func (n *portAllocation) release(port int32) bool {
+	if !n.isTaken(port) {
+		return false
+	}
+	i := port - n.base
+	n.taken[i/64] &^= 1 << uint(i%64)
+	n.allocated--
+	for _, l := range n.free {
+		if l.r.contains(port) {
+			l.add(port)
+		}
+	}
+	return true
+}
+
+// countFree returns how many ports of the port range are free
+func (n *portAllocation) countFree(r PortRange) int {
+	if l, ok := n.free[r]; ok {
+		return len(l.ports)
+	}
+	return 0
+}
+
+// takeFree takes a free port of the port range, and returns false if there are none
+func (n *portAllocation) takeFree(r PortRange) (int32, bool) {
+	if n.countFree(r) == 0 {
+		return 0, false
+	}
+	l := n.free[r]
+	port := l.ports[len(l.ports)-1]
+	n.take(port)
+	return port, true
+}
+
+// add adds the port to the free list
+func (l *freeList) add(port int32) {
+	l.index[port-l.r.MinPort] = int32(len(l.ports))
+	l.ports = append(l.ports, port)
+}
+
+// remove removes the port from the free list, if it is in it
+func (l *freeList) remove(port int32) {
+	i := l.index[port-l.r.MinPort]
+	if i < 0 {
+		return
+	}
+	last := l.ports[len(l.ports)-1]
+	l.ports[i] = last
+	l.index[last-l.r.MinPort] = i
+	l.ports = l.ports[:len(l.ports)-1]
+	l.index[port-l.r.MinPort] = -1
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortAllocationTakeRelease(t *testing.T) {
+	t.Parallel()
+
+	// the namespace range overlaps the top of the default range
+	defaultRange := PortRange{MinPort: 10, MaxPort: 20}
+	teamRange := PortRange{MinPort: 18, MaxPort: 100}
+	n := newPortAllocation([]PortRange{defaultRange, teamRange, defaultRange})
+	assert.Equal(t, 91, n.ports)
+	assert.Equal(t, 11, n.countFree(defaultRange))
+	assert.Equal(t, 83, n.countFree(teamRange))
+
+	// ports are taken from the bottom of the range
+	port, ok := n.takeFree(defaultRange)
+	assert.True(t, ok)
+	assert.Equal(t, int32(10), port)
+	assert.True(t, n.isTaken(10))
+	assert.False(t, n.take(10), "a port cannot be taken twice")
+
+	// a port in both ranges is not free in either of them once taken
+	assert.True(t, n.take(19))
+	assert.Equal(t, 9, n.countFree(defaultRange))
+	assert.Equal(t, 82, n.countFree(teamRange))
+	assert.Equal(t, 2, n.allocated)
+
+	// ports outside of the ranges cannot be allocated
+	assert.False(t, n.take(9))
+	assert.False(t, n.take(101))
+	assert.False(t, n.isTaken(101))
+	assert.False(t, n.release(101))
+
+	assert.True(t, n.release(19))
+	assert.False(t, n.release(19), "a port cannot be released twice")
+	assert.Equal(t, 10, n.countFree(defaultRange))
+	assert.Equal(t, 83, n.countFree(teamRange))
+	assert.Equal(t, 1, n.allocated)
+
+	// every free port is taken exactly once
+	taken := map[int32]bool{}
+	for {
+		port, ok := n.takeFree(defaultRange)
+		if !ok {
+			break
+		}
+		assert.False(t, taken[port], "port %d taken twice", port)
+		assert.True(t, defaultRange.contains(port))
+		taken[port] = true
+	}
+	assert.Len(t, taken, 10)
+	assert.Equal(t, 0, n.countFree(defaultRange))
+	assert.Equal(t, 80, n.countFree(teamRange))
+	assert.Equal(t, 11, n.allocated)
+
+	_, ok = n.takeFree(PortRange{MinPort: 1, MaxPort: 5})
+	assert.False(t, ok, "the node does not have the range")
+}
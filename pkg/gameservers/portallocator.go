@@ -17,74 +17,142 @@ package gameservers
 import (
 	"sort"
 	"sync"
+	"time"
 
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"agones.dev/agones/pkg/client/informers/externalversions"
 	listerv1 "agones.dev/agones/pkg/client/listers/agones/v1"
+	"agones.dev/agones/pkg/util/logfields"
 	"agones.dev/agones/pkg/util/runtime"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	corelisterv1 "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/cache"
 )
 
-// A set of port allocations for a node
-type portAllocation map[int32]bool
+// portReclaimPeriod is how often port reservations of GameServers that no longer exist are reclaimed
+const portReclaimPeriod = time.Minute
+
+// portProtocols are the protocols that host ports are allocated for. Each of them has its own port allocations,
+// as a TCP and a UDP host port with the same number can be used on a node at the same time.
+var portProtocols = []corev1.Protocol{corev1.ProtocolUDP, corev1.ProtocolTCP}
+
+// hostPort is a host port that is reserved for a GameServer
+type hostPort struct {
+	port     int32
+	protocol corev1.Protocol
+}
+
+// portProtocol returns the protocol of the port allocations that the host port of the GameServer port is allocated
+// from. Protocols other than TCP share the port allocations of UDP, which is the default protocol.
+func portProtocol(p agonesv1.GameServerPort) corev1.Protocol {
+	if p.Protocol == corev1.ProtocolTCP {
+		return corev1.ProtocolTCP
+	}
+	return corev1.ProtocolUDP
+}
+
+// PortRange is a range of ports that can be allocated to GameServers
+type PortRange struct {
+	MinPort int32
+	MaxPort int32
+}
+
+// contains returns true if the port is within the range
+func (r PortRange) contains(port int32) bool {
+	return port >= r.MinPort && port <= r.MaxPort
+}
 
 // PortAllocator manages the dynamic port
 // allocation strategy. Only use exposed methods to ensure
 // appropriate locking is taken.
 // The PortAllocator does not currently support mixing static portAllocations (or any pods with defined HostPort)
 // within the dynamic port range other than the ones it coordinates.
+// The ports of a GameServer are released once both the GameServer and its Pod are gone, as a Pod that is still
+// terminating holds on to its host ports.
 type PortAllocator struct {
-	logger             *logrus.Entry
-	mutex              sync.RWMutex
-	portAllocations    []portAllocation
-	gameServerRegistry map[types.UID]bool
+	logger *logrus.Entry
+	mutex  sync.RWMutex
+	// portAllocations are the port allocations of the nodes for each of the portProtocols, which all have
+	// the same number of nodes
+	portAllocations map[corev1.Protocol][]*portAllocation
+	// gameServerRegistry holds the host ports reserved for each GameServer
+	gameServerRegistry map[types.UID][]hostPort
 	minPort            int32
 	maxPort            int32
+	// namespacePortRanges are the port ranges of the namespaces that do not use the minPort to maxPort range
+	namespacePortRanges map[string]PortRange
+	// portRanges are the port range and the namespace port ranges, which the nodes have free lists for
+	portRanges         []PortRange
 	gameServerSynced   cache.InformerSynced
 	gameServerLister   listerv1.GameServerLister
 	gameServerInformer cache.SharedIndexInformer
 	nodeSynced         cache.InformerSynced
 	nodeLister         corelisterv1.NodeLister
 	nodeInformer       cache.SharedIndexInformer
+	podSynced          cache.InformerSynced
+	podLister          corelisterv1.PodLister
+	podInformer        cache.SharedIndexInformer
+}
+
+// PortUtilization is how many of the dynamic ports of the schedulable nodes are allocated
+type PortUtilization struct {
+	// Ports are the dynamic ports of all the schedulable nodes, counted once for each protocol
+	Ports int `json:"ports"`
+	// Allocated are the ports that are allocated to GameServers
+	Allocated int `json:"allocated"`
 }
 
 // NewPortAllocator returns a new dynamic port
 // allocator. minPort and maxPort are the top and bottom portAllocations that can be allocated in the range for
-// the game servers
-func NewPortAllocator(minPort, maxPort int32,
+// the game servers. namespacePortRanges overrides that range for the game servers of specific namespaces.
+func NewPortAllocator(minPort, maxPort int32, namespacePortRanges map[string]PortRange,
 	kubeInformerFactory informers.SharedInformerFactory,
 	agonesInformerFactory externalversions.SharedInformerFactory) *PortAllocator {
 
 	v1 := kubeInformerFactory.Core().V1()
 	nodes := v1.Nodes()
+	pods := v1.Pods()
 	gameServers := agonesInformerFactory.Agones().V1().GameServers()
 
 	pa := &PortAllocator{
-		mutex:              sync.RWMutex{},
-		minPort:            minPort,
-		maxPort:            maxPort,
-		gameServerRegistry: map[types.UID]bool{},
-		gameServerSynced:   gameServers.Informer().HasSynced,
-		gameServerLister:   gameServers.Lister(),
-		gameServerInformer: gameServers.Informer(),
-		nodeLister:         nodes.Lister(),
-		nodeInformer:       nodes.Informer(),
-		nodeSynced:         nodes.Informer().HasSynced,
+		mutex:               sync.RWMutex{},
+		minPort:             minPort,
+		maxPort:             maxPort,
+		namespacePortRanges: namespacePortRanges,
+		portRanges:          []PortRange{{MinPort: minPort, MaxPort: maxPort}},
+		portAllocations:     map[corev1.Protocol][]*portAllocation{},
+		gameServerRegistry:  map[types.UID][]hostPort{},
+		gameServerSynced:    gameServers.Informer().HasSynced,
+		gameServerLister:    gameServers.Lister(),
+		gameServerInformer:  gameServers.Informer(),
+		nodeLister:          nodes.Lister(),
+		nodeInformer:        nodes.Informer(),
+		nodeSynced:          nodes.Informer().HasSynced,
+		podLister:           pods.Lister(),
+		podInformer:         pods.Informer(),
+		podSynced:           pods.Informer().HasSynced,
 	}
 	pa.logger = runtime.NewLoggerWithType(pa)
 
+	for _, r := range namespacePortRanges {
+		pa.portRanges = append(pa.portRanges, r)
+	}
+
 	pa.gameServerInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		DeleteFunc: pa.syncDeleteGameServer,
 	})
+	pa.podInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: pa.syncDeletePod,
+	})
 
-	pa.logger.WithField("minPort", minPort).WithField("maxPort", maxPort).Info("Starting")
+	pa.logger.WithField("minPort", minPort).WithField("maxPort", maxPort).WithField("namespacePortRanges", namespacePortRanges).Info("Starting")
 	return pa
 }
 
@@ -93,7 +161,7 @@ func NewPortAllocator(minPort, maxPort int32,
 func (pa *PortAllocator) Run(stop <-chan struct{}) error {
 	pa.logger.Info("Running")
 
-	if !cache.WaitForCacheSync(stop, pa.gameServerSynced, pa.nodeSynced) {
+	if !cache.WaitForCacheSync(stop, pa.gameServerSynced, pa.nodeSynced, pa.podSynced) {
 		return errors.New("failed to wait for caches to sync")
 	}
 
@@ -102,80 +170,98 @@ func (pa *PortAllocator) Run(stop <-chan struct{}) error {
 		return errors.Wrap(err, "error performing initial sync")
 	}
 
+	go wait.Until(pa.reclaimOrphanedPorts, portReclaimPeriod, stop)
+
 	return nil
 }
 
+// Utilization returns how many of the dynamic ports of the schedulable nodes are allocated
+func (pa *PortAllocator) Utilization() PortUtilization {
+	pa.mutex.RLock()
+	defer pa.mutex.RUnlock()
+
+	u := PortUtilization{}
+	for _, allocations := range pa.portAllocations {
+		for _, n := range allocations {
+			u.Ports += n.ports
+			u.Allocated += n.allocated
+		}
+	}
+	return u
+}
+
 // Allocate assigns a port to the GameServer and returns it.
 // Return ErrPortNotFound if no port is allocatable
 func (pa *PortAllocator) Allocate(gs *agonesv1.GameServer) *agonesv1.GameServer {
 	pa.mutex.Lock()
 	defer pa.mutex.Unlock()
 
-	type pn struct {
-		pa   portAllocation
-		port int32
-	}
-
-	// we only want this to be called inside the mutex lock
-	// so let's define the function here so it can never be called elsewhere.
-	// Also the return gives an escape from the double loop
-	findOpenPorts := func(amount int) []pn {
-		var ports []pn
-		for _, n := range pa.portAllocations {
-			for p, taken := range n {
-				if !taken {
-					ports = append(ports, pn{pa: n, port: p})
-					// only allocate as many ports as are asked for by the GameServer
-					if len(ports) == amount {
-						return ports
-					}
-				}
+	// we only want these to be called inside the mutex lock
+	// so let's define the functions here so they can never be called elsewhere.
+	hasFreePorts := func(amount int, r PortRange, protocol corev1.Protocol) bool {
+		free := 0
+		for _, n := range pa.portAllocations[protocol] {
+			free += n.countFree(r)
+			if free >= amount {
+				return true
+			}
+		}
+		return false
+	}
+	takeFreePort := func(r PortRange, protocol corev1.Protocol) int32 {
+		for _, n := range pa.portAllocations[protocol] {
+			if port, ok := n.takeFree(r); ok {
+				return port
 			}
 		}
-		return ports
+		return 0
 	}
 
 	// this allows us to do recursion, within the mutex lock
 	var allocate func(gs *agonesv1.GameServer) *agonesv1.GameServer
 	allocate = func(gs *agonesv1.GameServer) *agonesv1.GameServer {
-		amount := gs.CountPorts(func(policy agonesv1.PortPolicy) bool {
-			return policy == agonesv1.Dynamic || policy == agonesv1.Passthrough
-		})
-		allocations := findOpenPorts(amount)
-
-		if len(allocations) == amount {
-			pa.gameServerRegistry[gs.ObjectMeta.UID] = true
-
-			for i, p := range gs.Spec.Ports {
-				if p.PortPolicy == agonesv1.Dynamic || p.PortPolicy == agonesv1.Passthrough {
-					// pop off allocation
-					var a pn
-					a, allocations = allocations[0], allocations[1:]
-					a.pa[a.port] = true
-					gs.Spec.Ports[i].HostPort = a.port
-
-					if p.PortPolicy == agonesv1.Passthrough {
-						gs.Spec.Ports[i].ContainerPort = a.port
-					}
-				}
+		r := pa.portRange(gs.ObjectMeta.Namespace)
+		amounts := map[corev1.Protocol]int{}
+		for _, p := range gs.Spec.Ports {
+			if p.PortPolicy == agonesv1.Dynamic || p.PortPolicy == agonesv1.Passthrough {
+				amounts[portProtocol(p)]++
 			}
+		}
 
-			return gs
+		for protocol, amount := range amounts {
+			if !hasFreePorts(amount, r, protocol) {
+				// if we get here, we ran out of ports. Add a node, and try again.
+				// this is important, because to autoscale scale up, we create GameServers that
+				// can't be scheduled on the current set of nodes, so we need to be sure
+				// there are always ports available to be allocated.
+				pa.addPortAllocation()
+				return allocate(gs)
+			}
 		}
 
-		// if we get here, we ran out of ports. Add a node, and try again.
-		// this is important, because to autoscale scale up, we create GameServers that
-		// can't be scheduled on the current set of nodes, so we need to be sure
-		// there are always ports available to be allocated.
-		pa.portAllocations = append(pa.portAllocations, pa.newPortAllocation())
+		for i, p := range gs.Spec.Ports {
+			if p.PortPolicy == agonesv1.Dynamic || p.PortPolicy == agonesv1.Passthrough {
+				protocol := portProtocol(p)
+				port := takeFreePort(r, protocol)
+				gs.Spec.Ports[i].HostPort = port
+				// a GameServer that is retried may already hold a reservation, so add to it
+				pa.gameServerRegistry[gs.ObjectMeta.UID] = append(pa.gameServerRegistry[gs.ObjectMeta.UID], hostPort{port: port, protocol: protocol})
+
+				// the container port must be the host port when the Pod uses the host network
+				if p.PortPolicy == agonesv1.Passthrough || gs.Spec.Template.Spec.HostNetwork {
+					gs.Spec.Ports[i].ContainerPort = port
+				}
+			}
+		}
 
-		return allocate(gs)
+		return gs
 	}
 
 	return allocate(gs)
 }
 
-// DeAllocate marks the given port as no longer allocated
+// DeAllocate marks the ports of the given GameServer as no longer allocated.
+// Only ports that are reserved for the GameServer are released.
 func (pa *PortAllocator) DeAllocate(gs *agonesv1.GameServer) {
 	// skip if it wasn't previously allocated
 
@@ -196,22 +282,125 @@ func (pa *PortAllocator) DeAllocate(gs *agonesv1.GameServer) {
 
 	pa.mutex.Lock()
 	defer pa.mutex.Unlock()
+	reserved := pa.gameServerRegistry[gs.ObjectMeta.UID]
 	for _, p := range gs.Spec.Ports {
-		if p.HostPort < pa.minPort || p.HostPort > pa.maxPort {
+		if !pa.allocatable(p.HostPort) {
 			continue
 		}
-		pa.portAllocations = setPortAllocation(p.HostPort, pa.portAllocations, false)
+		protocol := portProtocol(p)
+		for i, r := range reserved {
+			if r.port == p.HostPort && r.protocol == protocol {
+				reserved = append(reserved[:i], reserved[i+1:]...)
+				pa.portAllocations[protocol] = setPortAllocation(p.HostPort, pa.portAllocations[protocol], false)
+				break
+			}
+		}
 	}
 
-	delete(pa.gameServerRegistry, gs.ObjectMeta.UID)
+	if len(reserved) == 0 {
+		delete(pa.gameServerRegistry, gs.ObjectMeta.UID)
+	} else {
+		pa.gameServerRegistry[gs.ObjectMeta.UID] = reserved
+	}
 }
 
-// syncDeleteGameServer when a GameServer Pod is deleted
-// make the HostPort available
+// release marks all the ports reserved for the GameServer as no longer allocated,
+// and returns how many ports were released.
+// pa.mutex must be held.
+func (pa *PortAllocator) release(uid types.UID) int {
+	reserved := pa.gameServerRegistry[uid]
+	for _, p := range reserved {
+		if !pa.allocatable(p.port) {
+			continue
+		}
+		pa.portAllocations[p.protocol] = setPortAllocation(p.port, pa.portAllocations[p.protocol], false)
+	}
+	delete(pa.gameServerRegistry, uid)
+	return len(reserved)
+}
+
+// syncDeleteGameServer when a GameServer is deleted
+// make the HostPort available, unless its Pod is still there
 func (pa *PortAllocator) syncDeleteGameServer(object interface{}) {
+	if tombstone, ok := object.(cache.DeletedFinalStateUnknown); ok {
+		object = tombstone.Obj
+	}
 	if gs, ok := object.(*agonesv1.GameServer); ok {
-		pa.logger.WithField("gs", gs).Info("syncing deleted GameServer")
-		pa.DeAllocate(gs)
+		logger := logfields.AugmentLogEntry(pa.logger, logfields.GameServerKey, gs.ObjectMeta.Namespace+"/"+gs.ObjectMeta.Name)
+		// the Pod still uses the host ports while it is terminating, so they are released once it is deleted
+		if pod, err := pa.podLister.Pods(gs.ObjectMeta.Namespace).Get(gs.ObjectMeta.Name); err == nil && metav1.IsControlledBy(pod, gs) {
+			logger.Debug("syncing deleted GameServer, deferring port release until its Pod is deleted")
+			return
+		}
+		logger.Debug("syncing deleted GameServer")
+		// release everything reserved for the GameServer, rather than the ports on the deleted object,
+		// as the last version seen may be from before its ports were allocated
+		pa.mutex.Lock()
+		defer pa.mutex.Unlock()
+		pa.release(gs.ObjectMeta.UID)
+	}
+}
+
+// syncDeletePod when the Pod of a GameServer that is deleted is deleted
+// make the HostPort available
+func (pa *PortAllocator) syncDeletePod(object interface{}) {
+	if tombstone, ok := object.(cache.DeletedFinalStateUnknown); ok {
+		object = tombstone.Obj
+	}
+	pod, ok := object.(*corev1.Pod)
+	if !ok || !isGameServerPod(pod) {
+		return
+	}
+	owner := metav1.GetControllerOf(pod)
+	if gs, err := pa.gameServerLister.GameServers(pod.ObjectMeta.Namespace).Get(owner.Name); err == nil && gs.ObjectMeta.UID == owner.UID {
+		return
+	}
+
+	logfields.AugmentLogEntry(pa.logger, logfields.GameServerKey, pod.ObjectMeta.Namespace+"/"+owner.Name).
+		Debug("syncing deleted Pod of deleted GameServer")
+	pa.mutex.Lock()
+	defer pa.mutex.Unlock()
+	pa.release(owner.UID)
+}
+
+// reclaimOrphanedPorts releases the ports reserved for GameServers that no longer exist, and
+// do not have a Pod anymore either, e.g. because a GameServer was deleted before its port allocation
+// could be recorded, so that those ports are not leaked.
+func (pa *PortAllocator) reclaimOrphanedPorts() {
+	pa.mutex.Lock()
+	defer pa.mutex.Unlock()
+
+	// list within the lock, so that every GameServer that was allocated ports is in the list, unless it has been deleted
+	gameservers, err := pa.gameServerLister.List(labels.Everything())
+	if err != nil {
+		pa.logger.WithError(err).Error("error listing all GameServers to reclaim ports")
+		return
+	}
+	pods, err := pa.podLister.List(labels.Everything())
+	if err != nil {
+		pa.logger.WithError(err).Error("error listing all Pods to reclaim ports")
+		return
+	}
+	existing := make(map[types.UID]bool, len(gameservers))
+	for _, gs := range gameservers {
+		existing[gs.ObjectMeta.UID] = true
+	}
+	for _, pod := range pods {
+		if isGameServerPod(pod) {
+			existing[metav1.GetControllerOf(pod).UID] = true
+		}
+	}
+
+	reclaimed := 0
+	for uid := range pa.gameServerRegistry {
+		if !existing[uid] {
+			reclaimed += pa.release(uid)
+		}
+	}
+
+	if reclaimed > 0 {
+		pa.logger.WithField("ports", reclaimed).Warn("Reclaimed ports reserved for GameServers that no longer exist")
+		recordReclaimedPorts(reclaimed)
 	}
 }
 
@@ -237,7 +426,13 @@ func (pa *PortAllocator) syncAll() error {
 		return errors.Wrapf(err, "error listing all GameServers")
 	}
 
-	gsRegistry := map[types.UID]bool{}
+	pods, err := pa.podLister.List(labels.Everything())
+	if err != nil {
+		return errors.Wrap(err, "error listing all Pods")
+	}
+	gameservers = append(gameservers, deletedGameServerPods(gameservers, pods)...)
+
+	gsRegistry := map[types.UID][]hostPort{}
 
 	// place to put GameServer port allocations that are not ready yet/after the ready state
 	allocations, nonReadyNodesPorts := pa.registerExistingGameServerPorts(gameservers, nodes, gsRegistry)
@@ -247,7 +442,7 @@ func (pa *PortAllocator) syncAll() error {
 	// that there is a port open *somewhere* as the default scheduler
 	// will re-route for us based on HostPort allocation
 	for _, p := range nonReadyNodesPorts {
-		allocations = setPortAllocation(p, allocations, true)
+		allocations[p.protocol] = setPortAllocation(p.port, allocations[p.protocol], true)
 	}
 
 	pa.portAllocations = allocations
@@ -257,38 +452,45 @@ func (pa *PortAllocator) syncAll() error {
 }
 
 // registerExistingGameServerPorts registers the gameservers against gsRegistry and the ports against nodePorts.
-// and returns an ordered list of portAllocations per cluster nodes, and an array of
-// any GameServers allocated a port, but not yet assigned a Node will returned as an array of port values.
-func (pa *PortAllocator) registerExistingGameServerPorts(gameservers []*agonesv1.GameServer, nodes []*corev1.Node, gsRegistry map[types.UID]bool) ([]portAllocation, []int32) {
+// and returns an ordered list of portAllocations per cluster nodes for each protocol, and an array of
+// any GameServers allocated a port, but not yet assigned a Node will returned as an array of host ports.
+func (pa *PortAllocator) registerExistingGameServerPorts(gameservers []*agonesv1.GameServer, nodes []*corev1.Node,
+	gsRegistry map[types.UID][]hostPort) (map[corev1.Protocol][]*portAllocation, []hostPort) {
 	// setup blank port values
-	nodePortAllocation := pa.nodePortAllocation(nodes)
+	nodePortAllocations := make(map[corev1.Protocol]map[string]*portAllocation, len(portProtocols))
+	for _, protocol := range portProtocols {
+		nodePortAllocations[protocol] = pa.nodePortAllocation(nodes)
+	}
 	nodePortCount := make(map[string]int64, len(nodes))
 	for _, n := range nodes {
 		nodePortCount[n.ObjectMeta.Name] = 0
 	}
 
-	var nonReadyNodesPorts []int32
+	var nonReadyNodesPorts []hostPort
 
 	for _, gs := range gameservers {
 		for _, p := range gs.Spec.Ports {
 			if p.PortPolicy == agonesv1.Dynamic || p.PortPolicy == agonesv1.Passthrough {
-				gsRegistry[gs.ObjectMeta.UID] = true
+				protocol := portProtocol(p)
+				if p.HostPort != 0 {
+					gsRegistry[gs.ObjectMeta.UID] = append(gsRegistry[gs.ObjectMeta.UID], hostPort{port: p.HostPort, protocol: protocol})
+				}
 
 				// if the node doesn't exist, it's likely unscheduled
-				_, ok := nodePortAllocation[gs.Status.NodeName]
+				nodePortAllocation, ok := nodePortAllocations[protocol][gs.Status.NodeName]
 				if gs.Status.NodeName != "" && ok {
-					nodePortAllocation[gs.Status.NodeName][p.HostPort] = true
+					nodePortAllocation.take(p.HostPort)
 					nodePortCount[gs.Status.NodeName]++
 				} else if p.HostPort != 0 {
-					nonReadyNodesPorts = append(nonReadyNodesPorts, p.HostPort)
+					nonReadyNodesPorts = append(nonReadyNodesPorts, hostPort{port: p.HostPort, protocol: protocol})
 				}
 			}
 		}
 	}
 
 	// make a list of the keys
-	keys := make([]string, 0, len(nodePortAllocation))
-	for k := range nodePortAllocation {
+	keys := make([]string, 0, len(nodePortCount))
+	for k := range nodePortAllocations[corev1.ProtocolUDP] {
 		keys = append(keys, k)
 	}
 
@@ -299,10 +501,12 @@ func (pa *PortAllocator) registerExistingGameServerPorts(gameservers []*agonesv1
 	})
 
 	// this gives us back an ordered node list
-	allocations := make([]portAllocation, len(nodePortAllocation))
-	for i, k := range keys {
-		allocations[i] = nodePortAllocation[k]
-
+	allocations := make(map[corev1.Protocol][]*portAllocation, len(portProtocols))
+	for _, protocol := range portProtocols {
+		allocations[protocol] = make([]*portAllocation, len(keys))
+		for i, k := range keys {
+			allocations[protocol][i] = nodePortAllocations[protocol][k]
+		}
 	}
 
 	return allocations, nonReadyNodesPorts
@@ -310,35 +514,88 @@ func (pa *PortAllocator) registerExistingGameServerPorts(gameservers []*agonesv1
 
 // nodePortAllocation returns a map of port allocations all set to being available
 // with a map key for each node, as well as the node registry record (since we're already looping)
-func (pa *PortAllocator) nodePortAllocation(nodes []*corev1.Node) map[string]portAllocation {
-	nodePorts := map[string]portAllocation{}
+func (pa *PortAllocator) nodePortAllocation(nodes []*corev1.Node) map[string]*portAllocation {
+	nodePorts := map[string]*portAllocation{}
 
 	for _, n := range nodes {
 		// ignore unschedulable nodes
 		if !n.Spec.Unschedulable {
-			nodePorts[n.Name] = pa.newPortAllocation()
+			nodePorts[n.Name] = newPortAllocation(pa.portRanges)
 		}
 	}
 
 	return nodePorts
 }
 
-func (pa *PortAllocator) newPortAllocation() portAllocation {
-	p := make(portAllocation, (pa.maxPort-pa.minPort)+1)
-	for i := pa.minPort; i <= pa.maxPort; i++ {
-		p[i] = false
+// addPortAllocation adds the port allocations of a node for each protocol.
+// pa.mutex must be held.
+func (pa *PortAllocator) addPortAllocation() {
+	for _, protocol := range portProtocols {
+		pa.portAllocations[protocol] = append(pa.portAllocations[protocol], newPortAllocation(pa.portRanges))
 	}
+}
 
-	return p
+// portRange returns the range of ports that can be allocated to the game servers of the namespace
+func (pa *PortAllocator) portRange(namespace string) PortRange {
+	if r, ok := pa.namespacePortRanges[namespace]; ok {
+		return r
+	}
+	return PortRange{MinPort: pa.minPort, MaxPort: pa.maxPort}
 }
 
-// setPortAllocation takes a port from an all
-func setPortAllocation(port int32, allocations []portAllocation, taken bool) []portAllocation {
+// allocatable returns true if the port is in the port range of any namespace
+func (pa *PortAllocator) allocatable(port int32) bool {
+	if port >= pa.minPort && port <= pa.maxPort {
+		return true
+	}
+	for _, r := range pa.namespacePortRanges {
+		if r.contains(port) {
+			return true
+		}
+	}
+	return false
+}
+
+// setPortAllocation takes a port on the first node it is free on, or releases it on the first node it is taken on
+func setPortAllocation(port int32, allocations []*portAllocation, taken bool) []*portAllocation {
 	for _, np := range allocations {
-		if np[port] != taken {
-			np[port] = taken
+		if taken && np.take(port) || !taken && np.release(port) {
 			break
 		}
 	}
 	return allocations
 }
+
+// deletedGameServerPods returns GameServers with the host ports of the Pods of GameServers that are not in gameservers
+// anymore, as the host ports of those Pods are in use until they are deleted
+func deletedGameServerPods(gameservers []*agonesv1.GameServer, pods []*corev1.Pod) []*agonesv1.GameServer {
+	existing := make(map[types.UID]bool, len(gameservers))
+	for _, gs := range gameservers {
+		existing[gs.ObjectMeta.UID] = true
+	}
+
+	var result []*agonesv1.GameServer
+	for _, pod := range pods {
+		if !isGameServerPod(pod) {
+			continue
+		}
+		owner := metav1.GetControllerOf(pod)
+		if existing[owner.UID] {
+			continue
+		}
+		gs := &agonesv1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: owner.Name, Namespace: pod.ObjectMeta.Namespace, UID: owner.UID},
+			Status:     agonesv1.GameServerStatus{NodeName: pod.Spec.NodeName},
+		}
+		// the host ports are registered as Dynamic ports, as those are the ones that are tracked
+		for _, c := range pod.Spec.Containers {
+			for _, p := range c.Ports {
+				if p.HostPort != 0 {
+					gs.Spec.Ports = append(gs.Spec.Ports, agonesv1.GameServerPort{PortPolicy: agonesv1.Dynamic, HostPort: p.HostPort, Protocol: p.Protocol})
+				}
+			}
+		}
+		result = append(result, gs)
+	}
+	return result
+}
@@ -19,6 +19,7 @@ import (
 	"strconv"
 	"sync"
 	"testing"
+	"time"
 
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	agtesting "agones.dev/agones/pkg/testing"
@@ -28,6 +29,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
 	k8stesting "k8s.io/client-go/testing"
 	"k8s.io/client-go/tools/cache"
@@ -45,7 +47,7 @@ func TestPortAllocatorAllocate(t *testing.T) {
 
 	t.Run("test allocated port counts", func(t *testing.T) {
 		m := agtesting.NewMocks()
-		pa := NewPortAllocator(10, 50, m.KubeInformerFactory, m.AgonesInformerFactory)
+		pa := NewPortAllocator(10, 50, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 		nodeWatch := watch.NewFake()
 		m.KubeClient.AddWatchReactor("nodes", k8stesting.DefaultWatchReactor(nodeWatch, nil))
 
@@ -110,7 +112,7 @@ func TestPortAllocatorAllocate(t *testing.T) {
 
 	t.Run("ports are all allocated", func(t *testing.T) {
 		m := agtesting.NewMocks()
-		pa := NewPortAllocator(10, 20, m.KubeInformerFactory, m.AgonesInformerFactory)
+		pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 		nodeWatch := watch.NewFake()
 		m.KubeClient.AddWatchReactor("nodes", k8stesting.DefaultWatchReactor(nodeWatch, nil))
 
@@ -136,16 +138,16 @@ func TestPortAllocatorAllocate(t *testing.T) {
 			}
 		}
 
-		assert.Len(t, pa.portAllocations, 2)
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 2)
 		gs := pa.Allocate(fixture.DeepCopy())
 		assert.NotEmpty(t, gs.Spec.Ports[0].HostPort)
-		assert.Len(t, pa.portAllocations, 3)
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 3)
 	})
 
 	t.Run("ports are all allocated with multiple ports per GameServers", func(t *testing.T) {
 		m := agtesting.NewMocks()
 		maxPort := int32(19) // make sure we have an even number
-		pa := NewPortAllocator(10, maxPort, m.KubeInformerFactory, m.AgonesInformerFactory)
+		pa := NewPortAllocator(10, maxPort, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 		nodeWatch := watch.NewFake()
 		m.KubeClient.AddWatchReactor("nodes", k8stesting.DefaultWatchReactor(nodeWatch, nil))
 
@@ -194,17 +196,17 @@ func TestPortAllocatorAllocate(t *testing.T) {
 			}
 		}
 
-		logrus.WithField("allocated", countTotalAllocatedPorts(pa)).WithField("count", len(pa.portAllocations[0])+len(pa.portAllocations[1])).Info("How many allocated")
-		assert.Len(t, pa.portAllocations, 3)
+		logrus.WithField("allocated", countTotalAllocatedPorts(pa)).WithField("count", pa.portAllocations[corev1.ProtocolUDP][0].ports+pa.portAllocations[corev1.ProtocolUDP][1].ports).Info("How many allocated")
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 3)
 		gs := pa.Allocate(fixture.DeepCopy())
 		assert.NotEmpty(t, gs.Spec.Ports[0].HostPort)
-		assert.Len(t, pa.portAllocations, 4)
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 4)
 	})
 
 	t.Run("ports are unique in a node", func(t *testing.T) {
 		fixture := dynamicGameServerFixture()
 		m := agtesting.NewMocks()
-		pa := NewPortAllocator(10, 20, m.KubeInformerFactory, m.AgonesInformerFactory)
+		pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 
 		m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
 			nl := &corev1.NodeList{Items: []corev1.Node{n1}}
@@ -222,12 +224,131 @@ func TestPortAllocatorAllocate(t *testing.T) {
 			ports = append(ports, gs.Spec.Ports[0].HostPort)
 		}
 	})
+
+	t.Run("host network", func(t *testing.T) {
+		m := agtesting.NewMocks()
+		pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
+		m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &corev1.NodeList{Items: []corev1.Node{n1}}, nil
+		})
+		_, cancel := agtesting.StartInformers(m, pa.nodeSynced)
+		defer cancel()
+		assert.Nil(t, pa.syncAll())
+
+		gs := dynamicGameServerFixture()
+		gs.Spec.Template.Spec.HostNetwork = true
+		gs.Spec.Ports[0].ContainerPort = 0
+		gs = pa.Allocate(gs)
+		assert.NotEmpty(t, gs.Spec.Ports[0].HostPort)
+		assert.Equal(t, gs.Spec.Ports[0].HostPort, gs.Spec.Ports[0].ContainerPort)
+	})
+
+	t.Run("tcp and udp ports are allocated independently", func(t *testing.T) {
+		m := agtesting.NewMocks()
+		pa := NewPortAllocator(10, 10, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
+
+		m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			nl := &corev1.NodeList{Items: []corev1.Node{n1}}
+			return true, nl, nil
+		})
+		_, cancel := agtesting.StartInformers(m, pa.nodeSynced)
+		defer cancel()
+		assert.Nil(t, pa.syncAll())
+
+		udp := dynamicGameServerFixture()
+		udp.Spec.Ports[0].Protocol = corev1.ProtocolUDP
+		tcp := dynamicGameServerFixture()
+		tcp.ObjectMeta.UID = "5678"
+		tcp.Spec.Ports[0].Protocol = corev1.ProtocolTCP
+
+		udp = pa.Allocate(udp)
+		tcp = pa.Allocate(tcp)
+		assert.Equal(t, int32(10), udp.Spec.Ports[0].HostPort)
+		assert.Equal(t, int32(10), tcp.Spec.Ports[0].HostPort)
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 1)
+		assert.Len(t, pa.portAllocations[corev1.ProtocolTCP], 1)
+
+		// a GameServer with both protocols fits on a node that has the port free for each of them
+		both := dynamicGameServerFixture()
+		both.ObjectMeta.UID = "9012"
+		both.Spec.Ports = append(both.Spec.Ports, agonesv1.GameServerPort{Name: "tcp", ContainerPort: 7778, PortPolicy: agonesv1.Dynamic, Protocol: corev1.ProtocolTCP})
+		pa.DeAllocate(udp)
+		both = pa.Allocate(both)
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 2)
+		assert.Len(t, pa.portAllocations[corev1.ProtocolTCP], 2)
+		assert.Equal(t, 3, countTotalAllocatedPorts(pa))
+
+		pa.DeAllocate(tcp)
+		assert.Equal(t, 2, countTotalAllocatedPorts(pa))
+		assert.True(t, pa.portAllocations[corev1.ProtocolUDP][0].isTaken(10) || pa.portAllocations[corev1.ProtocolUDP][1].isTaken(10))
+	})
+
+	t.Run("namespace port ranges", func(t *testing.T) {
+		m := agtesting.NewMocks()
+		pa := NewPortAllocator(10, 20, map[string]PortRange{"team-a": {MinPort: 30, MaxPort: 32}}, m.KubeInformerFactory, m.AgonesInformerFactory)
+
+		m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			nl := &corev1.NodeList{Items: []corev1.Node{n1}}
+			return true, nl, nil
+		})
+		_, cancel := agtesting.StartInformers(m, pa.nodeSynced)
+		defer cancel()
+		assert.Nil(t, pa.syncAll())
+
+		teamA := dynamicGameServerFixture()
+		teamA.ObjectMeta.Namespace = "team-a"
+		var ports []int32
+		for i := 0; i < 3; i++ {
+			gs := pa.Allocate(teamA.DeepCopy())
+			assert.True(t, 30 <= gs.Spec.Ports[0].HostPort && gs.Spec.Ports[0].HostPort <= 32, "%v is not between 30 and 32", gs.Spec.Ports[0].HostPort)
+			assert.NotContains(t, ports, gs.Spec.Ports[0].HostPort)
+			ports = append(ports, gs.Spec.Ports[0].HostPort)
+		}
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 1)
+
+		// the range of the namespace is full on the node, so a node is added
+		gs := pa.Allocate(teamA.DeepCopy())
+		assert.True(t, 30 <= gs.Spec.Ports[0].HostPort && gs.Spec.Ports[0].HostPort <= 32, "%v is not between 30 and 32", gs.Spec.Ports[0].HostPort)
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 2)
+
+		// other namespaces use the default range
+		for i := 0; i < 11; i++ {
+			gs := pa.Allocate(dynamicGameServerFixture())
+			assert.True(t, 10 <= gs.Spec.Ports[0].HostPort && gs.Spec.Ports[0].HostPort <= 20, "%v is not between 10 and 20", gs.Spec.Ports[0].HostPort)
+		}
+		assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 2)
+		assert.Equal(t, 15, countTotalAllocatedPorts(pa))
+
+		pa.DeAllocate(gs)
+		assert.Equal(t, 14, countTotalAllocatedPorts(pa))
+	})
+}
+
+func TestPortAllocatorUtilization(t *testing.T) {
+	t.Parallel()
+
+	m := agtesting.NewMocks()
+	pa := NewPortAllocator(10, 19, map[string]PortRange{"team-a": {MinPort: 20, MaxPort: 24}}, m.KubeInformerFactory, m.AgonesInformerFactory)
+	nodeWatch := watch.NewFake()
+	m.KubeClient.AddWatchReactor("nodes", k8stesting.DefaultWatchReactor(nodeWatch, nil))
+
+	stop, cancel := agtesting.StartInformers(m, pa.nodeSynced)
+	defer cancel()
+
+	nodeWatch.Add(&n1)
+	nodeWatch.Add(&n2)
+	assert.True(t, cache.WaitForCacheSync(stop, pa.nodeSynced))
+	assert.Nil(t, pa.syncAll())
+	assert.Equal(t, PortUtilization{Ports: 60}, pa.Utilization())
+
+	pa.Allocate(dynamicGameServerFixture())
+	assert.Equal(t, PortUtilization{Ports: 60, Allocated: 1}, pa.Utilization())
 }
 
 func TestPortAllocatorMultithreadAllocate(t *testing.T) {
 	fixture := dynamicGameServerFixture()
 	m := agtesting.NewMocks()
-	pa := NewPortAllocator(10, 20, m.KubeInformerFactory, m.AgonesInformerFactory)
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 
 	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
 		nl := &corev1.NodeList{Items: []corev1.Node{n1, n2}}
@@ -259,12 +380,39 @@ func TestPortAllocatorMultithreadAllocate(t *testing.T) {
 	wg.Wait()
 }
 
+func BenchmarkPortAllocatorAllocate(b *testing.B) {
+	m := agtesting.NewMocks()
+	pa := NewPortAllocator(7000, 8000, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
+	nodes := make([]corev1.Node, 50)
+	for i := range nodes {
+		nodes[i] = corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node" + strconv.Itoa(i)}}
+	}
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: nodes}, nil
+	})
+	_, cancel := agtesting.StartInformers(m, pa.nodeSynced)
+	defer cancel()
+	assert.Nil(b, pa.syncAll())
+
+	fixture := dynamicGameServerFixture()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		gs := fixture.DeepCopy()
+		gs.ObjectMeta.UID = types.UID(strconv.Itoa(i))
+		gs = pa.Allocate(gs)
+		// keep the nodes about half full
+		if i%2 == 0 {
+			pa.DeAllocate(gs)
+		}
+	}
+}
+
 func TestPortAllocatorDeAllocate(t *testing.T) {
 	t.Parallel()
 
 	fixture := dynamicGameServerFixture()
 	m := agtesting.NewMocks()
-	pa := NewPortAllocator(10, 20, m.KubeInformerFactory, m.AgonesInformerFactory)
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 	nodes := []corev1.Node{n1, n2, n3}
 	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
 		nl := &corev1.NodeList{Items: nodes}
@@ -304,7 +452,7 @@ func TestPortAllocatorSyncPortAllocations(t *testing.T) {
 	t.Parallel()
 
 	m := agtesting.NewMocks()
-	pa := NewPortAllocator(10, 20, m.KubeInformerFactory, m.AgonesInformerFactory)
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 
 	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
 		nl := &corev1.NodeList{Items: []corev1.Node{n1, n2, n3}}
@@ -352,7 +500,7 @@ func TestPortAllocatorSyncPortAllocations(t *testing.T) {
 	err := pa.syncAll()
 	assert.Nil(t, err)
 
-	assert.Len(t, pa.portAllocations, 3)
+	assert.Len(t, pa.portAllocations[corev1.ProtocolUDP], 3)
 	assert.Len(t, pa.gameServerRegistry, 5)
 
 	// count the number of allocated ports,
@@ -395,7 +543,7 @@ func TestPortAllocatorSyncDeleteGameServer(t *testing.T) {
 		},
 		Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady, Ports: []agonesv1.GameServerStatusPort{{Port: 10}}, NodeName: n2.ObjectMeta.Name}}
 
-	pa := NewPortAllocator(10, 20, m.KubeInformerFactory, m.AgonesInformerFactory)
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 
 	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
 		nl := &corev1.NodeList{Items: []corev1.Node{n1, n2, n3}}
@@ -437,13 +585,194 @@ func TestPortAllocatorSyncDeleteGameServer(t *testing.T) {
 	assert.Equal(t, 1, countAllocatedPorts(pa, 10))
 	assert.Equal(t, 1, countAllocatedPorts(pa, 11))
 	pa.mutex.RUnlock()
+
+	// delete with a version from before the ports were allocated, the reserved port should still be released
+	stale := gs2.DeepCopy()
+	stale.Spec.Ports[0].HostPort = 0
+	gsWatch.Delete(stale)
+	assert.True(t, cache.WaitForCacheSync(stop, pa.gameServerSynced))
+	assert.True(t, waitForPorts(pa, 11, 0))
+	pa.mutex.RLock() // reading mutable state, so read lock
+	assert.Equal(t, 1, countAllocatedPorts(pa, 10))
+	assert.Len(t, pa.gameServerRegistry, 1)
+	pa.mutex.RUnlock()
+
+	// tombstones release the ports as well
+	pa.syncDeleteGameServer(cache.DeletedFinalStateUnknown{Key: "default/gs1", Obj: gs1.DeepCopy()})
+	pa.mutex.RLock() // reading mutable state, so read lock
+	assert.Equal(t, 0, countAllocatedPorts(pa, 10))
+	assert.Len(t, pa.gameServerRegistry, 0)
+	pa.mutex.RUnlock()
+}
+
+func TestPortAllocatorSyncDeleteGameServerDeferredRelease(t *testing.T) {
+	t.Parallel()
+
+	m := agtesting.NewMocks()
+	gsWatch := watch.NewFake()
+	podWatch := watch.NewFake()
+	m.AgonesClient.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(gsWatch, nil))
+	m.KubeClient.AddWatchReactor("pods", k8stesting.DefaultWatchReactor(podWatch, nil))
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: []corev1.Node{n1}}, nil
+	})
+
+	gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "gs1", Namespace: "default", UID: "1"},
+		Spec: agonesv1.GameServerSpec{
+			Ports: []agonesv1.GameServerPort{{PortPolicy: agonesv1.Dynamic, HostPort: 10}},
+		},
+		Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady, NodeName: n1.ObjectMeta.Name}}
+	pod := gameServerPodFixture(gs)
+
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
+	stop, cancel := agtesting.StartInformers(m, pa.gameServerSynced, pa.nodeSynced, pa.podSynced)
+	defer cancel()
+
+	gsWatch.Add(gs.DeepCopy())
+	podWatch.Add(pod.DeepCopy())
+	assert.True(t, cache.WaitForCacheSync(stop, pa.gameServerSynced, pa.podSynced))
+	assert.True(t, wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := pa.podLister.Pods(pod.ObjectMeta.Namespace).Get(pod.ObjectMeta.Name)
+		return err == nil, nil
+	}) == nil)
+	assert.Nil(t, pa.syncAll())
+	assert.Equal(t, 1, countAllocatedPorts(pa, 10))
+
+	// the Pod is still terminating, so the port is still in use
+	gsWatch.Delete(gs.DeepCopy())
+	assert.True(t, wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		_, err := pa.gameServerLister.GameServers(gs.ObjectMeta.Namespace).Get(gs.ObjectMeta.Name)
+		return err != nil, nil
+	}) == nil)
+	pa.mutex.RLock() // reading mutable state, so read lock
+	assert.Equal(t, 1, countAllocatedPorts(pa, 10))
+	assert.Len(t, pa.gameServerRegistry, 1)
+	pa.mutex.RUnlock()
+
+	// the port is not reclaimed either
+	pa.reclaimOrphanedPorts()
+	pa.mutex.RLock() // reading mutable state, so read lock
+	assert.Equal(t, 1, countAllocatedPorts(pa, 10))
+	pa.mutex.RUnlock()
+
+	// nor lost on a resync
+	assert.Nil(t, pa.syncAll())
+	pa.mutex.RLock() // reading mutable state, so read lock
+	assert.Equal(t, 1, countAllocatedPorts(pa, 10))
+	assert.Len(t, pa.gameServerRegistry, 1)
+	pa.mutex.RUnlock()
+
+	podWatch.Delete(pod.DeepCopy())
+	assert.True(t, waitForPorts(pa, 10, 0))
+	pa.mutex.RLock() // reading mutable state, so read lock
+	assert.Len(t, pa.gameServerRegistry, 0)
+	pa.mutex.RUnlock()
+}
+
+func TestPortAllocatorSyncDeletePod(t *testing.T) {
+	t.Parallel()
+
+	m := agtesting.NewMocks()
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{*dynamicGameServerFixture()}}, nil
+	})
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: []corev1.Node{n1}}, nil
+	})
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
+	_, cancel := agtesting.StartInformers(m, pa.gameServerSynced, pa.nodeSynced)
+	defer cancel()
+	assert.Nil(t, pa.syncAll())
+
+	existing := pa.Allocate(dynamicGameServerFixture())
+	deleted := dynamicGameServerFixture()
+	deleted.ObjectMeta.Name = "deleted"
+	deleted.ObjectMeta.UID = "deleted"
+	deleted = pa.Allocate(deleted)
+	assert.Equal(t, 2, countTotalAllocatedPorts(pa))
+
+	// the GameServer of the Pod still exists, so it keeps its ports
+	pa.syncDeletePod(gameServerPodFixture(existing))
+	assert.Equal(t, 2, countTotalAllocatedPorts(pa))
+
+	pa.syncDeletePod(cache.DeletedFinalStateUnknown{Key: "default/deleted", Obj: gameServerPodFixture(deleted)})
+	assert.Equal(t, 1, countTotalAllocatedPorts(pa))
+	assert.Equal(t, 0, countAllocatedPorts(pa, deleted.Spec.Ports[0].HostPort))
+
+	// Pods that are not of GameServers are ignored
+	pod := gameServerPodFixture(existing)
+	pod.ObjectMeta.OwnerReferences = nil
+	pa.syncDeletePod(pod)
+	assert.Equal(t, 1, countTotalAllocatedPorts(pa))
+}
+
+func TestPortAllocatorDeAllocateRetriedAllocation(t *testing.T) {
+	t.Parallel()
+
+	fixture := dynamicGameServerFixture()
+	m := agtesting.NewMocks()
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: []corev1.Node{n1}}, nil
+	})
+	_, cancel := agtesting.StartInformers(m, pa.nodeSynced)
+	defer cancel()
+	err := pa.syncAll()
+	assert.Nil(t, err)
+
+	// the first allocation was stored, but the GameServer was allocated again
+	// from a stale version, and storing that failed
+	first := pa.Allocate(fixture.DeepCopy())
+	second := pa.Allocate(fixture.DeepCopy())
+	assert.NotEqual(t, first.Spec.Ports[0].HostPort, second.Spec.Ports[0].HostPort)
+	pa.DeAllocate(second)
+
+	assert.Equal(t, 1, countAllocatedPorts(pa, first.Spec.Ports[0].HostPort))
+	assert.Equal(t, 0, countAllocatedPorts(pa, second.Spec.Ports[0].HostPort))
+	assert.Equal(t, []hostPort{{port: first.Spec.Ports[0].HostPort, protocol: corev1.ProtocolUDP}}, pa.gameServerRegistry[fixture.ObjectMeta.UID])
+}
+
+func TestPortAllocatorReclaimOrphanedPorts(t *testing.T) {
+	t.Parallel()
+
+	m := agtesting.NewMocks()
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
+
+	existing := dynamicGameServerFixture()
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: []corev1.Node{n1}}, nil
+	})
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{*existing}}, nil
+	})
+	_, cancel := agtesting.StartInformers(m, pa.gameServerSynced, pa.nodeSynced)
+	defer cancel()
+	err := pa.syncAll()
+	assert.Nil(t, err)
+
+	gs := pa.Allocate(existing.DeepCopy())
+	orphan := existing.DeepCopy()
+	orphan.ObjectMeta.Name = "orphan"
+	orphan.ObjectMeta.UID = "orphan"
+	orphan = pa.Allocate(orphan)
+	assert.Equal(t, 2, countTotalAllocatedPorts(pa))
+
+	pa.reclaimOrphanedPorts()
+	assert.Equal(t, 1, countTotalAllocatedPorts(pa))
+	assert.Equal(t, 1, countAllocatedPorts(pa, gs.Spec.Ports[0].HostPort))
+	assert.Equal(t, 0, countAllocatedPorts(pa, orphan.Spec.Ports[0].HostPort))
+	assert.Len(t, pa.gameServerRegistry, 1)
+
+	// nothing else to reclaim
+	pa.reclaimOrphanedPorts()
+	assert.Equal(t, 1, countTotalAllocatedPorts(pa))
 }
 
 func TestNodePortAllocation(t *testing.T) {
 	t.Parallel()
 
 	m := agtesting.NewMocks()
-	pa := NewPortAllocator(10, 20, m.KubeInformerFactory, m.AgonesInformerFactory)
+	pa := NewPortAllocator(10, 20, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 	nodes := []corev1.Node{n1, n2, n3}
 	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
 		nl := &corev1.NodeList{Items: nodes}
@@ -454,9 +783,10 @@ func TestNodePortAllocation(t *testing.T) {
 	for _, n := range nodes {
 		ports, ok := result[n.ObjectMeta.Name]
 		assert.True(t, ok, "Should have a port allocation for %s", n.ObjectMeta.Name)
-		assert.Len(t, ports, 11)
-		for _, v := range ports {
-			assert.False(t, v)
+		assert.Equal(t, 11, ports.ports)
+		assert.Equal(t, 0, ports.allocated)
+		for p := int32(10); p <= 20; p++ {
+			assert.False(t, ports.isTaken(p))
 		}
 	}
 }
@@ -464,23 +794,32 @@ func TestNodePortAllocation(t *testing.T) {
 func TestTakePortAllocation(t *testing.T) {
 	t.Parallel()
 
-	fixture := []portAllocation{{1: false, 2: false}, {1: false, 2: false}, {1: false, 3: false}}
+	ranges := []PortRange{{MinPort: 1, MaxPort: 3}}
+	fixture := []*portAllocation{newPortAllocation(ranges), newPortAllocation(ranges), newPortAllocation(ranges)}
 	result := setPortAllocation(2, fixture, true)
-	assert.True(t, result[0][2])
+	assert.True(t, result[0].isTaken(2))
 
 	for i, row := range fixture {
-		for p, taken := range row {
-			if i != 0 && p != 2 {
-				assert.False(t, taken, fmt.Sprintf("row %d and port %d should be false", i, p))
+		for p := int32(1); p <= 3; p++ {
+			if i != 0 || p != 2 {
+				assert.False(t, row.isTaken(p), fmt.Sprintf("row %d and port %d should be false", i, p))
 			}
 		}
 	}
+
+	result = setPortAllocation(2, fixture, true)
+	assert.True(t, result[1].isTaken(2))
+	assert.False(t, result[2].isTaken(2))
+
+	result = setPortAllocation(2, fixture, false)
+	assert.False(t, result[0].isTaken(2))
+	assert.True(t, result[1].isTaken(2))
 }
 
 func TestPortAllocatorRegisterExistingGameServerPorts(t *testing.T) {
 	t.Parallel()
 	m := agtesting.NewMocks()
-	pa := NewPortAllocator(10, 13, m.KubeInformerFactory, m.AgonesInformerFactory)
+	pa := NewPortAllocator(10, 13, nil, m.KubeInformerFactory, m.AgonesInformerFactory)
 
 	gs1 := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "gs1", UID: "1"},
 		Spec: agonesv1.GameServerSpec{
@@ -506,12 +845,22 @@ func TestPortAllocatorRegisterExistingGameServerPorts(t *testing.T) {
 		},
 		Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStatePortAllocation, Ports: []agonesv1.GameServerStatusPort{{Port: 13}}}}
 
-	allocations, nonReadyNodesPorts := pa.registerExistingGameServerPorts([]*agonesv1.GameServer{gs1, gs2, gs3, gs4}, []*corev1.Node{&n1, &n2, &n3}, map[types.UID]bool{})
+	allocations, nonReadyNodesPorts := pa.registerExistingGameServerPorts([]*agonesv1.GameServer{gs1, gs2, gs3, gs4}, []*corev1.Node{&n1, &n2, &n3}, map[types.UID][]hostPort{})
 
-	assert.Equal(t, []int32{13}, nonReadyNodesPorts)
-	assert.Equal(t, portAllocation{10: true, 11: false, 12: true, 13: false}, allocations[0])
-	assert.Equal(t, portAllocation{10: false, 11: true, 12: false, 13: false}, allocations[1])
-	assert.Equal(t, portAllocation{10: false, 11: false, 12: false, 13: false}, allocations[2])
+	assert.Equal(t, []hostPort{{port: 13, protocol: corev1.ProtocolUDP}}, nonReadyNodesPorts)
+	taken := func(n *portAllocation) []int32 {
+		var ports []int32
+		for p := int32(10); p <= 13; p++ {
+			if n.isTaken(p) {
+				ports = append(ports, p)
+			}
+		}
+		return ports
+	}
+	assert.Equal(t, []int32{10, 12}, taken(allocations[corev1.ProtocolUDP][0]))
+	assert.Equal(t, []int32{11}, taken(allocations[corev1.ProtocolUDP][1]))
+	assert.Empty(t, taken(allocations[corev1.ProtocolUDP][2]))
+	assert.Empty(t, taken(allocations[corev1.ProtocolTCP][0]))
 }
 
 func dynamicGameServerFixture() *agonesv1.GameServer {
@@ -522,13 +871,43 @@ func dynamicGameServerFixture() *agonesv1.GameServer {
 	}
 }
 
+// gameServerPodFixture returns a Pod of the GameServer with its host ports
+func gameServerPodFixture(gs *agonesv1.GameServer) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            gs.ObjectMeta.Name,
+			Namespace:       gs.ObjectMeta.Namespace,
+			Labels:          map[string]string{agonesv1.RoleLabel: agonesv1.GameServerLabelRole},
+			OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(gs, agonesv1.SchemeGroupVersion.WithKind("GameServer"))},
+		},
+		Spec: corev1.PodSpec{NodeName: gs.Status.NodeName, Containers: []corev1.Container{{Name: "container"}}},
+	}
+	for _, p := range gs.Spec.Ports {
+		pod.Spec.Containers[0].Ports = append(pod.Spec.Containers[0].Ports,
+			corev1.ContainerPort{ContainerPort: p.ContainerPort, HostPort: p.HostPort, Protocol: p.Protocol})
+	}
+	return pod
+}
+
+// waitForPorts waits for the count of allocated ports for port p to be count
+func waitForPorts(pa *PortAllocator, p int32, count int) bool {
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		pa.mutex.RLock()
+		defer pa.mutex.RUnlock()
+		return countAllocatedPorts(pa, p) == count, nil
+	})
+	return err == nil
+}
+
 // countAllocatedPorts counts how many of a given port have been
-// allocated across nodes
+// allocated across nodes and protocols
 func countAllocatedPorts(pa *PortAllocator, p int32) int {
 	count := 0
-	for _, node := range pa.portAllocations {
-		if node[p] {
-			count++
+	for _, allocations := range pa.portAllocations {
+		for _, node := range allocations {
+			if node.isTaken(p) {
+				count++
+			}
 		}
 	}
 	return count
@@ -537,11 +916,9 @@ func countAllocatedPorts(pa *PortAllocator, p int32) int {
 // countTotalAllocatedPorts counts the total number of allocated ports
 func countTotalAllocatedPorts(pa *PortAllocator) int {
 	count := 0
-	for _, node := range pa.portAllocations {
-		for _, alloc := range node {
-			if alloc {
-				count++
-			}
+	for _, allocations := range pa.portAllocations {
+		for _, node := range allocations {
+			count += node.allocated
 		}
 	}
 	return count
@@ -0,0 +1,126 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/util/runtime"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corelisterv1 "k8s.io/client-go/listers/core/v1"
+)
+
+// defaultPreemptionNotice is how long before its deadline a node is assumed to be given notice of its preemption,
+// when neither its PreemptionDeadlineAnnotation nor its taint tell when it is preempted. It is the notice that
+// GCE gives preemptible VMs, the shortest of the cloud providers.
+const defaultPreemptionNotice = 30 * time.Second
+
+// DefaultPreemptionTaints are the taints that the node termination handlers of the cloud providers put on nodes
+// that are about to be preempted
+var DefaultPreemptionTaints = []string{
+	"cloud.google.com/impending-node-termination",
+	"aws-node-termination-handler/spot-itn",
+}
+
+// NodePreemptions tracks the nodes that have been given notice that they are about to be preempted, such as spot
+// or preemptible VMs, from the taints that node termination handlers put on them.
+// A DaemonSet that surfaces the preemption notices can set the PreemptionDeadlineAnnotation on the node, to the
+// RFC3339 time it is preempted at.
+type NodePreemptions struct {
+	logger     *logrus.Entry
+	taints     map[string]bool
+	nodeLister corelisterv1.NodeLister
+}
+
+// NewNodePreemptions returns a NodePreemptions for the nodes with one of the taints
+func NewNodePreemptions(kubeInformerFactory informers.SharedInformerFactory, taints []string) *NodePreemptions {
+	p := &NodePreemptions{
+		taints:     map[string]bool{},
+		nodeLister: kubeInformerFactory.Core().V1().Nodes().Lister(),
+	}
+	p.logger = runtime.NewLoggerWithType(p)
+	for _, t := range taints {
+		p.taints[t] = true
+	}
+	return p
+}
+
+// Deadline returns when the node is preempted, and false if it has not been given notice that it is preempted
+func (p *NodePreemptions) Deadline(nodeName string, now time.Time) (time.Time, bool) {
+	if nodeName == "" {
+		return time.Time{}, false
+	}
+	node, err := p.nodeLister.Get(nodeName)
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			p.logger.WithError(err).WithField("node", nodeName).Warn("error retrieving node for its preemption notice")
+		}
+		return time.Time{}, false
+	}
+	return p.nodeDeadline(node, now)
+}
+
+// PreemptedNodes returns the names of the nodes that have been given notice that they are preempted
+func (p *NodePreemptions) PreemptedNodes() map[string]bool {
+	result := map[string]bool{}
+	if len(p.taints) == 0 {
+		return result
+	}
+	nodes, err := p.nodeLister.List(labels.Everything())
+	if err != nil {
+		p.logger.WithError(err).Warn("error listing nodes for their preemption notices")
+		return result
+	}
+	for _, n := range nodes {
+		if p.preemptionTaint(n) != nil {
+			result[n.ObjectMeta.Name] = true
+		}
+	}
+	return result
+}
+
+// nodeDeadline returns when the node is preempted: the time of its PreemptionDeadlineAnnotation, or else
+// defaultPreemptionNotice after its taint was added, or after now if that is not known
+func (p *NodePreemptions) nodeDeadline(node *corev1.Node, now time.Time) (time.Time, bool) {
+	taint := p.preemptionTaint(node)
+	if taint == nil {
+		return time.Time{}, false
+	}
+	if v, ok := node.ObjectMeta.Annotations[agonesv1.PreemptionDeadlineAnnotation]; ok {
+		if deadline, err := time.Parse(time.RFC3339, v); err == nil {
+			return deadline, true
+		}
+		p.logger.WithField("node", node.ObjectMeta.Name).WithField("value", v).Warn("invalid preemption deadline annotation on node")
+	}
+	if taint.TimeAdded != nil {
+		return taint.TimeAdded.Add(defaultPreemptionNotice), true
+	}
+	return now.Add(defaultPreemptionNotice), true
+}
+
+// preemptionTaint returns the preemption taint of the node, or nil if it has none
+func (p *NodePreemptions) preemptionTaint(node *corev1.Node) *corev1.Taint {
+	for i, t := range node.Spec.Taints {
+		if p.taints[t.Key] {
+			return &node.Spec.Taints[i]
+		}
+	}
+	return nil
+}
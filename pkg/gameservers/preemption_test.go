@@ -0,0 +1,85 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestNodePreemptions(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2019, time.July, 10, 10, 30, 0, 0, time.UTC)
+	added := metav1.NewTime(now.Add(-10 * time.Second))
+	preemptionTaint := corev1.Taint{Key: DefaultPreemptionTaints[0], Effect: corev1.TaintEffectNoSchedule}
+	nodes := []corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "healthy"},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "agones.dev/gameservers", Effect: corev1.TaintEffectNoExecute}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "tainted"},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{preemptionTaint}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "added"},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: DefaultPreemptionTaints[1], Effect: corev1.TaintEffectNoExecute, TimeAdded: &added}}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "annotated", Annotations: map[string]string{agonesv1.PreemptionDeadlineAnnotation: "2019-07-10T10:32:00Z"}},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{preemptionTaint}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "invalid", Annotations: map[string]string{agonesv1.PreemptionDeadlineAnnotation: "soon"}},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{preemptionTaint}}},
+	}
+
+	m := agtesting.NewMocks()
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: nodes}, nil
+	})
+	p := NewNodePreemptions(m.KubeInformerFactory, DefaultPreemptionTaints)
+	stop, cancel := agtesting.StartInformers(m)
+	defer cancel()
+	assert.True(t, cache.WaitForCacheSync(stop, m.KubeInformerFactory.Core().V1().Nodes().Informer().HasSynced))
+
+	assert.Equal(t, map[string]bool{"tainted": true, "added": true, "annotated": true, "invalid": true}, p.PreemptedNodes())
+
+	fixtures := map[string]struct {
+		deadline  time.Time
+		preempted bool
+	}{
+		"healthy":   {},
+		"missing":   {},
+		"":          {},
+		"tainted":   {deadline: now.Add(defaultPreemptionNotice), preempted: true},
+		"added":     {deadline: added.Add(defaultPreemptionNotice), preempted: true},
+		"annotated": {deadline: time.Date(2019, time.July, 10, 10, 32, 0, 0, time.UTC), preempted: true},
+		"invalid":   {deadline: now.Add(defaultPreemptionNotice), preempted: true},
+	}
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			deadline, ok := p.Deadline(k, now)
+			assert.Equal(t, v.preempted, ok)
+			assert.True(t, v.deadline.Equal(deadline), "expected %s, got %s", v.deadline, deadline)
+		})
+	}
+
+	none := NewNodePreemptions(m.KubeInformerFactory, nil)
+	assert.Empty(t, none.PreemptedNodes())
+	_, ok := none.Deadline("tainted", now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ImagePullSecrets are the names of the image pull secrets that are added to the Pods of GameServers,
+// keyed by the namespace of the GameServers, or by "namespace/fleet" for the GameServers of a Fleet
+type ImagePullSecrets map[string][]string
+
+// forGameServer returns the image pull secrets of the Fleet of the GameServer, if there are any,
+// and the ones of its namespace otherwise
+func (s ImagePullSecrets) forGameServer(gs *agonesv1.GameServer) []string {
+	if fleet, ok := gs.ObjectMeta.Labels[agonesv1.FleetNameLabel]; ok && fleet != "" {
+		if secrets, ok := s[gs.ObjectMeta.Namespace+"/"+fleet]; ok {
+			return secrets
+		}
+	}
+	return s[gs.ObjectMeta.Namespace]
+}
+
+// applyImagePullSecrets adds the image pull secrets that are configured for the GameServer to its Pod template,
+// unless the template references them already
+func (c *Controller) applyImagePullSecrets(gs *agonesv1.GameServer) {
+	podSpec := &gs.Spec.Template.Spec
+	for _, name := range c.imagePullSecrets.forGameServer(gs) {
+		found := false
+		for _, ref := range podSpec.ImagePullSecrets {
+			if ref.Name == name {
+				found = true
+				break
+			}
+		}
+		if !found {
+			podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+		}
+	}
+}
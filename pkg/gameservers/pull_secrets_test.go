@@ -0,0 +1,102 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"encoding/json"
+	"testing"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"github.com/mattbaird/jsonpatch"
+	"github.com/stretchr/testify/assert"
+	admv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestControllerApplyImagePullSecrets(t *testing.T) {
+	t.Parallel()
+
+	c, _ := newFakeController()
+	c.imagePullSecrets = ImagePullSecrets{
+		"team-a":         {"registry-a", "registry-b"},
+		"team-a/fleet-1": {"registry-c"},
+	}
+
+	fixtures := map[string]struct {
+		namespace string
+		labels    map[string]string
+		existing  []corev1.LocalObjectReference
+		expected  []corev1.LocalObjectReference
+	}{
+		"namespace": {
+			namespace: "team-a",
+			expected:  []corev1.LocalObjectReference{{Name: "registry-a"}, {Name: "registry-b"}},
+		},
+		"fleet": {
+			namespace: "team-a",
+			labels:    map[string]string{agonesv1.FleetNameLabel: "fleet-1"},
+			expected:  []corev1.LocalObjectReference{{Name: "registry-c"}},
+		},
+		"fleet without its own secrets": {
+			namespace: "team-a",
+			labels:    map[string]string{agonesv1.FleetNameLabel: "fleet-2"},
+			expected:  []corev1.LocalObjectReference{{Name: "registry-a"}, {Name: "registry-b"}},
+		},
+		"already referenced": {
+			namespace: "team-a",
+			existing:  []corev1.LocalObjectReference{{Name: "registry-b"}, {Name: "other"}},
+			expected:  []corev1.LocalObjectReference{{Name: "registry-b"}, {Name: "other"}, {Name: "registry-a"}},
+		},
+		"other namespace": {
+			namespace: "team-b",
+			existing:  []corev1.LocalObjectReference{{Name: "other"}},
+			expected:  []corev1.LocalObjectReference{{Name: "other"}},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: v.namespace, Labels: v.labels},
+				Spec: newSingleContainerSpec()}
+			gs.Spec.Template.Spec.ImagePullSecrets = v.existing
+			c.applyImagePullSecrets(gs)
+			assert.Equal(t, v.expected, gs.Spec.Template.Spec.ImagePullSecrets)
+		})
+	}
+
+	t.Run("mutation handler", func(t *testing.T) {
+		fixture := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "team-a"},
+			Spec: newSingleContainerSpec()}
+		raw, err := json.Marshal(fixture)
+		assert.NoError(t, err)
+		review := admv1beta1.AdmissionReview{
+			Request: &admv1beta1.AdmissionRequest{
+				Kind:      GameServerKind,
+				Operation: admv1beta1.Create,
+				Object:    runtime.RawExtension{Raw: raw},
+			},
+			Response: &admv1beta1.AdmissionResponse{Allowed: true},
+		}
+
+		result, err := c.creationMutationHandler(review)
+		assert.NoError(t, err)
+		patch := jsonpatch.ByPath{}
+		assert.NoError(t, json.Unmarshal(result.Response.Patch, &patch))
+		assert.Contains(t, patch, jsonpatch.JsonPatchOperation{Operation: "add", Path: "/spec/template/spec/imagePullSecrets",
+			Value: []interface{}{map[string]interface{}{"name": "registry-a"}, map[string]interface{}{"name": "registry-b"}}})
+	})
+}
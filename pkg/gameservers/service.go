@@ -0,0 +1,130 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// usesHostPorts returns true if the ports of the GameServer are allocated host ports, which they are not when
+// the cloud product does not let Pods use host ports, or when they are exposed through a Service
+func (c *Controller) usesHostPorts(gs *agonesv1.GameServer) bool {
+	return c.cloudProduct.UsesHostPorts() && gs.Spec.ServiceType == ""
+}
+
+// serviceEventHandler enqueues the owning GameServer of a Service that was changed, so that its address
+// is populated once a LoadBalancer Service has an ingress
+func (c *Controller) serviceEventHandler(obj interface{}) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return
+	}
+	ref := metav1.GetControllerOf(svc)
+	if ref == nil || ref.APIVersion != agonesv1.SchemeGroupVersion.String() || ref.Kind != "GameServer" {
+		return
+	}
+	c.workerqueue.Enqueue(cache.ExplicitKey(svc.ObjectMeta.Namespace + "/" + ref.Name))
+}
+
+// syncGameServerService creates the Service that exposes the ports of the GameServer, if it has a ServiceType
+// and the Service does not exist yet. A Service of the same name that the GameServer does not control is an error,
+// as the GameServer cannot be reached through it.
+func (c *Controller) syncGameServerService(gs *agonesv1.GameServer) error {
+	desired := gs.Service()
+	if desired == nil {
+		return nil
+	}
+
+	svc, err := c.serviceLister.Services(gs.ObjectMeta.Namespace).Get(desired.ObjectMeta.Name)
+	if err == nil {
+		if !metav1.IsControlledBy(svc, gs) {
+			c.recorder.Eventf(gs, corev1.EventTypeWarning, "ServiceConflict",
+				"Service %s already exists and is not controlled by the GameServer", svc.ObjectMeta.Name)
+			return errors.Errorf("Service %s is not controlled by GameServer %s", svc.ObjectMeta.Name, gs.ObjectMeta.Name)
+		}
+		return nil
+	}
+	if !k8serrors.IsNotFound(err) {
+		return errors.Wrapf(err, "error retrieving Service of GameServer %s", gs.ObjectMeta.Name)
+	}
+
+	if _, err := c.serviceGetter.Services(desired.ObjectMeta.Namespace).Create(desired); err != nil && !k8serrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "error creating Service for GameServer %s", gs.ObjectMeta.Name)
+	}
+	c.recorder.Eventf(gs, corev1.EventTypeNormal, string(gs.Status.State), "Service %s created", desired.ObjectMeta.Name)
+	return nil
+}
+
+// applyGameServerServiceAddressAndPort sets the Address and Ports of the GameServer to those of its Service.
+// These are the address of the node and the node ports of a NodePort Service, or the ingress and the ports
+// of a LoadBalancer Service, which is an error until the load balancer is provisioned.
+func (c *Controller) applyGameServerServiceAddressAndPort(gs *agonesv1.GameServer, pod *corev1.Pod) (*agonesv1.GameServer, error) {
+	svc, err := c.serviceLister.Services(gs.ObjectMeta.Namespace).Get(gs.ObjectMeta.Name)
+	if err != nil {
+		return gs, errors.Wrapf(err, "error retrieving Service of GameServer %s", gs.ObjectMeta.Name)
+	}
+
+	var addr string
+	var addresses []corev1.NodeAddress
+	switch svc.Spec.Type {
+	case corev1.ServiceTypeLoadBalancer:
+		// a dual-stack load balancer has an ingress for each IP family
+		for _, ingress := range svc.Status.LoadBalancer.Ingress {
+			if ingress.IP != "" {
+				addresses = append(addresses, corev1.NodeAddress{Type: corev1.NodeExternalIP, Address: ingress.IP})
+			} else if ingress.Hostname != "" {
+				addresses = append(addresses, corev1.NodeAddress{Type: corev1.NodeExternalDNS, Address: ingress.Hostname})
+			}
+		}
+		if len(addresses) == 0 {
+			return gs, errors.Errorf("Service %s of GameServer %s does not have a load balancer ingress yet", svc.ObjectMeta.Name, gs.ObjectMeta.Name)
+		}
+		addr = addresses[0].Address
+	default:
+		addr, addresses, err = c.address(gs, pod)
+		if err != nil {
+			return gs, errors.Wrapf(err, "error getting external address for GameServer %s", gs.ObjectMeta.Name)
+		}
+	}
+
+	servicePorts := make(map[string]corev1.ServicePort, len(svc.Spec.Ports))
+	for _, p := range svc.Spec.Ports {
+		servicePorts[p.Name] = p
+	}
+	ports := make([]agonesv1.GameServerStatusPort, len(gs.Spec.Ports))
+	for i, p := range gs.Spec.Ports {
+		sp, ok := servicePorts[agonesv1.ServicePortName(i)]
+		if !ok {
+			return gs, errors.Errorf("Service %s of GameServer %s does not expose port %s", svc.ObjectMeta.Name, gs.ObjectMeta.Name, p.Name)
+		}
+		ports[i] = p.Status()
+		if svc.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			ports[i].Port = sp.Port
+		} else {
+			ports[i].Port = sp.NodePort
+		}
+	}
+
+	gs.Status.Address = addr
+	gs.Status.Addresses = addresses
+	gs.Status.NodeName = pod.Spec.NodeName
+	gs.Status.Ports = ports
+	return gs, nil
+}
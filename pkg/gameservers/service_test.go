@@ -0,0 +1,161 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"testing"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestControllerSyncGameServerService(t *testing.T) {
+	t.Parallel()
+
+	fixture := func(serviceType corev1.ServiceType) *agonesv1.GameServer {
+		gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "1234"},
+			Spec: newSingleContainerSpec(), Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateCreating}}
+		gs.Spec.ServiceType = serviceType
+		gs.ApplyDefaults()
+		return gs
+	}
+	// run syncs the Service of the GameServer against the existing Services, and returns the Service it created
+	run := func(t *testing.T, gs *agonesv1.GameServer, services ...corev1.Service) (*corev1.Service, agtesting.Mocks, error) {
+		c, m := newFakeController()
+		m.KubeClient.AddReactor("list", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &corev1.ServiceList{Items: services}, nil
+		})
+		var created *corev1.Service
+		m.KubeClient.AddReactor("create", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			created = action.(k8stesting.CreateAction).GetObject().(*corev1.Service)
+			return true, created, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.serviceSynced)
+		defer cancel()
+
+		err := c.syncGameServerService(gs)
+		return created, m, err
+	}
+
+	t.Run("no service type", func(t *testing.T) {
+		created, _, err := run(t, fixture(""))
+		assert.NoError(t, err)
+		assert.Nil(t, created)
+	})
+
+	t.Run("create", func(t *testing.T) {
+		gs := fixture(corev1.ServiceTypeNodePort)
+		created, m, err := run(t, gs)
+		assert.NoError(t, err)
+		if assert.NotNil(t, created) {
+			assert.Equal(t, gs.Service(), created)
+		}
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Service test created")
+	})
+
+	t.Run("exists", func(t *testing.T) {
+		gs := fixture(corev1.ServiceTypeNodePort)
+		created, _, err := run(t, gs, *gs.Service())
+		assert.NoError(t, err)
+		assert.Nil(t, created)
+	})
+
+	t.Run("conflict", func(t *testing.T) {
+		gs := fixture(corev1.ServiceTypeNodePort)
+		svc := gs.Service()
+		svc.ObjectMeta.OwnerReferences = nil
+		created, m, err := run(t, gs, *svc)
+		assert.Error(t, err)
+		assert.Nil(t, created)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "Warning ServiceConflict")
+	})
+}
+
+func TestControllerApplyGameServerServiceAddressAndPort(t *testing.T) {
+	t.Parallel()
+
+	node := corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: nodeFixtureName}, Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{{Address: ipFixture, Type: corev1.NodeExternalIP}}}}
+	fixture := func(serviceType corev1.ServiceType) (*agonesv1.GameServer, *corev1.Pod, *corev1.Service) {
+		gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default", UID: "1234"},
+			Spec: newSingleContainerSpec(), Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateStarting}}
+		gs.Spec.ServiceType = serviceType
+		gs.ApplyDefaults()
+		pod, err := gs.Pod()
+		assert.NoError(t, err)
+		pod.Spec.NodeName = node.ObjectMeta.Name
+		svc := gs.Service()
+		svc.Spec.Ports[0].NodePort = 31000
+		return gs, pod, svc
+	}
+	run := func(t *testing.T, gs *agonesv1.GameServer, pod *corev1.Pod, svc *corev1.Service) (*agonesv1.GameServer, error) {
+		c, m := newFakeController()
+		m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &corev1.NodeList{Items: []corev1.Node{node}}, nil
+		})
+		m.KubeClient.AddReactor("list", "services", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &corev1.ServiceList{Items: []corev1.Service{*svc}}, nil
+		})
+
+		_, cancel := agtesting.StartInformers(m, c.nodeSynced, c.serviceSynced)
+		defer cancel()
+
+		return c.applyGameServerAddressAndPort(gs, pod)
+	}
+
+	t.Run("node port", func(t *testing.T) {
+		gs, pod, svc := fixture(corev1.ServiceTypeNodePort)
+		gs, err := run(t, gs, pod, svc)
+		assert.NoError(t, err)
+		assert.Equal(t, ipFixture, gs.Status.Address)
+		assert.Equal(t, node.Status.Addresses, gs.Status.Addresses)
+		assert.Equal(t, node.ObjectMeta.Name, gs.Status.NodeName)
+		if assert.Len(t, gs.Status.Ports, 1) {
+			assert.Equal(t, int32(31000), gs.Status.Ports[0].Port)
+		}
+	})
+
+	t.Run("dual-stack load balancer", func(t *testing.T) {
+		gs, pod, svc := fixture(corev1.ServiceTypeLoadBalancer)
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "203.0.113.10"}, {IP: "2001:db8::10"}}
+		gs, err := run(t, gs, pod, svc)
+		assert.NoError(t, err)
+		assert.Equal(t, "203.0.113.10", gs.Status.Address)
+		assert.Equal(t, []corev1.NodeAddress{
+			{Type: corev1.NodeExternalIP, Address: "203.0.113.10"},
+			{Type: corev1.NodeExternalIP, Address: "2001:db8::10"},
+		}, gs.Status.Addresses)
+	})
+
+	t.Run("load balancer", func(t *testing.T) {
+		gs, pod, svc := fixture(corev1.ServiceTypeLoadBalancer)
+		_, err := run(t, gs.DeepCopy(), pod, svc)
+		assert.Error(t, err, "the load balancer does not have an ingress yet")
+
+		svc.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{Hostname: "lb.example.com"}}
+		gs, err = run(t, gs, pod, svc)
+		assert.NoError(t, err)
+		assert.Equal(t, "lb.example.com", gs.Status.Address)
+		assert.Equal(t, []corev1.NodeAddress{{Type: corev1.NodeExternalDNS, Address: "lb.example.com"}}, gs.Status.Addresses)
+		if assert.Len(t, gs.Status.Ports, 1) {
+			assert.Equal(t, gs.Spec.Ports[0].ContainerPort, gs.Status.Ports[0].Port)
+		}
+	})
+}
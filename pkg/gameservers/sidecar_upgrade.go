@@ -0,0 +1,81 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// sidecarUpgradesPerSecond is how many SDK servers are upgraded in place per second at most, so that
+// the SDK servers of all the GameServers do not restart at once when Agones is upgraded
+const sidecarUpgradesPerSecond = 5
+
+// syncGameServerSidecarUpgrade sets the image of the SDK server container of the Pod of a GameServer that is
+// serving, if in place upgrades are enabled and it runs another image than the sidecar image of the controller,
+// such as after Agones was upgraded. The kubelet then only restarts the SDK server container, so the game server
+// container keeps running, and its players stay connected. The SDK server reads the state of the GameServer back
+// when it restarts, so an Allocated GameServer stays Allocated.
+func (c *Controller) syncGameServerSidecarUpgrade(gs *agonesv1.GameServer) (*agonesv1.GameServer, error) {
+	if !c.sidecarUpgradeInPlace || gs.IsBeingDeleted() {
+		return gs, nil
+	}
+	switch gs.Status.State {
+	case agonesv1.GameServerStateReady, agonesv1.GameServerStateReserved, agonesv1.GameServerStateAllocated:
+	default:
+		return gs, nil
+	}
+	if _, isDev := gs.GetDevAddress(); isDev {
+		return gs, nil
+	}
+
+	pod, err := c.gameServerPod(gs)
+	if k8serrors.IsNotFound(err) {
+		return gs, nil
+	}
+	if err != nil {
+		return gs, err
+	}
+
+	index := -1
+	for i, container := range pod.Spec.Containers {
+		if container.Name == sidecarContainerName {
+			index = i
+			break
+		}
+	}
+	if index < 0 || pod.Spec.Containers[index].Image == c.sidecarImage {
+		return gs, nil
+	}
+
+	if !c.sidecarUpgradeLimiter.Allow() {
+		c.workerqueue.EnqueueAfter(gs, time.Second)
+		return gs, nil
+	}
+
+	from := pod.Spec.Containers[index].Image
+	podCopy := pod.DeepCopy()
+	podCopy.Spec.Containers[index].Image = c.sidecarImage
+	if _, err := c.podGetter.Pods(podCopy.ObjectMeta.Namespace).Update(podCopy); err != nil {
+		return gs, errors.Wrapf(err, "error upgrading the sidecar of the Pod of GameServer %s", gs.ObjectMeta.Name)
+	}
+	c.loggerForGameServer(gs).WithField("from", from).WithField("to", c.sidecarImage).Info("Upgrading sidecar in place")
+	c.recorder.Eventf(gs, corev1.EventTypeNormal, string(gs.Status.State), "Upgrading the SDK server in place from image %s to %s", from, c.sidecarImage)
+	return gs, nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameservers
+
+import (
+	"testing"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestControllerSyncGameServerSidecarUpgrade(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]struct {
+		state    agonesv1.GameServerState
+		image    string
+		disabled bool
+		upgraded bool
+	}{
+		"allocated":       {state: agonesv1.GameServerStateAllocated, image: "sidecar:old", upgraded: true},
+		"ready":           {state: agonesv1.GameServerStateReady, image: "sidecar:old", upgraded: true},
+		"reserved":        {state: agonesv1.GameServerStateReserved, image: "sidecar:old", upgraded: true},
+		"up to date":      {state: agonesv1.GameServerStateAllocated, image: "sidecar:dev"},
+		"not serving yet": {state: agonesv1.GameServerStateScheduled, image: "sidecar:old"},
+		"disabled":        {state: agonesv1.GameServerStateAllocated, image: "sidecar:old", disabled: true},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			c, mocks := newFakeController()
+			c.sidecarUpgradeInPlace = !v.disabled
+
+			gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "default"},
+				Spec: newSingleContainerSpec(), Status: agonesv1.GameServerStatus{State: v.state}}
+			gs.ApplyDefaults()
+			sidecar := c.sidecar(gs)
+			sidecar.Image = v.image
+			pod, err := gs.Pod(sidecar)
+			assert.NoError(t, err)
+
+			mocks.KubeClient.AddReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				return true, &corev1.PodList{Items: []corev1.Pod{*pod}}, nil
+			})
+			var updated *corev1.Pod
+			mocks.KubeClient.AddReactor("update", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updated = action.(k8stesting.UpdateAction).GetObject().(*corev1.Pod)
+				return true, updated, nil
+			})
+			_, cancel := agtesting.StartInformers(mocks, c.podSynced)
+			defer cancel()
+
+			result, err := c.syncGameServerSidecarUpgrade(gs)
+			assert.NoError(t, err)
+			assert.Equal(t, gs, result)
+
+			if !v.upgraded {
+				assert.Nil(t, updated)
+				agtesting.AssertNoEvent(t, mocks.FakeRecorder.Events)
+				return
+			}
+			if assert.NotNil(t, updated) {
+				assert.Len(t, updated.Spec.Containers, 2)
+				for i, container := range updated.Spec.Containers {
+					if container.Name == sidecarContainerName {
+						assert.Equal(t, "sidecar:dev", container.Image)
+					} else {
+						// the game server container is left as it is
+						assert.Equal(t, pod.Spec.Containers[i], container)
+					}
+				}
+			}
+			agtesting.AssertEventContains(t, mocks.FakeRecorder.Events, "Upgrading the SDK server in place from image sidecar:old to sidecar:dev")
+		})
+	}
+}
@@ -17,6 +17,8 @@ package gameserversets
 import (
 	"encoding/json"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"agones.dev/agones/pkg/apis"
 	"agones.dev/agones/pkg/apis/agones"
@@ -40,6 +42,7 @@ import (
 	"k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset/typed/apiextensions/v1beta1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
@@ -79,6 +82,7 @@ type Controller struct {
 	stop                <-chan struct{}
 	recorder            record.EventRecorder
 	stateCache          *gameServerStateCache
+	syncLog             runtime.SampledLogger
 }
 
 // NewController returns a new gameserverset crd controller
@@ -149,6 +153,11 @@ func NewController(
 	return c
 }
 
+// WorkQueues returns the work queues of the controller, for the statusz page
+func (c *Controller) WorkQueues() []*workerqueue.WorkerQueue {
+	return []*workerqueue.WorkerQueue{c.workerqueue}
+}
+
 // Run the GameServerSet controller. Will block until stop is closed.
 // Runs threadiness number workers to process the rate limited queue
 func (c *Controller) Run(workers int, stop <-chan struct{}) error {
@@ -171,7 +180,7 @@ func (c *Controller) Run(workers int, stop <-chan struct{}) error {
 // updateValidationHandler that validates a GameServerSet when is updated
 // Should only be called on gameserverset update operations.
 func (c *Controller) updateValidationHandler(review admv1beta1.AdmissionReview) (admv1beta1.AdmissionReview, error) {
-	c.baseLogger.WithField("review", review).Info("updateValidationHandler")
+	c.baseLogger.WithField("review", review).Debug("updateValidationHandler")
 
 	newGss := &agonesv1.GameServerSet{}
 	oldGss := &agonesv1.GameServerSet{}
@@ -212,7 +221,7 @@ func (c *Controller) updateValidationHandler(review admv1beta1.AdmissionReview)
 // creationValidationHandler that validates a GameServerSet when is created
 // Should only be called on gameserverset create operations.
 func (c *Controller) creationValidationHandler(review admv1beta1.AdmissionReview) (admv1beta1.AdmissionReview, error) {
-	c.baseLogger.WithField("review", review).Info("creationValidationHandler")
+	c.baseLogger.WithField("review", review).Debug("creationValidationHandler")
 
 	newGss := &agonesv1.GameServerSet{}
 
@@ -252,6 +261,14 @@ func (c *Controller) gameServerEventHandler(obj interface{}) {
 
 	ref := metav1.GetControllerOf(gs)
 	if ref == nil {
+		// an orphaned GameServer may be adopted by the GameServerSet of its label
+		name, ok := gs.ObjectMeta.Labels[agonesv1.GameServerSetGameServerLabel]
+		if !ok || gs.IsBeingDeleted() {
+			return
+		}
+		if gsSet, err := c.gameServerSetLister.GameServerSets(gs.ObjectMeta.Namespace).Get(name); err == nil {
+			c.workerqueue.Enqueue(gsSet)
+		}
 		return
 	}
 	gsSet, err := c.gameServerSetLister.GameServerSets(gs.ObjectMeta.Namespace).Get(ref.Name)
@@ -276,14 +293,13 @@ func (c *Controller) loggerForGameServerSet(gsSet *agonesv1.GameServerSet) *logr
 	if gsSet != nil {
 		gsSetName = gsSet.Namespace + "/" + gsSet.Name
 	}
-	return c.loggerForGameServerSetKey(gsSetName).WithField("gss", gsSet)
+	return logfields.WithObject(c.loggerForGameServerSetKey(gsSetName), "gss", gsSet)
 }
 
 // syncGameServer synchronises the GameServers for the Set,
 // making sure there are aways as many GameServers as requested
 func (c *Controller) syncGameServerSet(key string) error {
-	c.loggerForGameServerSetKey(key).Info("syncGameServerSet")
-	defer c.loggerForGameServerSetKey(key).Info("syncGameServerSet finished")
+	c.syncLog.Debug(c.loggerForGameServerSetKey(key), "Synchronising")
 
 	// Convert the namespace/name string into a distinct namespace and name
 	namespace, name, err := cache.SplitMetaNamespaceKey(key)
@@ -307,6 +323,12 @@ func (c *Controller) syncGameServerSet(key string) error {
 		return err
 	}
 
+	adopted, err := c.adoptOrphanedGameServers(gsSet)
+	if err != nil {
+		return err
+	}
+	list = append(list, adopted...)
+
 	list = c.stateCache.forGameServerSet(gsSet).reconcileWithUpdatedServerList(list)
 
 	numServersToAdd, toDelete, isPartial := computeReconciliationAction(gsSet.Spec.Scheduling, list, c.counter.Counts(),
@@ -347,13 +369,20 @@ func (c *Controller) syncGameServerSet(key string) error {
 		}
 	}
 
+	var failed int32
 	if len(toDelete) > 0 {
-		if err := c.deleteGameServers(gsSet, toDelete); err != nil {
+		if failed, err = c.deleteGameServers(gsSet, toDelete); err != nil {
 			c.loggerForGameServerSet(gsSet).WithError(err).Warning("error deleting game servers")
 		}
 	}
 
-	return c.syncGameServerSetStatus(gsSet, list)
+	if gsSet.Spec.AllocationOverflow != nil {
+		if err := c.markAllocationOverflow(gsSet, list); err != nil {
+			c.loggerForGameServerSet(gsSet).WithError(err).Warning("error marking allocation overflow")
+		}
+	}
+
+	return c.syncGameServerSetStatus(gsSet, list, failed)
 }
 
 // computeReconciliationAction computes the action to take to reconcile a game server set set given
@@ -460,11 +489,8 @@ func computeReconciliationAction(strategy apis.SchedulingStrategy, list []*agone
 	}
 
 	if deleteCount > 0 {
-		if strategy == apis.Packed {
-			potentialDeletions = sortGameServersByLeastFullNodes(potentialDeletions, counts)
-		} else {
-			potentialDeletions = sortGameServersByNewFirst(potentialDeletions)
-		}
+		potentialDeletions = scaleDownOrderFor(strategy)(potentialDeletions, counts)
+		potentialDeletions = deprioritizeRecentlyReady(potentialDeletions, time.Now())
 
 		toDelete = append(toDelete, potentialDeletions[0:deleteCount]...)
 	}
@@ -484,6 +510,10 @@ func (c *Controller) addMoreGameServers(gsSet *agonesv1.GameServerSet, count int
 	return parallelize(newGameServersChannel(count, gsSet), maxCreationParalellism, func(gs *agonesv1.GameServer) error {
 		gs, err := c.gameServerGetter.GameServers(gs.Namespace).Create(gs)
 		if err != nil {
+			err = apis.FromAPIError(err)
+			if reason := apis.ReasonForError(err); reason != "" {
+				c.recorder.Event(gsSet, corev1.EventTypeWarning, string(reason), err.Error())
+			}
 			return errors.Wrapf(err, "error creating gameserver for gameserverset %s", gsSet.ObjectMeta.Name)
 		}
 
@@ -493,10 +523,13 @@ func (c *Controller) addMoreGameServers(gsSet *agonesv1.GameServerSet, count int
 	})
 }
 
-func (c *Controller) deleteGameServers(gsSet *agonesv1.GameServerSet, toDelete []*agonesv1.GameServer) error {
+// deleteGameServers deletes the GameServers of the set, and returns how many of those deleted had failed,
+// by moving to Unhealthy or Error
+func (c *Controller) deleteGameServers(gsSet *agonesv1.GameServerSet, toDelete []*agonesv1.GameServer) (int32, error) {
 	c.loggerForGameServerSet(gsSet).WithField("diff", len(toDelete)).Info("Deleting gameservers")
 
-	return parallelize(gameServerListToChannel(toDelete), maxDeletionParallelism, func(gs *agonesv1.GameServer) error {
+	var failed int32
+	err := parallelize(gameServerListToChannel(toDelete), maxDeletionParallelism, func(gs *agonesv1.GameServer) error {
 		// We should not delete the gameservers directly buy set their state to shutdown and let the gameserver controller to delete
 		gsCopy := gs.DeepCopy()
 		gsCopy.Status.State = agonesv1.GameServerStateShutdown
@@ -507,6 +540,102 @@ func (c *Controller) deleteGameServers(gsSet *agonesv1.GameServerSet, toDelete [
 
 		c.stateCache.forGameServerSet(gsSet).deleted(gs)
 		c.recorder.Eventf(gsSet, corev1.EventTypeNormal, "SuccessfulDelete", "Deleted gameserver in state %s: %v", gs.Status.State, gs.ObjectMeta.Name)
+		if gs.Status.State == agonesv1.GameServerStateUnhealthy || gs.Status.State == agonesv1.GameServerStateError {
+			atomic.AddInt32(&failed, 1)
+			c.recordFailure(gsSet, gs.Status.State)
+		}
+		return nil
+	})
+	return atomic.LoadInt32(&failed), err
+}
+
+// adoptOrphanedGameServers takes ownership of the GameServers in the namespace of the GameServerSet that have its
+// GameServerSet label, but no controller, such as when the GameServerSet was deleted while orphaning its GameServers,
+// and then recreated. Like a ReplicaSet adopting its orphaned Pods, nothing is adopted by a GameServerSet that is
+// being deleted, or that was recreated since it was cached. It returns the adopted GameServers.
+func (c *Controller) adoptOrphanedGameServers(gsSet *agonesv1.GameServerSet) ([]*agonesv1.GameServer, error) {
+	if gsSet.ObjectMeta.DeletionTimestamp != nil {
+		return nil, nil
+	}
+	list, err := c.gameServerLister.GameServers(gsSet.ObjectMeta.Namespace).List(
+		labels.SelectorFromSet(labels.Set{agonesv1.GameServerSetGameServerLabel: gsSet.ObjectMeta.Name}))
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing gameservers for gameserverset %s", gsSet.ObjectMeta.Name)
+	}
+	var orphans []*agonesv1.GameServer
+	for _, gs := range list {
+		if metav1.GetControllerOf(gs) == nil && !gs.IsBeingDeleted() {
+			orphans = append(orphans, gs)
+		}
+	}
+	if len(orphans) == 0 {
+		return nil, nil
+	}
+
+	// the cache may be behind, so check with the api server that the GameServerSet can adopt them
+	fresh, err := c.gameServerSetGetter.GameServerSets(gsSet.ObjectMeta.Namespace).Get(gsSet.ObjectMeta.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "error retrieving gameserverset %s to adopt gameservers", gsSet.ObjectMeta.Name)
+	}
+	if fresh.ObjectMeta.UID != gsSet.ObjectMeta.UID {
+		return nil, errors.Errorf("gameserverset %s was recreated: got uid %s, wanted %s", gsSet.ObjectMeta.Name, fresh.ObjectMeta.UID, gsSet.ObjectMeta.UID)
+	}
+	if fresh.ObjectMeta.DeletionTimestamp != nil {
+		return nil, nil
+	}
+
+	c.loggerForGameServerSet(gsSet).WithField("count", len(orphans)).Info("Adopting orphaned gameservers")
+	var adopted []*agonesv1.GameServer
+	for _, gs := range orphans {
+		gsCopy := gs.DeepCopy()
+		ref := metav1.NewControllerRef(gsSet, agonesv1.SchemeGroupVersion.WithKind("GameServerSet"))
+		gsCopy.ObjectMeta.OwnerReferences = append(gsCopy.ObjectMeta.OwnerReferences, *ref)
+		result, err := c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(gsCopy)
+		if k8serrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error adopting gameserver %s", gs.ObjectMeta.Name)
+		}
+		c.recorder.Eventf(gsSet, corev1.EventTypeNormal, "SuccessfulAdopt", "Adopted orphaned gameserver: %s", gs.ObjectMeta.Name)
+		adopted = append(adopted, result)
+	}
+	return adopted, nil
+}
+
+// markAllocationOverflow applies the labels and annotations of the AllocationOverflow of the GameServerSet to its
+// Allocated GameServers over its Replicas, that don't have them yet. GameServers are marked in the order they would
+// be deleted in on scale down, if they were Ready.
+func (c *Controller) markAllocationOverflow(gsSet *agonesv1.GameServerSet, list []*agonesv1.GameServer) error {
+	overflow := gsSet.Spec.AllocationOverflow
+	var allocated, marked int32
+	var unmarked []*agonesv1.GameServer
+	for _, gs := range list {
+		if gs.Status.State != agonesv1.GameServerStateAllocated || gs.IsBeingDeleted() {
+			continue
+		}
+		allocated++
+		if overflow.Matches(gs) {
+			marked++
+		} else {
+			unmarked = append(unmarked, gs)
+		}
+	}
+	// the GameServers that are marked already count towards the overflow
+	count := allocated - gsSet.Spec.Replicas - marked
+	if count <= 0 {
+		return nil
+	}
+	unmarked = scaleDownOrderFor(gsSet.Spec.Scheduling)(unmarked, c.counter.Counts())
+
+	c.loggerForGameServerSet(gsSet).WithField("count", count).Info("Marking allocation overflow")
+	return parallelize(gameServerListToChannel(unmarked[:count]), maxDeletionParallelism, func(gs *agonesv1.GameServer) error {
+		gsCopy := gs.DeepCopy()
+		overflow.Apply(gsCopy)
+		if _, err := c.gameServerGetter.GameServers(gs.ObjectMeta.Namespace).Update(gsCopy); err != nil {
+			return errors.Wrapf(err, "error marking gameserver %s as allocation overflow", gs.ObjectMeta.Name)
+		}
+		c.recorder.Eventf(gsSet, corev1.EventTypeNormal, "AllocationOverflow", "Marked Allocated gameserver over the replicas: %v", gs.ObjectMeta.Name)
 		return nil
 	})
 }
@@ -569,9 +698,13 @@ func parallelize(gameServers chan *agonesv1.GameServer, parallelism int, work fu
 	return <-errch
 }
 
-// syncGameServerSetStatus synchronises the GameServerSet State with active GameServer counts
-func (c *Controller) syncGameServerSetStatus(gsSet *agonesv1.GameServerSet, list []*agonesv1.GameServer) error {
-	return c.updateStatusIfChanged(gsSet, computeStatus(list))
+// syncGameServerSetStatus synchronises the GameServerSet State with active GameServer counts,
+// adding the GameServers that failed and were just deleted to its failed ones
+func (c *Controller) syncGameServerSetStatus(gsSet *agonesv1.GameServerSet, list []*agonesv1.GameServer, failed int32) error {
+	status := computeStatus(list)
+	status.LabelSelector = gsSet.LabelSelector()
+	status.FailedReplicas = gsSet.Status.FailedReplicas + failed
+	return c.updateStatusIfChanged(gsSet, status)
 }
 
 // updateStatusIfChanged updates GameServerSet status if it's different than provided.
@@ -605,6 +738,10 @@ func computeStatus(list []*agonesv1.GameServer) agonesv1.GameServerSetStatus {
 			status.AllocatedReplicas++
 		case agonesv1.GameServerStateReserved:
 			status.ReservedReplicas++
+		case agonesv1.GameServerStateScheduled:
+			status.ScheduledReplicas++
+		case agonesv1.GameServerStateUnhealthy:
+			status.UnhealthyReplicas++
 		}
 	}
 
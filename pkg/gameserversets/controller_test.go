@@ -15,9 +15,11 @@
 package gameserversets
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
@@ -30,6 +32,7 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	admv1beta1 "k8s.io/api/admission/v1beta1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/watch"
@@ -238,6 +241,22 @@ func TestComputeReconciliationAction(t *testing.T) {
 		assert.Equal(t, "gs2", toDelete[1].ObjectMeta.Name)
 	})
 
+	t.Run("test recently ready gameservers are deleted last", func(t *testing.T) {
+		list := []*agonesv1.GameServer{
+			{ObjectMeta: metav1.ObjectMeta{Name: "gs1"}, Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady, NodeName: "node1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "gs2"}, Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady, NodeName: "node3"}},
+		}
+		list[0].Status.SetCondition(agonesv1.GameServerCondition{Type: agonesv1.GameServerConditionReady,
+			Status: corev1.ConditionTrue, LastTransitionTime: metav1.Now()})
+
+		counts := map[string]gameservers.NodeCount{"node1": {Ready: 1}, "node3": {Ready: 2}}
+		_, toDelete, _ := computeReconciliationAction(apis.Packed, list, counts, 1, 1000, 1000, 1000)
+
+		// gs1 is on the least full node, but has only just become Ready
+		assert.Len(t, toDelete, 1)
+		assert.Equal(t, "gs2", toDelete[0].ObjectMeta.Name)
+	})
+
 	t.Run("test distributed scale down", func(t *testing.T) {
 		now := metav1.Now()
 
@@ -288,6 +307,15 @@ func TestComputeStatus(t *testing.T) {
 			},
 			wantStatus: agonesv1.GameServerSetStatus{Replicas: 3, ReadyReplicas: 1, ReservedReplicas: 2},
 		},
+		{
+			list: []*agonesv1.GameServer{
+				gsWithState(agonesv1.GameServerStateScheduled),
+				gsWithState(agonesv1.GameServerStateUnhealthy),
+				gsWithState(agonesv1.GameServerStateUnhealthy),
+				gsWithState(agonesv1.GameServerStateReady),
+			},
+			wantStatus: agonesv1.GameServerSetStatus{Replicas: 4, ReadyReplicas: 1, ScheduledReplicas: 1, UnhealthyReplicas: 2},
+		},
 	}
 
 	for _, tc := range cases {
@@ -453,6 +481,119 @@ func TestSyncGameServerSet(t *testing.T) {
 	})
 }
 
+func TestControllerMarkAllocationOverflow(t *testing.T) {
+	t.Parallel()
+
+	gsSet := defaultFixture()
+	gsSet.Spec.Replicas = 2
+	gsSet.Spec.AllocationOverflow = &agonesv1.AllocationOverflow{Labels: map[string]string{"stale": "true"}}
+	list := createGameServers(gsSet, 5)
+	for i := range list {
+		list[i].Status.State = agonesv1.GameServerStateAllocated
+	}
+	list[4].Status.State = agonesv1.GameServerStateReady
+	// already marked, so it counts towards the overflow
+	list[0].ObjectMeta.Labels["stale"] = "true"
+
+	c, m := newFakeController()
+	marked := map[string]bool{}
+	var mutex sync.Mutex
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		assert.Equal(t, agonesv1.GameServerStateAllocated, gs.Status.State)
+		assert.Equal(t, "true", gs.ObjectMeta.Labels["stale"])
+		mutex.Lock()
+		defer mutex.Unlock()
+		marked[gs.ObjectMeta.Name] = true
+		return true, gs, nil
+	})
+
+	var gameServers []*agonesv1.GameServer
+	for i := range list {
+		gameServers = append(gameServers, &list[i])
+	}
+	err := c.markAllocationOverflow(gsSet, gameServers)
+	assert.NoError(t, err)
+	// 4 Allocated over 2 replicas, one of which is marked already
+	assert.Len(t, marked, 1)
+	assert.NotContains(t, marked, list[0].ObjectMeta.Name)
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "AllocationOverflow")
+
+	// nothing to mark once the overflow is covered
+	marked = map[string]bool{}
+	gsSet.Spec.Replicas = 3
+	err = c.markAllocationOverflow(gsSet, gameServers)
+	assert.NoError(t, err)
+	assert.Empty(t, marked)
+}
+
+func TestControllerAdoptOrphanedGameServers(t *testing.T) {
+	t.Parallel()
+
+	gsSet := defaultFixture()
+	list := createGameServers(gsSet, 4)
+	// orphaned when the previous GameServerSet of the same name was deleted
+	list[0].ObjectMeta.OwnerReferences = nil
+	list[1].ObjectMeta.OwnerReferences = nil
+	list[2].ObjectMeta.OwnerReferences = nil
+	now := metav1.Now()
+	list[2].ObjectMeta.DeletionTimestamp = &now
+
+	setup := func(fresh *agonesv1.GameServerSet) (*Controller, agtesting.Mocks, map[string]bool, context.CancelFunc) {
+		c, m := newFakeController()
+		m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, &agonesv1.GameServerList{Items: list}, nil
+		})
+		m.AgonesClient.AddReactor("get", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			return true, fresh, nil
+		})
+		adopted := map[string]bool{}
+		m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+			assert.True(t, metav1.IsControlledBy(gs, gsSet))
+			adopted[gs.ObjectMeta.Name] = true
+			return true, gs, nil
+		})
+		_, cancel := agtesting.StartInformers(m, c.gameServerSynced)
+		return c, m, adopted, cancel
+	}
+
+	t.Run("adopt orphans", func(t *testing.T) {
+		c, m, adopted, cancel := setup(gsSet.DeepCopy())
+		defer cancel()
+
+		result, err := c.adoptOrphanedGameServers(gsSet)
+		assert.NoError(t, err)
+		assert.Len(t, result, 2)
+		assert.Equal(t, map[string]bool{list[0].ObjectMeta.Name: true, list[1].ObjectMeta.Name: true}, adopted)
+		agtesting.AssertEventContains(t, m.FakeRecorder.Events, "SuccessfulAdopt")
+	})
+
+	t.Run("gameserverset was recreated", func(t *testing.T) {
+		fresh := gsSet.DeepCopy()
+		fresh.ObjectMeta.UID = "5678"
+		c, _, adopted, cancel := setup(fresh)
+		defer cancel()
+
+		result, err := c.adoptOrphanedGameServers(gsSet)
+		assert.EqualError(t, err, "gameserverset test was recreated: got uid 5678, wanted 1234")
+		assert.Empty(t, result)
+		assert.Empty(t, adopted)
+	})
+
+	t.Run("gameserverset is being deleted", func(t *testing.T) {
+		fresh := gsSet.DeepCopy()
+		fresh.ObjectMeta.DeletionTimestamp = &now
+		c, _, adopted, cancel := setup(fresh)
+		defer cancel()
+
+		result, err := c.adoptOrphanedGameServers(gsSet)
+		assert.NoError(t, err)
+		assert.Empty(t, result)
+		assert.Empty(t, adopted)
+	})
+}
+
 func TestControllerSyncUnhealthyGameServers(t *testing.T) {
 	gsSet := defaultFixture()
 
@@ -486,8 +627,9 @@ func TestControllerSyncUnhealthyGameServers(t *testing.T) {
 	_, cancel := agtesting.StartInformers(m)
 	defer cancel()
 
-	err := c.deleteGameServers(gsSet, []*agonesv1.GameServer{gs1, gs2, gs3})
+	failed, err := c.deleteGameServers(gsSet, []*agonesv1.GameServer{gs1, gs2, gs3})
 	assert.Nil(t, err)
+	assert.Equal(t, int32(1), failed, "The Unhealthy GameServer should be counted as failed")
 
 	assert.Equal(t, 3, updatedCount, "Updates should have occurred")
 }
@@ -534,12 +676,13 @@ func TestControllerSyncGameServerSetStatus(t *testing.T) {
 			assert.Equal(t, int32(1), gsSet.Status.Replicas)
 			assert.Equal(t, int32(1), gsSet.Status.ReadyReplicas)
 			assert.Equal(t, int32(0), gsSet.Status.AllocatedReplicas)
+			assert.Equal(t, agonesv1.GameServerSetGameServerLabel+"="+gsSet.ObjectMeta.Name, gsSet.Status.LabelSelector)
 
 			return true, nil, nil
 		})
 
 		list := []*agonesv1.GameServer{{Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}}}
-		err := c.syncGameServerSetStatus(gsSet, list)
+		err := c.syncGameServerSetStatus(gsSet, list, 0)
 		assert.Nil(t, err)
 		assert.True(t, updated)
 	})
@@ -571,7 +714,25 @@ func TestControllerSyncGameServerSetStatus(t *testing.T) {
 			{Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateAllocated}},
 			{Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateAllocated}},
 		}
-		err := c.syncGameServerSetStatus(gsSet, list)
+		err := c.syncGameServerSetStatus(gsSet, list, 0)
+		assert.Nil(t, err)
+		assert.True(t, updated)
+	})
+	t.Run("failed replicas", func(t *testing.T) {
+		gsSet := defaultFixture()
+		gsSet.Status.FailedReplicas = 2
+		c, m := newFakeController()
+
+		updated := false
+		m.AgonesClient.AddReactor("update", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+			updated = true
+			gsSet := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServerSet)
+			assert.Equal(t, int32(5), gsSet.Status.FailedReplicas)
+			return true, nil, nil
+		})
+
+		list := []*agonesv1.GameServer{{Status: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady}}}
+		err := c.syncGameServerSetStatus(gsSet, list, 3)
 		assert.Nil(t, err)
 		assert.True(t, updated)
 	})
@@ -16,7 +16,10 @@ package gameserversets
 
 import (
 	"sort"
+	"sync"
+	"time"
 
+	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	listerv1 "agones.dev/agones/pkg/client/listers/agones/v1"
 	"agones.dev/agones/pkg/gameservers"
@@ -25,6 +28,62 @@ import (
 	"k8s.io/apimachinery/pkg/labels"
 )
 
+// recentlyReadyPeriod is how long after a GameServer becomes Ready it is only deleted on scale down
+// once there are no other GameServers to delete, as it has only just paid the cost of starting up
+const recentlyReadyPeriod = time.Minute
+
+// ScaleDownOrder sorts the GameServers that may be deleted when a GameServerSet scales down, in the order
+// they should be deleted. counts are the Ready and Allocated GameServers on each node.
+// Allocated and Reserved GameServers are never passed to it, as they are not deleted on scale down.
+type ScaleDownOrder func(list []*agonesv1.GameServer, counts map[string]gameservers.NodeCount) []*agonesv1.GameServer
+
+var (
+	// scaleDownOrdersMutex guards scaleDownOrders, as they can be registered while GameServerSets sync
+	scaleDownOrdersMutex sync.RWMutex
+	// scaleDownOrders are the ScaleDownOrders of the scheduling strategies of GameServerSets
+	scaleDownOrders = map[apis.SchedulingStrategy]ScaleDownOrder{
+		apis.Packed:      sortGameServersByLeastFullNodes,
+		apis.Distributed: sortGameServersByMostFullNodes,
+	}
+)
+
+// RegisterScaleDownOrder registers the ScaleDownOrder of GameServerSets with the scheduling strategy.
+// It must be called before the controller is created, and replaces any ScaleDownOrder registered under
+// the same name, including the built in ones of Packed and Distributed.
+func RegisterScaleDownOrder(name apis.SchedulingStrategy, order ScaleDownOrder) {
+	scaleDownOrdersMutex.Lock()
+	defer scaleDownOrdersMutex.Unlock()
+	scaleDownOrders[name] = order
+}
+
+// scaleDownOrderFor returns the ScaleDownOrder of the scheduling strategy,
+// or sortGameServersByNewFirst if none is registered
+func scaleDownOrderFor(name apis.SchedulingStrategy) ScaleDownOrder {
+	scaleDownOrdersMutex.RLock()
+	defer scaleDownOrdersMutex.RUnlock()
+	if order, ok := scaleDownOrders[name]; ok {
+		return order
+	}
+	return func(list []*agonesv1.GameServer, _ map[string]gameservers.NodeCount) []*agonesv1.GameServer {
+		return sortGameServersByNewFirst(list)
+	}
+}
+
+// deprioritizeRecentlyReady moves the GameServers that became Ready within the recentlyReadyPeriod before now
+// to the end of the list, keeping the order of the GameServers otherwise
+func deprioritizeRecentlyReady(list []*agonesv1.GameServer, now time.Time) []*agonesv1.GameServer {
+	var rest, recent []*agonesv1.GameServer
+	for _, gs := range list {
+		c, ok := gs.Status.GetCondition(agonesv1.GameServerConditionReady)
+		if gs.Status.State == agonesv1.GameServerStateReady && ok && now.Sub(c.LastTransitionTime.Time) < recentlyReadyPeriod {
+			recent = append(recent, gs)
+		} else {
+			rest = append(rest, gs)
+		}
+	}
+	return append(rest, recent...)
+}
+
 // sortGameServersByLeastFullNodes sorts the list of gameservers by which gameservers reside on the least full nodes
 func sortGameServersByLeastFullNodes(list []*agonesv1.GameServer, count map[string]gameservers.NodeCount) []*agonesv1.GameServer {
 	sort.Slice(list, func(i, j int) bool {
@@ -47,6 +106,43 @@ func sortGameServersByLeastFullNodes(list []*agonesv1.GameServer, count map[stri
 	return list
 }
 
+// sortGameServersByMostFullNodes sorts the list of gameservers so that deleting them in order keeps the
+// remaining gameservers spread across nodes: each next gameserver resides on the node that would have
+// the most gameservers left on it, which leaves the emptiest nodes for last. Gameservers on the same
+// node keep the order of sortGameServersByNewFirst.
+func sortGameServersByMostFullNodes(list []*agonesv1.GameServer, count map[string]gameservers.NodeCount) []*agonesv1.GameServer {
+	list = sortGameServersByNewFirst(list)
+
+	// how many gameservers would be left on the node of each gameserver, once it is deleted
+	left := make(map[*agonesv1.GameServer]int64, len(list))
+	seen := map[string]int64{}
+	for _, gs := range list {
+		c := count[gs.Status.NodeName]
+		left[gs] = c.Allocated + c.Ready - seen[gs.Status.NodeName]
+		seen[gs.Status.NodeName]++
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		a := list[i]
+		b := list[j]
+		// not scheduled yet/node deleted, put them first
+		_, aOk := count[a.Status.NodeName]
+		_, bOk := count[b.Status.NodeName]
+		if aOk != bOk {
+			return !aOk
+		}
+		if !aOk {
+			return false
+		}
+		if left[a] != left[b] {
+			return left[a] > left[b]
+		}
+		return a.Status.NodeName < b.Status.NodeName
+	})
+
+	return list
+}
+
 // sortGameServersByNewFirst sorts by newest gameservers first, and returns them
 func sortGameServersByNewFirst(list []*agonesv1.GameServer) []*agonesv1.GameServer {
 	sort.Slice(list, func(i, j int) bool {
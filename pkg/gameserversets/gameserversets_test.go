@@ -19,10 +19,12 @@ import (
 	"testing"
 	"time"
 
+	"agones.dev/agones/pkg/apis"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"agones.dev/agones/pkg/gameservers"
 	agtesting "agones.dev/agones/pkg/testing"
 	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	k8stesting "k8s.io/client-go/testing"
@@ -50,6 +52,91 @@ func TestSortGameServersByLeastFullNodes(t *testing.T) {
 	assert.Equal(t, "g1", result[2].ObjectMeta.Name)
 }
 
+func TestSortGameServersByMostFullNodes(t *testing.T) {
+	t.Parallel()
+
+	now := metav1.Now()
+	nc := map[string]gameservers.NodeCount{
+		"n1": {Ready: 3, Allocated: 1},
+		"n2": {Ready: 2, Allocated: 0},
+		"n3": {Ready: 1, Allocated: 0},
+	}
+	newGs := func(name, node string, age time.Duration) *agonesv1.GameServer {
+		return &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: metav1.Time{Time: now.Add(-age)}},
+			Status: agonesv1.GameServerStatus{NodeName: node}}
+	}
+
+	list := []*agonesv1.GameServer{
+		newGs("g1", "n3", time.Second),
+		newGs("g2", "n1", time.Second),
+		newGs("g3", "n2", time.Second),
+		newGs("g4", "n1", time.Hour),
+		newGs("g5", "", time.Second),
+		newGs("g6", "n2", time.Hour),
+		newGs("g7", "n1", time.Minute),
+	}
+
+	result := sortGameServersByMostFullNodes(list, nc)
+
+	var names []string
+	for _, gs := range result {
+		names = append(names, gs.ObjectMeta.Name)
+	}
+	// n1 is drained down to as many gameservers as n2 has, and then both down to as many as n3 has
+	assert.Equal(t, []string{"g5", "g4", "g7", "g2", "g6", "g3", "g1"}, names)
+}
+
+func TestDeprioritizeRecentlyReady(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	newGs := func(name string, state agonesv1.GameServerState, readyAge time.Duration) *agonesv1.GameServer {
+		gs := &agonesv1.GameServer{ObjectMeta: metav1.ObjectMeta{Name: name}, Status: agonesv1.GameServerStatus{State: state}}
+		if readyAge > 0 {
+			gs.Status.SetCondition(agonesv1.GameServerCondition{Type: agonesv1.GameServerConditionReady,
+				Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(now.Add(-readyAge))})
+		}
+		return gs
+	}
+
+	list := []*agonesv1.GameServer{
+		newGs("g1", agonesv1.GameServerStateReady, time.Second),
+		newGs("g2", agonesv1.GameServerStateReady, time.Hour),
+		newGs("g3", agonesv1.GameServerStateReady, 10*time.Second),
+		newGs("g4", agonesv1.GameServerStateStarting, 0),
+		// Ready once, but now waiting to become Ready again
+		newGs("g5", agonesv1.GameServerStateRequestReady, time.Second),
+	}
+
+	var names []string
+	for _, gs := range deprioritizeRecentlyReady(list, now) {
+		names = append(names, gs.ObjectMeta.Name)
+	}
+	assert.Equal(t, []string{"g2", "g4", "g5", "g1", "g3"}, names)
+}
+
+func TestRegisterScaleDownOrder(t *testing.T) {
+	t.Parallel()
+
+	list := []*agonesv1.GameServer{{ObjectMeta: metav1.ObjectMeta{Name: "g1"}}, {ObjectMeta: metav1.ObjectMeta{Name: "g2"}}}
+	reversed := func(list []*agonesv1.GameServer, _ map[string]gameservers.NodeCount) []*agonesv1.GameServer {
+		return []*agonesv1.GameServer{list[1], list[0]}
+	}
+
+	name := apis.SchedulingStrategy("TestReversed")
+	RegisterScaleDownOrder(name, reversed)
+	result := scaleDownOrderFor(name)(list, nil)
+	assert.Equal(t, "g2", result[0].ObjectMeta.Name)
+	assert.Equal(t, "g1", result[1].ObjectMeta.Name)
+
+	// unregistered strategies fall back to sortGameServersByNewFirst
+	now := metav1.Now()
+	list[0].ObjectMeta.CreationTimestamp = metav1.NewTime(now.Add(time.Second))
+	list[1].ObjectMeta.CreationTimestamp = now
+	result = scaleDownOrderFor("TestUnregistered")(list, nil)
+	assert.Equal(t, "g2", result[0].ObjectMeta.Name)
+}
+
 func TestSortGameServersByNewFirst(t *testing.T) {
 	now := metav1.Now()
 
@@ -0,0 +1,55 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserversets
+
+import (
+	"context"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	mt "agones.dev/agones/pkg/metrics"
+	"agones.dev/agones/pkg/util/runtime"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	keyName      = mt.MustTagKey("name")
+	keyFleetName = mt.MustTagKey("fleet_name")
+	keyState     = mt.MustTagKey("state")
+
+	failuresStats = stats.Int64("gss/failures", "The number of GameServers of a game server set that failed and were replaced", "1")
+)
+
+func init() {
+	runtime.Must(view.Register(&view.View{
+		Name:        "gameserversets_failures_total",
+		Measure:     failuresStats,
+		Description: "The total of gameservers of game server sets that moved to Unhealthy or Error and were replaced, per game server set",
+		Aggregation: view.Count(),
+		TagKeys:     []tag.Key{keyName, keyFleetName, keyState},
+	}))
+}
+
+// recordFailure records that a GameServer of the set failed in the state, and was replaced
+func (c *Controller) recordFailure(gsSet *agonesv1.GameServerSet, state agonesv1.GameServerState) {
+	ctx, err := tag.New(context.Background(), tag.Insert(keyName, gsSet.ObjectMeta.Name),
+		tag.Insert(keyFleetName, gsSet.ObjectMeta.Labels[agonesv1.FleetNameLabel]), tag.Insert(keyState, string(state)))
+	if err != nil {
+		c.loggerForGameServerSet(gsSet).WithError(err).Warn("failed to tag game server set failure metric")
+		return
+	}
+	stats.Record(ctx, failuresStats.M(1))
+}
@@ -0,0 +1,89 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gameserversets
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"agones.dev/agones/pkg/apis"
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/gameservers"
+	agtesting "agones.dev/agones/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestControllerSyncGameServerSetScale(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping scale test in short mode")
+	}
+	t.Parallel()
+
+	const replicas, scaledTo = 5000, 1000
+	fixture := agtesting.NewScaleFixture("default", 1, replicas, 100)
+	gsSet := &fixture.GameServerSets[0]
+	gsSet.Spec.Replicas = scaledTo
+
+	c, m := newFakeController()
+	c.recorder = agtesting.NewDiscardingRecorder()
+	fixture.AddReactors(m)
+	var mutex sync.Mutex
+	shutdown := map[string]bool{}
+	m.AgonesClient.PrependReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		mutex.Lock()
+		defer mutex.Unlock()
+		assert.False(t, shutdown[gs.ObjectMeta.Name], "GameServer %s shut down twice", gs.ObjectMeta.Name)
+		shutdown[gs.ObjectMeta.Name] = gs.Status.State == agonesv1.GameServerStateShutdown
+		return false, nil, nil
+	})
+
+	_, cancel := agtesting.StartInformers(m, c.gameServerSetSynced, c.gameServerSynced)
+	defer cancel()
+
+	// the lister keeps returning all the GameServers, as the fake clients do not apply the updates, so it is up
+	// to the state cache of the controller to not shut down the same GameServers again
+	start := time.Now()
+	syncs := 0
+	for fixture.Updates() < replicas-scaledTo && syncs < replicas {
+		syncs++
+		c.syncGameServerSet(gsSet.ObjectMeta.Namespace + "/" + gsSet.ObjectMeta.Name) // nolint: errcheck
+	}
+
+	t.Logf("shut down %d GameServers in %d syncs and %v", fixture.Updates(), syncs, time.Since(start))
+	assert.Equal(t, replicas-scaledTo, fixture.Updates())
+	assert.Len(t, shutdown, replicas-scaledTo)
+	assert.Equal(t, (replicas-scaledTo+maxGameServerDeletionsPerBatch-1)/maxGameServerDeletionsPerBatch, syncs)
+}
+
+func BenchmarkComputeReconciliationAction(b *testing.B) {
+	fixture := agtesting.NewScaleFixture("default", 1, 5000, 100)
+	list := make([]*agonesv1.GameServer, len(fixture.GameServers))
+	counts := map[string]gameservers.NodeCount{}
+	for i := range fixture.GameServers {
+		list[i] = &fixture.GameServers[i]
+		count := counts[list[i].Status.NodeName]
+		count.Ready++
+		counts[list[i].Status.NodeName] = count
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		computeReconciliationAction(apis.Packed, list, counts, 1000, maxGameServerCreationsPerBatch, maxGameServerDeletionsPerBatch, maxPodPendingCount)
+	}
+}
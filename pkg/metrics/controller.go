@@ -262,7 +262,24 @@ func (c *Controller) recordGameServerStatusChanges(old, new interface{}) {
 		}
 		recordWithTags(context.Background(), []tag.Mutator{tag.Upsert(keyType, string(newGs.Status.State)),
 			tag.Upsert(keyFleetName, fleetName)}, gameServerTotalStats.M(1))
+
+		if newGs.Status.State == agonesv1.GameServerStateReady && isStartingState(oldGs.Status.State) &&
+			!newGs.ObjectMeta.CreationTimestamp.IsZero() {
+			recordWithTags(context.Background(), []tag.Mutator{tag.Upsert(keyFleetName, fleetName)},
+				gameServerStartupStats.M(time.Since(newGs.ObjectMeta.CreationTimestamp.Time).Seconds()))
+		}
+	}
+}
+
+// isStartingState returns true if the state is one a GameServer goes through before it is first Ready,
+// rather than one it can move back to Ready from, e.g. Reserved or Allocated
+func isStartingState(state agonesv1.GameServerState) bool {
+	switch state {
+	case "", agonesv1.GameServerStatePortAllocation, agonesv1.GameServerStateCreating, agonesv1.GameServerStateStarting,
+		agonesv1.GameServerStateScheduled, agonesv1.GameServerStateRequestReady:
+		return true
 	}
+	return false
 }
 
 // Run the Metrics controller. Will block until stop is closed.
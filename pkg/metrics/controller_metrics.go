@@ -32,6 +32,7 @@ var (
 	gameServerTotalStats      = stats.Int64("gameservers/total", "The total of gameservers", "1")
 	nodesCountStats           = stats.Int64("nodes/count", "The count of nodes in the cluster", "1")
 	gsPerNodesCountStats      = stats.Int64("gameservers_node/count", "The count of gameservers per node in the cluster", "1")
+	gameServerStartupStats    = stats.Float64("gameservers/startup_duration", "The duration of gameservers from creation to Ready", "s")
 
 	stateViews = []*view.View{
 		&view.View{
@@ -110,6 +111,13 @@ var (
 			Description: "The count of gameservers per node in the cluster",
 			Aggregation: view.Distribution(0.00001, 1.00001, 2.00001, 3.00001, 4.00001, 5.00001, 6.00001, 7.00001, 8.00001, 9.00001, 10.00001, 11.00001, 12.00001, 13.00001, 14.00001, 15.00001, 16.00001, 32.00001, 40.00001, 50.00001, 60.00001, 70.00001, 80.00001, 90.00001, 100.00001, 110.00001, 120.00001),
 		},
+		&view.View{
+			Name:        "gameservers_startup_duration_seconds",
+			Measure:     gameServerStartupStats,
+			Description: "The distribution of how long gameservers took from creation to Ready, per fleet",
+			Aggregation: view.Distribution(0, 1, 2, 5, 10, 15, 20, 30, 45, 60, 90, 120, 180, 300, 600),
+			TagKeys:     []tag.Key{keyFleetName},
+		},
 	}
 )
 
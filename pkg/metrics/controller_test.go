@@ -17,12 +17,16 @@ package metrics
 import (
 	"strings"
 	"testing"
+	"time"
 
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"go.opencensus.io/stats/view"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 func TestControllerGameServerCount(t *testing.T) {
@@ -87,6 +91,73 @@ func TestControllerGameServersTotal(t *testing.T) {
 	assert.Nil(t, testutil.GatherAndCompare(registry, strings.NewReader(gsTotalExpected), "agones_gameservers_total"))
 }
 
+func TestControllerGameServersStartupDuration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	_, err := RegisterPrometheusExporter(registry)
+	assert.Nil(t, err)
+
+	c := newFakeController()
+	defer c.close()
+	c.run(t)
+
+	moveThrough := func(gs *agonesv1.GameServer, states ...agonesv1.GameServerState) {
+		for _, state := range states {
+			gs = gs.DeepCopy()
+			gs.Status.State = state
+			c.gsWatch.Modify(gs)
+		}
+	}
+
+	gs := gameServerWithFleetAndState("test", "")
+	gs.ObjectMeta.CreationTimestamp = metav1.NewTime(time.Now().Add(-12 * time.Second))
+	c.gsWatch.Add(gs)
+	moveThrough(gs, agonesv1.GameServerStateScheduled, agonesv1.GameServerStateRequestReady, agonesv1.GameServerStateReady,
+		// returning to Ready is not a startup
+		agonesv1.GameServerStateReserved, agonesv1.GameServerStateReady)
+
+	gs = gameServerWithFleetAndState("", agonesv1.GameServerStateRequestReady)
+	gs.ObjectMeta.CreationTimestamp = metav1.NewTime(time.Now().Add(-40 * time.Second))
+	c.gsWatch.Add(gs)
+	moveThrough(gs, agonesv1.GameServerStateReady)
+
+	c.sync()
+	err = wait.PollImmediate(100*time.Millisecond, 5*time.Second, func() (bool, error) {
+		rows, err := view.RetrieveData("gameservers_startup_duration_seconds")
+		if err != nil || len(rows) != 2 {
+			return false, err
+		}
+		for _, r := range rows {
+			if r.Data.(*view.DistributionData).Count != 1 {
+				return false, nil
+			}
+		}
+		return true, nil
+	})
+	assert.NoError(t, err)
+	report()
+
+	metrics, err := registry.Gather()
+	assert.NoError(t, err)
+	counts := map[string]uint64{}
+	for _, mf := range metrics {
+		if mf.GetName() != "agones_gameservers_startup_duration_seconds" {
+			continue
+		}
+		for _, m := range mf.GetMetric() {
+			h := m.GetHistogram()
+			for _, b := range h.GetBucket() {
+				if b.GetCumulativeCount() > 0 {
+					// the first bucket the startup falls in
+					assert.Equal(t, map[string]float64{"test": 15, "none": 45}[m.GetLabel()[0].GetValue()], b.GetUpperBound())
+					break
+				}
+			}
+			counts[m.GetLabel()[0].GetValue()] = h.GetSampleCount()
+		}
+	}
+	assert.Equal(t, map[string]uint64{"test": 1, "none": 1}, counts)
+}
+
 func TestControllerFleetReplicasCount(t *testing.T) {
 
 	registry := prometheus.NewRegistry()
@@ -0,0 +1,182 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package externalmetrics serves the replicas and allocation rates of Fleets on the Kubernetes external metrics API,
+// so that HorizontalPodAutoscalers can scale Fleets and GameServerSets through their scale subresource
+package externalmetrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	"agones.dev/agones/pkg/client/informers/externalversions"
+	listerv1 "agones.dev/agones/pkg/client/listers/agones/v1"
+	"agones.dev/agones/pkg/util/apiserver"
+	"agones.dev/agones/pkg/util/https"
+	"agones.dev/agones/pkg/util/runtime"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	// GroupVersion is the group and version of the Kubernetes external metrics API
+	GroupVersion = "external.metrics.k8s.io/v1beta1"
+
+	// FleetReplicas is the number of GameServers of the Fleet
+	FleetReplicas = "agones_fleet_replicas"
+	// FleetReadyReplicas is the number of Ready GameServers of the Fleet
+	FleetReadyReplicas = "agones_fleet_ready_replicas"
+	// FleetAllocatedReplicas is the number of Allocated GameServers of the Fleet
+	FleetAllocatedReplicas = "agones_fleet_allocated_replicas"
+	// FleetAllocationRate is the number of GameServers of the Fleet allocated per second, over AllocationRateWindow
+	FleetAllocationRate = "agones_fleet_allocation_rate"
+
+	// AllocationRateWindow is the window over which the FleetAllocationRate is computed
+	AllocationRateWindow = time.Minute
+)
+
+// AllocationHistory tells how many GameServers of a Fleet were allocated recently
+type AllocationHistory interface {
+	// AllocatedSince returns how many GameServers of the Fleet were moved to Allocated after the given time
+	AllocatedSince(namespace, fleetName string, since time.Time) int32
+}
+
+// ExternalMetricValueList is a list of values of an external metric, as served by the external metrics API
+type ExternalMetricValueList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ExternalMetricValue `json:"items"`
+}
+
+// ExternalMetricValue is the value of an external metric for a Fleet, at a point in time
+type ExternalMetricValue struct {
+	metav1.TypeMeta `json:",inline"`
+	// MetricName is the name of the metric
+	MetricName string `json:"metricName"`
+	// MetricLabels are the labels of the Fleet, and the agones.dev/fleet label with its name
+	MetricLabels map[string]string `json:"metricLabels"`
+	// Timestamp is when the value was computed
+	Timestamp metav1.Time `json:"timestamp"`
+	// WindowSeconds is the window over which a rate was computed
+	WindowSeconds *int64 `json:"window,omitempty"`
+	// Value of the metric
+	Value resource.Quantity `json:"value"`
+}
+
+// Provider serves the metrics of Fleets on the external metrics API
+type Provider struct {
+	baseLogger  *logrus.Entry
+	fleetLister listerv1.FleetLister
+	allocations AllocationHistory
+	now         func() time.Time
+}
+
+// NewProvider returns a Provider, and registers its metrics with the api server
+func NewProvider(api *apiserver.APIServer, agonesInformerFactory externalversions.SharedInformerFactory, allocations AllocationHistory) *Provider {
+	p := &Provider{
+		fleetLister: agonesInformerFactory.Agones().V1().Fleets().Lister(),
+		allocations: allocations,
+		now:         time.Now,
+	}
+	p.baseLogger = runtime.NewLoggerWithType(p)
+
+	for _, name := range []string{FleetReplicas, FleetReadyReplicas, FleetAllocatedReplicas, FleetAllocationRate} {
+		metricName := name
+		resource := metav1.APIResource{
+			Name:       metricName,
+			Namespaced: true,
+			Kind:       "ExternalMetricValueList",
+			Verbs:      []string{"get"},
+		}
+		api.AddAPIResource(GroupVersion, resource, func(w http.ResponseWriter, r *http.Request, namespace string) error {
+			return p.serveMetric(w, r, namespace, metricName)
+		})
+	}
+
+	return p
+}
+
+// serveMetric writes the values of the metric for the Fleets of the namespace that match the label selector
+// of the request
+func (p *Provider) serveMetric(w http.ResponseWriter, r *http.Request, namespace, metricName string) error {
+	log := https.LogRequest(p.baseLogger, r)
+	if r.Method != http.MethodGet {
+		log.Warn("external metrics handler only supports GET")
+		http.Error(w, "Method not supported", http.StatusMethodNotAllowed)
+		return nil
+	}
+
+	selector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "invalid labelSelector").Error(), http.StatusBadRequest)
+		return nil
+	}
+
+	list, err := p.values(namespace, metricName, selector)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set(apiserver.ContentTypeHeader, k8sruntime.ContentTypeJSON)
+	return errors.Wrap(json.NewEncoder(w).Encode(list), "error encoding external metric values")
+}
+
+// values returns the values of the metric for the Fleets of the namespace that match the selector. The
+// selector is matched against the labels of each Fleet and the agones.dev/fleet label with its name.
+func (p *Provider) values(namespace, metricName string, selector labels.Selector) (*ExternalMetricValueList, error) {
+	fleets, err := p.fleetLister.Fleets(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing fleets for external metric %s", metricName)
+	}
+
+	now := metav1.NewTime(p.now())
+	list := &ExternalMetricValueList{
+		TypeMeta: metav1.TypeMeta{Kind: "ExternalMetricValueList", APIVersion: GroupVersion},
+		Items:    []ExternalMetricValue{},
+	}
+	for _, f := range fleets {
+		metricLabels := map[string]string{agonesv1.FleetNameLabel: f.ObjectMeta.Name}
+		for k, v := range f.ObjectMeta.Labels {
+			metricLabels[k] = v
+		}
+		if !selector.Matches(labels.Set(metricLabels)) {
+			continue
+		}
+
+		value := ExternalMetricValue{MetricName: metricName, MetricLabels: metricLabels, Timestamp: now}
+		switch metricName {
+		case FleetReplicas:
+			value.Value = *resource.NewQuantity(int64(f.Status.Replicas), resource.DecimalSI)
+		case FleetReadyReplicas:
+			value.Value = *resource.NewQuantity(int64(f.Status.ReadyReplicas), resource.DecimalSI)
+		case FleetAllocatedReplicas:
+			value.Value = *resource.NewQuantity(int64(f.Status.AllocatedReplicas), resource.DecimalSI)
+		case FleetAllocationRate:
+			window := int64(AllocationRateWindow.Seconds())
+			allocated := p.allocations.AllocatedSince(f.ObjectMeta.Namespace, f.ObjectMeta.Name, now.Add(-AllocationRateWindow))
+			value.WindowSeconds = &window
+			value.Value = *resource.NewMilliQuantity(int64(allocated)*1000/window, resource.DecimalSI)
+		default:
+			return nil, errors.Errorf("unknown external metric %s", metricName)
+		}
+		list.Items = append(list.Items, value)
+	}
+	return list, nil
+}
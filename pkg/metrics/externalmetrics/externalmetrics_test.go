@@ -0,0 +1,162 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package externalmetrics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	agtesting "agones.dev/agones/pkg/testing"
+	"agones.dev/agones/pkg/util/apiserver"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// fakeAllocations is an AllocationHistory with a fixed number of allocations per Fleet
+type fakeAllocations map[string]int32
+
+func (f fakeAllocations) AllocatedSince(namespace, fleetName string, since time.Time) int32 {
+	return f[namespace+"/"+fleetName]
+}
+
+func TestProviderServeMetric(t *testing.T) {
+	t.Parallel()
+
+	fleets := []agonesv1.Fleet{
+		{ObjectMeta: metav1.ObjectMeta{Name: "fleet-eu", Namespace: "default", Labels: map[string]string{"region": "eu"}},
+			Status: agonesv1.FleetStatus{Replicas: 10, ReadyReplicas: 4, AllocatedReplicas: 6}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "fleet-us", Namespace: "default", Labels: map[string]string{"region": "us"}},
+			Status: agonesv1.FleetStatus{Replicas: 5, ReadyReplicas: 5}},
+	}
+
+	m := agtesting.NewMocks()
+	m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, k8sruntime.Object, error) {
+		return true, &agonesv1.FleetList{Items: fleets}, nil
+	})
+
+	mux := http.NewServeMux()
+	api := apiserver.NewAPIServer(mux)
+	p := NewProvider(api, m.AgonesInformerFactory, fakeAllocations{"default/fleet-eu": 30})
+	now := time.Now()
+	p.now = func() time.Time { return now }
+	_, cancel := agtesting.StartInformers(m, m.AgonesInformerFactory.Agones().V1().Fleets().Informer().HasSynced)
+	defer cancel()
+
+	get := func(t *testing.T, path, selector string) (int, *ExternalMetricValueList) {
+		r := httptest.NewRequest(http.MethodGet, path+"?labelSelector="+url.QueryEscape(selector), nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		if rec.Code != http.StatusOK {
+			return rec.Code, nil
+		}
+		list := &ExternalMetricValueList{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), list))
+		return rec.Code, list
+	}
+
+	fixtures := map[string]struct {
+		metric   string
+		selector string
+		values   map[string]string
+	}{
+		"ready replicas of a fleet": {
+			metric:   FleetReadyReplicas,
+			selector: agonesv1.FleetNameLabel + "=fleet-eu",
+			values:   map[string]string{"fleet-eu": "4"},
+		},
+		"allocated replicas of all fleets": {
+			metric: FleetAllocatedReplicas,
+			values: map[string]string{"fleet-eu": "6", "fleet-us": "0"},
+		},
+		"replicas of fleets by label": {
+			metric:   FleetReplicas,
+			selector: "region=us",
+			values:   map[string]string{"fleet-us": "5"},
+		},
+		"allocation rate": {
+			metric:   FleetAllocationRate,
+			selector: "region in (eu, us)",
+			values:   map[string]string{"fleet-eu": "500m", "fleet-us": "0"},
+		},
+		"no match": {
+			metric:   FleetReplicas,
+			selector: "region=asia",
+			values:   map[string]string{},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			code, list := get(t, "/apis/"+GroupVersion+"/namespaces/default/"+v.metric, v.selector)
+			assert.Equal(t, http.StatusOK, code)
+			if !assert.NotNil(t, list) {
+				return
+			}
+			assert.Equal(t, "ExternalMetricValueList", list.Kind)
+			assert.Equal(t, GroupVersion, list.APIVersion)
+
+			values := map[string]string{}
+			for _, item := range list.Items {
+				assert.Equal(t, v.metric, item.MetricName)
+				assert.Equal(t, now.Unix(), item.Timestamp.Unix())
+				if v.metric == FleetAllocationRate && assert.NotNil(t, item.WindowSeconds) {
+					assert.Equal(t, int64(60), *item.WindowSeconds)
+				}
+				name := item.MetricLabels[agonesv1.FleetNameLabel]
+				values[name] = item.Value.String()
+			}
+			assert.Equal(t, v.values, values)
+		})
+	}
+
+	t.Run("other namespace", func(t *testing.T) {
+		code, list := get(t, "/apis/"+GroupVersion+"/namespaces/other/"+FleetReplicas, "")
+		assert.Equal(t, http.StatusOK, code)
+		if assert.NotNil(t, list) {
+			assert.Empty(t, list.Items)
+		}
+	})
+
+	t.Run("invalid selector", func(t *testing.T) {
+		code, _ := get(t, "/apis/"+GroupVersion+"/namespaces/default/"+FleetReplicas, "region in (")
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("unknown metric", func(t *testing.T) {
+		code, _ := get(t, "/apis/"+GroupVersion+"/namespaces/default/agones_fleet_unknown", "")
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("discovery", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodGet, "/apis/"+GroupVersion, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, r)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		list := &metav1.APIResourceList{}
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), list))
+		var names []string
+		for _, r := range list.APIResources {
+			names = append(names, r.Name)
+		}
+		assert.Equal(t, []string{FleetReplicas, FleetReadyReplicas, FleetAllocatedReplicas, FleetAllocationRate}, names)
+	})
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sdk
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ErrorReason is why the SDK server rejected a request of the game server. It is the prefix of the message of the
+// FailedPrecondition gRPC status that the request fails with, so that SDKs can tell the errors apart.
+type ErrorReason string
+
+const (
+	// ErrorReasonShutdownRequested is returned by Ready() once Shutdown() has been called, or the GameServer
+	// is being deleted, as it can not be moved back to Ready
+	ErrorReasonShutdownRequested ErrorReason = "ShutdownRequested"
+	// ErrorReasonUnhealthy is returned by Ready() once the GameServer is Unhealthy, as it can not recover from it
+	ErrorReasonUnhealthy ErrorReason = "Unhealthy"
+)
+
+// errorReasons are the ErrorReasons that the SDK server returns
+var errorReasons = map[ErrorReason]bool{
+	ErrorReasonShutdownRequested: true,
+	ErrorReasonUnhealthy:         true,
+}
+
+// NewError returns the FailedPrecondition gRPC status error that the SDK server rejects a request with
+func NewError(reason ErrorReason, message string) error {
+	return status.Errorf(codes.FailedPrecondition, "%s: %s", reason, message)
+}
+
+// ReasonForError returns the ErrorReason that the SDK server rejected a request with,
+// and false if the error is not one of them
+func ReasonForError(err error) (ErrorReason, bool) {
+	s, ok := status.FromError(errors.Cause(err))
+	if !ok || s.Code() != codes.FailedPrecondition {
+		return "", false
+	}
+	i := strings.Index(s.Message(), ":")
+	if i < 0 {
+		return "", false
+	}
+	reason := ErrorReason(s.Message()[:i])
+	return reason, errorReasons[reason]
+}
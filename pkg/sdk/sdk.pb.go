@@ -518,6 +518,57 @@ func (m *GameServer_Status_Port) GetPort() int32 {
 	return 0
 }
 
+// The features supported by the SDK server, so that game servers
+// can degrade gracefully when running against an older SDK server
+type Capabilities struct {
+	// version of Agones the SDK server was built from
+	Version string `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	// map of supported feature name, e.g. "Reserve", to its stability:
+	// "alpha", "beta" or "stable"
+	Features             map[string]string `protobuf:"bytes,2,rep,name=features,proto3" json:"features,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *Capabilities) Reset()         { *m = Capabilities{} }
+func (m *Capabilities) String() string { return proto.CompactTextString(m) }
+func (*Capabilities) ProtoMessage()    {}
+func (*Capabilities) Descriptor() ([]byte, []int) {
+	return fileDescriptor_sdk_a0e878ab4087e6bc, []int{4}
+}
+func (m *Capabilities) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Capabilities.Unmarshal(m, b)
+}
+func (m *Capabilities) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Capabilities.Marshal(b, m, deterministic)
+}
+func (dst *Capabilities) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Capabilities.Merge(dst, src)
+}
+func (m *Capabilities) XXX_Size() int {
+	return xxx_messageInfo_Capabilities.Size(m)
+}
+func (m *Capabilities) XXX_DiscardUnknown() {
+	xxx_messageInfo_Capabilities.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Capabilities proto.InternalMessageInfo
+
+func (m *Capabilities) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *Capabilities) GetFeatures() map[string]string {
+	if m != nil {
+		return m.Features
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Empty)(nil), "agones.dev.sdk.Empty")
 	proto.RegisterType((*KeyValue)(nil), "agones.dev.sdk.KeyValue")
@@ -530,6 +581,8 @@ func init() {
 	proto.RegisterType((*GameServer_Spec_Health)(nil), "agones.dev.sdk.GameServer.Spec.Health")
 	proto.RegisterType((*GameServer_Status)(nil), "agones.dev.sdk.GameServer.Status")
 	proto.RegisterType((*GameServer_Status_Port)(nil), "agones.dev.sdk.GameServer.Status.Port")
+	proto.RegisterType((*Capabilities)(nil), "agones.dev.sdk.Capabilities")
+	proto.RegisterMapType((map[string]string)(nil), "agones.dev.sdk.Capabilities.FeaturesEntry")
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -562,6 +615,8 @@ type SDKClient interface {
 	SetAnnotation(ctx context.Context, in *KeyValue, opts ...grpc.CallOption) (*Empty, error)
 	// Marks the GameServer as the Reserved state for Duration
 	Reserve(ctx context.Context, in *Duration, opts ...grpc.CallOption) (*Empty, error)
+	// Retrieve the features supported by this SDK server, and their stability
+	GetCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Capabilities, error)
 }
 
 type sDKClient struct {
@@ -701,6 +756,15 @@ func (c *sDKClient) Reserve(ctx context.Context, in *Duration, opts ...grpc.Call
 	return out, nil
 }
 
+func (c *sDKClient) GetCapabilities(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Capabilities, error) {
+	out := new(Capabilities)
+	err := c.cc.Invoke(ctx, "/agones.dev.sdk.SDK/GetCapabilities", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SDKServer is the server API for SDK service.
 type SDKServer interface {
 	// Call when the GameServer is ready
@@ -721,6 +785,8 @@ type SDKServer interface {
 	SetAnnotation(context.Context, *KeyValue) (*Empty, error)
 	// Marks the GameServer as the Reserved state for Duration
 	Reserve(context.Context, *Duration) (*Empty, error)
+	// Retrieve the features supported by this SDK server, and their stability
+	GetCapabilities(context.Context, *Empty) (*Capabilities, error)
 }
 
 func RegisterSDKServer(s *grpc.Server, srv SDKServer) {
@@ -900,6 +966,24 @@ func _SDK_Reserve_Handler(srv interface{}, ctx context.Context, dec func(interfa
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SDK_GetCapabilities_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SDKServer).GetCapabilities(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/agones.dev.sdk.SDK/GetCapabilities",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SDKServer).GetCapabilities(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _SDK_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "agones.dev.sdk.SDK",
 	HandlerType: (*SDKServer)(nil),
@@ -932,6 +1016,10 @@ var _SDK_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Reserve",
 			Handler:    _SDK_Reserve_Handler,
 		},
+		{
+			MethodName: "GetCapabilities",
+			Handler:    _SDK_GetCapabilities_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -951,58 +1039,63 @@ var _SDK_serviceDesc = grpc.ServiceDesc{
 func init() { proto.RegisterFile("sdk.proto", fileDescriptor_sdk_a0e878ab4087e6bc) }
 
 var fileDescriptor_sdk_a0e878ab4087e6bc = []byte{
-	// 843 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x95, 0x5f, 0x8f, 0xdb, 0x44,
-	0x10, 0xc0, 0xe5, 0x4b, 0xe2, 0x24, 0x13, 0xee, 0xdf, 0xde, 0x55, 0x72, 0xad, 0x8a, 0x16, 0x8b,
-	0xa2, 0xe3, 0x10, 0x36, 0xa4, 0x12, 0xa2, 0x27, 0x54, 0xa9, 0xe8, 0x4a, 0x8b, 0x5a, 0x28, 0x72,
-	0xaa, 0x16, 0xf1, 0x12, 0xed, 0x79, 0x87, 0xc4, 0x9c, 0xe3, 0xb5, 0x76, 0x37, 0x57, 0xe5, 0x11,
-	0x1e, 0xf8, 0x02, 0x3c, 0xf1, 0x05, 0x78, 0xe2, 0xdb, 0xf0, 0x15, 0xf8, 0x0a, 0xbc, 0xa3, 0x9d,
-	0xb5, 0x2f, 0xe1, 0xe8, 0xb5, 0x17, 0x78, 0xca, 0xce, 0xbf, 0xdf, 0x6c, 0xc6, 0x33, 0xb3, 0xd0,
-	0xd7, 0xe2, 0x34, 0xae, 0x94, 0x34, 0x92, 0x6d, 0xf1, 0x89, 0x2c, 0x51, 0xc7, 0x02, 0xcf, 0x62,
-	0x2d, 0x4e, 0xc3, 0x1b, 0x13, 0x29, 0x27, 0x05, 0x26, 0xbc, 0xca, 0x13, 0x5e, 0x96, 0xd2, 0x70,
-	0x93, 0xcb, 0x52, 0x3b, 0xef, 0xa8, 0x0b, 0x9d, 0x07, 0xb3, 0xca, 0x2c, 0xa2, 0x21, 0xf4, 0x1e,
-	0xe3, 0xe2, 0x39, 0x2f, 0xe6, 0xc8, 0x76, 0xa0, 0x75, 0x8a, 0x8b, 0xc0, 0xbb, 0xe5, 0x1d, 0xf4,
-	0x53, 0x7b, 0x64, 0xfb, 0xd0, 0x39, 0xb3, 0xa6, 0x60, 0x83, 0x74, 0x4e, 0x88, 0xde, 0x85, 0xde,
-	0xf1, 0x5c, 0x11, 0x8f, 0x05, 0xd0, 0xd5, 0x98, 0xc9, 0x52, 0x68, 0x8a, 0x6b, 0xa5, 0x8d, 0x18,
-	0xfd, 0xd8, 0x07, 0x78, 0xc8, 0x67, 0x38, 0x42, 0x75, 0x86, 0x8a, 0x7d, 0x01, 0x03, 0x79, 0xf2,
-	0x03, 0x66, 0x66, 0x3c, 0x43, 0xc3, 0xc9, 0x79, 0x30, 0xbc, 0x1d, 0xff, 0xf3, 0xd6, 0xf1, 0x32,
-	0x20, 0x7e, 0x4a, 0xde, 0x5f, 0xa1, 0xe1, 0x29, 0xc8, 0xf3, 0x33, 0xbb, 0x03, 0x6d, 0x5d, 0x61,
-	0x46, 0x37, 0x1a, 0x0c, 0x6f, 0xbe, 0x06, 0x30, 0xaa, 0x30, 0x4b, 0xc9, 0x99, 0xdd, 0x05, 0x5f,
-	0x1b, 0x6e, 0xe6, 0x3a, 0x68, 0x51, 0xd8, 0x3b, 0xaf, 0x0b, 0x23, 0xc7, 0xb4, 0x0e, 0x08, 0x7f,
-	0x6d, 0x03, 0x2c, 0xaf, 0xc2, 0x18, 0xb4, 0x4b, 0x3e, 0xc3, 0xba, 0x48, 0x74, 0x66, 0x37, 0xa0,
-	0x6f, 0x7f, 0x75, 0xc5, 0xb3, 0xa6, 0x52, 0x4b, 0x85, 0xad, 0xea, 0x3c, 0x17, 0x94, 0xb8, 0x9f,
-	0xda, 0x23, 0x7b, 0x1f, 0x76, 0x14, 0x6a, 0x39, 0x57, 0x19, 0x8e, 0xcf, 0x50, 0xe9, 0x5c, 0x96,
-	0x41, 0x9b, 0xcc, 0xdb, 0x8d, 0xfe, 0xb9, 0x53, 0xb3, 0xb7, 0x01, 0x26, 0x58, 0xa2, 0x2b, 0x76,
-	0xd0, 0xa1, 0x0a, 0xaf, 0x68, 0xd8, 0x87, 0xc0, 0x32, 0x85, 0x74, 0x1e, 0x9b, 0x7c, 0x86, 0xda,
-	0xf0, 0x59, 0x15, 0xf8, 0xe4, 0xb7, 0xdb, 0x58, 0x9e, 0x35, 0x06, 0xeb, 0x2e, 0xb0, 0xc0, 0x0b,
-	0xee, 0x5d, 0xe7, 0xde, 0x58, 0x96, 0xee, 0xdf, 0xc2, 0x60, 0xa5, 0x75, 0x82, 0xde, 0xad, 0xd6,
-	0xc1, 0x60, 0xf8, 0xc9, 0x95, 0xbe, 0x59, 0x7c, 0x7f, 0x19, 0xf8, 0xa0, 0x34, 0x6a, 0x91, 0xae,
-	0xa2, 0xd8, 0x97, 0xe0, 0x17, 0xfc, 0x04, 0x0b, 0x1d, 0xf4, 0x09, 0xfa, 0xf1, 0xd5, 0xa0, 0x4f,
-	0x28, 0xc6, 0xf1, 0x6a, 0x40, 0x78, 0x0f, 0x76, 0x2e, 0xe6, 0xba, 0x6a, 0x27, 0x1f, 0x6d, 0x7c,
-	0xea, 0x85, 0x77, 0x61, 0xb0, 0x82, 0x5d, 0x2b, 0xf4, 0x2f, 0x0f, 0xda, 0xb6, 0xcb, 0xd8, 0x3d,
-	0xf0, 0xa7, 0xc8, 0x0b, 0x33, 0xad, 0xfb, 0xfa, 0xbd, 0x37, 0xb4, 0x65, 0xfc, 0x88, 0xbc, 0xd3,
-	0x3a, 0x2a, 0xfc, 0xdd, 0x03, 0xdf, 0xa9, 0x58, 0x08, 0x3d, 0x91, 0x6b, 0x7e, 0x52, 0xa0, 0x20,
-	0x58, 0x2f, 0x3d, 0x97, 0xd9, 0x6d, 0xd8, 0xaa, 0x50, 0xe5, 0x52, 0x8c, 0x9b, 0x99, 0xb3, 0x57,
-	0xea, 0xa4, 0x9b, 0x4e, 0x3b, 0x72, 0x4a, 0xf6, 0x01, 0xec, 0x7e, 0xcf, 0xf3, 0x62, 0xae, 0x70,
-	0x6c, 0xa6, 0x0a, 0xf5, 0x54, 0x16, 0xae, 0xff, 0x3a, 0xe9, 0x4e, 0x6d, 0x78, 0xd6, 0xe8, 0xd9,
-	0x10, 0xae, 0xe5, 0x65, 0x6e, 0x72, 0x5e, 0x8c, 0x05, 0x16, 0x7c, 0x71, 0x8e, 0x6e, 0x53, 0xc0,
-	0x5e, 0x6d, 0x3c, 0xb6, 0xb6, 0x3a, 0x41, 0xf8, 0x9b, 0x07, 0xbe, 0x1b, 0x13, 0x5b, 0x1c, 0x3b,
-	0x28, 0xcd, 0x40, 0x38, 0xc1, 0x6e, 0x05, 0x2e, 0x84, 0x42, 0xad, 0xeb, 0xa2, 0x35, 0x22, 0xfb,
-	0x0c, 0x3a, 0x95, 0x54, 0xc6, 0x0e, 0x62, 0xeb, 0x4d, 0x85, 0xa2, 0x0c, 0xf1, 0x37, 0x52, 0x99,
-	0xd4, 0x05, 0x85, 0x31, 0xb4, 0xad, 0xf8, 0xca, 0x29, 0x64, 0xd0, 0xb6, 0x4e, 0x75, 0x49, 0xe8,
-	0x3c, 0xfc, 0xd9, 0x87, 0xd6, 0xe8, 0xf8, 0x31, 0x7b, 0x04, 0x9d, 0x14, 0xb9, 0x58, 0xb0, 0x6b,
-	0x17, 0xf3, 0xd1, 0x16, 0x0c, 0x5f, 0xad, 0x8e, 0x76, 0x7f, 0xfa, 0xe3, 0xcf, 0x5f, 0x36, 0x06,
-	0x91, 0x9f, 0x28, 0x1b, 0x7d, 0xe4, 0x1d, 0xb2, 0xaf, 0xa1, 0x77, 0xbf, 0x28, 0x64, 0x66, 0xff,
-	0xe5, 0x7a, 0xb0, 0x7d, 0x82, 0x6d, 0x45, 0xfd, 0x84, 0xd7, 0x80, 0x9a, 0x37, 0x9a, 0xce, 0x8d,
-	0x90, 0x2f, 0xcb, 0xff, 0xcc, 0xd3, 0x35, 0xc0, 0xf2, 0x9e, 0x9c, 0x37, 0xd2, 0x7a, 0x34, 0x46,
-	0xb4, 0xb7, 0xa2, 0x6e, 0xe2, 0x5a, 0xf2, 0xc8, 0x3b, 0x3c, 0xf0, 0xd8, 0x0b, 0xd8, 0x7c, 0x88,
-	0x66, 0x65, 0x8b, 0x5f, 0x02, 0x0d, 0x2f, 0xff, 0x8c, 0xd1, 0x1e, 0x91, 0x37, 0xd9, 0x20, 0x99,
-	0xd8, 0x9d, 0xe8, 0x38, 0x1c, 0xb6, 0x5f, 0x70, 0x93, 0x4d, 0xff, 0x1f, 0xfa, 0x3a, 0xa1, 0xf7,
-	0xd8, 0x6e, 0xf2, 0xd2, 0xc2, 0x56, 0x12, 0x7c, 0x64, 0xef, 0xde, 0x1b, 0xa1, 0xa1, 0xd1, 0x66,
-	0xc1, 0x45, 0x48, 0xf3, 0xe6, 0x5d, 0x56, 0x8e, 0x90, 0xc8, 0xfb, 0xe1, 0x76, 0x62, 0x5f, 0x2b,
-	0xc1, 0x0d, 0x4f, 0x68, 0xdd, 0xd8, 0x12, 0x73, 0xd8, 0x1c, 0xa1, 0x59, 0xee, 0x9c, 0xf5, 0xe9,
-	0x37, 0x89, 0x7e, 0x3d, 0xdc, 0x5f, 0xd2, 0x97, 0xcb, 0xd1, 0xa6, 0x78, 0x0a, 0xdd, 0xd4, 0xfd,
-	0x93, 0x7f, 0xc3, 0x9b, 0xa7, 0xf7, 0x32, 0x78, 0x5d, 0xef, 0xa8, 0x97, 0x28, 0x87, 0x38, 0xf2,
-	0x0e, 0x3f, 0xef, 0x7c, 0xd7, 0xd2, 0xe2, 0xf4, 0xc4, 0xa7, 0xd7, 0xff, 0xce, 0xdf, 0x01, 0x00,
-	0x00, 0xff, 0xff, 0x42, 0xa7, 0x46, 0xe0, 0x38, 0x08, 0x00, 0x00,
+	// 918 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x96, 0xe1, 0x6e, 0x1b, 0x45,
+	0x10, 0xc7, 0x75, 0xb1, 0x7d, 0x39, 0x8f, 0xeb, 0x38, 0xd9, 0x24, 0xd2, 0xf5, 0x14, 0xd1, 0x72,
+	0xa2, 0x28, 0x04, 0x71, 0x07, 0xae, 0x84, 0x68, 0x40, 0x95, 0x0a, 0x69, 0x52, 0xd4, 0x42, 0xd1,
+	0xb9, 0x6a, 0x11, 0x12, 0xb2, 0xd6, 0x77, 0x53, 0xfb, 0xc8, 0xf9, 0xf6, 0xb4, 0xbb, 0x4e, 0xe5,
+	0x8f, 0xf0, 0x0a, 0x7c, 0xe2, 0x05, 0xf8, 0x04, 0x4f, 0xc3, 0x2b, 0xc0, 0x23, 0xf0, 0x1d, 0xed,
+	0xee, 0x9d, 0xed, 0x98, 0x3a, 0x8d, 0xe1, 0x93, 0x77, 0x67, 0xe6, 0xff, 0xdb, 0xf1, 0xdc, 0xcc,
+	0xde, 0x41, 0x53, 0x24, 0xe7, 0x41, 0xc1, 0x99, 0x64, 0x64, 0x8b, 0x0e, 0x59, 0x8e, 0x22, 0x48,
+	0xf0, 0x22, 0x10, 0xc9, 0xb9, 0x77, 0x30, 0x64, 0x6c, 0x98, 0x61, 0x48, 0x8b, 0x34, 0xa4, 0x79,
+	0xce, 0x24, 0x95, 0x29, 0xcb, 0x85, 0x89, 0xf6, 0x37, 0xa1, 0xf1, 0x70, 0x5c, 0xc8, 0xa9, 0xdf,
+	0x05, 0xe7, 0x31, 0x4e, 0x9f, 0xd3, 0x6c, 0x82, 0x64, 0x1b, 0x6a, 0xe7, 0x38, 0x75, 0xad, 0xdb,
+	0xd6, 0x61, 0x33, 0x52, 0x4b, 0xb2, 0x07, 0x8d, 0x0b, 0xe5, 0x72, 0x37, 0xb4, 0xcd, 0x6c, 0xfc,
+	0x77, 0xc0, 0x39, 0x99, 0x70, 0xcd, 0x23, 0x2e, 0x6c, 0x0a, 0x8c, 0x59, 0x9e, 0x08, 0xad, 0xab,
+	0x45, 0xd5, 0xd6, 0xff, 0xb1, 0x09, 0x70, 0x46, 0xc7, 0xd8, 0x43, 0x7e, 0x81, 0x9c, 0x9c, 0x42,
+	0x8b, 0x0d, 0x7e, 0xc0, 0x58, 0xf6, 0xc7, 0x28, 0xa9, 0x0e, 0x6e, 0x75, 0xef, 0x04, 0x97, 0xb3,
+	0x0e, 0xe6, 0x82, 0xe0, 0xa9, 0x8e, 0xfe, 0x0a, 0x25, 0x8d, 0x80, 0xcd, 0xd6, 0xe4, 0x2e, 0xd4,
+	0x45, 0x81, 0xb1, 0xce, 0xa8, 0xd5, 0xbd, 0x75, 0x05, 0xa0, 0x57, 0x60, 0x1c, 0xe9, 0x60, 0x72,
+	0x0f, 0x6c, 0x21, 0xa9, 0x9c, 0x08, 0xb7, 0xa6, 0x65, 0x6f, 0x5f, 0x25, 0xd3, 0x81, 0x51, 0x29,
+	0xf0, 0x7e, 0xa9, 0x03, 0xcc, 0x53, 0x21, 0x04, 0xea, 0x39, 0x1d, 0x63, 0x59, 0x24, 0xbd, 0x26,
+	0x07, 0xd0, 0x54, 0xbf, 0xa2, 0xa0, 0x71, 0x55, 0xa9, 0xb9, 0x41, 0x55, 0x75, 0x92, 0x26, 0xfa,
+	0xe0, 0x66, 0xa4, 0x96, 0xe4, 0x3d, 0xd8, 0xe6, 0x28, 0xd8, 0x84, 0xc7, 0xd8, 0xbf, 0x40, 0x2e,
+	0x52, 0x96, 0xbb, 0x75, 0xed, 0xee, 0x54, 0xf6, 0xe7, 0xc6, 0x4c, 0xde, 0x02, 0x18, 0x62, 0x8e,
+	0xa6, 0xd8, 0x6e, 0x43, 0x57, 0x78, 0xc1, 0x42, 0x3e, 0x00, 0x12, 0x73, 0xd4, 0xeb, 0xbe, 0x4c,
+	0xc7, 0x28, 0x24, 0x1d, 0x17, 0xae, 0xad, 0xe3, 0x76, 0x2a, 0xcf, 0xb3, 0xca, 0xa1, 0xc2, 0x13,
+	0xcc, 0x70, 0x29, 0x7c, 0xd3, 0x84, 0x57, 0x9e, 0x79, 0xf8, 0xb7, 0xd0, 0x5a, 0x68, 0x1d, 0xd7,
+	0xb9, 0x5d, 0x3b, 0x6c, 0x75, 0x3f, 0xbe, 0xd6, 0x33, 0x0b, 0x1e, 0xcc, 0x85, 0x0f, 0x73, 0xc9,
+	0xa7, 0xd1, 0x22, 0x8a, 0x7c, 0x09, 0x76, 0x46, 0x07, 0x98, 0x09, 0xb7, 0xa9, 0xa1, 0x1f, 0x5d,
+	0x0f, 0xfa, 0x44, 0x6b, 0x0c, 0xaf, 0x04, 0x78, 0xf7, 0x61, 0x7b, 0xf9, 0xac, 0xeb, 0x76, 0xf2,
+	0xf1, 0xc6, 0x27, 0x96, 0x77, 0x0f, 0x5a, 0x0b, 0xd8, 0xb5, 0xa4, 0x7f, 0x5b, 0x50, 0x57, 0x5d,
+	0x46, 0xee, 0x83, 0x3d, 0x42, 0x9a, 0xc9, 0x51, 0xd9, 0xd7, 0xef, 0xbe, 0xa1, 0x2d, 0x83, 0x47,
+	0x3a, 0x3a, 0x2a, 0x55, 0xde, 0x6f, 0x16, 0xd8, 0xc6, 0x44, 0x3c, 0x70, 0x92, 0x54, 0xd0, 0x41,
+	0x86, 0x89, 0x86, 0x39, 0xd1, 0x6c, 0x4f, 0xee, 0xc0, 0x56, 0x81, 0x3c, 0x65, 0x49, 0xbf, 0x9a,
+	0x39, 0x95, 0x52, 0x23, 0x6a, 0x1b, 0x6b, 0xcf, 0x18, 0xc9, 0xfb, 0xb0, 0xf3, 0x92, 0xa6, 0xd9,
+	0x84, 0x63, 0x5f, 0x8e, 0x38, 0x8a, 0x11, 0xcb, 0x4c, 0xff, 0x35, 0xa2, 0xed, 0xd2, 0xf1, 0xac,
+	0xb2, 0x93, 0x2e, 0xec, 0xa7, 0x79, 0x2a, 0x53, 0x9a, 0xf5, 0x13, 0xcc, 0xe8, 0x74, 0x86, 0xae,
+	0x6b, 0xc1, 0x6e, 0xe9, 0x3c, 0x51, 0xbe, 0xf2, 0x00, 0xef, 0x57, 0x0b, 0x6c, 0x33, 0x26, 0xaa,
+	0x38, 0x6a, 0x50, 0xaa, 0x81, 0x30, 0x1b, 0x75, 0x2b, 0xd0, 0x24, 0xe1, 0x28, 0x44, 0x59, 0xb4,
+	0x6a, 0x4b, 0x3e, 0x83, 0x46, 0xc1, 0xb8, 0x54, 0x83, 0x58, 0x7b, 0x53, 0xa1, 0xf4, 0x09, 0xc1,
+	0x37, 0x8c, 0xcb, 0xc8, 0x88, 0xbc, 0x00, 0xea, 0x6a, 0xfb, 0xda, 0x29, 0x24, 0x50, 0x57, 0x41,
+	0x65, 0x49, 0xf4, 0xda, 0xff, 0xdd, 0x82, 0x1b, 0x5f, 0xd0, 0x82, 0x0e, 0xd2, 0x2c, 0x95, 0x29,
+	0x0a, 0x95, 0x58, 0x35, 0x71, 0x46, 0x5b, 0x6d, 0xc9, 0x29, 0x38, 0x2f, 0x91, 0xca, 0x09, 0x47,
+	0x95, 0xb3, 0xca, 0xed, 0x68, 0x39, 0xb7, 0x45, 0x52, 0x70, 0x5a, 0x06, 0x9b, 0x66, 0x9c, 0x69,
+	0xbd, 0x4f, 0xa1, 0x7d, 0xc9, 0xb5, 0x4e, 0x43, 0x75, 0xff, 0xb2, 0xa1, 0xd6, 0x3b, 0x79, 0x4c,
+	0x1e, 0x41, 0x23, 0x42, 0x9a, 0x4c, 0xc9, 0xfe, 0x72, 0x0e, 0xfa, 0xd6, 0xf6, 0x5e, 0x6f, 0xf6,
+	0x77, 0x7e, 0xfa, 0xe3, 0xcf, 0x9f, 0x37, 0x5a, 0xbe, 0x1d, 0x72, 0xa5, 0x3e, 0xb6, 0x8e, 0xc8,
+	0xd7, 0xe0, 0x3c, 0xc8, 0x32, 0x16, 0xab, 0xa7, 0xb2, 0x1e, 0x6c, 0x4f, 0xc3, 0xb6, 0xfc, 0x66,
+	0x48, 0x4b, 0x40, 0xc9, 0xeb, 0x8d, 0x26, 0x32, 0x61, 0xaf, 0xf2, 0xff, 0xcc, 0x13, 0x25, 0x40,
+	0xf1, 0x9e, 0xcc, 0x1a, 0x7f, 0x3d, 0x1a, 0xd1, 0xb4, 0x1b, 0xfe, 0x66, 0x68, 0x46, 0xe8, 0xd8,
+	0x3a, 0x3a, 0xb4, 0xc8, 0x0b, 0x68, 0x9f, 0xa1, 0x5c, 0x78, 0xeb, 0xac, 0x80, 0x7a, 0xab, 0xdb,
+	0xce, 0xdf, 0xd5, 0xe4, 0x36, 0x69, 0x85, 0x43, 0x75, 0x87, 0x1b, 0x0e, 0x85, 0xce, 0x0b, 0x2a,
+	0xe3, 0xd1, 0xff, 0x43, 0xdf, 0xd4, 0xe8, 0x5d, 0xb2, 0x13, 0xbe, 0x52, 0xb0, 0x85, 0x03, 0x3e,
+	0x54, 0xb9, 0x3b, 0x3d, 0x94, 0xfa, 0x2a, 0x22, 0xee, 0x32, 0xa4, 0x7a, 0x47, 0xaf, 0x2a, 0x87,
+	0xa7, 0xc9, 0x7b, 0x5e, 0x27, 0x54, 0x6f, 0xd7, 0x84, 0x4a, 0x1a, 0xea, 0xeb, 0x51, 0x95, 0x98,
+	0x42, 0xbb, 0x87, 0x72, 0x7e, 0x47, 0xae, 0x4f, 0xbf, 0xa5, 0xe9, 0x37, 0xbd, 0xbd, 0x39, 0x7d,
+	0x7e, 0x99, 0xab, 0x23, 0x9e, 0xc2, 0x66, 0x64, 0xfe, 0xc9, 0xbf, 0xe1, 0xd5, 0xa7, 0xc2, 0x2a,
+	0x78, 0x59, 0x6f, 0xdf, 0x09, 0xb9, 0x41, 0x28, 0xe0, 0xf7, 0xd0, 0x39, 0x43, 0x79, 0x69, 0x74,
+	0x57, 0xd4, 0xfb, 0xe0, 0xaa, 0x29, 0xf5, 0xf7, 0x35, 0xbc, 0x43, 0xda, 0x61, 0xbc, 0x60, 0xfe,
+	0xbc, 0xf1, 0x5d, 0x4d, 0x24, 0xe7, 0x03, 0x5b, 0x7f, 0x0c, 0xdd, 0xfd, 0x67, 0x00, 0x39, 0x12,
+	0x2d, 0xcb, 0x47, 0x09, 0x00, 0x00,
 }
@@ -187,6 +187,9 @@ func (l *LocalSDKServer) Ready(context.Context, *sdk.Empty) (*sdk.Empty, error)
 	l.gsMutex.Lock()
 	defer l.gsMutex.Unlock()
 
+	if l.gsState == agonesv1.GameServerStateShutdown {
+		return nil, sdk.NewError(sdk.ErrorReasonShutdownRequested, "the GameServer is shutting down, and can not be moved to Ready")
+	}
 	// Follow the GameServer state diagram
 	l.updateState(agonesv1.GameServerStateReady)
 	l.stopReserveTimer()
@@ -213,6 +216,9 @@ func (l *LocalSDKServer) Shutdown(context.Context, *sdk.Empty) (*sdk.Empty, erro
 	l.recordRequest("shutdown")
 	l.gsMutex.Lock()
 	defer l.gsMutex.Unlock()
+	if l.gsState == agonesv1.GameServerStateShutdown {
+		return &sdk.Empty{}, nil
+	}
 	l.updateState(agonesv1.GameServerStateShutdown)
 	l.stopReserveTimer()
 	l.update <- struct{}{}
@@ -325,6 +331,13 @@ func (l *LocalSDKServer) Reserve(ctx context.Context, d *sdk.Duration) (*sdk.Emp
 	return &sdk.Empty{}, nil
 }
 
+// GetCapabilities returns the version and supported features of the SDK server
+func (l *LocalSDKServer) GetCapabilities(context.Context, *sdk.Empty) (*sdk.Capabilities, error) {
+	logrus.Info("GetCapabilities request has been received!")
+	l.recordRequest("capabilities")
+	return capabilities(), nil
+}
+
 func (l *LocalSDKServer) resetReserveAfter(ctx context.Context, duration time.Duration) {
 	if l.reserveTimer != nil {
 		l.reserveTimer.Stop()
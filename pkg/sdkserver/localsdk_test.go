@@ -22,6 +22,7 @@ import (
 	"testing"
 	"time"
 
+	"agones.dev/agones/pkg"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"agones.dev/agones/pkg/sdk"
 	"github.com/stretchr/testify/assert"
@@ -42,6 +43,13 @@ func TestLocal(t *testing.T) {
 	_, err = l.Shutdown(ctx, e)
 	assert.Nil(t, err, "Shutdown should not error")
 
+	_, err = l.Ready(ctx, e)
+	reason, _ := sdk.ReasonForError(err)
+	assert.Equal(t, sdk.ErrorReasonShutdownRequested, reason, "Ready should error after Shutdown")
+
+	_, err = l.Shutdown(ctx, e)
+	assert.Nil(t, err, "Shutdown should not error when called twice")
+
 	wg := sync.WaitGroup{}
 	wg.Add(1)
 	stream := newEmptyMockStream()
@@ -61,6 +69,11 @@ func TestLocal(t *testing.T) {
 	assert.Nil(t, err)
 
 	assert.Equal(t, defaultGs, gs)
+
+	c, err := l.GetCapabilities(ctx, e)
+	assert.Nil(t, err)
+	assert.Equal(t, pkg.Version, c.Version)
+	assert.Equal(t, "stable", c.Features["Ready"])
 }
 
 func TestLocalSDKWithTestMode(t *testing.T) {
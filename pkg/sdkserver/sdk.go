@@ -15,6 +15,7 @@
 package sdkserver
 
 import (
+	"agones.dev/agones/pkg"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"agones.dev/agones/pkg/sdk"
 )
@@ -22,8 +23,57 @@ import (
 const (
 	// metadataPrefix prefix for labels and annotations
 	metadataPrefix = "agones.dev/sdk-"
+
+	// featureAlpha is the stage of a feature that may change, or be removed, without notice
+	featureAlpha = "alpha"
+	// featureStable is the stage of a feature that will not change in incompatible ways
+	featureStable = "stable"
 )
 
+// sdkFeatures is the set of SDK calls, and SDK server features, that this SDK server supports, and their stage:
+// "alpha", "beta" or "stable". Every SDK call must be listed, which is tested.
+// Add new SDK calls and features here as they are introduced, and promote them as they stabilise.
+var sdkFeatures = map[string]string{
+	"Ready":           featureStable,
+	"Allocate":        featureStable,
+	"Shutdown":        featureStable,
+	"Health":          featureStable,
+	"GetGameServer":   featureStable,
+	"WatchGameServer": featureStable,
+	"SetLabel":        featureStable,
+	"SetAnnotation":   featureStable,
+	"Reserve":         featureStable,
+	"GetCapabilities": featureAlpha,
+	// AcknowledgeAllocation acknowledges allocations that wait for it, through SetAnnotation
+	"AcknowledgeAllocation": featureAlpha,
+	// ErrorReasons are the reasons that invalid Ready, Allocate and Shutdown calls are rejected with
+	"ErrorReasons": featureAlpha,
+	// DisconnectGracePeriod stops allocating the GameServer once the game server disconnected for too long
+	"DisconnectGracePeriod": featureAlpha,
+	// SessionMaxDuration starts the session cap of the GameServer when it is allocated
+	"SessionMaxDuration": featureAlpha,
+}
+
+// logTailFeature is the feature of serving the tail of the game server container log on /logs,
+// which is only supported when it is enabled
+const logTailFeature = "LogTail"
+
+// capabilities returns the version and supported features of this SDK server, with the given
+// optional features that are enabled
+func capabilities(enabled ...string) *sdk.Capabilities {
+	features := make(map[string]string, len(sdkFeatures)+len(enabled))
+	for k, v := range sdkFeatures {
+		features[k] = v
+	}
+	for _, k := range enabled {
+		features[k] = featureAlpha
+	}
+	return &sdk.Capabilities{
+		Version:  pkg.Version,
+		Features: features,
+	}
+}
+
 // convert converts a K8s GameServer object, into a gRPC SDK GameServer object
 func convert(gs *agonesv1.GameServer) *sdk.GameServer {
 	meta := gs.ObjectMeta
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -35,6 +36,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/status"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/fields"
@@ -54,6 +56,13 @@ const (
 	updateState      Operation = "updateState"
 	updateLabel      Operation = "updateLabel"
 	updateAnnotation Operation = "updateAnnotation"
+	updateCondition  Operation = "updateCondition"
+	updateRejection  Operation = "updateRejection"
+
+	// defaultLogTailLines is how many lines of the game server container log are served by default
+	defaultLogTailLines = 100
+	// maxLogTailLines is the most lines of the game server container log that can be requested
+	maxLogTailLines = 5000
 )
 
 var _ sdk.SDKServer = &SDKServer{}
@@ -88,6 +97,19 @@ type SDKServer struct {
 	gsWaitForSync      sync.WaitGroup
 	reserveTimer       *time.Timer
 	gsReserveDuration  *time.Duration
+	// gsRejection is the SDKRequestRejected condition of the last state change request that was rejected
+	gsRejection *agonesv1.GameServerCondition
+	// connectionMutex guards the tracking of the SDK connections, for Health.DisconnectGracePeriodSeconds
+	connectionMutex       sync.Mutex
+	disconnectGracePeriod time.Duration
+	sdkConnections        int
+	sdkConnected          corev1.ConditionStatus
+	// disconnectTimer is the grace period of the pending disconnect, which is cancelled by closing disconnectCancel
+	disconnectTimer  clock.Timer
+	disconnectCancel chan struct{}
+	// logTailContainer is the game server container whose log is served on /logs, if enabled
+	logTailContainer string
+	podLogs          func(container string, tailLines int64) (io.ReadCloser, error)
 }
 
 // NewSDKServer creates a SDKServer that sets up an
@@ -124,6 +146,10 @@ func NewSDKServer(gameServerName, namespace string, kubeClient kubernetes.Interf
 	}
 
 	s.informerFactory = factory
+	s.podLogs = func(container string, tailLines int64) (io.ReadCloser, error) {
+		opts := &corev1.PodLogOptions{Container: container, TailLines: &tailLines}
+		return kubeClient.CoreV1().Pods(namespace).GetLogs(gameServerName, opts).Stream()
+	}
 	s.logger = runtime.NewLoggerWithType(s).WithField("gsKey", namespace+"/"+gameServerName)
 
 	gameServers.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
@@ -157,6 +183,8 @@ func NewSDKServer(gameServerName, namespace string, kubeClient kubernetes.Interf
 		}
 	})
 
+	mux.HandleFunc("/logs", s.logTail)
+
 	// we haven't synced yet
 	s.gsWaitForSync.Add(1)
 	s.workerqueue = workerqueue.NewWorkerQueue(
@@ -176,6 +204,12 @@ func (s *SDKServer) initHealthLastUpdated(healthInitialDelay time.Duration) {
 	s.healthLastUpdated = s.clock.Now().UTC().Add(healthInitialDelay)
 }
 
+// EnableLogTail serves the tail of the log of the given game server container on /logs, through the
+// Kubernetes API, so it can be read without access to the Pod logs. Must be called before Run.
+func (s *SDKServer) EnableLogTail(container string) {
+	s.logTailContainer = container
+}
+
 // Run processes the rate limited queue.
 // Will block until stop is closed
 func (s *SDKServer) Run(stop <-chan struct{}) error {
@@ -209,6 +243,9 @@ func (s *SDKServer) Run(stop <-chan struct{}) error {
 	s.logger.WithField("health", s.health).Info("Setting health configuration")
 	s.healthTimeout = time.Duration(gs.Spec.Health.PeriodSeconds) * time.Second
 	s.initHealthLastUpdated(time.Duration(gs.Spec.Health.InitialDelaySeconds) * time.Second)
+	s.connectionMutex.Lock()
+	s.disconnectGracePeriod = time.Duration(gs.Spec.Health.DisconnectGracePeriodSeconds) * time.Second
+	s.connectionMutex.Unlock()
 
 	if gs.Status.State == agonesv1.GameServerStateReserved && gs.Status.ReservedUntil != nil {
 		s.gsUpdateMutex.Lock()
@@ -253,6 +290,10 @@ func (s *SDKServer) syncGameServer(key string) error {
 		return s.updateLabels()
 	case updateAnnotation:
 		return s.updateAnnotations()
+	case updateCondition:
+		return s.updateSDKConnected()
+	case updateRejection:
+		return s.updateSDKRequestRejected()
 	}
 
 	return errors.Errorf("could not sync game server key: %s", key)
@@ -285,6 +326,7 @@ func (s *SDKServer) updateState() error {
 	}
 
 	s.gsUpdateMutex.RLock()
+	previous := gs.Status.State
 	gs.Status.State = s.gsState
 
 	// If we are setting the Reserved status, check for the duration, and set that too.
@@ -296,6 +338,15 @@ func (s *SDKServer) updateState() error {
 	}
 	s.gsUpdateMutex.RUnlock()
 
+	// The session cap runs from when the GameServer was first moved to Allocated
+	if gs.Status.State == agonesv1.GameServerStateAllocated {
+		if previous != agonesv1.GameServerStateAllocated {
+			gs.StartSession(time.Now())
+		}
+	} else {
+		gs.Status.AllocatedUntil = nil
+	}
+
 	_, err = gameServers.Update(gs)
 	if err != nil {
 		return errors.Wrapf(err, "could not update GameServer %s/%s to state %s", s.namespace, s.gameServerName, gs.Status.State)
@@ -377,6 +428,126 @@ func (s *SDKServer) updateAnnotations() error {
 	return err
 }
 
+// updateSDKConnected sets the SDKConnected condition of the GameServer to the one persisted in SDKServer,
+// i.e. SDKServer.sdkConnected
+func (s *SDKServer) updateSDKConnected() error {
+	s.connectionMutex.Lock()
+	status := s.sdkConnected
+	gracePeriod := s.disconnectGracePeriod
+	s.connectionMutex.Unlock()
+	s.logger.WithField("status", status).Info("Updating SDKConnected condition")
+
+	gs, err := s.gameServer()
+	if err != nil {
+		return err
+	}
+	if gs.IsBeingDeleted() {
+		s.logger.Info("GameServerState being shutdown. Skipping update.")
+		return nil
+	}
+
+	condition := agonesv1.GameServerCondition{
+		Type:               agonesv1.GameServerConditionSDKConnected,
+		Status:             status,
+		LastTransitionTime: metav1.NewTime(s.clock.Now().UTC()),
+		Reason:             "Connected",
+		Message:            "SDK connected",
+	}
+	if status == corev1.ConditionFalse {
+		condition.Reason = "Disconnected"
+		condition.Message = fmt.Sprintf("SDK disconnected for longer than %s", gracePeriod)
+	}
+
+	gsCopy := gs.DeepCopy()
+	if !gsCopy.Status.SetCondition(condition) {
+		return nil
+	}
+	gs, err = s.gameServerGetter.GameServers(s.namespace).Update(gsCopy)
+	if err != nil {
+		return errors.Wrapf(err, "could not update SDKConnected condition of GameServer %s/%s", s.namespace, s.gameServerName)
+	}
+
+	if status == corev1.ConditionFalse {
+		s.recorder.Event(gs, corev1.EventTypeWarning, "SDKDisconnected", condition.Message)
+	}
+	return nil
+}
+
+// updateSDKRequestRejected sets the SDKRequestRejected condition of the GameServer to the one persisted in
+// SDKServer, i.e. SDKServer.gsRejection
+func (s *SDKServer) updateSDKRequestRejected() error {
+	s.gsUpdateMutex.RLock()
+	condition := *s.gsRejection
+	s.gsUpdateMutex.RUnlock()
+	s.logger.WithField("reason", condition.Reason).Info("Updating SDKRequestRejected condition")
+
+	gs, err := s.gameServer()
+	if err != nil {
+		return err
+	}
+	// a GameServer that is being deleted is not updated any more
+	if !gs.ObjectMeta.DeletionTimestamp.IsZero() {
+		s.logger.Info("GameServer being deleted. Skipping update.")
+		return nil
+	}
+
+	gsCopy := gs.DeepCopy()
+	if !gsCopy.Status.SetCondition(condition) {
+		return nil
+	}
+	gs, err = s.gameServerGetter.GameServers(s.namespace).Update(gsCopy)
+	if err != nil {
+		return errors.Wrapf(err, "could not update SDKRequestRejected condition of GameServer %s/%s", s.namespace, s.gameServerName)
+	}
+
+	s.recorder.Event(gs, corev1.EventTypeWarning, string(agonesv1.GameServerConditionSDKRequestRejected), condition.Message)
+	return nil
+}
+
+// stateChangeError returns the error that a request to move the GameServer to the state is rejected with,
+// or nil if it can still be moved to it. It is checked against the state last requested of the SDKServer, as
+// well as the current one, as the request may not have been applied yet.
+func (s *SDKServer) stateChangeError(state agonesv1.GameServerState) error {
+	s.gsUpdateMutex.RLock()
+	requested := s.gsState
+	s.gsUpdateMutex.RUnlock()
+
+	shutdown := requested == agonesv1.GameServerStateShutdown
+	unhealthy := requested == agonesv1.GameServerStateUnhealthy
+	// this is not waiting for the cache to sync, as the request would be queued until it is
+	if gs, err := s.gameServerLister.GameServers(s.namespace).Get(s.gameServerName); err == nil {
+		shutdown = shutdown || gs.IsBeingDeleted()
+		unhealthy = unhealthy || gs.Status.State == agonesv1.GameServerStateUnhealthy
+	}
+
+	switch {
+	case shutdown:
+		return sdk.NewError(sdk.ErrorReasonShutdownRequested, fmt.Sprintf("the GameServer is shutting down, and can not be moved to %s", state))
+	case unhealthy && (state == agonesv1.GameServerStateRequestReady || state == agonesv1.GameServerStateAllocated):
+		return sdk.NewError(sdk.ErrorReasonUnhealthy, fmt.Sprintf("the GameServer is Unhealthy, and can not be moved to %s", state))
+	}
+	return nil
+}
+
+// rejectStateChange surfaces the error that a state change request was rejected with as the SDKRequestRejected
+// condition of the GameServer, and returns it
+func (s *SDKServer) rejectStateChange(err error) error {
+	reason, _ := sdk.ReasonForError(err)
+	s.logger.WithError(err).Warn("Rejected state change request")
+
+	s.gsUpdateMutex.Lock()
+	s.gsRejection = &agonesv1.GameServerCondition{
+		Type:               agonesv1.GameServerConditionSDKRequestRejected,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(s.clock.Now().UTC()),
+		Reason:             string(reason),
+		Message:            status.Convert(err).Message(),
+	}
+	s.gsUpdateMutex.Unlock()
+	s.workerqueue.Enqueue(cache.ExplicitKey(string(updateRejection)))
+	return err
+}
+
 // enqueueState enqueue a State change request into the
 // workerqueue
 func (s *SDKServer) enqueueState(state agonesv1.GameServerState) {
@@ -387,24 +558,39 @@ func (s *SDKServer) enqueueState(state agonesv1.GameServerState) {
 }
 
 // Ready enters the RequestReady state change for this GameServer into
-// the workqueue so it can be updated
+// the workqueue so it can be updated. It is rejected with ErrorReasonShutdownRequested
+// or ErrorReasonUnhealthy if the GameServer can not be moved back to Ready.
 func (s *SDKServer) Ready(ctx context.Context, e *sdk.Empty) (*sdk.Empty, error) {
+	if err := s.stateChangeError(agonesv1.GameServerStateRequestReady); err != nil {
+		return nil, s.rejectStateChange(err)
+	}
 	s.logger.Info("Received Ready request, adding to queue")
 	s.stopReserveTimer()
 	s.enqueueState(agonesv1.GameServerStateRequestReady)
 	return e, nil
 }
 
-// Allocate enters an Allocate state change into the workqueue, so it can be updated
+// Allocate enters an Allocate state change into the workqueue, so it can be updated.
+// It is rejected with ErrorReasonShutdownRequested or ErrorReasonUnhealthy if the
+// GameServer can not be allocated anymore.
 func (s *SDKServer) Allocate(ctx context.Context, e *sdk.Empty) (*sdk.Empty, error) {
+	if err := s.stateChangeError(agonesv1.GameServerStateAllocated); err != nil {
+		return nil, s.rejectStateChange(err)
+	}
+	s.logger.Info("Received Allocate request, adding to queue")
 	s.stopReserveTimer()
 	s.enqueueState(agonesv1.GameServerStateAllocated)
 	return e, nil
 }
 
 // Shutdown enters the Shutdown state change for this GameServer into
-// the workqueue so it can be updated
+// the workqueue so it can be updated. If it was already called, or the GameServer
+// is being deleted, it succeeds without changing anything.
 func (s *SDKServer) Shutdown(ctx context.Context, e *sdk.Empty) (*sdk.Empty, error) {
+	if err := s.stateChangeError(agonesv1.GameServerStateShutdown); err != nil {
+		s.logger.Info("Received Shutdown request, GameServer is already shutting down")
+		return e, nil
+	}
 	s.logger.Info("Received Shutdown request, adding to queue")
 	s.stopReserveTimer()
 	s.enqueueState(agonesv1.GameServerStateShutdown)
@@ -414,6 +600,8 @@ func (s *SDKServer) Shutdown(ctx context.Context, e *sdk.Empty) (*sdk.Empty, err
 // Health receives each health ping, and tracks the last time the health
 // check was received, to track if a GameServer is healthy
 func (s *SDKServer) Health(stream sdk.SDK_HealthServer) error {
+	s.connectSDK()
+	defer s.disconnectSDK()
 	for {
 		_, err := stream.Recv()
 		if err == io.EOF {
@@ -497,6 +685,16 @@ func (s *SDKServer) Reserve(ctx context.Context, d *sdk.Duration) (*sdk.Empty, e
 	return e, nil
 }
 
+// GetCapabilities returns the version of this SDK server, and the SDK features it supports,
+// so that game servers can detect what is available
+func (s *SDKServer) GetCapabilities(context.Context, *sdk.Empty) (*sdk.Capabilities, error) {
+	s.logger.Info("Received GetCapabilities request")
+	if s.logTailContainer != "" {
+		return capabilities(logTailFeature), nil
+	}
+	return capabilities(), nil
+}
+
 // resetReserveAfter will move the GameServer back to being ready after the specified duration.
 // This function should be wrapped in a s.gsUpdateMutex lock when being called.
 func (s *SDKServer) resetReserveAfter(ctx context.Context, duration time.Duration) {
@@ -552,6 +750,67 @@ func (s *SDKServer) runHealth() {
 	}
 }
 
+// connectSDK tracks a Health stream opened by the SDK, and sets the SDKConnected condition to True
+// if a disconnect grace period is set
+func (s *SDKServer) connectSDK() {
+	s.connectionMutex.Lock()
+	defer s.connectionMutex.Unlock()
+	s.sdkConnections++
+	s.cancelDisconnect()
+	if s.disconnectGracePeriod > 0 && s.sdkConnected != corev1.ConditionTrue {
+		s.sdkConnected = corev1.ConditionTrue
+		s.workerqueue.Enqueue(cache.ExplicitKey(string(updateCondition)))
+	}
+}
+
+// disconnectSDK tracks a Health stream closed by the SDK. Once all of them are closed, the SDKConnected
+// condition is set to False if none is opened again within the disconnect grace period.
+func (s *SDKServer) disconnectSDK() {
+	s.connectionMutex.Lock()
+	defer s.connectionMutex.Unlock()
+	s.sdkConnections--
+	if s.sdkConnections > 0 || s.disconnectGracePeriod <= 0 {
+		return
+	}
+
+	s.cancelDisconnect()
+	timer := s.clock.NewTimer(s.disconnectGracePeriod)
+	cancel := make(chan struct{})
+	s.disconnectTimer, s.disconnectCancel = timer, cancel
+	s.logger.WithField("gracePeriod", s.disconnectGracePeriod).Info("SDK disconnected")
+	go func() {
+		select {
+		case <-timer.C():
+		case <-cancel:
+			return
+		}
+		s.connectionMutex.Lock()
+		defer s.connectionMutex.Unlock()
+		// the SDK may have reconnected while the lock was held
+		if s.disconnectCancel != cancel {
+			return
+		}
+		s.disconnectTimer, s.disconnectCancel = nil, nil
+		if s.sdkConnected == corev1.ConditionFalse {
+			return
+		}
+		s.logger.Info("SDK did not reconnect within the grace period")
+		s.sdkConnected = corev1.ConditionFalse
+		s.workerqueue.Enqueue(cache.ExplicitKey(string(updateCondition)))
+	}()
+}
+
+// cancelDisconnect stops the grace period of a pending disconnect, if there is one.
+// connectionMutex must be held.
+func (s *SDKServer) cancelDisconnect() {
+	if s.disconnectCancel == nil {
+		return
+	}
+	s.disconnectTimer.Stop()
+	close(s.disconnectCancel)
+	s.disconnectTimer, s.disconnectCancel = nil, nil
+}
+
 // touchHealthLastUpdated sets the healthLastUpdated
 // value to now in UTC
 func (s *SDKServer) touchHealthLastUpdated() {
@@ -586,3 +845,34 @@ func (s *SDKServer) healthy() bool {
 	defer s.healthMutex.RUnlock()
 	return s.healthFailureCount < s.health.FailureThreshold
 }
+
+// logTail writes the last `lines` lines of the log of the game server container, if the log tail is enabled
+func (s *SDKServer) logTail(w http.ResponseWriter, r *http.Request) {
+	if s.logTailContainer == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	lines := int64(defaultLogTailLines)
+	if v := r.URL.Query().Get("lines"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 || n > maxLogTailLines {
+			http.Error(w, fmt.Sprintf("lines must be a number between 1 and %d", maxLogTailLines), http.StatusBadRequest)
+			return
+		}
+		lines = n
+	}
+
+	stream, err := s.podLogs(s.logTailContainer, lines)
+	if err != nil {
+		s.logger.WithError(err).Error("could not get the log of the game server container")
+		http.Error(w, "could not get the log of the game server container", http.StatusInternalServerError)
+		return
+	}
+	defer stream.Close() // nolint: errcheck
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if _, err := io.Copy(w, stream); err != nil {
+		s.logger.WithError(err).Error("could not send the log of the game server container")
+	}
+}
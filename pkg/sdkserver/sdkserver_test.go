@@ -15,17 +15,26 @@
 package sdkserver
 
 import (
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"agones.dev/agones/pkg"
 	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
 	"agones.dev/agones/pkg/sdk"
 	agtesting "agones.dev/agones/pkg/testing"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/clock"
@@ -364,6 +373,76 @@ func TestSidecarUpdateState(t *testing.T) {
 	}
 }
 
+func TestSidecarUpdateStateSession(t *testing.T) {
+	t.Parallel()
+
+	then := metav1.NewTime(time.Now().Add(10 * time.Minute).Truncate(time.Second))
+	fixtures := map[string]struct {
+		current  agonesv1.GameServerStatus
+		state    agonesv1.GameServerState
+		expected func(t *testing.T, until *metav1.Time)
+	}{
+		"allocated": {
+			current: agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady},
+			state:   agonesv1.GameServerStateAllocated,
+			expected: func(t *testing.T, until *metav1.Time) {
+				if assert.NotNil(t, until) {
+					assert.WithinDuration(t, time.Now().Add(time.Hour), until.Time, 5*time.Second)
+				}
+			},
+		},
+		"already allocated": {
+			current: agonesv1.GameServerStatus{State: agonesv1.GameServerStateAllocated, AllocatedUntil: &then},
+			state:   agonesv1.GameServerStateAllocated,
+			expected: func(t *testing.T, until *metav1.Time) {
+				assert.Equal(t, &then, until)
+			},
+		},
+		"ready": {
+			current: agonesv1.GameServerStatus{State: agonesv1.GameServerStateAllocated, AllocatedUntil: &then},
+			state:   agonesv1.GameServerStateReady,
+			expected: func(t *testing.T, until *metav1.Time) {
+				assert.Nil(t, until)
+			},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			m := agtesting.NewMocks()
+			sc, err := defaultSidecar(m)
+			assert.Nil(t, err)
+			sc.gsState = v.state
+
+			m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				gs := agonesv1.GameServer{
+					ObjectMeta: metav1.ObjectMeta{Name: sc.gameServerName, Namespace: sc.namespace},
+					Spec:       agonesv1.GameServerSpec{Session: agonesv1.Session{MaxDuration: &metav1.Duration{Duration: time.Hour}}},
+					Status:     *v.current.DeepCopy(),
+				}
+				return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{gs}}, nil
+			})
+			updated := false
+			m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				updated = true
+				gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+				assert.Equal(t, v.state, gs.Status.State)
+				v.expected(t, gs.Status.AllocatedUntil)
+				return true, gs, nil
+			})
+
+			stop := make(chan struct{})
+			defer close(stop)
+			sc.informerFactory.Start(stop)
+			assert.True(t, cache.WaitForCacheSync(stop, sc.gameServerSynced))
+			sc.gsWaitForSync.Done()
+
+			assert.NoError(t, sc.updateState())
+			assert.True(t, updated)
+		})
+	}
+}
+
 func TestSidecarHealthLastUpdated(t *testing.T) {
 	t.Parallel()
 	now := time.Now().UTC()
@@ -412,6 +491,153 @@ func TestSidecarHealthLastUpdated(t *testing.T) {
 	wg.Wait()
 }
 
+func TestSidecarDisconnectGracePeriod(t *testing.T) {
+	t.Parallel()
+	m := agtesting.NewMocks()
+
+	sc, err := defaultSidecar(m)
+	assert.Nil(t, err)
+	fc := clock.NewFakeClock(time.Now())
+	sc.clock = fc
+	sc.disconnectGracePeriod = 10 * time.Second
+
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gs := agonesv1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: sc.gameServerName, Namespace: sc.namespace},
+			Status:     agonesv1.GameServerStatus{State: agonesv1.GameServerStateReady},
+		}
+		return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{gs}}, nil
+	})
+	conditions := make(chan agonesv1.GameServerCondition, 10)
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		if c, ok := gs.Status.GetCondition(agonesv1.GameServerConditionSDKConnected); ok {
+			conditions <- c
+		}
+		return true, gs, nil
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	sc.informerFactory.Start(stop)
+	assert.True(t, cache.WaitForCacheSync(stop, sc.gameServerSynced))
+	sc.gsWaitForSync.Done()
+	go sc.workerqueue.Run(1, stop)
+
+	assertCondition := func(status corev1.ConditionStatus) {
+		select {
+		case c := <-conditions:
+			assert.Equal(t, status, c.Status)
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "timeout waiting for the SDKConnected condition", string(status))
+		}
+	}
+	// waitForConnections waits for the Health streams to be opened or closed
+	waitForConnections := func(n int) {
+		err := wait.PollImmediate(time.Millisecond, 5*time.Second, func() (bool, error) {
+			sc.connectionMutex.Lock()
+			defer sc.connectionMutex.Unlock()
+			return sc.sdkConnections == n, nil
+		})
+		assert.NoError(t, err)
+	}
+	connect := func() *emptyMockStream {
+		stream := newEmptyMockStream()
+		go func() {
+			assert.Nil(t, sc.Health(stream))
+		}()
+		waitForConnections(1)
+		return stream
+	}
+
+	stream := connect()
+	assertCondition(corev1.ConditionTrue)
+
+	// reconnecting within the grace period cancels the disconnect
+	close(stream.msgs)
+	waitForConnections(0)
+	fc.Step(5 * time.Second)
+	stream = connect()
+	sc.connectionMutex.Lock()
+	assert.Nil(t, sc.disconnectCancel)
+	sc.connectionMutex.Unlock()
+	fc.Step(10 * time.Second)
+	assert.Empty(t, conditions)
+
+	close(stream.msgs)
+	waitForConnections(0)
+	fc.Step(10 * time.Second)
+	assertCondition(corev1.ConditionFalse)
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "SDKDisconnected")
+
+	stream = connect()
+	assertCondition(corev1.ConditionTrue)
+	close(stream.msgs)
+}
+
+func TestSidecarStateChangeRejected(t *testing.T) {
+	t.Parallel()
+	m := agtesting.NewMocks()
+
+	sc, err := defaultSidecar(m)
+	assert.Nil(t, err)
+
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gs := agonesv1.GameServer{
+			ObjectMeta: metav1.ObjectMeta{Name: sc.gameServerName, Namespace: sc.namespace},
+			Status:     agonesv1.GameServerStatus{State: agonesv1.GameServerStateUnhealthy},
+		}
+		return true, &agonesv1.GameServerList{Items: []agonesv1.GameServer{gs}}, nil
+	})
+	conditions := make(chan agonesv1.GameServerCondition, 10)
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		if c, ok := gs.Status.GetCondition(agonesv1.GameServerConditionSDKRequestRejected); ok {
+			conditions <- c
+		}
+		return true, gs, nil
+	})
+
+	stop := make(chan struct{})
+	defer close(stop)
+	sc.informerFactory.Start(stop)
+	assert.True(t, cache.WaitForCacheSync(stop, sc.gameServerSynced))
+	sc.gsWaitForSync.Done()
+	go sc.workerqueue.Run(1, stop)
+
+	assertRejected := func(err error, expected sdk.ErrorReason) {
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+		reason, ok := sdk.ReasonForError(err)
+		assert.True(t, ok)
+		assert.Equal(t, expected, reason)
+
+		select {
+		case c := <-conditions:
+			assert.Equal(t, corev1.ConditionTrue, c.Status)
+			assert.Equal(t, string(expected), c.Reason)
+			assert.Equal(t, status.Convert(err).Message(), c.Message)
+		case <-time.After(5 * time.Second):
+			assert.FailNow(t, "timeout waiting for the SDKRequestRejected condition", string(expected))
+		}
+	}
+
+	// an Unhealthy GameServer can not be moved back to Ready or allocated, but can be shut down
+	_, err = sc.Ready(context.Background(), &sdk.Empty{})
+	assertRejected(err, sdk.ErrorReasonUnhealthy)
+	agtesting.AssertEventContains(t, m.FakeRecorder.Events, "SDKRequestRejected")
+	_, err = sc.Allocate(context.Background(), &sdk.Empty{})
+	assertRejected(err, sdk.ErrorReasonUnhealthy)
+
+	_, err = sc.Shutdown(context.Background(), &sdk.Empty{})
+	assert.NoError(t, err)
+	_, err = sc.Shutdown(context.Background(), &sdk.Empty{})
+	assert.NoError(t, err)
+	_, err = sc.Ready(context.Background(), &sdk.Empty{})
+	assertRejected(err, sdk.ErrorReasonShutdownRequested)
+	_, err = sc.Allocate(context.Background(), &sdk.Empty{})
+	assertRejected(err, sdk.ErrorReasonShutdownRequested)
+}
+
 func TestSidecarHealthy(t *testing.T) {
 	t.Parallel()
 
@@ -562,6 +788,45 @@ func TestSidecarHTTPHealthCheck(t *testing.T) {
 	wg.Wait() // wait for go routine test results.
 }
 
+func TestSDKServerLogTail(t *testing.T) {
+	t.Parallel()
+
+	m := agtesting.NewMocks()
+	sc, err := NewSDKServer("test", "default", m.KubeClient, m.AgonesClient)
+	assert.NoError(t, err)
+
+	var requested int64
+	sc.podLogs = func(container string, tailLines int64) (io.ReadCloser, error) {
+		assert.Equal(t, "game", container)
+		requested = tailLines
+		return ioutil.NopCloser(strings.NewReader("line 1\nline 2\n")), nil
+	}
+
+	get := func(url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		sc.logTail(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	w := get("/logs")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	sc.EnableLogTail("game")
+	w = get("/logs")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "line 1\nline 2\n", w.Body.String())
+	assert.Equal(t, int64(defaultLogTailLines), requested)
+
+	w = get("/logs?lines=2")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, int64(2), requested)
+
+	w = get("/logs?lines=0")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = get("/logs?lines=foo")
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestSDKServerGetGameServer(t *testing.T) {
 	t.Parallel()
 
@@ -597,6 +862,43 @@ func TestSDKServerGetGameServer(t *testing.T) {
 	assert.Equal(t, string(fixture.Status.State), result.Status.State)
 }
 
+func TestSDKServerGetCapabilities(t *testing.T) {
+	t.Parallel()
+
+	m := agtesting.NewMocks()
+	sc, err := defaultSidecar(m)
+	assert.Nil(t, err)
+
+	result, err := sc.GetCapabilities(context.Background(), &sdk.Empty{})
+	assert.Nil(t, err)
+	assert.Equal(t, pkg.Version, result.Version)
+	assert.Equal(t, "stable", result.Features["Ready"])
+	assert.Equal(t, "stable", result.Features["Reserve"])
+	assert.Equal(t, "alpha", result.Features["GetCapabilities"])
+
+	// changing the result should not change the features of the server
+	result.Features["Ready"] = "alpha"
+	result, err = sc.GetCapabilities(context.Background(), &sdk.Empty{})
+	assert.Nil(t, err)
+	assert.Equal(t, "stable", result.Features["Ready"])
+	assert.NotContains(t, result.Features, "LogTail")
+
+	sc.EnableLogTail("game")
+	result, err = sc.GetCapabilities(context.Background(), &sdk.Empty{})
+	assert.Nil(t, err)
+	assert.Equal(t, "alpha", result.Features["LogTail"])
+}
+
+func TestSDKServerCapabilitiesListAllCalls(t *testing.T) {
+	t.Parallel()
+
+	calls := reflect.TypeOf((*sdk.SDKServer)(nil)).Elem()
+	for i := 0; i < calls.NumMethod(); i++ {
+		name := calls.Method(i).Name
+		assert.Contains(t, sdkFeatures, name, "SDK call %s is not listed in sdkFeatures", name)
+	}
+}
+
 func TestSDKServerWatchGameServer(t *testing.T) {
 	t.Parallel()
 	m := agtesting.NewMocks()
@@ -0,0 +1,129 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package testing
+
+import (
+	"fmt"
+	"sync"
+
+	agonesv1 "agones.dev/agones/pkg/apis/agones/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	k8stesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/record"
+)
+
+// Handy tools for scale testing controllers against thousands of fake GameServers, in memory
+
+// ScaleFixture is a set of Fleets, with their GameServerSets and Ready GameServers, spread over nodes
+type ScaleFixture struct {
+	Nodes          []corev1.Node
+	Fleets         []agonesv1.Fleet
+	GameServerSets []agonesv1.GameServerSet
+	GameServers    []agonesv1.GameServer
+
+	// mutex guards the updates of the GameServers through the reactors
+	mutex sync.Mutex
+	// updates counts the updates of the GameServers through the reactors
+	updates int
+}
+
+// NewScaleFixture returns a ScaleFixture of the number of Fleets in the namespace, of replicas Ready
+// GameServers each, spread evenly over the number of nodes
+func NewScaleFixture(namespace string, fleets, replicas, nodes int) *ScaleFixture {
+	f := &ScaleFixture{}
+	for i := 0; i < nodes; i++ {
+		f.Nodes = append(f.Nodes, corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("node-%d", i)}})
+	}
+
+	for i := 0; i < fleets; i++ {
+		fleet := agonesv1.Fleet{
+			ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("fleet-%d", i), Namespace: namespace, UID: types.UID(fmt.Sprintf("fleet-uid-%d", i))},
+			Spec:       agonesv1.FleetSpec{Replicas: int32(replicas)},
+		}
+		fleet.ApplyDefaults()
+		fleet.Status = agonesv1.FleetStatus{Replicas: int32(replicas), ReadyReplicas: int32(replicas)}
+
+		gsSet := fleet.GameServerSet()
+		gsSet.ObjectMeta.Name = fleet.ObjectMeta.Name + "-set"
+		gsSet.ObjectMeta.UID = types.UID(fleet.ObjectMeta.Name + "-set-uid")
+		gsSet.Status = agonesv1.GameServerSetStatus{Replicas: int32(replicas), ReadyReplicas: int32(replicas)}
+
+		for j := 0; j < replicas; j++ {
+			gs := gsSet.GameServer()
+			gs.ObjectMeta.Name = fmt.Sprintf("%s-%d", gsSet.ObjectMeta.Name, j)
+			gs.ObjectMeta.UID = types.UID(gs.ObjectMeta.Name + "-uid")
+			gs.ObjectMeta.ResourceVersion = "1"
+			gs.Status.State = agonesv1.GameServerStateReady
+			if nodes > 0 {
+				gs.Status.NodeName = f.Nodes[len(f.GameServers)%nodes].ObjectMeta.Name
+			}
+			f.GameServers = append(f.GameServers, *gs)
+		}
+
+		f.Fleets = append(f.Fleets, fleet)
+		f.GameServerSets = append(f.GameServerSets, *gsSet)
+	}
+
+	return f
+}
+
+// AddReactors makes the fake clients of the mocks list and watch the nodes, Fleets, GameServerSets and GameServers
+// of the fixture, and accept the updates of GameServers, which are sent to their watch, as the apiserver would
+func (f *ScaleFixture) AddReactors(m Mocks) {
+	// without a watch, the informers would list the fixture again, and see the updated GameServers as they were
+	gsWatch := watch.NewFakeWithChanSize(len(f.GameServers), false)
+	m.KubeClient.AddWatchReactor("nodes", k8stesting.DefaultWatchReactor(watch.NewFake(), nil))
+	m.AgonesClient.AddWatchReactor("fleets", k8stesting.DefaultWatchReactor(watch.NewFake(), nil))
+	m.AgonesClient.AddWatchReactor("gameserversets", k8stesting.DefaultWatchReactor(watch.NewFake(), nil))
+	m.AgonesClient.AddWatchReactor("gameservers", k8stesting.DefaultWatchReactor(gsWatch, nil))
+
+	m.KubeClient.AddReactor("list", "nodes", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &corev1.NodeList{Items: f.Nodes}, nil
+	})
+	m.AgonesClient.AddReactor("list", "fleets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &agonesv1.FleetList{Items: f.Fleets}, nil
+	})
+	m.AgonesClient.AddReactor("list", "gameserversets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &agonesv1.GameServerSetList{Items: f.GameServerSets}, nil
+	})
+	m.AgonesClient.AddReactor("list", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &agonesv1.GameServerList{Items: f.GameServers}, nil
+	})
+	m.AgonesClient.AddReactor("update", "gameservers", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gs := action.(k8stesting.UpdateAction).GetObject().(*agonesv1.GameServer)
+		f.mutex.Lock()
+		defer f.mutex.Unlock()
+		f.updates++
+		gsWatch.Modify(gs.DeepCopy())
+		return true, gs, nil
+	})
+}
+
+// Updates returns how many times the GameServers of the fixture were updated through its reactors
+func (f *ScaleFixture) Updates() int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return f.updates
+}
+
+// NewDiscardingRecorder returns a FakeRecorder that drops its events, as scale tests record more events
+// than the FakeRecorder of the Mocks can hold without blocking
+func NewDiscardingRecorder() *record.FakeRecorder {
+	return &record.FakeRecorder{}
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	fixtures := map[string]string{
+		"too few fields":    "* * * *",
+		"not a number":      "a * * * *",
+		"out of range":      "60 * * * *",
+		"backwards range":   "* 10-5 * * *",
+		"zero step":         "*/0 * * * *",
+		"day of month zero": "* * 0 * *",
+		"day of week eight": "* * * * 8",
+	}
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			_, err := Parse(v)
+			assert.Error(t, err)
+		})
+	}
+
+	_, err := Parse("*/15 9-17 1,15 * 1-5")
+	assert.NoError(t, err)
+}
+
+func TestScheduleNext(t *testing.T) {
+	t.Parallel()
+
+	// a Wednesday
+	from := time.Date(2019, time.July, 10, 10, 30, 15, 0, time.UTC)
+
+	fixtures := map[string]struct {
+		expr     string
+		expected time.Time
+	}{
+		"every minute": {
+			expr:     "* * * * *",
+			expected: time.Date(2019, time.July, 10, 10, 31, 0, 0, time.UTC),
+		},
+		"every 15 minutes": {
+			expr:     "*/15 * * * *",
+			expected: time.Date(2019, time.July, 10, 10, 45, 0, 0, time.UTC),
+		},
+		"later today": {
+			expr:     "0 18 * * *",
+			expected: time.Date(2019, time.July, 10, 18, 0, 0, 0, time.UTC),
+		},
+		"tomorrow": {
+			expr:     "0 9 * * *",
+			expected: time.Date(2019, time.July, 11, 9, 0, 0, 0, time.UTC),
+		},
+		"weekend": {
+			expr:     "0 12 * * 6,7",
+			expected: time.Date(2019, time.July, 13, 12, 0, 0, 0, time.UTC),
+		},
+		"sunday as seven": {
+			expr:     "0 12 * * 7",
+			expected: time.Date(2019, time.July, 14, 12, 0, 0, 0, time.UTC),
+		},
+		"day of month or day of week": {
+			expr:     "0 0 1 * 5",
+			expected: time.Date(2019, time.July, 12, 0, 0, 0, 0, time.UTC),
+		},
+		"next year": {
+			expr:     "0 0 1 1 *",
+			expected: time.Date(2020, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		"leap day": {
+			expr:     "0 0 29 2 *",
+			expected: time.Date(2020, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		"never": {
+			expr:     "0 0 30 2 *",
+			expected: time.Time{},
+		},
+	}
+
+	for k, v := range fixtures {
+		t.Run(k, func(t *testing.T) {
+			s, err := Parse(v.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, v.expected, s.Next(from))
+		})
+	}
+}
+
+func TestScheduleNextLocation(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("IST", 5*60*60+30*60)
+	s, err := Parse("0 * * * *")
+	assert.NoError(t, err)
+	next := s.Next(time.Date(2019, time.July, 10, 10, 45, 0, 0, loc))
+	assert.Equal(t, time.Date(2019, time.July, 10, 11, 0, 0, 0, loc), next)
+}
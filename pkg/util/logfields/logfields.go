@@ -14,9 +14,20 @@ const (
 	GameServerAllocationKey ResourceType = "gsaKey"
 	FleetKey                ResourceType = "fleetKey"
 	FleetAutoscalerKey      ResourceType = "fasKey"
+	NodeKey                 ResourceType = "nodeKey"
 )
 
 // AugmentLogEntry creates derived log entry with a given resource identifier ("namespace/name")
 func AugmentLogEntry(base *logrus.Entry, resourceType ResourceType, resourceID string) *logrus.Entry {
 	return base.WithField(string(resourceType), resourceID)
 }
+
+// WithObject adds the whole object to the log entry under field, when Debug logging is enabled.
+// Objects are left out otherwise, as logging them on every change of a resource dominates the log volume of
+// a large cluster.
+func WithObject(entry *logrus.Entry, field string, obj interface{}) *logrus.Entry {
+	if !entry.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return entry
+	}
+	return entry.WithField(field, obj)
+}
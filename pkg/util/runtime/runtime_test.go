@@ -17,9 +17,12 @@
 package runtime
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/util/runtime"
 )
@@ -44,3 +47,41 @@ func TestHandleError(t *testing.T) {
 		t.Errorf("did not receive custom handler")
 	}
 }
+
+func TestSampledLoggerDebug(t *testing.T) {
+	defer SetLogSampleRate(1)
+
+	buf := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.Out = buf
+	entry := logrus.NewEntry(logger)
+
+	lines := func() int {
+		n := strings.Count(buf.String(), "\n")
+		buf.Reset()
+		return n
+	}
+
+	s := &SampledLogger{}
+	s.Debug(entry, "Synchronising")
+	assert.Equal(t, 0, lines(), "debug logging is disabled")
+
+	logger.SetLevel(logrus.DebugLevel)
+	for i := 0; i < 10; i++ {
+		s.Debug(entry, "Synchronising")
+	}
+	assert.Equal(t, 10, lines(), "every message is logged by default")
+
+	SetLogSampleRate(4)
+	s = &SampledLogger{}
+	for i := 0; i < 10; i++ {
+		s.Debug(entry, "Synchronising")
+	}
+	assert.Equal(t, 3, lines(), "one in every 4 messages is logged")
+
+	SetLogSampleRate(0)
+	for i := 0; i < 10; i++ {
+		s.Debug(entry, "Synchronising")
+	}
+	assert.Equal(t, 10, lines(), "a rate below 1 logs every message")
+}
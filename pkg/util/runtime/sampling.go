@@ -0,0 +1,53 @@
+// Copyright 2019 Google LLC All Rights Reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runtime
+
+import (
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// logSampleRate is the rate of the messages that each SampledLogger logs, see SetLogSampleRate
+var logSampleRate uint64 = 1
+
+// SetLogSampleRate sets how many of the messages given to each SampledLogger are logged:
+// one in every rate messages. A rate of 1 or less logs every message.
+func SetLogSampleRate(rate int) {
+	if rate < 1 {
+		rate = 1
+	}
+	atomic.StoreUint64(&logSampleRate, uint64(rate))
+}
+
+// SampledLogger logs a sample of the high frequency Debug messages it is given, such as the ones logged
+// every time a resource is synchronised, so they can be enabled on a large cluster without flooding the logs.
+// Use one SampledLogger per message, so that each message is sampled on its own.
+// The zero value is ready to use.
+type SampledLogger struct {
+	count uint64
+}
+
+// Debug logs args at Debug level on logger, for one in every log sample rate of the calls
+// made while Debug logging is enabled
+func (s *SampledLogger) Debug(logger *logrus.Entry, args ...interface{}) {
+	if !logger.Logger.IsLevelEnabled(logrus.DebugLevel) {
+		return
+	}
+	n := atomic.AddUint64(&s.count, 1)
+	if (n-1)%atomic.LoadUint64(&logSampleRate) == 0 {
+		logger.Debug(args...)
+	}
+}
@@ -45,6 +45,7 @@ type Handler func(string) error
 type WorkerQueue struct {
 	logger  *logrus.Entry
 	keyName string
+	name    string
 	queue   workqueue.RateLimitingInterface
 	// SyncHandler is exported to make testing easier (hack)
 	SyncHandler Handler
@@ -63,6 +64,7 @@ func NewWorkerQueue(handler Handler, logger *logrus.Entry, keyName logfields.Res
 func NewWorkerQueueWithRateLimiter(handler Handler, logger *logrus.Entry, keyName logfields.ResourceType, queueName string, rateLimiter workqueue.RateLimiter) *WorkerQueue {
 	return &WorkerQueue{
 		keyName:     string(keyName),
+		name:        queueName,
 		logger:      logger.WithField("queue", queueName),
 		queue:       workqueue.NewNamedRateLimitingQueue(rateLimiter, queueName),
 		SyncHandler: handler,
@@ -185,6 +187,17 @@ func (wq *WorkerQueue) Healthy() error {
 	return nil
 }
 
+// Name returns the name of the queue
+func (wq *WorkerQueue) Name() string {
+	return wq.name
+}
+
+// Len returns the number of keys in the queue that are waiting to be processed.
+// Keys that are delayed by the rate limiter are not counted until they are added.
+func (wq *WorkerQueue) Len() int {
+	return wq.queue.Len()
+}
+
 // RunCount reports the number of running worker goroutines started by Run.
 func (wq *WorkerQueue) RunCount() int {
 	wq.mu.Lock()
@@ -57,7 +57,7 @@ namespace protobuf_sdk_2eproto {
 struct AGONES_EXPORT TableStruct {
   static const ::google::protobuf::internal::ParseTableField entries[];
   static const ::google::protobuf::internal::AuxillaryParseTableField aux[];
-  static const ::google::protobuf::internal::ParseTable schema[11];
+  static const ::google::protobuf::internal::ParseTable schema[13];
   static const ::google::protobuf::internal::FieldMetadata field_metadata[];
   static const ::google::protobuf::internal::SerializationTable serialization_table[];
   static const ::google::protobuf::uint32 offsets[];
@@ -67,6 +67,12 @@ void AGONES_EXPORT AddDescriptors();
 namespace agones {
 namespace dev {
 namespace sdk {
+class Capabilities;
+class CapabilitiesDefaultTypeInternal;
+AGONES_EXPORT extern CapabilitiesDefaultTypeInternal _Capabilities_default_instance_;
+class Capabilities_FeaturesEntry_DoNotUse;
+class Capabilities_FeaturesEntry_DoNotUseDefaultTypeInternal;
+AGONES_EXPORT extern Capabilities_FeaturesEntry_DoNotUseDefaultTypeInternal _Capabilities_FeaturesEntry_DoNotUse_default_instance_;
 class Duration;
 class DurationDefaultTypeInternal;
 AGONES_EXPORT extern DurationDefaultTypeInternal _Duration_default_instance_;
@@ -105,6 +111,8 @@ AGONES_EXPORT extern KeyValueDefaultTypeInternal _KeyValue_default_instance_;
 }  // namespace agones
 namespace google {
 namespace protobuf {
+template<> AGONES_EXPORT ::agones::dev::sdk::Capabilities* Arena::CreateMaybeMessage<::agones::dev::sdk::Capabilities>(Arena*);
+template<> AGONES_EXPORT ::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse* Arena::CreateMaybeMessage<::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse>(Arena*);
 template<> AGONES_EXPORT ::agones::dev::sdk::Duration* Arena::CreateMaybeMessage<::agones::dev::sdk::Duration>(Arena*);
 template<> AGONES_EXPORT ::agones::dev::sdk::Empty* Arena::CreateMaybeMessage<::agones::dev::sdk::Empty>(Arena*);
 template<> AGONES_EXPORT ::agones::dev::sdk::GameServer* Arena::CreateMaybeMessage<::agones::dev::sdk::GameServer>(Arena*);
@@ -1330,6 +1338,154 @@ class AGONES_EXPORT GameServer : public ::google::protobuf::Message /* @@protoc_
   mutable ::google::protobuf::internal::CachedSize _cached_size_;
   friend struct ::protobuf_sdk_2eproto::TableStruct;
 };
+// -------------------------------------------------------------------
+
+class Capabilities_FeaturesEntry_DoNotUse : public ::google::protobuf::internal::MapEntry<Capabilities_FeaturesEntry_DoNotUse, 
+    ::std::string, ::std::string,
+    ::google::protobuf::internal::WireFormatLite::TYPE_STRING,
+    ::google::protobuf::internal::WireFormatLite::TYPE_STRING,
+    0 > {
+public:
+  typedef ::google::protobuf::internal::MapEntry<Capabilities_FeaturesEntry_DoNotUse, 
+    ::std::string, ::std::string,
+    ::google::protobuf::internal::WireFormatLite::TYPE_STRING,
+    ::google::protobuf::internal::WireFormatLite::TYPE_STRING,
+    0 > SuperType;
+  Capabilities_FeaturesEntry_DoNotUse();
+  Capabilities_FeaturesEntry_DoNotUse(::google::protobuf::Arena* arena);
+  void MergeFrom(const Capabilities_FeaturesEntry_DoNotUse& other);
+  static const Capabilities_FeaturesEntry_DoNotUse* internal_default_instance() { return reinterpret_cast<const Capabilities_FeaturesEntry_DoNotUse*>(&_Capabilities_FeaturesEntry_DoNotUse_default_instance_); }
+  void MergeFrom(const ::google::protobuf::Message& other) final;
+  ::google::protobuf::Metadata GetMetadata() const;
+};
+
+// -------------------------------------------------------------------
+
+class AGONES_EXPORT Capabilities : public ::google::protobuf::Message /* @@protoc_insertion_point(class_definition:agones.dev.sdk.Capabilities) */ {
+ public:
+  Capabilities();
+  virtual ~Capabilities();
+
+  Capabilities(const Capabilities& from);
+
+  inline Capabilities& operator=(const Capabilities& from) {
+    CopyFrom(from);
+    return *this;
+  }
+  #if LANG_CXX11
+  Capabilities(Capabilities&& from) noexcept
+    : Capabilities() {
+    *this = ::std::move(from);
+  }
+
+  inline Capabilities& operator=(Capabilities&& from) noexcept {
+    if (GetArenaNoVirtual() == from.GetArenaNoVirtual()) {
+      if (this != &from) InternalSwap(&from);
+    } else {
+      CopyFrom(from);
+    }
+    return *this;
+  }
+  #endif
+  static const ::google::protobuf::Descriptor* descriptor();
+  static const Capabilities& default_instance();
+
+  static void InitAsDefaultInstance();  // FOR INTERNAL USE ONLY
+  static inline const Capabilities* internal_default_instance() {
+    return reinterpret_cast<const Capabilities*>(
+               &_Capabilities_default_instance_);
+  }
+  static constexpr int kIndexInFileMessages =
+    12;
+
+  void Swap(Capabilities* other);
+  friend void swap(Capabilities& a, Capabilities& b) {
+    a.Swap(&b);
+  }
+
+  // implements Message ----------------------------------------------
+
+  inline Capabilities* New() const final {
+    return CreateMaybeMessage<Capabilities>(NULL);
+  }
+
+  Capabilities* New(::google::protobuf::Arena* arena) const final {
+    return CreateMaybeMessage<Capabilities>(arena);
+  }
+  void CopyFrom(const ::google::protobuf::Message& from) final;
+  void MergeFrom(const ::google::protobuf::Message& from) final;
+  void CopyFrom(const Capabilities& from);
+  void MergeFrom(const Capabilities& from);
+  void Clear() final;
+  bool IsInitialized() const final;
+
+  size_t ByteSizeLong() const final;
+  bool MergePartialFromCodedStream(
+      ::google::protobuf::io::CodedInputStream* input) final;
+  void SerializeWithCachedSizes(
+      ::google::protobuf::io::CodedOutputStream* output) const final;
+  ::google::protobuf::uint8* InternalSerializeWithCachedSizesToArray(
+      bool deterministic, ::google::protobuf::uint8* target) const final;
+  int GetCachedSize() const final { return _cached_size_.Get(); }
+
+  private:
+  void SharedCtor();
+  void SharedDtor();
+  void SetCachedSize(int size) const final;
+  void InternalSwap(Capabilities* other);
+  private:
+  inline ::google::protobuf::Arena* GetArenaNoVirtual() const {
+    return NULL;
+  }
+  inline void* MaybeArenaPtr() const {
+    return NULL;
+  }
+  public:
+
+  ::google::protobuf::Metadata GetMetadata() const final;
+
+  // nested types ----------------------------------------------------
+
+
+  // accessors -------------------------------------------------------
+
+  // map<string, string> features = 2;
+  int features_size() const;
+  void clear_features();
+  static const int kFeaturesFieldNumber = 2;
+  const ::google::protobuf::Map< ::std::string, ::std::string >&
+      features() const;
+  ::google::protobuf::Map< ::std::string, ::std::string >*
+      mutable_features();
+
+  // string version = 1;
+  void clear_version();
+  static const int kVersionFieldNumber = 1;
+  const ::std::string& version() const;
+  void set_version(const ::std::string& value);
+  #if LANG_CXX11
+  void set_version(::std::string&& value);
+  #endif
+  void set_version(const char* value);
+  void set_version(const char* value, size_t size);
+  ::std::string* mutable_version();
+  ::std::string* release_version();
+  void set_allocated_version(::std::string* version);
+
+  // @@protoc_insertion_point(class_scope:agones.dev.sdk.Capabilities)
+ private:
+
+  ::google::protobuf::internal::InternalMetadataWithArena _internal_metadata_;
+  ::google::protobuf::internal::MapField<
+      Capabilities_FeaturesEntry_DoNotUse,
+      ::std::string, ::std::string,
+      ::google::protobuf::internal::WireFormatLite::TYPE_STRING,
+      ::google::protobuf::internal::WireFormatLite::TYPE_STRING,
+      0 > features_;
+  ::google::protobuf::internal::ArenaStringPtr version_;
+  mutable ::google::protobuf::internal::CachedSize _cached_size_;
+  friend struct ::protobuf_sdk_2eproto::TableStruct;
+};
 // ===================================================================
 
 
@@ -2262,6 +2418,83 @@ inline void GameServer::set_allocated_status(::agones::dev::sdk::GameServer_Stat
   // @@protoc_insertion_point(field_set_allocated:agones.dev.sdk.GameServer.status)
 }
 
+// -------------------------------------------------------------------
+
+// -------------------------------------------------------------------
+
+// Capabilities
+
+// string version = 1;
+inline void Capabilities::clear_version() {
+  version_.ClearToEmptyNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited());
+}
+inline const ::std::string& Capabilities::version() const {
+  // @@protoc_insertion_point(field_get:agones.dev.sdk.Capabilities.version)
+  return version_.GetNoArena();
+}
+inline void Capabilities::set_version(const ::std::string& value) {
+  
+  version_.SetNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited(), value);
+  // @@protoc_insertion_point(field_set:agones.dev.sdk.Capabilities.version)
+}
+#if LANG_CXX11
+inline void Capabilities::set_version(::std::string&& value) {
+  
+  version_.SetNoArena(
+    &::google::protobuf::internal::GetEmptyStringAlreadyInited(), ::std::move(value));
+  // @@protoc_insertion_point(field_set_rvalue:agones.dev.sdk.Capabilities.version)
+}
+#endif
+inline void Capabilities::set_version(const char* value) {
+  GOOGLE_DCHECK(value != NULL);
+  
+  version_.SetNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited(), ::std::string(value));
+  // @@protoc_insertion_point(field_set_char:agones.dev.sdk.Capabilities.version)
+}
+inline void Capabilities::set_version(const char* value, size_t size) {
+  
+  version_.SetNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited(),
+      ::std::string(reinterpret_cast<const char*>(value), size));
+  // @@protoc_insertion_point(field_set_pointer:agones.dev.sdk.Capabilities.version)
+}
+inline ::std::string* Capabilities::mutable_version() {
+  
+  // @@protoc_insertion_point(field_mutable:agones.dev.sdk.Capabilities.version)
+  return version_.MutableNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited());
+}
+inline ::std::string* Capabilities::release_version() {
+  // @@protoc_insertion_point(field_release:agones.dev.sdk.Capabilities.version)
+  
+  return version_.ReleaseNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited());
+}
+inline void Capabilities::set_allocated_version(::std::string* version) {
+  if (version != NULL) {
+    
+  } else {
+    
+  }
+  version_.SetAllocatedNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited(), version);
+  // @@protoc_insertion_point(field_set_allocated:agones.dev.sdk.Capabilities.version)
+}
+
+// map<string, string> features = 2;
+inline int Capabilities::features_size() const {
+  return features_.size();
+}
+inline void Capabilities::clear_features() {
+  features_.Clear();
+}
+inline const ::google::protobuf::Map< ::std::string, ::std::string >&
+Capabilities::features() const {
+  // @@protoc_insertion_point(field_map:agones.dev.sdk.Capabilities.features)
+  return features_.GetMap();
+}
+inline ::google::protobuf::Map< ::std::string, ::std::string >*
+Capabilities::mutable_features() {
+  // @@protoc_insertion_point(field_mutable_map:agones.dev.sdk.Capabilities.features)
+  return features_.MutableMap();
+}
+
 #ifdef __GNUC__
   #pragma GCC diagnostic pop
 #endif  // __GNUC__
@@ -2285,6 +2518,10 @@ inline void GameServer::set_allocated_status(::agones::dev::sdk::GameServer_Stat
 
 // -------------------------------------------------------------------
 
+// -------------------------------------------------------------------
+
+// -------------------------------------------------------------------
+
 
 // @@protoc_insertion_point(namespace_scope)
 
@@ -44,6 +44,7 @@ static const char* SDK_method_names[] = {
   "/agones.dev.sdk.SDK/SetLabel",
   "/agones.dev.sdk.SDK/SetAnnotation",
   "/agones.dev.sdk.SDK/Reserve",
+  "/agones.dev.sdk.SDK/GetCapabilities",
 };
 
 std::unique_ptr< SDK::Stub> SDK::NewStub(const std::shared_ptr< ::grpc::ChannelInterface>& channel, const ::grpc::StubOptions& options) {
@@ -62,6 +63,7 @@ SDK::Stub::Stub(const std::shared_ptr< ::grpc::ChannelInterface>& channel)
   , rpcmethod_SetLabel_(SDK_method_names[6], ::grpc::internal::RpcMethod::NORMAL_RPC, channel)
   , rpcmethod_SetAnnotation_(SDK_method_names[7], ::grpc::internal::RpcMethod::NORMAL_RPC, channel)
   , rpcmethod_Reserve_(SDK_method_names[8], ::grpc::internal::RpcMethod::NORMAL_RPC, channel)
+  , rpcmethod_GetCapabilities_(SDK_method_names[9], ::grpc::internal::RpcMethod::NORMAL_RPC, channel)
   {}
 
 ::grpc::Status SDK::Stub::Ready(::grpc::ClientContext* context, const ::agones::dev::sdk::Empty& request, ::agones::dev::sdk::Empty* response) {
@@ -200,6 +202,22 @@ void SDK::Stub::experimental_async::Reserve(::grpc::ClientContext* context, cons
   return ::grpc::internal::ClientAsyncResponseReaderFactory< ::agones::dev::sdk::Empty>::Create(channel_.get(), cq, rpcmethod_Reserve_, context, request, false);
 }
 
+::grpc::Status SDK::Stub::GetCapabilities(::grpc::ClientContext* context, const ::agones::dev::sdk::Empty& request, ::agones::dev::sdk::Capabilities* response) {
+  return ::grpc::internal::BlockingUnaryCall(channel_.get(), rpcmethod_GetCapabilities_, context, request, response);
+}
+
+void SDK::Stub::experimental_async::GetCapabilities(::grpc::ClientContext* context, const ::agones::dev::sdk::Empty* request, ::agones::dev::sdk::Capabilities* response, std::function<void(::grpc::Status)> f) {
+  return ::grpc::internal::CallbackUnaryCall(stub_->channel_.get(), stub_->rpcmethod_GetCapabilities_, context, request, response, std::move(f));
+}
+
+::grpc::ClientAsyncResponseReader< ::agones::dev::sdk::Capabilities>* SDK::Stub::AsyncGetCapabilitiesRaw(::grpc::ClientContext* context, const ::agones::dev::sdk::Empty& request, ::grpc::CompletionQueue* cq) {
+  return ::grpc::internal::ClientAsyncResponseReaderFactory< ::agones::dev::sdk::Capabilities>::Create(channel_.get(), cq, rpcmethod_GetCapabilities_, context, request, true);
+}
+
+::grpc::ClientAsyncResponseReader< ::agones::dev::sdk::Capabilities>* SDK::Stub::PrepareAsyncGetCapabilitiesRaw(::grpc::ClientContext* context, const ::agones::dev::sdk::Empty& request, ::grpc::CompletionQueue* cq) {
+  return ::grpc::internal::ClientAsyncResponseReaderFactory< ::agones::dev::sdk::Capabilities>::Create(channel_.get(), cq, rpcmethod_GetCapabilities_, context, request, false);
+}
+
 SDK::Service::Service() {
   AddMethod(new ::grpc::internal::RpcServiceMethod(
       SDK_method_names[0],
@@ -246,6 +264,11 @@ SDK::Service::Service() {
       ::grpc::internal::RpcMethod::NORMAL_RPC,
       new ::grpc::internal::RpcMethodHandler< SDK::Service, ::agones::dev::sdk::Duration, ::agones::dev::sdk::Empty>(
           std::mem_fn(&SDK::Service::Reserve), this)));
+  AddMethod(new ::grpc::internal::RpcServiceMethod(
+      SDK_method_names[9],
+      ::grpc::internal::RpcMethod::NORMAL_RPC,
+      new ::grpc::internal::RpcMethodHandler< SDK::Service, ::agones::dev::sdk::Empty, ::agones::dev::sdk::Capabilities>(
+          std::mem_fn(&SDK::Service::GetCapabilities), this)));
 }
 
 SDK::Service::~Service() {
@@ -314,6 +337,13 @@ SDK::Service::~Service() {
   return ::grpc::Status(::grpc::StatusCode::UNIMPLEMENTED, "");
 }
 
+::grpc::Status SDK::Service::GetCapabilities(::grpc::ServerContext* context, const ::agones::dev::sdk::Empty* request, ::agones::dev::sdk::Capabilities* response) {
+  (void) context;
+  (void) request;
+  (void) response;
+  return ::grpc::Status(::grpc::StatusCode::UNIMPLEMENTED, "");
+}
+
 
 }  // namespace agones
 }  // namespace dev
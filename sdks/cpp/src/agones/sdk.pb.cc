@@ -36,6 +36,7 @@
 
 namespace protobuf_sdk_2eproto {
 extern PROTOBUF_INTERNAL_EXPORT_protobuf_sdk_2eproto ::google::protobuf::internal::SCCInfo<0> scc_info_GameServer_ObjectMeta_AnnotationsEntry_DoNotUse;
+extern PROTOBUF_INTERNAL_EXPORT_protobuf_sdk_2eproto ::google::protobuf::internal::SCCInfo<0> scc_info_Capabilities_FeaturesEntry_DoNotUse;
 extern PROTOBUF_INTERNAL_EXPORT_protobuf_sdk_2eproto ::google::protobuf::internal::SCCInfo<0> scc_info_GameServer_ObjectMeta_LabelsEntry_DoNotUse;
 extern PROTOBUF_INTERNAL_EXPORT_protobuf_sdk_2eproto ::google::protobuf::internal::SCCInfo<0> scc_info_GameServer_Spec_Health;
 extern PROTOBUF_INTERNAL_EXPORT_protobuf_sdk_2eproto ::google::protobuf::internal::SCCInfo<0> scc_info_GameServer_Status_Port;
@@ -101,6 +102,16 @@ class GameServerDefaultTypeInternal {
   ::google::protobuf::internal::ExplicitlyConstructed<GameServer>
       _instance;
 } _GameServer_default_instance_;
+class Capabilities_FeaturesEntry_DoNotUseDefaultTypeInternal {
+ public:
+  ::google::protobuf::internal::ExplicitlyConstructed<Capabilities_FeaturesEntry_DoNotUse>
+      _instance;
+} _Capabilities_FeaturesEntry_DoNotUse_default_instance_;
+class CapabilitiesDefaultTypeInternal {
+ public:
+  ::google::protobuf::internal::ExplicitlyConstructed<Capabilities>
+      _instance;
+} _Capabilities_default_instance_;
 }  // namespace sdk
 }  // namespace dev
 }  // namespace agones
@@ -264,6 +275,34 @@ AGONES_EXPORT ::google::protobuf::internal::SCCInfo<3> scc_info_GameServer =
       &protobuf_sdk_2eproto::scc_info_GameServer_Spec.base,
       &protobuf_sdk_2eproto::scc_info_GameServer_Status.base,}};
 
+static void InitDefaultsCapabilities_FeaturesEntry_DoNotUse() {
+  GOOGLE_PROTOBUF_VERIFY_VERSION;
+
+  {
+    void* ptr = &::agones::dev::sdk::_Capabilities_FeaturesEntry_DoNotUse_default_instance_;
+    new (ptr) ::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse();
+  }
+  ::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse::InitAsDefaultInstance();
+}
+
+AGONES_EXPORT ::google::protobuf::internal::SCCInfo<0> scc_info_Capabilities_FeaturesEntry_DoNotUse =
+    {{ATOMIC_VAR_INIT(::google::protobuf::internal::SCCInfoBase::kUninitialized), 0, InitDefaultsCapabilities_FeaturesEntry_DoNotUse}, {}};
+
+static void InitDefaultsCapabilities() {
+  GOOGLE_PROTOBUF_VERIFY_VERSION;
+
+  {
+    void* ptr = &::agones::dev::sdk::_Capabilities_default_instance_;
+    new (ptr) ::agones::dev::sdk::Capabilities();
+    ::google::protobuf::internal::OnShutdownDestroyMessage(ptr);
+  }
+  ::agones::dev::sdk::Capabilities::InitAsDefaultInstance();
+}
+
+AGONES_EXPORT ::google::protobuf::internal::SCCInfo<1> scc_info_Capabilities =
+    {{ATOMIC_VAR_INIT(::google::protobuf::internal::SCCInfoBase::kUninitialized), 1, InitDefaultsCapabilities}, {
+      &protobuf_sdk_2eproto::scc_info_Capabilities_FeaturesEntry_DoNotUse.base,}};
+
 void InitDefaults() {
   ::google::protobuf::internal::InitSCC(&scc_info_Empty.base);
   ::google::protobuf::internal::InitSCC(&scc_info_KeyValue.base);
@@ -276,9 +315,11 @@ void InitDefaults() {
   ::google::protobuf::internal::InitSCC(&scc_info_GameServer_Status_Port.base);
   ::google::protobuf::internal::InitSCC(&scc_info_GameServer_Status.base);
   ::google::protobuf::internal::InitSCC(&scc_info_GameServer.base);
+  ::google::protobuf::internal::InitSCC(&scc_info_Capabilities_FeaturesEntry_DoNotUse.base);
+  ::google::protobuf::internal::InitSCC(&scc_info_Capabilities.base);
 }
 
-::google::protobuf::Metadata file_level_metadata[11];
+::google::protobuf::Metadata file_level_metadata[13];
 
 const ::google::protobuf::uint32 TableStruct::offsets[] GOOGLE_PROTOBUF_ATTRIBUTE_SECTION_VARIABLE(protodesc_cold) = {
   ~0u,  // no _has_bits_
@@ -369,6 +410,22 @@ const ::google::protobuf::uint32 TableStruct::offsets[] GOOGLE_PROTOBUF_ATTRIBUT
   GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::GameServer, object_meta_),
   GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::GameServer, spec_),
   GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::GameServer, status_),
+  GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse, _has_bits_),
+  GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse, _internal_metadata_),
+  ~0u,  // no _extensions_
+  ~0u,  // no _oneof_case_
+  ~0u,  // no _weak_field_map_
+  GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse, key_),
+  GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse, value_),
+  0,
+  1,
+  ~0u,  // no _has_bits_
+  GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::Capabilities, _internal_metadata_),
+  ~0u,  // no _extensions_
+  ~0u,  // no _oneof_case_
+  ~0u,  // no _weak_field_map_
+  GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::Capabilities, version_),
+  GOOGLE_PROTOBUF_GENERATED_MESSAGE_FIELD_OFFSET(::agones::dev::sdk::Capabilities, features_),
 };
 static const ::google::protobuf::internal::MigrationSchema schemas[] GOOGLE_PROTOBUF_ATTRIBUTE_SECTION_VARIABLE(protodesc_cold) = {
   { 0, -1, sizeof(::agones::dev::sdk::Empty)},
@@ -382,6 +439,8 @@ static const ::google::protobuf::internal::MigrationSchema schemas[] GOOGLE_PROT
   { 65, -1, sizeof(::agones::dev::sdk::GameServer_Status_Port)},
   { 72, -1, sizeof(::agones::dev::sdk::GameServer_Status)},
   { 80, -1, sizeof(::agones::dev::sdk::GameServer)},
+  { 88, 95, sizeof(::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse)},
+  { 97, -1, sizeof(::agones::dev::sdk::Capabilities)},
 };
 
 static ::google::protobuf::Message const * const file_default_instances[] = {
@@ -396,6 +455,8 @@ static ::google::protobuf::Message const * const file_default_instances[] = {
   reinterpret_cast<const ::google::protobuf::Message*>(&::agones::dev::sdk::_GameServer_Status_Port_default_instance_),
   reinterpret_cast<const ::google::protobuf::Message*>(&::agones::dev::sdk::_GameServer_Status_default_instance_),
   reinterpret_cast<const ::google::protobuf::Message*>(&::agones::dev::sdk::_GameServer_default_instance_),
+  reinterpret_cast<const ::google::protobuf::Message*>(&::agones::dev::sdk::_Capabilities_FeaturesEntry_DoNotUse_default_instance_),
+  reinterpret_cast<const ::google::protobuf::Message*>(&::agones::dev::sdk::_Capabilities_default_instance_),
 };
 
 void protobuf_AssignDescriptors() {
@@ -413,7 +474,7 @@ void protobuf_AssignDescriptorsOnce() {
 void protobuf_RegisterTypes(const ::std::string&) GOOGLE_PROTOBUF_ATTRIBUTE_COLD;
 void protobuf_RegisterTypes(const ::std::string&) {
   protobuf_AssignDescriptorsOnce();
-  ::google::protobuf::internal::RegisterAllTypes(file_level_metadata, 11);
+  ::google::protobuf::internal::RegisterAllTypes(file_level_metadata, 13);
 }
 
 void AddDescriptorsImpl() {
@@ -444,30 +505,36 @@ void AddDescriptorsImpl() {
       "nds\030\004 \001(\005\032\203\001\n\006Status\022\r\n\005state\030\001 \001(\t\022\017\n\007a"
       "ddress\030\002 \001(\t\0225\n\005ports\030\003 \003(\0132&.agones.dev"
       ".sdk.GameServer.Status.Port\032\"\n\004Port\022\014\n\004n"
-      "ame\030\001 \001(\t\022\014\n\004port\030\002 \001(\0052\206\006\n\003SDK\022H\n\005Ready"
-      "\022\025.agones.dev.sdk.Empty\032\025.agones.dev.sdk"
-      ".Empty\"\021\202\323\344\223\002\013\"\006/ready:\001*\022N\n\010Allocate\022\025."
-      "agones.dev.sdk.Empty\032\025.agones.dev.sdk.Em"
-      "pty\"\024\202\323\344\223\002\016\"\t/allocate:\001*\022N\n\010Shutdown\022\025."
-      "agones.dev.sdk.Empty\032\025.agones.dev.sdk.Em"
-      "pty\"\024\202\323\344\223\002\016\"\t/shutdown:\001*\022L\n\006Health\022\025.ag"
-      "ones.dev.sdk.Empty\032\025.agones.dev.sdk.Empt"
-      "y\"\022\202\323\344\223\002\014\"\007/health:\001*(\001\022W\n\rGetGameServer"
-      "\022\025.agones.dev.sdk.Empty\032\032.agones.dev.sdk"
-      ".GameServer\"\023\202\323\344\223\002\r\022\013/gameserver\022a\n\017Watc"
-      "hGameServer\022\025.agones.dev.sdk.Empty\032\032.ago"
-      "nes.dev.sdk.GameServer\"\031\202\323\344\223\002\023\022\021/watch/g"
-      "ameserver0\001\022W\n\010SetLabel\022\030.agones.dev.sdk"
-      ".KeyValue\032\025.agones.dev.sdk.Empty\"\032\202\323\344\223\002\024"
-      "\032\017/metadata/label:\001*\022a\n\rSetAnnotation\022\030."
-      "agones.dev.sdk.KeyValue\032\025.agones.dev.sdk"
-      ".Empty\"\037\202\323\344\223\002\031\032\024/metadata/annotation:\001*\022"
-      "O\n\007Reserve\022\030.agones.dev.sdk.Duration\032\025.a"
-      "gones.dev.sdk.Empty\"\023\202\323\344\223\002\r\"\010/reserve:\001*"
+      "ame\030\001 \001(\t\022\014\n\004port\030\002 \001(\005\"\216\001\n\014Capabilities"
+      "\022\017\n\007version\030\001 \001(\t\022<\n\010features\030\002 \003(\0132*.ag"
+      "ones.dev.sdk.Capabilities.FeaturesEntry\032"
+      "/\n\rFeaturesEntry\022\013\n\003key\030\001 \001(\t\022\r\n\005value\030\002"
+      " \001(\t:\0028\0012\345\006\n\003SDK\022H\n\005Ready\022\025.agones.dev.s"
+      "dk.Empty\032\025.agones.dev.sdk.Empty\"\021\202\323\344\223\002\013\""
+      "\006/ready:\001*\022N\n\010Allocate\022\025.agones.dev.sdk."
+      "Empty\032\025.agones.dev.sdk.Empty\"\024\202\323\344\223\002\016\"\t/a"
+      "llocate:\001*\022N\n\010Shutdown\022\025.agones.dev.sdk."
+      "Empty\032\025.agones.dev.sdk.Empty\"\024\202\323\344\223\002\016\"\t/s"
+      "hutdown:\001*\022L\n\006Health\022\025.agones.dev.sdk.Em"
+      "pty\032\025.agones.dev.sdk.Empty\"\022\202\323\344\223\002\014\"\007/hea"
+      "lth:\001*(\001\022W\n\rGetGameServer\022\025.agones.dev.s"
+      "dk.Empty\032\032.agones.dev.sdk.GameServer\"\023\202\323"
+      "\344\223\002\r\022\013/gameserver\022a\n\017WatchGameServer\022\025.a"
+      "gones.dev.sdk.Empty\032\032.agones.dev.sdk.Gam"
+      "eServer\"\031\202\323\344\223\002\023\022\021/watch/gameserver0\001\022W\n\010"
+      "SetLabel\022\030.agones.dev.sdk.KeyValue\032\025.ago"
+      "nes.dev.sdk.Empty\"\032\202\323\344\223\002\024\032\017/metadata/lab"
+      "el:\001*\022a\n\rSetAnnotation\022\030.agones.dev.sdk."
+      "KeyValue\032\025.agones.dev.sdk.Empty\"\037\202\323\344\223\002\031\032"
+      "\024/metadata/annotation:\001*\022O\n\007Reserve\022\030.ag"
+      "ones.dev.sdk.Duration\032\025.agones.dev.sdk.E"
+      "mpty\"\023\202\323\344\223\002\r\"\010/reserve:\001*\022]\n\017GetCapabili"
+      "ties\022\025.agones.dev.sdk.Empty\032\034.agones.dev"
+      ".sdk.Capabilities\"\025\202\323\344\223\002\017\022\r/capabilities"
       "B\005Z\003sdkb\006proto3"
   };
   ::google::protobuf::DescriptorPool::InternalAddGeneratedFile(
-      descriptor, 1815);
+      descriptor, 2055);
   ::google::protobuf::MessageFactory::InternalRegisterGeneratedFile(
     "sdk.proto", &protobuf_RegisterTypes);
   ::protobuf_google_2fapi_2fannotations_2eproto::AddDescriptors();
@@ -3598,6 +3665,424 @@ void GameServer::InternalSwap(GameServer* other) {
 }
 
 
+// ===================================================================
+
+Capabilities_FeaturesEntry_DoNotUse::Capabilities_FeaturesEntry_DoNotUse() {}
+Capabilities_FeaturesEntry_DoNotUse::Capabilities_FeaturesEntry_DoNotUse(::google::protobuf::Arena* arena) : SuperType(arena) {}
+void Capabilities_FeaturesEntry_DoNotUse::MergeFrom(const Capabilities_FeaturesEntry_DoNotUse& other) {
+  MergeFromInternal(other);
+}
+::google::protobuf::Metadata Capabilities_FeaturesEntry_DoNotUse::GetMetadata() const {
+  ::protobuf_sdk_2eproto::protobuf_AssignDescriptorsOnce();
+  return ::protobuf_sdk_2eproto::file_level_metadata[11];
+}
+void Capabilities_FeaturesEntry_DoNotUse::MergeFrom(
+    const ::google::protobuf::Message& other) {
+  ::google::protobuf::Message::MergeFrom(other);
+}
+
+
+// ===================================================================
+
+void Capabilities::InitAsDefaultInstance() {
+}
+#if !defined(_MSC_VER) || _MSC_VER >= 1900
+const int Capabilities::kVersionFieldNumber;
+const int Capabilities::kFeaturesFieldNumber;
+#endif  // !defined(_MSC_VER) || _MSC_VER >= 1900
+
+Capabilities::Capabilities()
+  : ::google::protobuf::Message(), _internal_metadata_(NULL) {
+  ::google::protobuf::internal::InitSCC(
+      &protobuf_sdk_2eproto::scc_info_Capabilities.base);
+  SharedCtor();
+  // @@protoc_insertion_point(constructor:agones.dev.sdk.Capabilities)
+}
+Capabilities::Capabilities(const Capabilities& from)
+  : ::google::protobuf::Message(),
+      _internal_metadata_(NULL) {
+  _internal_metadata_.MergeFrom(from._internal_metadata_);
+  features_.MergeFrom(from.features_);
+  version_.UnsafeSetDefault(&::google::protobuf::internal::GetEmptyStringAlreadyInited());
+  if (from.version().size() > 0) {
+    version_.AssignWithDefault(&::google::protobuf::internal::GetEmptyStringAlreadyInited(), from.version_);
+  }
+  // @@protoc_insertion_point(copy_constructor:agones.dev.sdk.Capabilities)
+}
+
+void Capabilities::SharedCtor() {
+  version_.UnsafeSetDefault(&::google::protobuf::internal::GetEmptyStringAlreadyInited());
+}
+
+Capabilities::~Capabilities() {
+  // @@protoc_insertion_point(destructor:agones.dev.sdk.Capabilities)
+  SharedDtor();
+}
+
+void Capabilities::SharedDtor() {
+  version_.DestroyNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited());
+}
+
+void Capabilities::SetCachedSize(int size) const {
+  _cached_size_.Set(size);
+}
+const ::google::protobuf::Descriptor* Capabilities::descriptor() {
+  ::protobuf_sdk_2eproto::protobuf_AssignDescriptorsOnce();
+  return ::protobuf_sdk_2eproto::file_level_metadata[kIndexInFileMessages].descriptor;
+}
+
+const Capabilities& Capabilities::default_instance() {
+  ::google::protobuf::internal::InitSCC(&protobuf_sdk_2eproto::scc_info_Capabilities.base);
+  return *internal_default_instance();
+}
+
+
+void Capabilities::Clear() {
+// @@protoc_insertion_point(message_clear_start:agones.dev.sdk.Capabilities)
+  ::google::protobuf::uint32 cached_has_bits = 0;
+  // Prevent compiler warnings about cached_has_bits being unused
+  (void) cached_has_bits;
+
+  features_.Clear();
+  version_.ClearToEmptyNoArena(&::google::protobuf::internal::GetEmptyStringAlreadyInited());
+  _internal_metadata_.Clear();
+}
+
+bool Capabilities::MergePartialFromCodedStream(
+    ::google::protobuf::io::CodedInputStream* input) {
+#define DO_(EXPRESSION) if (!GOOGLE_PREDICT_TRUE(EXPRESSION)) goto failure
+  ::google::protobuf::uint32 tag;
+  // @@protoc_insertion_point(parse_start:agones.dev.sdk.Capabilities)
+  for (;;) {
+    ::std::pair<::google::protobuf::uint32, bool> p = input->ReadTagWithCutoffNoLastTag(127u);
+    tag = p.first;
+    if (!p.second) goto handle_unusual;
+    switch (::google::protobuf::internal::WireFormatLite::GetTagFieldNumber(tag)) {
+      // string version = 1;
+      case 1: {
+        if (static_cast< ::google::protobuf::uint8>(tag) ==
+            static_cast< ::google::protobuf::uint8>(10u /* 10 & 0xFF */)) {
+          DO_(::google::protobuf::internal::WireFormatLite::ReadString(
+                input, this->mutable_version()));
+          DO_(::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+            this->version().data(), static_cast<int>(this->version().length()),
+            ::google::protobuf::internal::WireFormatLite::PARSE,
+            "agones.dev.sdk.Capabilities.version"));
+        } else {
+          goto handle_unusual;
+        }
+        break;
+      }
+
+      // map<string, string> features = 2;
+      case 2: {
+        if (static_cast< ::google::protobuf::uint8>(tag) ==
+            static_cast< ::google::protobuf::uint8>(18u /* 18 & 0xFF */)) {
+          Capabilities_FeaturesEntry_DoNotUse::Parser< ::google::protobuf::internal::MapField<
+              Capabilities_FeaturesEntry_DoNotUse,
+              ::std::string, ::std::string,
+              ::google::protobuf::internal::WireFormatLite::TYPE_STRING,
+              ::google::protobuf::internal::WireFormatLite::TYPE_STRING,
+              0 >,
+            ::google::protobuf::Map< ::std::string, ::std::string > > parser(&features_);
+          DO_(::google::protobuf::internal::WireFormatLite::ReadMessageNoVirtual(
+              input, &parser));
+          DO_(::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+            parser.key().data(), static_cast<int>(parser.key().length()),
+            ::google::protobuf::internal::WireFormatLite::PARSE,
+            "agones.dev.sdk.Capabilities.FeaturesEntry.key"));
+          DO_(::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+            parser.value().data(), static_cast<int>(parser.value().length()),
+            ::google::protobuf::internal::WireFormatLite::PARSE,
+            "agones.dev.sdk.Capabilities.FeaturesEntry.value"));
+        } else {
+          goto handle_unusual;
+        }
+        break;
+      }
+
+      default: {
+      handle_unusual:
+        if (tag == 0) {
+          goto success;
+        }
+        DO_(::google::protobuf::internal::WireFormat::SkipField(
+              input, tag, _internal_metadata_.mutable_unknown_fields()));
+        break;
+      }
+    }
+  }
+success:
+  // @@protoc_insertion_point(parse_success:agones.dev.sdk.Capabilities)
+  return true;
+failure:
+  // @@protoc_insertion_point(parse_failure:agones.dev.sdk.Capabilities)
+  return false;
+#undef DO_
+}
+
+void Capabilities::SerializeWithCachedSizes(
+    ::google::protobuf::io::CodedOutputStream* output) const {
+  // @@protoc_insertion_point(serialize_start:agones.dev.sdk.Capabilities)
+  ::google::protobuf::uint32 cached_has_bits = 0;
+  (void) cached_has_bits;
+
+  // string version = 1;
+  if (this->version().size() > 0) {
+    ::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+      this->version().data(), static_cast<int>(this->version().length()),
+      ::google::protobuf::internal::WireFormatLite::SERIALIZE,
+      "agones.dev.sdk.Capabilities.version");
+    ::google::protobuf::internal::WireFormatLite::WriteStringMaybeAliased(
+      1, this->version(), output);
+  }
+
+  // map<string, string> features = 2;
+  if (!this->features().empty()) {
+    typedef ::google::protobuf::Map< ::std::string, ::std::string >::const_pointer
+        ConstPtr;
+    typedef ConstPtr SortItem;
+    typedef ::google::protobuf::internal::CompareByDerefFirst<SortItem> Less;
+    struct Utf8Check {
+      static void Check(ConstPtr p) {
+        ::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+          p->first.data(), static_cast<int>(p->first.length()),
+          ::google::protobuf::internal::WireFormatLite::SERIALIZE,
+          "agones.dev.sdk.Capabilities.FeaturesEntry.key");
+        ::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+          p->second.data(), static_cast<int>(p->second.length()),
+          ::google::protobuf::internal::WireFormatLite::SERIALIZE,
+          "agones.dev.sdk.Capabilities.FeaturesEntry.value");
+      }
+    };
+
+    if (output->IsSerializationDeterministic() &&
+        this->features().size() > 1) {
+      ::std::unique_ptr<SortItem[]> items(
+          new SortItem[this->features().size()]);
+      typedef ::google::protobuf::Map< ::std::string, ::std::string >::size_type size_type;
+      size_type n = 0;
+      for (::google::protobuf::Map< ::std::string, ::std::string >::const_iterator
+          it = this->features().begin();
+          it != this->features().end(); ++it, ++n) {
+        items[static_cast<ptrdiff_t>(n)] = SortItem(&*it);
+      }
+      ::std::sort(&items[0], &items[static_cast<ptrdiff_t>(n)], Less());
+      ::std::unique_ptr<Capabilities_FeaturesEntry_DoNotUse> entry;
+      for (size_type i = 0; i < n; i++) {
+        entry.reset(features_.NewEntryWrapper(
+            items[static_cast<ptrdiff_t>(i)]->first, items[static_cast<ptrdiff_t>(i)]->second));
+        ::google::protobuf::internal::WireFormatLite::WriteMessageMaybeToArray(
+            2, *entry, output);
+        Utf8Check::Check(items[static_cast<ptrdiff_t>(i)]);
+      }
+    } else {
+      ::std::unique_ptr<Capabilities_FeaturesEntry_DoNotUse> entry;
+      for (::google::protobuf::Map< ::std::string, ::std::string >::const_iterator
+          it = this->features().begin();
+          it != this->features().end(); ++it) {
+        entry.reset(features_.NewEntryWrapper(
+            it->first, it->second));
+        ::google::protobuf::internal::WireFormatLite::WriteMessageMaybeToArray(
+            2, *entry, output);
+        Utf8Check::Check(&*it);
+      }
+    }
+  }
+
+  if ((_internal_metadata_.have_unknown_fields() &&  ::google::protobuf::internal::GetProto3PreserveUnknownsDefault())) {
+    ::google::protobuf::internal::WireFormat::SerializeUnknownFields(
+        (::google::protobuf::internal::GetProto3PreserveUnknownsDefault()   ? _internal_metadata_.unknown_fields()   : _internal_metadata_.default_instance()), output);
+  }
+  // @@protoc_insertion_point(serialize_end:agones.dev.sdk.Capabilities)
+}
+
+::google::protobuf::uint8* Capabilities::InternalSerializeWithCachedSizesToArray(
+    bool deterministic, ::google::protobuf::uint8* target) const {
+  (void)deterministic; // Unused
+  // @@protoc_insertion_point(serialize_to_array_start:agones.dev.sdk.Capabilities)
+  ::google::protobuf::uint32 cached_has_bits = 0;
+  (void) cached_has_bits;
+
+  // string version = 1;
+  if (this->version().size() > 0) {
+    ::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+      this->version().data(), static_cast<int>(this->version().length()),
+      ::google::protobuf::internal::WireFormatLite::SERIALIZE,
+      "agones.dev.sdk.Capabilities.version");
+    target =
+      ::google::protobuf::internal::WireFormatLite::WriteStringToArray(
+        1, this->version(), target);
+  }
+
+  // map<string, string> features = 2;
+  if (!this->features().empty()) {
+    typedef ::google::protobuf::Map< ::std::string, ::std::string >::const_pointer
+        ConstPtr;
+    typedef ConstPtr SortItem;
+    typedef ::google::protobuf::internal::CompareByDerefFirst<SortItem> Less;
+    struct Utf8Check {
+      static void Check(ConstPtr p) {
+        ::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+          p->first.data(), static_cast<int>(p->first.length()),
+          ::google::protobuf::internal::WireFormatLite::SERIALIZE,
+          "agones.dev.sdk.Capabilities.FeaturesEntry.key");
+        ::google::protobuf::internal::WireFormatLite::VerifyUtf8String(
+          p->second.data(), static_cast<int>(p->second.length()),
+          ::google::protobuf::internal::WireFormatLite::SERIALIZE,
+          "agones.dev.sdk.Capabilities.FeaturesEntry.value");
+      }
+    };
+
+    if (deterministic &&
+        this->features().size() > 1) {
+      ::std::unique_ptr<SortItem[]> items(
+          new SortItem[this->features().size()]);
+      typedef ::google::protobuf::Map< ::std::string, ::std::string >::size_type size_type;
+      size_type n = 0;
+      for (::google::protobuf::Map< ::std::string, ::std::string >::const_iterator
+          it = this->features().begin();
+          it != this->features().end(); ++it, ++n) {
+        items[static_cast<ptrdiff_t>(n)] = SortItem(&*it);
+      }
+      ::std::sort(&items[0], &items[static_cast<ptrdiff_t>(n)], Less());
+      ::std::unique_ptr<Capabilities_FeaturesEntry_DoNotUse> entry;
+      for (size_type i = 0; i < n; i++) {
+        entry.reset(features_.NewEntryWrapper(
+            items[static_cast<ptrdiff_t>(i)]->first, items[static_cast<ptrdiff_t>(i)]->second));
+        target = ::google::protobuf::internal::WireFormatLite::
+                   InternalWriteMessageNoVirtualToArray(
+                       2, *entry, deterministic, target);
+;
+        Utf8Check::Check(items[static_cast<ptrdiff_t>(i)]);
+      }
+    } else {
+      ::std::unique_ptr<Capabilities_FeaturesEntry_DoNotUse> entry;
+      for (::google::protobuf::Map< ::std::string, ::std::string >::const_iterator
+          it = this->features().begin();
+          it != this->features().end(); ++it) {
+        entry.reset(features_.NewEntryWrapper(
+            it->first, it->second));
+        target = ::google::protobuf::internal::WireFormatLite::
+                   InternalWriteMessageNoVirtualToArray(
+                       2, *entry, deterministic, target);
+;
+        Utf8Check::Check(&*it);
+      }
+    }
+  }
+
+  if ((_internal_metadata_.have_unknown_fields() &&  ::google::protobuf::internal::GetProto3PreserveUnknownsDefault())) {
+    target = ::google::protobuf::internal::WireFormat::SerializeUnknownFieldsToArray(
+        (::google::protobuf::internal::GetProto3PreserveUnknownsDefault()   ? _internal_metadata_.unknown_fields()   : _internal_metadata_.default_instance()), target);
+  }
+  // @@protoc_insertion_point(serialize_to_array_end:agones.dev.sdk.Capabilities)
+  return target;
+}
+
+size_t Capabilities::ByteSizeLong() const {
+// @@protoc_insertion_point(message_byte_size_start:agones.dev.sdk.Capabilities)
+  size_t total_size = 0;
+
+  if ((_internal_metadata_.have_unknown_fields() &&  ::google::protobuf::internal::GetProto3PreserveUnknownsDefault())) {
+    total_size +=
+      ::google::protobuf::internal::WireFormat::ComputeUnknownFieldsSize(
+        (::google::protobuf::internal::GetProto3PreserveUnknownsDefault()   ? _internal_metadata_.unknown_fields()   : _internal_metadata_.default_instance()));
+  }
+  // map<string, string> features = 2;
+  total_size += 1 *
+      ::google::protobuf::internal::FromIntSize(this->features_size());
+  {
+    ::std::unique_ptr<Capabilities_FeaturesEntry_DoNotUse> entry;
+    for (::google::protobuf::Map< ::std::string, ::std::string >::const_iterator
+        it = this->features().begin();
+        it != this->features().end(); ++it) {
+      entry.reset(features_.NewEntryWrapper(it->first, it->second));
+      total_size += ::google::protobuf::internal::WireFormatLite::
+          MessageSizeNoVirtual(*entry);
+    }
+  }
+
+  // string version = 1;
+  if (this->version().size() > 0) {
+    total_size += 1 +
+      ::google::protobuf::internal::WireFormatLite::StringSize(
+        this->version());
+  }
+
+  int cached_size = ::google::protobuf::internal::ToCachedSize(total_size);
+  SetCachedSize(cached_size);
+  return total_size;
+}
+
+void Capabilities::MergeFrom(const ::google::protobuf::Message& from) {
+// @@protoc_insertion_point(generalized_merge_from_start:agones.dev.sdk.Capabilities)
+  GOOGLE_DCHECK_NE(&from, this);
+  const Capabilities* source =
+      ::google::protobuf::internal::DynamicCastToGenerated<const Capabilities>(
+          &from);
+  if (source == NULL) {
+  // @@protoc_insertion_point(generalized_merge_from_cast_fail:agones.dev.sdk.Capabilities)
+    ::google::protobuf::internal::ReflectionOps::Merge(from, this);
+  } else {
+  // @@protoc_insertion_point(generalized_merge_from_cast_success:agones.dev.sdk.Capabilities)
+    MergeFrom(*source);
+  }
+}
+
+void Capabilities::MergeFrom(const Capabilities& from) {
+// @@protoc_insertion_point(class_specific_merge_from_start:agones.dev.sdk.Capabilities)
+  GOOGLE_DCHECK_NE(&from, this);
+  _internal_metadata_.MergeFrom(from._internal_metadata_);
+  ::google::protobuf::uint32 cached_has_bits = 0;
+  (void) cached_has_bits;
+
+  features_.MergeFrom(from.features_);
+  if (from.version().size() > 0) {
+
+    version_.AssignWithDefault(&::google::protobuf::internal::GetEmptyStringAlreadyInited(), from.version_);
+  }
+}
+
+void Capabilities::CopyFrom(const ::google::protobuf::Message& from) {
+// @@protoc_insertion_point(generalized_copy_from_start:agones.dev.sdk.Capabilities)
+  if (&from == this) return;
+  Clear();
+  MergeFrom(from);
+}
+
+void Capabilities::CopyFrom(const Capabilities& from) {
+// @@protoc_insertion_point(class_specific_copy_from_start:agones.dev.sdk.Capabilities)
+  if (&from == this) return;
+  Clear();
+  MergeFrom(from);
+}
+
+bool Capabilities::IsInitialized() const {
+  return true;
+}
+
+void Capabilities::Swap(Capabilities* other) {
+  if (other == this) return;
+  InternalSwap(other);
+}
+void Capabilities::InternalSwap(Capabilities* other) {
+  using std::swap;
+  features_.Swap(&other->features_);
+  version_.Swap(&other->version_, &::google::protobuf::internal::GetEmptyStringAlreadyInited(),
+    GetArenaNoVirtual());
+  _internal_metadata_.Swap(&other->_internal_metadata_);
+}
+
+::google::protobuf::Metadata Capabilities::GetMetadata() const {
+  protobuf_sdk_2eproto::protobuf_AssignDescriptorsOnce();
+  return ::protobuf_sdk_2eproto::file_level_metadata[kIndexInFileMessages];
+}
+
+
+
+// ===================================================================
+
 // @@protoc_insertion_point(namespace_scope)
 }  // namespace sdk
 }  // namespace dev
@@ -3637,6 +4122,12 @@ template<> GOOGLE_PROTOBUF_ATTRIBUTE_NOINLINE ::agones::dev::sdk::GameServer_Sta
 template<> GOOGLE_PROTOBUF_ATTRIBUTE_NOINLINE ::agones::dev::sdk::GameServer* Arena::CreateMaybeMessage< ::agones::dev::sdk::GameServer >(Arena* arena) {
   return Arena::CreateInternal< ::agones::dev::sdk::GameServer >(arena);
 }
+template<> GOOGLE_PROTOBUF_ATTRIBUTE_NOINLINE ::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse* Arena::CreateMaybeMessage< ::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse >(Arena* arena) {
+  return Arena::CreateInternal< ::agones::dev::sdk::Capabilities_FeaturesEntry_DoNotUse >(arena);
+}
+template<> GOOGLE_PROTOBUF_ATTRIBUTE_NOINLINE ::agones::dev::sdk::Capabilities* Arena::CreateMaybeMessage< ::agones::dev::sdk::Capabilities >(Arena* arena) {
+  return Arena::CreateInternal< ::agones::dev::sdk::Capabilities >(arena);
+}
 }  // namespace protobuf
 }  // namespace google
 
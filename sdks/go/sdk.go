@@ -24,9 +24,19 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-const port = 59357
+const (
+	port = 59357
+
+	// allocationAnnotation identifies the current allocation of the GameServer,
+	// when the GameServerAllocation waits for it to be acknowledged
+	allocationAnnotation = "agones.dev/allocation"
+	// allocationAcknowledgedKey is the key of the annotation that acknowledges an allocation
+	allocationAcknowledgedKey = "allocation-acknowledged"
+)
 
 // GameServerCallback is a function definition to be called
 // when a GameServer CRD has been changed
@@ -79,6 +89,13 @@ func (s *SDK) Shutdown() error {
 	return errors.Wrapf(err, "could not send Shutdown message")
 }
 
+// ErrorReason returns the reason that the SDK server rejected a request with, e.g.
+// sdk.ErrorReasonShutdownRequested when Ready() is called after Shutdown(),
+// and false if the error is not one of them.
+func ErrorReason(err error) (sdk.ErrorReason, bool) {
+	return sdk.ReasonForError(err)
+}
+
 // Reserve marks the Game Server as Reserved for a given duration, at which point
 // it will return the GameServer to a Ready state.
 // Do note, the smallest unit available in the time.Duration argument is a second.
@@ -109,12 +126,39 @@ func (s *SDK) SetAnnotation(key, value string) error {
 	return errors.Wrap(err, "could not set annotation")
 }
 
+// AcknowledgeAllocation acknowledges the current allocation of the GameServer, once the game server
+// is ready for the players of the match, e.g. after it has loaded the map. A GameServerAllocation that
+// sets `acknowledgeTimeoutSeconds` is only returned to the caller after it is acknowledged.
+func (s *SDK) AcknowledgeAllocation() error {
+	gs, err := s.GameServer()
+	if err != nil {
+		return err
+	}
+	id, ok := gs.GetObjectMeta().GetAnnotations()[allocationAnnotation]
+	if !ok {
+		return errors.New("could not acknowledge allocation, as the GameServer has no allocation to acknowledge")
+	}
+	return s.SetAnnotation(allocationAcknowledgedKey, id)
+}
+
 // GameServer retrieve the GameServer details
 func (s *SDK) GameServer() (*sdk.GameServer, error) {
 	gs, err := s.client.GetGameServer(s.ctx, &sdk.Empty{})
 	return gs, errors.Wrap(err, "could not retrieve gameserver")
 }
 
+// GetCapabilities retrieves the version of the SDK server, and the SDK features
+// it supports, mapped to their stage: "alpha", "beta" or "stable".
+// If the SDK server is too old to support GetCapabilities, an empty Capabilities is
+// returned, so only the features that all SDK servers support should be relied on.
+func (s *SDK) GetCapabilities() (*sdk.Capabilities, error) {
+	c, err := s.client.GetCapabilities(s.ctx, &sdk.Empty{})
+	if status.Code(err) == codes.Unimplemented {
+		return &sdk.Capabilities{Features: map[string]string{}}, nil
+	}
+	return c, errors.Wrap(err, "could not retrieve capabilities")
+}
+
 // WatchGameServer asynchronously calls the given GameServerCallback with the current GameServer
 // configuration when the backing GameServer configuration is updated.
 // This function can be called multiple times to add more than one GameServerCallback.
@@ -22,7 +22,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 )
 
 func TestSDK(t *testing.T) {
@@ -127,19 +129,95 @@ func TestSDKSetAnnotation(t *testing.T) {
 	assert.Equal(t, expected, sm.annotations["foo"])
 }
 
+func TestSDKAcknowledgeAllocation(t *testing.T) {
+	t.Parallel()
+	sm := &sdkMock{
+		annotations: map[string]string{},
+	}
+	s := SDK{
+		ctx:    context.Background(),
+		client: sm,
+	}
+
+	err := s.AcknowledgeAllocation()
+	assert.NotNil(t, err)
+	assert.Empty(t, sm.annotations)
+
+	sm.gs = &sdk.GameServer{ObjectMeta: &sdk.GameServer_ObjectMeta{
+		Annotations: map[string]string{"agones.dev/allocation": "abc123"}}}
+	err = s.AcknowledgeAllocation()
+	assert.Nil(t, err)
+	assert.Equal(t, "abc123", sm.annotations["allocation-acknowledged"])
+}
+
+func TestSDKErrorReason(t *testing.T) {
+	t.Parallel()
+	sm := &sdkMock{}
+	s := SDK{
+		ctx:    context.Background(),
+		client: sm,
+	}
+
+	assert.NoError(t, s.Shutdown())
+
+	err := s.Ready()
+	assert.EqualError(t, err, "could not send Ready message: rpc error: code = FailedPrecondition desc = ShutdownRequested: the GameServer is shutting down, and can not be moved to RequestReady")
+	reason, ok := ErrorReason(err)
+	assert.True(t, ok)
+	assert.Equal(t, sdk.ErrorReasonShutdownRequested, reason)
+
+	assert.NoError(t, s.Shutdown())
+
+	_, ok = ErrorReason(nil)
+	assert.False(t, ok)
+	_, ok = ErrorReason(status.Error(codes.FailedPrecondition, "Unknown: not an SDK error"))
+	assert.False(t, ok)
+	_, ok = ErrorReason(status.Error(codes.Unavailable, "connection refused"))
+	assert.False(t, ok)
+}
+
+func TestSDKGetCapabilities(t *testing.T) {
+	t.Parallel()
+	sm := &sdkMock{
+		capabilities: &sdk.Capabilities{Version: "1.2.0", Features: map[string]string{"Reserve": "stable"}},
+	}
+	s := SDK{
+		ctx:    context.Background(),
+		client: sm,
+	}
+
+	c, err := s.GetCapabilities()
+	assert.Nil(t, err)
+	assert.Equal(t, sm.capabilities, c)
+
+	// older SDK servers don't support capabilities
+	sm.capabilitiesErr = status.Error(codes.Unimplemented, "unknown method GetCapabilities")
+	c, err = s.GetCapabilities()
+	assert.Nil(t, err)
+	assert.Equal(t, "", c.Version)
+	assert.Empty(t, c.Features)
+
+	sm.capabilitiesErr = status.Error(codes.Unavailable, "connection refused")
+	_, err = s.GetCapabilities()
+	assert.NotNil(t, err)
+}
+
 var _ sdk.SDKClient = &sdkMock{}
 var _ sdk.SDK_HealthClient = &healthMock{}
 var _ sdk.SDK_WatchGameServerClient = &watchMock{}
 
 type sdkMock struct {
-	ready       bool
-	shutdown    bool
-	allocated   bool
-	reserved    *sdk.Duration
-	hm          *healthMock
-	wm          *watchMock
-	labels      map[string]string
-	annotations map[string]string
+	ready           bool
+	shutdown        bool
+	allocated       bool
+	reserved        *sdk.Duration
+	hm              *healthMock
+	wm              *watchMock
+	labels          map[string]string
+	annotations     map[string]string
+	capabilities    *sdk.Capabilities
+	capabilitiesErr error
+	gs              *sdk.GameServer
 }
 
 func (m *sdkMock) SetLabel(ctx context.Context, in *sdk.KeyValue, opts ...grpc.CallOption) (*sdk.Empty, error) {
@@ -157,10 +235,16 @@ func (m *sdkMock) WatchGameServer(ctx context.Context, in *sdk.Empty, opts ...gr
 }
 
 func (m *sdkMock) GetGameServer(ctx context.Context, in *sdk.Empty, opts ...grpc.CallOption) (*sdk.GameServer, error) {
+	if m.gs != nil {
+		return m.gs, nil
+	}
 	return &sdk.GameServer{}, nil
 }
 
 func (m *sdkMock) Ready(ctx context.Context, e *sdk.Empty, opts ...grpc.CallOption) (*sdk.Empty, error) {
+	if m.shutdown {
+		return nil, sdk.NewError(sdk.ErrorReasonShutdownRequested, "the GameServer is shutting down, and can not be moved to RequestReady")
+	}
 	m.ready = true
 	return e, nil
 }
@@ -184,6 +268,13 @@ func (m *sdkMock) Reserve(ctx context.Context, in *sdk.Duration, opts ...grpc.Ca
 	return &sdk.Empty{}, nil
 }
 
+func (m *sdkMock) GetCapabilities(ctx context.Context, in *sdk.Empty, opts ...grpc.CallOption) (*sdk.Capabilities, error) {
+	if m.capabilitiesErr != nil {
+		return nil, m.capabilitiesErr
+	}
+	return m.capabilities, nil
+}
+
 type healthMock struct {
 	healthy bool
 }
@@ -20,6 +20,17 @@ var grpc = require('grpc');
 var sdk_pb = require('./sdk_pb.js');
 var google_api_annotations_pb = require('./google/api/annotations_pb.js');
 
+function serialize_agones_dev_sdk_Capabilities(arg) {
+  if (!(arg instanceof sdk_pb.Capabilities)) {
+    throw new Error('Expected argument of type agones.dev.sdk.Capabilities');
+  }
+  return new Buffer(arg.serializeBinary());
+}
+
+function deserialize_agones_dev_sdk_Capabilities(buffer_arg) {
+  return sdk_pb.Capabilities.deserializeBinary(new Uint8Array(buffer_arg));
+}
+
 function serialize_agones_dev_sdk_Duration(arg) {
   if (!(arg instanceof sdk_pb.Duration)) {
     throw new Error('Expected argument of type agones.dev.sdk.Duration');
@@ -175,6 +186,18 @@ var SDKService = exports.SDKService = {
     responseSerialize: serialize_agones_dev_sdk_Empty,
     responseDeserialize: deserialize_agones_dev_sdk_Empty,
   },
+  // Retrieve the features supported by this SDK server, and their stability
+  getCapabilities: {
+    path: '/agones.dev.sdk.SDK/GetCapabilities',
+    requestStream: false,
+    responseStream: false,
+    requestType: sdk_pb.Empty,
+    responseType: sdk_pb.Capabilities,
+    requestSerialize: serialize_agones_dev_sdk_Empty,
+    requestDeserialize: deserialize_agones_dev_sdk_Empty,
+    responseSerialize: serialize_agones_dev_sdk_Capabilities,
+    responseDeserialize: deserialize_agones_dev_sdk_Capabilities,
+  },
 };
 
 exports.SDKClient = grpc.makeGenericClientConstructor(SDKService);
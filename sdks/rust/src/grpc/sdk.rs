@@ -2168,6 +2168,206 @@ impl ::protobuf::reflect::ProtobufValue for GameServer_Status_Port {
     }
 }
 
+#[derive(PartialEq,Clone,Default)]
+pub struct Capabilities {
+    // message fields
+    pub version: ::std::string::String,
+    pub features: ::std::collections::HashMap<::std::string::String, ::std::string::String>,
+    // special fields
+    unknown_fields: ::protobuf::UnknownFields,
+    cached_size: ::protobuf::CachedSize,
+}
+
+impl Capabilities {
+    pub fn new() -> Capabilities {
+        ::std::default::Default::default()
+    }
+
+    // string version = 1;
+
+    pub fn clear_version(&mut self) {
+        self.version.clear();
+    }
+
+    // Param is passed by value, moved
+    pub fn set_version(&mut self, v: ::std::string::String) {
+        self.version = v;
+    }
+
+    // Mutable pointer to the field.
+    // If field is not initialized, it is initialized with default value first.
+    pub fn mut_version(&mut self) -> &mut ::std::string::String {
+        &mut self.version
+    }
+
+    // Take field
+    pub fn take_version(&mut self) -> ::std::string::String {
+        ::std::mem::replace(&mut self.version, ::std::string::String::new())
+    }
+
+    pub fn get_version(&self) -> &str {
+        &self.version
+    }
+
+    // repeated .agones.dev.sdk.Capabilities.FeaturesEntry features = 2;
+
+    pub fn clear_features(&mut self) {
+        self.features.clear();
+    }
+
+    // Param is passed by value, moved
+    pub fn set_features(&mut self, v: ::std::collections::HashMap<::std::string::String, ::std::string::String>) {
+        self.features = v;
+    }
+
+    // Mutable pointer to the field.
+    pub fn mut_features(&mut self) -> &mut ::std::collections::HashMap<::std::string::String, ::std::string::String> {
+        &mut self.features
+    }
+
+    // Take field
+    pub fn take_features(&mut self) -> ::std::collections::HashMap<::std::string::String, ::std::string::String> {
+        ::std::mem::replace(&mut self.features, ::std::collections::HashMap::new())
+    }
+
+    pub fn get_features(&self) -> &::std::collections::HashMap<::std::string::String, ::std::string::String> {
+        &self.features
+    }
+}
+
+impl ::protobuf::Message for Capabilities {
+    fn is_initialized(&self) -> bool {
+        true
+    }
+
+    fn merge_from(&mut self, is: &mut ::protobuf::CodedInputStream) -> ::protobuf::ProtobufResult<()> {
+        while !is.eof()? {
+            let (field_number, wire_type) = is.read_tag_unpack()?;
+            match field_number {
+                1 => {
+                    ::protobuf::rt::read_singular_proto3_string_into(wire_type, is, &mut self.version)?;
+                },
+                2 => {
+                    ::protobuf::rt::read_map_into::<::protobuf::types::ProtobufTypeString, ::protobuf::types::ProtobufTypeString>(wire_type, is, &mut self.features)?;
+                },
+                _ => {
+                    ::protobuf::rt::read_unknown_or_skip_group(field_number, wire_type, is, self.mut_unknown_fields())?;
+                },
+            };
+        }
+        ::std::result::Result::Ok(())
+    }
+
+    // Compute sizes of nested messages
+    #[allow(unused_variables)]
+    fn compute_size(&self) -> u32 {
+        let mut my_size = 0;
+        if !self.version.is_empty() {
+            my_size += ::protobuf::rt::string_size(1, &self.version);
+        }
+        my_size += ::protobuf::rt::compute_map_size::<::protobuf::types::ProtobufTypeString, ::protobuf::types::ProtobufTypeString>(2, &self.features);
+        my_size += ::protobuf::rt::unknown_fields_size(self.get_unknown_fields());
+        self.cached_size.set(my_size);
+        my_size
+    }
+
+    fn write_to_with_cached_sizes(&self, os: &mut ::protobuf::CodedOutputStream) -> ::protobuf::ProtobufResult<()> {
+        if !self.version.is_empty() {
+            os.write_string(1, &self.version)?;
+        }
+        ::protobuf::rt::write_map_with_cached_sizes::<::protobuf::types::ProtobufTypeString, ::protobuf::types::ProtobufTypeString>(2, &self.features, os)?;
+        os.write_unknown_fields(self.get_unknown_fields())?;
+        ::std::result::Result::Ok(())
+    }
+
+    fn get_cached_size(&self) -> u32 {
+        self.cached_size.get()
+    }
+
+    fn get_unknown_fields(&self) -> &::protobuf::UnknownFields {
+        &self.unknown_fields
+    }
+
+    fn mut_unknown_fields(&mut self) -> &mut ::protobuf::UnknownFields {
+        &mut self.unknown_fields
+    }
+
+    fn as_any(&self) -> &::std::any::Any {
+        self as &::std::any::Any
+    }
+    fn as_any_mut(&mut self) -> &mut ::std::any::Any {
+        self as &mut ::std::any::Any
+    }
+    fn into_any(self: Box<Self>) -> ::std::boxed::Box<::std::any::Any> {
+        self
+    }
+
+    fn descriptor(&self) -> &'static ::protobuf::reflect::MessageDescriptor {
+        Self::descriptor_static()
+    }
+
+    fn new() -> Capabilities {
+        Capabilities::new()
+    }
+
+    fn descriptor_static() -> &'static ::protobuf::reflect::MessageDescriptor {
+        static mut descriptor: ::protobuf::lazy::Lazy<::protobuf::reflect::MessageDescriptor> = ::protobuf::lazy::Lazy {
+            lock: ::protobuf::lazy::ONCE_INIT,
+            ptr: 0 as *const ::protobuf::reflect::MessageDescriptor,
+        };
+        unsafe {
+            descriptor.get(|| {
+                let mut fields = ::std::vec::Vec::new();
+                fields.push(::protobuf::reflect::accessor::make_simple_field_accessor::<_, ::protobuf::types::ProtobufTypeString>(
+                    "version",
+                    |m: &Capabilities| { &m.version },
+                    |m: &mut Capabilities| { &mut m.version },
+                ));
+                fields.push(::protobuf::reflect::accessor::make_map_accessor::<_, ::protobuf::types::ProtobufTypeString, ::protobuf::types::ProtobufTypeString>(
+                    "features",
+                    |m: &Capabilities| { &m.features },
+                    |m: &mut Capabilities| { &mut m.features },
+                ));
+                ::protobuf::reflect::MessageDescriptor::new::<Capabilities>(
+                    "Capabilities",
+                    fields,
+                    file_descriptor_proto()
+                )
+            })
+        }
+    }
+
+    fn default_instance() -> &'static Capabilities {
+        static mut instance: ::protobuf::lazy::Lazy<Capabilities> = ::protobuf::lazy::Lazy {
+            lock: ::protobuf::lazy::ONCE_INIT,
+            ptr: 0 as *const Capabilities,
+        };
+        unsafe {
+            instance.get(Capabilities::new)
+        }
+    }
+}
+
+impl ::protobuf::Clear for Capabilities {
+    fn clear(&mut self) {
+        self.clear_version();
+        self.clear_features();
+        self.unknown_fields.clear();
+    }
+}
+
+impl ::std::fmt::Debug for Capabilities {
+    fn fmt(&self, f: &mut ::std::fmt::Formatter) -> ::std::fmt::Result {
+        ::protobuf::text_format::fmt(self, f)
+    }
+}
+
+impl ::protobuf::reflect::ProtobufValue for Capabilities {
+    fn as_ref(&self) -> ::protobuf::reflect::ProtobufValueRef {
+        ::protobuf::reflect::ProtobufValueRef::Message(self)
+    }
+}
+
 static file_descriptor_proto_data: &'static [u8] = b"\
     \n\tsdk.proto\x12\x0eagones.dev.sdk\x1a\x1cgoogle/api/annotations.proto\
     \"\x07\n\x05Empty\"2\n\x08KeyValue\x12\x10\n\x03key\x18\x01\x20\x01(\tR\
@@ -2199,210 +2399,221 @@ static file_descriptor_proto_data: &'static [u8] = b"\
     \n\x07address\x18\x02\x20\x01(\tR\x07address\x12<\n\x05ports\x18\x03\x20\
     \x03(\x0b2&.agones.dev.sdk.GameServer.Status.PortR\x05ports\x1a.\n\x04Po\
     rt\x12\x12\n\x04name\x18\x01\x20\x01(\tR\x04name\x12\x12\n\x04port\x18\
-    \x02\x20\x01(\x05R\x04port2\x86\x06\n\x03SDK\x12H\n\x05Ready\x12\x15.ago\
-    nes.dev.sdk.Empty\x1a\x15.agones.dev.sdk.Empty\"\x11\x82\xd3\xe4\x93\x02\
-    \x0b\"\x06/ready:\x01*\x12N\n\x08Allocate\x12\x15.agones.dev.sdk.Empty\
-    \x1a\x15.agones.dev.sdk.Empty\"\x14\x82\xd3\xe4\x93\x02\x0e\"\t/allocate\
-    :\x01*\x12N\n\x08Shutdown\x12\x15.agones.dev.sdk.Empty\x1a\x15.agones.de\
-    v.sdk.Empty\"\x14\x82\xd3\xe4\x93\x02\x0e\"\t/shutdown:\x01*\x12L\n\x06H\
-    ealth\x12\x15.agones.dev.sdk.Empty\x1a\x15.agones.dev.sdk.Empty\"\x12\
-    \x82\xd3\xe4\x93\x02\x0c\"\x07/health:\x01*(\x01\x12W\n\rGetGameServer\
-    \x12\x15.agones.dev.sdk.Empty\x1a\x1a.agones.dev.sdk.GameServer\"\x13\
-    \x82\xd3\xe4\x93\x02\r\x12\x0b/gameserver\x12a\n\x0fWatchGameServer\x12\
-    \x15.agones.dev.sdk.Empty\x1a\x1a.agones.dev.sdk.GameServer\"\x19\x82\
-    \xd3\xe4\x93\x02\x13\x12\x11/watch/gameserver0\x01\x12W\n\x08SetLabel\
-    \x12\x18.agones.dev.sdk.KeyValue\x1a\x15.agones.dev.sdk.Empty\"\x1a\x82\
-    \xd3\xe4\x93\x02\x14\x1a\x0f/metadata/label:\x01*\x12a\n\rSetAnnotation\
-    \x12\x18.agones.dev.sdk.KeyValue\x1a\x15.agones.dev.sdk.Empty\"\x1f\x82\
-    \xd3\xe4\x93\x02\x19\x1a\x14/metadata/annotation:\x01*\x12O\n\x07Reserve\
-    \x12\x18.agones.dev.sdk.Duration\x1a\x15.agones.dev.sdk.Empty\"\x13\x82\
-    \xd3\xe4\x93\x02\r\"\x08/reserve:\x01*B\x05Z\x03sdkJ\xfe$\n\x07\x12\x05\
-    \x0e\0\x96\x01\x01\n\xd1\x04\n\x01\x0c\x12\x03\x0e\0\x122\xc6\x04\x20Cop\
-    yright\x202017\x20Google\x20LLC\x20All\x20Rights\x20Reserved.\n\n\x20Lic\
-    ensed\x20under\x20the\x20Apache\x20License,\x20Version\x202.0\x20(the\
-    \x20\"License\");\n\x20you\x20may\x20not\x20use\x20this\x20file\x20excep\
-    t\x20in\x20compliance\x20with\x20the\x20License.\n\x20You\x20may\x20obta\
-    in\x20a\x20copy\x20of\x20the\x20License\x20at\n\n\x20\x20\x20\x20\x20htt\
-    p://www.apache.org/licenses/LICENSE-2.0\n\n\x20Unless\x20required\x20by\
-    \x20applicable\x20law\x20or\x20agreed\x20to\x20in\x20writing,\x20softwar\
-    e\n\x20distributed\x20under\x20the\x20License\x20is\x20distributed\x20on\
-    \x20an\x20\"AS\x20IS\"\x20BASIS,\n\x20WITHOUT\x20WARRANTIES\x20OR\x20CON\
-    DITIONS\x20OF\x20ANY\x20KIND,\x20either\x20express\x20or\x20implied.\n\
-    \x20See\x20the\x20License\x20for\x20the\x20specific\x20language\x20gover\
-    ning\x20permissions\x20and\n\x20limitations\x20under\x20the\x20License.\
-    \n\n\x08\n\x01\x02\x12\x03\x10\x08\x16\n\x08\n\x01\x08\x12\x03\x11\0\x1a\
-    \n\t\n\x02\x08\x0b\x12\x03\x11\0\x1a\n\t\n\x02\x03\0\x12\x03\x13\x07%\nM\
-    \n\x02\x06\0\x12\x04\x16\0Y\x01\x1aA\x20SDK\x20service\x20to\x20be\x20us\
-    ed\x20in\x20the\x20GameServer\x20SDK\x20to\x20the\x20Pod\x20Sidecar\n\n\
-    \n\n\x03\x06\0\x01\x12\x03\x16\x08\x0b\n1\n\x04\x06\0\x02\0\x12\x04\x18\
-    \x04\x1d\x05\x1a#\x20Call\x20when\x20the\x20GameServer\x20is\x20ready\n\
-    \n\x0c\n\x05\x06\0\x02\0\x01\x12\x03\x18\x08\r\n\x0c\n\x05\x06\0\x02\0\
-    \x02\x12\x03\x18\x0f\x14\n\x0c\n\x05\x06\0\x02\0\x03\x12\x03\x18\x1f$\n\
-    \r\n\x05\x06\0\x02\0\x04\x12\x04\x19\x08\x1c\n\n\x11\n\t\x06\0\x02\0\x04\
-    \xb0\xca\xbc\"\x12\x04\x19\x08\x1c\n\n6\n\x04\x06\0\x02\x01\x12\x04\x20\
-    \x04%\x05\x1a(\x20Call\x20to\x20self\x20Allocation\x20the\x20GameServer\
-    \n\n\x0c\n\x05\x06\0\x02\x01\x01\x12\x03\x20\x08\x10\n\x0c\n\x05\x06\0\
-    \x02\x01\x02\x12\x03\x20\x11\x16\n\x0c\n\x05\x06\0\x02\x01\x03\x12\x03\
-    \x20!&\n\r\n\x05\x06\0\x02\x01\x04\x12\x04!\x08$\n\n\x11\n\t\x06\0\x02\
-    \x01\x04\xb0\xca\xbc\"\x12\x04!\x08$\n\n9\n\x04\x06\0\x02\x02\x12\x04(\
-    \x04-\x05\x1a+\x20Call\x20when\x20the\x20GameServer\x20is\x20shutting\
-    \x20down\n\n\x0c\n\x05\x06\0\x02\x02\x01\x12\x03(\x08\x10\n\x0c\n\x05\
-    \x06\0\x02\x02\x02\x12\x03(\x12\x17\n\x0c\n\x05\x06\0\x02\x02\x03\x12\
-    \x03(\"'\n\r\n\x05\x06\0\x02\x02\x04\x12\x04)\x08,\n\n\x11\n\t\x06\0\x02\
-    \x02\x04\xb0\xca\xbc\"\x12\x04)\x08,\n\nW\n\x04\x06\0\x02\x03\x12\x04/\
-    \x044\x05\x1aI\x20Send\x20a\x20Empty\x20every\x20d\x20Duration\x20to\x20\
-    declare\x20that\x20this\x20GameSever\x20is\x20healthy\n\n\x0c\n\x05\x06\
-    \0\x02\x03\x01\x12\x03/\x08\x0e\n\x0c\n\x05\x06\0\x02\x03\x05\x12\x03/\
-    \x10\x16\n\x0c\n\x05\x06\0\x02\x03\x02\x12\x03/\x17\x1c\n\x0c\n\x05\x06\
-    \0\x02\x03\x03\x12\x03/',\n\r\n\x05\x06\0\x02\x03\x04\x12\x040\x083\x12\
-    \n\x11\n\t\x06\0\x02\x03\x04\xb0\xca\xbc\"\x12\x040\x083\x12\n4\n\x04\
-    \x06\0\x02\x04\x12\x046\x04:\x05\x1a&\x20Retrieve\x20the\x20current\x20G\
-    ameServer\x20data\n\n\x0c\n\x05\x06\0\x02\x04\x01\x12\x036\x08\x15\n\x0c\
-    \n\x05\x06\0\x02\x04\x02\x12\x036\x17\x1c\n\x0c\n\x05\x06\0\x02\x04\x03\
-    \x12\x036'1\n\r\n\x05\x06\0\x02\x04\x04\x12\x047\x089\n\n\x11\n\t\x06\0\
-    \x02\x04\x04\xb0\xca\xbc\"\x12\x047\x089\n\nJ\n\x04\x06\0\x02\x05\x12\
-    \x04<\x04@\x05\x1a<\x20Send\x20GameServer\x20details\x20whenever\x20the\
-    \x20GameServer\x20is\x20updated\n\n\x0c\n\x05\x06\0\x02\x05\x01\x12\x03<\
-    \x08\x17\n\x0c\n\x05\x06\0\x02\x05\x02\x12\x03<\x19\x1e\n\x0c\n\x05\x06\
-    \0\x02\x05\x06\x12\x03<)/\n\x0c\n\x05\x06\0\x02\x05\x03\x12\x03<0:\n\r\n\
-    \x05\x06\0\x02\x05\x04\x12\x04=\x08?\n\n\x11\n\t\x06\0\x02\x05\x04\xb0\
-    \xca\xbc\"\x12\x04=\x08?\n\n@\n\x04\x06\0\x02\x06\x12\x04C\x04H\x05\x1a2\
-    \x20Apply\x20a\x20Label\x20to\x20the\x20backing\x20GameServer\x20metadat\
-    a\n\n\x0c\n\x05\x06\0\x02\x06\x01\x12\x03C\x08\x10\n\x0c\n\x05\x06\0\x02\
-    \x06\x02\x12\x03C\x11\x19\n\x0c\n\x05\x06\0\x02\x06\x03\x12\x03C$)\n\r\n\
-    \x05\x06\0\x02\x06\x04\x12\x04D\x08G\x12\n\x11\n\t\x06\0\x02\x06\x04\xb0\
-    \xca\xbc\"\x12\x04D\x08G\x12\nE\n\x04\x06\0\x02\x07\x12\x04K\x04P\x05\
-    \x1a7\x20Apply\x20a\x20Annotation\x20to\x20the\x20backing\x20GameServer\
-    \x20metadata\n\n\x0c\n\x05\x06\0\x02\x07\x01\x12\x03K\x08\x15\n\x0c\n\
-    \x05\x06\0\x02\x07\x02\x12\x03K\x16\x1e\n\x0c\n\x05\x06\0\x02\x07\x03\
-    \x12\x03K).\n\r\n\x05\x06\0\x02\x07\x04\x12\x04L\x08O\x12\n\x11\n\t\x06\
-    \0\x02\x07\x04\xb0\xca\xbc\"\x12\x04L\x08O\x12\nG\n\x04\x06\0\x02\x08\
-    \x12\x04S\x04X\x05\x1a9\x20Marks\x20the\x20GameServer\x20as\x20the\x20Re\
-    served\x20state\x20for\x20Duration\n\n\x0c\n\x05\x06\0\x02\x08\x01\x12\
-    \x03S\x08\x0f\n\x0c\n\x05\x06\0\x02\x08\x02\x12\x03S\x10\x18\n\x0c\n\x05\
-    \x06\0\x02\x08\x03\x12\x03S#(\n\r\n\x05\x06\0\x02\x08\x04\x12\x04T\x08W\
-    \n\n\x11\n\t\x06\0\x02\x08\x04\xb0\xca\xbc\"\x12\x04T\x08W\n\n\x18\n\x02\
-    \x04\0\x12\x04\\\0]\x01\x1a\x0c\x20I\x20am\x20Empty\n\n\n\n\x03\x04\0\
-    \x01\x12\x03\\\x08\r\n\x1e\n\x02\x04\x01\x12\x04`\0c\x01\x1a\x12\x20Key,\
-    \x20Value\x20entry\n\n\n\n\x03\x04\x01\x01\x12\x03`\x08\x10\n\x0b\n\x04\
-    \x04\x01\x02\0\x12\x03a\x04\x13\n\r\n\x05\x04\x01\x02\0\x04\x12\x04a\x04\
-    `\x12\n\x0c\n\x05\x04\x01\x02\0\x05\x12\x03a\x04\n\n\x0c\n\x05\x04\x01\
-    \x02\0\x01\x12\x03a\x0b\x0e\n\x0c\n\x05\x04\x01\x02\0\x03\x12\x03a\x11\
-    \x12\n\x0b\n\x04\x04\x01\x02\x01\x12\x03b\x04\x15\n\r\n\x05\x04\x01\x02\
-    \x01\x04\x12\x04b\x04a\x13\n\x0c\n\x05\x04\x01\x02\x01\x05\x12\x03b\x04\
-    \n\n\x0c\n\x05\x04\x01\x02\x01\x01\x12\x03b\x0b\x10\n\x0c\n\x05\x04\x01\
-    \x02\x01\x03\x12\x03b\x13\x14\n'\n\x02\x04\x02\x12\x04f\0h\x01\x1a\x1b\
-    \x20time\x20duration,\x20in\x20seconds\n\n\n\n\x03\x04\x02\x01\x12\x03f\
-    \x08\x10\n\x0b\n\x04\x04\x02\x02\0\x12\x03g\x04\x16\n\r\n\x05\x04\x02\
-    \x02\0\x04\x12\x04g\x04f\x12\n\x0c\n\x05\x04\x02\x02\0\x05\x12\x03g\x04\
-    \t\n\x0c\n\x05\x04\x02\x02\0\x01\x12\x03g\n\x11\n\x0c\n\x05\x04\x02\x02\
-    \0\x03\x12\x03g\x14\x15\n\xa3\x01\n\x02\x04\x03\x12\x05m\0\x96\x01\x01\
-    \x1a\x95\x01\x20A\x20GameServer\x20Custom\x20Resource\x20Definition\x20o\
-    bject\n\x20We\x20will\x20only\x20export\x20those\x20resources\x20that\
-    \x20make\x20the\x20most\n\x20sense.\x20Can\x20always\x20expand\x20to\x20\
-    more\x20as\x20needed.\n\n\n\n\x03\x04\x03\x01\x12\x03m\x08\x12\n\x0b\n\
-    \x04\x04\x03\x02\0\x12\x03n\x04\x1f\n\r\n\x05\x04\x03\x02\0\x04\x12\x04n\
-    \x04m\x14\n\x0c\n\x05\x04\x03\x02\0\x06\x12\x03n\x04\x0e\n\x0c\n\x05\x04\
-    \x03\x02\0\x01\x12\x03n\x0f\x1a\n\x0c\n\x05\x04\x03\x02\0\x03\x12\x03n\
-    \x1d\x1e\n\x0b\n\x04\x04\x03\x02\x01\x12\x03o\x04\x12\n\r\n\x05\x04\x03\
-    \x02\x01\x04\x12\x04o\x04n\x1f\n\x0c\n\x05\x04\x03\x02\x01\x06\x12\x03o\
-    \x04\x08\n\x0c\n\x05\x04\x03\x02\x01\x01\x12\x03o\t\r\n\x0c\n\x05\x04\
-    \x03\x02\x01\x03\x12\x03o\x10\x11\n\x0b\n\x04\x04\x03\x02\x02\x12\x03p\
-    \x04\x16\n\r\n\x05\x04\x03\x02\x02\x04\x12\x04p\x04o\x12\n\x0c\n\x05\x04\
-    \x03\x02\x02\x06\x12\x03p\x04\n\n\x0c\n\x05\x04\x03\x02\x02\x01\x12\x03p\
-    \x0b\x11\n\x0c\n\x05\x04\x03\x02\x02\x03\x12\x03p\x14\x15\n=\n\x04\x04\
-    \x03\x03\0\x12\x04s\x04\x7f\x05\x1a/\x20representation\x20of\x20the\x20K\
-    8s\x20ObjectMeta\x20resource\n\n\x0c\n\x05\x04\x03\x03\0\x01\x12\x03s\
-    \x0c\x16\n\r\n\x06\x04\x03\x03\0\x02\0\x12\x03t\x08\x18\n\x0f\n\x07\x04\
-    \x03\x03\0\x02\0\x04\x12\x04t\x08s\x18\n\x0e\n\x07\x04\x03\x03\0\x02\0\
-    \x05\x12\x03t\x08\x0e\n\x0e\n\x07\x04\x03\x03\0\x02\0\x01\x12\x03t\x0f\
-    \x13\n\x0e\n\x07\x04\x03\x03\0\x02\0\x03\x12\x03t\x16\x17\n\r\n\x06\x04\
-    \x03\x03\0\x02\x01\x12\x03u\x08\x1d\n\x0f\n\x07\x04\x03\x03\0\x02\x01\
-    \x04\x12\x04u\x08t\x18\n\x0e\n\x07\x04\x03\x03\0\x02\x01\x05\x12\x03u\
-    \x08\x0e\n\x0e\n\x07\x04\x03\x03\0\x02\x01\x01\x12\x03u\x0f\x18\n\x0e\n\
-    \x07\x04\x03\x03\0\x02\x01\x03\x12\x03u\x1b\x1c\n\r\n\x06\x04\x03\x03\0\
-    \x02\x02\x12\x03v\x08\x17\n\x0f\n\x07\x04\x03\x03\0\x02\x02\x04\x12\x04v\
-    \x08u\x1d\n\x0e\n\x07\x04\x03\x03\0\x02\x02\x05\x12\x03v\x08\x0e\n\x0e\n\
-    \x07\x04\x03\x03\0\x02\x02\x01\x12\x03v\x0f\x12\n\x0e\n\x07\x04\x03\x03\
-    \0\x02\x02\x03\x12\x03v\x15\x16\n\r\n\x06\x04\x03\x03\0\x02\x03\x12\x03w\
-    \x08$\n\x0f\n\x07\x04\x03\x03\0\x02\x03\x04\x12\x04w\x08v\x17\n\x0e\n\
-    \x07\x04\x03\x03\0\x02\x03\x05\x12\x03w\x08\x0e\n\x0e\n\x07\x04\x03\x03\
-    \0\x02\x03\x01\x12\x03w\x0f\x1f\n\x0e\n\x07\x04\x03\x03\0\x02\x03\x03\
-    \x12\x03w\"#\n\r\n\x06\x04\x03\x03\0\x02\x04\x12\x03x\x08\x1d\n\x0f\n\
-    \x07\x04\x03\x03\0\x02\x04\x04\x12\x04x\x08w$\n\x0e\n\x07\x04\x03\x03\0\
-    \x02\x04\x05\x12\x03x\x08\r\n\x0e\n\x07\x04\x03\x03\0\x02\x04\x01\x12\
-    \x03x\x0e\x18\n\x0e\n\x07\x04\x03\x03\0\x02\x04\x03\x12\x03x\x1b\x1c\n<\
-    \n\x06\x04\x03\x03\0\x02\x05\x12\x03z\x08%\x1a-\x20timestamp\x20is\x20in\
-    \x20Epoch\x20format,\x20unit:\x20seconds\n\n\x0f\n\x07\x04\x03\x03\0\x02\
-    \x05\x04\x12\x04z\x08x\x1d\n\x0e\n\x07\x04\x03\x03\0\x02\x05\x05\x12\x03\
-    z\x08\r\n\x0e\n\x07\x04\x03\x03\0\x02\x05\x01\x12\x03z\x0e\x20\n\x0e\n\
-    \x07\x04\x03\x03\0\x02\x05\x03\x12\x03z#$\nK\n\x06\x04\x03\x03\0\x02\x06\
-    \x12\x03|\x08%\x1a<\x20optional\x20deletion\x20timestamp\x20in\x20Epoch\
-    \x20format,\x20unit:\x20seconds\n\n\x0f\n\x07\x04\x03\x03\0\x02\x06\x04\
-    \x12\x04|\x08z%\n\x0e\n\x07\x04\x03\x03\0\x02\x06\x05\x12\x03|\x08\r\n\
-    \x0e\n\x07\x04\x03\x03\0\x02\x06\x01\x12\x03|\x0e\x20\n\x0e\n\x07\x04\
-    \x03\x03\0\x02\x06\x03\x12\x03|#$\n\r\n\x06\x04\x03\x03\0\x02\x07\x12\
-    \x03}\x08,\n\x0f\n\x07\x04\x03\x03\0\x02\x07\x04\x12\x04}\x08|%\n\x0e\n\
-    \x07\x04\x03\x03\0\x02\x07\x06\x12\x03}\x08\x1b\n\x0e\n\x07\x04\x03\x03\
-    \0\x02\x07\x01\x12\x03}\x1c'\n\x0e\n\x07\x04\x03\x03\0\x02\x07\x03\x12\
-    \x03}*+\n\r\n\x06\x04\x03\x03\0\x02\x08\x12\x03~\x08'\n\x0f\n\x07\x04\
-    \x03\x03\0\x02\x08\x04\x12\x04~\x08},\n\x0e\n\x07\x04\x03\x03\0\x02\x08\
-    \x06\x12\x03~\x08\x1b\n\x0e\n\x07\x04\x03\x03\0\x02\x08\x01\x12\x03~\x1c\
-    \"\n\x0e\n\x07\x04\x03\x03\0\x02\x08\x03\x12\x03~%&\n\x0e\n\x04\x04\x03\
-    \x03\x01\x12\x06\x81\x01\x04\x8a\x01\x05\n\r\n\x05\x04\x03\x03\x01\x01\
-    \x12\x04\x81\x01\x0c\x10\n\x0e\n\x06\x04\x03\x03\x01\x02\0\x12\x04\x82\
-    \x01\x08\x1a\n\x11\n\x07\x04\x03\x03\x01\x02\0\x04\x12\x06\x82\x01\x08\
-    \x81\x01\x12\n\x0f\n\x07\x04\x03\x03\x01\x02\0\x06\x12\x04\x82\x01\x08\
-    \x0e\n\x0f\n\x07\x04\x03\x03\x01\x02\0\x01\x12\x04\x82\x01\x0f\x15\n\x0f\
-    \n\x07\x04\x03\x03\x01\x02\0\x03\x12\x04\x82\x01\x18\x19\n\x10\n\x06\x04\
-    \x03\x03\x01\x03\0\x12\x06\x84\x01\x08\x89\x01\t\n\x0f\n\x07\x04\x03\x03\
-    \x01\x03\0\x01\x12\x04\x84\x01\x10\x16\n\x10\n\x08\x04\x03\x03\x01\x03\0\
-    \x02\0\x12\x04\x85\x01\x0c\x1e\n\x13\n\t\x04\x03\x03\x01\x03\0\x02\0\x04\
-    \x12\x06\x85\x01\x0c\x84\x01\x18\n\x11\n\t\x04\x03\x03\x01\x03\0\x02\0\
-    \x05\x12\x04\x85\x01\x0c\x10\n\x11\n\t\x04\x03\x03\x01\x03\0\x02\0\x01\
-    \x12\x04\x85\x01\x11\x19\n\x11\n\t\x04\x03\x03\x01\x03\0\x02\0\x03\x12\
-    \x04\x85\x01\x1c\x1d\n\x10\n\x08\x04\x03\x03\x01\x03\0\x02\x01\x12\x04\
-    \x86\x01\x0c%\n\x13\n\t\x04\x03\x03\x01\x03\0\x02\x01\x04\x12\x06\x86\
-    \x01\x0c\x85\x01\x1e\n\x11\n\t\x04\x03\x03\x01\x03\0\x02\x01\x05\x12\x04\
-    \x86\x01\x0c\x11\n\x11\n\t\x04\x03\x03\x01\x03\0\x02\x01\x01\x12\x04\x86\
-    \x01\x12\x20\n\x11\n\t\x04\x03\x03\x01\x03\0\x02\x01\x03\x12\x04\x86\x01\
-    #$\n\x10\n\x08\x04\x03\x03\x01\x03\0\x02\x02\x12\x04\x87\x01\x0c(\n\x13\
-    \n\t\x04\x03\x03\x01\x03\0\x02\x02\x04\x12\x06\x87\x01\x0c\x86\x01%\n\
-    \x11\n\t\x04\x03\x03\x01\x03\0\x02\x02\x05\x12\x04\x87\x01\x0c\x11\n\x11\
-    \n\t\x04\x03\x03\x01\x03\0\x02\x02\x01\x12\x04\x87\x01\x12#\n\x11\n\t\
-    \x04\x03\x03\x01\x03\0\x02\x02\x03\x12\x04\x87\x01&'\n\x10\n\x08\x04\x03\
-    \x03\x01\x03\0\x02\x03\x12\x04\x88\x01\x0c,\n\x13\n\t\x04\x03\x03\x01\
-    \x03\0\x02\x03\x04\x12\x06\x88\x01\x0c\x87\x01(\n\x11\n\t\x04\x03\x03\
-    \x01\x03\0\x02\x03\x05\x12\x04\x88\x01\x0c\x11\n\x11\n\t\x04\x03\x03\x01\
-    \x03\0\x02\x03\x01\x12\x04\x88\x01\x12'\n\x11\n\t\x04\x03\x03\x01\x03\0\
+    \x02\x20\x01(\x05R\x04port\"\xad\x01\n\x0cCapabilities\x12\x18\n\x07vers\
+    ion\x18\x01\x20\x01(\tR\x07version\x12F\n\x08features\x18\x02\x20\x03(\
+    \x0b2*.agones.dev.sdk.Capabilities.FeaturesEntryR\x08features\x1a;\n\rFe\
+    aturesEntry\x12\x10\n\x03key\x18\x01\x20\x01(\tR\x03key\x12\x14\n\x05val\
+    ue\x18\x02\x20\x01(\tR\x05value:\x028\x012\xe5\x06\n\x03SDK\x12H\n\x05Re\
+    ady\x12\x15.agones.dev.sdk.Empty\x1a\x15.agones.dev.sdk.Empty\"\x11\x82\
+    \xd3\xe4\x93\x02\x0b\"\x06/ready:\x01*\x12N\n\x08Allocate\x12\x15.agones\
+    .dev.sdk.Empty\x1a\x15.agones.dev.sdk.Empty\"\x14\x82\xd3\xe4\x93\x02\
+    \x0e\"\t/allocate:\x01*\x12N\n\x08Shutdown\x12\x15.agones.dev.sdk.Empty\
+    \x1a\x15.agones.dev.sdk.Empty\"\x14\x82\xd3\xe4\x93\x02\x0e\"\t/shutdown\
+    :\x01*\x12L\n\x06Health\x12\x15.agones.dev.sdk.Empty\x1a\x15.agones.dev.\
+    sdk.Empty\"\x12\x82\xd3\xe4\x93\x02\x0c\"\x07/health:\x01*(\x01\x12W\n\r\
+    GetGameServer\x12\x15.agones.dev.sdk.Empty\x1a\x1a.agones.dev.sdk.GameSe\
+    rver\"\x13\x82\xd3\xe4\x93\x02\r\x12\x0b/gameserver\x12a\n\x0fWatchGameS\
+    erver\x12\x15.agones.dev.sdk.Empty\x1a\x1a.agones.dev.sdk.GameServer\"\
+    \x19\x82\xd3\xe4\x93\x02\x13\x12\x11/watch/gameserver0\x01\x12W\n\x08Set\
+    Label\x12\x18.agones.dev.sdk.KeyValue\x1a\x15.agones.dev.sdk.Empty\"\x1a\
+    \x82\xd3\xe4\x93\x02\x14\x1a\x0f/metadata/label:\x01*\x12a\n\rSetAnnotat\
+    ion\x12\x18.agones.dev.sdk.KeyValue\x1a\x15.agones.dev.sdk.Empty\"\x1f\
+    \x82\xd3\xe4\x93\x02\x19\x1a\x14/metadata/annotation:\x01*\x12O\n\x07Res\
+    erve\x12\x18.agones.dev.sdk.Duration\x1a\x15.agones.dev.sdk.Empty\"\x13\
+    \x82\xd3\xe4\x93\x02\r\"\x08/reserve:\x01*\x12]\n\x0fGetCapabilities\x12\
+    \x15.agones.dev.sdk.Empty\x1a\x1c.agones.dev.sdk.Capabilities\"\x15\x82\
+    \xd3\xe4\x93\x02\x0f\x12\r/capabilitiesB\x05Z\x03sdkJ\xfe$\n\x07\x12\x05\
+    \x0e\x00\x96\x01\x01\n\xd1\x04\n\x01\x0c\x12\x03\x0e\x00\x122\xc6\x04\
+    \x20Copyright\x202017\x20Google\x20LLC\x20All\x20Rights\x20Reserved.\n\n\
+    \x20Licensed\x20under\x20the\x20Apache\x20License,\x20Version\x202.0\x20\
+    (the\x20\"License\");\n\x20you\x20may\x20not\x20use\x20this\x20file\x20e\
+    xcept\x20in\x20compliance\x20with\x20the\x20License.\n\x20You\x20may\x20\
+    obtain\x20a\x20copy\x20of\x20the\x20License\x20at\n\n\x20\x20\x20\x20\
+    \x20http://www.apache.org/licenses/LICENSE-2.0\n\n\x20Unless\x20required\
+    \x20by\x20applicable\x20law\x20or\x20agreed\x20to\x20in\x20writing,\x20s\
+    oftware\n\x20distributed\x20under\x20the\x20License\x20is\x20distributed\
+    \x20on\x20an\x20\"AS\x20IS\"\x20BASIS,\n\x20WITHOUT\x20WARRANTIES\x20OR\
+    \x20CONDITIONS\x20OF\x20ANY\x20KIND,\x20either\x20express\x20or\x20impli\
+    ed.\n\x20See\x20the\x20License\x20for\x20the\x20specific\x20language\x20\
+    governing\x20permissions\x20and\n\x20limitations\x20under\x20the\x20Lice\
+    nse.\n\n\x08\n\x01\x02\x12\x03\x10\x08\x16\n\x08\n\x01\x08\x12\x03\x11\
+    \x00\x1a\n\t\n\x02\x08\x0b\x12\x03\x11\x00\x1a\n\t\n\x02\x03\x00\x12\x03\
+    \x13\x07%\nM\n\x02\x06\x00\x12\x04\x16\x00Y\x01\x1aA\x20SDK\x20service\
+    \x20to\x20be\x20used\x20in\x20the\x20GameServer\x20SDK\x20to\x20the\x20P\
+    od\x20Sidecar\n\n\n\n\x03\x06\x00\x01\x12\x03\x16\x08\x0b\n1\n\x04\x06\
+    \x00\x02\x00\x12\x04\x18\x04\x1d\x05\x1a#\x20Call\x20when\x20the\x20Game\
+    Server\x20is\x20ready\n\n\x0c\n\x05\x06\x00\x02\x00\x01\x12\x03\x18\x08\
+    \r\n\x0c\n\x05\x06\x00\x02\x00\x02\x12\x03\x18\x0f\x14\n\x0c\n\x05\x06\
+    \x00\x02\x00\x03\x12\x03\x18\x1f$\n\r\n\x05\x06\x00\x02\x00\x04\x12\x04\
+    \x19\x08\x1c\n\n\x11\n\t\x06\x00\x02\x00\x04\xb0\xca\xbc\"\x12\x04\x19\
+    \x08\x1c\n\n6\n\x04\x06\x00\x02\x01\x12\x04\x20\x04%\x05\x1a(\x20Call\
+    \x20to\x20self\x20Allocation\x20the\x20GameServer\n\n\x0c\n\x05\x06\x00\
+    \x02\x01\x01\x12\x03\x20\x08\x10\n\x0c\n\x05\x06\x00\x02\x01\x02\x12\x03\
+    \x20\x11\x16\n\x0c\n\x05\x06\x00\x02\x01\x03\x12\x03\x20!&\n\r\n\x05\x06\
+    \x00\x02\x01\x04\x12\x04!\x08$\n\n\x11\n\t\x06\x00\x02\x01\x04\xb0\xca\
+    \xbc\"\x12\x04!\x08$\n\n9\n\x04\x06\x00\x02\x02\x12\x04(\x04-\x05\x1a+\
+    \x20Call\x20when\x20the\x20GameServer\x20is\x20shutting\x20down\n\n\x0c\
+    \n\x05\x06\x00\x02\x02\x01\x12\x03(\x08\x10\n\x0c\n\x05\x06\x00\x02\x02\
+    \x02\x12\x03(\x12\x17\n\x0c\n\x05\x06\x00\x02\x02\x03\x12\x03(\"\'\n\r\n\
+    \x05\x06\x00\x02\x02\x04\x12\x04)\x08,\n\n\x11\n\t\x06\x00\x02\x02\x04\
+    \xb0\xca\xbc\"\x12\x04)\x08,\n\nW\n\x04\x06\x00\x02\x03\x12\x04/\x044\
+    \x05\x1aI\x20Send\x20a\x20Empty\x20every\x20d\x20Duration\x20to\x20decla\
+    re\x20that\x20this\x20GameSever\x20is\x20healthy\n\n\x0c\n\x05\x06\x00\
+    \x02\x03\x01\x12\x03/\x08\x0e\n\x0c\n\x05\x06\x00\x02\x03\x05\x12\x03/\
+    \x10\x16\n\x0c\n\x05\x06\x00\x02\x03\x02\x12\x03/\x17\x1c\n\x0c\n\x05\
+    \x06\x00\x02\x03\x03\x12\x03/\',\n\r\n\x05\x06\x00\x02\x03\x04\x12\x040\
+    \x083\x12\n\x11\n\t\x06\x00\x02\x03\x04\xb0\xca\xbc\"\x12\x040\x083\x12\
+    \n4\n\x04\x06\x00\x02\x04\x12\x046\x04:\x05\x1a&\x20Retrieve\x20the\x20c\
+    urrent\x20GameServer\x20data\n\n\x0c\n\x05\x06\x00\x02\x04\x01\x12\x036\
+    \x08\x15\n\x0c\n\x05\x06\x00\x02\x04\x02\x12\x036\x17\x1c\n\x0c\n\x05\
+    \x06\x00\x02\x04\x03\x12\x036\'1\n\r\n\x05\x06\x00\x02\x04\x04\x12\x047\
+    \x089\n\n\x11\n\t\x06\x00\x02\x04\x04\xb0\xca\xbc\"\x12\x047\x089\n\nJ\n\
+    \x04\x06\x00\x02\x05\x12\x04<\x04@\x05\x1a<\x20Send\x20GameServer\x20det\
+    ails\x20whenever\x20the\x20GameServer\x20is\x20updated\n\n\x0c\n\x05\x06\
+    \x00\x02\x05\x01\x12\x03<\x08\x17\n\x0c\n\x05\x06\x00\x02\x05\x02\x12\
+    \x03<\x19\x1e\n\x0c\n\x05\x06\x00\x02\x05\x06\x12\x03<)/\n\x0c\n\x05\x06\
+    \x00\x02\x05\x03\x12\x03<0:\n\r\n\x05\x06\x00\x02\x05\x04\x12\x04=\x08?\
+    \n\n\x11\n\t\x06\x00\x02\x05\x04\xb0\xca\xbc\"\x12\x04=\x08?\n\n@\n\x04\
+    \x06\x00\x02\x06\x12\x04C\x04H\x05\x1a2\x20Apply\x20a\x20Label\x20to\x20\
+    the\x20backing\x20GameServer\x20metadata\n\n\x0c\n\x05\x06\x00\x02\x06\
+    \x01\x12\x03C\x08\x10\n\x0c\n\x05\x06\x00\x02\x06\x02\x12\x03C\x11\x19\n\
+    \x0c\n\x05\x06\x00\x02\x06\x03\x12\x03C$)\n\r\n\x05\x06\x00\x02\x06\x04\
+    \x12\x04D\x08G\x12\n\x11\n\t\x06\x00\x02\x06\x04\xb0\xca\xbc\"\x12\x04D\
+    \x08G\x12\nE\n\x04\x06\x00\x02\x07\x12\x04K\x04P\x05\x1a7\x20Apply\x20a\
+    \x20Annotation\x20to\x20the\x20backing\x20GameServer\x20metadata\n\n\x0c\
+    \n\x05\x06\x00\x02\x07\x01\x12\x03K\x08\x15\n\x0c\n\x05\x06\x00\x02\x07\
+    \x02\x12\x03K\x16\x1e\n\x0c\n\x05\x06\x00\x02\x07\x03\x12\x03K).\n\r\n\
+    \x05\x06\x00\x02\x07\x04\x12\x04L\x08O\x12\n\x11\n\t\x06\x00\x02\x07\x04\
+    \xb0\xca\xbc\"\x12\x04L\x08O\x12\nG\n\x04\x06\x00\x02\x08\x12\x04S\x04X\
+    \x05\x1a9\x20Marks\x20the\x20GameServer\x20as\x20the\x20Reserved\x20stat\
+    e\x20for\x20Duration\n\n\x0c\n\x05\x06\x00\x02\x08\x01\x12\x03S\x08\x0f\
+    \n\x0c\n\x05\x06\x00\x02\x08\x02\x12\x03S\x10\x18\n\x0c\n\x05\x06\x00\
+    \x02\x08\x03\x12\x03S#(\n\r\n\x05\x06\x00\x02\x08\x04\x12\x04T\x08W\n\n\
+    \x11\n\t\x06\x00\x02\x08\x04\xb0\xca\xbc\"\x12\x04T\x08W\n\n\x18\n\x02\
+    \x04\x00\x12\x04\\\x00]\x01\x1a\x0c\x20I\x20am\x20Empty\n\n\n\n\x03\x04\
+    \x00\x01\x12\x03\\\x08\r\n\x1e\n\x02\x04\x01\x12\x04`\x00c\x01\x1a\x12\
+    \x20Key,\x20Value\x20entry\n\n\n\n\x03\x04\x01\x01\x12\x03`\x08\x10\n\
+    \x0b\n\x04\x04\x01\x02\x00\x12\x03a\x04\x13\n\r\n\x05\x04\x01\x02\x00\
+    \x04\x12\x04a\x04`\x12\n\x0c\n\x05\x04\x01\x02\x00\x05\x12\x03a\x04\n\n\
+    \x0c\n\x05\x04\x01\x02\x00\x01\x12\x03a\x0b\x0e\n\x0c\n\x05\x04\x01\x02\
+    \x00\x03\x12\x03a\x11\x12\n\x0b\n\x04\x04\x01\x02\x01\x12\x03b\x04\x15\n\
+    \r\n\x05\x04\x01\x02\x01\x04\x12\x04b\x04a\x13\n\x0c\n\x05\x04\x01\x02\
+    \x01\x05\x12\x03b\x04\n\n\x0c\n\x05\x04\x01\x02\x01\x01\x12\x03b\x0b\x10\
+    \n\x0c\n\x05\x04\x01\x02\x01\x03\x12\x03b\x13\x14\n\'\n\x02\x04\x02\x12\
+    \x04f\x00h\x01\x1a\x1b\x20time\x20duration,\x20in\x20seconds\n\n\n\n\x03\
+    \x04\x02\x01\x12\x03f\x08\x10\n\x0b\n\x04\x04\x02\x02\x00\x12\x03g\x04\
+    \x16\n\r\n\x05\x04\x02\x02\x00\x04\x12\x04g\x04f\x12\n\x0c\n\x05\x04\x02\
+    \x02\x00\x05\x12\x03g\x04\t\n\x0c\n\x05\x04\x02\x02\x00\x01\x12\x03g\n\
+    \x11\n\x0c\n\x05\x04\x02\x02\x00\x03\x12\x03g\x14\x15\n\xa3\x01\n\x02\
+    \x04\x03\x12\x05m\x00\x96\x01\x01\x1a\x95\x01\x20A\x20GameServer\x20Cust\
+    om\x20Resource\x20Definition\x20object\n\x20We\x20will\x20only\x20export\
+    \x20those\x20resources\x20that\x20make\x20the\x20most\n\x20sense.\x20Can\
+    \x20always\x20expand\x20to\x20more\x20as\x20needed.\n\n\n\n\x03\x04\x03\
+    \x01\x12\x03m\x08\x12\n\x0b\n\x04\x04\x03\x02\x00\x12\x03n\x04\x1f\n\r\n\
+    \x05\x04\x03\x02\x00\x04\x12\x04n\x04m\x14\n\x0c\n\x05\x04\x03\x02\x00\
+    \x06\x12\x03n\x04\x0e\n\x0c\n\x05\x04\x03\x02\x00\x01\x12\x03n\x0f\x1a\n\
+    \x0c\n\x05\x04\x03\x02\x00\x03\x12\x03n\x1d\x1e\n\x0b\n\x04\x04\x03\x02\
+    \x01\x12\x03o\x04\x12\n\r\n\x05\x04\x03\x02\x01\x04\x12\x04o\x04n\x1f\n\
+    \x0c\n\x05\x04\x03\x02\x01\x06\x12\x03o\x04\x08\n\x0c\n\x05\x04\x03\x02\
+    \x01\x01\x12\x03o\t\r\n\x0c\n\x05\x04\x03\x02\x01\x03\x12\x03o\x10\x11\n\
+    \x0b\n\x04\x04\x03\x02\x02\x12\x03p\x04\x16\n\r\n\x05\x04\x03\x02\x02\
+    \x04\x12\x04p\x04o\x12\n\x0c\n\x05\x04\x03\x02\x02\x06\x12\x03p\x04\n\n\
+    \x0c\n\x05\x04\x03\x02\x02\x01\x12\x03p\x0b\x11\n\x0c\n\x05\x04\x03\x02\
+    \x02\x03\x12\x03p\x14\x15\n=\n\x04\x04\x03\x03\x00\x12\x04s\x04\x7f\x05\
+    \x1a/\x20representation\x20of\x20the\x20K8s\x20ObjectMeta\x20resource\n\
+    \n\x0c\n\x05\x04\x03\x03\x00\x01\x12\x03s\x0c\x16\n\r\n\x06\x04\x03\x03\
+    \x00\x02\x00\x12\x03t\x08\x18\n\x0f\n\x07\x04\x03\x03\x00\x02\x00\x04\
+    \x12\x04t\x08s\x18\n\x0e\n\x07\x04\x03\x03\x00\x02\x00\x05\x12\x03t\x08\
+    \x0e\n\x0e\n\x07\x04\x03\x03\x00\x02\x00\x01\x12\x03t\x0f\x13\n\x0e\n\
+    \x07\x04\x03\x03\x00\x02\x00\x03\x12\x03t\x16\x17\n\r\n\x06\x04\x03\x03\
+    \x00\x02\x01\x12\x03u\x08\x1d\n\x0f\n\x07\x04\x03\x03\x00\x02\x01\x04\
+    \x12\x04u\x08t\x18\n\x0e\n\x07\x04\x03\x03\x00\x02\x01\x05\x12\x03u\x08\
+    \x0e\n\x0e\n\x07\x04\x03\x03\x00\x02\x01\x01\x12\x03u\x0f\x18\n\x0e\n\
+    \x07\x04\x03\x03\x00\x02\x01\x03\x12\x03u\x1b\x1c\n\r\n\x06\x04\x03\x03\
+    \x00\x02\x02\x12\x03v\x08\x17\n\x0f\n\x07\x04\x03\x03\x00\x02\x02\x04\
+    \x12\x04v\x08u\x1d\n\x0e\n\x07\x04\x03\x03\x00\x02\x02\x05\x12\x03v\x08\
+    \x0e\n\x0e\n\x07\x04\x03\x03\x00\x02\x02\x01\x12\x03v\x0f\x12\n\x0e\n\
+    \x07\x04\x03\x03\x00\x02\x02\x03\x12\x03v\x15\x16\n\r\n\x06\x04\x03\x03\
+    \x00\x02\x03\x12\x03w\x08$\n\x0f\n\x07\x04\x03\x03\x00\x02\x03\x04\x12\
+    \x04w\x08v\x17\n\x0e\n\x07\x04\x03\x03\x00\x02\x03\x05\x12\x03w\x08\x0e\
+    \n\x0e\n\x07\x04\x03\x03\x00\x02\x03\x01\x12\x03w\x0f\x1f\n\x0e\n\x07\
+    \x04\x03\x03\x00\x02\x03\x03\x12\x03w\"#\n\r\n\x06\x04\x03\x03\x00\x02\
+    \x04\x12\x03x\x08\x1d\n\x0f\n\x07\x04\x03\x03\x00\x02\x04\x04\x12\x04x\
+    \x08w$\n\x0e\n\x07\x04\x03\x03\x00\x02\x04\x05\x12\x03x\x08\r\n\x0e\n\
+    \x07\x04\x03\x03\x00\x02\x04\x01\x12\x03x\x0e\x18\n\x0e\n\x07\x04\x03\
+    \x03\x00\x02\x04\x03\x12\x03x\x1b\x1c\n<\n\x06\x04\x03\x03\x00\x02\x05\
+    \x12\x03z\x08%\x1a-\x20timestamp\x20is\x20in\x20Epoch\x20format,\x20unit\
+    :\x20seconds\n\n\x0f\n\x07\x04\x03\x03\x00\x02\x05\x04\x12\x04z\x08x\x1d\
+    \n\x0e\n\x07\x04\x03\x03\x00\x02\x05\x05\x12\x03z\x08\r\n\x0e\n\x07\x04\
+    \x03\x03\x00\x02\x05\x01\x12\x03z\x0e\x20\n\x0e\n\x07\x04\x03\x03\x00\
+    \x02\x05\x03\x12\x03z#$\nK\n\x06\x04\x03\x03\x00\x02\x06\x12\x03|\x08%\
+    \x1a<\x20optional\x20deletion\x20timestamp\x20in\x20Epoch\x20format,\x20\
+    unit:\x20seconds\n\n\x0f\n\x07\x04\x03\x03\x00\x02\x06\x04\x12\x04|\x08z\
+    %\n\x0e\n\x07\x04\x03\x03\x00\x02\x06\x05\x12\x03|\x08\r\n\x0e\n\x07\x04\
+    \x03\x03\x00\x02\x06\x01\x12\x03|\x0e\x20\n\x0e\n\x07\x04\x03\x03\x00\
+    \x02\x06\x03\x12\x03|#$\n\r\n\x06\x04\x03\x03\x00\x02\x07\x12\x03}\x08,\
+    \n\x0f\n\x07\x04\x03\x03\x00\x02\x07\x04\x12\x04}\x08|%\n\x0e\n\x07\x04\
+    \x03\x03\x00\x02\x07\x06\x12\x03}\x08\x1b\n\x0e\n\x07\x04\x03\x03\x00\
+    \x02\x07\x01\x12\x03}\x1c\'\n\x0e\n\x07\x04\x03\x03\x00\x02\x07\x03\x12\
+    \x03}*+\n\r\n\x06\x04\x03\x03\x00\x02\x08\x12\x03~\x08\'\n\x0f\n\x07\x04\
+    \x03\x03\x00\x02\x08\x04\x12\x04~\x08},\n\x0e\n\x07\x04\x03\x03\x00\x02\
+    \x08\x06\x12\x03~\x08\x1b\n\x0e\n\x07\x04\x03\x03\x00\x02\x08\x01\x12\
+    \x03~\x1c\"\n\x0e\n\x07\x04\x03\x03\x00\x02\x08\x03\x12\x03~%&\n\x0e\n\
+    \x04\x04\x03\x03\x01\x12\x06\x81\x01\x04\x8a\x01\x05\n\r\n\x05\x04\x03\
+    \x03\x01\x01\x12\x04\x81\x01\x0c\x10\n\x0e\n\x06\x04\x03\x03\x01\x02\x00\
+    \x12\x04\x82\x01\x08\x1a\n\x11\n\x07\x04\x03\x03\x01\x02\x00\x04\x12\x06\
+    \x82\x01\x08\x81\x01\x12\n\x0f\n\x07\x04\x03\x03\x01\x02\x00\x06\x12\x04\
+    \x82\x01\x08\x0e\n\x0f\n\x07\x04\x03\x03\x01\x02\x00\x01\x12\x04\x82\x01\
+    \x0f\x15\n\x0f\n\x07\x04\x03\x03\x01\x02\x00\x03\x12\x04\x82\x01\x18\x19\
+    \n\x10\n\x06\x04\x03\x03\x01\x03\x00\x12\x06\x84\x01\x08\x89\x01\t\n\x0f\
+    \n\x07\x04\x03\x03\x01\x03\x00\x01\x12\x04\x84\x01\x10\x16\n\x10\n\x08\
+    \x04\x03\x03\x01\x03\x00\x02\x00\x12\x04\x85\x01\x0c\x1e\n\x13\n\t\x04\
+    \x03\x03\x01\x03\x00\x02\x00\x04\x12\x06\x85\x01\x0c\x84\x01\x18\n\x11\n\
+    \t\x04\x03\x03\x01\x03\x00\x02\x00\x05\x12\x04\x85\x01\x0c\x10\n\x11\n\t\
+    \x04\x03\x03\x01\x03\x00\x02\x00\x01\x12\x04\x85\x01\x11\x19\n\x11\n\t\
+    \x04\x03\x03\x01\x03\x00\x02\x00\x03\x12\x04\x85\x01\x1c\x1d\n\x10\n\x08\
+    \x04\x03\x03\x01\x03\x00\x02\x01\x12\x04\x86\x01\x0c%\n\x13\n\t\x04\x03\
+    \x03\x01\x03\x00\x02\x01\x04\x12\x06\x86\x01\x0c\x85\x01\x1e\n\x11\n\t\
+    \x04\x03\x03\x01\x03\x00\x02\x01\x05\x12\x04\x86\x01\x0c\x11\n\x11\n\t\
+    \x04\x03\x03\x01\x03\x00\x02\x01\x01\x12\x04\x86\x01\x12\x20\n\x11\n\t\
+    \x04\x03\x03\x01\x03\x00\x02\x01\x03\x12\x04\x86\x01#$\n\x10\n\x08\x04\
+    \x03\x03\x01\x03\x00\x02\x02\x12\x04\x87\x01\x0c(\n\x13\n\t\x04\x03\x03\
+    \x01\x03\x00\x02\x02\x04\x12\x06\x87\x01\x0c\x86\x01%\n\x11\n\t\x04\x03\
+    \x03\x01\x03\x00\x02\x02\x05\x12\x04\x87\x01\x0c\x11\n\x11\n\t\x04\x03\
+    \x03\x01\x03\x00\x02\x02\x01\x12\x04\x87\x01\x12#\n\x11\n\t\x04\x03\x03\
+    \x01\x03\x00\x02\x02\x03\x12\x04\x87\x01&\'\n\x10\n\x08\x04\x03\x03\x01\
+    \x03\x00\x02\x03\x12\x04\x88\x01\x0c,\n\x13\n\t\x04\x03\x03\x01\x03\x00\
+    \x02\x03\x04\x12\x06\x88\x01\x0c\x87\x01(\n\x11\n\t\x04\x03\x03\x01\x03\
+    \x00\x02\x03\x05\x12\x04\x88\x01\x0c\x11\n\x11\n\t\x04\x03\x03\x01\x03\
+    \x00\x02\x03\x01\x12\x04\x88\x01\x12\'\n\x11\n\t\x04\x03\x03\x01\x03\x00\
     \x02\x03\x03\x12\x04\x88\x01*+\n\x0e\n\x04\x04\x03\x03\x02\x12\x06\x8c\
     \x01\x04\x95\x01\x05\n\r\n\x05\x04\x03\x03\x02\x01\x12\x04\x8c\x01\x0c\
-    \x12\n\x10\n\x06\x04\x03\x03\x02\x03\0\x12\x06\x8d\x01\x08\x90\x01\t\n\
-    \x0f\n\x07\x04\x03\x03\x02\x03\0\x01\x12\x04\x8d\x01\x10\x14\n\x10\n\x08\
-    \x04\x03\x03\x02\x03\0\x02\0\x12\x04\x8e\x01\x0c\x1c\n\x13\n\t\x04\x03\
-    \x03\x02\x03\0\x02\0\x04\x12\x06\x8e\x01\x0c\x8d\x01\x16\n\x11\n\t\x04\
-    \x03\x03\x02\x03\0\x02\0\x05\x12\x04\x8e\x01\x0c\x12\n\x11\n\t\x04\x03\
-    \x03\x02\x03\0\x02\0\x01\x12\x04\x8e\x01\x13\x17\n\x11\n\t\x04\x03\x03\
-    \x02\x03\0\x02\0\x03\x12\x04\x8e\x01\x1a\x1b\n\x10\n\x08\x04\x03\x03\x02\
-    \x03\0\x02\x01\x12\x04\x8f\x01\x0c\x1b\n\x13\n\t\x04\x03\x03\x02\x03\0\
-    \x02\x01\x04\x12\x06\x8f\x01\x0c\x8e\x01\x1c\n\x11\n\t\x04\x03\x03\x02\
-    \x03\0\x02\x01\x05\x12\x04\x8f\x01\x0c\x11\n\x11\n\t\x04\x03\x03\x02\x03\
-    \0\x02\x01\x01\x12\x04\x8f\x01\x12\x16\n\x11\n\t\x04\x03\x03\x02\x03\0\
-    \x02\x01\x03\x12\x04\x8f\x01\x19\x1a\n\x0e\n\x06\x04\x03\x03\x02\x02\0\
-    \x12\x04\x92\x01\x08\x19\n\x11\n\x07\x04\x03\x03\x02\x02\0\x04\x12\x06\
-    \x92\x01\x08\x90\x01\t\n\x0f\n\x07\x04\x03\x03\x02\x02\0\x05\x12\x04\x92\
-    \x01\x08\x0e\n\x0f\n\x07\x04\x03\x03\x02\x02\0\x01\x12\x04\x92\x01\x0f\
-    \x14\n\x0f\n\x07\x04\x03\x03\x02\x02\0\x03\x12\x04\x92\x01\x17\x18\n\x0e\
-    \n\x06\x04\x03\x03\x02\x02\x01\x12\x04\x93\x01\x08\x1b\n\x11\n\x07\x04\
-    \x03\x03\x02\x02\x01\x04\x12\x06\x93\x01\x08\x92\x01\x19\n\x0f\n\x07\x04\
-    \x03\x03\x02\x02\x01\x05\x12\x04\x93\x01\x08\x0e\n\x0f\n\x07\x04\x03\x03\
-    \x02\x02\x01\x01\x12\x04\x93\x01\x0f\x16\n\x0f\n\x07\x04\x03\x03\x02\x02\
-    \x01\x03\x12\x04\x93\x01\x19\x1a\n\x0e\n\x06\x04\x03\x03\x02\x02\x02\x12\
-    \x04\x94\x01\x08\x20\n\x0f\n\x07\x04\x03\x03\x02\x02\x02\x04\x12\x04\x94\
-    \x01\x08\x10\n\x0f\n\x07\x04\x03\x03\x02\x02\x02\x06\x12\x04\x94\x01\x11\
-    \x15\n\x0f\n\x07\x04\x03\x03\x02\x02\x02\x01\x12\x04\x94\x01\x16\x1b\n\
-    \x0f\n\x07\x04\x03\x03\x02\x02\x02\x03\x12\x04\x94\x01\x1e\x1fb\x06proto\
-    3\
+    \x12\n\x10\n\x06\x04\x03\x03\x02\x03\x00\x12\x06\x8d\x01\x08\x90\x01\t\n\
+    \x0f\n\x07\x04\x03\x03\x02\x03\x00\x01\x12\x04\x8d\x01\x10\x14\n\x10\n\
+    \x08\x04\x03\x03\x02\x03\x00\x02\x00\x12\x04\x8e\x01\x0c\x1c\n\x13\n\t\
+    \x04\x03\x03\x02\x03\x00\x02\x00\x04\x12\x06\x8e\x01\x0c\x8d\x01\x16\n\
+    \x11\n\t\x04\x03\x03\x02\x03\x00\x02\x00\x05\x12\x04\x8e\x01\x0c\x12\n\
+    \x11\n\t\x04\x03\x03\x02\x03\x00\x02\x00\x01\x12\x04\x8e\x01\x13\x17\n\
+    \x11\n\t\x04\x03\x03\x02\x03\x00\x02\x00\x03\x12\x04\x8e\x01\x1a\x1b\n\
+    \x10\n\x08\x04\x03\x03\x02\x03\x00\x02\x01\x12\x04\x8f\x01\x0c\x1b\n\x13\
+    \n\t\x04\x03\x03\x02\x03\x00\x02\x01\x04\x12\x06\x8f\x01\x0c\x8e\x01\x1c\
+    \n\x11\n\t\x04\x03\x03\x02\x03\x00\x02\x01\x05\x12\x04\x8f\x01\x0c\x11\n\
+    \x11\n\t\x04\x03\x03\x02\x03\x00\x02\x01\x01\x12\x04\x8f\x01\x12\x16\n\
+    \x11\n\t\x04\x03\x03\x02\x03\x00\x02\x01\x03\x12\x04\x8f\x01\x19\x1a\n\
+    \x0e\n\x06\x04\x03\x03\x02\x02\x00\x12\x04\x92\x01\x08\x19\n\x11\n\x07\
+    \x04\x03\x03\x02\x02\x00\x04\x12\x06\x92\x01\x08\x90\x01\t\n\x0f\n\x07\
+    \x04\x03\x03\x02\x02\x00\x05\x12\x04\x92\x01\x08\x0e\n\x0f\n\x07\x04\x03\
+    \x03\x02\x02\x00\x01\x12\x04\x92\x01\x0f\x14\n\x0f\n\x07\x04\x03\x03\x02\
+    \x02\x00\x03\x12\x04\x92\x01\x17\x18\n\x0e\n\x06\x04\x03\x03\x02\x02\x01\
+    \x12\x04\x93\x01\x08\x1b\n\x11\n\x07\x04\x03\x03\x02\x02\x01\x04\x12\x06\
+    \x93\x01\x08\x92\x01\x19\n\x0f\n\x07\x04\x03\x03\x02\x02\x01\x05\x12\x04\
+    \x93\x01\x08\x0e\n\x0f\n\x07\x04\x03\x03\x02\x02\x01\x01\x12\x04\x93\x01\
+    \x0f\x16\n\x0f\n\x07\x04\x03\x03\x02\x02\x01\x03\x12\x04\x93\x01\x19\x1a\
+    \n\x0e\n\x06\x04\x03\x03\x02\x02\x02\x12\x04\x94\x01\x08\x20\n\x0f\n\x07\
+    \x04\x03\x03\x02\x02\x02\x04\x12\x04\x94\x01\x08\x10\n\x0f\n\x07\x04\x03\
+    \x03\x02\x02\x02\x06\x12\x04\x94\x01\x11\x15\n\x0f\n\x07\x04\x03\x03\x02\
+    \x02\x02\x01\x12\x04\x94\x01\x16\x1b\n\x0f\n\x07\x04\x03\x03\x02\x02\x02\
+    \x03\x12\x04\x94\x01\x1e\x1fb\x06proto3\
 ";
 
 static mut file_descriptor_proto_lazy: ::protobuf::lazy::Lazy<::protobuf::descriptor::FileDescriptorProto> = ::protobuf::lazy::Lazy {
@@ -96,6 +96,13 @@ const METHOD_SDK_RESERVE: ::grpcio::Method<super::sdk::Duration, super::sdk::Emp
     resp_mar: ::grpcio::Marshaller { ser: ::grpcio::pb_ser, de: ::grpcio::pb_de },
 };
 
+const METHOD_SDK_GET_CAPABILITIES: ::grpcio::Method<super::sdk::Empty, super::sdk::Capabilities> = ::grpcio::Method {
+    ty: ::grpcio::MethodType::Unary,
+    name: "/agones.dev.sdk.SDK/GetCapabilities",
+    req_mar: ::grpcio::Marshaller { ser: ::grpcio::pb_ser, de: ::grpcio::pb_de },
+    resp_mar: ::grpcio::Marshaller { ser: ::grpcio::pb_ser, de: ::grpcio::pb_de },
+};
+
 pub struct SdkClient {
     client: ::grpcio::Client,
 }
@@ -234,6 +241,22 @@ impl SdkClient {
     pub fn reserve_async(&self, req: &super::sdk::Duration) -> ::grpcio::Result<::grpcio::ClientUnaryReceiver<super::sdk::Empty>> {
         self.reserve_async_opt(req, ::grpcio::CallOption::default())
     }
+
+    pub fn get_capabilities_opt(&self, req: &super::sdk::Empty, opt: ::grpcio::CallOption) -> ::grpcio::Result<super::sdk::Capabilities> {
+        self.client.unary_call(&METHOD_SDK_GET_CAPABILITIES, req, opt)
+    }
+
+    pub fn get_capabilities(&self, req: &super::sdk::Empty) -> ::grpcio::Result<super::sdk::Capabilities> {
+        self.get_capabilities_opt(req, ::grpcio::CallOption::default())
+    }
+
+    pub fn get_capabilities_async_opt(&self, req: &super::sdk::Empty, opt: ::grpcio::CallOption) -> ::grpcio::Result<::grpcio::ClientUnaryReceiver<super::sdk::Capabilities>> {
+        self.client.unary_call_async(&METHOD_SDK_GET_CAPABILITIES, req, opt)
+    }
+
+    pub fn get_capabilities_async(&self, req: &super::sdk::Empty) -> ::grpcio::Result<::grpcio::ClientUnaryReceiver<super::sdk::Capabilities>> {
+        self.get_capabilities_async_opt(req, ::grpcio::CallOption::default())
+    }
     pub fn spawn<F>(&self, f: F) where F: ::futures::Future<Item = (), Error = ()> + Send + 'static {
         self.client.spawn(f)
     }
@@ -249,6 +272,7 @@ pub trait Sdk {
     fn set_label(&self, ctx: ::grpcio::RpcContext, req: super::sdk::KeyValue, sink: ::grpcio::UnarySink<super::sdk::Empty>);
     fn set_annotation(&self, ctx: ::grpcio::RpcContext, req: super::sdk::KeyValue, sink: ::grpcio::UnarySink<super::sdk::Empty>);
     fn reserve(&self, ctx: ::grpcio::RpcContext, req: super::sdk::Duration, sink: ::grpcio::UnarySink<super::sdk::Empty>);
+    fn get_capabilities(&self, ctx: ::grpcio::RpcContext, req: super::sdk::Empty, sink: ::grpcio::UnarySink<super::sdk::Capabilities>);
 }
 
 pub fn create_sdk<S: Sdk + Send + Clone + 'static>(s: S) -> ::grpcio::Service {
@@ -289,5 +313,9 @@ pub fn create_sdk<S: Sdk + Send + Clone + 'static>(s: S) -> ::grpcio::Service {
     builder = builder.add_unary_handler(&METHOD_SDK_RESERVE, move |ctx, req, resp| {
         instance.reserve(ctx, req, resp)
     });
+    let instance = s.clone();
+    builder = builder.add_unary_handler(&METHOD_SDK_GET_CAPABILITIES, move |ctx, req, resp| {
+        instance.get_capabilities(ctx, req, resp)
+    });
     builder.build()
 }
@@ -169,7 +169,7 @@ func TestUnhealthyGameServersWithoutFreePorts(t *testing.T) {
 	assert.True(t, len(nodes.Items) > 0)
 
 	gs := defaultGameServer()
-	gs.Spec.Ports[0].HostPort = 7515
+	gs.Spec.Ports[0].HostPort = 9515
 	gs.Spec.Ports[0].PortPolicy = agonesv1.Static
 
 	gameServers := framework.AgonesClient.AgonesV1().GameServers(defaultNs)